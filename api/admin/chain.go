@@ -7,6 +7,9 @@ import (
 	"net/http"
 
 	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/engine/common"
+
+	cjson "github.com/ava-labs/gecko/utils/json"
 )
 
 // GetChainAliasesArgs are the arguments for Admin.GetChainAliases API call
@@ -25,3 +28,88 @@ func (service *Admin) GetChainAliases(r *http.Request, args *GetChainAliasesArgs
 	reply.Aliases = service.chainManager.Aliases(ID)
 	return nil
 }
+
+// GetBootstrapStatusArgs are the arguments for Admin.GetBootstrapStatus API call
+type GetBootstrapStatusArgs struct {
+	Chain string `json:"chain"`
+}
+
+// BootstrapPeer is the number of containers requested from, and received
+// from, a peer while bootstrapping
+type BootstrapPeer struct {
+	NodeID    ids.ShortID  `json:"nodeID"`
+	Requested cjson.Uint64 `json:"requested"`
+	Received  cjson.Uint64 `json:"received"`
+}
+
+// GetBootstrapStatusReply are the results from calling Admin.GetBootstrapStatus
+type GetBootstrapStatusReply struct {
+	Phase    string          `json:"phase"`
+	Resumed  cjson.Uint32    `json:"resumed"`
+	Fetched  cjson.Uint64    `json:"fetched"`
+	Executed cjson.Uint64    `json:"executed"`
+	Queued   cjson.Uint64    `json:"queued"`
+	Pending  cjson.Uint32    `json:"pending"`
+	Elapsed  string          `json:"elapsed"`
+	ETA      string          `json:"eta"`
+	Peers    []BootstrapPeer `json:"peers"`
+}
+
+// GetBootstrapStatus returns the progress of bootstrapping the chain with
+// alias [args.Chain]. The ETA is empty until the fetched containers are being
+// executed.
+func (service *Admin) GetBootstrapStatus(_ *http.Request, args *GetBootstrapStatusArgs, reply *GetBootstrapStatusReply) error {
+	service.log.Debug("Admin: GetBootstrapStatus called with Chain: %s", args.Chain)
+
+	chainID, err := service.chainManager.Lookup(args.Chain)
+	if err != nil {
+		return err
+	}
+	status, err := service.chainManager.BootstrapStatus(chainID)
+	if err != nil {
+		return err
+	}
+
+	reply.Phase = status.Phase
+	reply.Resumed = cjson.Uint32(status.Resumed)
+	reply.Fetched = cjson.Uint64(status.Fetched)
+	reply.Executed = cjson.Uint64(status.Executed)
+	reply.Queued = cjson.Uint64(status.Queued)
+	reply.Pending = cjson.Uint32(status.Pending)
+	reply.Elapsed = status.Elapsed.String()
+	if status.ETA > 0 {
+		reply.ETA = status.ETA.String()
+	}
+	reply.Peers = make([]BootstrapPeer, len(status.Peers))
+	for i, peer := range status.Peers {
+		reply.Peers[i] = BootstrapPeer{
+			NodeID:    peer.ID,
+			Requested: cjson.Uint64(peer.Requested),
+			Received:  cjson.Uint64(peer.Received),
+		}
+	}
+	return nil
+}
+
+// GetConsensusTraceArgs are the arguments for Admin.GetConsensusTrace API call
+type GetConsensusTraceArgs struct {
+	Chain string `json:"chain"`
+}
+
+// GetConsensusTraceReply are the results from calling Admin.GetConsensusTrace
+type GetConsensusTraceReply struct {
+	Events []common.TraceEvent `json:"events"`
+}
+
+// GetConsensusTrace returns the most recent consensus events of the chain with
+// alias [args.Chain], oldest first
+func (service *Admin) GetConsensusTrace(_ *http.Request, args *GetConsensusTraceArgs, reply *GetConsensusTraceReply) error {
+	service.log.Debug("Admin: GetConsensusTrace called with Chain: %s", args.Chain)
+
+	chainID, err := service.chainManager.Lookup(args.Chain)
+	if err != nil {
+		return err
+	}
+	reply.Events, err = service.chainManager.Trace(chainID)
+	return err
+}
@@ -0,0 +1,72 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/database/backup"
+
+	cjson "github.com/ava-labs/gecko/utils/json"
+)
+
+var (
+	errNoFilename  = errors.New("argument 'filename' not provided")
+	errNoSnapshots = errors.New("the node's database doesn't support snapshots, so it can't be backed up while the node runs")
+)
+
+// BackupDatabaseArgs are the arguments for calling BackupDatabase
+type BackupDatabaseArgs struct {
+	Filename string `json:"filename"`
+}
+
+// BackupDatabaseReply are the results from calling BackupDatabase
+type BackupDatabaseReply struct {
+	Keys cjson.Uint64 `json:"keys"`
+}
+
+// BackupDatabase writes a compressed backup of the node's whole database to
+// [args.Filename]. The backup is written from a snapshot of the database, so
+// the node's chains keep running while it's written. The backup can be
+// restored with --db-restore.
+func (service *Admin) BackupDatabase(_ *http.Request, args *BackupDatabaseArgs, reply *BackupDatabaseReply) error {
+	service.log.Info("Admin: BackupDatabase called with %s", args.Filename)
+
+	if args.Filename == "" {
+		return errNoFilename
+	}
+	snapshotter, ok := service.db.(database.Snapshotter)
+	if !ok {
+		return errNoSnapshots
+	}
+
+	// The backup is moved into place once it's complete, so a partial backup
+	// is never mistaken for a complete one
+	tmpFilename := args.Filename + ".tmp"
+	file, err := os.Create(tmpFilename)
+	if err != nil {
+		return err
+	}
+	numKeys, err := backup.Write(file, snapshotter)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFilename)
+		return err
+	}
+	if err := os.Rename(tmpFilename, args.Filename); err != nil {
+		return err
+	}
+
+	service.log.Info("backed up %d keys to %s", numKeys, args.Filename)
+	reply.Keys = cjson.Uint64(numKeys)
+	return nil
+}
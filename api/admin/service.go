@@ -4,19 +4,29 @@
 package admin
 
 import (
+	"errors"
 	"net/http"
+	"sort"
+	"time"
 
 	"github.com/gorilla/rpc/v2"
 
 	"github.com/ava-labs/gecko/api"
 	"github.com/ava-labs/gecko/chains"
+	"github.com/ava-labs/gecko/database"
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/snow/engine/common"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
+	"github.com/ava-labs/gecko/snow/networking/timeout"
 	"github.com/ava-labs/gecko/utils/logging"
 
 	cjson "github.com/ava-labs/gecko/utils/json"
 )
 
+var (
+	errNoNodeID = errors.New("argument 'nodeID' not provided")
+)
+
 // Admin is the API service for node admin management
 type Admin struct {
 	nodeID       ids.ShortID
@@ -25,11 +35,14 @@ type Admin struct {
 	networking   Networking
 	performance  Performance
 	chainManager chains.Manager
+	reputation   *reputation.Manager
+	timeouts     *timeout.Manager
 	httpServer   *api.Server
+	db           database.Database
 }
 
 // NewService returns a new admin API service
-func NewService(nodeID ids.ShortID, networkID uint32, log logging.Logger, chainManager chains.Manager, peers Peerable, httpServer *api.Server) *common.HTTPHandler {
+func NewService(nodeID ids.ShortID, networkID uint32, log logging.Logger, chainManager chains.Manager, peers Peerable, reputation *reputation.Manager, timeouts *timeout.Manager, httpServer *api.Server, db database.Database) *common.HTTPHandler {
 	newServer := rpc.NewServer()
 	codec := cjson.NewCodec()
 	newServer.RegisterCodec(codec, "application/json")
@@ -42,7 +55,10 @@ func NewService(nodeID ids.ShortID, networkID uint32, log logging.Logger, chainM
 		networking: Networking{
 			peers: peers,
 		},
+		reputation: reputation,
+		timeouts:   timeouts,
 		httpServer: httpServer,
+		db:         db,
 	}, "admin")
 	return &common.HTTPHandler{Handler: newServer}
 }
@@ -115,6 +131,140 @@ func (service *Admin) Peers(r *http.Request, args *PeersArgs, reply *PeersReply)
 	return err
 }
 
+// PeerScoresArgs are the arguments for calling PeerScores
+type PeerScoresArgs struct{}
+
+// PeerScore is the reputation of a peer
+type PeerScore struct {
+	NodeID      ids.ShortID `json:"nodeID"`
+	Score       float64     `json:"score"`
+	BannedUntil string      `json:"bannedUntil,omitempty"`
+}
+
+// PeerScoresReply are the results from calling PeerScores
+type PeerScoresReply struct {
+	Peers []PeerScore `json:"peers"`
+}
+
+// PeerScores returns the reputation of the peers that have misbehaved, along
+// with the peers that are currently banned
+func (service *Admin) PeerScores(r *http.Request, args *PeerScoresArgs, reply *PeerScoresReply) error {
+	service.log.Debug("Admin: PeerScores called")
+
+	peers := make(map[[20]byte]*PeerScore)
+	for _, score := range service.reputation.Scores() {
+		peers[score.ID.Key()] = &PeerScore{
+			NodeID: score.ID,
+			Score:  score.Score,
+		}
+	}
+	for _, ban := range service.reputation.Bans() {
+		peer, exists := peers[ban.ID.Key()]
+		if !exists {
+			peer = &PeerScore{
+				NodeID: ban.ID,
+				Score:  service.reputation.Score(ban.ID),
+			}
+			peers[ban.ID.Key()] = peer
+		}
+		peer.BannedUntil = ban.Until.UTC().Format(time.RFC3339)
+	}
+
+	reply.Peers = make([]PeerScore, 0, len(peers))
+	for _, peer := range peers {
+		reply.Peers = append(reply.Peers, *peer)
+	}
+	sort.Slice(reply.Peers, func(i, j int) bool { return reply.Peers[i].Score < reply.Peers[j].Score })
+	return nil
+}
+
+// PeerTimeoutsArgs are the arguments for calling PeerTimeouts
+type PeerTimeoutsArgs struct{}
+
+// PeerTimeout is the latency of a peer, and the timeout of requests sent to it
+type PeerTimeout struct {
+	NodeID   ids.ShortID `json:"nodeID"`
+	Latency  string      `json:"latency"`
+	Timeout  string      `json:"timeout"`
+	Failures int         `json:"failures"`
+}
+
+// PeerTimeoutsReply are the results from calling PeerTimeouts
+type PeerTimeoutsReply struct {
+	Peers []PeerTimeout `json:"peers"`
+}
+
+// PeerTimeouts returns the estimated latency of the peers this node has sent
+// requests to, along with the timeout of the next request sent to each peer
+func (service *Admin) PeerTimeouts(r *http.Request, args *PeerTimeoutsArgs, reply *PeerTimeoutsReply) error {
+	service.log.Debug("Admin: PeerTimeouts called")
+
+	estimates := service.timeouts.Estimates()
+	sort.Slice(estimates, func(i, j int) bool { return estimates[i].Timeout < estimates[j].Timeout })
+
+	reply.Peers = make([]PeerTimeout, len(estimates))
+	for i, estimate := range estimates {
+		reply.Peers[i] = PeerTimeout{
+			NodeID:   estimate.ID,
+			Latency:  estimate.Latency.String(),
+			Timeout:  estimate.Timeout.String(),
+			Failures: estimate.Failures,
+		}
+	}
+	return nil
+}
+
+// BanPeerArgs are the arguments for calling BanPeer
+type BanPeerArgs struct {
+	NodeID ids.ShortID `json:"nodeID"`
+
+	// Duration of the ban, such as "1h30m"
+	Duration string `json:"duration"`
+}
+
+// BanPeerReply are the results from calling BanPeer
+type BanPeerReply struct {
+	Success bool `json:"success"`
+}
+
+// BanPeer bans the peer for the provided duration, and disconnects from it
+func (service *Admin) BanPeer(r *http.Request, args *BanPeerArgs, reply *BanPeerReply) error {
+	service.log.Debug("Admin: BanPeer called with %s for %s", args.NodeID, args.Duration)
+
+	if args.NodeID.IsZero() {
+		return errNoNodeID
+	}
+	duration, err := time.ParseDuration(args.Duration)
+	if err != nil {
+		return err
+	}
+	service.reputation.Ban(args.NodeID, duration)
+	reply.Success = true
+	return nil
+}
+
+// UnbanPeerArgs are the arguments for calling UnbanPeer
+type UnbanPeerArgs struct {
+	NodeID ids.ShortID `json:"nodeID"`
+}
+
+// UnbanPeerReply are the results from calling UnbanPeer
+type UnbanPeerReply struct {
+	Success bool `json:"success"`
+}
+
+// UnbanPeer lifts the ban of the peer and forgives its penalties
+func (service *Admin) UnbanPeer(r *http.Request, args *UnbanPeerArgs, reply *UnbanPeerReply) error {
+	service.log.Debug("Admin: UnbanPeer called with %s", args.NodeID)
+
+	if args.NodeID.IsZero() {
+		return errNoNodeID
+	}
+	service.reputation.Unban(args.NodeID)
+	reply.Success = true
+	return nil
+}
+
 // StartCPUProfilerArgs are the arguments for calling StartCPUProfiler
 type StartCPUProfilerArgs struct {
 	Filename string `json:"filename"`
@@ -54,6 +54,12 @@ func (s *Server) DispatchTLS(certFile, keyFile string) error {
 	return http.ListenAndServeTLS(s.portURL, certFile, keyFile, handler)
 }
 
+// ServeHTTP handles the request with the registered routes, without going
+// through a listener
+func (s *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	s.router.ServeHTTP(writer, request)
+}
+
 // RegisterChain registers the API endpoints associated with this chain That
 // is, add <route, handler> pairs to server so that http calls can be made to
 // the vm
@@ -24,6 +24,12 @@ type Cacher interface {
 	Flush()
 }
 
+// Sizer allows a cache to bound the number of bytes of the values it holds
+type Sizer interface {
+	// Size returns the number of bytes of the value
+	Size() int
+}
+
 // Evictable allows the object to be notified when it is evicted
 type Evictable interface {
 	ID() ids.ID
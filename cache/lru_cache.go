@@ -13,16 +13,30 @@ import (
 type entry struct {
 	Key   ids.ID
 	Value interface{}
+	size  int
 }
 
 // LRU is a key value store with bounded size. If the size is attempted to be
 // exceeded, then an element is removed from the cache before the insertion is
 // done, based on evicting the least recently used value.
+//
+// The size of the cache is bounded by its number of entries, by the number of
+// bytes of its entries, or by both. An entry's size is the size of its key plus
+// the size of its value, if the value implements Sizer.
 type LRU struct {
 	lock      sync.Mutex
 	entryMap  map[[32]byte]*list.Element
 	entryList *list.List
-	Size      int
+	bytes     int // Number of bytes of the entries in the cache
+
+	// Size is the maximum number of entries in the cache. If MaxBytes is set,
+	// the number of entries is only bounded if Size is too. Otherwise, Size
+	// defaults to 1.
+	Size int
+
+	// MaxBytes, if positive, is the maximum number of bytes of the entries in
+	// the cache. An entry larger than MaxBytes isn't cached.
+	MaxBytes int
 }
 
 // Put implements the cache interface
@@ -64,18 +78,20 @@ func (c *LRU) init() {
 	if c.entryList == nil {
 		c.entryList = list.New()
 	}
-	if c.Size <= 0 {
+	if c.Size <= 0 && c.MaxBytes <= 0 {
 		c.Size = 1
 	}
 }
 
 func (c *LRU) resize() {
-	for c.entryList.Len() > c.Size {
+	for (c.Size > 0 && c.entryList.Len() > c.Size) ||
+		(c.MaxBytes > 0 && c.bytes > c.MaxBytes) {
 		e := c.entryList.Front()
 		c.entryList.Remove(e)
 
 		val := e.Value.(*entry)
 		delete(c.entryMap, val.Key.Key())
+		c.bytes -= val.size
 	}
 }
 
@@ -83,19 +99,30 @@ func (c *LRU) put(key ids.ID, value interface{}) {
 	c.init()
 	c.resize()
 
+	size := sizeOf(key, value)
+	if c.MaxBytes > 0 && size > c.MaxBytes {
+		// The entry can't be cached, and any older value of it is stale, so
+		// only that key is evicted
+		c.evict(key)
+		return
+	}
+
 	if e, ok := c.entryMap[key.Key()]; !ok {
-		if c.entryList.Len() >= c.Size {
+		if c.Size > 0 && c.entryList.Len() >= c.Size {
 			e = c.entryList.Front()
 			c.entryList.MoveToBack(e)
 
 			val := e.Value.(*entry)
 			delete(c.entryMap, val.Key.Key())
+			c.bytes -= val.size
 			val.Key = key
 			val.Value = value
+			val.size = size
 		} else {
 			e = c.entryList.PushBack(&entry{
 				Key:   key,
 				Value: value,
+				size:  size,
 			})
 		}
 		c.entryMap[key.Key()] = e
@@ -103,8 +130,14 @@ func (c *LRU) put(key ids.ID, value interface{}) {
 		c.entryList.MoveToBack(e)
 
 		val := e.Value.(*entry)
+		c.bytes -= val.size
 		val.Value = value
+		val.size = size
 	}
+	c.bytes += size
+
+	// Evicts entries until the new entry fits within MaxBytes
+	c.resize()
 }
 
 func (c *LRU) get(key ids.ID) (interface{}, bool) {
@@ -128,6 +161,7 @@ func (c *LRU) evict(key ids.ID) {
 	if e, ok := c.entryMap[keyBytes]; ok {
 		c.entryList.Remove(e)
 		delete(c.entryMap, keyBytes)
+		c.bytes -= e.Value.(*entry).size
 	}
 }
 
@@ -136,4 +170,14 @@ func (c *LRU) flush() {
 
 	c.entryMap = make(map[[32]byte]*list.Element)
 	c.entryList = list.New()
+	c.bytes = 0
+}
+
+// sizeOf returns the number of bytes of an entry
+func sizeOf(key ids.ID, value interface{}) int {
+	size := len(key.Key())
+	if sizer, ok := value.(Sizer); ok {
+		size += sizer.Size()
+	}
+	return size
 }
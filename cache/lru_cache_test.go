@@ -169,3 +169,128 @@ func TestLRUResize(t *testing.T) {
 		t.Fatalf("Retrieved wrong value")
 	}
 }
+
+// sized is a value of the given number of bytes
+type sized int
+
+func (s sized) Size() int { return int(s) }
+
+func TestLRUMaxBytes(t *testing.T) {
+	// Each entry is its 32 byte key plus its value
+	cache := LRU{MaxBytes: 2*32 + 30}
+
+	id1 := ids.NewID([32]byte{1})
+	id2 := ids.NewID([32]byte{2})
+	id3 := ids.NewID([32]byte{3})
+
+	cache.Put(id1, sized(10))
+	cache.Put(id2, sized(20))
+
+	if val, found := cache.Get(id1); !found {
+		t.Fatalf("Failed to retrieve value when one exists")
+	} else if val != sized(10) {
+		t.Fatalf("Retrieved wrong value")
+	} else if val, found := cache.Get(id2); !found {
+		t.Fatalf("Failed to retrieve value when one exists")
+	} else if val != sized(20) {
+		t.Fatalf("Retrieved wrong value")
+	}
+
+	// id1 is the least recently used, so it's evicted to make room for id3
+	cache.Put(id3, sized(1))
+
+	if _, found := cache.Get(id1); found {
+		t.Fatalf("Retrieved value when none exists")
+	} else if _, found := cache.Get(id2); !found {
+		t.Fatalf("Failed to retrieve value when one exists")
+	} else if _, found := cache.Get(id3); !found {
+		t.Fatalf("Failed to retrieve value when one exists")
+	}
+
+	// Growing the value of id3 evicts id2, the least recently used
+	cache.Put(id3, sized(30))
+
+	if _, found := cache.Get(id2); found {
+		t.Fatalf("Retrieved value when none exists")
+	} else if val, found := cache.Get(id3); !found {
+		t.Fatalf("Failed to retrieve value when one exists")
+	} else if val != sized(30) {
+		t.Fatalf("Retrieved wrong value")
+	}
+
+	cache.Evict(id3)
+	if cache.bytes != 0 {
+		t.Fatalf("Cache should be empty, but holds %d bytes", cache.bytes)
+	}
+
+	// A value too large for the cache isn't cached
+	cache.Put(id1, sized(2*32))
+	if _, found := cache.Get(id1); found {
+		t.Fatalf("Retrieved value larger than the cache")
+	}
+	if cache.bytes != 0 {
+		t.Fatalf("Cache should be empty, but holds %d bytes", cache.bytes)
+	}
+}
+
+func TestLRUMaxBytesOversized(t *testing.T) {
+	cache := LRU{MaxBytes: 2*32 + 30}
+
+	id1 := ids.NewID([32]byte{1})
+	id2 := ids.NewID([32]byte{2})
+	id3 := ids.NewID([32]byte{3})
+
+	cache.Put(id1, sized(10))
+	cache.Put(id2, sized(20))
+
+	// A value too large for the cache doesn't evict the other entries
+	cache.Put(id3, sized(2*32+30))
+
+	if _, found := cache.Get(id3); found {
+		t.Fatalf("Retrieved value larger than the cache")
+	} else if val, found := cache.Get(id1); !found {
+		t.Fatalf("Failed to retrieve value when one exists")
+	} else if val != sized(10) {
+		t.Fatalf("Retrieved wrong value")
+	} else if val, found := cache.Get(id2); !found {
+		t.Fatalf("Failed to retrieve value when one exists")
+	} else if val != sized(20) {
+		t.Fatalf("Retrieved wrong value")
+	}
+
+	// Replacing a value with one too large for the cache evicts the old value
+	cache.Put(id1, sized(2*32+30))
+
+	if _, found := cache.Get(id1); found {
+		t.Fatalf("Retrieved stale value")
+	} else if _, found := cache.Get(id2); !found {
+		t.Fatalf("Failed to retrieve value when one exists")
+	}
+	if cache.bytes != 32+20 {
+		t.Fatalf("Cache should hold %d bytes, but holds %d bytes", 32+20, cache.bytes)
+	}
+}
+
+func TestLRUSizeAndMaxBytes(t *testing.T) {
+	cache := LRU{Size: 2, MaxBytes: 1000}
+
+	id1 := ids.NewID([32]byte{1})
+	id2 := ids.NewID([32]byte{2})
+	id3 := ids.NewID([32]byte{3})
+
+	cache.Put(id1, sized(1))
+	cache.Put(id2, sized(1))
+	cache.Put(id3, sized(1))
+
+	if _, found := cache.Get(id1); found {
+		t.Fatalf("Retrieved value when none exists")
+	}
+	if expected := 2 * (32 + 1); cache.bytes != expected {
+		t.Fatalf("Cache should hold %d bytes, but holds %d", expected, cache.bytes)
+	}
+
+	cache.Flush()
+	if cache.bytes != 0 {
+		t.Fatalf("Cache should be empty, but holds %d bytes", cache.bytes)
+	}
+}
@@ -0,0 +1,145 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package chains
+
+import (
+	"fmt"
+
+	"github.com/ava-labs/gecko/ids"
+
+	avacon "github.com/ava-labs/gecko/snow/consensus/avalanche"
+	smcon "github.com/ava-labs/gecko/snow/consensus/snowman"
+)
+
+const (
+	// TopologicalConsensus names the topological implementations of Avalanche
+	// and Snowman. It is the default for every chain.
+	TopologicalConsensus = "topological"
+)
+
+var (
+	avalancheFactories = map[string]avacon.Factory{
+		TopologicalConsensus: avacon.TopologicalFactory{},
+	}
+	snowmanFactories = map[string]smcon.Factory{
+		TopologicalConsensus: smcon.TopologicalFactory{},
+	}
+)
+
+// ConsensusConfig overrides the node's consensus parameters for a chain. Zero
+// values aren't overridden.
+type ConsensusConfig struct {
+	K            int `json:"k"`
+	Alpha        int `json:"alpha"`
+	BetaVirtuous int `json:"betaVirtuous"`
+	BetaRogue    int `json:"betaRogue"`
+
+	// Parents and BatchSize only apply to Avalanche chains
+	Parents   int `json:"parents"`
+	BatchSize int `json:"batchSize"`
+
+	// Consensus names the implementation of the chain's consensus. It must
+	// be one of the names accepted by ValidateConsensus.
+	Consensus string `json:"consensus"`
+}
+
+// ValidateConsensus returns nil if [name] names an implementation of
+// Avalanche or Snowman
+func ValidateConsensus(name string) error {
+	_, isAvalanche := avalancheFactories[name]
+	_, isSnowman := snowmanFactories[name]
+	if !isAvalanche && !isSnowman {
+		return fmt.Errorf("unknown consensus %s", name)
+	}
+	return nil
+}
+
+// Valid returns nil if the overrides could describe a valid configuration.
+// Parameters that depend on each other are only checked if both are
+// overridden. Whether the parameters are valid when combined with the node's
+// parameters is only known when the chain is created.
+func (c *ConsensusConfig) Valid() error {
+	switch {
+	case c.K < 0, c.Alpha < 0, c.BetaVirtuous < 0, c.BetaRogue < 0, c.Parents < 0, c.BatchSize < 0:
+		return fmt.Errorf("consensus parameters must not be negative")
+	case c.K != 0 && c.Alpha != 0 && (c.Alpha <= c.K/2 || c.Alpha > c.K):
+		return fmt.Errorf("K = %d, Alpha = %d: Fails the condition that: K/2 < Alpha <= K", c.K, c.Alpha)
+	case c.BetaVirtuous != 0 && c.BetaRogue != 0 && c.BetaRogue < c.BetaVirtuous:
+		return fmt.Errorf("BetaVirtuous = %d, BetaRogue = %d: Fails the condition that: BetaVirtuous <= BetaRogue", c.BetaVirtuous, c.BetaRogue)
+	case c.Consensus == "":
+		return nil
+	default:
+		return ValidateConsensus(c.Consensus)
+	}
+}
+
+// apply returns [params] with the overrides of [c] applied
+func (c *ConsensusConfig) apply(params avacon.Parameters) avacon.Parameters {
+	if c.K != 0 {
+		params.K = c.K
+	}
+	if c.Alpha != 0 {
+		params.Alpha = c.Alpha
+	}
+	if c.BetaVirtuous != 0 {
+		params.BetaVirtuous = c.BetaVirtuous
+	}
+	if c.BetaRogue != 0 {
+		params.BetaRogue = c.BetaRogue
+	}
+	if c.Parents != 0 {
+		params.Parents = c.Parents
+	}
+	if c.BatchSize != 0 {
+		params.BatchSize = c.BatchSize
+	}
+	return params
+}
+
+// consensus returns the name of the implementation of the chain's consensus
+func (c *ConsensusConfig) consensus() string {
+	if c.Consensus == "" {
+		return TopologicalConsensus
+	}
+	return c.Consensus
+}
+
+// AvalancheFactory returns the factory of the Avalanche implementation named
+// [name]
+func AvalancheFactory(name string) (avacon.Factory, error) {
+	factory, exists := avalancheFactories[name]
+	if !exists {
+		return nil, fmt.Errorf("%s isn't an implementation of avalanche", name)
+	}
+	return factory, nil
+}
+
+// SnowmanFactory returns the factory of the Snowman implementation named
+// [name]
+func SnowmanFactory(name string) (smcon.Factory, error) {
+	factory, exists := snowmanFactories[name]
+	if !exists {
+		return nil, fmt.Errorf("%s isn't an implementation of snowman", name)
+	}
+	return factory, nil
+}
+
+// ChainConsensusConfigs maps chains to their consensus configuration. Chains
+// are named by their ID or any of their aliases.
+type ChainConsensusConfigs map[string]ConsensusConfig
+
+// config returns the configuration of the chain with ID [chainID] and the
+// aliases [aliases]. If none is configured, the empty configuration, which
+// inherits all the node's parameters, is returned.
+func (c ChainConsensusConfigs) config(chainID ids.ID, aliases []string) ConsensusConfig {
+	if config, exists := c[chainID.String()]; exists {
+		return config
+	}
+	for _, alias := range aliases {
+		if config, exists := c[alias]; exists {
+			return config
+		}
+	}
+	return ConsensusConfig{}
+}
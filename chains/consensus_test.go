@@ -0,0 +1,93 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package chains
+
+import (
+	"testing"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+
+	avacon "github.com/ava-labs/gecko/snow/consensus/avalanche"
+)
+
+func TestConsensusConfigApply(t *testing.T) {
+	defaults := avacon.Parameters{
+		Parameters: snowball.Parameters{
+			K:                 20,
+			Alpha:             15,
+			BetaVirtuous:      15,
+			BetaRogue:         20,
+			ConcurrentRepolls: 1,
+		},
+		Parents:   5,
+		BatchSize: 30,
+	}
+
+	config := ConsensusConfig{
+		K:         10,
+		Alpha:     8,
+		BatchSize: 5,
+	}
+	params := config.apply(defaults)
+	switch {
+	case params.K != 10:
+		t.Fatalf("K should have been overridden")
+	case params.Alpha != 8:
+		t.Fatalf("Alpha should have been overridden")
+	case params.BetaVirtuous != 15:
+		t.Fatalf("BetaVirtuous shouldn't have been overridden")
+	case params.BetaRogue != 20:
+		t.Fatalf("BetaRogue shouldn't have been overridden")
+	case params.Parents != 5:
+		t.Fatalf("Parents shouldn't have been overridden")
+	case params.BatchSize != 5:
+		t.Fatalf("BatchSize should have been overridden")
+	}
+	if err := params.Valid(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConsensusConfigValid(t *testing.T) {
+	if err := (&ConsensusConfig{}).Valid(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&ConsensusConfig{Consensus: TopologicalConsensus}).Valid(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&ConsensusConfig{Consensus: "unknown"}).Valid(); err == nil {
+		t.Fatalf("Should have errored due to the unknown consensus")
+	}
+	if err := (&ConsensusConfig{K: -1}).Valid(); err == nil {
+		t.Fatalf("Should have errored due to the negative K")
+	}
+	if err := (&ConsensusConfig{K: 10, Alpha: 5}).Valid(); err == nil {
+		t.Fatalf("Should have errored due to Alpha not being a majority of K")
+	}
+	if err := (&ConsensusConfig{Alpha: 5}).Valid(); err != nil {
+		t.Fatalf("Alpha alone can't be checked, but errored due to %s", err)
+	}
+	if err := (&ConsensusConfig{BetaVirtuous: 20, BetaRogue: 10}).Valid(); err == nil {
+		t.Fatalf("Should have errored due to BetaRogue being less than BetaVirtuous")
+	}
+}
+
+func TestChainConsensusConfigs(t *testing.T) {
+	chainID := ids.Empty.Prefix(0)
+	configs := ChainConsensusConfigs{
+		chainID.String(): ConsensusConfig{K: 1},
+		"X":              ConsensusConfig{K: 2},
+	}
+
+	if config := configs.config(chainID, []string{"X"}); config.K != 1 {
+		t.Fatalf("The chain ID should take precedence over aliases")
+	}
+	if config := configs.config(ids.Empty.Prefix(1), []string{"avm", "X"}); config.K != 2 {
+		t.Fatalf("The chain should have been found by its alias")
+	}
+	if config := configs.config(ids.Empty.Prefix(2), nil); config != (ConsensusConfig{}) {
+		t.Fatalf("Unconfigured chains should inherit the node's parameters")
+	}
+}
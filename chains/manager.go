@@ -5,12 +5,18 @@ package chains
 
 import (
 	"fmt"
+	"os"
+	"path"
+	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/ava-labs/gecko/api"
 	"github.com/ava-labs/gecko/api/keystore"
 	"github.com/ava-labs/gecko/chains/atomic"
 	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/database/meterdb"
 	"github.com/ava-labs/gecko/database/prefixdb"
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/snow"
@@ -21,6 +27,7 @@ import (
 	"github.com/ava-labs/gecko/snow/engine/common/queue"
 	"github.com/ava-labs/gecko/snow/networking"
 	"github.com/ava-labs/gecko/snow/networking/handler"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
 	"github.com/ava-labs/gecko/snow/networking/router"
 	"github.com/ava-labs/gecko/snow/networking/sender"
 	"github.com/ava-labs/gecko/snow/networking/timeout"
@@ -29,6 +36,7 @@ import (
 	"github.com/ava-labs/gecko/utils/logging"
 	"github.com/ava-labs/gecko/utils/math"
 	"github.com/ava-labs/gecko/vms"
+	"github.com/ava-labs/gecko/vms/proposervm"
 
 	avacon "github.com/ava-labs/gecko/snow/consensus/avalanche"
 	avaeng "github.com/ava-labs/gecko/snow/engine/avalanche"
@@ -39,7 +47,6 @@ import (
 
 const (
 	defaultChannelSize = 1000
-	requestTimeout     = 2 * time.Second
 )
 
 // Manager manages the chains running on this node.
@@ -75,6 +82,15 @@ type Manager interface {
 	// Add an alias to a chain
 	Alias(ids.ID, string) error
 
+	// Returns true iff the chain with the given ID has finished bootstrapping
+	IsBootstrapped(ids.ID) bool
+
+	// Returns the progress of bootstrapping the chain with the given ID
+	BootstrapStatus(ids.ID) (common.BootstrapStatus, error)
+
+	// Returns the most recent consensus events of the chain with the given ID
+	Trace(ids.ID) ([]common.TraceEvent, error)
+
 	Shutdown()
 }
 
@@ -89,6 +105,45 @@ type ChainParameters struct {
 	CustomBeacons validators.Set // Should only be set if the default beacons can't be used.
 }
 
+// SamplerConfig selects the strategy used to sample the validators of each
+// subnet. Strategies are named as in the validators package. Every chain of a
+// subnet shares the subnet's validator set, so the strategy of a subnet applies
+// to all of its chains.
+type SamplerConfig struct {
+	// Default is the strategy of subnets that aren't listed in Subnets
+	Default string
+
+	// Subnets maps the ID of a subnet to its strategy
+	Subnets map[[32]byte]string
+}
+
+// Valid returns nil if every strategy is known and would be used. When staking
+// is disabled, every chain is validated by the validators of the default
+// subnet, so the strategies of other subnets would be ignored.
+func (c *SamplerConfig) Valid(stakingEnabled bool) error {
+	if err := validators.ValidateStrategy(c.Default); err != nil {
+		return err
+	}
+	for key, strategy := range c.Subnets {
+		subnetID := ids.NewID(key)
+		if !stakingEnabled && !subnetID.Equals(ids.Empty) {
+			return fmt.Errorf("sampler of subnet %s would be ignored, since staking is disabled", subnetID)
+		}
+		if err := validators.ValidateStrategy(strategy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Strategy returns the strategy configured for [subnetID]
+func (c *SamplerConfig) Strategy(subnetID ids.ID) string {
+	if strategy, exists := c.Subnets[subnetID.Key()]; exists {
+		return strategy
+	}
+	return c.Default
+}
+
 type manager struct {
 	// Note: The string representation of a chain's ID is also considered to be an alias of the chain
 	// That is, [chainID].String() is an alias for the chain, too
@@ -105,6 +160,7 @@ type manager struct {
 	sender          sender.ExternalSender // Sends consensus messages to other validators
 	timeoutManager  *timeout.Manager      // Manages request timeouts when sending messages to other validators
 	consensusParams avacon.Parameters     // The consensus parameters (alpha, beta, etc.) for new chains
+	chainConsensus  ChainConsensusConfigs // Overrides of the consensus parameters of specific chains
 	validators      validators.Manager    // Validators validating on this chain
 	registrants     []Registrant          // Those notified when a chain is created
 	nodeID          ids.ShortID           // The ID of this node
@@ -113,9 +169,30 @@ type manager struct {
 	server          *api.Server           // Handles HTTP API calls
 	keystore        *keystore.Keystore
 	sharedMemory    *atomic.SharedMemory
-
+	reputation      reputation.Reporter     // Tracks the reputation of peers
+	samplerConfig   SamplerConfig           // Strategies used to sample the validators of each subnet
+	connectivity    validators.Connectivity // Reports the validators this node is connected to
+	stateSync       bool                    // Whether snowman chains may be bootstrapped from a state summary
+	proposerWindows bool                    // Whether the validators of snowman chains take turns proposing blocks
+	proposerConfig  proposervm.Config       // Proposer windows of snowman chains, if enabled
+	traceSize       int                     // Number of consensus events traced per chain
+	recordDir       string                  // Directory the inputs of each chain's engine are recorded to, if not empty
+
+	// Subnets whose validator sets were given the subnet's sampling strategy.
+	// The sets are shared by the chains of each subnet, so each is only given
+	// a strategy once.
+	sampledLock    sync.Mutex
+	sampledSubnets ids.Set
+
+	// Chains whose creation waits until bootstrapping finishes
+	blockedLock   sync.Mutex
 	unblocked     bool
 	blockedChains []ChainParameters
+
+	bootstrappedLock sync.Mutex
+	bootstrapped     ids.Set                                // IDs of the chains that have finished bootstrapping
+	progress         map[[32]byte]*common.BootstrapProgress // Chain ID --> Progress of bootstrapping the chain
+	traces           map[[32]byte]*common.Trace             // Chain ID --> Consensus events of the chain
 }
 
 // New returns a new Manager where:
@@ -134,6 +211,7 @@ func New(
 	router router.Router,
 	sender sender.ExternalSender,
 	consensusParams avacon.Parameters,
+	chainConsensus ChainConsensusConfigs,
 	validators validators.Manager,
 	nodeID ids.ShortID,
 	networkID uint32,
@@ -141,12 +219,17 @@ func New(
 	server *api.Server,
 	keystore *keystore.Keystore,
 	sharedMemory *atomic.SharedMemory,
+	reputation reputation.Reporter,
+	samplerConfig SamplerConfig,
+	connectivity validators.Connectivity,
+	timeoutManager *timeout.Manager,
+	stateSync bool,
+	proposerWindows bool,
+	proposerConfig proposervm.Config,
+	traceSize int,
+	recordDir string,
 ) Manager {
-	timeoutManager := timeout.Manager{}
-	timeoutManager.Initialize(requestTimeout)
-	go log.RecoverAndPanic(timeoutManager.Dispatch)
-
-	router.Initialize(log, &timeoutManager)
+	router.Initialize(log, timeoutManager)
 
 	m := &manager{
 		stakingEnabled:  stakingEnabled,
@@ -158,8 +241,9 @@ func New(
 		db:              db,
 		chainRouter:     router,
 		sender:          sender,
-		timeoutManager:  &timeoutManager,
+		timeoutManager:  timeoutManager,
 		consensusParams: consensusParams,
+		chainConsensus:  chainConsensus,
 		validators:      validators,
 		nodeID:          nodeID,
 		networkID:       networkID,
@@ -167,21 +251,55 @@ func New(
 		server:          server,
 		keystore:        keystore,
 		sharedMemory:    sharedMemory,
+		reputation:      reputation,
+		samplerConfig:   samplerConfig,
+		connectivity:    connectivity,
+		stateSync:       stateSync,
+		proposerWindows: proposerWindows,
+		proposerConfig:  proposerConfig,
+		traceSize:       traceSize,
+		recordDir:       recordDir,
+		progress:        make(map[[32]byte]*common.BootstrapProgress),
+		traces:          make(map[[32]byte]*common.Trace),
 	}
 	m.Initialize()
 	return m
 }
 
+// setSampler gives [vdrs], the validator set of [subnetID], the sampling
+// strategy configured for the subnet, unless it was already given one
+func (m *manager) setSampler(subnetID ids.ID, vdrs validators.Set) error {
+	m.sampledLock.Lock()
+	defer m.sampledLock.Unlock()
+
+	if m.sampledSubnets.Contains(subnetID) {
+		return nil
+	}
+	if strategy := m.samplerConfig.Strategy(subnetID); strategy != "" {
+		sampler, err := validators.NewSampler(strategy, m.connectivity)
+		if err != nil {
+			return err
+		}
+		vdrs.SetSampler(sampler)
+	}
+	m.sampledSubnets.Add(subnetID)
+	return nil
+}
+
 // Router that this chain manager is using to route consensus messages to chains
 func (m *manager) Router() router.Router { return m.chainRouter }
 
 // Create a chain
 func (m *manager) CreateChain(chain ChainParameters) {
+	m.blockedLock.Lock()
 	if !m.unblocked {
 		m.blockedChains = append(m.blockedChains, chain)
-	} else {
-		m.ForceCreateChain(chain)
+		m.blockedLock.Unlock()
+		return
 	}
+	m.blockedLock.Unlock()
+
+	m.ForceCreateChain(chain)
 }
 
 // Create a chain
@@ -267,8 +385,11 @@ func (m *manager) ForceCreateChain(chain ChainParameters) {
 		Keystore:            m.keystore.NewBlockchainKeyStore(chain.ID),
 		SharedMemory:        m.sharedMemory.NewBlockchainSharedMemory(chain.ID),
 		BCLookup:            m,
+		Reputation:          m.reputation,
 	}
-	consensusParams := m.consensusParams
+	// Chains may be tuned individually through the node's configuration
+	consensusConfig := m.chainConsensus.config(chain.ID, m.Aliases(chain.ID))
+	consensusParams := consensusConfig.apply(m.consensusParams)
 	if alias, err := m.PrimaryAlias(ctx.ChainID); err == nil {
 		consensusParams.Namespace = fmt.Sprintf("gecko_%s", alias)
 	} else {
@@ -276,26 +397,62 @@ func (m *manager) ForceCreateChain(chain ChainParameters) {
 	}
 
 	// The validators of this blockchain
-	var validators validators.Set // Validators validating this blockchain
-	var ok bool
-	if m.stakingEnabled {
-		validators, ok = m.validators.GetValidatorSet(chain.SubnetID)
-	} else { // Staking is disabled. Every peer validates every subnet.
-		validators, ok = m.validators.GetValidatorSet(ids.Empty) // ids.Empty is the default subnet ID. TODO: Move to const package so we can use it here.
+	subnetID := chain.SubnetID
+	if !m.stakingEnabled { // Staking is disabled. Every peer validates every subnet.
+		subnetID = ids.Empty // ids.Empty is the default subnet ID. TODO: Move to const package so we can use it here.
 	}
+	validators, ok := m.validators.GetValidatorSet(subnetID)
 	if !ok {
 		m.log.Error("couldn't get validator set of subnet with ID %s. The subnet may not exist", chain.SubnetID)
 		return
 	}
+	if err := m.setSampler(subnetID, validators); err != nil {
+		m.log.Error("error while creating validator sampler: %s", err)
+		return
+	}
 
 	beacons := validators
 	if chain.CustomBeacons != nil {
 		beacons = chain.CustomBeacons
 	}
 
+	// The chain's consensus is checked before the chain is recorded, so that
+	// chains that fail to be created don't leave recordings behind
+	var (
+		avalancheFactory avacon.Factory
+		snowmanFactory   smcon.Factory
+	)
+	switch vm.(type) {
+	case avalanche.DAGVM:
+		err = consensusParams.Valid()
+		if err == nil {
+			avalancheFactory, err = AvalancheFactory(consensusConfig.consensus())
+		}
+	case smeng.ChainVM:
+		err = consensusParams.Parameters.Valid()
+		if err == nil {
+			snowmanFactory, err = SnowmanFactory(consensusConfig.consensus())
+		}
+	default:
+		m.log.Error("the vm should have type avalanche.DAGVM or snowman.ChainVM. Chain not created")
+		return
+	}
+	if err != nil {
+		m.log.Error("invalid consensus for chain %s: %s", chain.ID, err)
+		return
+	}
+
+	// Records the inputs of the chain's engine, so they can be replayed
+	recorder, err := m.newRecorder(ctx, chain, vmID, fxs, beacons, consensusParams, consensusConfig.consensus())
+	if err != nil {
+		m.log.Error("error while creating the recording of chain %s: %s", chain.ID, err)
+		return
+	}
+	validators = recorder.Validators(validators)
+
 	switch vm := vm.(type) {
 	case avalanche.DAGVM:
-		err := m.createAvalancheChain(
+		err = m.createAvalancheChain(
 			ctx,
 			chain.GenesisData,
 			validators,
@@ -303,13 +460,16 @@ func (m *manager) ForceCreateChain(chain ChainParameters) {
 			vm,
 			fxs,
 			consensusParams,
+			avalancheFactory.New(),
+			recorder,
 		)
 		if err != nil {
 			m.log.Error("error while creating new avalanche vm %s", err)
+			recorder.Close()
 			return
 		}
 	case smeng.ChainVM:
-		err := m.createSnowmanChain(
+		err = m.createSnowmanChain(
 			ctx,
 			chain.GenesisData,
 			validators,
@@ -317,14 +477,14 @@ func (m *manager) ForceCreateChain(chain ChainParameters) {
 			vm,
 			fxs,
 			consensusParams.Parameters,
+			snowmanFactory.New(),
+			recorder,
 		)
 		if err != nil {
 			m.log.Error("error while creating new snowman vm %s", err)
+			recorder.Close()
 			return
 		}
-	default:
-		m.log.Error("the vm should have type avalanche.DAGVM or snowman.ChainVM. Chain not created")
-		return
 	}
 
 	// Associate the newly created chain with its default alias
@@ -338,14 +498,158 @@ func (m *manager) ForceCreateChain(chain ChainParameters) {
 func (m *manager) AddRegistrant(r Registrant) { m.registrants = append(m.registrants, r) }
 
 func (m *manager) unblockChains() {
+	m.blockedLock.Lock()
 	m.unblocked = true
 	blocked := m.blockedChains
 	m.blockedChains = nil
+	m.blockedLock.Unlock()
+
 	for _, chain := range blocked {
 		m.ForceCreateChain(chain)
 	}
 }
 
+// Implements Manager.IsBootstrapped
+func (m *manager) IsBootstrapped(chainID ids.ID) bool {
+	m.bootstrappedLock.Lock()
+	defer m.bootstrappedLock.Unlock()
+
+	return m.bootstrapped.Contains(chainID)
+}
+
+// Implements Manager.BootstrapStatus
+func (m *manager) BootstrapStatus(chainID ids.ID) (common.BootstrapStatus, error) {
+	m.bootstrappedLock.Lock()
+	progress, exists := m.progress[chainID.Key()]
+	m.bootstrappedLock.Unlock()
+
+	if !exists {
+		return common.BootstrapStatus{}, fmt.Errorf("chain %s isn't running", chainID)
+	}
+	return progress.Status(), nil
+}
+
+// Implements Manager.Trace
+func (m *manager) Trace(chainID ids.ID) ([]common.TraceEvent, error) {
+	m.bootstrappedLock.Lock()
+	trace, exists := m.traces[chainID.Key()]
+	m.bootstrappedLock.Unlock()
+
+	if !exists {
+		return nil, fmt.Errorf("chain %s isn't being traced", chainID)
+	}
+	return trace.Events(), nil
+}
+
+// newTrace returns the trace of the consensus events of the chain with ID
+// [chainID], or nil if tracing is disabled
+func (m *manager) newTrace(chainID ids.ID) (*common.Trace, error) {
+	if m.traceSize <= 0 {
+		return nil, nil
+	}
+
+	trace := &common.Trace{}
+	trace.Initialize(m.traceSize)
+	// Containers are issued, accepted and rejected by consensus, not by the
+	// engine
+	if err := m.consensusEvents.RegisterChain(chainID, "trace", trace); err != nil {
+		return nil, err
+	}
+
+	m.bootstrappedLock.Lock()
+	defer m.bootstrappedLock.Unlock()
+
+	m.traces[chainID.Key()] = trace
+	return trace, nil
+}
+
+// newRecorder returns the recorder of the inputs of the engine of [chain], or
+// nil if recording is disabled. Each run of a chain is recorded to a new file,
+// so restarting the node doesn't overwrite the recording of a crash.
+func (m *manager) newRecorder(
+	ctx *snow.Context,
+	chain ChainParameters,
+	vmID ids.ID,
+	fxs []*common.Fx,
+	beacons validators.Set,
+	consensusParams avacon.Parameters,
+	consensus string,
+) (*handler.Recorder, error) {
+	if m.recordDir == "" {
+		return nil, nil
+	}
+
+	header := handler.Header{
+		NetworkID:         ctx.NetworkID,
+		NodeID:            ctx.NodeID,
+		ChainID:           chain.ID,
+		SubnetID:          chain.SubnetID,
+		VMID:              vmID,
+		GenesisData:       chain.GenesisData,
+		K:                 consensusParams.K,
+		Alpha:             consensusParams.Alpha,
+		BetaVirtuous:      consensusParams.BetaVirtuous,
+		BetaRogue:         consensusParams.BetaRogue,
+		ConcurrentRepolls: consensusParams.ConcurrentRepolls,
+		Parents:           consensusParams.Parents,
+		BatchSize:         consensusParams.BatchSize,
+		Consensus:         consensus,
+		StateSync:         m.stateSync,
+		ProposerWindows:   m.proposerWindows,
+	}
+	for _, fx := range fxs {
+		header.FxIDs = append(header.FxIDs, fx.ID)
+	}
+	for _, beacon := range beacons.List() {
+		header.Beacons = append(header.Beacons, handler.RecordedValidator{
+			ID:     beacon.ID(),
+			Weight: beacon.Weight(),
+		})
+	}
+
+	if err := os.MkdirAll(m.recordDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	filename := path.Join(m.recordDir, fmt.Sprintf("%s-%d.rec", chain.ID, time.Now().Unix()))
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	recorder := &handler.Recorder{}
+	if err := recorder.Initialize(file, header); err != nil {
+		file.Close()
+		return nil, err
+	}
+	m.log.Info("recording the inputs of chain %s to %s", chain.ID, filename)
+	return recorder, nil
+}
+
+// chainDB returns the database of the chain, whose calls are measured in the
+// chain's metrics. The calls are measured below the chain's prefix, so that
+// the databases the chain nests under it keep the keys they always had.
+func (m *manager) chainDB(ctx *snow.Context, namespace string, registerer prometheus.Registerer) (database.Database, error) {
+	meterDB, err := meterdb.New(namespace, registerer, m.db)
+	if err != nil {
+		return nil, err
+	}
+	return prefixdb.New(ctx.ChainID.Bytes(), meterDB), nil
+}
+
+func (m *manager) trackProgress(chainID ids.ID, progress *common.BootstrapProgress) {
+	m.bootstrappedLock.Lock()
+	defer m.bootstrappedLock.Unlock()
+
+	m.progress[chainID.Key()] = progress
+}
+
+func (m *manager) markBootstrapped(chainID ids.ID) {
+	m.bootstrappedLock.Lock()
+	defer m.bootstrappedLock.Unlock()
+
+	m.bootstrapped.Add(chainID)
+}
+
 // Create a DAG-based blockchain that uses Avalanche
 func (m *manager) createAvalancheChain(
 	ctx *snow.Context,
@@ -355,11 +659,16 @@ func (m *manager) createAvalancheChain(
 	vm avalanche.DAGVM,
 	fxs []*common.Fx,
 	consensusParams avacon.Parameters,
+	consensus avacon.Consensus,
+	recorder *handler.Recorder,
 ) error {
 	ctx.Lock.Lock()
 	defer ctx.Lock.Unlock()
 
-	db := prefixdb.New(ctx.ChainID.Bytes(), m.db)
+	db, err := m.chainDB(ctx, consensusParams.Namespace, consensusParams.Metrics)
+	if err != nil {
+		return err
+	}
 	vmDB := prefixdb.New([]byte("vm"), db)
 	vertexDB := prefixdb.New([]byte("vertex"), db)
 	vertexBootstrappingDB := prefixdb.New([]byte("vertex_bootstrapping"), db)
@@ -391,6 +700,12 @@ func (m *manager) createAvalancheChain(
 	sender := sender.Sender{}
 	sender.Initialize(ctx, m.sender, m.chainRouter, m.timeoutManager)
 
+	// Records the consensus events of the chain for debugging
+	trace, err := m.newTrace(ctx.ChainID)
+	if err != nil {
+		return err
+	}
+
 	// The engine handles consensus
 	engine := avaeng.Transitive{
 		Config: avaeng.Config{
@@ -420,18 +735,22 @@ func (m *manager) createAvalancheChain(
 				Alpha:      bootstrapWeight/2 + 1, // must be > 50%
 				Sender:     &sender,
 			},
-			VtxBlocked: vtxBlocker,
-			TxBlocked:  txBlocker,
-			State:      vtxState,
-			VM:         vm,
+			VtxBlocked:   vtxBlocker,
+			TxBlocked:    txBlocker,
+			State:        vtxState,
+			VM:           vm,
+			Bootstrapped: func() { m.markBootstrapped(ctx.ChainID) },
 		},
 		Params:    consensusParams,
-		Consensus: &avacon.Topological{},
+		Consensus: consensus,
+		Trace:     trace,
 	})
+	m.trackProgress(ctx.ChainID, &engine.Progress)
 
 	// Asynchronously passes messages from the network to the consensus engine
 	handler := &handler.Handler{}
 	handler.Initialize(&engine, msgChan, defaultChannelSize)
+	handler.Record(recorder)
 
 	// Allows messages to be routed to the new chain
 	m.chainRouter.AddChain(handler)
@@ -444,6 +763,7 @@ func (m *manager) createAvalancheChain(
 			ctx.Lock.Lock()
 			defer ctx.Lock.Unlock()
 
+			recorder.Startup()
 			engine.Startup()
 		},
 	}
@@ -461,11 +781,16 @@ func (m *manager) createSnowmanChain(
 	vm smeng.ChainVM,
 	fxs []*common.Fx,
 	consensusParams snowball.Parameters,
+	consensus smcon.Consensus,
+	recorder *handler.Recorder,
 ) error {
 	ctx.Lock.Lock()
 	defer ctx.Lock.Unlock()
 
-	db := prefixdb.New(ctx.ChainID.Bytes(), m.db)
+	db, err := m.chainDB(ctx, consensusParams.Namespace, consensusParams.Metrics)
+	if err != nil {
+		return err
+	}
 	vmDB := prefixdb.New([]byte("vm"), db)
 	bootstrappingDB := prefixdb.New([]byte("bootstrapping"), db)
 
@@ -478,6 +803,11 @@ func (m *manager) createSnowmanChain(
 	// VM uses this channel to notify engine that a block is ready to be made
 	msgChan := make(chan common.Message, defaultChannelSize)
 
+	// Have the validators take turns proposing blocks
+	if m.proposerWindows {
+		vm = proposervm.New(vm, prefixdb.New([]byte("proposer"), db), validators, m.proposerConfig)
+	}
+
 	// Initialize the VM
 	if err := vm.Initialize(ctx, vmDB, genesisData, msgChan, fxs); err != nil {
 		return err
@@ -487,6 +817,12 @@ func (m *manager) createSnowmanChain(
 	sender := sender.Sender{}
 	sender.Initialize(ctx, m.sender, m.chainRouter, m.timeoutManager)
 
+	// Records the consensus events of the chain for debugging
+	trace, err := m.newTrace(ctx.ChainID)
+	if err != nil {
+		return err
+	}
+
 	bootstrapWeight := uint64(0)
 	for _, beacon := range beacons.List() {
 		newWeight, err := math.Add64(bootstrapWeight, beacon.Weight())
@@ -507,17 +843,24 @@ func (m *manager) createSnowmanChain(
 				Alpha:      bootstrapWeight/2 + 1, // must be > 50%
 				Sender:     &sender,
 			},
-			Blocked:      blocked,
-			VM:           vm,
-			Bootstrapped: m.unblockChains,
+			Blocked:   blocked,
+			VM:        vm,
+			StateSync: m.stateSync,
+			Bootstrapped: func() {
+				m.markBootstrapped(ctx.ChainID)
+				m.unblockChains()
+			},
 		},
 		Params:    consensusParams,
-		Consensus: &smcon.Topological{},
+		Consensus: consensus,
+		Trace:     trace,
 	})
+	m.trackProgress(ctx.ChainID, &engine.Progress)
 
 	// Asynchronously passes messages from the network to the consensus engine
 	handler := &handler.Handler{}
 	handler.Initialize(&engine, msgChan, defaultChannelSize)
+	handler.Record(recorder)
 
 	// Allow incoming messages to be routed to the new chain
 	m.chainRouter.AddChain(handler)
@@ -530,6 +873,7 @@ func (m *manager) createSnowmanChain(
 			ctx.Lock.Lock()
 			defer ctx.Lock.Unlock()
 
+			recorder.Startup()
 			engine.Startup()
 		},
 	}
@@ -0,0 +1,75 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package chains
+
+import (
+	"testing"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/validators"
+)
+
+// vdrSet is embedded under another name, since Set is also one of its methods
+type vdrSet = validators.Set
+
+// samplerCountingSet counts the samplers it's given
+type samplerCountingSet struct {
+	vdrSet
+	numSamplers int
+}
+
+func (s *samplerCountingSet) SetSampler(sampler validators.Sampler) {
+	s.numSamplers++
+	s.vdrSet.SetSampler(sampler)
+}
+
+func TestManagerSetSamplerOncePerSubnet(t *testing.T) {
+	subnetID := ids.Empty.Prefix(1)
+	m := &manager{
+		samplerConfig: SamplerConfig{
+			Default: validators.WeightedStrategy,
+			Subnets: map[[32]byte]string{
+				subnetID.Key(): validators.UniformStrategy,
+			},
+		},
+	}
+
+	vdrs := &samplerCountingSet{vdrSet: validators.NewSet()}
+	for i := 0; i < 2; i++ {
+		// Each chain of the subnet is created with the same validator set
+		if err := m.setSampler(subnetID, vdrs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if vdrs.numSamplers != 1 {
+		t.Fatalf("The subnet's validators should have been given a sampler once, but were given %d", vdrs.numSamplers)
+	}
+}
+
+func TestSamplerConfigValid(t *testing.T) {
+	subnetID := ids.Empty.Prefix(1)
+	config := SamplerConfig{
+		Default: validators.WeightedStrategy,
+		Subnets: map[[32]byte]string{
+			ids.Empty.Key(): validators.UniformStrategy,
+			subnetID.Key():  validators.UniformStrategy,
+		},
+	}
+	if err := config.Valid(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Valid(false); err == nil {
+		t.Fatalf("Subnet samplers other than the default subnet's should be rejected when staking is disabled")
+	}
+
+	delete(config.Subnets, subnetID.Key())
+	if err := config.Valid(false); err != nil {
+		t.Fatal(err)
+	}
+
+	config.Subnets[subnetID.Key()] = "unknown"
+	if err := config.Valid(true); err == nil {
+		t.Fatalf("Unknown strategies should be rejected")
+	}
+}
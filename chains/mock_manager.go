@@ -2,6 +2,7 @@ package chains
 
 import (
 	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/engine/common"
 	"github.com/ava-labs/gecko/snow/networking/router"
 )
 
@@ -33,5 +34,16 @@ func (mm MockManager) Aliases(ids.ID) []string { return nil }
 // Alias ...
 func (mm MockManager) Alias(ids.ID, string) error { return nil }
 
+// IsBootstrapped ...
+func (mm MockManager) IsBootstrapped(ids.ID) bool { return false }
+
+// BootstrapStatus ...
+func (mm MockManager) BootstrapStatus(ids.ID) (common.BootstrapStatus, error) {
+	return common.BootstrapStatus{}, nil
+}
+
+// Trace ...
+func (mm MockManager) Trace(ids.ID) ([]common.TraceEvent, error) { return nil, nil }
+
 // Shutdown ...
 func (mm MockManager) Shutdown() {}
@@ -0,0 +1,288 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/utils/wrappers"
+)
+
+// A backup is a gzip compressed stream of:
+//   - magic, followed by the version of the format
+//   - a record for each key/value pair, in key order. A record is entryRecord,
+//     followed by the length prefixed key and value.
+//   - endRecord, followed by the number of key/value pairs
+const (
+	version uint32 = 0
+
+	entryRecord byte = 1
+	endRecord   byte = 0
+
+	// restoreBatchSize is the number of value bytes written to the database
+	// at once when restoring
+	restoreBatchSize = 4 << 20
+)
+
+var (
+	magic = []byte("gecko database backup\x00")
+
+	// incompleteKey is written to a database while a backup is restored to
+	// it. It starts with a zero byte so it stands apart from the node's keys,
+	// which are hash prefixed or plain words.
+	incompleteKey = []byte("\x00backup restore incomplete")
+
+	errNotBackup   = errors.New("not a database backup")
+	errNotEmpty    = errors.New("backups can only be restored to empty databases")
+	errTruncated   = errors.New("backup is truncated")
+	errTrailing    = errors.New("backup has data after its end")
+	errUnknownKind = errors.New("backup has a record of an unknown kind")
+)
+
+// Write a backup of [db] to [w]. The backup is made from a snapshot of [db],
+// so it's consistent while [db] is written to. Returns the number of
+// key/value pairs backed up.
+func Write(w io.Writer, db database.Snapshotter) (uint64, error) {
+	snapshot, err := db.NewSnapshot()
+	if err != nil {
+		return 0, err
+	}
+	defer snapshot.Release()
+
+	zw := gzip.NewWriter(w)
+	bw := bufio.NewWriter(zw)
+	buf := [wrappers.IntLen]byte{}
+	binary.BigEndian.PutUint32(buf[:], version)
+	if _, err := bw.Write(magic); err != nil {
+		return 0, err
+	}
+	if _, err := bw.Write(buf[:]); err != nil {
+		return 0, err
+	}
+
+	numKeys := uint64(0)
+	it := snapshot.NewIterator()
+	defer it.Release()
+
+	for it.Next() {
+		if err := bw.WriteByte(entryRecord); err != nil {
+			return 0, err
+		}
+		if err := writeBytes(bw, it.Key()); err != nil {
+			return 0, err
+		}
+		if err := writeBytes(bw, it.Value()); err != nil {
+			return 0, err
+		}
+		numKeys++
+	}
+	if err := it.Error(); err != nil {
+		return 0, err
+	}
+
+	end := [1 + wrappers.LongLen]byte{endRecord}
+	binary.BigEndian.PutUint64(end[1:], numKeys)
+	if _, err := bw.Write(end[:]); err != nil {
+		return 0, err
+	}
+	if err := bw.Flush(); err != nil {
+		return 0, err
+	}
+	return numKeys, zw.Close()
+}
+
+// Verify reads the backup from [r] to the end, and checks that it's valid,
+// without restoring it. Returns the number of key/value pairs in the backup.
+func Verify(r io.Reader) (uint64, error) {
+	return read(r, func([]byte, []byte) error { return nil })
+}
+
+// Restore the backup read from [r] to [db], which must be empty or be left
+// from a restore that didn't complete. Until the restore completes, [db] is
+// marked as incomplete, so a backup that turns out to be invalid, or a restore
+// that's interrupted, isn't mistaken for a restored database. Running Verify
+// on the backup first avoids most failed restores. Returns the number of
+// key/value pairs restored.
+func Restore(r io.Reader, db database.Database) (uint64, error) {
+	incomplete, err := Incomplete(db)
+	if err != nil {
+		return 0, err
+	}
+	if incomplete {
+		if err := clear(db); err != nil {
+			return 0, err
+		}
+	}
+
+	it := db.NewIterator()
+	empty := !it.Next()
+	err = it.Error()
+	it.Release()
+	switch {
+	case err != nil:
+		return 0, err
+	case !empty:
+		return 0, errNotEmpty
+	}
+
+	if err := db.Put(incompleteKey, nil); err != nil {
+		return 0, err
+	}
+
+	batch := db.NewBatch()
+	numKeys, err := read(r, func(key, value []byte) error {
+		if err := batch.Put(key, value); err != nil {
+			return err
+		}
+		if batch.ValueSize() < restoreBatchSize {
+			return nil
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	})
+	if err != nil {
+		return numKeys, err
+	}
+
+	// The restore is only complete once every key is written
+	if err := batch.Delete(incompleteKey); err != nil {
+		return numKeys, err
+	}
+	return numKeys, batch.Write()
+}
+
+// Incomplete returns true if [db] is left from a restore that didn't
+// complete. Such a database should be restored again before it's used.
+func Incomplete(db database.Database) (bool, error) {
+	return db.Has(incompleteKey)
+}
+
+// clear deletes every key of [db]
+func clear(db database.Database) error {
+	it := db.NewIterator()
+	defer it.Release()
+
+	batch := db.NewBatch()
+	for it.Next() {
+		if err := batch.Delete(it.Key()); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= restoreBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// read the backup from [r], calling [put] with each of its key/value pairs.
+// Returns the number of key/value pairs read.
+func read(r io.Reader, put func(key, value []byte) error) (uint64, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+	br := bufio.NewReader(zr)
+
+	header := make([]byte, len(magic)+wrappers.IntLen)
+	if _, err := io.ReadFull(br, header); err != nil || !bytes.Equal(header[:len(magic)], magic) {
+		return 0, errNotBackup
+	}
+	if v := binary.BigEndian.Uint32(header[len(magic):]); v != version {
+		return 0, fmt.Errorf("backup has version %d, but only version %d is supported", v, version)
+	}
+
+	numKeys := uint64(0)
+	for {
+		kind, err := br.ReadByte()
+		if err != nil {
+			return numKeys, readError(err)
+		}
+
+		switch kind {
+		case entryRecord:
+			key, err := readBytes(br)
+			if err != nil {
+				return numKeys, err
+			}
+			value, err := readBytes(br)
+			if err != nil {
+				return numKeys, err
+			}
+			if err := put(key, value); err != nil {
+				return numKeys, err
+			}
+			numKeys++
+		case endRecord:
+			buf := [wrappers.LongLen]byte{}
+			if _, err := io.ReadFull(br, buf[:]); err != nil {
+				return numKeys, readError(err)
+			}
+			if backedUp := binary.BigEndian.Uint64(buf[:]); backedUp != numKeys {
+				return numKeys, fmt.Errorf("backup should have %d keys, but has %d", backedUp, numKeys)
+			}
+
+			// Reading to the end of the stream verifies its checksum
+			switch _, err := br.ReadByte(); err {
+			case io.EOF:
+				return numKeys, nil
+			case nil:
+				return numKeys, errTrailing
+			default:
+				return numKeys, readError(err)
+			}
+		default:
+			return numKeys, errUnknownKind
+		}
+	}
+}
+
+// writeBytes writes [b] prefixed by its length
+func writeBytes(w *bufio.Writer, b []byte) error {
+	buf := [wrappers.IntLen]byte{}
+	binary.BigEndian.PutUint32(buf[:], uint32(len(b)))
+	if _, err := w.Write(buf[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// readBytes reads bytes prefixed by their length
+func readBytes(r *bufio.Reader) ([]byte, error) {
+	buf := [wrappers.IntLen]byte{}
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, readError(err)
+	}
+	// The bytes are read as they arrive, rather than allocated up front, so a
+	// corrupted length can't exhaust the memory
+	b := bytes.Buffer{}
+	if _, err := io.CopyN(&b, r, int64(binary.BigEndian.Uint32(buf[:]))); err != nil {
+		return nil, readError(err)
+	}
+	return b.Bytes(), nil
+}
+
+// readError reports a backup that ends early as truncated
+func readError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errTruncated
+	}
+	return err
+}
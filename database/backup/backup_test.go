@@ -0,0 +1,121 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"testing"
+
+	"github.com/ava-labs/gecko/database/memdb"
+)
+
+func TestWriteAndRestore(t *testing.T) {
+	db := memdb.New()
+	for i := 0; i < 100; i++ {
+		if err := db.Put([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Put([]byte("empty"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	backup := bytes.Buffer{}
+	if numKeys, err := Write(&backup, db); err != nil {
+		t.Fatal(err)
+	} else if numKeys != 101 {
+		t.Fatalf("Should have backed up 101 keys but backed up %d", numKeys)
+	}
+
+	restored := memdb.New()
+	if numKeys, err := Restore(bytes.NewReader(backup.Bytes()), restored); err != nil {
+		t.Fatal(err)
+	} else if numKeys != 101 {
+		t.Fatalf("Should have restored 101 keys but restored %d", numKeys)
+	}
+
+	if incomplete, err := Incomplete(restored); err != nil {
+		t.Fatal(err)
+	} else if incomplete {
+		t.Fatalf("A completed restore shouldn't leave the database incomplete")
+	}
+
+	it := db.NewIterator()
+	defer it.Release()
+	for it.Next() {
+		value, err := restored.Get(it.Key())
+		if err != nil {
+			t.Fatalf("Key %s wasn't restored: %s", it.Key(), err)
+		}
+		if !bytes.Equal(value, it.Value()) {
+			t.Fatalf("Key %s was restored with value %s but should have been %s", it.Key(), value, it.Value())
+		}
+	}
+
+	if _, err := Restore(bytes.NewReader(backup.Bytes()), restored); err != errNotEmpty {
+		t.Fatalf("Should have refused to restore to a database that isn't empty")
+	}
+}
+
+func TestRestoreTruncated(t *testing.T) {
+	db := memdb.New()
+	if err := db.Put([]byte("hello"), []byte("world")); err != nil {
+		t.Fatal(err)
+	}
+	backup := bytes.Buffer{}
+	if _, err := Write(&backup, db); err != nil {
+		t.Fatal(err)
+	}
+
+	truncated := backup.Bytes()[:backup.Len()-1]
+	if _, err := Verify(bytes.NewReader(truncated)); err == nil {
+		t.Fatalf("Should have failed to verify a truncated backup")
+	}
+	restored := memdb.New()
+	if _, err := Restore(bytes.NewReader(truncated), restored); err == nil {
+		t.Fatalf("Should have failed to restore a truncated backup")
+	}
+	if incomplete, err := Incomplete(restored); err != nil {
+		t.Fatal(err)
+	} else if !incomplete {
+		t.Fatalf("A failed restore should leave the database incomplete")
+	}
+
+	// Restoring again replaces what the failed restore wrote
+	if numKeys, err := Verify(bytes.NewReader(backup.Bytes())); err != nil {
+		t.Fatal(err)
+	} else if numKeys != 1 {
+		t.Fatalf("Should have verified 1 key but verified %d", numKeys)
+	}
+	if _, err := Restore(bytes.NewReader(backup.Bytes()), restored); err != nil {
+		t.Fatal(err)
+	}
+	if incomplete, err := Incomplete(restored); err != nil {
+		t.Fatal(err)
+	} else if incomplete {
+		t.Fatalf("A completed restore shouldn't leave the database incomplete")
+	}
+	if value, err := restored.Get([]byte("hello")); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(value, []byte("world")) {
+		t.Fatalf("Key hello was restored with value %s but should have been world", value)
+	}
+}
+
+func TestRestoreNotBackup(t *testing.T) {
+	notBackup := bytes.Buffer{}
+	w := gzip.NewWriter(&notBackup)
+	if _, err := w.Write([]byte("not a backup of a database")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Restore(bytes.NewReader(notBackup.Bytes()), memdb.New()); err != errNotBackup {
+		t.Fatalf("Should have failed with %s but failed with %v", errNotBackup, err)
+	}
+}
@@ -0,0 +1,287 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cachedb
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/ava-labs/gecko/cache"
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/database/nodb"
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/utils/hashing"
+)
+
+// Database caches the values read from a database in memory, along with the
+// keys the database doesn't have. Writes go through to the database, and
+// update the cache once they're done. Iterators read from the database, which
+// has every write, so they don't need the cache.
+type Database struct {
+	lock  sync.RWMutex
+	cache cache.LRU
+	db    database.Database
+}
+
+// New returns a database that caches up to [maxBytes] of the keys and values
+// of [db]
+func New(maxBytes int, db database.Database) *Database {
+	return &Database{
+		cache: cache.LRU{MaxBytes: maxBytes},
+		db:    db,
+	}
+}
+
+// Has implements the Database interface
+func (db *Database) Has(key []byte) (bool, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.db == nil {
+		return false, database.ErrClosed
+	}
+	if entry, ok := db.lookup(key); ok {
+		return entry.exists, nil
+	}
+
+	has, err := db.db.Has(key)
+	if err == nil && !has {
+		// The value of a key that exists isn't known, so only the keys that
+		// don't exist are cached
+		db.put(key, nil, false)
+	}
+	return has, err
+}
+
+// Get implements the Database interface
+func (db *Database) Get(key []byte) ([]byte, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.db == nil {
+		return nil, database.ErrClosed
+	}
+	if entry, ok := db.lookup(key); ok {
+		if !entry.exists {
+			return nil, database.ErrNotFound
+		}
+		return copyBytes(entry.value), nil
+	}
+
+	value, err := db.db.Get(key)
+	switch err {
+	case nil:
+		db.put(key, value, true)
+	case database.ErrNotFound:
+		db.put(key, nil, false)
+	}
+	return value, err
+}
+
+// Put implements the Database interface
+func (db *Database) Put(key, value []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.db == nil {
+		return database.ErrClosed
+	}
+	if err := db.db.Put(key, value); err != nil {
+		db.cache.Evict(cacheKey(key))
+		return err
+	}
+	db.put(key, value, true)
+	return nil
+}
+
+// Delete implements the Database interface
+func (db *Database) Delete(key []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.db == nil {
+		return database.ErrClosed
+	}
+	if err := db.db.Delete(key); err != nil {
+		db.cache.Evict(cacheKey(key))
+		return err
+	}
+	db.put(key, nil, false)
+	return nil
+}
+
+// NewBatch implements the Database interface
+func (db *Database) NewBatch() database.Batch {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.db == nil {
+		return &nodb.Batch{}
+	}
+	return &batch{
+		Batch: db.db.NewBatch(),
+		db:    db,
+	}
+}
+
+// NewIterator implements the Database interface
+func (db *Database) NewIterator() database.Iterator {
+	return db.NewIteratorWithStartAndPrefix(nil, nil)
+}
+
+// NewIteratorWithStart implements the Database interface
+func (db *Database) NewIteratorWithStart(start []byte) database.Iterator {
+	return db.NewIteratorWithStartAndPrefix(start, nil)
+}
+
+// NewIteratorWithPrefix implements the Database interface
+func (db *Database) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return db.NewIteratorWithStartAndPrefix(nil, prefix)
+}
+
+// NewIteratorWithStartAndPrefix implements the Database interface
+func (db *Database) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.db == nil {
+		return &nodb.Iterator{Err: database.ErrClosed}
+	}
+	return db.db.NewIteratorWithStartAndPrefix(start, prefix)
+}
+
+// Stat implements the Database interface
+func (db *Database) Stat(stat string) (string, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.db == nil {
+		return "", database.ErrClosed
+	}
+	return db.db.Stat(stat)
+}
+
+// Compact implements the Database interface
+func (db *Database) Compact(start, limit []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.db == nil {
+		return database.ErrClosed
+	}
+	return db.db.Compact(start, limit)
+}
+
+// Close implements the Database interface
+func (db *Database) Close() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.db == nil {
+		return database.ErrClosed
+	}
+	db.cache.Flush()
+	db.db = nil
+	return nil
+}
+
+// lookup returns the cached entry of [key], if there is one
+func (db *Database) lookup(key []byte) (*entry, bool) {
+	cached, ok := db.cache.Get(cacheKey(key))
+	if !ok {
+		return nil, false
+	}
+	entry := cached.(*entry)
+	// Keys are cached by their hash, so a key whose hash collides with another
+	// key's isn't found
+	return entry, bytes.Equal(entry.key, key)
+}
+
+// put caches the entry of [key]. The key and value are copied, as the caller
+// may modify them.
+func (db *Database) put(key, value []byte, exists bool) {
+	db.cache.Put(cacheKey(key), &entry{
+		key:    copyBytes(key),
+		value:  copyBytes(value),
+		exists: exists,
+	})
+}
+
+// entry is the result of reading a key from the database. [exists] is false if
+// the database doesn't have the key.
+type entry struct {
+	key, value []byte
+	exists     bool
+}
+
+// Size implements the cache.Sizer interface
+func (e *entry) Size() int { return len(e.key) + len(e.value) }
+
+type keyValue struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+type batch struct {
+	database.Batch
+	db     *Database
+	writes []keyValue
+}
+
+// Put implements the Batch interface
+func (b *batch) Put(key, value []byte) error {
+	b.writes = append(b.writes, keyValue{copyBytes(key), copyBytes(value), false})
+	return b.Batch.Put(key, value)
+}
+
+// Delete implements the Batch interface
+func (b *batch) Delete(key []byte) error {
+	b.writes = append(b.writes, keyValue{copyBytes(key), nil, true})
+	return b.Batch.Delete(key)
+}
+
+// Write flushes any accumulated data to the database, and then caches it. If
+// the write fails, the keys of the batch are evicted from the cache, as it
+// isn't known which of them were written.
+func (b *batch) Write() error {
+	b.db.lock.Lock()
+	defer b.db.lock.Unlock()
+
+	if b.db.db == nil {
+		return database.ErrClosed
+	}
+
+	err := b.Batch.Write()
+	for _, keyValue := range b.writes {
+		switch {
+		case err != nil:
+			b.db.cache.Evict(cacheKey(keyValue.key))
+		case keyValue.delete:
+			b.db.put(keyValue.key, nil, false)
+		default:
+			b.db.put(keyValue.key, keyValue.value, true)
+		}
+	}
+	return err
+}
+
+// Reset resets the batch for reuse.
+func (b *batch) Reset() {
+	b.writes = b.writes[:0]
+	b.Batch.Reset()
+}
+
+// Inner returns itself, as writing to the database directly would leave stale
+// values in the cache
+func (b *batch) Inner() database.Batch { return b }
+
+// cacheKey returns the key [key] is cached under
+func cacheKey(key []byte) ids.ID { return ids.NewID(hashing.ComputeHash256Array(key)) }
+
+func copyBytes(bytes []byte) []byte {
+	copiedBytes := make([]byte, len(bytes))
+	copy(copiedBytes, bytes)
+	return copiedBytes
+}
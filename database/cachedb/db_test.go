@@ -0,0 +1,214 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cachedb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/database/memdb"
+)
+
+func TestInterface(t *testing.T) {
+	for _, test := range database.Tests {
+		test(t, New(1<<20, memdb.New()))
+		// Only one small value fits in this cache
+		test(t, New(64, memdb.New()))
+	}
+}
+
+func TestNegativeLookup(t *testing.T) {
+	baseDB := memdb.New()
+	db := New(1<<20, baseDB)
+
+	key := []byte("hello")
+	value := []byte("world")
+
+	if _, err := db.Get(key); err != database.ErrNotFound {
+		t.Fatalf("Expected %s, got %v", database.ErrNotFound, err)
+	}
+
+	// The missing key is cached, so writes that bypass the cache aren't seen
+	if err := baseDB.Put(key, value); err != nil {
+		t.Fatal(err)
+	}
+	if has, err := db.Has(key); err != nil {
+		t.Fatal(err)
+	} else if has {
+		t.Fatalf("db.Has should have returned the cached lookup")
+	}
+
+	if err := db.Put(key, value); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := db.Get(key); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(v, value) {
+		t.Fatalf("db.Get(%s) returned %s but should have returned %s", key, v, value)
+	}
+}
+
+func TestCachedValuesAreCopied(t *testing.T) {
+	db := New(1<<20, memdb.New())
+
+	key := []byte("hello")
+	value := []byte("world")
+
+	if err := db.Put(key, value); err != nil {
+		t.Fatal(err)
+	}
+	value[0] = 'W'
+
+	v, err := db.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(v, []byte("world")) {
+		t.Fatalf("Modifying the put value changed the cached value to %s", v)
+	}
+	v[0] = 'W'
+
+	if v, err := db.Get(key); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(v, []byte("world")) {
+		t.Fatalf("Modifying the returned value changed the cached value to %s", v)
+	}
+}
+
+func TestBatchUpdatesCache(t *testing.T) {
+	baseDB := memdb.New()
+	db := New(1<<20, baseDB)
+
+	key1 := []byte("hello1")
+	key2 := []byte("hello2")
+	value1 := []byte("world1")
+	value2 := []byte("world2")
+
+	if err := db.Put(key1, value1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Get(key2); err != database.ErrNotFound {
+		t.Fatalf("Expected %s, got %v", database.ErrNotFound, err)
+	}
+
+	batch := db.NewBatch()
+	if err := batch.Delete(key1); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Put(key2, value1); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Put(key2, value2); err != nil {
+		t.Fatal(err)
+	}
+
+	// The cache isn't changed until the batch is written
+	if v, err := db.Get(key1); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(v, value1) {
+		t.Fatalf("db.Get(%s) returned %s but should have returned %s", key1, v, value1)
+	}
+
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	if has, err := db.Has(key1); err != nil {
+		t.Fatal(err)
+	} else if has {
+		t.Fatalf("db.Has should have returned false after the batch deleted the key")
+	}
+	if v, err := db.Get(key2); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(v, value2) {
+		t.Fatalf("db.Get(%s) returned %s but should have returned %s", key2, v, value2)
+	}
+
+	// The batch's writes are cached, so they're read without the database
+	if err := baseDB.Put(key1, value1); err != nil {
+		t.Fatal(err)
+	}
+	if has, err := db.Has(key1); err != nil {
+		t.Fatal(err)
+	} else if has {
+		t.Fatalf("db.Has should have returned the cached lookup")
+	}
+}
+
+func TestFailedBatchEvicts(t *testing.T) {
+	baseDB := memdb.New()
+	db := New(1<<20, baseDB)
+
+	key := []byte("hello")
+	value := []byte("world")
+
+	if err := db.Put(key, value); err != nil {
+		t.Fatal(err)
+	}
+
+	batch := db.NewBatch()
+	if err := batch.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing the base database makes the batch fail
+	if err := baseDB.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Write(); err == nil {
+		t.Fatalf("batch.Write should have failed on a closed database")
+	}
+
+	if _, err := db.Get(key); err != database.ErrClosed {
+		t.Fatalf("db.Get should have read the closed database, but returned %v", err)
+	}
+}
+
+func TestIteratorSeesWrites(t *testing.T) {
+	db := New(1<<20, memdb.New())
+
+	key := []byte("hello")
+	value1 := []byte("world1")
+	value2 := []byte("world2")
+
+	if err := db.Put(key, value1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Get(key); err != nil {
+		t.Fatal(err)
+	}
+	batch := db.NewBatch()
+	if err := batch.Put(key, value2); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	iterator := db.NewIterator()
+	defer iterator.Release()
+
+	if !iterator.Next() {
+		t.Fatalf("iterator.Next Returned: %v ; Expected: %v", false, true)
+	} else if !bytes.Equal(iterator.Value(), value2) {
+		t.Fatalf("iterator.Value Returned: %s ; Expected: %s", iterator.Value(), value2)
+	} else if iterator.Next() {
+		t.Fatalf("iterator.Next Returned: %v ; Expected: %v", true, false)
+	}
+}
+
+func TestNewBatchClosed(t *testing.T) {
+	db := New(1<<20, memdb.New())
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	batch := db.NewBatch()
+	if err := batch.Put([]byte("hello"), []byte("world")); err != database.ErrClosed {
+		t.Fatalf("Expected %s, got %v", database.ErrClosed, err)
+	}
+	if err := batch.Write(); err != database.ErrClosed {
+		t.Fatalf("Expected %s, got %v", database.ErrClosed, err)
+	}
+}
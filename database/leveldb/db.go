@@ -5,8 +5,10 @@ package leveldb
 
 import (
 	"bytes"
+	"sync"
 
 	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/database/nodb"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
 	"github.com/syndtr/goleveldb/leveldb/filter"
@@ -64,6 +66,20 @@ func New(file string, blockCacheSize, writeBufferSize, handleCap int) (*Database
 	return &Database{DB: db}, nil
 }
 
+// NewReadOnly returns a wrapped LevelDB object that can only be read from. The
+// database must already exist, and isn't recovered if it's corrupted, as that
+// would write to it.
+func NewReadOnly(file string) (*Database, error) {
+	db, err := leveldb.OpenFile(file, &opt.Options{
+		ReadOnly:       true,
+		ErrorIfMissing: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Database{DB: db}, nil
+}
+
 // Has returns if the key is set in the database
 func (db *Database) Has(key []byte) (bool, error) {
 	has, err := db.DB.Has(key, nil)
@@ -137,6 +153,15 @@ func (db *Database) Compact(start []byte, limit []byte) error {
 	return updateError(db.DB.CompactRange(util.Range{Start: start, Limit: limit}))
 }
 
+// NewSnapshot returns a snapshot of the current state of the database
+func (db *Database) NewSnapshot() (database.Snapshot, error) {
+	snap, err := db.DB.GetSnapshot()
+	if err != nil {
+		return nil, updateError(err)
+	}
+	return &snapshot{snap: snap}, nil
+}
+
 // Close implements the Database interface
 func (db *Database) Close() error { return updateError(db.DB.Close()) }
 
@@ -187,6 +212,85 @@ func (b *batch) Replay(w database.KeyValueWriter) error {
 // Inner returns itself
 func (b *batch) Inner() database.Batch { return b }
 
+// snapshot is a wrapper around a levelDB snapshot that fails once it's
+// released, as reading from a released levelDB snapshot panics
+type snapshot struct {
+	lock     sync.RWMutex
+	snap     *leveldb.Snapshot
+	released bool
+}
+
+// Has returns if the key is set in the snapshot
+func (s *snapshot) Has(key []byte) (bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.released {
+		return false, database.ErrClosed
+	}
+	has, err := s.snap.Has(key, nil)
+	return has, updateError(err)
+}
+
+// Get returns the value the key mapped to when the snapshot was taken
+func (s *snapshot) Get(key []byte) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.released {
+		return nil, database.ErrClosed
+	}
+	value, err := s.snap.Get(key, nil)
+	return value, updateError(err)
+}
+
+// NewIterator creates a lexicographically ordered iterator over the snapshot
+func (s *snapshot) NewIterator() database.Iterator { return s.newIter(new(util.Range)) }
+
+// NewIteratorWithStart creates a lexicographically ordered iterator over the
+// snapshot starting at the provided key
+func (s *snapshot) NewIteratorWithStart(start []byte) database.Iterator {
+	return s.newIter(&util.Range{Start: start})
+}
+
+// NewIteratorWithPrefix creates a lexicographically ordered iterator over the
+// snapshot ignoring keys that do not start with the provided prefix
+func (s *snapshot) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.newIter(util.BytesPrefix(prefix))
+}
+
+// NewIteratorWithStartAndPrefix creates a lexicographically ordered iterator
+// over the snapshot starting at start and ignoring keys that do not start with
+// the provided prefix
+func (s *snapshot) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	iterRange := util.BytesPrefix(prefix)
+	if bytes.Compare(start, prefix) == 1 {
+		iterRange.Start = start
+	}
+	return s.newIter(iterRange)
+}
+
+func (s *snapshot) newIter(iterRange *util.Range) database.Iterator {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.released {
+		return &nodb.Iterator{Err: database.ErrClosed}
+	}
+	return &iter{s.snap.NewIterator(iterRange, nil)}
+}
+
+// Release releases the snapshot
+func (s *snapshot) Release() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.released {
+		s.released = true
+		s.snap.Release()
+	}
+}
+
 type replayer struct {
 	writer database.KeyValueWriter
 	err    error
@@ -212,7 +316,7 @@ func (i *iter) Error() error { return updateError(i.Iterator.Error()) }
 
 func updateError(err error) error {
 	switch err {
-	case leveldb.ErrClosed:
+	case leveldb.ErrClosed, leveldb.ErrSnapshotReleased:
 		return database.ErrClosed
 	case leveldb.ErrNotFound:
 		return database.ErrNotFound
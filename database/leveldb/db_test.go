@@ -8,6 +8,8 @@ import (
 	"os"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/ava-labs/gecko/database"
 )
 
@@ -25,3 +27,100 @@ func TestInterface(t *testing.T) {
 		test(t, db)
 	}
 }
+
+func TestSnapshotInterface(t *testing.T) {
+	for i, test := range database.SnapshotTests {
+		folder := fmt.Sprintf("snapshot_db%d", i)
+
+		db, err := New(folder, 0, 0, 0)
+		if err != nil {
+			t.Fatalf("leveldb.New(%s, 0, 0, 0) errored with %s", folder, err)
+		}
+		defer os.RemoveAll(folder)
+		defer db.Close()
+
+		test(t, db)
+	}
+}
+
+func TestReadOnlyInterface(t *testing.T) {
+	open := func(dir string) (database.Database, error) { return New(dir, 0, 0, 0) }
+	openReadOnly := func(dir string) (database.Database, error) { return NewReadOnly(dir) }
+	for i, test := range database.ReadOnlyTests {
+		folder := fmt.Sprintf("read_only_db%d", i)
+		defer os.RemoveAll(folder)
+
+		test(t, folder, open, openReadOnly)
+	}
+}
+
+func TestCollector(t *testing.T) {
+	folder := "db_collector"
+	defer os.RemoveAll(folder)
+
+	db, err := New(folder, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.Put([]byte("hello"), []byte("world")); err != nil {
+		t.Fatal(err)
+	}
+	// Compacting writes the memory table to a level
+	if err := db.Compact(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(NewCollector("test", db)); err != nil {
+		t.Fatal(err)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tables := 0.
+	names := make(map[string]bool)
+	for _, family := range families {
+		names[family.GetName()] = true
+		if family.GetName() == "test_level_tables" {
+			for _, metric := range family.GetMetric() {
+				tables += metric.GetGauge().GetValue()
+			}
+		}
+	}
+	if tables != 1 {
+		t.Fatalf("Should have reported 1 table, but reported %f", tables)
+	}
+	for _, name := range []string{"test_level_size", "test_io_write", "test_alive_iterators"} {
+		if !names[name] {
+			t.Fatalf("Should have reported %s", name)
+		}
+	}
+}
+
+func TestParseLevels(t *testing.T) {
+	stats := "Compactions\n" +
+		" Level |   Tables   |    Size(MB)   |    Time(sec)  |    Read(MB)   |   Write(MB)\n" +
+		"-------+------------+---------------+---------------+---------------+---------------\n" +
+		"   0   |          1 |       0.00012 |       0.00100 |       0.00000 |       0.00012\n" +
+		"   2   |          3 |       2.00000 |       1.50000 |       4.00000 |       2.00000\n"
+
+	rows, err := parseLevels(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Should have parsed 2 levels, but parsed %d", len(rows))
+	}
+	if row := rows[1]; row.level != "2" || row.tables != 3 || row.size != 2*bytesPerMB ||
+		row.time != 1.5 || row.read != 4*bytesPerMB || row.write != 2*bytesPerMB {
+		t.Fatalf("Wrongly parsed level: %+v", row)
+	}
+
+	if _, err := parseLevels(stats + "   3   |          1\n"); err == nil {
+		t.Fatalf("Should have failed to parse a truncated row")
+	}
+}
@@ -0,0 +1,197 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package leveldb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// bytesPerMB is the number of bytes in the megabytes leveldb reports sizes in
+const bytesPerMB = 1 << 20
+
+type collector struct {
+	db *Database
+
+	levelTables, levelSize, levelCompactionTime,
+	levelCompactionRead, levelCompactionWrite,
+	ioRead, ioWrite,
+	writeDelays, writeDelayTime, writePaused,
+	aliveSnapshots, aliveIterators,
+	openedTables, blockCacheSize *prometheus.Desc
+}
+
+// NewCollector returns a collector of the internal statistics of [db], that
+// are read from its stats every time the metrics are gathered. This includes
+// the tables and compactions of each level of the database.
+func NewCollector(namespace string, db *Database) prometheus.Collector {
+	level := []string{"level"}
+	return &collector{
+		db: db,
+
+		levelTables: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "level_tables"),
+			"Number of tables in the level",
+			level, nil),
+		levelSize: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "level_size"),
+			"Size of the tables in the level in bytes",
+			level, nil),
+		levelCompactionTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "level_compaction_time"),
+			"Time spent compacting into the level in seconds",
+			level, nil),
+		levelCompactionRead: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "level_compaction_read"),
+			"Bytes read by compactions into the level",
+			level, nil),
+		levelCompactionWrite: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "level_compaction_write"),
+			"Bytes written by compactions into the level",
+			level, nil),
+		ioRead: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "io_read"),
+			"Bytes read from storage",
+			nil, nil),
+		ioWrite: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "io_write"),
+			"Bytes written to storage",
+			nil, nil),
+		writeDelays: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "write_delays"),
+			"Number of writes delayed by compactions",
+			nil, nil),
+		writeDelayTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "write_delay_time"),
+			"Time writes were delayed by compactions in seconds",
+			nil, nil),
+		writePaused: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "write_paused"),
+			"1 if writes are paused until level 0 is compacted, 0 otherwise",
+			nil, nil),
+		aliveSnapshots: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "alive_snapshots"),
+			"Number of snapshots that haven't been released",
+			nil, nil),
+		aliveIterators: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "alive_iterators"),
+			"Number of iterators that haven't been released",
+			nil, nil),
+		openedTables: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "opened_tables"),
+			"Number of tables kept open",
+			nil, nil),
+		blockCacheSize: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "block_cache_size"),
+			"Size of the cached blocks in bytes",
+			nil, nil),
+	}
+}
+
+// Describe implements the prometheus.Collector interface
+func (c *collector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- c.levelTables
+	descs <- c.levelSize
+	descs <- c.levelCompactionTime
+	descs <- c.levelCompactionRead
+	descs <- c.levelCompactionWrite
+	descs <- c.ioRead
+	descs <- c.ioWrite
+	descs <- c.writeDelays
+	descs <- c.writeDelayTime
+	descs <- c.writePaused
+	descs <- c.aliveSnapshots
+	descs <- c.aliveIterators
+	descs <- c.openedTables
+	descs <- c.blockCacheSize
+}
+
+// Collect implements the prometheus.Collector interface. Nothing is collected
+// once the database is closed.
+func (c *collector) Collect(metrics chan<- prometheus.Metric) {
+	stats := leveldb.DBStats{}
+	if err := c.db.DB.Stats(&stats); err != nil {
+		return
+	}
+	levels, err := c.db.Stat("leveldb.stats")
+	if err != nil {
+		return
+	}
+	rows, err := parseLevels(levels)
+	if err != nil {
+		return
+	}
+
+	for _, row := range rows {
+		metrics <- prometheus.MustNewConstMetric(c.levelTables, prometheus.GaugeValue, row.tables, row.level)
+		metrics <- prometheus.MustNewConstMetric(c.levelSize, prometheus.GaugeValue, row.size, row.level)
+		metrics <- prometheus.MustNewConstMetric(c.levelCompactionTime, prometheus.CounterValue, row.time, row.level)
+		metrics <- prometheus.MustNewConstMetric(c.levelCompactionRead, prometheus.CounterValue, row.read, row.level)
+		metrics <- prometheus.MustNewConstMetric(c.levelCompactionWrite, prometheus.CounterValue, row.write, row.level)
+	}
+
+	writePaused := 0.
+	if stats.WritePaused {
+		writePaused = 1
+	}
+	metrics <- prometheus.MustNewConstMetric(c.ioRead, prometheus.CounterValue, float64(stats.IORead))
+	metrics <- prometheus.MustNewConstMetric(c.ioWrite, prometheus.CounterValue, float64(stats.IOWrite))
+	metrics <- prometheus.MustNewConstMetric(c.writeDelays, prometheus.CounterValue, float64(stats.WriteDelayCount))
+	metrics <- prometheus.MustNewConstMetric(c.writeDelayTime, prometheus.CounterValue, stats.WriteDelayDuration.Seconds())
+	metrics <- prometheus.MustNewConstMetric(c.writePaused, prometheus.GaugeValue, writePaused)
+	metrics <- prometheus.MustNewConstMetric(c.aliveSnapshots, prometheus.GaugeValue, float64(stats.AliveSnapshots))
+	metrics <- prometheus.MustNewConstMetric(c.aliveIterators, prometheus.GaugeValue, float64(stats.AliveIterators))
+	metrics <- prometheus.MustNewConstMetric(c.openedTables, prometheus.GaugeValue, float64(stats.OpenedTablesCount))
+	metrics <- prometheus.MustNewConstMetric(c.blockCacheSize, prometheus.GaugeValue, float64(stats.BlockCacheSize))
+}
+
+// levelStats is a row of the "leveldb.stats" property
+type levelStats struct {
+	level                           string
+	tables, size, time, read, write float64
+}
+
+// parseLevels parses the rows of the "leveldb.stats" property. Each row is a
+// level that has tables or was compacted into. Its columns are the level, its
+// number of tables, their size, and the time, reads and writes of compactions
+// into the level. Sizes are in megabytes, and are returned in bytes.
+func parseLevels(stats string) ([]levelStats, error) {
+	lines := strings.Split(stats, "\n")
+	// The first three lines are the title and header of the table
+	if len(lines) < 3 {
+		return nil, fmt.Errorf("stats are missing their header: %q", stats)
+	}
+
+	rows := []levelStats(nil)
+	for _, line := range lines[3:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		columns := strings.Split(line, "|")
+		if len(columns) != 6 {
+			return nil, fmt.Errorf("stats row should have 6 columns: %q", line)
+		}
+		values := make([]float64, len(columns))
+		for i, column := range columns {
+			value, err := strconv.ParseFloat(strings.TrimSpace(column), 64)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't parse stats row %q: %w", line, err)
+			}
+			values[i] = value
+		}
+		rows = append(rows, levelStats{
+			level:  strings.TrimSpace(columns[0]),
+			tables: values[1],
+			size:   values[2] * bytesPerMB,
+			time:   values[3],
+			read:   values[4] * bytesPerMB,
+			write:  values[5] * bytesPerMB,
+		})
+	}
+	return rows, nil
+}
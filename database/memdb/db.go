@@ -141,6 +141,40 @@ func (db *Database) Stat(property string) (string, error) { return "", database.
 // Compact implements the Database interface
 func (db *Database) Compact(start []byte, limit []byte) error { return nil }
 
+// NewSnapshot implements the Snapshotter interface. The snapshot is a copy of
+// the database.
+func (db *Database) NewSnapshot() (database.Snapshot, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.db == nil {
+		return nil, database.ErrClosed
+	}
+	snap := NewWithSize(len(db.db))
+	for key, value := range db.db {
+		// Values are never modified in place, so they don't need to be copied
+		snap.db[key] = value
+	}
+	return &snapshot{snap}, nil
+}
+
+// snapshot is a read-only copy of a database
+type snapshot struct{ db *Database }
+
+func (s *snapshot) Has(key []byte) (bool, error)   { return s.db.Has(key) }
+func (s *snapshot) Get(key []byte) ([]byte, error) { return s.db.Get(key) }
+func (s *snapshot) NewIterator() database.Iterator { return s.db.NewIterator() }
+func (s *snapshot) NewIteratorWithStart(start []byte) database.Iterator {
+	return s.db.NewIteratorWithStart(start)
+}
+func (s *snapshot) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.db.NewIteratorWithPrefix(prefix)
+}
+func (s *snapshot) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	return s.db.NewIteratorWithStartAndPrefix(start, prefix)
+}
+func (s *snapshot) Release() { s.db.Close() }
+
 type keyValue struct {
 	key    []byte
 	value  []byte
@@ -0,0 +1,219 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package meterdb
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/utils/timer"
+)
+
+// Database tracks the amount of time each call to a database takes, and the
+// number of bytes read and written by those calls.
+type Database struct {
+	metrics
+	db    database.Database
+	clock timer.Clock
+}
+
+// New returns a database that measures the calls made to [db]. Its metrics are
+// registered in [registerer] under [namespace].
+func New(namespace string, registerer prometheus.Registerer, db database.Database) (*Database, error) {
+	meterDB := &Database{db: db}
+	return meterDB, meterDB.metrics.Initialize(namespace, registerer)
+}
+
+// Has implements the Database interface
+func (db *Database) Has(key []byte) (bool, error) {
+	start := db.clock.Time()
+	has, err := db.db.Has(key)
+	db.observe(db.has, start)
+	return has, err
+}
+
+// Get implements the Database interface
+func (db *Database) Get(key []byte) ([]byte, error) {
+	start := db.clock.Time()
+	value, err := db.db.Get(key)
+	db.observe(db.get, start)
+	db.getSize.Observe(float64(len(value)))
+	return value, err
+}
+
+// Put implements the Database interface
+func (db *Database) Put(key, value []byte) error {
+	start := db.clock.Time()
+	err := db.db.Put(key, value)
+	db.observe(db.put, start)
+	db.putSize.Observe(float64(len(key) + len(value)))
+	return err
+}
+
+// Delete implements the Database interface
+func (db *Database) Delete(key []byte) error {
+	start := db.clock.Time()
+	err := db.db.Delete(key)
+	db.observe(db.delete, start)
+	db.deleteSize.Observe(float64(len(key)))
+	return err
+}
+
+// NewBatch implements the Database interface
+func (db *Database) NewBatch() database.Batch {
+	start := db.clock.Time()
+	b := &batch{
+		batch: db.db.NewBatch(),
+		db:    db,
+	}
+	db.observe(db.newBatch, start)
+	return b
+}
+
+// NewIterator implements the Database interface
+func (db *Database) NewIterator() database.Iterator {
+	return db.NewIteratorWithStartAndPrefix(nil, nil)
+}
+
+// NewIteratorWithStart implements the Database interface
+func (db *Database) NewIteratorWithStart(start []byte) database.Iterator {
+	return db.NewIteratorWithStartAndPrefix(start, nil)
+}
+
+// NewIteratorWithPrefix implements the Database interface
+func (db *Database) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return db.NewIteratorWithStartAndPrefix(nil, prefix)
+}
+
+// NewIteratorWithStartAndPrefix implements the Database interface
+func (db *Database) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	startTime := db.clock.Time()
+	it := &iterator{
+		iterator: db.db.NewIteratorWithStartAndPrefix(start, prefix),
+		db:       db,
+	}
+	db.observe(db.newIterator, startTime)
+	return it
+}
+
+// Stat implements the Database interface
+func (db *Database) Stat(property string) (string, error) {
+	start := db.clock.Time()
+	stat, err := db.db.Stat(property)
+	db.observe(db.stat, start)
+	return stat, err
+}
+
+// Compact implements the Database interface
+func (db *Database) Compact(start, limit []byte) error {
+	startTime := db.clock.Time()
+	err := db.db.Compact(start, limit)
+	db.observe(db.compact, startTime)
+	return err
+}
+
+// Close implements the Database interface
+func (db *Database) Close() error {
+	start := db.clock.Time()
+	err := db.db.Close()
+	db.observe(db.close, start)
+	return err
+}
+
+// observe the time elapsed since [start] in [latency]
+func (db *Database) observe(latency prometheus.Histogram, start time.Time) {
+	latency.Observe(float64(db.clock.Time().Sub(start)))
+}
+
+type batch struct {
+	batch database.Batch
+	db    *Database
+}
+
+// Put implements the Batch interface
+func (b *batch) Put(key, value []byte) error {
+	start := b.db.clock.Time()
+	err := b.batch.Put(key, value)
+	b.db.observe(b.db.batchPut, start)
+	b.db.batchPutSize.Observe(float64(len(key) + len(value)))
+	return err
+}
+
+// Delete implements the Batch interface
+func (b *batch) Delete(key []byte) error {
+	start := b.db.clock.Time()
+	err := b.batch.Delete(key)
+	b.db.observe(b.db.batchDelete, start)
+	b.db.batchDeleteSize.Observe(float64(len(key)))
+	return err
+}
+
+// ValueSize implements the Batch interface
+func (b *batch) ValueSize() int { return b.batch.ValueSize() }
+
+// Write implements the Batch interface
+func (b *batch) Write() error {
+	start := b.db.clock.Time()
+	err := b.batch.Write()
+	b.db.observe(b.db.batchWrite, start)
+	b.db.batchWriteSize.Observe(float64(b.batch.ValueSize()))
+	return err
+}
+
+// Reset implements the Batch interface
+func (b *batch) Reset() {
+	start := b.db.clock.Time()
+	b.batch.Reset()
+	b.db.observe(b.db.batchReset, start)
+}
+
+// Replay implements the Batch interface
+func (b *batch) Replay(w database.KeyValueWriter) error {
+	start := b.db.clock.Time()
+	err := b.batch.Replay(w)
+	b.db.observe(b.db.batchReplay, start)
+	return err
+}
+
+// Inner returns the batch of the measured database, which isn't measured
+func (b *batch) Inner() database.Batch { return b.batch.Inner() }
+
+type iterator struct {
+	iterator database.Iterator
+	db       *Database
+}
+
+// Next implements the Iterator interface
+func (it *iterator) Next() bool {
+	start := it.db.clock.Time()
+	next := it.iterator.Next()
+	it.db.observe(it.db.iteratorNext, start)
+	if next {
+		it.db.iteratorSize.Observe(float64(len(it.iterator.Key()) + len(it.iterator.Value())))
+	}
+	return next
+}
+
+// Error implements the Iterator interface
+func (it *iterator) Error() error {
+	start := it.db.clock.Time()
+	err := it.iterator.Error()
+	it.db.observe(it.db.iteratorError, start)
+	return err
+}
+
+// Key implements the Iterator interface
+func (it *iterator) Key() []byte { return it.iterator.Key() }
+
+// Value implements the Iterator interface
+func (it *iterator) Value() []byte { return it.iterator.Value() }
+
+// Release implements the Iterator interface
+func (it *iterator) Release() {
+	start := it.db.clock.Time()
+	it.iterator.Release()
+	it.db.observe(it.db.iteratorRelease, start)
+}
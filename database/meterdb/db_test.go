@@ -0,0 +1,117 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package meterdb
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/database/memdb"
+)
+
+func TestInterface(t *testing.T) {
+	for _, test := range database.Tests {
+		db, err := New("", prometheus.NewRegistry(), memdb.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+		test(t, db)
+	}
+}
+
+// gather returns the number of observations, and their sum, of each histogram
+// registered in [registry]
+func gather(t *testing.T, registry *prometheus.Registry) (map[string]uint64, map[string]float64) {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]uint64)
+	sums := make(map[string]float64)
+	for _, family := range families {
+		histogram := family.GetMetric()[0].GetHistogram()
+		counts[family.GetName()] = histogram.GetSampleCount()
+		sums[family.GetName()] = histogram.GetSampleSum()
+	}
+	return counts, sums
+}
+
+func TestMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	db, err := New("test", registry, memdb.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Put([]byte("hello"), []byte("world")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Get([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Get([]byte("missing")); err != database.ErrNotFound {
+		t.Fatalf("expected %s, got %v", database.ErrNotFound, err)
+	}
+
+	batch := db.NewBatch()
+	if err := batch.Put([]byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Delete([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	it := db.NewIterator()
+	for it.Next() {
+	}
+	it.Release()
+
+	counts, sums := gather(t, registry)
+	expectedCounts := map[string]uint64{
+		"test_db_put":                1,
+		"test_db_get":                2,
+		"test_db_has":                0,
+		"test_db_new_batch":          1,
+		"test_db_batch_put":          1,
+		"test_db_batch_delete":       1,
+		"test_db_batch_write":        1,
+		"test_db_new_iterator":       1,
+		"test_db_iterator_next":      2,
+		"test_db_iterator_release":   1,
+		"test_db_iterator_next_size": 1,
+	}
+	for name, expected := range expectedCounts {
+		if count := counts[name]; count != expected {
+			t.Fatalf("%s should have %d observations, but has %d", name, expected, count)
+		}
+	}
+
+	expectedSums := map[string]float64{
+		"test_db_put_size":           10,
+		"test_db_get_size":           5,
+		"test_db_batch_put_size":     8,
+		"test_db_batch_delete_size":  5,
+		"test_db_iterator_next_size": 8,
+	}
+	for name, expected := range expectedSums {
+		if sum := sums[name]; sum != expected {
+			t.Fatalf("%s should sum to %f, but sums to %f", name, expected, sum)
+		}
+	}
+}
+
+func TestDuplicateNamespace(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	if _, err := New("test", registry, memdb.New()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New("test", registry, memdb.New()); err == nil {
+		t.Fatalf("should have failed to register the metrics twice")
+	}
+}
@@ -0,0 +1,99 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package meterdb
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// latencyBuckets are in nanoseconds, from 100ns to ~1.7s. Database calls
+	// are too fast to be measured in milliseconds, like timer.Buckets are.
+	latencyBuckets = prometheus.ExponentialBuckets(100, 4, 13)
+
+	// sizeBuckets are in bytes, from 16B to 64MiB
+	sizeBuckets = prometheus.ExponentialBuckets(16, 4, 12)
+)
+
+type metrics struct {
+	has, get, put, delete,
+	newBatch, newIterator,
+	stat, compact, close,
+	batchPut, batchDelete, batchWrite, batchReset, batchReplay,
+	iteratorNext, iteratorError, iteratorRelease prometheus.Histogram
+
+	getSize, putSize, deleteSize,
+	batchPutSize, batchDeleteSize, batchWriteSize,
+	iteratorSize prometheus.Histogram
+}
+
+// Initialize the metrics of the calls to a database. Each call is counted by
+// the histogram of its latency.
+func (m *metrics) Initialize(namespace string, registerer prometheus.Registerer) error {
+	latencies := []struct {
+		histogram *prometheus.Histogram
+		name      string
+	}{
+		{&m.has, "has"},
+		{&m.get, "get"},
+		{&m.put, "put"},
+		{&m.delete, "delete"},
+		{&m.newBatch, "new_batch"},
+		{&m.newIterator, "new_iterator"},
+		{&m.stat, "stat"},
+		{&m.compact, "compact"},
+		{&m.close, "close"},
+		{&m.batchPut, "batch_put"},
+		{&m.batchDelete, "batch_delete"},
+		{&m.batchWrite, "batch_write"},
+		{&m.batchReset, "batch_reset"},
+		{&m.batchReplay, "batch_replay"},
+		{&m.iteratorNext, "iterator_next"},
+		{&m.iteratorError, "iterator_error"},
+		{&m.iteratorRelease, "iterator_release"},
+	}
+	for _, latency := range latencies {
+		name := fmt.Sprintf("db_%s", latency.name)
+		*latency.histogram = prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      name,
+				Help:      fmt.Sprintf("Latency of %s calls to the database in nanoseconds", latency.name),
+				Buckets:   latencyBuckets,
+			})
+		if err := registerer.Register(*latency.histogram); err != nil {
+			return fmt.Errorf("Failed to register %s statistics due to %w", name, err)
+		}
+	}
+
+	sizes := []struct {
+		histogram *prometheus.Histogram
+		name      string
+		help      string
+	}{
+		{&m.getSize, "get", "Size of the values read by get calls in bytes"},
+		{&m.putSize, "put", "Size of the keys and values written by put calls in bytes"},
+		{&m.deleteSize, "delete", "Size of the keys deleted by delete calls in bytes"},
+		{&m.batchPutSize, "batch_put", "Size of the keys and values added to batches in bytes"},
+		{&m.batchDeleteSize, "batch_delete", "Size of the keys deleted in batches in bytes"},
+		{&m.batchWriteSize, "batch_write", "Size of the batches written in bytes"},
+		{&m.iteratorSize, "iterator_next", "Size of the keys and values read by iterators in bytes"},
+	}
+	for _, size := range sizes {
+		name := fmt.Sprintf("db_%s_size", size.name)
+		*size.histogram = prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      name,
+				Help:      size.help,
+				Buckets:   sizeBuckets,
+			})
+		if err := registerer.Register(*size.histogram); err != nil {
+			return fmt.Errorf("Failed to register %s statistics due to %w", name, err)
+		}
+	}
+	return nil
+}
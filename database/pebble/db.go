@@ -0,0 +1,590 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package pebble
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/database/nodb"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/bloom"
+)
+
+const (
+	// minBlockCacheSize is the minimum number of bytes to use for block caching
+	// in pebble.
+	minBlockCacheSize = 8 << 20
+
+	// minMemTableSize is the minimum number of bytes to use for memtables in
+	// pebble.
+	minMemTableSize = 8 << 20
+
+	// minHandleCap is the minimum number of files descriptors to cap pebble to
+	// use
+	minHandleCap = 16
+
+	// MetricsProperty is the property whose stat describes the levels,
+	// compactions and caches of the database
+	MetricsProperty = "pebble.metrics"
+)
+
+var (
+	errUnknownProperty = errors.New("unknown property")
+
+	// Writes aren't synced, as in leveldb. They're still written to the
+	// write-ahead log, so they survive the node crashing, but not the machine.
+	writeOptions = pebble.NoSync
+)
+
+// Database is a persistent key-value store built on pebble, a log-structured
+// merge tree in the style of RocksDB. Apart from basic data storage
+// functionality it also supports batch writes and iterating over the keyspace
+// in binary-alphabetical order.
+type Database struct {
+	// lock guards against using pebble after it was closed, which panics
+	lock   sync.RWMutex
+	db     *pebble.DB
+	closed bool
+
+	// iters and snapshots are the open iterators and snapshots, which are
+	// released when the database is closed
+	iters     map[*iter]struct{}
+	snapshots map[*snapshot]struct{}
+}
+
+// New returns a wrapped pebble object.
+func New(file string, blockCacheSize, memTableSize, handleCap int) (*Database, error) {
+	// Enforce minimums
+	if blockCacheSize < minBlockCacheSize {
+		blockCacheSize = minBlockCacheSize
+	}
+	if memTableSize < minMemTableSize {
+		memTableSize = minMemTableSize
+	}
+	if handleCap < minHandleCap {
+		handleCap = minHandleCap
+	}
+
+	cache := pebble.NewCache(int64(blockCacheSize))
+	defer cache.Unref()
+
+	return open(file, &pebble.Options{
+		Cache:        cache,
+		MemTableSize: uint64(memTableSize),
+		MaxOpenFiles: handleCap,
+		Levels: []pebble.LevelOptions{{
+			FilterPolicy: bloom.FilterPolicy(10),
+		}},
+	})
+}
+
+// NewReadOnly returns a wrapped pebble object that can only be read from. The
+// database must already exist.
+func NewReadOnly(file string) (*Database, error) {
+	return open(file, &pebble.Options{
+		ReadOnly:         true,
+		ErrorIfNotExists: true,
+	})
+}
+
+func open(file string, options *pebble.Options) (*Database, error) {
+	db, err := pebble.Open(file, options)
+	if err != nil {
+		return nil, err
+	}
+	return &Database{
+		db:        db,
+		iters:     make(map[*iter]struct{}),
+		snapshots: make(map[*snapshot]struct{}),
+	}, nil
+}
+
+// Has returns if the key is set in the database
+func (db *Database) Has(key []byte) (bool, error) {
+	_, err := db.Get(key)
+	switch err {
+	case nil:
+		return true, nil
+	case database.ErrNotFound:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// Get returns the value the key maps to in the database
+func (db *Database) Get(key []byte) ([]byte, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return nil, database.ErrClosed
+	}
+	return get(db.db, key)
+}
+
+// Put sets the value of the provided key to the provided value
+func (db *Database) Put(key []byte, value []byte) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return database.ErrClosed
+	}
+	return updateError(db.db.Set(key, value, writeOptions))
+}
+
+// Delete removes the key from the database
+func (db *Database) Delete(key []byte) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return database.ErrClosed
+	}
+	return updateError(db.db.Delete(key, writeOptions))
+}
+
+// NewBatch creates a write/delete-only buffer that is atomically committed to
+// the database when write is called
+func (db *Database) NewBatch() database.Batch {
+	return &batch{
+		db:    db,
+		batch: db.db.NewBatch(),
+	}
+}
+
+// NewIterator creates a lexicographically ordered iterator over the database
+func (db *Database) NewIterator() database.Iterator {
+	return db.NewIteratorWithStartAndPrefix(nil, nil)
+}
+
+// NewIteratorWithStart creates a lexicographically ordered iterator over the
+// database starting at the provided key
+func (db *Database) NewIteratorWithStart(start []byte) database.Iterator {
+	return db.NewIteratorWithStartAndPrefix(start, nil)
+}
+
+// NewIteratorWithPrefix creates a lexicographically ordered iterator over the
+// database ignoring keys that do not start with the provided prefix
+func (db *Database) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return db.NewIteratorWithStartAndPrefix(nil, prefix)
+}
+
+// NewIteratorWithStartAndPrefix creates a lexicographically ordered iterator
+// over the database starting at start and ignoring keys that do not start with
+// the provided prefix
+func (db *Database) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.closed {
+		return &nodb.Iterator{Err: database.ErrClosed}
+	}
+	return db.newIter(db.db, start, prefix)
+}
+
+// newIter returns an iterator over [reader] starting at [start] and ignoring
+// keys that do not start with [prefix]. Assumes the database's lock is held.
+func (db *Database) newIter(reader pebble.Reader, start, prefix []byte) database.Iterator {
+	options := &pebble.IterOptions{
+		LowerBound: prefix,
+		UpperBound: prefixLimit(prefix),
+	}
+	if bytes.Compare(start, prefix) == 1 {
+		options.LowerBound = start
+	}
+	it, err := reader.NewIter(options)
+	if err != nil {
+		return &nodb.Iterator{Err: updateError(err)}
+	}
+	i := &iter{
+		db:   db,
+		iter: it,
+	}
+	db.iters[i] = struct{}{}
+	return i
+}
+
+// Stat returns a particular internal stat of the database. The only supported
+// property is MetricsProperty.
+func (db *Database) Stat(property string) (string, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	switch {
+	case db.closed:
+		return "", database.ErrClosed
+	case property != MetricsProperty:
+		return "", errUnknownProperty
+	default:
+		return db.db.Metrics().String(), nil
+	}
+}
+
+// Compact the underlying DB for the given key range.
+// Specifically, deleted and overwritten versions are discarded,
+// and the data is rearranged to reduce the cost of operations
+// needed to access the data. This operation should typically only
+// be invoked by users who understand the underlying implementation.
+//
+// A nil start is treated as a key before all keys in the DB.
+// And a nil limit is treated as a key after all keys in the DB.
+// Therefore if both are nil then it will compact entire DB.
+func (db *Database) Compact(start []byte, limit []byte) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return database.ErrClosed
+	}
+
+	// Pebble requires an explicit limit, so the key after the last key is used
+	if limit == nil {
+		it, err := db.db.NewIter(&pebble.IterOptions{})
+		if err != nil {
+			return updateError(err)
+		}
+		if it.Last() {
+			limit = append(copyBytes(it.Key()), 0)
+		}
+		if err := it.Close(); err != nil {
+			return updateError(err)
+		}
+		if limit == nil {
+			// The database is empty
+			return nil
+		}
+	}
+	if bytes.Compare(start, limit) >= 0 {
+		return nil
+	}
+	return updateError(db.db.Compact(start, limit, true /*=parallelize*/))
+}
+
+// NewSnapshot returns a snapshot of the current state of the database
+func (db *Database) NewSnapshot() (database.Snapshot, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.closed {
+		return nil, database.ErrClosed
+	}
+	s := &snapshot{
+		db:   db,
+		snap: db.db.NewSnapshot(),
+	}
+	db.snapshots[s] = struct{}{}
+	return s, nil
+}
+
+// Close implements the Database interface
+func (db *Database) Close() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.closed {
+		return database.ErrClosed
+	}
+	db.closed = true
+
+	// Pebble refuses to close while iterators or snapshots are open
+	for i := range db.iters {
+		i.release()
+	}
+	for s := range db.snapshots {
+		s.release()
+	}
+	return updateError(db.db.Close())
+}
+
+// snapshot is a wrapper around a pebble snapshot that fails once it's released
+// or the database is closed
+type snapshot struct {
+	db       *Database
+	snap     *pebble.Snapshot
+	released bool
+}
+
+// Has returns if the key is set in the snapshot
+func (s *snapshot) Has(key []byte) (bool, error) {
+	_, err := s.Get(key)
+	switch err {
+	case nil:
+		return true, nil
+	case database.ErrNotFound:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// Get returns the value the key mapped to when the snapshot was taken
+func (s *snapshot) Get(key []byte) ([]byte, error) {
+	s.db.lock.RLock()
+	defer s.db.lock.RUnlock()
+
+	if s.db.closed || s.released {
+		return nil, database.ErrClosed
+	}
+	return get(s.snap, key)
+}
+
+// NewIterator creates a lexicographically ordered iterator over the snapshot
+func (s *snapshot) NewIterator() database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(nil, nil)
+}
+
+// NewIteratorWithStart creates a lexicographically ordered iterator over the
+// snapshot starting at the provided key
+func (s *snapshot) NewIteratorWithStart(start []byte) database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(start, nil)
+}
+
+// NewIteratorWithPrefix creates a lexicographically ordered iterator over the
+// snapshot ignoring keys that do not start with the provided prefix
+func (s *snapshot) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(nil, prefix)
+}
+
+// NewIteratorWithStartAndPrefix creates a lexicographically ordered iterator
+// over the snapshot starting at start and ignoring keys that do not start with
+// the provided prefix
+func (s *snapshot) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	s.db.lock.Lock()
+	defer s.db.lock.Unlock()
+
+	if s.db.closed || s.released {
+		return &nodb.Iterator{Err: database.ErrClosed}
+	}
+	return s.db.newIter(s.snap, start, prefix)
+}
+
+// Release releases the snapshot
+func (s *snapshot) Release() {
+	s.db.lock.Lock()
+	defer s.db.lock.Unlock()
+
+	s.release()
+}
+
+// release assumes the database's lock is held
+func (s *snapshot) release() {
+	if s.released {
+		return
+	}
+	s.released = true
+	// Closing a snapshot only fails if it was already closed
+	s.snap.Close()
+	delete(s.db.snapshots, s)
+}
+
+// batch is a wrapper around a pebble batch to contain sizes.
+type batch struct {
+	db    *Database
+	batch *pebble.Batch
+	size  int
+}
+
+// Put the value into the batch for later writing
+func (b *batch) Put(key, value []byte) error {
+	b.size += len(value)
+	return updateError(b.batch.Set(key, value, nil))
+}
+
+// Delete the key during writing
+func (b *batch) Delete(key []byte) error {
+	b.size++
+	return updateError(b.batch.Delete(key, nil))
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *batch) ValueSize() int { return b.size }
+
+// Write flushes any accumulated data to disk.
+func (b *batch) Write() error {
+	b.db.lock.RLock()
+	defer b.db.lock.RUnlock()
+
+	if b.db.closed {
+		return database.ErrClosed
+	}
+
+	// Pebble batches can only be committed once, and large batches are
+	// cleared when committed, so a copy is committed to allow the batch to be
+	// rewritten and replayed
+	commit := b.db.db.NewBatchWithSize(len(b.batch.Repr()))
+	defer commit.Close()
+
+	if err := commit.Apply(b.batch, nil); err != nil {
+		return updateError(err)
+	}
+	return updateError(commit.Commit(writeOptions))
+}
+
+// Reset resets the batch for reuse.
+func (b *batch) Reset() {
+	b.batch.Reset()
+	b.size = 0
+}
+
+// Replay the batch contents.
+func (b *batch) Replay(w database.KeyValueWriter) error {
+	reader := b.batch.Reader()
+	for {
+		kind, key, value, ok, err := reader.Next()
+		switch {
+		case err != nil:
+			return updateError(err)
+		case !ok:
+			return nil
+		}
+
+		switch kind {
+		case pebble.InternalKeyKindSet:
+			err = w.Put(key, value)
+		case pebble.InternalKeyKindDelete:
+			err = w.Delete(key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Inner returns itself
+func (b *batch) Inner() database.Batch { return b }
+
+// iter is a wrapper around a pebble iterator that starts before the first key,
+// and that fails once the database is closed
+type iter struct {
+	db   *Database
+	iter *pebble.Iterator
+
+	started, released bool
+	err               error
+	key, value        []byte
+}
+
+// Next moves the iterator to the next key/value pair
+func (i *iter) Next() bool {
+	i.db.lock.RLock()
+	defer i.db.lock.RUnlock()
+
+	i.key = nil
+	i.value = nil
+	switch {
+	case i.db.closed:
+		i.err = database.ErrClosed
+		return false
+	case i.released:
+		return false
+	}
+
+	valid := false
+	if i.started {
+		valid = i.iter.Next()
+	} else {
+		valid = i.iter.First()
+		i.started = true
+	}
+	if !valid {
+		return false
+	}
+
+	// The key and value are copied, as they're only valid until the iterator
+	// moves, which happens when it's released
+	i.key = copyBytes(i.iter.Key())
+	value, err := i.iter.ValueAndErr()
+	if err != nil {
+		i.key = nil
+		i.err = err
+		return false
+	}
+	i.value = copyBytes(value)
+	return true
+}
+
+// Error returns any accumulated error
+func (i *iter) Error() error {
+	i.db.lock.RLock()
+	defer i.db.lock.RUnlock()
+
+	if i.err != nil || i.released {
+		return updateError(i.err)
+	}
+	return updateError(i.iter.Error())
+}
+
+// Key returns the key of the current key/value pair
+func (i *iter) Key() []byte { return i.key }
+
+// Value returns the value of the current key/value pair
+func (i *iter) Value() []byte { return i.value }
+
+// Release releases the iterator
+func (i *iter) Release() {
+	i.db.lock.Lock()
+	defer i.db.lock.Unlock()
+
+	i.release()
+}
+
+// release assumes the database's lock is held
+func (i *iter) release() {
+	if i.released {
+		return
+	}
+	i.released = true
+	if err := i.iter.Close(); err != nil && i.err == nil {
+		i.err = err
+	}
+	delete(i.db.iters, i)
+}
+
+// prefixLimit returns the smallest key that's larger than every key starting
+// with [prefix], or nil if there is none
+func prefixLimit(prefix []byte) []byte {
+	limit := copyBytes(prefix)
+	for i := len(limit) - 1; i >= 0; i-- {
+		if limit[i] != 0xff {
+			limit[i]++
+			return limit[:i+1]
+		}
+	}
+	return nil
+}
+
+// get the value [key] maps to in [reader]
+func get(reader pebble.Reader, key []byte) ([]byte, error) {
+	value, closer, err := reader.Get(key)
+	if err != nil {
+		return nil, updateError(err)
+	}
+	// The value is only valid until the closer is closed
+	value = copyBytes(value)
+	return value, closer.Close()
+}
+
+func copyBytes(bytes []byte) []byte {
+	copiedBytes := make([]byte, len(bytes))
+	copy(copiedBytes, bytes)
+	return copiedBytes
+}
+
+func updateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, pebble.ErrClosed):
+		return database.ErrClosed
+	case errors.Is(err, pebble.ErrNotFound):
+		return database.ErrNotFound
+	default:
+		return err
+	}
+}
@@ -0,0 +1,53 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package pebble
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/ava-labs/gecko/database"
+)
+
+func TestInterface(t *testing.T) {
+	for i, test := range database.Tests {
+		folder := fmt.Sprintf("db%d", i)
+
+		db, err := New(folder, 0, 0, 0)
+		if err != nil {
+			t.Fatalf("pebble.New(%s, 0, 0, 0) errored with %s", folder, err)
+		}
+		defer os.RemoveAll(folder)
+		defer db.Close()
+
+		test(t, db)
+	}
+}
+
+func TestSnapshotInterface(t *testing.T) {
+	for i, test := range database.SnapshotTests {
+		folder := fmt.Sprintf("snapshot_db%d", i)
+
+		db, err := New(folder, 0, 0, 0)
+		if err != nil {
+			t.Fatalf("pebble.New(%s, 0, 0, 0) errored with %s", folder, err)
+		}
+		defer os.RemoveAll(folder)
+		defer db.Close()
+
+		test(t, db)
+	}
+}
+
+func TestReadOnlyInterface(t *testing.T) {
+	open := func(dir string) (database.Database, error) { return New(dir, 0, 0, 0) }
+	openReadOnly := func(dir string) (database.Database, error) { return NewReadOnly(dir) }
+	for i, test := range database.ReadOnlyTests {
+		folder := fmt.Sprintf("read_only_db%d", i)
+		defer os.RemoveAll(folder)
+
+		test(t, folder, open, openReadOnly)
+	}
+}
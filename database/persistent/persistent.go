@@ -0,0 +1,58 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package persistent
+
+import (
+	"fmt"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/database/leveldb"
+	"github.com/ava-labs/gecko/database/pebble"
+)
+
+// Storage engines that persist a database to disk
+const (
+	LevelDB = "leveldb"
+	Pebble  = "pebble"
+)
+
+// Types lists the storage engines that can be opened
+var Types = []string{LevelDB, Pebble}
+
+// Validate returns nil if [dbType] names a storage engine
+func Validate(dbType string) error {
+	switch dbType {
+	case LevelDB, Pebble:
+		return nil
+	default:
+		return fmt.Errorf("unknown database type %s", dbType)
+	}
+}
+
+// Open the database in [dir] with the storage engine named by [dbType]. The
+// directory must have been written by that engine, if it exists.
+func Open(dbType, dir string) (database.Database, error) {
+	switch dbType {
+	case LevelDB:
+		return leveldb.New(dir, 0, 0, 0)
+	case Pebble:
+		return pebble.New(dir, 0, 0, 0)
+	default:
+		return nil, Validate(dbType)
+	}
+}
+
+// OpenReadOnly opens the existing database in [dir] with the storage engine
+// named by [dbType], such that it can't be written to. Writes to the returned
+// database fail.
+func OpenReadOnly(dbType, dir string) (database.Database, error) {
+	switch dbType {
+	case LevelDB:
+		return leveldb.NewReadOnly(dir)
+	case Pebble:
+		return pebble.NewReadOnly(dir)
+	default:
+		return nil, Validate(dbType)
+	}
+}
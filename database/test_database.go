@@ -28,6 +28,20 @@ var (
 		TestStatNoPanic,
 		TestCompactNoPanic,
 	}
+
+	// SnapshotTests is a list of the tests of databases that implement
+	// Snapshotter
+	SnapshotTests = []func(t *testing.T, db Database){
+		TestSnapshot,
+		TestSnapshotClosed,
+	}
+
+	// ReadOnlyTests is a list of the tests of persistent databases that can be
+	// opened read-only. [open] opens the database in [dir] for writing, and
+	// [openReadOnly] opens it read-only.
+	ReadOnlyTests = []func(t *testing.T, dir string, open, openReadOnly func(dir string) (Database, error)){
+		TestReadOnly,
+	}
 )
 
 // TestSimpleKeyValue ...
@@ -707,3 +721,116 @@ func TestCompactNoPanic(t *testing.T, db Database) {
 
 	db.Compact(nil, nil)
 }
+
+// TestSnapshot ...
+func TestSnapshot(t *testing.T, db Database) {
+	key1 := []byte("hello1")
+	value1 := []byte("world1")
+
+	key2 := []byte("hello2")
+	value2 := []byte("world2")
+
+	if err := db.Put(key1, value1); err != nil {
+		t.Fatalf("Unexpected error on db.Put: %s", err)
+	}
+
+	snapshotter, ok := db.(Snapshotter)
+	if !ok {
+		t.Fatalf("Database doesn't implement Snapshotter")
+	}
+	snapshot, err := snapshotter.NewSnapshot()
+	if err != nil {
+		t.Fatalf("Unexpected error on db.NewSnapshot: %s", err)
+	}
+
+	if err := db.Put(key1, value2); err != nil {
+		t.Fatalf("Unexpected error on db.Put: %s", err)
+	} else if err := db.Put(key2, value2); err != nil {
+		t.Fatalf("Unexpected error on db.Put: %s", err)
+	}
+
+	if value, err := snapshot.Get(key1); err != nil {
+		t.Fatalf("Unexpected error on snapshot.Get: %s", err)
+	} else if !bytes.Equal(value, value1) {
+		t.Fatalf("snapshot.Get Returned: 0x%x ; Expected: 0x%x", value, value1)
+	} else if has, err := snapshot.Has(key2); err != nil {
+		t.Fatalf("Unexpected error on snapshot.Has: %s", err)
+	} else if has {
+		t.Fatalf("snapshot.Has Returned: %v ; Expected: %v", has, false)
+	}
+
+	iterator := snapshot.NewIteratorWithPrefix([]byte("hello"))
+	if !iterator.Next() {
+		t.Fatalf("iterator.Next Returned: %v ; Expected: %v", false, true)
+	} else if key := iterator.Key(); !bytes.Equal(key, key1) {
+		t.Fatalf("iterator.Key Returned: 0x%x ; Expected: 0x%x", key, key1)
+	} else if value := iterator.Value(); !bytes.Equal(value, value1) {
+		t.Fatalf("iterator.Value Returned: 0x%x ; Expected: 0x%x", value, value1)
+	} else if iterator.Next() {
+		t.Fatalf("iterator.Next Returned: %v ; Expected: %v", true, false)
+	} else if err := iterator.Error(); err != nil {
+		t.Fatalf("iterator.Error Returned: %s ; Expected: nil", err)
+	}
+	iterator.Release()
+
+	snapshot.Release()
+
+	if _, err := snapshot.Get(key1); err != ErrClosed {
+		t.Fatalf("Expected %s on snapshot.Get after snapshot.Release", ErrClosed)
+	}
+	if value, err := db.Get(key1); err != nil {
+		t.Fatalf("Unexpected error on db.Get: %s", err)
+	} else if !bytes.Equal(value, value2) {
+		t.Fatalf("db.Get Returned: 0x%x ; Expected: 0x%x", value, value2)
+	}
+}
+
+// TestSnapshotClosed ...
+func TestSnapshotClosed(t *testing.T, db Database) {
+	snapshotter, ok := db.(Snapshotter)
+	if !ok {
+		t.Fatalf("Database doesn't implement Snapshotter")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Unexpected error on db.Close: %s", err)
+	}
+
+	if _, err := snapshotter.NewSnapshot(); err != ErrClosed {
+		t.Fatalf("Expected %s on db.NewSnapshot", ErrClosed)
+	}
+}
+
+// TestReadOnly ...
+func TestReadOnly(t *testing.T, dir string, open, openReadOnly func(dir string) (Database, error)) {
+	if _, err := openReadOnly(dir); err == nil {
+		t.Fatalf("Shouldn't have opened a missing database read-only")
+	}
+
+	db, err := open(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error on open: %s", err)
+	}
+	key, value := []byte("hello"), []byte("world")
+	if err := db.Put(key, value); err != nil {
+		t.Fatalf("Unexpected error on db.Put: %s", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Unexpected error on db.Close: %s", err)
+	}
+
+	db, err = openReadOnly(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error on openReadOnly: %s", err)
+	}
+	defer db.Close()
+
+	if v, err := db.Get(key); err != nil {
+		t.Fatalf("Unexpected error on db.Get: %s", err)
+	} else if !bytes.Equal(v, value) {
+		t.Fatalf("db.Get Returned: 0x%x ; Expected: 0x%x", v, value)
+	}
+	if err := db.Put(key, value); err == nil {
+		t.Fatalf("Shouldn't have written to a read-only database")
+	}
+}
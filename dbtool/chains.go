@@ -0,0 +1,99 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/database/prefixdb"
+	"github.com/ava-labs/gecko/genesis"
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/utils/logging"
+	"github.com/ava-labs/gecko/vms/platformvm"
+)
+
+// chain is a chain whose data may be in the node's database
+type chain struct {
+	name  string // first alias of the chain, or its ID if it has none
+	id    ids.ID
+	vmID  ids.ID
+	fxIDs []ids.ID
+}
+
+// chains returns the platform chain, the chains of the network's genesis and
+// the chains created since, which are read from the platform chain's state
+func chains(db database.Database) ([]*chain, error) {
+	_, chainAliases, _, err := genesis.Aliases(config.NetworkID)
+	if err != nil {
+		return nil, err
+	}
+
+	genesisBytes, err := genesis.Genesis(config.NetworkID)
+	if err != nil {
+		return nil, err
+	}
+	genesisState := &platformvm.Genesis{}
+	if err := platformvm.Codec.Unmarshal(genesisBytes, genesisState); err != nil {
+		return nil, err
+	}
+	if err := genesisState.Initialize(); err != nil {
+		return nil, err
+	}
+
+	platform, err := platformvm.NewInspector(context(ids.Empty), vmDB(db, ids.Empty))
+	if err != nil {
+		return nil, err
+	}
+	createdTxs, err := platform.Get("chains")
+	switch err {
+	case nil:
+	case database.ErrNotFound:
+		// The platform chain was never initialized
+		createdTxs = []*platformvm.CreateChainTx(nil)
+	default:
+		return nil, err
+	}
+
+	chains := []*chain{{
+		name: chainAliases[ids.Empty.Key()][0],
+		id:   ids.Empty,
+		vmID: platformvm.ID,
+	}}
+	seen := ids.Set{}
+	seen.Add(ids.Empty)
+	for _, tx := range append(genesisState.Chains, createdTxs.([]*platformvm.CreateChainTx)...) {
+		chainID := tx.ID()
+		if seen.Contains(chainID) {
+			continue
+		}
+		seen.Add(chainID)
+
+		name := chainID.String()
+		if aliases := chainAliases[chainID.Key()]; len(aliases) > 0 {
+			name = aliases[0]
+		}
+		chains = append(chains, &chain{
+			name:  name,
+			id:    chainID,
+			vmID:  tx.VMID,
+			fxIDs: tx.FxIDs,
+		})
+	}
+	return chains, nil
+}
+
+// vmDB returns the database of the VM of the chain [chainID], as created by
+// the chain manager
+func vmDB(db database.Database, chainID ids.ID) database.Database {
+	return prefixdb.New([]byte("vm"), prefixdb.New(chainID.Bytes(), db))
+}
+
+// context returns the context of the chain [chainID] given to its inspector
+func context(chainID ids.ID) *snow.Context {
+	return &snow.Context{
+		NetworkID: config.NetworkID,
+		ChainID:   chainID,
+		Log:       logging.NoLog{},
+	}
+}
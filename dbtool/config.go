@@ -0,0 +1,28 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+// Config contains all of the configurations of a run of the database tool
+type Config struct {
+	// Command is the command to run: stats, dump or copy
+	Command string
+
+	// DBDir is the node's database, and DBType its storage engine. The
+	// database is opened read-only.
+	DBDir  string
+	DBType string
+
+	// NetworkID is the network the node's database belongs to. Its genesis
+	// names the chains in the database.
+	NetworkID uint32
+
+	// Chain is the alias or ID of the chain whose state Keys are dumped from
+	Chain string
+	Keys  []string
+
+	// DstDir is the database a copy is written to, and DstType its storage
+	// engine
+	DstDir  string
+	DstType string
+}
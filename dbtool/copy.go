@@ -0,0 +1,69 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/database/persistent"
+)
+
+// copyBatchSize is the number of value bytes written to the copy at once
+const copyBatchSize = 4 << 20
+
+// copyDB copies every key of [src] to a new database in --dst-dir, using the
+// storage engine given with --dst-type
+func copyDB(src database.Database) error {
+	dst, err := persistent.Open(config.DstType, config.DstDir)
+	if err != nil {
+		return err
+	}
+	err = copyKeys(src, dst)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func copyKeys(src, dst database.Database) error {
+	dstIt := dst.NewIterator()
+	empty := !dstIt.Next()
+	err := dstIt.Error()
+	dstIt.Release()
+	switch {
+	case err != nil:
+		return err
+	case !empty:
+		return errDstNotEmpty
+	}
+
+	batch := dst.NewBatch()
+	copied := 0
+	it := src.NewIterator()
+	defer it.Release()
+
+	for it.Next() {
+		if err := batch.Put(it.Key(), it.Value()); err != nil {
+			return err
+		}
+		copied++
+
+		if batch.ValueSize() >= copyBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Copied %d keys to %s\n", copied, config.DstDir)
+	return nil
+}
@@ -0,0 +1,113 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/snow/engine/common"
+	"github.com/ava-labs/gecko/vms/avm"
+	"github.com/ava-labs/gecko/vms/nftfx"
+	"github.com/ava-labs/gecko/vms/platformvm"
+	"github.com/ava-labs/gecko/vms/propertyfx"
+	"github.com/ava-labs/gecko/vms/secp256k1fx"
+)
+
+// rawPrefix marks the keys that are dumped without decoding them
+const rawPrefix = "0x"
+
+// inspector decodes the state of a chain
+type inspector interface {
+	Get(key string) (interface{}, error)
+}
+
+// dump prints the keys of the chain's state given with --keys. Keys of the
+// platform chain and of AVM chains are decoded with the VM's codec and printed
+// as JSON. Raw keys are looked up in the VM's database and printed in hex.
+func dump(db database.Database) error {
+	chains, err := chains(db)
+	if err != nil {
+		return err
+	}
+	var dumped *chain
+	for _, chain := range chains {
+		if chain.name == config.Chain || chain.id.String() == config.Chain {
+			dumped = chain
+		}
+	}
+	if dumped == nil {
+		return errUnknownChain
+	}
+
+	chainDB := vmDB(db, dumped.id)
+	inspector, err := newInspector(dumped, chainDB)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range config.Keys {
+		if strings.HasPrefix(key, rawPrefix) {
+			rawKey, err := hex.DecodeString(strings.TrimPrefix(key, rawPrefix))
+			if err != nil {
+				return err
+			}
+			value, err := chainDB.Get(rawKey)
+			if err != nil {
+				return fmt.Errorf("couldn't get %s: %s", key, err)
+			}
+			fmt.Printf("%s: 0x%x\n", key, value)
+			continue
+		}
+
+		if inspector == nil {
+			return fmt.Errorf("the state of VM %s can't be decoded. Its keys can be dumped raw, with the %s prefix", dumped.vmID, rawPrefix)
+		}
+		value, err := inspector.Get(key)
+		if err != nil {
+			return fmt.Errorf("couldn't get %s: %s", key, err)
+		}
+		valueJSON, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %s\n", key, valueJSON)
+	}
+	return nil
+}
+
+// newInspector returns the inspector of the state of [chain], whose VM's
+// database is [db], or nil if its VM's state can't be decoded
+func newInspector(chain *chain, db database.Database) (inspector, error) {
+	ctx := context(chain.id)
+	switch {
+	case chain.vmID.Equals(platformvm.ID):
+		return platformvm.NewInspector(ctx, db)
+	case chain.vmID.Equals(avm.ID):
+		fxs := make([]*common.Fx, len(chain.fxIDs))
+		for i, fxID := range chain.fxIDs {
+			var fx interface{}
+			switch {
+			case fxID.Equals(secp256k1fx.ID):
+				fx = &secp256k1fx.Fx{}
+			case fxID.Equals(nftfx.ID):
+				fx = &nftfx.Fx{}
+			case fxID.Equals(propertyfx.ID):
+				fx = &propertyfx.Fx{}
+			default:
+				return nil, fmt.Errorf("the state of fx %s can't be decoded", fxID)
+			}
+			fxs[i] = &common.Fx{
+				ID: fxID,
+				Fx: fx,
+			}
+		}
+		return avm.NewInspector(ctx, db, fxs)
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,56 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/database/persistent"
+)
+
+var (
+	errNoCommand    = errors.New("the first argument must be the command to run: stats, dump or copy")
+	errNoDB         = errors.New("the database to open must be given with --db-dir")
+	errNoKeys       = errors.New("the keys to dump must be given with --keys")
+	errNoDst        = errors.New("the directory to copy the database to must be given with --dst-dir")
+	errDstNotEmpty  = errors.New("the database to copy to isn't empty")
+	errUnknownChain = errors.New("the chain to dump isn't in the database")
+)
+
+func main() {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse arguments: %s\n", err)
+		os.Exit(2)
+	}
+
+	db, err := persistent.OpenReadOnly(config.DBType, config.DBDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open the database: %s\n", err)
+		os.Exit(1)
+	}
+
+	err = run(db)
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to %s the database: %s\n", config.Command, err)
+		os.Exit(1)
+	}
+}
+
+// run the command on the node's database, [db]
+func run(db database.Database) error {
+	switch config.Command {
+	case statsCommand:
+		return stats(db)
+	case dumpCommand:
+		return dump(db)
+	default:
+		return copyDB(db)
+	}
+}
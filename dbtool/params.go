@@ -0,0 +1,95 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ava-labs/gecko/database/persistent"
+	"github.com/ava-labs/gecko/genesis"
+	"github.com/ava-labs/gecko/utils/wrappers"
+)
+
+// Commands of the tool
+const (
+	statsCommand = "stats"
+	dumpCommand  = "dump"
+	copyCommand  = "copy"
+)
+
+var (
+	config Config
+	err    error
+)
+
+// Parse the CLI arguments. The first argument is the command, followed by its
+// flags.
+func init() {
+	errs := &wrappers.Errs{}
+	defer func() { err = errs.Err }()
+
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") {
+		errs.Add(errNoCommand)
+		return
+	}
+	config.Command = os.Args[1]
+
+	fs := flag.NewFlagSet("dbtool", flag.ContinueOnError)
+
+	// Database:
+	fs.StringVar(&config.DBDir, "db-dir", "", "Database of the node, such as ~/.gecko/db/<network>. It's opened read-only")
+	fs.StringVar(&config.DBType, "db-type", persistent.LevelDB, fmt.Sprintf("Storage engine of the database in --db-dir. Should be one of {%s}", strings.Join(persistent.Types, ", ")))
+	networkName := fs.String("network-id", genesis.CascadeName, "Network ID the database belongs to")
+
+	// Dump:
+	fs.StringVar(&config.Chain, "chain", "P", "Alias or ID of the chain to dump the state of")
+	keys := fs.String("keys", "", "Comma separated keys of the chain's state to dump. Keys starting with 0x are dumped raw")
+
+	// Copy:
+	fs.StringVar(&config.DstDir, "dst-dir", "", "Empty directory to copy the database to")
+	fs.StringVar(&config.DstType, "dst-type", persistent.Pebble, fmt.Sprintf("Storage engine of the copy. Should be one of {%s}", strings.Join(persistent.Types, ", ")))
+
+	ferr := fs.Parse(os.Args[2:])
+
+	if ferr == flag.ErrHelp {
+		// display usage/help text and exit successfully
+		os.Exit(0)
+	}
+
+	if ferr != nil {
+		// other type of error occurred when parsing args
+		os.Exit(2)
+	}
+
+	networkID, err := genesis.NetworkID(*networkName)
+	errs.Add(err)
+	config.NetworkID = networkID
+
+	if *keys != "" {
+		config.Keys = strings.Split(*keys, ",")
+	}
+
+	if config.DBDir == "" {
+		errs.Add(errNoDB)
+	}
+	errs.Add(persistent.Validate(config.DBType))
+
+	switch config.Command {
+	case statsCommand:
+	case dumpCommand:
+		if len(config.Keys) == 0 {
+			errs.Add(errNoKeys)
+		}
+	case copyCommand:
+		if config.DstDir == "" {
+			errs.Add(errNoDst)
+		}
+		errs.Add(persistent.Validate(config.DstType))
+	default:
+		errs.Add(fmt.Errorf("unknown command %s. Should be one of {%s, %s, %s}", config.Command, statsCommand, dumpCommand, copyCommand))
+	}
+}
@@ -0,0 +1,185 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/utils/hashing"
+	"github.com/ava-labs/gecko/vms/components/codec"
+)
+
+const (
+	// prefixLen is the length of the hashed prefixes prefixdb puts in front of
+	// keys
+	prefixLen = 32
+
+	// unprefixedName is the namespace of the keys the node writes without a
+	// prefix, such as the genesis hash
+	unprefixedName = "unprefixed"
+)
+
+// chainNamespaces are the databases the chain manager creates for each chain,
+// whose prefixes are appended to the chain's ID. Avalanche and Snowman chains
+// only use some of them.
+var chainNamespaces = [][]string{
+	{"vm"},
+	{"vertex"},
+	{"vertex_bootstrapping"},
+	{"tx_bootstrapping"},
+	{"bootstrapping"},
+	{"proposer"},
+	{"proposer", "block"},
+	{"proposer", "inner"},
+	{"proposer", "state"},
+}
+
+// usage of the database by the keys of a namespace
+type usage struct {
+	keys, keyBytes, valueBytes uint64
+}
+
+func (u *usage) add(key, value []byte) {
+	u.keys++
+	u.keyBytes += uint64(len(key))
+	u.valueBytes += uint64(len(value))
+}
+
+// stats prints the number and size of the keys in each namespace of [db]
+func stats(db database.Database) error {
+	names, err := namespaces(db)
+	if err != nil {
+		return err
+	}
+
+	usages := map[string]*usage{}
+	total := usage{}
+	it := db.NewIterator()
+	defer it.Release()
+
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+		name := unprefixedName
+		if len(key) >= prefixLen {
+			prefix := [prefixLen]byte{}
+			copy(prefix[:], key)
+			if knownName, ok := names[prefix]; ok {
+				name = knownName
+			} else {
+				name = fmt.Sprintf("unknown %x", prefix)
+			}
+		}
+
+		u, ok := usages[name]
+		if !ok {
+			u = &usage{}
+			usages[name] = u
+		}
+		u.add(key, value)
+		total.add(key, value)
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+
+	sortedNames := make([]string, 0, len(usages))
+	for name := range usages {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "namespace\tkeys\tkey bytes\tvalue bytes\t")
+	for _, name := range sortedNames {
+		u := usages[name]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t\n", name, u.keys, u.keyBytes, u.valueBytes)
+	}
+	fmt.Fprintf(w, "total\t%d\t%d\t%d\t\n", total.keys, total.keyBytes, total.valueBytes)
+	return w.Flush()
+}
+
+// namespaces returns the names of the prefixes of the databases the node
+// creates: its peers, the keystore and the databases of each user in it, the
+// shared memory of each pair of chains, and the databases of each chain
+func namespaces(db database.Database) (map[[prefixLen]byte]string, error) {
+	names := map[[prefixLen]byte]string{}
+	add := func(name string, prefixes ...[]byte) {
+		names[prefixOf(prefixes...)] = name
+	}
+
+	add("peers", []byte("peers"))
+	add("keystore/users", []byte("keystore"), []byte("users"))
+	add("keystore/bcs", []byte("keystore"), []byte("bcs"))
+
+	// The keys of the users database are the usernames
+	usersPrefix := prefixOf([]byte("keystore"), []byte("users"))
+	it := db.NewIteratorWithPrefix(usersPrefix[:])
+	for it.Next() {
+		username := it.Key()[prefixLen:]
+		add(fmt.Sprintf("keystore/bcs/%s", username), []byte("keystore"), []byte("bcs"), username)
+	}
+	err := it.Error()
+	it.Release()
+	if err != nil {
+		return nil, err
+	}
+
+	chains, err := chains(db)
+	if err != nil {
+		return nil, err
+	}
+	c := codec.NewDefault()
+	for i, chain := range chains {
+		for _, namespace := range chainNamespaces {
+			prefixes := [][]byte{chain.id.Bytes()}
+			for _, prefix := range namespace {
+				prefixes = append(prefixes, []byte(prefix))
+			}
+			add(fmt.Sprintf("%s/%s", chain.name, bytes.Join(prefixes[1:], []byte("/"))), prefixes...)
+		}
+
+		for _, peer := range chains[i+1:] {
+			sharedID, err := sharedID(c, chain.id, peer.id)
+			if err != nil {
+				return nil, err
+			}
+			add(fmt.Sprintf("shared memory/%s-%s", chain.name, peer.name), []byte("shared memory"), sharedID.Bytes())
+		}
+	}
+	return names, nil
+}
+
+// prefixOf returns the prefix of the keys of the database created by nesting
+// prefixdb.New with each of [prefixes], starting with the outermost. A nested
+// prefix is appended to the hash of the prefix it's nested in.
+func prefixOf(prefixes ...[]byte) [prefixLen]byte {
+	prefix := hashing.ComputeHash256Array(prefixes[0])
+	for _, nested := range prefixes[1:] {
+		prefix = hashing.ComputeHash256Array(append(prefix[:], nested...))
+	}
+	return prefix
+}
+
+// sharedID returns the ID of the shared memory of two chains, as computed by
+// atomic.SharedMemory
+func sharedID(c codec.Codec, id1, id2 ids.ID) (ids.ID, error) {
+	idKey1 := id1.Key()
+	idKey2 := id2.Key()
+
+	if bytes.Compare(idKey1[:], idKey2[:]) == 1 {
+		idKey1, idKey2 = idKey2, idKey1
+	}
+
+	combinedBytes, err := c.Marshal([2][32]byte{idKey1, idKey2})
+	if err != nil {
+		return ids.ID{}, err
+	}
+	return ids.NewID(hashing.ComputeHash256Array(combinedBytes)), nil
+}
@@ -5,12 +5,14 @@ package ids
 
 import (
 	"fmt"
+	"sync"
 )
 
 // Aliaser allows one to give an ID aliases and lookup the aliases given to an
 // ID. An ID can have arbitrarily many aliases; two IDs may not have the same
-// alias.
+// alias. It's safe to use concurrently.
 type Aliaser struct {
+	lock    sync.RWMutex
 	dealias map[string]ID
 	aliases map[[32]byte][]string
 }
@@ -23,6 +25,9 @@ func (a *Aliaser) Initialize() {
 
 // Lookup returns the ID associated with alias
 func (a *Aliaser) Lookup(alias string) (ID, error) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
 	if ID, ok := a.dealias[alias]; ok {
 		return ID, nil
 	}
@@ -30,10 +35,18 @@ func (a *Aliaser) Lookup(alias string) (ID, error) {
 }
 
 // Aliases returns the aliases of an ID
-func (a Aliaser) Aliases(id ID) []string { return a.aliases[id.Key()] }
+func (a *Aliaser) Aliases(id ID) []string {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	return a.aliases[id.Key()]
+}
 
 // PrimaryAlias returns the first alias of [id]
-func (a Aliaser) PrimaryAlias(id ID) (string, error) {
+func (a *Aliaser) PrimaryAlias(id ID) (string, error) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
 	aliases, exists := a.aliases[id.Key()]
 	if !exists || len(aliases) == 0 {
 		return "", fmt.Errorf("there is no alias for ID %s", id)
@@ -42,7 +55,10 @@ func (a Aliaser) PrimaryAlias(id ID) (string, error) {
 }
 
 // Alias gives [id] the alias [alias]
-func (a Aliaser) Alias(id ID, alias string) error {
+func (a *Aliaser) Alias(id ID, alias string) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
 	if _, exists := a.dealias[alias]; exists {
 		return fmt.Errorf("%s is already used as an alias for an ID", alias)
 	}
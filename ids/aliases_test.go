@@ -9,15 +9,15 @@ import (
 )
 
 func TestAliaserLookupError(t *testing.T) {
-	emptyAliaser := Aliaser{}
+	emptyAliaser := &Aliaser{}
 	emptyAliaser.Initialize()
 	tests := []struct {
 		label   string
-		aliaser Aliaser
+		aliaser *Aliaser
 		alias   string
 		res     ID
 	}{
-		{"Unitialized", Aliaser{}, "Batwoman", ID{}},
+		{"Unitialized", &Aliaser{}, "Batwoman", ID{}},
 		{"Empty", emptyAliaser, "Batman", ID{}},
 	}
 	for _, tt := range tests {
@@ -4,6 +4,7 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -11,19 +12,23 @@ import (
 	"os"
 	"path"
 	"strings"
+	"time"
 
-	"github.com/ava-labs/gecko/database/leveldb"
 	"github.com/ava-labs/gecko/database/memdb"
+	"github.com/ava-labs/gecko/database/persistent"
 	"github.com/ava-labs/gecko/genesis"
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/nat"
+	"github.com/ava-labs/gecko/networking"
 	"github.com/ava-labs/gecko/node"
 	"github.com/ava-labs/gecko/snow/networking/router"
+	"github.com/ava-labs/gecko/snow/validators"
 	"github.com/ava-labs/gecko/utils"
 	"github.com/ava-labs/gecko/utils/formatting"
 	"github.com/ava-labs/gecko/utils/hashing"
 	"github.com/ava-labs/gecko/utils/logging"
 	"github.com/ava-labs/gecko/utils/wrappers"
+	"github.com/ava-labs/gecko/vms/proposervm"
 )
 
 // Results of parsing the CLI
@@ -77,9 +82,11 @@ func init() {
 	// Database:
 	db := fs.Bool("db-enabled", true, "Turn on persistent storage")
 	dbDir := fs.String("db-dir", "db", "Database directory for Ava state")
+	dbType := fs.String("db-type", persistent.LevelDB, fmt.Sprintf("Storage engine of the database. Should be one of {%s}. Each engine keeps its own state in the database directory", strings.Join(persistent.Types, ", ")))
+	fs.StringVar(&Config.DBRestoreFile, "db-restore", "", "Backup of a node's database, written by admin.backupDatabase, to restore before starting. The database must be empty, or be left from a restore that didn't complete")
 
 	// IP:
-	consensusIP := fs.String("public-ip", "", "Public IP of this node")
+	consensusIP := fs.String("public-ip", "", "Comma separated list of the public IPs of this node. The first IP is the primary one; the others, such as an IPv6 address, are also advertised to peers")
 
 	// HTTP Server:
 	httpPort := fs.Uint("http-port", 9650, "Port of the HTTP server")
@@ -90,6 +97,7 @@ func init() {
 	// Bootstrapping:
 	bootstrapIPs := fs.String("bootstrap-ips", "default", "Comma separated list of bootstrap peer ips to connect to. Example: 127.0.0.1:9630,127.0.0.1:9631")
 	bootstrapIDs := fs.String("bootstrap-ids", "default", "Comma separated list of bootstrap peer ids to connect to. Example: JR4dVmy6ffUGAKCBDkyCbeZbyHQBeDsET,8CrVPQZ4VSqgL8zTdvL14G8HqAfrBr4z")
+	fs.BoolVar(&Config.StateSyncEnabled, "state-sync-enabled", false, "If true, snowman chains whose VM supports state sync download the state of the bootstrap peers rather than every block")
 
 	// Staking:
 	consensusPort := fs.Uint("staking-port", 9651, "Port of the consensus server")
@@ -97,6 +105,32 @@ func init() {
 	fs.StringVar(&Config.StakingKeyFile, "staking-tls-key-file", "keys/staker.key", "TLS private key file for staking connections")
 	fs.StringVar(&Config.StakingCertFile, "staking-tls-cert-file", "keys/staker.crt", "TLS certificate file for staking connections")
 
+	// Compression:
+	fs.BoolVar(&Config.EnableCompression, "network-compression-enabled", true, "If true, peers may send this node compressed containers")
+
+	// Throttling:
+	fs.Float64Var(&Config.ThrottlerConfig.ValidatorRate, "network-validator-msg-rate", 1000, "Number of messages of each type per second that a validator with the average stake may send")
+	fs.Float64Var(&Config.ThrottlerConfig.NonValidatorRate, "network-non-validator-msg-rate", 100, "Number of messages of each type per second that a peer without stake may send")
+	fs.DurationVar(&Config.ThrottlerConfig.Burst, "network-msg-burst", 2*time.Second, "Amount of time worth of messages that a peer may send at once")
+
+	// Reputation:
+	fs.Float64Var(&Config.ReputationConfig.BanThreshold, "network-ban-threshold", -100, "Peers whose score falls to this threshold are banned")
+	fs.DurationVar(&Config.ReputationConfig.BanDuration, "network-ban-duration", time.Hour, "Amount of time misbehaving peers are banned for")
+	fs.DurationVar(&Config.ReputationConfig.HalfLife, "network-score-half-life", 10*time.Minute, "Amount of time it takes for half of a peer's penalties to be forgiven")
+
+	// Request timeouts:
+	fs.DurationVar(&Config.TimeoutConfig.InitialTimeout, "network-initial-timeout", 2*time.Second, "Timeout of requests to peers whose latency hasn't been measured yet")
+	fs.DurationVar(&Config.TimeoutConfig.MinimumTimeout, "network-minimum-timeout", 500*time.Millisecond, "Minimum timeout of requests to peers")
+	fs.DurationVar(&Config.TimeoutConfig.MaximumTimeout, "network-maximum-timeout", 10*time.Second, "Maximum timeout of requests to peers")
+	fs.Float64Var(&Config.TimeoutConfig.LatencyMultiplier, "network-timeout-multiplier", 2, "Multiple of a peer's average latency to wait before its requests time out")
+	fs.Float64Var(&Config.TimeoutConfig.LatencyWeight, "network-latency-weight", 0.1, "Weight, in (0, 1], of each new latency sample in a peer's average latency")
+	fs.Float64Var(&Config.TimeoutConfig.FailureReduction, "network-timeout-reduction", 0.5, "Factor, in (0, 1], that a peer's timeout is multiplied by for each consecutive request to the peer that failed")
+
+	// Validator connections:
+	fs.IntVar(&Config.ConnectionManagerConfig.TargetValidators, "network-target-validator-conns", 20, "Number of validators of each subnet this node validates to stay connected to")
+	fs.DurationVar(&Config.ConnectionManagerConfig.MinBackoff, "network-min-redial-backoff", time.Second, "Amount of time to wait before first redialing a validator that couldn't be connected to")
+	fs.DurationVar(&Config.ConnectionManagerConfig.MaxBackoff, "network-max-redial-backoff", 5*time.Minute, "Maximum amount of time to wait before redialing a validator that couldn't be connected to")
+
 	// Plugins:
 	fs.StringVar(&Config.PluginDir, "plugin-dir", "./build/plugins", "Plugin directory for Ava VMs")
 
@@ -112,6 +146,16 @@ func init() {
 	fs.IntVar(&Config.ConsensusParams.Parents, "snow-avalanche-num-parents", 5, "Number of vertexes for reference from each new vertex")
 	fs.IntVar(&Config.ConsensusParams.BatchSize, "snow-avalanche-batch-size", 30, "Number of operations to batch in each new vertex")
 	fs.IntVar(&Config.ConsensusParams.ConcurrentRepolls, "snow-concurrent-repolls", 1, "Minimum number of concurrent polls for finalizing consensus")
+	fs.StringVar(&Config.SamplerConfig.Default, "snow-sampler", validators.WeightedStrategy, "Strategy used to sample validators. Should be one of {weighted, uniform}, optionally prefixed with connected- to only sample connected validators")
+	chainConsensus := fs.String("snow-chain-consensus", "", "JSON object that maps chain IDs or aliases to overrides of their consensus parameters, with the fields k, alpha, betaVirtuous, betaRogue, parents, batchSize and consensus")
+	subnetSamplers := fs.String("snow-subnet-samplers", "", "Comma separated list of subnetID=strategy pairs that override the sampling strategy of the listed subnets. A subnet's strategy applies to every chain of the subnet. If staking is disabled, every chain is validated by the default subnet, so only its entry may be listed")
+	fs.BoolVar(&Config.ProposerWindowsEnabled, "snow-proposer-windows-enabled", false, "If true, the validators of snowman chains take turns proposing blocks. Every node of a chain must agree on this")
+	fs.DurationVar(&Config.ProposerConfig.WindowDuration, "snow-proposer-window-duration", 5*time.Second, "Amount of time each proposer may propose a block before the next proposer may too")
+	fs.IntVar(&Config.ProposerConfig.NumWindows, "snow-proposer-num-windows", 6, "Number of proposers at each height, after whose windows any node may propose a block")
+	fs.DurationVar(&Config.ProposerConfig.MaxClockSkew, "snow-proposer-max-clock-skew", 10*time.Second, "Amount of time a block's timestamp may be ahead of local time")
+	proposerActivation := fs.Int64("snow-proposer-activation-time", proposervm.DefaultActivationTime.Unix(), "Unix time at which the proposer windows start being enforced. After it, blocks without a proposer are rejected. Every node of a chain must agree on this")
+	fs.IntVar(&Config.ConsensusTraceSize, "snow-trace-size", 0, "Number of recent consensus events recorded per chain, which can be dumped through the Admin API. If 0, no events are recorded")
+	fs.StringVar(&Config.ConsensusRecordDir, "snow-record-dir", "", "Directory to record every input of each chain's consensus engine to, so they can be replayed with the replay tool. If empty, nothing is recorded")
 
 	// Enable/Disable APIs:
 	fs.BoolVar(&Config.AdminAPIEnabled, "api-admin-enabled", true, "If true, this node exposes the Admin API")
@@ -141,10 +185,17 @@ func init() {
 	Config.NetworkID = networkID
 
 	// DB:
-	if *db && err == nil {
+	dbTypeErr := persistent.Validate(*dbType)
+	errs.Add(dbTypeErr)
+	if *db && err == nil && dbTypeErr == nil {
 		// TODO: Add better params here
 		dbPath := path.Join(*dbDir, genesis.NetworkName(Config.NetworkID))
-		db, err := leveldb.New(dbPath, 0, 0, 0)
+		if *dbType != persistent.LevelDB {
+			// leveldb keeps the directory it has always used, so existing
+			// databases are still found
+			dbPath = path.Join(*dbDir, *dbType, genesis.NetworkName(Config.NetworkID))
+		}
+		db, err := persistent.Open(*dbType, dbPath)
 		Config.DB = db
 		errs.Add(err)
 	} else {
@@ -153,23 +204,41 @@ func init() {
 
 	Config.Nat = nat.NewRouter()
 
-	var ip net.IP
-	// If public IP is not specified, get it using shell command dig
+	var ips []net.IP
+	// If public IP is not specified, get it from the router, along with a
+	// global IPv6 address if this host has one
 	if *consensusIP == "" {
-		ip, err = Config.Nat.IP()
-		if err != nil {
-			ip = net.IPv4zero
+		if ip, err := Config.Nat.IP(); err == nil {
+			ips = append(ips, ip)
+			Config.DynamicIP = true
+		}
+		if ip, err := nat.LocalIPv6(); err == nil {
+			ips = append(ips, ip)
+		}
+		if len(ips) == 0 {
+			ips = append(ips, net.IPv4zero)
 		}
 	} else {
-		ip = net.ParseIP(*consensusIP)
+		for _, ipStr := range strings.Split(*consensusIP, ",") {
+			ip := net.ParseIP(strings.TrimSpace(ipStr))
+			if ip == nil {
+				errs.Add(fmt.Errorf("Invalid IP Address %s", ipStr))
+				continue
+			}
+			ips = append(ips, ip)
+		}
 	}
 
-	if ip == nil {
-		errs.Add(fmt.Errorf("Invalid IP Address %s", *consensusIP))
-	}
-	Config.StakingIP = utils.IPDesc{
-		IP:   ip,
-		Port: uint16(*consensusPort),
+	for i, ip := range ips {
+		ipDesc := utils.IPDesc{
+			IP:   ip,
+			Port: uint16(*consensusPort),
+		}
+		if i == 0 {
+			Config.StakingIP = ipDesc
+		} else {
+			Config.AltStakingIPs = append(Config.AltStakingIPs, ipDesc)
+		}
 	}
 
 	// Bootstrapping:
@@ -219,6 +288,49 @@ func init() {
 		}
 	}
 
+	// Validator sampling:
+	Config.SamplerConfig.Subnets = make(map[[32]byte]string)
+	for _, pair := range strings.Split(*subnetSamplers, ",") {
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			errs.Add(fmt.Errorf("Invalid subnet sampler %s", pair))
+			continue
+		}
+		subnetID, err := ids.FromString(parts[0])
+		errs.Add(err)
+		Config.SamplerConfig.Subnets[subnetID.Key()] = parts[1]
+	}
+	errs.Add(Config.SamplerConfig.Valid(Config.EnableStaking))
+	if *chainConsensus != "" {
+		if err := json.Unmarshal([]byte(*chainConsensus), &Config.ChainConsensus); err != nil {
+			errs.Add(fmt.Errorf("Invalid chain consensus configuration: %s", err))
+		}
+	}
+	for chain, config := range Config.ChainConsensus {
+		if err := config.Valid(); err != nil {
+			errs.Add(fmt.Errorf("Invalid consensus configuration of chain %s: %s", chain, err))
+		}
+	}
+
+	// Proposer windows:
+	Config.ProposerConfig.ActivationTime = time.Unix(*proposerActivation, 0)
+	errs.Add(Config.ProposerConfig.Valid())
+
+	// Reputation:
+	errs.Add(Config.ReputationConfig.Valid())
+
+	// Validator connections:
+	errs.Add(Config.ConnectionManagerConfig.Valid())
+
+	// Request timeouts:
+	errs.Add(Config.TimeoutConfig.Valid())
+
+	// Throttling:
+	errs.Add(Config.ThrottlerConfig.Valid())
+
 	// HTTP:
 	Config.HTTPPort = uint16(*httpPort)
 
@@ -244,4 +356,7 @@ func init() {
 
 	// Router used for consensus
 	Config.ConsensusRouter = &router.ChainRouter{}
+
+	// Transport used to connect to other nodes
+	Config.Transport = networking.TCPTransport{}
 }
@@ -0,0 +1,56 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package nat
+
+import (
+	"errors"
+	"net"
+
+	"github.com/ava-labs/gecko/utils"
+)
+
+var (
+	errNoIPv6 = errors.New("no global IPv6 address was found")
+)
+
+// IPv6 addresses aren't translated by routers, so a host that has a global
+// IPv6 address can be reached at it directly. At most, the router's firewall
+// must be told to allow incoming connections, which UPnP supports by opening
+// pinholes. NAT-PMP only supports IPv4.
+
+// LocalIPv6 returns a global IPv6 address of one of this host's interfaces
+func LocalIPv6() (net.IP, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+
+		ip := ipNet.IP
+		ipDesc := utils.IPDesc{IP: ip}
+		if ipDesc.IsIPv4() || !ip.IsGlobalUnicast() || ipDesc.IsPrivate() {
+			continue
+		}
+		return ip, nil
+	}
+	return nil, errNoIPv6
+}
+
+// protocolNumber returns the IANA protocol number of the network protocol, as
+// used when opening IPv6 pinholes
+func protocolNumber(networkProtocol NetworkProtocol) uint16 {
+	switch networkProtocol {
+	case TCP:
+		return 6
+	case UDP:
+		return 17
+	default:
+		return 0
+	}
+}
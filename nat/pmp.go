@@ -16,7 +16,8 @@ var (
 )
 
 // natPMPClient adapts the NAT-PMP protocol implementation so it conforms to
-// the common interface.
+// the common interface. NAT-PMP only maps IPv4 ports, so IPv6 connections
+// aren't opened through it.
 type pmpClient struct {
 	client *natpmp.Client
 }
@@ -7,11 +7,14 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/huin/goupnp"
 	"github.com/huin/goupnp/dcps/internetgateway1"
 	"github.com/huin/goupnp/dcps/internetgateway2"
+
+	"github.com/ava-labs/gecko/utils/wrappers"
 )
 
 const (
@@ -49,9 +52,39 @@ type upnpClient interface {
 	GetNATRSIPStatus() (newRSIPAvailable bool, natEnabled bool, err error)
 }
 
+// upnpPinholeClient is the interface used by goupnp for opening the IPv6
+// firewall of a router
+type upnpPinholeClient interface {
+	// attempts to allow incoming connections using the provided protocol to
+	// the internal port of the internal client for the lease duration.
+	// Returns the ID of the pinhole.
+	AddPinhole(
+		newRemoteHost string,
+		newRemotePort uint16,
+		newInternalClient string,
+		newInternalPort uint16,
+		newProtocol uint16,
+		newLeaseTime uint32) (uint16, error)
+
+	// attempt to remove the pinhole with the provided ID.
+	DeletePinhole(uniqueID uint16) error
+}
+
+// pinhole identifies an opened IPv6 pinhole
+type pinhole struct {
+	protocol     NetworkProtocol
+	internalPort uint16
+}
+
 type upnpRouter struct {
 	root   *goupnp.RootDevice
 	client upnpClient
+
+	// pinholeClient is nil if the router doesn't firewall incoming IPv6
+	// connections, or doesn't allow them to be opened
+	pinholeClient upnpPinholeClient
+	pinholeLock   sync.Mutex
+	pinholes      map[pinhole]uint16 // values are the IDs of the pinholes
 }
 
 func (n *upnpRouter) MapPort(
@@ -74,24 +107,81 @@ func (n *upnpRouter) MapPort(
 	// exist.
 	n.UnmapPort(networkProtocol, newInternalPort, newExternalPort)
 
-	return n.client.AddPortMapping(
-		"", // newRemoteHost isn't used to limit the mapping to a host
-		newExternalPort,
-		protocol,
-		newInternalPort,
-		ip.String(), // newInternalClient is the client traffic should be sent to
-		true,        // newEnabled enables port mappings
-		mappingName,
-		lifetime,
+	errs := wrappers.Errs{}
+	errs.Add(
+		n.client.AddPortMapping(
+			"", // newRemoteHost isn't used to limit the mapping to a host
+			newExternalPort,
+			protocol,
+			newInternalPort,
+			ip.String(), // newInternalClient is the client traffic should be sent to
+			true,        // newEnabled enables port mappings
+			mappingName,
+			lifetime,
+		),
+		n.openPinhole(networkProtocol, newInternalPort, lifetime),
 	)
+	return errs.Err
 }
 
-func (n *upnpRouter) UnmapPort(networkProtocol NetworkProtocol, _, externalPort uint16) error {
+func (n *upnpRouter) UnmapPort(networkProtocol NetworkProtocol, internalPort, externalPort uint16) error {
 	protocol := string(networkProtocol)
-	return n.client.DeletePortMapping(
-		"", // newRemoteHost isn't used to limit the mapping to a host
-		externalPort,
-		protocol)
+
+	errs := wrappers.Errs{}
+	errs.Add(
+		n.client.DeletePortMapping(
+			"", // newRemoteHost isn't used to limit the mapping to a host
+			externalPort,
+			protocol),
+		n.closePinhole(networkProtocol, internalPort),
+	)
+	return errs.Err
+}
+
+// openPinhole allows incoming IPv6 connections to the internal port, if the
+// router firewalls them and this host has a global IPv6 address. IPv6
+// addresses aren't translated, so the external port is the internal port.
+func (n *upnpRouter) openPinhole(networkProtocol NetworkProtocol, internalPort uint16, lifetime uint32) error {
+	if n.pinholeClient == nil {
+		return nil
+	}
+	ip, err := LocalIPv6()
+	if err != nil {
+		// This host can't be reached over IPv6, so there's nothing to open
+		return nil
+	}
+
+	id, err := n.pinholeClient.AddPinhole(
+		"", // newRemoteHost isn't used to limit the pinhole to a host
+		0,  // newRemotePort isn't used to limit the pinhole to a port
+		ip.String(),
+		internalPort,
+		protocolNumber(networkProtocol),
+		lifetime,
+	)
+	if err != nil {
+		return err
+	}
+
+	n.pinholeLock.Lock()
+	defer n.pinholeLock.Unlock()
+
+	n.pinholes[pinhole{protocol: networkProtocol, internalPort: internalPort}] = id
+	return nil
+}
+
+// closePinhole removes the pinhole to the internal port, if one was opened
+func (n *upnpRouter) closePinhole(networkProtocol NetworkProtocol, internalPort uint16) error {
+	n.pinholeLock.Lock()
+	key := pinhole{protocol: networkProtocol, internalPort: internalPort}
+	id, exists := n.pinholes[key]
+	delete(n.pinholes, key)
+	n.pinholeLock.Unlock()
+
+	if !exists {
+		return nil
+	}
+	return n.pinholeClient.DeletePinhole(id)
 }
 
 func (n *upnpRouter) IP() (net.IP, error) {
@@ -108,7 +198,8 @@ func (n *upnpRouter) IP() (net.IP, error) {
 }
 
 func (n *upnpRouter) localAddress() (net.IP, error) {
-	// attempt to get an address on the router
+	// attempt to get an address on the router. Port mappings are only made for
+	// IPv4, because IPv6 addresses aren't translated.
 	deviceAddr, err := net.ResolveUDPAddr("udp4", n.root.URLBase.Host)
 	if err != nil {
 		return nil, err
@@ -245,9 +336,44 @@ func getRouter(rootDevice *goupnp.MaybeRootDevice, device *goupnp.Device, toClie
 
 		// we found a router!
 		return &upnpRouter{
-			root:   rootDevice.Root,
-			client: client,
+			root:          rootDevice.Root,
+			client:        client,
+			pinholeClient: getPinholeClient(rootDevice, device),
+			pinholes:      make(map[pinhole]uint16),
+		}
+	}
+	return nil
+}
+
+// getPinholeClient returns a client that can open pinholes in the device's
+// IPv6 firewall, or nil if the device doesn't need or allow pinholes
+func getPinholeClient(rootDevice *goupnp.MaybeRootDevice, device *goupnp.Device) upnpPinholeClient {
+	for i := range device.Services {
+		service := &device.Services[i]
+		if service.ServiceType != internetgateway2.URN_WANIPv6FirewallControl_1 {
+			continue
+		}
+
+		soapClient := service.NewSOAPClient()
+		// make sure the client times out if needed
+		soapClient.HTTPClient.Timeout = soapTimeout
+
+		client := &internetgateway2.WANIPv6FirewallControl1{
+			ServiceClient: goupnp.ServiceClient{
+				SOAPClient: soapClient,
+				RootDevice: rootDevice.Root,
+				Location:   rootDevice.Location,
+				Service:    service,
+			},
+		}
+
+		// check whether incoming connections are firewalled, and whether
+		// pinholes can be opened
+		enabled, allowed, err := client.GetFirewallStatus()
+		if err != nil || !enabled || !allowed {
+			return nil
 		}
+		return client
 	}
 	return nil
 }
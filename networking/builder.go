@@ -15,22 +15,38 @@ type Builder struct{ Codec }
 // GetVersion message
 func (m Builder) GetVersion() (Msg, error) { return m.Pack(GetVersion, nil) }
 
-// Version message
-func (m Builder) Version(networkID uint32, myTime uint64, ip utils.IPDesc, myVersion string) (Msg, error) {
-	return m.Pack(Version, map[Field]interface{}{
-		NetworkID:  networkID,
-		MyTime:     myTime,
-		IP:         ip,
-		VersionStr: myVersion,
-	})
+// Version message. If [signedIP] has no signature, the IP isn't signed. The
+// [altIPs] are other IPs the node can be reached at, and are only sent if
+// there are any.
+func (m Builder) Version(networkID uint32, myTime uint64, signedIP SignedIP, altIPs []SignedIP, myVersion string, msgVersion uint32, compressions uint32) (Msg, error) {
+	fields := map[Field]interface{}{
+		NetworkID:    networkID,
+		MyTime:       myTime,
+		IP:           signedIP.IP,
+		VersionStr:   myVersion,
+		MsgVersion:   msgVersion,
+		Compressions: compressions,
+	}
+	if len(signedIP.Signature) > 0 {
+		fields[IPTimestamp] = signedIP.Timestamp
+		fields[IPSignature] = signedIP.Signature
+	}
+	if len(altIPs) > 0 {
+		fields[AltIPs] = altIPs
+	}
+	return m.Pack(Version, fields)
 }
 
 // GetPeerList message
 func (m Builder) GetPeerList() (Msg, error) { return m.Pack(GetPeerList, nil) }
 
-// PeerList message
-func (m Builder) PeerList(ipDescs []utils.IPDesc) (Msg, error) {
-	return m.Pack(PeerList, map[Field]interface{}{Peers: ipDescs})
+// PeerList message. The [signedPeers] are only sent if there are any.
+func (m Builder) PeerList(ipDescs []utils.IPDesc, signedPeers []SignedPeer) (Msg, error) {
+	fields := map[Field]interface{}{Peers: ipDescs}
+	if len(signedPeers) > 0 {
+		fields[SignedPeers] = signedPeers
+	}
+	return m.Pack(PeerList, fields)
 }
 
 // GetAcceptedFrontier message
@@ -99,6 +115,17 @@ func (m Builder) Put(chainID ids.ID, requestID uint32, containerID ids.ID, conta
 	})
 }
 
+// CompressedPut message, where the container was compressed with [compression]
+func (m Builder) CompressedPut(chainID ids.ID, requestID uint32, containerID ids.ID, container []byte, compression Compression) (Msg, error) {
+	return m.Pack(Put, map[Field]interface{}{
+		ChainID:              chainID.Bytes(),
+		RequestID:            requestID,
+		ContainerID:          containerID.Bytes(),
+		ContainerBytes:       container,
+		ContainerCompression: uint32(compression),
+	})
+}
+
 // PushQuery message
 func (m Builder) PushQuery(chainID ids.ID, requestID uint32, containerID ids.ID, container []byte) (Msg, error) {
 	return m.Pack(PushQuery, map[Field]interface{}{
@@ -109,6 +136,18 @@ func (m Builder) PushQuery(chainID ids.ID, requestID uint32, containerID ids.ID,
 	})
 }
 
+// CompressedPushQuery message, where the container was compressed with
+// [compression]
+func (m Builder) CompressedPushQuery(chainID ids.ID, requestID uint32, containerID ids.ID, container []byte, compression Compression) (Msg, error) {
+	return m.Pack(PushQuery, map[Field]interface{}{
+		ChainID:              chainID.Bytes(),
+		RequestID:            requestID,
+		ContainerID:          containerID.Bytes(),
+		ContainerBytes:       container,
+		ContainerCompression: uint32(compression),
+	})
+}
+
 // PullQuery message
 func (m Builder) PullQuery(chainID ids.ID, requestID uint32, containerID ids.ID) (Msg, error) {
 	return m.Pack(PullQuery, map[Field]interface{}{
@@ -131,6 +170,60 @@ func (m Builder) Chits(chainID ids.ID, requestID uint32, containerIDs ids.Set) (
 	})
 }
 
+// GetStateSummary message
+func (m Builder) GetStateSummary(chainID ids.ID, requestID uint32) (Msg, error) {
+	return m.Pack(GetStateSummary, map[Field]interface{}{
+		ChainID:   chainID.Bytes(),
+		RequestID: requestID,
+	})
+}
+
+// StateSummary message
+func (m Builder) StateSummary(chainID ids.ID, requestID uint32, summary []byte) (Msg, error) {
+	return m.Pack(StateSummary, map[Field]interface{}{
+		ChainID:        chainID.Bytes(),
+		RequestID:      requestID,
+		ContainerBytes: summary,
+	})
+}
+
+// GetStateChunk message
+func (m Builder) GetStateChunk(chainID ids.ID, requestID uint32, summaryID ids.ID, index uint32) (Msg, error) {
+	return m.Pack(GetStateChunk, map[Field]interface{}{
+		ChainID:     chainID.Bytes(),
+		RequestID:   requestID,
+		ContainerID: summaryID.Bytes(),
+		ChunkIndex:  index,
+	})
+}
+
+// StateChunk message
+func (m Builder) StateChunk(chainID ids.ID, requestID uint32, chunk []byte) (Msg, error) {
+	return m.Pack(StateChunk, map[Field]interface{}{
+		ChainID:        chainID.Bytes(),
+		RequestID:      requestID,
+		ContainerBytes: chunk,
+	})
+}
+
+// GetAncestors message
+func (m Builder) GetAncestors(chainID ids.ID, requestID uint32, containerID ids.ID) (Msg, error) {
+	return m.Pack(GetAncestors, map[Field]interface{}{
+		ChainID:     chainID.Bytes(),
+		RequestID:   requestID,
+		ContainerID: containerID.Bytes(),
+	})
+}
+
+// MultiPut message
+func (m Builder) MultiPut(chainID ids.ID, requestID uint32, containers [][]byte) (Msg, error) {
+	return m.Pack(MultiPut, map[Field]interface{}{
+		ChainID:             chainID.Bytes(),
+		RequestID:           requestID,
+		MultiContainerBytes: containers,
+	})
+}
+
 // Ping message
 func (m Builder) Ping() (Msg, error) { return m.Pack(Ping, nil) }
 
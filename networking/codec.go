@@ -7,75 +7,135 @@ import (
 	"errors"
 	"math"
 
-	"github.com/ava-labs/salticidae-go"
-
 	"github.com/ava-labs/gecko/utils/wrappers"
 )
 
 var (
-	errBadLength    = errors.New("stream has unexpected length")
-	errMissingField = errors.New("message missing field")
-	errBadOp        = errors.New("input field has invalid operation")
+	errBadLength      = errors.New("stream has unexpected length")
+	errMissingField   = errors.New("message missing field")
+	errBadOp          = errors.New("input field has invalid operation")
+	errDuplicateField = errors.New("message contains a duplicated field")
 )
 
 // Codec defines the serialization and deserialization of network messages
+//
+// A message is an envelope of the form:
+//
+//	[op (1 byte)][field]...
+//
+// where each field is of the form:
+//
+//	[field tag (2 bytes)][length (4 bytes)][value (length bytes)]
+//
+// Because every field is tagged and length prefixed, fields can be added to an
+// op without breaking nodes that don't know about them. Unknown fields are
+// skipped when parsing. Fields in OptionalFields are only packed if they are
+// provided. Messages don't carry a version of their own: whether a peer
+// understands a field is decided by the message version agreed on in the
+// handshake.
 type Codec struct{}
 
 // Pack attempts to pack a map of fields into a message.
-func (Codec) Pack(op salticidae.Opcode, fields map[Field]interface{}) (Msg, error) {
+// The first byte of the message is the opcode of the message.
+func (Codec) Pack(op Op, fields map[Field]interface{}) (Msg, error) {
 	message, ok := Messages[op]
 	if !ok {
 		return nil, errBadOp
 	}
 
 	p := wrappers.Packer{MaxSize: math.MaxInt32}
+	p.PackByte(byte(op))
 	for _, field := range message {
 		data, ok := fields[field]
 		if !ok {
 			return nil, errMissingField
 		}
-		field.Packer()(&p, data)
+		packField(&p, field, data)
+	}
+	for _, field := range OptionalFields[op] {
+		if data, ok := fields[field]; ok {
+			packField(&p, field, data)
+		}
 	}
 
-	if p.Errored() { // Prevent the datastream from leaking
+	if p.Errored() {
 		return nil, p.Err
 	}
 
 	return &msg{
 		op:     op,
-		ds:     salticidae.NewDataStreamFromBytes(p.Bytes, false),
 		fields: fields,
+		bytes:  p.Bytes,
 	}, nil
 }
 
-// Parse attempts to convert a byte stream into a message.
-func (Codec) Parse(op salticidae.Opcode, ds salticidae.DataStream) (Msg, error) {
+// Parse attempts to convert bytes into a message.
+// The first byte of the message is the opcode of the message.
+func (Codec) Parse(b []byte) (Msg, error) {
+	p := wrappers.Packer{Bytes: b}
+	op := Op(p.UnpackByte())
+
 	message, ok := Messages[op]
 	if !ok {
 		return nil, errBadOp
 	}
 
-	// TODO: make this work without copy
-	size := ds.Size()
-	p := wrappers.Packer{Bytes: make([]byte, size)}
-
-	byteHandle := ds.GetDataInPlace(size)
-	defer byteHandle.Release()
+	fields := make(map[Field]interface{}, len(message))
+	for p.Offset < len(b) && !p.Errored() {
+		field := Field(p.UnpackShort())
+		fieldBytes := p.UnpackBytes()
+		if p.Errored() {
+			break
+		}
 
-	copy(p.Bytes, byteHandle.Get())
+		if !containsField(message, field) && !containsField(OptionalFields[op], field) {
+			// This field was added by a newer version of the protocol
+			continue
+		}
+		if _, exists := fields[field]; exists {
+			p.Add(errDuplicateField)
+			break
+		}
 
-	fields := make(map[Field]interface{}, len(message))
-	for _, field := range message {
-		fields[field] = field.Unpacker()(&p)
+		fieldPacker := wrappers.Packer{Bytes: fieldBytes}
+		fields[field] = field.Unpacker()(&fieldPacker)
+		if fieldPacker.Offset != len(fieldBytes) {
+			fieldPacker.Add(errBadLength)
+		}
+		p.Add(fieldPacker.Err)
 	}
 
-	if p.Offset != size {
-		return nil, errBadLength
+	if !p.Errored() {
+		for _, field := range message {
+			if _, ok := fields[field]; !ok {
+				p.Add(errMissingField)
+				break
+			}
+		}
 	}
 
 	return &msg{
 		op:     op,
-		ds:     ds,
 		fields: fields,
+		bytes:  b,
 	}, p.Err
 }
+
+// packField packs the tag and the length prefixed value of the field
+func packField(p *wrappers.Packer, field Field, data interface{}) {
+	fieldPacker := wrappers.Packer{MaxSize: math.MaxInt32}
+	field.Packer()(&fieldPacker, data)
+	p.Add(fieldPacker.Err)
+
+	p.PackShort(uint16(field))
+	p.PackBytes(fieldPacker.Bytes)
+}
+
+func containsField(fields []Field, field Field) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
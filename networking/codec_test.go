@@ -0,0 +1,234 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/utils/wrappers"
+)
+
+func TestCodecPackParse(t *testing.T) {
+	chainID := ids.NewID([32]byte{1})
+	containerID := ids.NewID([32]byte{2})
+	container := []byte{3, 4, 5}
+
+	build := Builder{}
+	msg, err := build.Put(chainID, 6, containerID, container)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedMsg, err := build.Parse(msg.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if op := parsedMsg.Op(); op != Put {
+		t.Fatalf("Parsed op %s, expected %s", op, Put)
+	}
+	if b := parsedMsg.Get(ChainID).([]byte); !bytes.Equal(b, chainID.Bytes()) {
+		t.Fatalf("Parsed wrong chain ID")
+	}
+	if requestID := parsedMsg.Get(RequestID).(uint32); requestID != 6 {
+		t.Fatalf("Parsed request ID %d, expected %d", requestID, 6)
+	}
+	if b := parsedMsg.Get(ContainerID).([]byte); !bytes.Equal(b, containerID.Bytes()) {
+		t.Fatalf("Parsed wrong container ID")
+	}
+	if b := parsedMsg.Get(ContainerBytes).([]byte); !bytes.Equal(b, container) {
+		t.Fatalf("Parsed wrong container")
+	}
+}
+
+func TestCodecParseBadOp(t *testing.T) {
+	codec := Codec{}
+	if _, err := codec.Parse([]byte{byte(MultiPut) + 1}); err == nil {
+		t.Fatalf("Should have errored due to an unknown op")
+	}
+}
+
+func TestCodecParseExtraBytes(t *testing.T) {
+	build := Builder{}
+	msg, err := build.Ping()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := build.Parse(append(msg.Bytes(), 0)); err == nil {
+		t.Fatalf("Should have errored due to trailing bytes")
+	}
+}
+
+func TestCodecParseUnknownField(t *testing.T) {
+	chainID := ids.NewID([32]byte{1})
+
+	build := Builder{}
+	msg, err := build.GetAcceptedFrontier(chainID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Append a field that a newer version of the protocol could have added
+	p := wrappers.Packer{MaxSize: math.MaxInt32, Bytes: msg.Bytes(), Offset: len(msg.Bytes())}
+	p.PackShort(math.MaxUint16)
+	p.PackBytes([]byte{3, 4, 5})
+	if p.Errored() {
+		t.Fatal(p.Err)
+	}
+
+	parsedMsg, err := build.Parse(p.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := parsedMsg.Get(ChainID).([]byte); !bytes.Equal(b, chainID.Bytes()) {
+		t.Fatalf("Parsed wrong chain ID")
+	}
+	if requestID := parsedMsg.Get(RequestID).(uint32); requestID != 2 {
+		t.Fatalf("Parsed request ID %d, expected %d", requestID, 2)
+	}
+	if field := parsedMsg.Get(Field(math.MaxUint16)); field != nil {
+		t.Fatalf("Shouldn't have parsed the unknown field")
+	}
+}
+
+func TestCodecParseMissingField(t *testing.T) {
+	p := wrappers.Packer{MaxSize: math.MaxInt32}
+	p.PackByte(byte(GetAcceptedFrontier))
+	p.PackShort(uint16(RequestID))
+	p.PackBytes([]byte{0, 0, 0, 1})
+	if p.Errored() {
+		t.Fatal(p.Err)
+	}
+
+	codec := Codec{}
+	if _, err := codec.Parse(p.Bytes); err == nil {
+		t.Fatalf("Should have errored due to a missing chain ID")
+	}
+}
+
+func TestCodecParseDuplicateField(t *testing.T) {
+	build := Builder{}
+	msg, err := build.Data([]byte{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := wrappers.Packer{MaxSize: math.MaxInt32, Bytes: msg.Bytes(), Offset: len(msg.Bytes())}
+	p.PackShort(uint16(Bytes))
+	p.PackBytes([]byte{0, 0, 0, 1, 2})
+	if p.Errored() {
+		t.Fatal(p.Err)
+	}
+
+	if _, err := build.Parse(p.Bytes); err == nil {
+		t.Fatalf("Should have errored due to a duplicated field")
+	}
+}
+
+func TestCodecParseBadFieldLength(t *testing.T) {
+	p := wrappers.Packer{MaxSize: math.MaxInt32}
+	p.PackByte(byte(Data))
+	p.PackShort(uint16(Bytes))
+	p.PackBytes([]byte{0, 0, 0, 1, 2, 3})
+	if p.Errored() {
+		t.Fatal(p.Err)
+	}
+
+	codec := Codec{}
+	if _, err := codec.Parse(p.Bytes); err == nil {
+		t.Fatalf("Should have errored due to trailing bytes in a field")
+	}
+}
+
+func TestCodecPackParseOptionalField(t *testing.T) {
+	chainID := ids.NewID([32]byte{1})
+	containerID := ids.NewID([32]byte{2})
+	container := []byte{3, 4, 5}
+
+	build := Builder{}
+	msg, err := build.PushQuery(chainID, 6, containerID, container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedMsg, err := build.Parse(msg.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := parsedMsg.Get(ContainerCompression).(uint32); ok {
+		t.Fatalf("Shouldn't have parsed a compression algorithm")
+	}
+
+	msg, err = build.CompressedPushQuery(chainID, 6, containerID, container, GzipCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedMsg, err = build.Parse(msg.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compression, ok := parsedMsg.Get(ContainerCompression).(uint32); !ok {
+		t.Fatalf("Should have parsed a compression algorithm")
+	} else if Compression(compression) != GzipCompression {
+		t.Fatalf("Parsed compression %s, expected %s", Compression(compression), GzipCompression)
+	}
+}
+
+func TestCodecPackParseGetStateChunk(t *testing.T) {
+	chainID := ids.NewID([32]byte{1})
+	summaryID := ids.NewID([32]byte{2})
+
+	build := Builder{}
+	msg, err := build.GetStateChunk(chainID, 6, summaryID, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedMsg, err := build.Parse(msg.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if op := parsedMsg.Op(); op != GetStateChunk {
+		t.Fatalf("Parsed op %s, expected %s", op, GetStateChunk)
+	}
+	if b := parsedMsg.Get(ContainerID).([]byte); !bytes.Equal(b, summaryID.Bytes()) {
+		t.Fatalf("Parsed wrong summary ID")
+	}
+	if index := parsedMsg.Get(ChunkIndex).(uint32); index != 7 {
+		t.Fatalf("Parsed chunk index %d, expected %d", index, 7)
+	}
+}
+
+func TestCodecPackParseMultiPut(t *testing.T) {
+	chainID := ids.NewID([32]byte{1})
+	containers := [][]byte{{1, 2, 3}, {4}, {}}
+
+	build := Builder{}
+	msg, err := build.MultiPut(chainID, 6, containers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedMsg, err := build.Parse(msg.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if op := parsedMsg.Op(); op != MultiPut {
+		t.Fatalf("Parsed op %s, expected %s", op, MultiPut)
+	}
+	parsedContainers := parsedMsg.Get(MultiContainerBytes).([][]byte)
+	if len(parsedContainers) != len(containers) {
+		t.Fatalf("Parsed %d containers, expected %d", len(parsedContainers), len(containers))
+	}
+	for i, container := range parsedContainers {
+		if !bytes.Equal(container, containers[i]) {
+			t.Fatalf("Parsed wrong container at %d", i)
+		}
+	}
+}
@@ -4,30 +4,39 @@
 package networking
 
 import (
-	"github.com/ava-labs/salticidae-go"
-
 	"github.com/ava-labs/gecko/utils/wrappers"
 )
 
 // Field that may be packed into a message
 type Field uint32
 
-// Fields that may be packed. These values are not sent over the wire.
+// Fields that may be packed. These values are sent over the wire as the tags
+// of the fields, so existing values must never be changed or reused. New fields
+// must be appended.
 const (
-	VersionStr     Field = iota // Used in handshake
-	NetworkID                   // Used in handshake
-	MyTime                      // Used in handshake
-	IP                          // Used in handshake
-	Peers                       // Used in handshake
-	ChainID                     // Used for dispatching
-	RequestID                   // Used for all messages
-	ContainerID                 // Used for querying
-	ContainerBytes              // Used for gossiping
-	ContainerIDs                // Used for querying
-	Bytes                       // Used as arbitrary data
-	TxID                        // Used for throughput tests
-	Tx                          // Used for throughput tests
-	Status                      // Used for throughput tests
+	VersionStr           Field = iota // Used in handshake
+	NetworkID                         // Used in handshake
+	MyTime                            // Used in handshake
+	IP                                // Used in handshake
+	Peers                             // Used in handshake
+	ChainID                           // Used for dispatching
+	RequestID                         // Used for all messages
+	ContainerID                       // Used for querying
+	ContainerBytes                    // Used for gossiping
+	ContainerIDs                      // Used for querying
+	Bytes                             // Used as arbitrary data
+	TxID                              // Used for throughput tests
+	Tx                                // Used for throughput tests
+	Status                            // Used for throughput tests
+	MsgVersion                        // Used in handshake
+	Compressions                      // Used in handshake
+	ContainerCompression              // Used for gossiping
+	IPTimestamp                       // Used in handshake
+	IPSignature                       // Used in handshake
+	SignedPeers                       // Used in handshake
+	AltIPs                            // Used in handshake
+	ChunkIndex                        // Used for state sync
+	MultiContainerBytes               // Used for fetching ancestors
 )
 
 // Packer returns the packer function that can be used to pack this field.
@@ -61,6 +70,24 @@ func (f Field) Packer() func(*wrappers.Packer, interface{}) {
 		return wrappers.TryPackBytes
 	case Status:
 		return wrappers.TryPackInt
+	case MsgVersion:
+		return wrappers.TryPackInt
+	case Compressions:
+		return wrappers.TryPackInt
+	case ContainerCompression:
+		return wrappers.TryPackInt
+	case IPTimestamp:
+		return wrappers.TryPackLong
+	case IPSignature:
+		return wrappers.TryPackBytes
+	case SignedPeers:
+		return tryPackSignedPeers
+	case AltIPs:
+		return tryPackSignedIPs
+	case ChunkIndex:
+		return wrappers.TryPackInt
+	case MultiContainerBytes:
+		return wrappers.TryPack2DBytes
 	default:
 		return nil
 	}
@@ -97,6 +124,24 @@ func (f Field) Unpacker() func(*wrappers.Packer) interface{} {
 		return wrappers.TryUnpackBytes
 	case Status:
 		return wrappers.TryUnpackInt
+	case MsgVersion:
+		return wrappers.TryUnpackInt
+	case Compressions:
+		return wrappers.TryUnpackInt
+	case ContainerCompression:
+		return wrappers.TryUnpackInt
+	case IPTimestamp:
+		return wrappers.TryUnpackLong
+	case IPSignature:
+		return wrappers.TryUnpackBytes
+	case SignedPeers:
+		return tryUnpackSignedPeers
+	case AltIPs:
+		return tryUnpackSignedIPs
+	case ChunkIndex:
+		return wrappers.TryUnpackInt
+	case MultiContainerBytes:
+		return wrappers.TryUnpack2DBytes
 	default:
 		return nil
 	}
@@ -116,6 +161,8 @@ func (f Field) String() string {
 		return "Peers"
 	case ChainID:
 		return "ChainID"
+	case RequestID:
+		return "RequestID"
 	case ContainerID:
 		return "ContainerID"
 	case ContainerBytes:
@@ -130,15 +177,36 @@ func (f Field) String() string {
 		return "Tx"
 	case Status:
 		return "Status"
+	case MsgVersion:
+		return "MsgVersion"
+	case Compressions:
+		return "Compressions"
+	case ContainerCompression:
+		return "Container Compression"
+	case IPTimestamp:
+		return "IP Timestamp"
+	case IPSignature:
+		return "IP Signature"
+	case SignedPeers:
+		return "Signed Peers"
+	case AltIPs:
+		return "Alt IPs"
+	case ChunkIndex:
+		return "Chunk Index"
+	case MultiContainerBytes:
+		return "Multi Container Bytes"
 	default:
 		return "Unknown Field"
 	}
 }
 
+// Op is an opcode
+type Op byte
+
 // Public commands that may be sent between stakers
 const (
 	// Handshake:
-	GetVersion salticidae.Opcode = iota
+	GetVersion Op = iota
 	Version
 	GetPeerList
 	PeerList
@@ -161,14 +229,22 @@ const (
 	// Throughput test:
 	IssueTx
 	DecidedTx
+	// State sync:
+	GetStateSummary
+	StateSummary
+	GetStateChunk
+	StateChunk
+	// Bootstrapping:
+	GetAncestors
+	MultiPut
 )
 
 // Defines the messages that can be sent/received with this network
 var (
-	Messages = map[salticidae.Opcode][]Field{
+	Messages = map[Op][]Field{
 		// Handshake:
 		GetVersion:  []Field{},
-		Version:     []Field{NetworkID, MyTime, IP, VersionStr},
+		Version:     []Field{NetworkID, MyTime, IP, VersionStr, MsgVersion},
 		GetPeerList: []Field{},
 		PeerList:    []Field{Peers},
 		// Bootstrapping:
@@ -190,5 +266,80 @@ var (
 		// Throughput test:
 		IssueTx:   []Field{ChainID, Tx},
 		DecidedTx: []Field{TxID, Status},
+		// State sync:
+		GetStateSummary: []Field{ChainID, RequestID},
+		StateSummary:    []Field{ChainID, RequestID, ContainerBytes},
+		GetStateChunk:   []Field{ChainID, RequestID, ContainerID, ChunkIndex},
+		StateChunk:      []Field{ChainID, RequestID, ContainerBytes},
+		// Bootstrapping:
+		GetAncestors: []Field{ChainID, RequestID, ContainerID},
+		MultiPut:     []Field{ChainID, RequestID, MultiContainerBytes},
+	}
+
+	// OptionalFields defines the fields that may be omitted from a message.
+	// These are fields that were added to an op after it was first defined,
+	// so peers that speak an older version of the protocol won't send them.
+	OptionalFields = map[Op][]Field{
+		// Handshake:
+		Version:  []Field{Compressions, IPTimestamp, IPSignature, AltIPs},
+		PeerList: []Field{SignedPeers},
+		// Consensus:
+		Put:       []Field{ContainerCompression},
+		PushQuery: []Field{ContainerCompression},
 	}
 )
+
+func (op Op) String() string {
+	switch op {
+	case GetVersion:
+		return "get_version"
+	case Version:
+		return "version"
+	case GetPeerList:
+		return "get_peerlist"
+	case PeerList:
+		return "peerlist"
+	case GetAcceptedFrontier:
+		return "get_accepted_frontier"
+	case AcceptedFrontier:
+		return "accepted_frontier"
+	case GetAccepted:
+		return "get_accepted"
+	case Accepted:
+		return "accepted"
+	case Get:
+		return "get"
+	case Put:
+		return "put"
+	case PushQuery:
+		return "push_query"
+	case PullQuery:
+		return "pull_query"
+	case Chits:
+		return "chits"
+	case Ping:
+		return "ping"
+	case Pong:
+		return "pong"
+	case Data:
+		return "data"
+	case IssueTx:
+		return "issue_tx"
+	case DecidedTx:
+		return "decided_tx"
+	case GetStateSummary:
+		return "get_state_summary"
+	case StateSummary:
+		return "state_summary"
+	case GetStateChunk:
+		return "get_state_chunk"
+	case StateChunk:
+		return "state_chunk"
+	case GetAncestors:
+		return "get_ancestors"
+	case MultiPut:
+		return "multi_put"
+	default:
+		return "Unknown Op"
+	}
+}
@@ -0,0 +1,116 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	// minCompressionSize is the smallest container that is worth compressing
+	minCompressionSize = 256
+
+	// maxDecompressedSize is the largest container that will be decompressed.
+	// A container that is larger than this couldn't have been sent
+	// uncompressed either.
+	maxDecompressedSize = 1 << 25
+)
+
+var (
+	errUnknownCompression = errors.New("unknown compression algorithm")
+	errDecompressedSize   = errors.New("decompressed container is too large")
+)
+
+// Compression is an algorithm that containers can be compressed with before
+// they are sent in Put and PushQuery messages
+type Compression uint32
+
+// Compression algorithms that can be negotiated with peers. These values are
+// sent over the wire, so existing values must never be changed or reused.
+const (
+	NoCompression Compression = iota
+	GzipCompression
+)
+
+// SupportedCompressions is the set of compression algorithms that this node
+// can decompress. Each algorithm is represented by the bit of its value.
+const SupportedCompressions uint32 = 1 << GzipCompression
+
+// negotiateCompression returns the compression algorithm to use when sending
+// containers to a peer that supports [peerCompressions]
+func negotiateCompression(myCompressions uint32, peerCompressions uint32) Compression {
+	if shared := myCompressions & peerCompressions; shared&(1<<GzipCompression) != 0 {
+		return GzipCompression
+	}
+	return NoCompression
+}
+
+// Compress the container. Returns false if the compressed container wouldn't
+// be smaller than the original, in which case it should be sent uncompressed.
+func (c Compression) Compress(container []byte) ([]byte, bool) {
+	if c == NoCompression || len(container) < minCompressionSize {
+		return nil, false
+	}
+
+	buf := bytes.Buffer{}
+	switch c {
+	case GzipCompression:
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(container); err != nil {
+			return nil, false
+		}
+		if err := writer.Close(); err != nil {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	if buf.Len() >= len(container) {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// Decompress the container
+func (c Compression) Decompress(compressed []byte) ([]byte, error) {
+	var reader io.Reader
+	switch c {
+	case NoCompression:
+		return compressed, nil
+	case GzipCompression:
+		gzipReader, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	default:
+		return nil, errUnknownCompression
+	}
+
+	container, err := ioutil.ReadAll(io.LimitReader(reader, maxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(container) > maxDecompressedSize {
+		return nil, errDecompressedSize
+	}
+	return container, nil
+}
+
+func (c Compression) String() string {
+	switch c {
+	case NoCompression:
+		return "none"
+	case GzipCompression:
+		return "gzip"
+	default:
+		return "Unknown Compression"
+	}
+}
@@ -0,0 +1,65 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestCompressionRoundTrip(t *testing.T) {
+	container := bytes.Repeat([]byte{1, 2, 3, 4}, 1024)
+
+	compressed, ok := GzipCompression.Compress(container)
+	if !ok {
+		t.Fatalf("Should have compressed a repetitive container")
+	}
+	if len(compressed) >= len(container) {
+		t.Fatalf("Compressed container should have been smaller")
+	}
+
+	decompressed, err := GzipCompression.Decompress(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decompressed, container) {
+		t.Fatalf("Decompressed the wrong container")
+	}
+}
+
+func TestCompressionSkipsSmallContainers(t *testing.T) {
+	container := bytes.Repeat([]byte{1}, minCompressionSize-1)
+	if _, ok := GzipCompression.Compress(container); ok {
+		t.Fatalf("Shouldn't have compressed a small container")
+	}
+}
+
+func TestCompressionSkipsIncompressibleContainers(t *testing.T) {
+	container := make([]byte, 1024)
+	if _, err := rand.Read(container); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := GzipCompression.Compress(container); ok {
+		t.Fatalf("Shouldn't have compressed a random container")
+	}
+}
+
+func TestCompressionDecompressUnknown(t *testing.T) {
+	if _, err := (GzipCompression + 1).Decompress([]byte{1}); err == nil {
+		t.Fatalf("Should have errored due to an unknown compression algorithm")
+	}
+}
+
+func TestNegotiateCompression(t *testing.T) {
+	if compression := negotiateCompression(SupportedCompressions, SupportedCompressions); compression != GzipCompression {
+		t.Fatalf("Negotiated %s, expected %s", compression, GzipCompression)
+	}
+	if compression := negotiateCompression(SupportedCompressions, 0); compression != NoCompression {
+		t.Fatalf("Negotiated %s, expected %s", compression, NoCompression)
+	}
+	if compression := negotiateCompression(0, SupportedCompressions); compression != NoCompression {
+		t.Fatalf("Negotiated %s, expected %s", compression, NoCompression)
+	}
+}
@@ -0,0 +1,171 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"bufio"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/utils"
+	"github.com/ava-labs/gecko/utils/wrappers"
+)
+
+var (
+	errMsgTooLarge = errors.New("message exceeds the maximum message size")
+)
+
+// Conn is an authenticated connection to a remote node
+type Conn interface {
+	// PeerID uniquely identifies the remote node. If TLS is enabled, this is
+	// the hash of the remote node's certificate. Otherwise, it is the hash of
+	// the IP the remote node is listening on.
+	PeerID() ids.ID
+
+	// IP the remote node is listening on
+	IP() utils.IPDesc
+
+	// Cert the remote node authenticated with. Nil if TLS is disabled.
+	Cert() *x509.Certificate
+
+	// Send queues the message to be written to the remote node. Returns false
+	// if the message was dropped.
+	Send(Msg) bool
+
+	// Close the connection
+	Close()
+}
+
+type conn struct {
+	net      *msgNetwork
+	conn     net.Conn
+	outbound bool
+
+	id   ids.ID
+	ip   utils.IPDesc
+	cert *x509.Certificate
+
+	lock   sync.Mutex
+	closed bool
+	sender chan []byte
+}
+
+func (c *conn) PeerID() ids.ID { return c.id }
+
+func (c *conn) IP() utils.IPDesc { return c.ip }
+
+func (c *conn) Cert() *x509.Certificate { return c.cert }
+
+func (c *conn) Send(msg Msg) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return false
+	}
+
+	select {
+	case c.sender <- msg.Bytes():
+		return true
+	default:
+		c.net.log.Debug("Dropping %s message to %s due to a full send queue", msg.Op(), c.ip)
+		return false
+	}
+}
+
+func (c *conn) Close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return
+	}
+	c.closed = true
+
+	close(c.sender)
+	c.conn.Close()
+}
+
+// readMessages parses messages from the connection until the connection is
+// closed. Parsed messages are dispatched to the network's event loop.
+func (c *conn) readMessages() {
+	defer c.net.post(func() { c.net.disconnected(c) })
+	defer c.Close()
+
+	reader := bufio.NewReader(c.conn)
+	codec := Codec{}
+	for {
+		b, err := readFrame(reader, c.net.maxMessageSize)
+		if err != nil {
+			c.net.log.Verbo("Stopped reading from %s due to %s", c.ip, err)
+			return
+		}
+
+		msg, err := codec.Parse(b)
+		if err != nil {
+			c.net.log.Debug("Failed to parse message from %s due to %s", c.ip, err)
+			continue
+		}
+
+		if !c.net.post(func() { c.net.received(c, msg) }) {
+			return
+		}
+	}
+}
+
+// writeMessages writes queued messages to the connection until the connection
+// is closed.
+func (c *conn) writeMessages() {
+	writer := bufio.NewWriter(c.conn)
+	for b := range c.sender {
+		if err := writeFrame(writer, b); err != nil {
+			c.net.log.Verbo("Stopped writing to %s due to %s", c.ip, err)
+			c.Close()
+			break
+		}
+		if len(c.sender) == 0 {
+			if err := writer.Flush(); err != nil {
+				c.net.log.Verbo("Stopped writing to %s due to %s", c.ip, err)
+				c.Close()
+				break
+			}
+		}
+	}
+
+	// Drain the queue so that Send never blocks on a dead connection
+	for range c.sender {
+	}
+}
+
+// readFrame reads a length prefixed frame from the reader
+func readFrame(reader io.Reader, maxSize uint32) ([]byte, error) {
+	lenBytes := [wrappers.IntLen]byte{}
+	if _, err := io.ReadFull(reader, lenBytes[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(lenBytes[:])
+	if size > maxSize {
+		return nil, errMsgTooLarge
+	}
+
+	b := make([]byte, size)
+	_, err := io.ReadFull(reader, b)
+	return b, err
+}
+
+// writeFrame writes a length prefixed frame to the writer
+func writeFrame(writer io.Writer, b []byte) error {
+	lenBytes := [wrappers.IntLen]byte{}
+	binary.BigEndian.PutUint32(lenBytes[:], uint32(len(b)))
+	if _, err := writer.Write(lenBytes[:]); err != nil {
+		return err
+	}
+	_, err := writer.Write(b)
+	return err
+}
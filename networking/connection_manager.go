@@ -0,0 +1,288 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/validators"
+	"github.com/ava-labs/gecko/utils"
+	"github.com/ava-labs/gecko/utils/logging"
+	"github.com/ava-labs/gecko/utils/random"
+	"github.com/ava-labs/gecko/utils/timer"
+)
+
+const (
+	// ConnectionManagerInterval is the amount of time between checks that
+	// enough validators are connected to
+	ConnectionManagerInterval = 5 * time.Second
+)
+
+// ConnectionManagerConfig defines how many validators the connection manager
+// keeps connections to, and how often it retries validators that can't be
+// reached
+type ConnectionManagerConfig struct {
+	// TargetValidators is the number of validators of each subnet this node
+	// validates that it tries to stay connected to
+	TargetValidators int
+
+	// MinBackoff is the amount of time to wait before redialing a validator
+	// the first time a connection attempt didn't succeed. The wait doubles
+	// with every failed attempt.
+	MinBackoff time.Duration
+
+	// MaxBackoff is the longest amount of time to wait before redialing a
+	// validator
+	MaxBackoff time.Duration
+}
+
+var (
+	errInvalidTargetValidators = errors.New("target number of validator connections must not be negative")
+	errInvalidBackoff          = errors.New("minimum redial backoff must be positive and at most the maximum redial backoff")
+)
+
+// Valid returns nil if the config describes a valid connection policy
+func (c ConnectionManagerConfig) Valid() error {
+	switch {
+	case c.TargetValidators < 0:
+		return errInvalidTargetValidators
+	case c.MinBackoff <= 0 || c.MinBackoff > c.MaxBackoff:
+		return errInvalidBackoff
+	default:
+		return nil
+	}
+}
+
+// ValidatorDialer connects to validators on behalf of the connection manager
+type ValidatorDialer interface {
+	// Connections that have completed the handshake
+	Connections() Connections
+
+	// KnownIP returns the last IP the validator was reachable at, either as
+	// gossiped by its peers or as seen when last connected to
+	KnownIP(ids.ShortID) (utils.IPDesc, bool)
+
+	// Connect attempts to connect to the peer at the IP
+	Connect(utils.IPDesc)
+}
+
+// SubnetCoverage describes how well connected this node is to the validators
+// of a subnet
+type SubnetCoverage struct {
+	// Validators is the number of validators of the subnet, other than this
+	// node
+	Validators int `json:"validators"`
+	// Target is the number of validators this node tries to be connected to
+	Target int `json:"target"`
+	// Connected is the number of validators this node is connected to
+	Connected int `json:"connected"`
+	// ConnectedWeight is the total weight of the connected validators
+	ConnectedWeight uint64 `json:"connectedWeight"`
+	// TotalWeight is the total weight of the validators, other than this node
+	TotalWeight uint64 `json:"totalWeight"`
+}
+
+// backoff tracks when a validator that wasn't connected to may be redialed
+type backoff struct {
+	delay time.Duration
+	next  time.Time
+}
+
+// ConnectionManager keeps connections open to a stake-weighted selection of the
+// validators of every subnet this node validates, so that polls of those
+// validators can be answered.
+type ConnectionManager struct {
+	log    logging.Logger
+	config ConnectionManagerConfig
+	myID   ids.ShortID
+	vdrs   validators.Manager
+	dialer ValidatorDialer
+	clock  timer.Clock
+
+	lock     sync.Mutex
+	backoffs map[[20]byte]*backoff       // keys are the IDs of validators that were dialed
+	coverage map[[32]byte]SubnetCoverage // keys are the IDs of subnets
+
+	connected, connectedWeight *prometheus.GaugeVec
+
+	repeater *timer.Repeater
+}
+
+// Initialize the connection manager and start maintaining connections
+func (cm *ConnectionManager) Initialize(
+	log logging.Logger,
+	config ConnectionManagerConfig,
+	myID ids.ShortID,
+	vdrs validators.Manager,
+	dialer ValidatorDialer,
+	registerer prometheus.Registerer,
+) {
+	cm.log = log
+	cm.config = config
+	cm.myID = myID
+	cm.vdrs = vdrs
+	cm.dialer = dialer
+	cm.backoffs = make(map[[20]byte]*backoff)
+	cm.coverage = make(map[[32]byte]SubnetCoverage)
+
+	cm.connected = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "gecko",
+			Name:      "validators_connected",
+			Help:      "Number of validators of each subnet this node is connected to",
+		},
+		[]string{"subnet"},
+	)
+	cm.connectedWeight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "gecko",
+			Name:      "validators_connected_weight",
+			Help:      "Fraction of the weight of each subnet's validators this node is connected to",
+		},
+		[]string{"subnet"},
+	)
+
+	if err := registerer.Register(cm.connected); err != nil {
+		log.Error("Failed to register validators_connected statistics due to %s", err)
+	}
+	if err := registerer.Register(cm.connectedWeight); err != nil {
+		log.Error("Failed to register validators_connected_weight statistics due to %s", err)
+	}
+
+	cm.repeater = timer.NewRepeater(cm.refresh, ConnectionManagerInterval)
+	go log.RecoverAndPanic(cm.repeater.Dispatch)
+}
+
+// Coverage returns how well connected this node is to the validators of
+// [subnetID]. Only subnets this node validates are reported.
+func (cm *ConnectionManager) Coverage(subnetID ids.ID) (SubnetCoverage, bool) {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	coverage, exists := cm.coverage[subnetID.Key()]
+	return coverage, exists
+}
+
+// Shutdown stops maintaining connections
+func (cm *ConnectionManager) Shutdown() { cm.repeater.Stop() }
+
+// refresh the coverage of every subnet this node validates, and dial
+// validators of the subnets that are below the target
+func (cm *ConnectionManager) refresh() {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	connections := cm.dialer.Connections()
+	coverage := make(map[[32]byte]SubnetCoverage)
+	validatorIDs := ids.ShortSet{}
+	for _, subnetID := range cm.vdrs.Subnets() {
+		vdrs, exists := cm.vdrs.GetValidatorSet(subnetID)
+		if !exists || !vdrs.Contains(cm.myID) {
+			continue
+		}
+
+		subnetCoverage := SubnetCoverage{}
+		unconnected := []validators.Validator{}
+		for _, vdr := range vdrs.List() {
+			vdrID := vdr.ID()
+			if vdrID.Equals(cm.myID) {
+				continue
+			}
+
+			validatorIDs.Add(vdrID)
+			subnetCoverage.Validators++
+			subnetCoverage.TotalWeight += vdr.Weight()
+			if connections.ContainsID(vdrID) {
+				subnetCoverage.Connected++
+				subnetCoverage.ConnectedWeight += vdr.Weight()
+				delete(cm.backoffs, vdrID.Key())
+			} else {
+				unconnected = append(unconnected, vdr)
+			}
+		}
+
+		subnetCoverage.Target = cm.config.TargetValidators
+		if subnetCoverage.Target > subnetCoverage.Validators {
+			subnetCoverage.Target = subnetCoverage.Validators
+		}
+		coverage[subnetID.Key()] = subnetCoverage
+
+		subnet := subnetID.String()
+		cm.connected.WithLabelValues(subnet).Set(float64(subnetCoverage.Connected))
+		if subnetCoverage.TotalWeight > 0 {
+			cm.connectedWeight.WithLabelValues(subnet).Set(float64(subnetCoverage.ConnectedWeight) / float64(subnetCoverage.TotalWeight))
+		}
+
+		if missing := subnetCoverage.Target - subnetCoverage.Connected; missing > 0 {
+			cm.log.Debug("Connected to %d of the %d targeted validators of subnet %s", subnetCoverage.Connected, subnetCoverage.Target, subnetID)
+			cm.dial(unconnected, missing)
+		}
+	}
+
+	// Forget the subnets this node no longer validates
+	for key := range cm.coverage {
+		if _, exists := coverage[key]; !exists {
+			subnet := ids.NewID(key).String()
+			cm.connected.DeleteLabelValues(subnet)
+			cm.connectedWeight.DeleteLabelValues(subnet)
+		}
+	}
+	cm.coverage = coverage
+
+	// Forget the backoffs of nodes that are no longer validators
+	for key := range cm.backoffs {
+		if !validatorIDs.Contains(ids.NewShortID(key)) {
+			delete(cm.backoffs, key)
+		}
+	}
+}
+
+// dial up to [n] of the [unconnected] validators, sampled by weight from the
+// validators whose address is known and that aren't backing off. Assumes the
+// lock is held.
+func (cm *ConnectionManager) dial(unconnected []validators.Validator, n int) {
+	now := cm.clock.Time()
+
+	candidates := []validators.Validator{}
+	ips := []utils.IPDesc{}
+	weights := []uint64{}
+	for _, vdr := range unconnected {
+		if b, exists := cm.backoffs[vdr.ID().Key()]; exists && now.Before(b.next) {
+			continue
+		}
+		ip, exists := cm.dialer.KnownIP(vdr.ID())
+		if !exists {
+			continue
+		}
+		candidates = append(candidates, vdr)
+		ips = append(ips, ip)
+		weights = append(weights, vdr.Weight())
+	}
+
+	sampler := random.Weighted{Weights: weights}
+	for ; n > 0 && sampler.CanSample(); n-- {
+		i := sampler.Sample()
+		vdrID := candidates[i].ID()
+
+		b, exists := cm.backoffs[vdrID.Key()]
+		if !exists {
+			b = &backoff{delay: cm.config.MinBackoff}
+			cm.backoffs[vdrID.Key()] = b
+		} else {
+			b.delay *= 2
+			if b.delay > cm.config.MaxBackoff {
+				b.delay = cm.config.MaxBackoff
+			}
+		}
+		b.next = now.Add(b.delay)
+
+		cm.log.Debug("Dialing validator %s at %s", vdrID, ips[i])
+		cm.dialer.Connect(ips[i])
+	}
+}
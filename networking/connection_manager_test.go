@@ -0,0 +1,193 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/validators"
+	"github.com/ava-labs/gecko/utils"
+	"github.com/ava-labs/gecko/utils/logging"
+)
+
+type testDialer struct {
+	connections Connections
+	ips         map[[20]byte]utils.IPDesc
+	dialed      []utils.IPDesc
+}
+
+func (d *testDialer) Connections() Connections { return d.connections }
+
+func (d *testDialer) KnownIP(id ids.ShortID) (utils.IPDesc, bool) {
+	ip, exists := d.ips[id.Key()]
+	return ip, exists
+}
+
+func (d *testDialer) Connect(ip utils.IPDesc) { d.dialed = append(d.dialed, ip) }
+
+// newTestConnectionManager returns a connection manager, for a node validating
+// [subnetID], whose validators are [vdrs]. Validator i is reachable at
+// 10.0.0.i.
+func newTestConnectionManager(myID ids.ShortID, subnetID ids.ID, vdrs []validators.Validator, target int) (*ConnectionManager, *testDialer) {
+	vdrSet := validators.NewSet()
+	vdrSet.Set(vdrs)
+	manager := validators.NewManager()
+	manager.PutValidatorSet(subnetID, vdrSet)
+
+	dialer := &testDialer{
+		connections: NewConnections(),
+		ips:         make(map[[20]byte]utils.IPDesc),
+	}
+	for i, vdr := range vdrs {
+		dialer.ips[vdr.ID().Key()] = utils.IPDesc{
+			IP:   net.IPv4(10, 0, 0, byte(i)),
+			Port: 9651,
+		}
+	}
+
+	cm := &ConnectionManager{}
+	cm.Initialize(logging.NoLog{}, ConnectionManagerConfig{
+		TargetValidators: target,
+		MinBackoff:       time.Second,
+		MaxBackoff:       4 * time.Second,
+	}, myID, manager, dialer, prometheus.NewRegistry())
+	cm.Shutdown()
+	cm.clock.Set(time.Unix(1000, 0))
+	return cm, dialer
+}
+
+func TestConnectionManagerDialsUpToTarget(t *testing.T) {
+	myID := ids.NewShortID([20]byte{255})
+	subnetID := ids.NewID([32]byte{1})
+	vdrs := []validators.Validator{validators.NewValidator(myID, 1)}
+	for i := 1; i <= 10; i++ {
+		vdrs = append(vdrs, validators.NewValidator(ids.NewShortID([20]byte{byte(i)}), uint64(i)))
+	}
+	cm, dialer := newTestConnectionManager(myID, subnetID, vdrs, 4)
+
+	// Already connected to one of the validators
+	dialer.connections.Add(ids.NewID([32]byte{1}), vdrs[1].ID(), dialer.ips[vdrs[1].ID().Key()])
+
+	cm.refresh()
+
+	if len(dialer.dialed) != 3 {
+		t.Fatalf("Dialed %d validators, expected %d", len(dialer.dialed), 3)
+	}
+	for _, ip := range dialer.dialed {
+		if ip.Equal(dialer.ips[myID.Key()]) {
+			t.Fatalf("Shouldn't dial myself")
+		}
+		if ip.Equal(dialer.ips[vdrs[1].ID().Key()]) {
+			t.Fatalf("Shouldn't dial a connected validator")
+		}
+	}
+
+	coverage, ok := cm.Coverage(subnetID)
+	if !ok {
+		t.Fatalf("Should report the coverage of a subnet I validate")
+	}
+	if coverage.Validators != 10 || coverage.Target != 4 || coverage.Connected != 1 {
+		t.Fatalf("Wrong coverage reported: %+v", coverage)
+	}
+	if coverage.ConnectedWeight != 1 || coverage.TotalWeight != 55 {
+		t.Fatalf("Wrong coverage weight reported: %+v", coverage)
+	}
+}
+
+func TestConnectionManagerIgnoresSubnetsNotValidated(t *testing.T) {
+	myID := ids.NewShortID([20]byte{255})
+	subnetID := ids.NewID([32]byte{1})
+	vdrs := []validators.Validator{
+		validators.NewValidator(ids.NewShortID([20]byte{1}), 1),
+		validators.NewValidator(ids.NewShortID([20]byte{2}), 1),
+	}
+	cm, dialer := newTestConnectionManager(myID, subnetID, vdrs, 4)
+
+	cm.refresh()
+
+	if len(dialer.dialed) != 0 {
+		t.Fatalf("Shouldn't dial validators of subnets I don't validate")
+	}
+	if _, ok := cm.Coverage(subnetID); ok {
+		t.Fatalf("Shouldn't report the coverage of subnets I don't validate")
+	}
+}
+
+func TestConnectionManagerBackoff(t *testing.T) {
+	myID := ids.NewShortID([20]byte{255})
+	subnetID := ids.NewID([32]byte{1})
+	vdrs := []validators.Validator{
+		validators.NewValidator(myID, 1),
+		validators.NewValidator(ids.NewShortID([20]byte{1}), 1),
+	}
+	cm, dialer := newTestConnectionManager(myID, subnetID, vdrs, 4)
+	now := cm.clock.Time()
+
+	// dials returns the number of times the validator was dialed after
+	// advancing the clock to [offset]
+	dials := func(offset time.Duration) int {
+		dialer.dialed = nil
+		cm.clock.Set(now.Add(offset))
+		cm.refresh()
+		return len(dialer.dialed)
+	}
+
+	expected := []struct {
+		offset time.Duration
+		dials  int
+	}{
+		{0, 1},
+		{500 * time.Millisecond, 0},
+		{time.Second, 1}, // backoff is now 2s
+		{2 * time.Second, 0},
+		{3 * time.Second, 1}, // backoff is now 4s
+		{6 * time.Second, 0},
+		{7 * time.Second, 1}, // backoff is capped at 4s
+		{11 * time.Second, 1},
+	}
+	for _, e := range expected {
+		if n := dials(e.offset); n != e.dials {
+			t.Fatalf("Dialed %d times at %s, expected %d", n, e.offset, e.dials)
+		}
+	}
+
+	// Once connected, the backoff is reset
+	dialer.connections.Add(ids.NewID([32]byte{1}), vdrs[1].ID(), dialer.ips[vdrs[1].ID().Key()])
+	if n := dials(11 * time.Second); n != 0 {
+		t.Fatalf("Shouldn't dial a connected validator")
+	}
+	dialer.connections.RemoveID(vdrs[1].ID())
+	if n := dials(11 * time.Second); n != 1 {
+		t.Fatalf("Should redial a disconnected validator immediately")
+	}
+}
+
+func TestConnectionManagerConfigValid(t *testing.T) {
+	valid := ConnectionManagerConfig{
+		TargetValidators: 20,
+		MinBackoff:       time.Second,
+		MaxBackoff:       time.Minute,
+	}
+	if err := valid.Valid(); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []func(*ConnectionManagerConfig){
+		func(c *ConnectionManagerConfig) { c.TargetValidators = -1 },
+		func(c *ConnectionManagerConfig) { c.MinBackoff = 0 },
+		func(c *ConnectionManagerConfig) { c.MaxBackoff = c.MinBackoff / 2 },
+	}
+	for i, invalidate := range invalid {
+		config := valid
+		invalidate(&config)
+		if err := config.Valid(); err == nil {
+			t.Fatalf("Config %d should have been invalid", i)
+		}
+	}
+}
@@ -4,11 +4,8 @@
 package networking
 
 import (
-	"fmt"
 	"sync"
 
-	"github.com/ava-labs/salticidae-go"
-
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/utils"
 )
@@ -16,23 +13,23 @@ import (
 // Connections provides an interface for what a group of connections will
 // support.
 type Connections interface {
-	Add(salticidae.PeerID, ids.ShortID, utils.IPDesc)
+	Add(ids.ID, ids.ShortID, utils.IPDesc)
 
-	GetPeerID(ids.ShortID) (salticidae.PeerID, bool)
-	GetID(salticidae.PeerID) (ids.ShortID, bool)
+	GetPeerID(ids.ShortID) (ids.ID, bool)
+	GetID(ids.ID) (ids.ShortID, bool)
 
-	ContainsPeerID(salticidae.PeerID) bool
+	ContainsPeerID(ids.ID) bool
 	ContainsID(ids.ShortID) bool
 	ContainsIP(utils.IPDesc) bool
 
-	Remove(salticidae.PeerID, ids.ShortID)
-	RemovePeerID(salticidae.PeerID)
+	Remove(ids.ID, ids.ShortID)
+	RemovePeerID(ids.ID)
 	RemoveID(ids.ShortID)
 
-	PeerIDs() []salticidae.PeerID
+	PeerIDs() []ids.ID
 	IDs() ids.ShortSet
 	IPs() []utils.IPDesc
-	Conns() ([]salticidae.PeerID, []ids.ShortID, []utils.IPDesc)
+	Conns() ([]ids.ID, []ids.ShortID, []utils.IPDesc)
 
 	Len() int
 }
@@ -42,7 +39,7 @@ type connections struct {
 	// peerID -> id
 	peerIDToID map[[32]byte]ids.ShortID
 	// id -> peerID
-	idToPeerID map[[20]byte]salticidae.PeerID
+	idToPeerID map[[20]byte]ids.ID
 	// id -> ip
 	idToIP map[[20]byte]utils.IPDesc
 }
@@ -51,13 +48,13 @@ type connections struct {
 func NewConnections() Connections {
 	return &connections{
 		peerIDToID: make(map[[32]byte]ids.ShortID),
-		idToPeerID: make(map[[20]byte]salticidae.PeerID),
+		idToPeerID: make(map[[20]byte]ids.ID),
 		idToIP:     make(map[[20]byte]utils.IPDesc),
 	}
 }
 
 // Add Assumes that peer is garbage collected normally
-func (c *connections) Add(peer salticidae.PeerID, id ids.ShortID, ip utils.IPDesc) {
+func (c *connections) Add(peer ids.ID, id ids.ShortID, ip utils.IPDesc) {
 	c.mux.Lock()
 	defer c.mux.Unlock()
 
@@ -65,7 +62,7 @@ func (c *connections) Add(peer salticidae.PeerID, id ids.ShortID, ip utils.IPDes
 }
 
 // GetPeerID returns the peer mapped to the id that is provided if one exists.
-func (c *connections) GetPeerID(id ids.ShortID) (salticidae.PeerID, bool) {
+func (c *connections) GetPeerID(id ids.ShortID) (ids.ID, bool) {
 	c.mux.Lock()
 	defer c.mux.Unlock()
 
@@ -73,7 +70,7 @@ func (c *connections) GetPeerID(id ids.ShortID) (salticidae.PeerID, bool) {
 }
 
 // GetID returns the id mapped to the peer that is provided if one exists.
-func (c *connections) GetID(peer salticidae.PeerID) (ids.ShortID, bool) {
+func (c *connections) GetID(peer ids.ID) (ids.ShortID, bool) {
 	c.mux.Lock()
 	defer c.mux.Unlock()
 
@@ -81,7 +78,7 @@ func (c *connections) GetID(peer salticidae.PeerID) (ids.ShortID, bool) {
 }
 
 // ContainsPeerID returns true if the peer is contained in the connection pool
-func (c *connections) ContainsPeerID(peer salticidae.PeerID) bool {
+func (c *connections) ContainsPeerID(peer ids.ID) bool {
 	_, exists := c.GetID(peer)
 	return exists
 }
@@ -104,7 +101,7 @@ func (c *connections) ContainsIP(ip utils.IPDesc) bool {
 
 // Remove ensures that no connection will have any mapping containing [peer] or
 // [id].
-func (c *connections) Remove(peer salticidae.PeerID, id ids.ShortID) {
+func (c *connections) Remove(peer ids.ID, id ids.ShortID) {
 	c.mux.Lock()
 	defer c.mux.Unlock()
 
@@ -112,7 +109,7 @@ func (c *connections) Remove(peer salticidae.PeerID, id ids.ShortID) {
 }
 
 // RemovePeerID ensures that no connection will have a mapping containing [peer]
-func (c *connections) RemovePeerID(peer salticidae.PeerID) {
+func (c *connections) RemovePeerID(peer ids.ID) {
 	c.mux.Lock()
 	defer c.mux.Unlock()
 
@@ -128,7 +125,7 @@ func (c *connections) RemoveID(id ids.ShortID) {
 }
 
 // PeerIDs returns the full list of peers contained in this connection pool.
-func (c *connections) PeerIDs() []salticidae.PeerID {
+func (c *connections) PeerIDs() []ids.ID {
 	c.mux.Lock()
 	defer c.mux.Unlock()
 
@@ -152,7 +149,7 @@ func (c *connections) IPs() []utils.IPDesc {
 }
 
 // Conns return the set of connections in this connection pool.
-func (c *connections) Conns() ([]salticidae.PeerID, []ids.ShortID, []utils.IPDesc) {
+func (c *connections) Conns() ([]ids.ID, []ids.ShortID, []utils.IPDesc) {
 	c.mux.Lock()
 	defer c.mux.Unlock()
 
@@ -167,32 +164,32 @@ func (c *connections) Len() int {
 	return c.len()
 }
 
-func (c *connections) add(peer salticidae.PeerID, id ids.ShortID, ip utils.IPDesc) {
+func (c *connections) add(peer ids.ID, id ids.ShortID, ip utils.IPDesc) {
 	c.remove(peer, id)
 
 	key := id.Key()
-	c.peerIDToID[toID(peer)] = id
+	c.peerIDToID[peer.Key()] = id
 	c.idToPeerID[key] = peer
 	c.idToIP[key] = ip
 }
 
-func (c *connections) getPeerID(id ids.ShortID) (salticidae.PeerID, bool) {
+func (c *connections) getPeerID(id ids.ShortID) (ids.ID, bool) {
 	peer, exists := c.idToPeerID[id.Key()]
 	return peer, exists
 }
 
-func (c *connections) getID(peer salticidae.PeerID) (ids.ShortID, bool) {
-	id, exists := c.peerIDToID[toID(peer)]
+func (c *connections) getID(peer ids.ID) (ids.ShortID, bool) {
+	id, exists := c.peerIDToID[peer.Key()]
 	return id, exists
 }
 
-func (c *connections) remove(peer salticidae.PeerID, id ids.ShortID) {
+func (c *connections) remove(peer ids.ID, id ids.ShortID) {
 	c.removePeerID(peer)
 	c.removeID(id)
 }
 
-func (c *connections) removePeerID(peer salticidae.PeerID) {
-	peerID := toID(peer)
+func (c *connections) removePeerID(peer ids.ID) {
+	peerID := peer.Key()
 	if id, exists := c.peerIDToID[peerID]; exists {
 		idKey := id.Key()
 
@@ -205,14 +202,14 @@ func (c *connections) removePeerID(peer salticidae.PeerID) {
 func (c *connections) removeID(id ids.ShortID) {
 	idKey := id.Key()
 	if peer, exists := c.idToPeerID[idKey]; exists {
-		delete(c.peerIDToID, toID(peer))
+		delete(c.peerIDToID, peer.Key())
 		delete(c.idToPeerID, idKey)
 		delete(c.idToIP, idKey)
 	}
 }
 
-func (c *connections) peerIDs() []salticidae.PeerID {
-	peers := make([]salticidae.PeerID, 0, len(c.idToPeerID))
+func (c *connections) peerIDs() []ids.ID {
+	peers := make([]ids.ID, 0, len(c.idToPeerID))
 	for _, peer := range c.idToPeerID {
 		peers = append(peers, peer)
 	}
@@ -235,8 +232,8 @@ func (c *connections) ips() []utils.IPDesc {
 	return ips
 }
 
-func (c *connections) conns() ([]salticidae.PeerID, []ids.ShortID, []utils.IPDesc) {
-	peers := make([]salticidae.PeerID, 0, len(c.idToPeerID))
+func (c *connections) conns() ([]ids.ID, []ids.ShortID, []utils.IPDesc) {
+	peers := make([]ids.ID, 0, len(c.idToPeerID))
 	idList := make([]ids.ShortID, 0, len(c.idToPeerID))
 	ips := make([]utils.IPDesc, 0, len(c.idToPeerID))
 	for id, peer := range c.idToPeerID {
@@ -248,33 +245,3 @@ func (c *connections) conns() ([]salticidae.PeerID, []ids.ShortID, []utils.IPDes
 }
 
 func (c *connections) len() int { return len(c.idToPeerID) }
-
-func toID(peer salticidae.PeerID) [32]byte {
-	ds := salticidae.NewDataStream(false)
-
-	peerInt := peer.AsUInt256()
-	peerInt.Serialize(ds)
-
-	size := ds.Size()
-	dsb := ds.GetDataInPlace(size)
-	idBytes := dsb.Get()
-
-	id := [32]byte{}
-	copy(id[:], idBytes)
-
-	ds.Free()
-	return id
-}
-
-func toIPDesc(addr salticidae.NetAddr) utils.IPDesc {
-	ip, err := ToIPDesc(addr)
-	HandshakeNet.log.AssertNoError(err)
-	return ip
-}
-
-// ToIPDesc converts an address to an IP
-func ToIPDesc(addr salticidae.NetAddr) (utils.IPDesc, error) {
-	ip := salticidae.FromBigEndianU32(addr.GetIP())
-	port := salticidae.FromBigEndianU16(addr.GetPort())
-	return utils.ToIPDesc(fmt.Sprintf("%d.%d.%d.%d:%d", byte(ip>>24), byte(ip>>16), byte(ip>>8), byte(ip), port))
-}
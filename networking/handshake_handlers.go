@@ -3,32 +3,21 @@
 
 package networking
 
-// #include "salticidae/network.h"
-// bool connHandler(msgnetwork_conn_t *, bool, void *);
-// void unknownPeerHandler(netaddr_t *, x509_t *, void *);
-// void peerHandler(peernetwork_conn_t *, bool, void *);
-// void ping(msg_t *, msgnetwork_conn_t *, void *);
-// void pong(msg_t *, msgnetwork_conn_t *, void *);
-// void getVersion(msg_t *, msgnetwork_conn_t *, void *);
-// void version(msg_t *, msgnetwork_conn_t *, void *);
-// void getPeerList(msg_t *, msgnetwork_conn_t *, void *);
-// void peerList(msg_t *, msgnetwork_conn_t *, void *);
-import "C"
-
 import (
+	"crypto"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"math"
 	"sync"
 	"time"
-	"unsafe"
 
 	"github.com/prometheus/client_golang/prometheus"
 
-	"github.com/ava-labs/salticidae-go"
-
+	"github.com/ava-labs/gecko/database"
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/snow/networking"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
 	"github.com/ava-labs/gecko/snow/validators"
 	"github.com/ava-labs/gecko/utils"
 	"github.com/ava-labs/gecko/utils/hashing"
@@ -64,6 +53,25 @@ Attempt reconnections
 const (
 	// CurrentVersion this avalanche instance is executing.
 	CurrentVersion = "avalanche/0.0.1"
+	// CurrentMsgVersion is the newest version of the message protocol this
+	// node speaks. It should be increased whenever a protocol feature is added
+	// that peers need to know about before it can be used.
+	CurrentMsgVersion uint32 = 3
+	// MinimumMsgVersion is the oldest version of the message protocol this
+	// node is willing to speak with a peer.
+	MinimumMsgVersion uint32 = 1
+	// SignedIPMsgVersion is the first version of the message protocol in which
+	// nodes sign the IPs they claim in the handshake, and gossip signed IPs in
+	// peer lists.
+	SignedIPMsgVersion uint32 = 2
+	// AncestorsMsgVersion is the first version of the message protocol in
+	// which nodes fetch containers in bulk with GetAncestors and MultiPut.
+	// Older peers are sent a Get instead.
+	AncestorsMsgVersion uint32 = 3
+	// StateSyncMsgVersion is the first version of the message protocol in
+	// which nodes serve state summaries and chunks. Older peers aren't asked
+	// for them.
+	StateSyncMsgVersion uint32 = 3
 	// MaxClockDifference allowed between connected nodes.
 	MaxClockDifference = time.Minute
 	// PeerListGossipSpacing is the amount of time to wait between pushing this
@@ -74,6 +82,11 @@ const (
 	// PeerListStakerGossipFraction calculates the fraction of stakers that are
 	// gossiped to. If set to 1, then only stakers will be gossiped to.
 	PeerListStakerGossipFraction = 2
+	// IPResignFrequency is the amount of time to wait between signing this
+	// node's IPs again, so that the claims peers gossip about it stay fresh.
+	IPResignFrequency = 10 * time.Minute
+	// MaxIPClaimAge is the age at which a signed IP is no longer trusted.
+	MaxIPClaimAge = time.Hour
 
 	// ConnectTimeout is the amount of time to wait before attempt to connect to
 	// an unknown peer
@@ -84,17 +97,28 @@ const (
 	// ReconnectTimeout is the amount of time to wait to reconnect to a staker
 	// before giving up
 	ReconnectTimeout = 10 * time.Minute
-)
-
-// Manager is the struct that will be accessed on event calls
-var (
-	HandshakeNet = Handshake{}
+	// ReconnectDelay is the amount of time to wait between attempts to connect
+	// to a peer
+	ReconnectDelay = time.Second
 )
 
 var (
 	errDSValidators = errors.New("couldn't get validator set of default subnet")
 )
 
+// PeerFeatures reports the protocol features that were negotiated with
+// connected peers
+type PeerFeatures interface {
+	PeerMsgVersion(ids.ShortID) (uint32, bool)
+	PeerCompression(ids.ShortID) Compression
+}
+
+// peerFeatures are the protocol features negotiated with a peer
+type peerFeatures struct {
+	msgVersion  uint32
+	compression Compression
+}
+
 // Handshake handles the authentication of new peers. Only valid stakers
 // will appear connected.
 type Handshake struct {
@@ -103,17 +127,18 @@ type Handshake struct {
 	networkID uint32 // ID of the network I'm running, used to prevent connecting to the wrong network
 
 	log           logging.Logger
-	vdrs          validators.Set         // set of current validators in the AVAnet
-	myAddr        salticidae.NetAddr     // IP I communicate to peers
-	myID          ids.ShortID            // ID that identifies myself as a staker or not
-	net           salticidae.PeerNetwork // C messaging network
-	enableStaking bool                   // Should only be false for local tests
+	vdrs          validators.Set      // set of current validators in the AVAnet
+	myID          ids.ShortID         // ID that identifies myself as a staker or not
+	net           PeerNetwork         // Go messaging network
+	enableStaking bool                // Should only be false for local tests
+	reputation    *reputation.Manager // Tracks the reputation of peers, and bans misbehaving ones
 
 	clock timer.Clock
 
-	// Connections that I have added by IP, but haven't gotten an ID from
+	// Connections that I have added by IP, but haven't gotten an ID from,
+	// along with the ID of the staker that claimed the IP, if it's known
 	requestedLock    sync.Mutex
-	requested        map[string]struct{}
+	requested        map[string]ids.ShortID
 	requestedTimeout timer.TimeoutManager // keys are hashes of the ip:port string
 
 	// Connections that I have added as a peer, but haven't gotten a version
@@ -125,6 +150,48 @@ type Handshake struct {
 	connections      Connections
 	reconnectTimeout timer.TimeoutManager // keys are the peer IDs
 
+	// Protocol features negotiated with each connected peer
+	featuresLock sync.Mutex
+	features     map[[20]byte]peerFeatures // keys are the IDs of the peers
+
+	// Compression algorithms that peers may use when sending me containers
+	compressions uint32
+
+	// The IPs I can be reached at, along with claims of them signed with my
+	// staking key. The claims are signed again periodically, and whenever my
+	// IP changes.
+	myIPLock       sync.Mutex
+	myAddr         utils.IPDesc   // IP I communicate to peers
+	myAltAddrs     []utils.IPDesc // Other IPs I can be reached at, such as an IPv6 address
+	mySignedIP     SignedIP
+	myAltSignedIPs []SignedIP
+	stakingKey     crypto.Signer
+	stakingCert    []byte
+	ipSigner       *timer.Repeater
+
+	// Address families that I can connect to peers over
+	dialIPv4, dialIPv6 bool
+
+	// Claims of the IPs connected peers can be reached at. When staking is
+	// enabled, the claims are signed by the peers, are replaced when newer
+	// claims of the peers are gossiped to me, and only these IPs are gossiped
+	// to peers that check signatures.
+	claimsLock sync.Mutex
+	claims     map[[20]byte][]SignedPeer // keys are the IDs of the peers
+
+	// Timestamp of the newest IP claim I've verified from each validator.
+	// Older claims are rejected, so that IPs a validator could previously be
+	// reached at can't be replayed.
+	newestClaims map[[20]byte]uint64 // keys are the IDs of the validators
+
+	// IPs from the newest claims I've verified from each validator, whether
+	// they were made during a handshake or gossiped to me, that I can connect
+	// to. Used to dial validators that I haven't been connected to yet.
+	claimedIPs map[[20]byte]utils.IPDesc // keys are the IDs of the validators
+
+	// Peers I've learned about, remembered across restarts
+	peers peerDB
+
 	// IPs of nodes I'm connected to will be repeatedly gossiped throughout the network
 	peerListGossiper *timer.Repeater
 
@@ -133,17 +200,23 @@ type Handshake struct {
 	awaiting     []*networking.AwaitingConnections
 }
 
-// Initialize to the c networking library. This should only be done once during
-// node setup.
+// Initialize to the peer network. This should only be done once during node
+// setup.
 func (nm *Handshake) Initialize(
 	log logging.Logger,
 	vdrs validators.Set,
-	myAddr salticidae.NetAddr,
+	myAddr utils.IPDesc,
+	altAddrs []utils.IPDesc,
 	myID ids.ShortID,
-	peerNet salticidae.PeerNetwork,
+	peerNet PeerNetwork,
 	registerer prometheus.Registerer,
 	enableStaking bool,
 	networkID uint32,
+	enableCompression bool,
+	reputation *reputation.Manager,
+	db database.Database,
+	stakingKey crypto.Signer,
+	stakingCert []byte,
 ) {
 	log.AssertTrue(nm.net == nil, "Should only register network handlers once")
 
@@ -153,12 +226,12 @@ func (nm *Handshake) Initialize(
 
 	nm.log = log
 	nm.vdrs = vdrs
-	nm.myAddr = myAddr
 	nm.myID = myID
 	nm.net = peerNet
 	nm.enableStaking = enableStaking
+	nm.reputation = reputation
 
-	nm.requested = make(map[string]struct{})
+	nm.requested = make(map[string]ids.ShortID)
 	nm.requestedTimeout.Initialize(ConnectTimeout)
 	go nm.log.RecoverAndPanic(nm.requestedTimeout.Dispatch)
 
@@ -167,28 +240,61 @@ func (nm *Handshake) Initialize(
 	go nm.log.RecoverAndPanic(nm.versionTimeout.Dispatch)
 
 	nm.connections = NewConnections()
+	nm.features = make(map[[20]byte]peerFeatures)
+	nm.claims = make(map[[20]byte][]SignedPeer)
+	nm.newestClaims = make(map[[20]byte]uint64)
+	nm.claimedIPs = make(map[[20]byte]utils.IPDesc)
+	nm.myAddr = myAddr
+	nm.myAltAddrs = altAddrs
+	nm.stakingKey = stakingKey
+	nm.stakingCert = stakingCert
+	nm.signIPs()
+	for _, addr := range append([]utils.IPDesc{myAddr}, altAddrs...) {
+		if addr.IsZero() {
+			continue
+		}
+		if addr.IsIPv4() {
+			nm.dialIPv4 = true
+		} else {
+			nm.dialIPv6 = true
+		}
+	}
+	if !nm.dialIPv4 && !nm.dialIPv6 {
+		// I don't know my own address, so any peer may be reachable
+		nm.dialIPv4 = true
+		nm.dialIPv6 = true
+	}
+	if enableCompression {
+		nm.compressions = SupportedCompressions
+	}
 	nm.reconnectTimeout.Initialize(ReconnectTimeout)
 	go nm.log.RecoverAndPanic(nm.reconnectTimeout.Dispatch)
 
+	nm.peers.Initialize(log, db)
+	nm.numKnownPeers.Set(float64(nm.peers.Len()))
+
 	nm.peerListGossiper = timer.NewRepeater(nm.gossipPeerList, PeerListGossipSpacing)
 	go nm.log.RecoverAndPanic(nm.peerListGossiper.Dispatch)
 
-	// register c message callbacks
-	net := peerNet.AsMsgNetwork()
-
-	net.RegConnHandler(salticidae.MsgNetworkConnCallback(C.connHandler), nil)
-	peerNet.RegPeerHandler(salticidae.PeerNetworkPeerCallback(C.peerHandler), nil)
-	peerNet.RegUnknownPeerHandler(salticidae.PeerNetworkUnknownPeerCallback(C.unknownPeerHandler), nil)
-	net.RegHandler(Ping, salticidae.MsgNetworkMsgCallback(C.ping), nil)
-	net.RegHandler(Pong, salticidae.MsgNetworkMsgCallback(C.pong), nil)
-	net.RegHandler(GetVersion, salticidae.MsgNetworkMsgCallback(C.getVersion), nil)
-	net.RegHandler(Version, salticidae.MsgNetworkMsgCallback(C.version), nil)
-	net.RegHandler(GetPeerList, salticidae.MsgNetworkMsgCallback(C.getPeerList), nil)
-	net.RegHandler(PeerList, salticidae.MsgNetworkMsgCallback(C.peerList), nil)
+	nm.ipSigner = timer.NewRepeater(nm.resignIPs, IPResignFrequency)
+	go nm.log.RecoverAndPanic(nm.ipSigner.Dispatch)
+
+	// register message callbacks
+	reputation.RegisterBanHandler(nm.disconnect)
+
+	peerNet.RegisterConnHandler(nm.connHandler)
+	peerNet.RegisterPeerHandler(nm.peerHandler)
+	peerNet.RegisterUnknownPeerHandler(nm.unknownPeerHandler)
+	peerNet.RegisterHandler(Ping, nm.ping)
+	peerNet.RegisterHandler(Pong, nm.pong)
+	peerNet.RegisterHandler(GetVersion, nm.getVersion)
+	peerNet.RegisterHandler(Version, nm.version)
+	peerNet.RegisterHandler(GetPeerList, nm.getPeerList)
+	peerNet.RegisterHandler(PeerList, nm.peerList)
 }
 
 // ConnectTo add the peer as a connection and connects to them.
-func (nm *Handshake) ConnectTo(peer salticidae.PeerID, stakerID ids.ShortID, addr salticidae.NetAddr) {
+func (nm *Handshake) ConnectTo(peer ids.ID, stakerID ids.ShortID, ip utils.IPDesc) {
 	if nm.pending.ContainsPeerID(peer) || nm.connections.ContainsPeerID(peer) {
 		return
 	}
@@ -196,16 +302,12 @@ func (nm *Handshake) ConnectTo(peer salticidae.PeerID, stakerID ids.ShortID, add
 	nm.log.Info("Attempting to connect to %s", stakerID)
 
 	nm.net.AddPeer(peer)
-	nm.net.SetPeerAddr(peer, addr)
-	nm.net.ConnPeer(peer, 600, 1)
+	nm.net.SetPeerAddr(peer, ip)
+	nm.net.ConnPeer(peer, ReconnectDelay, int(ReconnectTimeout/ReconnectDelay))
 
-	ip := toIPDesc(addr)
 	nm.pending.Add(peer, stakerID, ip)
 
-	peerBytes := toID(peer)
-	peerID := ids.NewID(peerBytes)
-
-	nm.reconnectTimeout.Put(peerID, func() {
+	nm.reconnectTimeout.Put(peer, func() {
 		nm.pending.Remove(peer, stakerID)
 		nm.connections.Remove(peer, stakerID)
 		nm.net.DelPeer(peer)
@@ -215,18 +317,23 @@ func (nm *Handshake) ConnectTo(peer salticidae.PeerID, stakerID ids.ShortID, add
 }
 
 // Connect ...
-func (nm *Handshake) Connect(addr salticidae.NetAddr) {
-	ip := toIPDesc(addr)
+func (nm *Handshake) Connect(ip utils.IPDesc) { nm.connect(ip, ids.ShortID{}) }
+
+// connect to [ip]. If [id] isn't empty, the connection is only kept if the peer
+// at [ip] is the staker [id].
+func (nm *Handshake) connect(ip utils.IPDesc, id ids.ShortID) {
 	ipStr := ip.String()
 	if nm.pending.ContainsIP(ip) || nm.connections.ContainsIP(ip) {
 		return
 	}
 
+	nm.peers.Attempted(ip)
+	nm.numKnownPeers.Set(float64(nm.peers.Len()))
+
 	if !nm.enableStaking {
 		nm.log.Info("Adding peer %s", ip)
 
-		peer := salticidae.NewPeerIDFromNetAddr(addr, true)
-		nm.ConnectTo(peer, toShortID(ip), addr)
+		nm.ConnectTo(ipToPeerID(ip), toShortID(ip), ip)
 		return
 	}
 
@@ -248,7 +355,7 @@ func (nm *Handshake) Connect(addr salticidae.NetAddr) {
 		defer nm.requestedLock.Unlock()
 
 		if *count == 100 {
-			nm.requested[ipStr] = struct{}{}
+			nm.requested[ipStr] = id
 		}
 
 		if _, exists := nm.requested[ipStr]; !exists {
@@ -267,8 +374,7 @@ func (nm *Handshake) Connect(addr salticidae.NetAddr) {
 
 		nm.log.Info("Attempting to discover peer at %s", ipStr)
 
-		msgNet := nm.net.AsMsgNetwork()
-		msgNet.Connect(addr)
+		nm.net.Connect(ip)
 
 		ipID := ids.NewID(hashing.ComputeHash256Array([]byte(ipStr)))
 		nm.requestedTimeout.Put(ipID, *handler)
@@ -276,6 +382,33 @@ func (nm *Handshake) Connect(addr salticidae.NetAddr) {
 	(*handler)()
 }
 
+// ConnectToKnownPeers attempts to connect to the most reliable of the peers
+// that were remembered from previous runs of this node
+func (nm *Handshake) ConnectToKnownPeers() {
+	for _, p := range nm.peers.Best(KnownPeersReconnectSize) {
+		if nm.isMyAddr(p.ip) || !nm.canDial(p.ip) || (!p.id.IsZero() && nm.reputation.Banned(p.id)) {
+			continue
+		}
+
+		nm.log.Debug("Reconnecting to known peer %s", p.ip)
+		nm.Connect(p.ip)
+	}
+}
+
+// KnownIP returns the IP that the validator [id] most recently claimed it can
+// be reached at, or else the IP that the staker was most recently connected to
+// at, if any
+func (nm *Handshake) KnownIP(id ids.ShortID) (utils.IPDesc, bool) {
+	nm.claimsLock.Lock()
+	ip, exists := nm.claimedIPs[id.Key()]
+	nm.claimsLock.Unlock()
+
+	if exists {
+		return ip, true
+	}
+	return nm.peers.IP(id)
+}
+
 // AwaitConnections ...
 func (nm *Handshake) AwaitConnections(awaiting *networking.AwaitingConnections) {
 	nm.awaitingLock.Lock()
@@ -323,7 +456,7 @@ func (nm *Handshake) gossipPeerList() {
 		idsToSend = append(idsToSend, nonStakers[sampler.Sample()])
 	}
 
-	peers := []salticidae.PeerID{}
+	peers := []ids.ID{}
 	for _, id := range idsToSend {
 		if peer, exists := nm.connections.GetPeerID(id); exists {
 			peers = append(peers, peer)
@@ -333,18 +466,27 @@ func (nm *Handshake) gossipPeerList() {
 	nm.SendPeerList(peers...)
 }
 
+// IsConnected returns true if I'm connected to the node with ID [id]. I'm
+// always considered to be connected to myself.
+func (nm *Handshake) IsConnected(id ids.ShortID) bool {
+	return nm.myID.Equals(id) || nm.connections.ContainsID(id)
+}
+
 // Connections returns the object that tracks the nodes that are currently
 // connected to this node.
 func (nm *Handshake) Connections() Connections { return nm.connections }
 
 // Shutdown the network
 func (nm *Handshake) Shutdown() {
+	nm.requestedTimeout.Stop()
 	nm.versionTimeout.Stop()
+	nm.reconnectTimeout.Stop()
 	nm.peerListGossiper.Stop()
+	nm.ipSigner.Stop()
 }
 
 // SendGetVersion to the requested peer
-func (nm *Handshake) SendGetVersion(peer salticidae.PeerID) {
+func (nm *Handshake) SendGetVersion(peer ids.ID) {
 	build := Builder{}
 	gv, err := build.GetVersion()
 	nm.log.AssertNoError(err)
@@ -354,9 +496,11 @@ func (nm *Handshake) SendGetVersion(peer salticidae.PeerID) {
 }
 
 // SendVersion to the requested peer
-func (nm *Handshake) SendVersion(peer salticidae.PeerID) error {
+func (nm *Handshake) SendVersion(peer ids.ID) error {
+	mySignedIP, myAltSignedIPs := nm.mySignedIPs()
+
 	build := Builder{}
-	v, err := build.Version(nm.networkID, nm.clock.Unix(), toIPDesc(nm.myAddr), CurrentVersion)
+	v, err := build.Version(nm.networkID, nm.clock.Unix(), mySignedIP, myAltSignedIPs, CurrentVersion, CurrentMsgVersion, nm.compressions)
 	if err != nil {
 		return fmt.Errorf("packing Version failed due to %s", err)
 	}
@@ -366,17 +510,52 @@ func (nm *Handshake) SendVersion(peer salticidae.PeerID) error {
 }
 
 // SendPeerList to the requested peer
-func (nm *Handshake) SendPeerList(peers ...salticidae.PeerID) error {
+func (nm *Handshake) SendPeerList(peers ...ids.ID) error {
 	if len(peers) == 0 {
 		return nil
 	}
 
 	_, ids, ips := nm.connections.Conns()
 	ipsToSend := []utils.IPDesc(nil)
+	signedPeersToSend := []SignedPeer(nil)
+	if nm.enableStaking && nm.vdrs.Contains(nm.myID) {
+		// My own claims are sent as well, so that peers learn when I sign my
+		// IPs again
+		mySignedIP, myAltSignedIPs := nm.mySignedIPs()
+		for _, signedIP := range append([]SignedIP{mySignedIP}, myAltSignedIPs...) {
+			if signedIP.IP.IsZero() || len(signedIP.Signature) == 0 {
+				continue
+			}
+			ipsToSend = append(ipsToSend, signedIP.IP)
+			signedPeersToSend = append(signedPeersToSend, SignedPeer{
+				SignedIP: signedIP,
+				Cert:     nm.stakingCert,
+			})
+		}
+	}
+	minTimestamp := nm.clock.Unix() - uint64(MaxIPClaimAge.Seconds())
 	for i, id := range ids {
 		ip := ips[i]
-		if !ip.IsZero() && nm.vdrs.Contains(id) {
+		if ip.IsZero() || !nm.vdrs.Contains(id) {
+			continue
+		}
+
+		claims := nm.getClaims(id)
+		if len(claims) == 0 {
+			// The peer didn't sign its IP, so its IP is only sent to peers
+			// that don't check signatures
 			ipsToSend = append(ipsToSend, ip)
+			continue
+		}
+		for _, claim := range claims {
+			if claim.IP.IsZero() {
+				continue
+			}
+			ipsToSend = append(ipsToSend, claim.IP)
+			// Expired claims would be rejected by the peers
+			if len(claim.Signature) > 0 && claim.Timestamp >= minTimestamp {
+				signedPeersToSend = append(signedPeersToSend, claim)
+			}
 		}
 	}
 
@@ -388,7 +567,7 @@ func (nm *Handshake) SendPeerList(peers ...salticidae.PeerID) error {
 	nm.log.Verbo("Sending %d ips to %d peer(s)", len(ipsToSend), len(peers))
 
 	build := Builder{}
-	pl, err := build.PeerList(ipsToSend)
+	pl, err := build.PeerList(ipsToSend, signedPeersToSend)
 	if err != nil {
 		return fmt.Errorf("Packing Peerlist failed due to %w", err)
 	}
@@ -397,84 +576,67 @@ func (nm *Handshake) SendPeerList(peers ...salticidae.PeerID) error {
 	return nil
 }
 
-func (nm *Handshake) send(msg Msg, peers ...salticidae.PeerID) {
-	ds := msg.DataStream()
-	defer ds.Free()
-	ba := salticidae.NewByteArrayMovedFromDataStream(ds, false)
-	defer ba.Free()
-	cMsg := salticidae.NewMsgMovedFromByteArray(msg.Op(), ba, false)
-	defer cMsg.Free()
-
-	switch len(peers) {
-	case 0:
-	case 1:
-		nm.net.SendMsg(cMsg, peers[0])
-	default:
-		nm.net.MulticastMsgByMove(cMsg, peers)
-	}
-}
+func (nm *Handshake) send(msg Msg, peers ...ids.ID) { nm.net.Send(msg, peers...) }
 
 // connHandler notifies of a new inbound connection
-//export connHandler
-func connHandler(_conn *C.struct_msgnetwork_conn_t, connected C.bool, _ unsafe.Pointer) C.bool {
-	if !HandshakeNet.enableStaking || !bool(connected) {
+func (nm *Handshake) connHandler(conn Conn, connected bool) bool {
+	if !nm.enableStaking || !connected {
 		return connected
 	}
 
-	HandshakeNet.requestedLock.Lock()
-	defer HandshakeNet.requestedLock.Unlock()
+	nm.requestedLock.Lock()
+	defer nm.requestedLock.Unlock()
 
-	conn := salticidae.MsgNetworkConnFromC(salticidae.CMsgNetworkConn(_conn))
-	addr := conn.GetAddr()
-	ip := toIPDesc(addr)
+	ip := conn.IP()
 	ipStr := ip.String()
 
 	ipID := ids.NewID(hashing.ComputeHash256Array([]byte(ipStr)))
-	HandshakeNet.requestedTimeout.Remove(ipID)
+	nm.requestedTimeout.Remove(ipID)
 
-	if _, exists := HandshakeNet.requested[ipStr]; !exists {
-		HandshakeNet.log.Debug("connHandler called with %s", ip)
+	expectedID, exists := nm.requested[ipStr]
+	if !exists {
+		nm.log.Debug("connHandler called with %s", ip)
 		return true
 	}
-	delete(HandshakeNet.requested, ipStr)
+	delete(nm.requested, ipStr)
 
-	cert := conn.GetPeerCert()
-	peer := salticidae.NewPeerIDFromX509(cert, true)
+	id := nm.getCert(conn.Cert())
+	if !expectedID.IsZero() && !expectedID.Equals(id) {
+		nm.log.Debug("Dropping connection to %s because it belongs to %s rather than %s, who claimed the IP", ip, id, expectedID)
+		return false
+	}
 
-	HandshakeNet.ConnectTo(peer, getCert(cert), addr)
+	nm.ConnectTo(conn.PeerID(), id, ip)
 	return true
 }
 
-func (nm *Handshake) connectedToPeer(conn *C.struct_peernetwork_conn_t, peer salticidae.PeerID) {
-	peerBytes := toID(peer)
-	peerID := ids.NewID(peerBytes)
-
+func (nm *Handshake) connectedToPeer(conn Conn, peer ids.ID) {
 	// If we're enforcing staking, use a peer's certificate to uniquely identify them
 	// Otherwise, use a hash of their ip to identify them
 	cert := ids.ShortID{}
 	if nm.enableStaking {
-		cert = getPeerCert(conn)
+		cert = nm.getCert(conn.Cert())
 	} else {
 		key := [20]byte{}
-		copy(key[:], peerID.Bytes())
+		copy(key[:], peer.Bytes())
 		cert = ids.NewShortID(key)
 	}
 
 	nm.log.Debug("Connected to %s", cert)
 
-	nm.reconnectTimeout.Remove(peerID)
+	nm.reconnectTimeout.Remove(peer)
 
 	handler := new(func())
 	*handler = func() {
 		if nm.pending.ContainsPeerID(peer) {
 			nm.SendGetVersion(peer)
-			nm.versionTimeout.Put(peerID, *handler)
+			nm.versionTimeout.Put(peer, *handler)
 		}
 	}
 	(*handler)()
 }
 
-func (nm *Handshake) disconnectedFromPeer(peer salticidae.PeerID) {
+func (nm *Handshake) disconnectedFromPeer(peer ids.ID) {
 	cert := ids.ShortID{}
 	if pendingCert, exists := nm.pending.GetID(peer); exists {
 		cert = pendingCert
@@ -486,15 +648,14 @@ func (nm *Handshake) disconnectedFromPeer(peer salticidae.PeerID) {
 		return
 	}
 
-	peerBytes := toID(peer)
-	peerID := ids.NewID(peerBytes)
-
-	nm.versionTimeout.Remove(peerID)
+	nm.versionTimeout.Remove(peer)
 	nm.connections.Remove(peer, cert)
+	nm.removeFeatures(cert)
+	nm.removeClaims(cert)
 	nm.numPeers.Set(float64(nm.connections.Len()))
 
 	if nm.vdrs.Contains(cert) {
-		nm.reconnectTimeout.Put(peerID, func() {
+		nm.reconnectTimeout.Put(peer, func() {
 			nm.pending.Remove(peer, cert)
 			nm.connections.Remove(peer, cert)
 			nm.net.DelPeer(peer)
@@ -513,7 +674,7 @@ func (nm *Handshake) disconnectedFromPeer(peer salticidae.PeerID) {
 
 	nm.awaitingLock.Lock()
 	defer nm.awaitingLock.Unlock()
-	for _, awaiting := range HandshakeNet.awaiting {
+	for _, awaiting := range nm.awaiting {
 		awaiting.Remove(cert)
 	}
 }
@@ -521,162 +682,195 @@ func (nm *Handshake) disconnectedFromPeer(peer salticidae.PeerID) {
 // peerHandler notifies a change to the set of connected peers
 // connected is true if a new peer is connected
 // connected is false if a formerly connected peer has disconnected
-//export peerHandler
-func peerHandler(_conn *C.struct_peernetwork_conn_t, connected C.bool, _ unsafe.Pointer) {
-	HandshakeNet.log.Debug("peerHandler called")
-
-	pConn := salticidae.PeerNetworkConnFromC(salticidae.CPeerNetworkConn(_conn))
-	peer := pConn.GetPeerID(true)
+func (nm *Handshake) peerHandler(conn Conn, connected bool) {
+	nm.log.Debug("peerHandler called")
 
+	peer := conn.PeerID()
 	if connected {
-		HandshakeNet.connectedToPeer(_conn, peer)
+		nm.connectedToPeer(conn, peer)
 	} else {
-		HandshakeNet.disconnectedFromPeer(peer)
+		nm.disconnectedFromPeer(peer)
 	}
 }
 
 // unknownPeerHandler notifies of an unknown peer connection attempt
-//export unknownPeerHandler
-func unknownPeerHandler(_addr *C.netaddr_t, _cert *C.x509_t, _ unsafe.Pointer) {
-	HandshakeNet.log.Debug("unknownPeerHandler called")
-
-	addr := salticidae.NetAddrFromC(salticidae.CNetAddr(_addr)).Copy(true)
-	ip := toIPDesc(addr)
+func (nm *Handshake) unknownPeerHandler(ip utils.IPDesc, cert *x509.Certificate) {
+	nm.log.Debug("unknownPeerHandler called")
 
-	HandshakeNet.log.Info("Adding peer %s", ip)
+	nm.log.Info("Adding peer %s", ip)
 
-	var peer salticidae.PeerID
+	var peer ids.ID
 	var id ids.ShortID
-	if HandshakeNet.enableStaking {
-		cert := salticidae.X509FromC(salticidae.CX509(_cert))
-		peer = salticidae.NewPeerIDFromX509(cert, true)
-		id = getCert(cert)
+	if nm.enableStaking {
+		peer = certToPeerID(cert.Raw)
+		id = nm.getCert(cert)
 	} else {
-		peer = salticidae.NewPeerIDFromNetAddr(addr, true)
+		peer = ipToPeerID(ip)
 		id = toShortID(ip)
 	}
 
-	peerBytes := toID(peer)
-	peerID := ids.NewID(peerBytes)
-
-	HandshakeNet.reconnectTimeout.Put(peerID, func() {
-		HandshakeNet.pending.Remove(peer, id)
-		HandshakeNet.connections.Remove(peer, id)
-		HandshakeNet.net.DelPeer(peer)
+	nm.reconnectTimeout.Put(peer, func() {
+		nm.pending.Remove(peer, id)
+		nm.connections.Remove(peer, id)
+		nm.net.DelPeer(peer)
 
-		HandshakeNet.numPeers.Set(float64(HandshakeNet.connections.Len()))
+		nm.numPeers.Set(float64(nm.connections.Len()))
 	})
-	HandshakeNet.pending.Add(peer, id, utils.IPDesc{})
-	HandshakeNet.net.AddPeer(peer)
+	nm.pending.Add(peer, id, utils.IPDesc{})
+	nm.net.AddPeer(peer)
 }
 
 // ping handles the recept of a ping message
-//export ping
-func ping(_ *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.Pointer) {
-	conn := salticidae.PeerNetworkConnFromC(salticidae.CPeerNetworkConn(_conn))
-	peer := conn.GetPeerID(false)
-	defer peer.Free()
-
+func (nm *Handshake) ping(_ Msg, conn Conn) {
 	build := Builder{}
 	pong, err := build.Pong()
-	HandshakeNet.log.AssertNoError(err)
+	nm.log.AssertNoError(err)
 
-	HandshakeNet.send(pong, peer)
+	nm.send(pong, conn.PeerID())
 }
 
 // pong handles the recept of a pong message
-//export pong
-func pong(*C.struct_msg_t, *C.struct_msgnetwork_conn_t, unsafe.Pointer) {}
+func (nm *Handshake) pong(Msg, Conn) {}
 
 // getVersion handles the recept of a getVersion message
-//export getVersion
-func getVersion(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.Pointer) {
-	HandshakeNet.numGetVersionReceived.Inc()
-
-	conn := salticidae.PeerNetworkConnFromC(salticidae.CPeerNetworkConn(_conn))
-	peer := conn.GetPeerID(false)
-	defer peer.Free()
+func (nm *Handshake) getVersion(_ Msg, conn Conn) {
+	nm.numGetVersionReceived.Inc()
 
-	HandshakeNet.SendVersion(peer)
+	nm.SendVersion(conn.PeerID())
 }
 
 // version handles the recept of a version message
-//export version
-func version(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.Pointer) {
-	HandshakeNet.numVersionReceived.Inc()
+func (nm *Handshake) version(msg Msg, conn Conn) {
+	nm.numVersionReceived.Inc()
 
-	msg := salticidae.MsgFromC(salticidae.CMsg(_msg))
-	conn := salticidae.PeerNetworkConnFromC(salticidae.CPeerNetworkConn(_conn))
-	peer := conn.GetPeerID(true)
+	peer := conn.PeerID()
 
-	peerBytes := toID(peer)
-	peerID := ids.NewID(peerBytes)
+	nm.versionTimeout.Remove(peer)
 
-	HandshakeNet.versionTimeout.Remove(peerID)
-
-	id, exists := HandshakeNet.pending.GetID(peer)
+	id, exists := nm.pending.GetID(peer)
 	if !exists {
-		HandshakeNet.log.Warn("Dropping Version message because the peer isn't pending")
+		nm.log.Warn("Dropping Version message because the peer isn't pending")
 		return
 	}
-	HandshakeNet.pending.Remove(peer, id)
+	nm.pending.Remove(peer, id)
 
-	build := Builder{}
-	pMsg, err := build.Parse(Version, msg.GetPayloadByMove())
-	if err != nil {
-		HandshakeNet.log.Warn("Failed to parse Version message")
+	if nm.reputation.Banned(id) {
+		nm.log.Debug("Dropping connection to %s because the peer is banned", id)
+
+		nm.net.DelPeer(peer)
+		return
+	}
+
+	if networkID := msg.Get(NetworkID).(uint32); networkID != nm.networkID {
+		nm.log.Warn("Peer's network ID doesn't match our networkID: Peer's = %d ; Ours = %d", networkID, nm.networkID)
 
-		HandshakeNet.net.DelPeer(peer)
+		nm.reputation.Report(id, reputation.HandshakeFailed)
+		nm.net.DelPeer(peer)
 		return
 	}
 
-	if networkID := pMsg.Get(NetworkID).(uint32); networkID != HandshakeNet.networkID {
-		HandshakeNet.log.Warn("Peer's network ID doesn't match our networkID: Peer's = %d ; Ours = %d", networkID, HandshakeNet.networkID)
+	myTime := float64(nm.clock.Unix())
+	if peerTime := float64(msg.Get(MyTime).(uint64)); math.Abs(peerTime-myTime) > MaxClockDifference.Seconds() {
+		nm.log.Warn("Peer's clock is too far out of sync with mine. His = %d, Mine = %d (seconds)", uint64(peerTime), uint64(myTime))
 
-		HandshakeNet.net.DelPeer(peer)
+		nm.reputation.Report(id, reputation.HandshakeFailed)
+		nm.net.DelPeer(peer)
 		return
 	}
 
-	myTime := float64(HandshakeNet.clock.Unix())
-	if peerTime := float64(pMsg.Get(MyTime).(uint64)); math.Abs(peerTime-myTime) > MaxClockDifference.Seconds() {
-		HandshakeNet.log.Warn("Peer's clock is too far out of sync with mine. His = %d, Mine = %d (seconds)", uint64(peerTime), uint64(myTime))
+	if peerVersion := msg.Get(VersionStr).(string); !checkCompatibility(CurrentVersion, peerVersion) {
+		nm.log.Warn("Bad version")
 
-		HandshakeNet.net.DelPeer(peer)
+		nm.reputation.Report(id, reputation.HandshakeFailed)
+		nm.net.DelPeer(peer)
 		return
 	}
 
-	if peerVersion := pMsg.Get(VersionStr).(string); !checkCompatibility(CurrentVersion, peerVersion) {
-		HandshakeNet.log.Warn("Bad version")
+	msgVersion, ok := negotiateMsgVersion(CurrentMsgVersion, msg.Get(MsgVersion).(uint32))
+	if !ok {
+		nm.log.Warn("Peer's message version is too old: Peer's = %d ; Minimum = %d", msg.Get(MsgVersion).(uint32), MinimumMsgVersion)
 
-		HandshakeNet.net.DelPeer(peer)
+		nm.reputation.Report(id, reputation.HandshakeFailed)
+		nm.net.DelPeer(peer)
 		return
 	}
 
-	ip := pMsg.Get(IP).(utils.IPDesc)
+	ip := msg.Get(IP).(utils.IPDesc)
+
+	// Peers that speak an older version of the Version message don't sign
+	// their IP, and don't send other IPs they can be reached at
+	timestamp, _ := msg.Get(IPTimestamp).(uint64)
+	signature, _ := msg.Get(IPSignature).([]byte)
+	altIPs, _ := msg.Get(AltIPs).([]SignedIP)
+	claimedIPs := append([]SignedIP{{
+		IP:        ip,
+		Timestamp: timestamp,
+		Signature: signature,
+	}}, altIPs...)
+
+	claims := []SignedPeer(nil)
+	switch {
+	case !nm.enableStaking:
+		for _, claimedIP := range claimedIPs {
+			claims = append(claims, SignedPeer{SignedIP: claimedIP})
+		}
+	case msgVersion >= SignedIPMsgVersion:
+		// Peers that sign their IPs must prove that they own the IPs they
+		// claim
+		cert := conn.Cert()
+		for _, claimedIP := range claimedIPs {
+			if err := nm.verifySignedIP(cert, claimedIP); err != nil {
+				nm.log.Warn("Peer's IP claim of %s is invalid due to %s", claimedIP.IP, err)
+
+				nm.reputation.Report(id, reputation.HandshakeFailed)
+				nm.net.DelPeer(peer)
+				return
+			}
+			claims = append(claims, SignedPeer{
+				SignedIP: claimedIP,
+				Cert:     cert.Raw,
+			})
+		}
+	}
+	nm.setClaims(id, claims)
 
-	HandshakeNet.log.Debug("Finishing handshake with %s", ip)
+	nm.log.Debug("Finishing handshake with %s", ip)
 
-	HandshakeNet.SendPeerList(peer)
-	HandshakeNet.connections.Add(peer, id, ip)
-	HandshakeNet.numPeers.Set(float64(HandshakeNet.connections.Len()))
+	nm.SendPeerList(peer)
+	// Peers that speak an older version of the Version message don't send
+	// the compression algorithms they support
+	peerCompressions, _ := msg.Get(Compressions).(uint32)
+	nm.setFeatures(id, peerFeatures{
+		msgVersion:  msgVersion,
+		compression: negotiateCompression(nm.compressions, peerCompressions),
+	})
+	nm.connections.Add(peer, id, ip)
+	nm.numPeers.Set(float64(nm.connections.Len()))
 
-	if !HandshakeNet.enableStaking {
-		HandshakeNet.vdrs.Add(validators.NewValidator(id, 1))
+	// When staking is enabled, only IPs whose ownership was proven are saved,
+	// so a peer that doesn't sign its IP can't claim an IP it doesn't own
+	if !nm.enableStaking || msgVersion >= SignedIPMsgVersion {
+		nm.peers.Connected(ip, id)
+		nm.numKnownPeers.Set(float64(nm.peers.Len()))
 	}
 
-	HandshakeNet.awaitingLock.Lock()
-	defer HandshakeNet.awaitingLock.Unlock()
+	if !nm.enableStaking {
+		nm.vdrs.Add(validators.NewValidator(id, 1))
+	}
+
+	nm.awaitingLock.Lock()
+	defer nm.awaitingLock.Unlock()
 
-	for i := 0; i < len(HandshakeNet.awaiting); i++ {
-		awaiting := HandshakeNet.awaiting[i]
+	for i := 0; i < len(nm.awaiting); i++ {
+		awaiting := nm.awaiting[i]
 		awaiting.Add(id)
 		if !awaiting.Ready() {
 			continue
 		}
 
-		newLen := len(HandshakeNet.awaiting) - 1
-		HandshakeNet.awaiting[i] = HandshakeNet.awaiting[newLen]
-		HandshakeNet.awaiting = HandshakeNet.awaiting[:newLen]
+		newLen := len(nm.awaiting) - 1
+		nm.awaiting[i] = nm.awaiting[newLen]
+		nm.awaiting = nm.awaiting[:newLen]
 
 		i--
 
@@ -684,60 +878,87 @@ func version(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.P
 	}
 }
 
-// getPeerList handles the recept of a getPeerList message
-//export getPeerList
-func getPeerList(_ *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.Pointer) {
-	HandshakeNet.numGetPeerlistReceived.Inc()
+// disconnect from the peer, if I'm connected to it. Called when the peer is
+// banned.
+func (nm *Handshake) disconnect(id ids.ShortID) {
+	if peer, exists := nm.connections.GetPeerID(id); exists {
+		nm.log.Info("Disconnecting from banned peer %s", id)
+		nm.net.DelPeer(peer)
+	} else if peer, exists := nm.pending.GetPeerID(id); exists {
+		nm.log.Info("Disconnecting from banned pending peer %s", id)
+		nm.net.DelPeer(peer)
+	}
+}
 
-	conn := salticidae.PeerNetworkConnFromC(salticidae.CPeerNetworkConn(_conn))
-	peer := conn.GetPeerID(false)
-	defer peer.Free()
+// getPeerList handles the recept of a getPeerList message
+func (nm *Handshake) getPeerList(_ Msg, conn Conn) {
+	nm.numGetPeerlistReceived.Inc()
 
-	HandshakeNet.SendPeerList(peer)
+	nm.SendPeerList(conn.PeerID())
 }
 
 // peerList handles the recept of a peerList message
-//export peerList
-func peerList(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.Pointer) {
-	HandshakeNet.numPeerlistReceived.Inc()
+func (nm *Handshake) peerList(msg Msg, conn Conn) {
+	nm.numPeerlistReceived.Inc()
 
-	msg := salticidae.MsgFromC(salticidae.CMsg(_msg))
-	build := Builder{}
-	pMsg, err := build.Parse(PeerList, msg.GetPayloadByMove())
-	if err != nil {
-		HandshakeNet.log.Warn("Failed to parse PeerList message due to %s", err)
-		// TODO: What should we do here?
+	if !nm.enableStaking {
+		ips := msg.Get(Peers).([]utils.IPDesc)
+		for _, ip := range ips {
+			if ip.IsZero() || nm.isMyAddr(ip) || !nm.canDial(ip) {
+				// Make sure not to connect to myself
+				continue
+			}
+
+			nm.Connect(ip)
+		}
 		return
 	}
 
-	ips := pMsg.Get(Peers).([]utils.IPDesc)
-	cErr := salticidae.NewError()
-	for _, ip := range ips {
-		addr := salticidae.NewNetAddrFromIPPortString(ip.String(), true, &cErr)
-
-		if cErr.GetCode() != 0 || HandshakeNet.myAddr.IsEq(addr) {
+	// When staking is enabled, only IPs that were signed by the validator that
+	// claims them are trusted, and the peer at the IP must be that validator.
+	// Unsigned IPs sent by older peers are ignored.
+	signedPeers, _ := msg.Get(SignedPeers).([]SignedPeer)
+	for _, signedPeer := range signedPeers {
+		if signedPeer.IP.IsZero() || nm.isMyAddr(signedPeer.IP) || !nm.canDial(signedPeer.IP) {
 			// Make sure not to connect to myself
 			continue
 		}
 
-		HandshakeNet.Connect(addr)
-	}
-}
+		cert, err := x509.ParseCertificate(signedPeer.Cert)
+		if err == nil {
+			err = nm.verifySignedIP(cert, signedPeer.SignedIP)
+		}
+		switch err {
+		case nil:
+		case errExpiredIPClaim, errStaleIPClaim:
+			// The peer may not have heard of the validator's newer claims yet
+			nm.log.Debug("Dropping peer list entry for %s due to %s", signedPeer.IP, err)
+			continue
+		default:
+			nm.log.Debug("Dropping peer list entry for %s due to %s", signedPeer.IP, err)
 
-func getPeerCert(_conn *C.struct_peernetwork_conn_t) ids.ShortID {
-	conn := salticidae.MsgNetworkConnFromC(salticidae.CMsgNetworkConn(_conn))
-	return getCert(conn.GetPeerCert())
-}
+			if id, exists := nm.connections.GetID(conn.PeerID()); exists {
+				nm.reputation.Report(id, reputation.InvalidPeerList)
+			}
+			continue
+		}
 
-func getCert(cert salticidae.X509) ids.ShortID {
-	der := cert.GetDer(false)
-	certDS := salticidae.NewDataStreamMovedFromByteArray(der, false)
-	certBytes := certDS.GetDataInPlace(certDS.Size()).Get()
-	certID, err := ids.ToShortID(hashing.PubkeyBytesToAddress(certBytes))
+		id := nm.getCert(cert)
+		if !nm.vdrs.Contains(id) {
+			nm.log.Debug("Dropping peer list entry for %s because %s isn't a validator", signedPeer.IP, id)
+			continue
+		}
+		if nm.connections.ContainsID(id) {
+			nm.refreshClaim(id, signedPeer)
+			continue
+		}
+		nm.connect(signedPeer.IP, id)
+	}
+}
 
-	certDS.Free()
-	der.Free()
-	HandshakeNet.log.AssertNoError(err)
+func (nm *Handshake) getCert(cert *x509.Certificate) ids.ShortID {
+	certID, err := ids.ToShortID(hashing.PubkeyBytesToAddress(cert.Raw))
+	nm.log.AssertNoError(err)
 	return certID
 }
 
@@ -747,6 +968,208 @@ func checkCompatibility(myVersion string, peerVersion string) bool {
 	return true
 }
 
+// negotiateMsgVersion returns the newest message version that both I and the
+// peer speak. Returns false if there is no such version.
+func negotiateMsgVersion(myVersion uint32, peerVersion uint32) (uint32, bool) {
+	version := myVersion
+	if peerVersion < version {
+		version = peerVersion
+	}
+	return version, version >= MinimumMsgVersion
+}
+
+// PeerMsgVersion returns the version of the message protocol that was
+// negotiated with the peer. Returns false if the peer isn't connected.
+func (nm *Handshake) PeerMsgVersion(id ids.ShortID) (uint32, bool) {
+	nm.featuresLock.Lock()
+	defer nm.featuresLock.Unlock()
+
+	features, exists := nm.features[id.Key()]
+	return features.msgVersion, exists
+}
+
+// PeerCompression returns the compression algorithm that containers sent to
+// the peer may be compressed with
+func (nm *Handshake) PeerCompression(id ids.ShortID) Compression {
+	nm.featuresLock.Lock()
+	defer nm.featuresLock.Unlock()
+
+	return nm.features[id.Key()].compression
+}
+
+func (nm *Handshake) setFeatures(id ids.ShortID, features peerFeatures) {
+	nm.featuresLock.Lock()
+	defer nm.featuresLock.Unlock()
+
+	nm.features[id.Key()] = features
+}
+
+func (nm *Handshake) removeFeatures(id ids.ShortID) {
+	nm.featuresLock.Lock()
+	defer nm.featuresLock.Unlock()
+
+	delete(nm.features, id.Key())
+}
+
+// verifySignedIP checks that [signedIP] was signed by the owner of [cert]
+// within the last MaxIPClaimAge, and wasn't signed in the future. If the owner
+// is a validator, the claim must also be at least as new as the newest claim
+// I've verified from it.
+func (nm *Handshake) verifySignedIP(cert *x509.Certificate, signedIP SignedIP) error {
+	now := nm.clock.Unix()
+	switch {
+	case signedIP.Timestamp > now+uint64(MaxClockDifference.Seconds()):
+		return errFutureIPTimestamp
+	case signedIP.Timestamp+uint64(MaxIPClaimAge.Seconds()) < now:
+		return errExpiredIPClaim
+	}
+	if err := signedIP.Verify(cert); err != nil {
+		return err
+	}
+
+	id := nm.getCert(cert)
+	if !nm.vdrs.Contains(id) {
+		return nil
+	}
+
+	nm.claimsLock.Lock()
+	defer nm.claimsLock.Unlock()
+
+	key := id.Key()
+	if signedIP.Timestamp < nm.newestClaims[key] {
+		return errStaleIPClaim
+	}
+	nm.newestClaims[key] = signedIP.Timestamp
+	if nm.canDial(signedIP.IP) {
+		nm.claimedIPs[key] = signedIP.IP
+	}
+	return nil
+}
+
+// SetIP changes the IP I communicate to peers to [ip]. If it changed, my IPs
+// are signed again, so that peers learn about the change.
+func (nm *Handshake) SetIP(ip utils.IPDesc) {
+	nm.myIPLock.Lock()
+	defer nm.myIPLock.Unlock()
+
+	if nm.myAddr.Equal(ip) {
+		return
+	}
+	nm.log.Info("My IP changed from %s to %s", nm.myAddr, ip)
+	nm.myAddr = ip
+	nm.signIPs()
+}
+
+// resignIPs signs the IPs I can be reached at again, so that my claims don't
+// expire
+func (nm *Handshake) resignIPs() {
+	nm.myIPLock.Lock()
+	defer nm.myIPLock.Unlock()
+
+	nm.signIPs()
+}
+
+// signIPs signs claims that I can be reached at myAddr and at myAltAddrs as of
+// now. Assumes myIPLock is held, or that Initialize is running.
+func (nm *Handshake) signIPs() {
+	timestamp := nm.clock.Unix()
+	nm.mySignedIP = nm.signIP(nm.myAddr, timestamp)
+	nm.myAltSignedIPs = nil
+	for _, altAddr := range nm.myAltAddrs {
+		nm.myAltSignedIPs = append(nm.myAltSignedIPs, nm.signIP(altAddr, timestamp))
+	}
+}
+
+// signIP returns my claim that I can be reached at [ip] as of [timestamp]. The
+// claim is only signed if staking is enabled.
+func (nm *Handshake) signIP(ip utils.IPDesc, timestamp uint64) SignedIP {
+	if !nm.enableStaking {
+		return SignedIP{IP: ip}
+	}
+
+	signedIP, err := signIP(nm.stakingKey, ip, timestamp)
+	if err != nil {
+		nm.log.Error("Failed to sign my IP %s due to %s", ip, err)
+		return SignedIP{IP: ip}
+	}
+	return signedIP
+}
+
+// mySignedIPs returns my current claims of the IPs I can be reached at
+func (nm *Handshake) mySignedIPs() (SignedIP, []SignedIP) {
+	nm.myIPLock.Lock()
+	defer nm.myIPLock.Unlock()
+
+	return nm.mySignedIP, nm.myAltSignedIPs
+}
+
+// isMyAddr returns true if [ip] is one of the IPs I can be reached at
+func (nm *Handshake) isMyAddr(ip utils.IPDesc) bool {
+	nm.myIPLock.Lock()
+	defer nm.myIPLock.Unlock()
+
+	if nm.myAddr.Equal(ip) {
+		return true
+	}
+	for _, altAddr := range nm.myAltAddrs {
+		if altAddr.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// canDial returns true if I'm able to connect to [ip]. Peers are only
+// connected to over the address families I can be reached over myself.
+func (nm *Handshake) canDial(ip utils.IPDesc) bool {
+	if ip.IsIPv4() {
+		return nm.dialIPv4
+	}
+	return nm.dialIPv6
+}
+
+// getClaims returns the claims of the IPs the peer can be reached at
+func (nm *Handshake) getClaims(id ids.ShortID) []SignedPeer {
+	nm.claimsLock.Lock()
+	defer nm.claimsLock.Unlock()
+
+	return nm.claims[id.Key()]
+}
+
+func (nm *Handshake) setClaims(id ids.ShortID, claims []SignedPeer) {
+	nm.claimsLock.Lock()
+	defer nm.claimsLock.Unlock()
+
+	if len(claims) == 0 {
+		delete(nm.claims, id.Key())
+	} else {
+		nm.claims[id.Key()] = claims
+	}
+}
+
+// refreshClaim adds [claim] to the claims of the connected peer [id], replacing
+// its older claims, so that the claims I gossip about the peer stay fresh
+func (nm *Handshake) refreshClaim(id ids.ShortID, claim SignedPeer) {
+	nm.claimsLock.Lock()
+	defer nm.claimsLock.Unlock()
+
+	key := id.Key()
+	claims := []SignedPeer{claim}
+	for _, oldClaim := range nm.claims[key] {
+		if oldClaim.Timestamp >= claim.Timestamp && !oldClaim.IP.Equal(claim.IP) {
+			claims = append(claims, oldClaim)
+		}
+	}
+	nm.claims[key] = claims
+}
+
+func (nm *Handshake) removeClaims(id ids.ShortID) {
+	nm.claimsLock.Lock()
+	defer nm.claimsLock.Unlock()
+
+	delete(nm.claims, id.Key())
+}
+
 func toShortID(ip utils.IPDesc) ids.ShortID {
 	return ids.NewShortID(hashing.ComputeHash160Array([]byte(ip.String())))
 }
@@ -0,0 +1,68 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"crypto/x509"
+	"net"
+	"testing"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/utils"
+	"github.com/ava-labs/gecko/utils/logging"
+)
+
+// testConn is a connection that only knows the IP and certificate of the
+// remote node
+type testConn struct {
+	ip   utils.IPDesc
+	cert *x509.Certificate
+}
+
+func (c *testConn) PeerID() ids.ID          { return certToPeerID(c.cert.Raw) }
+func (c *testConn) IP() utils.IPDesc        { return c.ip }
+func (c *testConn) Cert() *x509.Certificate { return c.cert }
+func (c *testConn) Send(Msg) bool           { return true }
+func (c *testConn) Close()                  {}
+
+func TestNegotiateMsgVersion(t *testing.T) {
+	if version, ok := negotiateMsgVersion(CurrentMsgVersion, CurrentMsgVersion+1); !ok {
+		t.Fatalf("Should have been able to speak with a newer peer")
+	} else if version != CurrentMsgVersion {
+		t.Fatalf("Negotiated version %d, expected %d", version, CurrentMsgVersion)
+	}
+
+	if version, ok := negotiateMsgVersion(CurrentMsgVersion+1, CurrentMsgVersion); !ok {
+		t.Fatalf("Should have been able to speak with an older peer")
+	} else if version != CurrentMsgVersion {
+		t.Fatalf("Negotiated version %d, expected %d", version, CurrentMsgVersion)
+	}
+
+	if _, ok := negotiateMsgVersion(CurrentMsgVersion, MinimumMsgVersion-1); ok {
+		t.Fatalf("Shouldn't have been able to speak with a peer older than the minimum version")
+	}
+}
+
+func TestHandshakeDropsConnectionToWrongStaker(t *testing.T) {
+	_, cert := testStakingKey(t)
+	ip := utils.IPDesc{
+		IP:   net.IPv4(1, 2, 3, 4),
+		Port: 9651,
+	}
+
+	nm := Handshake{
+		log:           logging.NoLog{},
+		enableStaking: true,
+		requested:     make(map[string]ids.ShortID),
+	}
+
+	// The IP was gossiped as belonging to another staker
+	nm.requested[ip.String()] = ids.NewShortID([20]byte{1})
+	if nm.connHandler(&testConn{ip: ip, cert: cert}, true) {
+		t.Fatalf("Should have dropped the connection to a staker that didn't claim the IP")
+	}
+	if _, exists := nm.requested[ip.String()]; exists {
+		t.Fatalf("Should have stopped connecting to the IP")
+	}
+}
@@ -10,7 +10,7 @@ import (
 )
 
 type handshakeMetrics struct {
-	numPeers prometheus.Gauge
+	numPeers, numKnownPeers prometheus.Gauge
 
 	numGetVersionSent, numGetVersionReceived,
 	numVersionSent, numVersionReceived,
@@ -25,6 +25,12 @@ func (hm *handshakeMetrics) Initialize(log logging.Logger, registerer prometheus
 			Name:      "peers",
 			Help:      "Number of network peers",
 		})
+	hm.numKnownPeers = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "gecko",
+			Name:      "known_peers",
+			Help:      "Number of peers remembered across restarts",
+		})
 	hm.numGetVersionSent = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Namespace: "gecko",
@@ -77,6 +83,9 @@ func (hm *handshakeMetrics) Initialize(log logging.Logger, registerer prometheus
 	if err := registerer.Register(hm.numPeers); err != nil {
 		log.Error("Failed to register peers statistics due to %s", err)
 	}
+	if err := registerer.Register(hm.numKnownPeers); err != nil {
+		log.Error("Failed to register known_peers statistics due to %s", err)
+	}
 	if err := registerer.Register(hm.numGetVersionSent); err != nil {
 		log.Error("Failed to register get_version_sent statistics due to %s", err)
 	}
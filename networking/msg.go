@@ -3,28 +3,24 @@
 
 package networking
 
-import (
-	"github.com/ava-labs/salticidae-go"
-)
-
 // Msg represents a set of fields that can be serialized into a byte stream
 type Msg interface {
-	Op() salticidae.Opcode
+	Op() Op
 	Get(Field) interface{}
-	DataStream() salticidae.DataStream
+	Bytes() []byte
 }
 
 type msg struct {
-	op     salticidae.Opcode
-	ds     salticidae.DataStream
+	op     Op
 	fields map[Field]interface{}
+	bytes  []byte
 }
 
-// Field returns the value of the specified field in this message
-func (msg *msg) Op() salticidae.Opcode { return msg.op }
+// Op returns the value of the specified operation in this message
+func (msg *msg) Op() Op { return msg.op }
 
-// Field returns the value of the specified field in this message
+// Get returns the value of the specified field in this message
 func (msg *msg) Get(field Field) interface{} { return msg.fields[field] }
 
 // Bytes returns this message in bytes
-func (msg *msg) DataStream() salticidae.DataStream { return msg.ds }
+func (msg *msg) Bytes() []byte { return msg.bytes }
@@ -0,0 +1,377 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/utils"
+	"github.com/ava-labs/gecko/utils/hashing"
+	"github.com/ava-labs/gecko/utils/logging"
+	"github.com/ava-labs/gecko/utils/wrappers"
+)
+
+const (
+	// DialTimeout is the amount of time to wait for an outbound connection to
+	// be established
+	DialTimeout = 10 * time.Second
+	// UpgradeTimeout is the amount of time to wait for a new connection to
+	// finish the TLS handshake and to exchange listening addresses
+	UpgradeTimeout = 10 * time.Second
+
+	// SendQueueSize is the number of messages that can be queued to be sent to
+	// a connection before messages start being dropped
+	SendQueueSize = 1 << 10
+	// EventQueueSize is the number of events that can be queued to be handled
+	// by the event loop before the readers block
+	EventQueueSize = 1 << 10
+)
+
+var (
+	errNoPeerCert = errors.New("remote node didn't provide a certificate")
+	errClosed     = errors.New("network has been closed")
+)
+
+// MsgHandler handles a message received from a connection
+type MsgHandler func(Msg, Conn)
+
+// ConnHandler is notified when a connection is established or closed.
+// connected is true if a new connection was established. If the connection was
+// established and false is returned, the connection will be closed.
+type ConnHandler func(conn Conn, connected bool) bool
+
+// MsgNetwork sends and receives messages over connections with remote nodes.
+// All registered handlers are called on a single event loop, which is run by
+// Dispatch.
+type MsgNetwork interface {
+	// RegisterHandler registers the handler that will be called when a message
+	// with the given opcode is received
+	RegisterHandler(Op, MsgHandler)
+
+	// RegisterConnHandler registers the handler that will be called when a
+	// connection is established or closed
+	RegisterConnHandler(ConnHandler)
+
+	// Listen for incoming connections on the port of the provided IP
+	Listen(utils.IPDesc) error
+
+	// Connect attempts to establish a connection to the provided IP in the
+	// background
+	Connect(utils.IPDesc)
+
+	// ConnectSync attempts to establish a connection to the provided IP and
+	// returns once the attempt has finished
+	ConnectSync(utils.IPDesc) (Conn, error)
+
+	// Dispatch runs the event loop. Returns once the network is closed.
+	Dispatch()
+
+	// Close the listener and all connections
+	Close()
+}
+
+type msgNetwork struct {
+	log            logging.Logger
+	transport      Transport
+	myIP           utils.IPDesc
+	tlsConfig      *tls.Config
+	maxMessageSize uint32
+
+	// Hooks into the event loop, overridden by the peer network
+	connected    func(*conn)
+	disconnected func(*conn)
+	received     func(*conn, Msg)
+
+	lock        sync.Mutex
+	handlers    map[Op]MsgHandler
+	connHandler ConnHandler
+	listener    net.Listener
+	conns       map[*conn]struct{}
+
+	events    chan func()
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewMsgNetwork returns a new message network that runs over [transport].
+// [myIP] is the IP that this node will tell remote nodes it is listening on. If
+// [tlsConfig] is nil, connections will not be encrypted or authenticated.
+func NewMsgNetwork(log logging.Logger, transport Transport, myIP utils.IPDesc, tlsConfig *tls.Config, maxMessageSize uint32) MsgNetwork {
+	return newMsgNetwork(log, transport, myIP, tlsConfig, maxMessageSize)
+}
+
+func newMsgNetwork(log logging.Logger, transport Transport, myIP utils.IPDesc, tlsConfig *tls.Config, maxMessageSize uint32) *msgNetwork {
+	n := &msgNetwork{
+		log:            log,
+		transport:      transport,
+		myIP:           myIP,
+		tlsConfig:      tlsConfig,
+		maxMessageSize: maxMessageSize,
+		handlers:       make(map[Op]MsgHandler),
+		conns:          make(map[*conn]struct{}),
+		events:         make(chan func(), EventQueueSize),
+		closed:         make(chan struct{}),
+	}
+	n.connected = n.connectedConn
+	n.disconnected = n.disconnectedConn
+	n.received = n.receivedMsg
+	return n
+}
+
+// RegisterHandler implements the MsgNetwork interface
+func (n *msgNetwork) RegisterHandler(op Op, handler MsgHandler) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.handlers[op] = handler
+}
+
+// RegisterConnHandler implements the MsgNetwork interface
+func (n *msgNetwork) RegisterConnHandler(handler ConnHandler) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.connHandler = handler
+}
+
+// Listen implements the MsgNetwork interface
+func (n *msgNetwork) Listen(ip utils.IPDesc) error {
+	listener, err := n.transport.Listen(ip)
+	if err != nil {
+		return err
+	}
+
+	n.lock.Lock()
+	n.listener = listener
+	n.lock.Unlock()
+
+	go n.log.RecoverAndPanic(func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				n.log.Debug("Stopped accepting connections due to %s", err)
+				return
+			}
+			go n.upgrade(c, false /*=outbound*/, utils.IPDesc{})
+		}
+	})
+	return nil
+}
+
+// Connect implements the MsgNetwork interface
+func (n *msgNetwork) Connect(ip utils.IPDesc) {
+	go func() {
+		if _, err := n.ConnectSync(ip); err != nil {
+			n.log.Debug("Failed to connect to %s due to %s", ip, err)
+		}
+	}()
+}
+
+// ConnectSync implements the MsgNetwork interface
+func (n *msgNetwork) ConnectSync(ip utils.IPDesc) (Conn, error) {
+	c, err := n.transport.Dial(ip, DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return n.upgrade(c, true /*=outbound*/, ip)
+}
+
+// Dispatch implements the MsgNetwork interface
+func (n *msgNetwork) Dispatch() {
+	for {
+		select {
+		case event := <-n.events:
+			event()
+		case <-n.closed:
+			return
+		}
+	}
+}
+
+// Close implements the MsgNetwork interface
+func (n *msgNetwork) Close() {
+	n.closeOnce.Do(func() {
+		close(n.closed)
+
+		n.lock.Lock()
+		defer n.lock.Unlock()
+
+		if n.listener != nil {
+			n.listener.Close()
+		}
+		for c := range n.conns {
+			c.Close()
+		}
+	})
+}
+
+// upgrade authenticates the raw connection and exchanges listening addresses
+// with the remote node. If the upgrade succeeds, the connection is registered
+// with the event loop.
+func (n *msgNetwork) upgrade(rawConn net.Conn, outbound bool, ip utils.IPDesc) (*conn, error) {
+	if err := rawConn.SetDeadline(time.Now().Add(UpgradeTimeout)); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+
+	c := &conn{
+		net:      n,
+		conn:     rawConn,
+		outbound: outbound,
+		sender:   make(chan []byte, SendQueueSize),
+	}
+
+	if n.tlsConfig != nil {
+		var tlsConn *tls.Conn
+		if outbound {
+			tlsConn = tls.Client(rawConn, n.tlsConfig)
+		} else {
+			tlsConn = tls.Server(rawConn, n.tlsConfig)
+		}
+		if err := tlsConn.Handshake(); err != nil {
+			rawConn.Close()
+			return nil, err
+		}
+
+		certs := tlsConn.ConnectionState().PeerCertificates
+		if len(certs) == 0 {
+			tlsConn.Close()
+			return nil, errNoPeerCert
+		}
+		c.conn = tlsConn
+		c.cert = certs[0]
+	}
+
+	// Exchange the IPs that both nodes are listening on
+	myIP := n.myIP
+	if myIP.IP == nil {
+		myIP.IP = net.IPv6zero
+	}
+	p := wrappers.Packer{Bytes: make([]byte, net.IPv6len+wrappers.ShortLen)}
+	p.PackIP(myIP)
+	if err := writeFrame(c.conn, p.Bytes); err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+	b, err := readFrame(c.conn, uint32(len(p.Bytes)))
+	if err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+	p = wrappers.Packer{Bytes: b}
+	peerIP := p.UnpackIP()
+	if p.Errored() {
+		c.conn.Close()
+		return nil, p.Err
+	}
+
+	if outbound {
+		c.ip = ip
+	} else {
+		// The remote node's advertised IP may not be reachable, so the IP the
+		// connection was made from is used along with the advertised port
+		remoteIP, err := utils.ToIPDesc(rawConn.RemoteAddr().String())
+		if err != nil {
+			c.conn.Close()
+			return nil, err
+		}
+		c.ip = remoteIP
+		if peerIP.Port != 0 {
+			c.ip.Port = peerIP.Port
+		}
+	}
+
+	if c.cert != nil {
+		c.id = certToPeerID(c.cert.Raw)
+	} else {
+		c.id = ipToPeerID(c.ip)
+	}
+
+	if err := c.conn.SetDeadline(time.Time{}); err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+
+	n.lock.Lock()
+	select {
+	case <-n.closed:
+		n.lock.Unlock()
+		c.conn.Close()
+		return nil, errClosed
+	default:
+		n.conns[c] = struct{}{}
+	}
+	n.lock.Unlock()
+
+	if !n.post(func() { n.connected(c) }) {
+		c.Close()
+		return nil, errClosed
+	}
+
+	go n.log.RecoverAndPanic(c.readMessages)
+	go n.log.RecoverAndPanic(c.writeMessages)
+	return c, nil
+}
+
+// post queues the event to be executed on the event loop. Returns false if the
+// network was closed.
+func (n *msgNetwork) post(event func()) bool {
+	select {
+	case n.events <- event:
+		return true
+	case <-n.closed:
+		return false
+	}
+}
+
+func (n *msgNetwork) connectedConn(c *conn) {
+	if !n.notifyConn(c, true) {
+		c.Close()
+	}
+}
+
+func (n *msgNetwork) disconnectedConn(c *conn) {
+	n.lock.Lock()
+	delete(n.conns, c)
+	n.lock.Unlock()
+
+	n.notifyConn(c, false)
+}
+
+func (n *msgNetwork) receivedMsg(c *conn, msg Msg) {
+	n.lock.Lock()
+	handler, exists := n.handlers[msg.Op()]
+	n.lock.Unlock()
+
+	if !exists {
+		n.log.Debug("Dropping %s message from %s due to no registered handler", msg.Op(), c.ip)
+		return
+	}
+	handler(msg, c)
+}
+
+// notifyConn calls the registered connection handler, if there is one
+func (n *msgNetwork) notifyConn(c *conn, connected bool) bool {
+	n.lock.Lock()
+	handler := n.connHandler
+	n.lock.Unlock()
+
+	if handler == nil {
+		return true
+	}
+	return handler(c, connected)
+}
+
+func certToPeerID(cert []byte) ids.ID {
+	return ids.NewID(hashing.ComputeHash256Array(cert))
+}
+
+func ipToPeerID(ip utils.IPDesc) ids.ID {
+	return ids.NewID(hashing.ComputeHash256Array([]byte(ip.String())))
+}
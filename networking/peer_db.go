@@ -0,0 +1,314 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/utils"
+	"github.com/ava-labs/gecko/utils/logging"
+	"github.com/ava-labs/gecko/utils/timer"
+	"github.com/ava-labs/gecko/utils/wrappers"
+)
+
+const (
+	// MaxKnownPeers is the maximum number of peers that will be remembered
+	// across restarts. Once full, the peer with the worst record is forgotten
+	// to make room for a new one.
+	MaxKnownPeers = 1000
+	// KnownPeerExpiry is the amount of time after the last successful
+	// handshake with a peer that it will be forgotten.
+	KnownPeerExpiry = 14 * 24 * time.Hour
+	// KnownPeersReconnectSize is the number of remembered peers that will be
+	// connected to on startup.
+	KnownPeersReconnectSize = 20
+
+	// ipLen is the length of a packed IP
+	ipLen = 16 + wrappers.ShortLen
+	// knownPeerLen is the length of a packed knownPeer
+	knownPeerLen = ipLen + 20 + wrappers.LongLen + 2*wrappers.IntLen
+)
+
+var (
+	errBadKnownPeer = errors.New("known peer has the wrong length")
+)
+
+// knownPeer is what is remembered about a peer between restarts
+type knownPeer struct {
+	ip utils.IPDesc
+	// id is the staker ID the peer had during the last successful handshake.
+	// It is empty if the handshake never succeeded.
+	id ids.ShortID
+	// lastSeen is the time of the last successful handshake with the peer
+	lastSeen time.Time
+	// attempts is the number of times a connection to the peer was attempted
+	attempts uint32
+	// successes is the number of times a handshake with the peer succeeded
+	successes uint32
+}
+
+// score of this peer, higher is better. Peers that have never been tried have
+// a score of 1/2, and the score tends to the peer's success rate as more
+// connections are attempted.
+func (p *knownPeer) score() float64 {
+	return float64(p.successes+1) / float64(p.attempts+2)
+}
+
+// better returns true if this peer should be connected to before [other]
+func (p *knownPeer) better(other *knownPeer) bool {
+	if myScore, otherScore := p.score(), other.score(); myScore != otherScore {
+		return myScore > otherScore
+	}
+	return p.lastSeen.After(other.lastSeen)
+}
+
+func (p *knownPeer) Bytes() []byte {
+	packer := wrappers.Packer{Bytes: make([]byte, knownPeerLen)}
+	packer.PackIP(p.ip)
+	if !p.id.IsZero() {
+		packer.PackFixedBytes(p.id.Bytes())
+	} else {
+		packer.PackFixedBytes(make([]byte, 20))
+	}
+	lastSeen := uint64(0)
+	if !p.lastSeen.IsZero() {
+		lastSeen = uint64(p.lastSeen.Unix())
+	}
+	packer.PackLong(lastSeen)
+	packer.PackInt(p.attempts)
+	packer.PackInt(p.successes)
+	return packer.Bytes
+}
+
+func parseKnownPeer(b []byte) (*knownPeer, error) {
+	if len(b) != knownPeerLen {
+		return nil, errBadKnownPeer
+	}
+
+	packer := wrappers.Packer{Bytes: b}
+	ip := packer.UnpackIP()
+	idBytes := packer.UnpackFixedBytes(20)
+	lastSeen := int64(packer.UnpackLong())
+	attempts := packer.UnpackInt()
+	successes := packer.UnpackInt()
+	if packer.Errored() {
+		return nil, packer.Err
+	}
+
+	p := &knownPeer{
+		ip:        ip,
+		attempts:  attempts,
+		successes: successes,
+	}
+	if lastSeen != 0 {
+		p.lastSeen = time.Unix(lastSeen, 0)
+	}
+	if !bytes.Equal(idBytes, make([]byte, 20)) {
+		p.id, _ = ids.ToShortID(idBytes)
+	}
+	return p, nil
+}
+
+// peerDB remembers the peers this node has learned about, and how reliably it
+// was able to connect to them, so that the node can find the network again
+// after a restart.
+type peerDB struct {
+	log   logging.Logger
+	db    database.Database
+	clock timer.Clock
+
+	lock  sync.Mutex
+	peers map[string]*knownPeer // keys are the packed IPs
+	byID  map[[20]byte]string   // staker ID -> packed IP it was last seen at
+}
+
+// Initialize the peerDB and load the peers that were previously stored in
+// [db]
+func (pdb *peerDB) Initialize(log logging.Logger, db database.Database) {
+	pdb.log = log
+	pdb.db = db
+	pdb.peers = make(map[string]*knownPeer)
+	pdb.byID = make(map[[20]byte]string)
+
+	iter := db.NewIterator()
+	defer iter.Release()
+
+	expired := [][]byte{}
+	now := pdb.clock.Time()
+	for iter.Next() {
+		key := string(iter.Key())
+		p, err := parseKnownPeer(iter.Value())
+		if err != nil || p.ip.IsZero() {
+			log.Warn("Dropping invalid known peer from the database")
+			expired = append(expired, []byte(key))
+			continue
+		}
+		if !p.lastSeen.IsZero() && now.Sub(p.lastSeen) > KnownPeerExpiry {
+			log.Debug("Forgetting known peer %s that hasn't been seen since %s", p.ip, p.lastSeen)
+			expired = append(expired, []byte(key))
+			continue
+		}
+		pdb.peers[key] = p
+		pdb.index(key, p)
+	}
+	if err := iter.Error(); err != nil {
+		log.Error("Failed to load known peers due to %s", err)
+	}
+
+	for _, key := range expired {
+		if err := db.Delete(key); err != nil {
+			log.Error("Failed to remove known peer due to %s", err)
+		}
+	}
+}
+
+// Attempted records that a connection to [ip] is being attempted
+func (pdb *peerDB) Attempted(ip utils.IPDesc) {
+	if ip.IsZero() {
+		return
+	}
+
+	pdb.lock.Lock()
+	defer pdb.lock.Unlock()
+
+	p := pdb.get(ip)
+	p.attempts++
+	pdb.put(p)
+}
+
+// Connected records that a handshake with [id], reachable at [ip], succeeded
+func (pdb *peerDB) Connected(ip utils.IPDesc, id ids.ShortID) {
+	if ip.IsZero() {
+		return
+	}
+
+	pdb.lock.Lock()
+	defer pdb.lock.Unlock()
+
+	p := pdb.get(ip)
+	p.id = id
+	p.lastSeen = pdb.clock.Time()
+	p.successes++
+	pdb.index(string(packIP(ip)), p)
+	// The peer may have connected to me, in which case I never attempted to
+	// connect to it
+	if p.attempts < p.successes {
+		p.attempts = p.successes
+	}
+	pdb.put(p)
+}
+
+// Best returns up to [n] of the known peers, ordered from the most to the
+// least reliable
+func (pdb *peerDB) Best(n int) []knownPeer {
+	pdb.lock.Lock()
+	defer pdb.lock.Unlock()
+
+	peers := make([]*knownPeer, 0, len(pdb.peers))
+	for _, p := range pdb.peers {
+		peers = append(peers, p)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].better(peers[j]) })
+
+	if len(peers) > n {
+		peers = peers[:n]
+	}
+	best := make([]knownPeer, len(peers))
+	for i, p := range peers {
+		best[i] = *p
+	}
+	return best
+}
+
+// IP returns the IP that the staker [id] was most recently seen at
+func (pdb *peerDB) IP(id ids.ShortID) (utils.IPDesc, bool) {
+	pdb.lock.Lock()
+	defer pdb.lock.Unlock()
+
+	if key, exists := pdb.byID[id.Key()]; exists {
+		return pdb.peers[key].ip, true
+	}
+	return utils.IPDesc{}, false
+}
+
+// Len returns the number of known peers
+func (pdb *peerDB) Len() int {
+	pdb.lock.Lock()
+	defer pdb.lock.Unlock()
+
+	return len(pdb.peers)
+}
+
+// get the record of [ip], creating a new one if the peer isn't known yet.
+// Assumes the lock is held.
+func (pdb *peerDB) get(ip utils.IPDesc) *knownPeer {
+	if p, exists := pdb.peers[string(packIP(ip))]; exists {
+		return p
+	}
+
+	if len(pdb.peers) >= MaxKnownPeers {
+		pdb.evict()
+	}
+	return &knownPeer{ip: ip}
+}
+
+// put writes the record of [p] to memory and to the database. Assumes the lock
+// is held.
+func (pdb *peerDB) put(p *knownPeer) {
+	key := packIP(p.ip)
+	pdb.peers[string(key)] = p
+	if err := pdb.db.Put(key, p.Bytes()); err != nil {
+		pdb.log.Error("Failed to store known peer %s due to %s", p.ip, err)
+	}
+}
+
+// evict forgets the peer with the worst record. Assumes the lock is held.
+func (pdb *peerDB) evict() {
+	worstKey := ""
+	var worst *knownPeer
+	for key, p := range pdb.peers {
+		if worst == nil || worst.better(p) {
+			worstKey = key
+			worst = p
+		}
+	}
+	if worst == nil {
+		return
+	}
+
+	delete(pdb.peers, worstKey)
+	if !worst.id.IsZero() && pdb.byID[worst.id.Key()] == worstKey {
+		delete(pdb.byID, worst.id.Key())
+	}
+	if err := pdb.db.Delete([]byte(worstKey)); err != nil {
+		pdb.log.Error("Failed to remove known peer %s due to %s", worst.ip, err)
+	}
+}
+
+// index [p], stored at [key], by its staker ID, unless the staker has been
+// seen more recently at a different IP. Assumes the lock is held.
+func (pdb *peerDB) index(key string, p *knownPeer) {
+	if p.id.IsZero() {
+		return
+	}
+	idKey := p.id.Key()
+	if otherKey, exists := pdb.byID[idKey]; exists && otherKey != key {
+		if other := pdb.peers[otherKey]; other.lastSeen.After(p.lastSeen) {
+			return
+		}
+	}
+	pdb.byID[idKey] = key
+}
+
+func packIP(ip utils.IPDesc) []byte {
+	packer := wrappers.Packer{Bytes: make([]byte, ipLen)}
+	packer.PackIP(ip)
+	return packer.Bytes
+}
@@ -0,0 +1,155 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/database/memdb"
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/utils"
+	"github.com/ava-labs/gecko/utils/logging"
+)
+
+func newTestPeerDB(db database.Database, now time.Time) *peerDB {
+	pdb := &peerDB{}
+	pdb.clock.Set(now)
+	pdb.Initialize(logging.NoLog{}, db)
+	return pdb
+}
+
+func knownPeerIP(i byte) utils.IPDesc {
+	return utils.IPDesc{
+		IP:   net.IPv4(10, 0, 0, i),
+		Port: 9651,
+	}
+}
+
+func TestPeerDBPersists(t *testing.T) {
+	db := memdb.New()
+	now := time.Unix(1000000, 0)
+	id := ids.NewShortID([20]byte{1})
+
+	pdb := newTestPeerDB(db, now)
+	pdb.Attempted(knownPeerIP(1))
+	pdb.Connected(knownPeerIP(1), id)
+	pdb.Attempted(knownPeerIP(2))
+
+	pdb = newTestPeerDB(db, now)
+	if n := pdb.Len(); n != 2 {
+		t.Fatalf("Loaded %d peers, expected %d", n, 2)
+	}
+
+	best := pdb.Best(1)
+	if len(best) != 1 {
+		t.Fatalf("Returned %d peers, expected %d", len(best), 1)
+	}
+	p := best[0]
+	if !p.ip.Equal(knownPeerIP(1)) {
+		t.Fatalf("Wrong peer returned: %s", p.ip)
+	}
+	if !p.id.Equals(id) {
+		t.Fatalf("Wrong staker ID loaded: %s", p.id)
+	}
+	if !p.lastSeen.Equal(now) {
+		t.Fatalf("Wrong last seen time loaded: %s", p.lastSeen)
+	}
+	if p.attempts != 1 || p.successes != 1 {
+		t.Fatalf("Wrong connection record loaded: %d/%d", p.successes, p.attempts)
+	}
+
+	if best := pdb.Best(10); len(best) != 2 || !best[1].id.IsZero() {
+		t.Fatalf("Peer that never connected should have an empty staker ID")
+	}
+}
+
+func TestPeerDBOrdering(t *testing.T) {
+	now := time.Unix(1000000, 0)
+	pdb := newTestPeerDB(memdb.New(), now)
+
+	// 10.0.0.1 never connects
+	for i := 0; i < 3; i++ {
+		pdb.Attempted(knownPeerIP(1))
+	}
+	// 10.0.0.2 connects every time
+	for i := 0; i < 3; i++ {
+		pdb.Attempted(knownPeerIP(2))
+		pdb.Connected(knownPeerIP(2), ids.NewShortID([20]byte{2}))
+	}
+	// 10.0.0.3 connects every time, but was last seen earlier
+	pdb.clock.Set(now.Add(-time.Hour))
+	for i := 0; i < 3; i++ {
+		pdb.Attempted(knownPeerIP(3))
+		pdb.Connected(knownPeerIP(3), ids.NewShortID([20]byte{3}))
+	}
+	// 10.0.0.4 has only been learned about
+	pdb.Attempted(knownPeerIP(4))
+
+	expected := []utils.IPDesc{knownPeerIP(2), knownPeerIP(3), knownPeerIP(4), knownPeerIP(1)}
+	best := pdb.Best(len(expected))
+	if len(best) != len(expected) {
+		t.Fatalf("Returned %d peers, expected %d", len(best), len(expected))
+	}
+	for i, p := range best {
+		if !p.ip.Equal(expected[i]) {
+			t.Fatalf("Peer %d should be %s, but is %s", i, expected[i], p.ip)
+		}
+	}
+}
+
+func TestPeerDBExpiry(t *testing.T) {
+	db := memdb.New()
+	now := time.Unix(10000000, 0)
+
+	pdb := newTestPeerDB(db, now)
+	pdb.Attempted(knownPeerIP(1))
+	pdb.Connected(knownPeerIP(1), ids.NewShortID([20]byte{1}))
+	pdb.Attempted(knownPeerIP(2))
+
+	pdb = newTestPeerDB(db, now.Add(KnownPeerExpiry+time.Second))
+	best := pdb.Best(10)
+	if len(best) != 1 || !best[0].ip.Equal(knownPeerIP(2)) {
+		t.Fatalf("Peer that wasn't seen recently should have been forgotten")
+	}
+
+	pdb = newTestPeerDB(db, now)
+	if n := pdb.Len(); n != 1 {
+		t.Fatalf("Expired peer should have been removed from the database")
+	}
+}
+
+func TestPeerDBEvictsWorst(t *testing.T) {
+	pdb := newTestPeerDB(memdb.New(), time.Unix(1000000, 0))
+
+	for i := 0; i < MaxKnownPeers; i++ {
+		ip := utils.IPDesc{
+			IP:   net.IPv4(10, 0, byte(i>>8), byte(i)),
+			Port: 9651,
+		}
+		pdb.Attempted(ip)
+		if i != 0 {
+			pdb.Connected(ip, ids.NewShortID([20]byte{byte(i >> 8), byte(i)}))
+		}
+	}
+	if n := pdb.Len(); n != MaxKnownPeers {
+		t.Fatalf("Stored %d peers, expected %d", n, MaxKnownPeers)
+	}
+
+	newIP := utils.IPDesc{
+		IP:   net.IPv4(10, 1, 0, 0),
+		Port: 9651,
+	}
+	pdb.Attempted(newIP)
+	if n := pdb.Len(); n != MaxKnownPeers {
+		t.Fatalf("Stored %d peers, expected %d", n, MaxKnownPeers)
+	}
+	for _, p := range pdb.Best(MaxKnownPeers) {
+		if p.ip.Equal(utils.IPDesc{IP: net.IPv4(10, 0, 0, 0), Port: 9651}) {
+			t.Fatalf("Peer with the worst record should have been evicted")
+		}
+	}
+}
@@ -0,0 +1,307 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/utils"
+	"github.com/ava-labs/gecko/utils/logging"
+)
+
+// PeerHandler is notified when a connection to a known peer is established or
+// closed. connected is true if a new connection was established.
+type PeerHandler func(conn Conn, connected bool)
+
+// UnknownPeerHandler is notified when a connection is established with a node
+// that hasn't been added as a peer. If the handler adds the node as a peer, the
+// connection will be kept. Otherwise, it will be closed. cert is nil if TLS is
+// disabled.
+type UnknownPeerHandler func(ip utils.IPDesc, cert *x509.Certificate)
+
+// PeerNetwork is a MsgNetwork that tracks a set of known peers. Messages are
+// only handled if they were received from a known peer. Connections to peers
+// that have an address will be re-established if they are dropped.
+type PeerNetwork interface {
+	MsgNetwork
+
+	// RegisterPeerHandler registers the handler that will be called when a
+	// connection to a known peer is established or closed
+	RegisterPeerHandler(PeerHandler)
+
+	// RegisterUnknownPeerHandler registers the handler that will be called
+	// when an unknown node connects to this node
+	RegisterUnknownPeerHandler(UnknownPeerHandler)
+
+	// AddPeer marks the peer as known
+	AddPeer(peer ids.ID)
+
+	// SetPeerAddr sets the IP that will be used to connect to the peer
+	SetPeerAddr(peer ids.ID, ip utils.IPDesc)
+
+	// ConnPeer attempts to connect to the peer in the background. Failed
+	// attempts are retried every [retryDelay]. If [retries] is negative, the
+	// attempts will be retried until the peer is deleted.
+	ConnPeer(peer ids.ID, retryDelay time.Duration, retries int)
+
+	// DelPeer closes any connection to the peer and marks the peer as unknown
+	DelPeer(peer ids.ID)
+
+	// Send the message to the provided peers. Peers that aren't connected are
+	// skipped.
+	Send(msg Msg, peers ...ids.ID)
+}
+
+type peer struct {
+	ip   utils.IPDesc
+	conn *conn
+
+	dialing    bool
+	retryDelay time.Duration
+	retries    int
+}
+
+type peerNetwork struct {
+	*msgNetwork
+
+	myID ids.ID
+
+	peerLock           sync.Mutex
+	peerHandler        PeerHandler
+	unknownPeerHandler UnknownPeerHandler
+	peers              map[[32]byte]*peer
+}
+
+// NewPeerNetwork returns a new peer network that runs over [transport]. [myIP]
+// is the IP that this node will tell remote nodes it is listening on. If
+// [tlsConfig] is nil, connections will not be encrypted or authenticated.
+func NewPeerNetwork(log logging.Logger, transport Transport, myIP utils.IPDesc, tlsConfig *tls.Config, maxMessageSize uint32) PeerNetwork {
+	n := &peerNetwork{
+		msgNetwork: newMsgNetwork(log, transport, myIP, tlsConfig, maxMessageSize),
+		myID:       ipToPeerID(myIP),
+		peers:      make(map[[32]byte]*peer),
+	}
+	if tlsConfig != nil && len(tlsConfig.Certificates) > 0 && len(tlsConfig.Certificates[0].Certificate) > 0 {
+		n.myID = certToPeerID(tlsConfig.Certificates[0].Certificate[0])
+	}
+
+	n.msgNetwork.connected = n.connectedPeer
+	n.msgNetwork.disconnected = n.disconnectedPeer
+	n.msgNetwork.received = n.receivedPeerMsg
+	return n
+}
+
+// RegisterPeerHandler implements the PeerNetwork interface
+func (n *peerNetwork) RegisterPeerHandler(handler PeerHandler) {
+	n.peerLock.Lock()
+	defer n.peerLock.Unlock()
+
+	n.peerHandler = handler
+}
+
+// RegisterUnknownPeerHandler implements the PeerNetwork interface
+func (n *peerNetwork) RegisterUnknownPeerHandler(handler UnknownPeerHandler) {
+	n.peerLock.Lock()
+	defer n.peerLock.Unlock()
+
+	n.unknownPeerHandler = handler
+}
+
+// AddPeer implements the PeerNetwork interface
+func (n *peerNetwork) AddPeer(peerID ids.ID) {
+	n.peerLock.Lock()
+	defer n.peerLock.Unlock()
+
+	key := peerID.Key()
+	if _, exists := n.peers[key]; !exists {
+		n.peers[key] = &peer{}
+	}
+}
+
+// SetPeerAddr implements the PeerNetwork interface
+func (n *peerNetwork) SetPeerAddr(peerID ids.ID, ip utils.IPDesc) {
+	n.peerLock.Lock()
+	defer n.peerLock.Unlock()
+
+	if p, exists := n.peers[peerID.Key()]; exists {
+		p.ip = ip
+	}
+}
+
+// ConnPeer implements the PeerNetwork interface
+func (n *peerNetwork) ConnPeer(peerID ids.ID, retryDelay time.Duration, retries int) {
+	n.peerLock.Lock()
+	defer n.peerLock.Unlock()
+
+	p, exists := n.peers[peerID.Key()]
+	if !exists {
+		return
+	}
+	p.retryDelay = retryDelay
+	p.retries = retries
+	n.dial(peerID, p)
+}
+
+// DelPeer implements the PeerNetwork interface
+func (n *peerNetwork) DelPeer(peerID ids.ID) {
+	n.peerLock.Lock()
+	key := peerID.Key()
+	p, exists := n.peers[key]
+	delete(n.peers, key)
+	n.peerLock.Unlock()
+
+	if exists && p.conn != nil {
+		p.conn.Close()
+	}
+}
+
+// Send implements the PeerNetwork interface
+func (n *peerNetwork) Send(msg Msg, peerIDs ...ids.ID) {
+	n.peerLock.Lock()
+	conns := make([]*conn, 0, len(peerIDs))
+	for _, peerID := range peerIDs {
+		if p, exists := n.peers[peerID.Key()]; exists && p.conn != nil {
+			conns = append(conns, p.conn)
+		}
+	}
+	n.peerLock.Unlock()
+
+	for _, c := range conns {
+		c.Send(msg)
+	}
+}
+
+// dial starts attempting to connect to the peer, if there isn't already an
+// attempt in progress. Assumes the peer lock is held.
+func (n *peerNetwork) dial(peerID ids.ID, p *peer) {
+	if p.dialing || p.conn != nil || p.ip.IsZero() {
+		return
+	}
+	p.dialing = true
+
+	go n.log.RecoverAndPanic(func() {
+		for attempt := 0; ; attempt++ {
+			n.peerLock.Lock()
+			current, exists := n.peers[peerID.Key()]
+			if !exists || current != p || p.conn != nil || (p.retries >= 0 && attempt > p.retries) {
+				p.dialing = false
+				n.peerLock.Unlock()
+				return
+			}
+			ip := p.ip
+			retryDelay := p.retryDelay
+			n.peerLock.Unlock()
+
+			if attempt > 0 {
+				select {
+				case <-time.After(retryDelay):
+				case <-n.closed:
+					n.peerLock.Lock()
+					p.dialing = false
+					n.peerLock.Unlock()
+					return
+				}
+			}
+
+			if _, err := n.ConnectSync(ip); err == nil {
+				n.peerLock.Lock()
+				p.dialing = false
+				n.peerLock.Unlock()
+				return
+			}
+			n.log.Verbo("Failed to connect to peer at %s", ip)
+		}
+	})
+}
+
+func (n *peerNetwork) connectedPeer(c *conn) {
+	if !n.notifyConn(c, true) {
+		c.Close()
+		return
+	}
+
+	n.peerLock.Lock()
+	_, known := n.peers[c.id.Key()]
+	unknownPeerHandler := n.unknownPeerHandler
+	n.peerLock.Unlock()
+
+	if !known && unknownPeerHandler != nil {
+		unknownPeerHandler(c.ip, c.cert)
+	}
+
+	n.peerLock.Lock()
+	p, known := n.peers[c.id.Key()]
+	if !known {
+		n.peerLock.Unlock()
+		c.Close()
+		return
+	}
+	if old := p.conn; old != nil {
+		// Both nodes may have connected to each other at the same time. Both
+		// nodes keep the connection that was dialed by the node with the larger
+		// ID, so that they agree on which connection to close.
+		if !n.preferred(c) {
+			n.peerLock.Unlock()
+			c.Close()
+			return
+		}
+		p.conn = c
+		n.peerLock.Unlock()
+		old.Close()
+		return
+	}
+	p.conn = c
+	peerHandler := n.peerHandler
+	n.peerLock.Unlock()
+
+	if peerHandler != nil {
+		peerHandler(c, true)
+	}
+}
+
+func (n *peerNetwork) disconnectedPeer(c *conn) {
+	n.msgNetwork.disconnectedConn(c)
+
+	n.peerLock.Lock()
+	p, known := n.peers[c.id.Key()]
+	if !known || p.conn != c {
+		n.peerLock.Unlock()
+		return
+	}
+	p.conn = nil
+	if p.retries != 0 {
+		n.dial(c.id, p)
+	}
+	peerHandler := n.peerHandler
+	n.peerLock.Unlock()
+
+	if peerHandler != nil {
+		peerHandler(c, false)
+	}
+}
+
+func (n *peerNetwork) receivedPeerMsg(c *conn, msg Msg) {
+	n.peerLock.Lock()
+	p, known := n.peers[c.id.Key()]
+	current := known && p.conn == c
+	n.peerLock.Unlock()
+
+	if !current {
+		n.log.Debug("Dropping %s message from %s due to not being a known peer", msg.Op(), c.ip)
+		return
+	}
+	n.msgNetwork.receivedMsg(c, msg)
+}
+
+// preferred returns true if the connection was dialed by the node with the
+// larger ID
+func (n *peerNetwork) preferred(c *conn) bool {
+	iAmLarger := bytes.Compare(n.myID.Bytes(), c.id.Bytes()) > 0
+	return c.outbound == iAmLarger
+}
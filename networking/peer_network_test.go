@@ -0,0 +1,221 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/database/memdb"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
+	"github.com/ava-labs/gecko/snow/validators"
+	"github.com/ava-labs/gecko/utils"
+	"github.com/ava-labs/gecko/utils/logging"
+)
+
+const testTimeout = 10 * time.Second
+
+// testIP returns a loopback IP with an unused port
+func testIP(t *testing.T) utils.IPDesc {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	ip, err := utils.ToIPDesc(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ip
+}
+
+// testTLSConfig returns a TLS config with a new self-signed certificate
+func testTLSConfig(t *testing.T) *tls.Config {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{certBytes},
+			PrivateKey:  key,
+		}},
+		ClientAuth:         tls.RequireAnyClientCert,
+		InsecureSkipVerify: true,
+	}
+}
+
+func testPeerNetworkSend(t *testing.T, tlsConfig0, tlsConfig1 *tls.Config) {
+	ip0 := testIP(t)
+	ip1 := testIP(t)
+
+	net0 := NewPeerNetwork(logging.NoLog{}, TCPTransport{}, ip0, tlsConfig0, 1<<20)
+	net1 := NewPeerNetwork(logging.NoLog{}, TCPTransport{}, ip1, tlsConfig1, 1<<20)
+	defer net0.Close()
+	defer net1.Close()
+
+	go net0.Dispatch()
+	go net1.Dispatch()
+
+	connected := make(chan Conn, 1)
+	net0.RegisterPeerHandler(func(conn Conn, isConnected bool) {
+		if isConnected {
+			connected <- conn
+		}
+	})
+
+	received := make(chan Msg, 1)
+	net1.RegisterUnknownPeerHandler(func(ip utils.IPDesc, cert *x509.Certificate) {
+		if cert != nil {
+			net1.AddPeer(certToPeerID(cert.Raw))
+		} else {
+			net1.AddPeer(ipToPeerID(ip))
+		}
+	})
+	net1.RegisterHandler(Data, func(msg Msg, _ Conn) { received <- msg })
+
+	if err := net1.Listen(ip1); err != nil {
+		t.Fatal(err)
+	}
+
+	peerID := ipToPeerID(ip1)
+	if tlsConfig1 != nil {
+		peerID = certToPeerID(tlsConfig1.Certificates[0].Certificate[0])
+	}
+	net0.AddPeer(peerID)
+	net0.SetPeerAddr(peerID, ip1)
+	net0.ConnPeer(peerID, 10*time.Millisecond, 100)
+
+	select {
+	case conn := <-connected:
+		if !conn.PeerID().Equals(peerID) {
+			t.Fatalf("Connected to %s, expected %s", conn.PeerID(), peerID)
+		}
+		if (conn.Cert() == nil) != (tlsConfig1 == nil) {
+			t.Fatalf("Unexpected certificate")
+		}
+	case <-time.After(testTimeout):
+		t.Fatalf("Timed out waiting for the connection")
+	}
+
+	build := Builder{}
+	msg, err := build.Data([]byte{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	net0.Send(msg, peerID)
+
+	select {
+	case msg := <-received:
+		if b := msg.Get(Bytes).([]byte); len(b) != 3 || b[0] != 1 || b[1] != 2 || b[2] != 3 {
+			t.Fatalf("Received unexpected bytes %v", b)
+		}
+	case <-time.After(testTimeout):
+		t.Fatalf("Timed out waiting for the message")
+	}
+}
+
+func TestPeerNetworkSend(t *testing.T) { testPeerNetworkSend(t, nil, nil) }
+
+func TestPeerNetworkSendTLS(t *testing.T) {
+	testPeerNetworkSend(t, testTLSConfig(t), testTLSConfig(t))
+}
+
+func TestPeerNetworkDropsUnknownPeers(t *testing.T) {
+	ip0 := testIP(t)
+	ip1 := testIP(t)
+
+	net0 := NewPeerNetwork(logging.NoLog{}, TCPTransport{}, ip0, nil, 1<<20)
+	net1 := NewPeerNetwork(logging.NoLog{}, TCPTransport{}, ip1, nil, 1<<20)
+	defer net0.Close()
+	defer net1.Close()
+
+	go net0.Dispatch()
+	go net1.Dispatch()
+
+	disconnected := make(chan struct{}, 1)
+	net0.RegisterConnHandler(func(_ Conn, connected bool) bool {
+		if !connected {
+			disconnected <- struct{}{}
+		}
+		return true
+	})
+
+	if err := net1.Listen(ip1); err != nil {
+		t.Fatal(err)
+	}
+	net0.Connect(ip1)
+
+	select {
+	case <-disconnected:
+	case <-time.After(testTimeout):
+		t.Fatalf("Connection from an unknown peer should have been closed")
+	}
+}
+
+func TestHandshakeConnect(t *testing.T) {
+	ip0 := testIP(t)
+	ip1 := testIP(t)
+
+	net0 := NewPeerNetwork(logging.NoLog{}, TCPTransport{}, ip0, nil, 1<<20)
+	net1 := NewPeerNetwork(logging.NoLog{}, TCPTransport{}, ip1, nil, 1<<20)
+	defer net0.Close()
+	defer net1.Close()
+
+	rep := reputation.Manager{}
+	rep.Initialize(logging.NoLog{}, reputation.Config{BanThreshold: -100}, prometheus.NewRegistry())
+
+	hs0 := Handshake{}
+	hs0.Initialize(logging.NoLog{}, validators.NewSet(), ip0, nil, toShortID(ip0), net0, prometheus.NewRegistry(), false, 12345, true, &rep, memdb.New(), nil, nil)
+	defer hs0.Shutdown()
+
+	hs1 := Handshake{}
+	hs1.Initialize(logging.NoLog{}, validators.NewSet(), ip1, nil, toShortID(ip1), net1, prometheus.NewRegistry(), false, 12345, true, &rep, memdb.New(), nil, nil)
+	defer hs1.Shutdown()
+
+	go net0.Dispatch()
+	go net1.Dispatch()
+
+	if err := net0.Listen(ip0); err != nil {
+		t.Fatal(err)
+	}
+	if err := net1.Listen(ip1); err != nil {
+		t.Fatal(err)
+	}
+
+	hs0.Connect(ip1)
+
+	deadline := time.Now().Add(testTimeout)
+	for hs0.Connections().Len() != 1 || hs1.Connections().Len() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the handshake to finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !hs0.Connections().ContainsID(toShortID(ip1)) {
+		t.Fatalf("Should have been connected to %s", toShortID(ip1))
+	}
+	if peers := hs1.Connections().IDs(); peers.Len() != 1 {
+		t.Fatalf("Should have been connected to one peer")
+	}
+}
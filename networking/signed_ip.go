@@ -0,0 +1,138 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+
+	"github.com/ava-labs/gecko/utils"
+	"github.com/ava-labs/gecko/utils/hashing"
+	"github.com/ava-labs/gecko/utils/wrappers"
+)
+
+var (
+	errUnsupportedKey    = errors.New("staking key type isn't supported for signing IPs")
+	errFutureIPTimestamp = errors.New("signed IP has a timestamp in the future")
+	errExpiredIPClaim    = errors.New("signed IP has expired")
+	errStaleIPClaim      = errors.New("signed IP is older than the newest IP its signer claimed")
+	errBadSignedPeers    = errors.New("expected a list of signed peers")
+	errBadSignedIPs      = errors.New("expected a list of signed IPs")
+)
+
+// SignedIP is a claim, signed with a node's staking key, that the node could be
+// reached at an IP as of a time
+type SignedIP struct {
+	IP        utils.IPDesc
+	Timestamp uint64
+	Signature []byte
+}
+
+// SignedPeer is a SignedIP along with the staking certificate of the node that
+// signed it. These are what is gossiped in peer lists.
+type SignedPeer struct {
+	SignedIP
+	Cert []byte
+}
+
+// signIP signs a claim that this node can be reached at [ip] as of [timestamp]
+func signIP(key crypto.Signer, ip utils.IPDesc, timestamp uint64) (SignedIP, error) {
+	signedIP := SignedIP{
+		IP:        ip,
+		Timestamp: timestamp,
+	}
+	sig, err := key.Sign(rand.Reader, hashing.ComputeHash256(signedIP.unsignedBytes()), crypto.SHA256)
+	signedIP.Signature = sig
+	return signedIP, err
+}
+
+// Verify that the claim was signed by the owner of [cert]
+func (s *SignedIP) Verify(cert *x509.Certificate) error {
+	var algorithm x509.SignatureAlgorithm
+	switch cert.PublicKeyAlgorithm {
+	case x509.RSA:
+		algorithm = x509.SHA256WithRSA
+	case x509.ECDSA:
+		algorithm = x509.ECDSAWithSHA256
+	default:
+		return errUnsupportedKey
+	}
+	return cert.CheckSignature(algorithm, s.unsignedBytes(), s.Signature)
+}
+
+// unsignedBytes returns the bytes that are signed to make the claim
+func (s *SignedIP) unsignedBytes() []byte {
+	p := wrappers.Packer{Bytes: make([]byte, ipLen+wrappers.LongLen)}
+	p.PackIP(s.IP)
+	p.PackLong(s.Timestamp)
+	return p.Bytes
+}
+
+func packSignedIP(p *wrappers.Packer, signedIP SignedIP) {
+	p.PackIP(signedIP.IP)
+	p.PackLong(signedIP.Timestamp)
+	p.PackBytes(signedIP.Signature)
+}
+
+func unpackSignedIP(p *wrappers.Packer) SignedIP {
+	return SignedIP{
+		IP:        p.UnpackIP(),
+		Timestamp: p.UnpackLong(),
+		Signature: p.UnpackBytes(),
+	}
+}
+
+// tryPackSignedIPs attempts to pack the value as a list of signed IPs
+func tryPackSignedIPs(p *wrappers.Packer, valIntf interface{}) {
+	val, ok := valIntf.([]SignedIP)
+	if !ok {
+		p.Add(errBadSignedIPs)
+		return
+	}
+
+	p.PackInt(uint32(len(val)))
+	for i := 0; i < len(val) && !p.Errored(); i++ {
+		packSignedIP(p, val[i])
+	}
+}
+
+// tryUnpackSignedIPs attempts to unpack the value as a list of signed IPs
+func tryUnpackSignedIPs(p *wrappers.Packer) interface{} {
+	n := p.UnpackInt()
+	signedIPs := []SignedIP(nil)
+	for i := uint32(0); i < n && !p.Errored(); i++ {
+		signedIPs = append(signedIPs, unpackSignedIP(p))
+	}
+	return signedIPs
+}
+
+// tryPackSignedPeers attempts to pack the value as a list of signed peers
+func tryPackSignedPeers(p *wrappers.Packer, valIntf interface{}) {
+	val, ok := valIntf.([]SignedPeer)
+	if !ok {
+		p.Add(errBadSignedPeers)
+		return
+	}
+
+	p.PackInt(uint32(len(val)))
+	for i := 0; i < len(val) && !p.Errored(); i++ {
+		packSignedIP(p, val[i].SignedIP)
+		p.PackBytes(val[i].Cert)
+	}
+}
+
+// tryUnpackSignedPeers attempts to unpack the value as a list of signed peers
+func tryUnpackSignedPeers(p *wrappers.Packer) interface{} {
+	n := p.UnpackInt()
+	peers := []SignedPeer(nil)
+	for i := uint32(0); i < n && !p.Errored(); i++ {
+		peers = append(peers, SignedPeer{
+			SignedIP: unpackSignedIP(p),
+			Cert:     p.UnpackBytes(),
+		})
+	}
+	return peers
+}
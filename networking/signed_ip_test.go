@@ -0,0 +1,322 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"crypto"
+	"crypto/x509"
+	"net"
+	"testing"
+
+	"github.com/ava-labs/gecko/database/memdb"
+	"github.com/ava-labs/gecko/snow/validators"
+	"github.com/ava-labs/gecko/utils"
+	"github.com/ava-labs/gecko/utils/logging"
+)
+
+// testStakingKey returns a staking key and the certificate it owns
+func testStakingKey(t *testing.T) (crypto.Signer, *x509.Certificate) {
+	tlsCert := testTLSConfig(t).Certificates[0]
+	cert, err := x509.ParseCertificate(tlsCert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tlsCert.PrivateKey.(crypto.Signer), cert
+}
+
+func TestSignedIPVerify(t *testing.T) {
+	key, cert := testStakingKey(t)
+	ip := utils.IPDesc{
+		IP:   net.IPv4(1, 2, 3, 4),
+		Port: 9651,
+	}
+
+	signedIP, err := signIP(key, ip, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signedIP.Verify(cert); err != nil {
+		t.Fatalf("Valid signature failed verification due to %s", err)
+	}
+
+	forgedIP := signedIP
+	forgedIP.IP = utils.IPDesc{
+		IP:   net.IPv4(5, 6, 7, 8),
+		Port: 9651,
+	}
+	if err := forgedIP.Verify(cert); err == nil {
+		t.Fatalf("Signature of a different IP should fail verification")
+	}
+
+	forgedTimestamp := signedIP
+	forgedTimestamp.Timestamp++
+	if err := forgedTimestamp.Verify(cert); err == nil {
+		t.Fatalf("Signature of a different timestamp should fail verification")
+	}
+
+	_, otherCert := testStakingKey(t)
+	if err := signedIP.Verify(otherCert); err == nil {
+		t.Fatalf("Signature by a different key should fail verification")
+	}
+}
+
+func TestHandshakeVerifySignedIPFromFuture(t *testing.T) {
+	key, cert := testStakingKey(t)
+	ip := utils.IPDesc{
+		IP:   net.IPv4(1, 2, 3, 4),
+		Port: 9651,
+	}
+
+	nm := Handshake{
+		log:          logging.NoLog{},
+		vdrs:         validators.NewSet(),
+		newestClaims: make(map[[20]byte]uint64),
+		claimedIPs:   make(map[[20]byte]utils.IPDesc),
+	}
+	now := nm.clock.Unix()
+
+	signedIP, err := signIP(key, ip, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := nm.verifySignedIP(cert, signedIP); err != nil {
+		t.Fatalf("Current claim failed verification due to %s", err)
+	}
+
+	signedIP, err = signIP(key, ip, now+uint64(2*MaxClockDifference.Seconds()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := nm.verifySignedIP(cert, signedIP); err != errFutureIPTimestamp {
+		t.Fatalf("Claim from the future should have failed verification")
+	}
+}
+
+func TestHandshakeVerifySignedIPFreshness(t *testing.T) {
+	key, cert := testStakingKey(t)
+	ip := utils.IPDesc{
+		IP:   net.IPv4(1, 2, 3, 4),
+		Port: 9651,
+	}
+
+	nm := Handshake{
+		log:          logging.NoLog{},
+		vdrs:         validators.NewSet(),
+		newestClaims: make(map[[20]byte]uint64),
+		claimedIPs:   make(map[[20]byte]utils.IPDesc),
+	}
+	now := nm.clock.Unix()
+
+	expiredIP, err := signIP(key, ip, now-uint64(2*MaxIPClaimAge.Seconds()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := nm.verifySignedIP(cert, expiredIP); err != errExpiredIPClaim {
+		t.Fatalf("Expired claim should have failed verification")
+	}
+
+	oldIP, err := signIP(key, ip, now-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newIP, err := signIP(key, ip, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The newest claims of nodes that aren't validators aren't tracked
+	if err := nm.verifySignedIP(cert, newIP); err != nil {
+		t.Fatalf("Current claim failed verification due to %s", err)
+	}
+	if err := nm.verifySignedIP(cert, oldIP); err != nil {
+		t.Fatalf("Older claim of a non-validator failed verification due to %s", err)
+	}
+
+	nm.vdrs.Add(validators.NewValidator(nm.getCert(cert), 1))
+	if err := nm.verifySignedIP(cert, newIP); err != nil {
+		t.Fatalf("Current claim failed verification due to %s", err)
+	}
+	if err := nm.verifySignedIP(cert, newIP); err != nil {
+		t.Fatalf("Repeated claim failed verification due to %s", err)
+	}
+	if err := nm.verifySignedIP(cert, oldIP); err != errStaleIPClaim {
+		t.Fatalf("Claim older than the validator's newest claim should have failed verification")
+	}
+}
+
+func TestHandshakeKnownIPFromClaims(t *testing.T) {
+	key, cert := testStakingKey(t)
+	ip := utils.IPDesc{
+		IP:   net.IPv4(1, 2, 3, 4),
+		Port: 9651,
+	}
+
+	nm := Handshake{
+		log:          logging.NoLog{},
+		vdrs:         validators.NewSet(),
+		dialIPv4:     true,
+		newestClaims: make(map[[20]byte]uint64),
+		claimedIPs:   make(map[[20]byte]utils.IPDesc),
+	}
+	nm.peers.Initialize(logging.NoLog{}, memdb.New())
+	id := nm.getCert(cert)
+	nm.vdrs.Add(validators.NewValidator(id, 1))
+
+	if _, exists := nm.KnownIP(id); exists {
+		t.Fatalf("Shouldn't know the IP of a validator I haven't heard from")
+	}
+
+	// The claim may have been gossiped, without ever connecting to the
+	// validator
+	signedIP, err := signIP(key, ip, nm.clock.Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := nm.verifySignedIP(cert, signedIP); err != nil {
+		t.Fatal(err)
+	}
+	if knownIP, exists := nm.KnownIP(id); !exists || !knownIP.Equal(ip) {
+		t.Fatalf("Should have known the validator's claimed IP %s, got %s", ip, knownIP)
+	}
+}
+
+func TestHandshakeSetIP(t *testing.T) {
+	key, cert := testStakingKey(t)
+	ip := utils.IPDesc{
+		IP:   net.IPv4(1, 2, 3, 4),
+		Port: 9651,
+	}
+	newIP := utils.IPDesc{
+		IP:   net.IPv4(5, 6, 7, 8),
+		Port: 9651,
+	}
+
+	nm := Handshake{
+		log:           logging.NoLog{},
+		enableStaking: true,
+		myAddr:        ip,
+		stakingKey:    key,
+	}
+	nm.signIPs()
+
+	nm.SetIP(newIP)
+	signedIP, _ := nm.mySignedIPs()
+	if !signedIP.IP.Equal(newIP) {
+		t.Fatalf("Claimed %s, expected %s", signedIP.IP, newIP)
+	}
+	if err := signedIP.Verify(cert); err != nil {
+		t.Fatalf("Claim of the new IP failed verification due to %s", err)
+	}
+	if nm.isMyAddr(ip) || !nm.isMyAddr(newIP) {
+		t.Fatalf("Should only be reachable at the new IP")
+	}
+}
+
+func TestBuilderSignedPeerList(t *testing.T) {
+	key, cert := testStakingKey(t)
+	ip := utils.IPDesc{
+		IP:   net.IPv4(1, 2, 3, 4),
+		Port: 9651,
+	}
+	signedIP, err := signIP(key, ip, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	build := Builder{}
+	msg, err := build.PeerList([]utils.IPDesc{ip}, []SignedPeer{{
+		SignedIP: signedIP,
+		Cert:     cert.Raw,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedMsg, err := build.Parse(msg.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedPeers, ok := parsedMsg.Get(SignedPeers).([]SignedPeer)
+	if !ok || len(signedPeers) != 1 {
+		t.Fatalf("Expected one signed peer")
+	}
+	parsedCert, err := x509.ParseCertificate(signedPeers[0].Cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signedPeers[0].Verify(parsedCert); err != nil {
+		t.Fatalf("Parsed signed peer failed verification due to %s", err)
+	}
+	if !signedPeers[0].IP.Equal(ip) || signedPeers[0].Timestamp != 1000 {
+		t.Fatalf("Signed peer was parsed incorrectly")
+	}
+
+	// Without any signed peers, the field should be omitted
+	msg, err = build.PeerList([]utils.IPDesc{ip}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsedMsg, err = build.Parse(msg.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if parsedMsg.Get(SignedPeers) != nil {
+		t.Fatalf("Signed peers shouldn't have been sent")
+	}
+}
+
+func TestBuilderVersionAltIPs(t *testing.T) {
+	key, cert := testStakingKey(t)
+	ip := utils.IPDesc{
+		IP:   net.IPv4(1, 2, 3, 4),
+		Port: 9651,
+	}
+	altIP := utils.IPDesc{
+		IP:   net.ParseIP("2001:db8::1"),
+		Port: 9651,
+	}
+	signedIP, err := signIP(key, ip, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedAltIP, err := signIP(key, altIP, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	build := Builder{}
+	msg, err := build.Version(12345, 1000, signedIP, []SignedIP{signedAltIP}, "avalanche/0.0.1", CurrentMsgVersion, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedMsg, err := build.Parse(msg.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsedIP := parsedMsg.Get(IP).(utils.IPDesc); !parsedIP.Equal(ip) {
+		t.Fatalf("Parsed IP %s, expected %s", parsedIP, ip)
+	}
+	altIPs, ok := parsedMsg.Get(AltIPs).([]SignedIP)
+	if !ok || len(altIPs) != 1 {
+		t.Fatalf("Expected one alternate IP")
+	}
+	if !altIPs[0].IP.Equal(altIP) {
+		t.Fatalf("Parsed alternate IP %s, expected %s", altIPs[0].IP, altIP)
+	}
+	if err := altIPs[0].Verify(cert); err != nil {
+		t.Fatalf("Parsed alternate IP failed verification due to %s", err)
+	}
+
+	// Without any alternate IPs, the field should be omitted
+	msg, err = build.Version(12345, 1000, signedIP, nil, "avalanche/0.0.1", CurrentMsgVersion, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsedMsg, err = build.Parse(msg.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if parsedMsg.Get(AltIPs) != nil {
+		t.Fatalf("Alternate IPs shouldn't have been sent")
+	}
+}
@@ -0,0 +1,235 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package simnet
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/gecko/utils"
+)
+
+var (
+	errClosed = errors.New("use of closed connection")
+	errReset  = errors.New("connection reset by peer")
+)
+
+// timeoutError is returned when a read deadline is exceeded
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// chunk is written data that will be readable at a given time
+type chunk struct {
+	bytes []byte
+	at    time.Time
+}
+
+// pipe is one direction of a connection
+type pipe struct {
+	lock     sync.Mutex
+	chunks   []chunk
+	lastAt   time.Time
+	eof      bool
+	err      error
+	deadline time.Time
+
+	// notify is closed, and replaced, whenever the state of the pipe changes
+	notify chan struct{}
+}
+
+func newPipe() *pipe { return &pipe{notify: make(chan struct{})} }
+
+// push the bytes to be read after [delay]. Data is never reordered, so the
+// bytes won't be readable before previously pushed bytes.
+func (p *pipe) push(b []byte, delay time.Duration) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.err != nil || p.eof {
+		return errReset
+	}
+
+	at := time.Now().Add(delay)
+	if at.Before(p.lastAt) {
+		at = p.lastAt
+	}
+	p.lastAt = at
+	p.chunks = append(p.chunks, chunk{
+		bytes: append([]byte(nil), b...),
+		at:    at,
+	})
+	p.wake()
+	return nil
+}
+
+func (p *pipe) read(b []byte) (int, error) {
+	for {
+		p.lock.Lock()
+		if p.err != nil {
+			p.lock.Unlock()
+			return 0, p.err
+		}
+
+		now := time.Now()
+		if !p.deadline.IsZero() && !now.Before(p.deadline) {
+			p.lock.Unlock()
+			return 0, timeoutError{}
+		}
+
+		var wait time.Duration
+		switch {
+		case len(p.chunks) > 0 && !p.chunks[0].at.After(now):
+			next := &p.chunks[0]
+			n := copy(b, next.bytes)
+			next.bytes = next.bytes[n:]
+			if len(next.bytes) == 0 {
+				p.chunks = p.chunks[1:]
+			}
+			p.lock.Unlock()
+			return n, nil
+		case len(p.chunks) > 0:
+			wait = p.chunks[0].at.Sub(now)
+		case p.eof:
+			p.lock.Unlock()
+			return 0, io.EOF
+		}
+		if !p.deadline.IsZero() {
+			if untilDeadline := p.deadline.Sub(now); wait == 0 || untilDeadline < wait {
+				wait = untilDeadline
+			}
+		}
+		notify := p.notify
+		p.lock.Unlock()
+
+		if wait == 0 {
+			<-notify
+			continue
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-notify:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+func (p *pipe) setDeadline(t time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.deadline = t
+	p.wake()
+}
+
+// close marks that no more data will be pushed. Data that was already pushed
+// can still be read.
+func (p *pipe) close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.eof = true
+	p.wake()
+}
+
+// fail drops all pending data and causes reads to return [err]
+func (p *pipe) fail(err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.err == nil {
+		p.err = err
+		p.chunks = nil
+		p.wake()
+	}
+}
+
+// wake up any blocked readers. Assumes the lock is held.
+func (p *pipe) wake() {
+	close(p.notify)
+	p.notify = make(chan struct{})
+}
+
+// conn is one end of a simulated connection
+type conn struct {
+	net           *Network
+	local, remote utils.IPDesc
+
+	in, out *pipe
+	once    sync.Once
+}
+
+func newConnPair(n *Network, local, remote utils.IPDesc) (*conn, *conn) {
+	localToRemote := newPipe()
+	remoteToLocal := newPipe()
+	return &conn{
+		net:    n,
+		local:  local,
+		remote: remote,
+		in:     remoteToLocal,
+		out:    localToRemote,
+	}, &conn{
+		net:    n,
+		local:  remote,
+		remote: local,
+		in:     localToRemote,
+		out:    remoteToLocal,
+	}
+}
+
+func (c *conn) Read(b []byte) (int, error) { return c.in.read(b) }
+
+func (c *conn) Write(b []byte) (int, error) {
+	c.in.lock.Lock()
+	err := c.in.err
+	c.in.lock.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	delay, reachable := c.net.writeDelay(c)
+	if !reachable {
+		c.reset()
+		return 0, errReset
+	}
+	if err := c.out.push(b, delay); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *conn) Close() error {
+	c.once.Do(func() {
+		c.in.fail(errClosed)
+		c.out.close()
+		c.net.closeConn(c)
+	})
+	return nil
+}
+
+// reset abruptly breaks both ends of the connection
+func (c *conn) reset() {
+	c.in.fail(errReset)
+	c.out.fail(errReset)
+}
+
+func (c *conn) LocalAddr() net.Addr  { return toAddr(c.local) }
+func (c *conn) RemoteAddr() net.Addr { return toAddr(c.remote) }
+
+func (c *conn) SetDeadline(t time.Time) error { return c.SetReadDeadline(t) }
+
+func (c *conn) SetReadDeadline(t time.Time) error {
+	c.in.setDeadline(t)
+	return nil
+}
+
+// SetWriteDeadline is a no-op, since writes never block
+func (c *conn) SetWriteDeadline(time.Time) error { return nil }
@@ -0,0 +1,289 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package simnet
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/gecko/networking"
+	"github.com/ava-labs/gecko/utils"
+)
+
+var (
+	errAddrInUse   = errors.New("address already in use")
+	errRefused     = errors.New("connection refused")
+	errUnreachable = errors.New("host unreachable")
+)
+
+// Link describes the conditions of the path that data takes from one host to
+// another
+type Link struct {
+	// Latency is the amount of time written data takes to be delivered
+	Latency time.Duration
+	// Jitter is the maximum amount of time that is randomly added to the
+	// latency of each write
+	Jitter time.Duration
+	// Loss is the probability that a write is lost. Since connections are
+	// reliable streams, a lost write is delivered after an additional
+	// RetransmitDelay, the way TCP would retransmit it.
+	Loss            float64
+	RetransmitDelay time.Duration
+}
+
+// Network is an in-memory network of hosts. The conditions of the links
+// between the hosts can be changed at any time. Random decisions are made with
+// a seeded source, so that runs are reproducible.
+type Network struct {
+	lock sync.Mutex
+	rng  *rand.Rand
+
+	defaultLink Link
+	links       map[[2]string]Link
+
+	// partition maps hosts to the group they were partitioned into. If nil,
+	// all hosts can reach each other.
+	partition map[string]int
+
+	listeners map[string]*listener
+	conns     map[*conn]struct{}
+	nextPort  uint16
+}
+
+// New returns a new network whose random decisions are made based on [seed]
+func New(seed int64) *Network {
+	return &Network{
+		rng:       rand.New(rand.NewSource(seed)),
+		links:     make(map[[2]string]Link),
+		listeners: make(map[string]*listener),
+		conns:     make(map[*conn]struct{}),
+		nextPort:  1 << 15,
+	}
+}
+
+// Transport returns the transport used by the host with the provided IP
+func (n *Network) Transport(host net.IP) networking.Transport {
+	return &transport{
+		net:  n,
+		host: host,
+	}
+}
+
+// SetDefaultLink sets the conditions of every link that wasn't explicitly set
+func (n *Network) SetDefaultLink(link Link) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.defaultLink = link
+}
+
+// SetLink sets the conditions of the link from [from] to [to]. The link in the
+// other direction is unchanged.
+func (n *Network) SetLink(from, to net.IP, link Link) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.links[[2]string{from.String(), to.String()}] = link
+}
+
+// Partition the hosts into the provided groups. Hosts can only reach hosts in
+// the same group. Hosts that aren't in any group are placed into a group
+// together. Connections between hosts in different groups are broken.
+func (n *Network) Partition(groups ...[]net.IP) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.partition = make(map[string]int)
+	for i, group := range groups {
+		for _, host := range group {
+			n.partition[host.String()] = i + 1
+		}
+	}
+
+	for c := range n.conns {
+		if !n.reachable(c.local.IP, c.remote.IP) {
+			c.reset()
+		}
+	}
+}
+
+// Heal removes the partition, if there is one
+func (n *Network) Heal() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.partition = nil
+}
+
+// Disconnect breaks all the connections of the provided host
+func (n *Network) Disconnect(host net.IP) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for c := range n.conns {
+		if c.local.IP.Equal(host) || c.remote.IP.Equal(host) {
+			c.reset()
+		}
+	}
+}
+
+// reachable returns true if [from] can send data to [to]. Assumes the lock is
+// held.
+func (n *Network) reachable(from, to net.IP) bool {
+	return n.partition == nil || n.partition[from.String()] == n.partition[to.String()]
+}
+
+// delay returns the amount of time a write from [from] to [to] takes to be
+// delivered. Assumes the lock is held.
+func (n *Network) delay(from, to net.IP) time.Duration {
+	link, exists := n.links[[2]string{from.String(), to.String()}]
+	if !exists {
+		link = n.defaultLink
+	}
+
+	delay := link.Latency
+	if link.Jitter > 0 {
+		delay += time.Duration(n.rng.Int63n(int64(link.Jitter)))
+	}
+	if link.Loss > 0 && n.rng.Float64() < link.Loss {
+		delay += link.RetransmitDelay
+	}
+	return delay
+}
+
+func (n *Network) listen(ip utils.IPDesc) (net.Listener, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	key := ip.String()
+	if _, exists := n.listeners[key]; exists {
+		return nil, errAddrInUse
+	}
+	l := &listener{
+		net:    n,
+		ip:     ip,
+		conns:  make(chan net.Conn, 1<<10),
+		closed: make(chan struct{}),
+	}
+	n.listeners[key] = l
+	return l, nil
+}
+
+func (n *Network) dial(from net.IP, to utils.IPDesc) (net.Conn, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if !n.reachable(from, to.IP) {
+		return nil, errUnreachable
+	}
+	l, exists := n.listeners[to.String()]
+	if !exists {
+		return nil, errRefused
+	}
+
+	local := utils.IPDesc{
+		IP:   from,
+		Port: n.nextPort,
+	}
+	n.nextPort++
+	if n.nextPort == 0 {
+		n.nextPort = 1 << 15
+	}
+
+	outbound, inbound := newConnPair(n, local, to)
+	select {
+	case l.conns <- inbound:
+	default:
+		return nil, errRefused
+	}
+	n.conns[outbound] = struct{}{}
+	n.conns[inbound] = struct{}{}
+	return outbound, nil
+}
+
+// closeListener removes the listener from the network
+func (n *Network) closeListener(l *listener) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if current, exists := n.listeners[l.ip.String()]; exists && current == l {
+		delete(n.listeners, l.ip.String())
+	}
+}
+
+// closeConn removes the connection from the network
+func (n *Network) closeConn(c *conn) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	delete(n.conns, c)
+}
+
+// writeDelay returns the amount of time a write on [c] takes to be delivered.
+// Returns false if the remote host isn't reachable.
+func (n *Network) writeDelay(c *conn) (time.Duration, bool) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if !n.reachable(c.local.IP, c.remote.IP) {
+		return 0, false
+	}
+	return n.delay(c.local.IP, c.remote.IP), true
+}
+
+type transport struct {
+	net  *Network
+	host net.IP
+}
+
+// Listen implements the networking.Transport interface. Listens on the
+// provided port of this transport's host.
+func (t *transport) Listen(ip utils.IPDesc) (net.Listener, error) {
+	return t.net.listen(utils.IPDesc{
+		IP:   t.host,
+		Port: ip.Port,
+	})
+}
+
+// Dial implements the networking.Transport interface
+func (t *transport) Dial(ip utils.IPDesc, _ time.Duration) (net.Conn, error) {
+	return t.net.dial(t.host, ip)
+}
+
+type listener struct {
+	net    *Network
+	ip     utils.IPDesc
+	conns  chan net.Conn
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.closed:
+		return nil, errClosed
+	}
+}
+
+func (l *listener) Close() error {
+	l.once.Do(func() {
+		close(l.closed)
+		l.net.closeListener(l)
+	})
+	return nil
+}
+
+func (l *listener) Addr() net.Addr { return toAddr(l.ip) }
+
+func toAddr(ip utils.IPDesc) net.Addr {
+	return &net.TCPAddr{
+		IP:   ip.IP,
+		Port: int(ip.Port),
+	}
+}
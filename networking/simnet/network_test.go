@@ -0,0 +1,126 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package simnet
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/gecko/utils"
+)
+
+var (
+	host0 = net.IPv4(10, 0, 0, 1)
+	host1 = net.IPv4(10, 0, 0, 2)
+)
+
+func connect(t *testing.T, n *Network) (net.Conn, net.Conn) {
+	l, err := n.Transport(host1).Listen(utils.IPDesc{Port: 9651})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	outbound, err := n.Transport(host0).Dial(utils.IPDesc{IP: host1, Port: 9651}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inbound, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return outbound, inbound
+}
+
+func TestConnReadWrite(t *testing.T) {
+	n := New(0)
+	outbound, inbound := connect(t, n)
+
+	if ip, err := utils.ToIPDesc(inbound.RemoteAddr().String()); err != nil {
+		t.Fatal(err)
+	} else if !ip.IP.Equal(host0) {
+		t.Fatalf("Remote address should have been %s, but was %s", host0, ip.IP)
+	}
+
+	if _, err := outbound.Write([]byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := outbound.Write([]byte{4}); err != nil {
+		t.Fatal(err)
+	}
+	outbound.Close()
+
+	b := make([]byte, 4)
+	if _, err := io.ReadFull(inbound, b); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range b {
+		if v != byte(i+1) {
+			t.Fatalf("Read unexpected bytes %v", b)
+		}
+	}
+
+	if _, err := inbound.Read(b); err != io.EOF {
+		t.Fatalf("Should have read EOF after the remote closed, but got %v", err)
+	}
+}
+
+func TestConnLatency(t *testing.T) {
+	n := New(0)
+	n.SetDefaultLink(Link{Latency: 50 * time.Millisecond})
+	outbound, inbound := connect(t, n)
+
+	start := time.Now()
+	if _, err := outbound.Write([]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inbound.Read(make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("Data was delivered after %s, which is before the latency", elapsed)
+	}
+}
+
+func TestConnReadDeadline(t *testing.T) {
+	n := New(0)
+	_, inbound := connect(t, n)
+
+	if err := inbound.SetReadDeadline(time.Now().Add(10 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := inbound.Read(make([]byte, 1))
+	if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+		t.Fatalf("Should have timed out, but got %v", err)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	n := New(0)
+	outbound, inbound := connect(t, n)
+
+	n.Partition([]net.IP{host0}, []net.IP{host1})
+
+	if _, err := inbound.Read(make([]byte, 1)); err != errReset {
+		t.Fatalf("Connection should have been reset, but got %v", err)
+	}
+	if _, err := outbound.Write([]byte{1}); err == nil {
+		t.Fatalf("Write should have failed across the partition")
+	}
+	if _, err := n.Transport(host0).Dial(utils.IPDesc{IP: host1, Port: 9651}, time.Second); err != errUnreachable {
+		t.Fatalf("Dial should have failed across the partition")
+	}
+
+	n.Heal()
+
+	outbound, inbound = connect(t, n)
+	if _, err := outbound.Write([]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inbound.Read(make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+}
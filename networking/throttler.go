@@ -0,0 +1,159 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/validators"
+	"github.com/ava-labs/gecko/utils/timer"
+)
+
+const (
+	// throttlerRefreshInterval is how often the average stake of the
+	// validators is recalculated and idle buckets are removed
+	throttlerRefreshInterval = time.Minute
+)
+
+var (
+	errInvalidRate  = errors.New("message rates must be positive")
+	errInvalidBurst = errors.New("message burst must be positive")
+)
+
+// ThrottlerConfig defines the rate that peers may send each type of message
+type ThrottlerConfig struct {
+	// NonValidatorRate is the number of messages of each type per second that
+	// a peer without stake may send
+	NonValidatorRate float64
+
+	// ValidatorRate is the number of messages of each type per second that a
+	// validator with the average stake may send. Validators are allotted a
+	// rate proportional to their stake, but never less than NonValidatorRate.
+	ValidatorRate float64
+
+	// Burst is the amount of time worth of messages that a peer may send at
+	// once, after being idle
+	Burst time.Duration
+}
+
+// Valid returns nil if the config describes a valid rate limit. A rate that
+// isn't positive would never refill the buckets, so every message would be
+// dropped.
+func (c ThrottlerConfig) Valid() error {
+	switch {
+	case c.NonValidatorRate <= 0 || c.ValidatorRate <= 0:
+		return errInvalidRate
+	case c.Burst <= 0:
+		return errInvalidBurst
+	default:
+		return nil
+	}
+}
+
+// tokenBucket rate limits one type of message sent by one peer
+type tokenBucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// throttler rate limits the messages received from each peer, separately for
+// each type of message
+type throttler struct {
+	config ThrottlerConfig
+	vdrs   validators.Set
+	clock  timer.Clock
+
+	lock          sync.Mutex
+	buckets       map[[20]byte]map[Op]*tokenBucket
+	averageWeight float64
+	lastRefresh   time.Time
+}
+
+func (t *throttler) Initialize(config ThrottlerConfig, vdrs validators.Set) {
+	t.config = config
+	t.vdrs = vdrs
+	t.buckets = make(map[[20]byte]map[Op]*tokenBucket)
+}
+
+// Allow returns true if the peer may send another message of type [op].
+// Otherwise, the message should be dropped.
+func (t *throttler) Allow(validatorID ids.ShortID, op Op) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.clock.Time()
+	if now.Sub(t.lastRefresh) >= throttlerRefreshInterval {
+		t.refresh(now)
+	}
+
+	rate := t.rate(validatorID)
+	burst := math.Max(rate*t.config.Burst.Seconds(), 1)
+
+	key := validatorID.Key()
+	peerBuckets, exists := t.buckets[key]
+	if !exists {
+		peerBuckets = make(map[Op]*tokenBucket)
+		t.buckets[key] = peerBuckets
+	}
+	bucket, exists := peerBuckets[op]
+	if !exists {
+		bucket = &tokenBucket{
+			tokens:     burst,
+			lastRefill: now,
+		}
+		peerBuckets[op] = bucket
+	}
+
+	bucket.tokens = math.Min(burst, bucket.tokens+now.Sub(bucket.lastRefill).Seconds()*rate)
+	bucket.lastRefill = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// rate returns the number of messages of each type per second that the peer
+// may send. Assumes the lock is held.
+func (t *throttler) rate(validatorID ids.ShortID) float64 {
+	vdr, exists := t.vdrs.Get(validatorID)
+	if !exists || t.averageWeight == 0 {
+		return t.config.NonValidatorRate
+	}
+	rate := t.config.ValidatorRate * float64(vdr.Weight()) / t.averageWeight
+	return math.Max(rate, t.config.NonValidatorRate)
+}
+
+// refresh recalculates the average stake of the validators and removes the
+// buckets of peers that have been idle long enough for their buckets to be
+// full, since a full bucket behaves the same as a new one. Assumes the lock
+// is held.
+func (t *throttler) refresh(now time.Time) {
+	t.lastRefresh = now
+
+	vdrs := t.vdrs.List()
+	totalWeight := float64(0)
+	for _, vdr := range vdrs {
+		totalWeight += float64(vdr.Weight())
+	}
+	t.averageWeight = 0
+	if len(vdrs) > 0 {
+		t.averageWeight = totalWeight / float64(len(vdrs))
+	}
+
+	for key, peerBuckets := range t.buckets {
+		for op, bucket := range peerBuckets {
+			if now.Sub(bucket.lastRefill) >= t.config.Burst {
+				delete(peerBuckets, op)
+			}
+		}
+		if len(peerBuckets) == 0 {
+			delete(t.buckets, key)
+		}
+	}
+}
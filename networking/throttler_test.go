@@ -0,0 +1,120 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/validators"
+)
+
+func newTestThrottler(vdrs validators.Set) *throttler {
+	t := &throttler{}
+	t.Initialize(ThrottlerConfig{
+		NonValidatorRate: 1,
+		ValidatorRate:    10,
+		Burst:            time.Second,
+	}, vdrs)
+	t.clock.Set(time.Unix(1000, 0))
+	return t
+}
+
+// allowed returns the number of messages that are allowed without advancing
+// the clock
+func allowed(t *throttler, validatorID ids.ShortID, op Op) int {
+	n := 0
+	for t.Allow(validatorID, op) {
+		n++
+	}
+	return n
+}
+
+func TestThrottlerNonValidator(t *testing.T) {
+	th := newTestThrottler(validators.NewSet())
+	peer := ids.NewShortID([20]byte{1})
+
+	if n := allowed(th, peer, PushQuery); n != 1 {
+		t.Fatalf("Allowed %d messages, expected %d", n, 1)
+	}
+
+	th.clock.Set(th.clock.Time().Add(500 * time.Millisecond))
+	if th.Allow(peer, PushQuery) {
+		t.Fatalf("Shouldn't have allowed a message before a token was refilled")
+	}
+
+	th.clock.Set(th.clock.Time().Add(500 * time.Millisecond))
+	if !th.Allow(peer, PushQuery) {
+		t.Fatalf("Should have allowed a message after a token was refilled")
+	}
+}
+
+func TestThrottlerSeparateOps(t *testing.T) {
+	th := newTestThrottler(validators.NewSet())
+	peer := ids.NewShortID([20]byte{1})
+
+	allowed(th, peer, PushQuery)
+	if !th.Allow(peer, Chits) {
+		t.Fatalf("Throttling one type of message shouldn't throttle another")
+	}
+	if !th.Allow(ids.NewShortID([20]byte{2}), PushQuery) {
+		t.Fatalf("Throttling one peer shouldn't throttle another")
+	}
+}
+
+func TestThrottlerStakeWeighted(t *testing.T) {
+	vdr0 := validators.NewValidator(ids.NewShortID([20]byte{1}), 1)
+	vdr1 := validators.NewValidator(ids.NewShortID([20]byte{2}), 3)
+
+	vdrs := validators.NewSet()
+	vdrs.Add(vdr0)
+	vdrs.Add(vdr1)
+	th := newTestThrottler(vdrs)
+
+	// The average weight is 2, so the rates are 5 and 15 per second
+	if n := allowed(th, vdr0.ID(), PushQuery); n != 5 {
+		t.Fatalf("Allowed %d messages, expected %d", n, 5)
+	}
+	if n := allowed(th, vdr1.ID(), PushQuery); n != 15 {
+		t.Fatalf("Allowed %d messages, expected %d", n, 15)
+	}
+}
+
+func TestThrottlerRemovesIdleBuckets(t *testing.T) {
+	th := newTestThrottler(validators.NewSet())
+	peer := ids.NewShortID([20]byte{1})
+
+	th.Allow(peer, PushQuery)
+	th.clock.Set(th.clock.Time().Add(throttlerRefreshInterval))
+	th.Allow(ids.NewShortID([20]byte{2}), PushQuery)
+
+	if _, exists := th.buckets[peer.Key()]; exists {
+		t.Fatalf("Should have removed the bucket of an idle peer")
+	}
+}
+
+func TestThrottlerConfigValid(t *testing.T) {
+	valid := ThrottlerConfig{
+		NonValidatorRate: 1,
+		ValidatorRate:    10,
+		Burst:            time.Second,
+	}
+	if err := valid.Valid(); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []func(*ThrottlerConfig){
+		func(c *ThrottlerConfig) { c.NonValidatorRate = 0 },
+		func(c *ThrottlerConfig) { c.ValidatorRate = -1 },
+		func(c *ThrottlerConfig) { c.Burst = 0 },
+	}
+	for i, invalidate := range invalid {
+		config := valid
+		invalidate(&config)
+		if err := config.Valid(); err == nil {
+			t.Fatalf("Config %d should have been invalid", i)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"net"
+	"time"
+
+	"github.com/ava-labs/gecko/utils"
+)
+
+// Transport creates the raw connections that a MsgNetwork runs over
+type Transport interface {
+	// Listen for incoming connections on the port of the provided IP
+	Listen(ip utils.IPDesc) (net.Listener, error)
+
+	// Dial the provided IP. Returns an error if the connection couldn't be
+	// established within [timeout].
+	Dial(ip utils.IPDesc, timeout time.Duration) (net.Conn, error)
+}
+
+// TCPTransport is a Transport that uses the operating system's TCP stack
+type TCPTransport struct{}
+
+// Listen implements the Transport interface
+func (TCPTransport) Listen(ip utils.IPDesc) (net.Listener, error) {
+	// Listening on only the port accepts connections over both IPv4 and IPv6
+	return net.Listen("tcp", ip.PortString())
+}
+
+// Dial implements the Transport interface
+func (TCPTransport) Dial(ip utils.IPDesc, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("tcp", ip.String(), timeout)
+}
@@ -3,28 +3,14 @@
 
 package networking
 
-// #include "salticidae/network.h"
-// void getAcceptedFrontier(msg_t *, msgnetwork_conn_t *, void *);
-// void acceptedFrontier(msg_t *, msgnetwork_conn_t *, void *);
-// void getAccepted(msg_t *, msgnetwork_conn_t *, void *);
-// void accepted(msg_t *, msgnetwork_conn_t *, void *);
-// void get(msg_t *, msgnetwork_conn_t *, void *);
-// void put(msg_t *, msgnetwork_conn_t *, void *);
-// void pushQuery(msg_t *, msgnetwork_conn_t *, void *);
-// void pullQuery(msg_t *, msgnetwork_conn_t *, void *);
-// void chits(msg_t *, msgnetwork_conn_t *, void *);
-import "C"
-
 import (
 	"errors"
 	"fmt"
-	"unsafe"
 
 	"github.com/prometheus/client_golang/prometheus"
 
-	"github.com/ava-labs/salticidae-go"
-
 	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
 	"github.com/ava-labs/gecko/snow/networking/router"
 	"github.com/ava-labs/gecko/snow/validators"
 	"github.com/ava-labs/gecko/utils/formatting"
@@ -32,30 +18,49 @@ import (
 	"github.com/ava-labs/gecko/utils/timer"
 )
 
-var (
-	// VotingNet implements the SenderExternal interface.
-	VotingNet = Voting{}
-)
-
 var (
 	errConnectionDropped = errors.New("connection dropped before receiving message")
 )
 
-// Voting implements the SenderExternal interface with a c++ library.
+// votingOps are the ops that are handled by the voting layer
+var votingOps = []Op{
+	GetAcceptedFrontier,
+	AcceptedFrontier,
+	GetAccepted,
+	Accepted,
+	Get,
+	Put,
+	PushQuery,
+	PullQuery,
+	Chits,
+	GetStateSummary,
+	StateSummary,
+	GetStateChunk,
+	StateChunk,
+	GetAncestors,
+	MultiPut,
+}
+
+// Voting implements the SenderExternal interface with a peer network.
 type Voting struct {
 	votingMetrics
 
-	log   logging.Logger
-	vdrs  validators.Set
-	net   salticidae.PeerNetwork
-	conns Connections
+	log      logging.Logger
+	vdrs     validators.Set
+	net      PeerNetwork
+	conns    Connections
+	features PeerFeatures
+
+	reputation reputation.Reporter
+
+	throttler throttler
 
 	router   router.Router
 	executor timer.Executor
 }
 
-// Initialize to the c networking library. Should only be called once ever.
-func (s *Voting) Initialize(log logging.Logger, vdrs validators.Set, peerNet salticidae.PeerNetwork, conns Connections, router router.Router, registerer prometheus.Registerer) {
+// Initialize to the peer network. Should only be called once ever.
+func (s *Voting) Initialize(log logging.Logger, vdrs validators.Set, peerNet PeerNetwork, conns Connections, features PeerFeatures, reputation reputation.Reporter, throttlerConfig ThrottlerConfig, router router.Router, registerer prometheus.Registerer) {
 	log.AssertTrue(s.net == nil, "Should only register network handlers once")
 	log.AssertTrue(s.conns == nil, "Should only set connections once")
 	log.AssertTrue(s.router == nil, "Should only set the router once")
@@ -64,21 +69,29 @@ func (s *Voting) Initialize(log logging.Logger, vdrs validators.Set, peerNet sal
 	s.vdrs = vdrs
 	s.net = peerNet
 	s.conns = conns
+	s.features = features
+	s.reputation = reputation
 	s.router = router
 
-	s.votingMetrics.Initialize(log, registerer)
+	s.throttler.Initialize(throttlerConfig, vdrs)
 
-	net := peerNet.AsMsgNetwork()
+	s.votingMetrics.Initialize(log, registerer)
 
-	net.RegHandler(GetAcceptedFrontier, salticidae.MsgNetworkMsgCallback(C.getAcceptedFrontier), nil)
-	net.RegHandler(AcceptedFrontier, salticidae.MsgNetworkMsgCallback(C.acceptedFrontier), nil)
-	net.RegHandler(GetAccepted, salticidae.MsgNetworkMsgCallback(C.getAccepted), nil)
-	net.RegHandler(Accepted, salticidae.MsgNetworkMsgCallback(C.accepted), nil)
-	net.RegHandler(Get, salticidae.MsgNetworkMsgCallback(C.get), nil)
-	net.RegHandler(Put, salticidae.MsgNetworkMsgCallback(C.put), nil)
-	net.RegHandler(PushQuery, salticidae.MsgNetworkMsgCallback(C.pushQuery), nil)
-	net.RegHandler(PullQuery, salticidae.MsgNetworkMsgCallback(C.pullQuery), nil)
-	net.RegHandler(Chits, salticidae.MsgNetworkMsgCallback(C.chits), nil)
+	peerNet.RegisterHandler(GetAcceptedFrontier, s.throttle(GetAcceptedFrontier, s.getAcceptedFrontier))
+	peerNet.RegisterHandler(AcceptedFrontier, s.throttle(AcceptedFrontier, s.acceptedFrontier))
+	peerNet.RegisterHandler(GetAccepted, s.throttle(GetAccepted, s.getAccepted))
+	peerNet.RegisterHandler(Accepted, s.throttle(Accepted, s.accepted))
+	peerNet.RegisterHandler(Get, s.throttle(Get, s.get))
+	peerNet.RegisterHandler(Put, s.throttle(Put, s.put))
+	peerNet.RegisterHandler(PushQuery, s.throttle(PushQuery, s.pushQuery))
+	peerNet.RegisterHandler(PullQuery, s.throttle(PullQuery, s.pullQuery))
+	peerNet.RegisterHandler(Chits, s.throttle(Chits, s.chits))
+	peerNet.RegisterHandler(GetStateSummary, s.throttle(GetStateSummary, s.getStateSummary))
+	peerNet.RegisterHandler(StateSummary, s.throttle(StateSummary, s.stateSummary))
+	peerNet.RegisterHandler(GetStateChunk, s.throttle(GetStateChunk, s.getStateChunk))
+	peerNet.RegisterHandler(StateChunk, s.throttle(StateChunk, s.stateChunk))
+	peerNet.RegisterHandler(GetAncestors, s.throttle(GetAncestors, s.getAncestors))
+	peerNet.RegisterHandler(MultiPut, s.throttle(MultiPut, s.multiPut))
 
 	s.executor.Initialize()
 	go log.RecoverAndPanic(s.executor.Dispatch)
@@ -89,17 +102,18 @@ func (s *Voting) Shutdown() { s.executor.Stop() }
 
 // Accept is called after every consensus decision
 func (s *Voting) Accept(chainID, containerID ids.ID, container []byte) error {
-	peers := []salticidae.PeerID(nil)
+	peers := []ids.ID(nil)
+	peerIDs := []ids.ShortID(nil)
 
 	allPeers, allIDs, _ := s.conns.Conns()
 	for i, id := range allIDs {
 		if !s.vdrs.Contains(id) {
 			peers = append(peers, allPeers[i])
+			peerIDs = append(peerIDs, id)
 		}
 	}
 
-	build := Builder{}
-	msg, err := build.Put(chainID, 0, containerID, container)
+	msgs, err := s.packContainer(peers, peerIDs, container, s.putBuilder(chainID, 0, containerID))
 	if err != nil {
 		return fmt.Errorf("Attempted to pack too large of a Put message.\nContainer length: %d: %w", len(container), err)
 	}
@@ -114,14 +128,14 @@ func (s *Voting) Accept(chainID, containerID ids.ID, container []byte) error {
 		containerID,
 		formatting.DumpBytes{Bytes: container},
 	)
-	s.send(msg, peers...)
+	s.sendContainer(msgs)
 	s.numPutSent.Add(float64(len(peers)))
 	return nil
 }
 
 // GetAcceptedFrontier implements the Sender interface.
 func (s *Voting) GetAcceptedFrontier(validatorIDs ids.ShortSet, chainID ids.ID, requestID uint32) {
-	peers := []salticidae.PeerID(nil)
+	peers := []ids.ID(nil)
 	validatorIDList := validatorIDs.List()
 	for _, validatorID := range validatorIDList {
 		vID := validatorID
@@ -181,7 +195,7 @@ func (s *Voting) AcceptedFrontier(validatorID ids.ShortID, chainID ids.ID, reque
 
 // GetAccepted implements the Sender interface.
 func (s *Voting) GetAccepted(validatorIDs ids.ShortSet, chainID ids.ID, requestID uint32, containerIDs ids.Set) {
-	peers := []salticidae.PeerID(nil)
+	peers := []ids.ID(nil)
 	validatorIDList := validatorIDs.List()
 	for _, validatorID := range validatorIDList {
 		vID := validatorID
@@ -284,8 +298,7 @@ func (s *Voting) Put(validatorID ids.ShortID, chainID ids.ID, requestID uint32,
 		return // Validator is not connected
 	}
 
-	build := Builder{}
-	msg, err := build.Put(chainID, requestID, containerID, container)
+	msgs, err := s.packContainer([]ids.ID{peer}, []ids.ShortID{validatorID}, container, s.putBuilder(chainID, requestID, containerID))
 	if err != nil {
 		s.log.Error("Attempted to pack too large of a Put message.\nContainer length: %d", len(container))
 		return // Packing message failed
@@ -303,18 +316,20 @@ func (s *Voting) Put(validatorID ids.ShortID, chainID ids.ID, requestID uint32,
 		containerID,
 		formatting.DumpBytes{Bytes: container},
 	)
-	s.send(msg, peer)
+	s.sendContainer(msgs)
 	s.numPutSent.Inc()
 }
 
 // PushQuery implements the Sender interface.
 func (s *Voting) PushQuery(validatorIDs ids.ShortSet, chainID ids.ID, requestID uint32, containerID ids.ID, container []byte) {
-	peers := []salticidae.PeerID(nil)
+	peers := []ids.ID(nil)
+	peerIDs := []ids.ShortID(nil)
 	validatorIDList := validatorIDs.List()
 	for _, validatorID := range validatorIDList {
 		vID := validatorID
 		if peer, exists := s.conns.GetPeerID(vID); exists {
 			peers = append(peers, peer)
+			peerIDs = append(peerIDs, vID)
 			s.log.Verbo("Sending a PushQuery to %s", vID)
 		} else {
 			s.log.Debug("Attempted to send a PushQuery message to a disconnected validator: %s", vID)
@@ -322,8 +337,7 @@ func (s *Voting) PushQuery(validatorIDs ids.ShortSet, chainID ids.ID, requestID
 		}
 	}
 
-	build := Builder{}
-	msg, err := build.PushQuery(chainID, requestID, containerID, container)
+	msgs, err := s.packContainer(peers, peerIDs, container, s.pushQueryBuilder(chainID, requestID, containerID))
 	if err != nil {
 		for _, peer := range peers {
 			if validatorID, exists := s.conns.GetID(peer); exists {
@@ -346,13 +360,13 @@ func (s *Voting) PushQuery(validatorIDs ids.ShortSet, chainID ids.ID, requestID
 		containerID,
 		formatting.DumpBytes{Bytes: container},
 	)
-	s.send(msg, peers...)
+	s.sendContainer(msgs)
 	s.numPushQuerySent.Add(float64(len(peers)))
 }
 
 // PullQuery implements the Sender interface.
 func (s *Voting) PullQuery(validatorIDs ids.ShortSet, chainID ids.ID, requestID uint32, containerID ids.ID) {
-	peers := []salticidae.PeerID(nil)
+	peers := []ids.ID(nil)
 	validatorIDList := validatorIDs.List()
 	for _, validatorID := range validatorIDList {
 		vID := validatorID
@@ -412,46 +426,344 @@ func (s *Voting) Chits(validatorID ids.ShortID, chainID ids.ID, requestID uint32
 	s.numChitsSent.Inc()
 }
 
-func (s *Voting) send(msg Msg, peers ...salticidae.PeerID) {
-	ds := msg.DataStream()
-	defer ds.Free()
-	ba := salticidae.NewByteArrayMovedFromDataStream(ds, false)
-	defer ba.Free()
-	cMsg := salticidae.NewMsgMovedFromByteArray(msg.Op(), ba, false)
-	defer cMsg.Free()
+// GetStateSummary implements the Sender interface.
+func (s *Voting) GetStateSummary(validatorIDs ids.ShortSet, chainID ids.ID, requestID uint32) {
+	peers := []ids.ID(nil)
+	validatorIDList := validatorIDs.List()
+	for _, validatorID := range validatorIDList {
+		vID := validatorID
+		peer, exists := s.conns.GetPeerID(vID)
+		switch {
+		case !exists:
+			s.log.Debug("Attempted to send a GetStateSummary message to a disconnected validator: %s", vID)
+			s.executor.Add(func() { s.router.GetStateSummaryFailed(vID, chainID, requestID) })
+		case !s.supports(vID, StateSyncMsgVersion):
+			s.log.Debug("Attempted to send a GetStateSummary message to a validator that doesn't support state sync: %s", vID)
+			s.executor.Add(func() { s.router.GetStateSummaryFailed(vID, chainID, requestID) })
+		default:
+			peers = append(peers, peer)
+			s.log.Verbo("Sending a GetStateSummary to %s", vID)
+		}
+	}
+
+	build := Builder{}
+	msg, err := build.GetStateSummary(chainID, requestID)
+	s.log.AssertNoError(err)
+
+	s.log.Verbo("Sending a GetStateSummary message."+
+		"\nNumber of Validators: %d"+
+		"\nChain: %s"+
+		"\nRequest ID: %d",
+		len(peers),
+		chainID,
+		requestID,
+	)
+	s.send(msg, peers...)
+	s.numGetStateSummarySent.Add(float64(len(peers)))
+}
+
+// StateSummary implements the Sender interface.
+func (s *Voting) StateSummary(validatorID ids.ShortID, chainID ids.ID, requestID uint32, summary []byte) {
+	peer, exists := s.conns.GetPeerID(validatorID)
+	if !exists {
+		s.log.Debug("Attempted to send a StateSummary message to a disconnected validator: %s", validatorID)
+		return // Validator is not connected
+	}
+
+	build := Builder{}
+	msg, err := build.StateSummary(chainID, requestID, summary)
+	if err != nil {
+		s.log.Error("Attempted to pack too large of a StateSummary message.\nSummary length: %d", len(summary))
+		return // Packing message failed
+	}
+
+	s.log.Verbo("Sending a StateSummary message."+
+		"\nValidator: %s"+
+		"\nChain: %s"+
+		"\nRequest ID: %d"+
+		"\nSummary:\n%s",
+		validatorID,
+		chainID,
+		requestID,
+		formatting.DumpBytes{Bytes: summary},
+	)
+	s.send(msg, peer)
+	s.numStateSummarySent.Inc()
+}
+
+// GetStateChunk implements the Sender interface.
+func (s *Voting) GetStateChunk(validatorID ids.ShortID, chainID ids.ID, requestID uint32, summaryID ids.ID, index uint32) {
+	peer, exists := s.conns.GetPeerID(validatorID)
+	if !exists {
+		s.log.Debug("Attempted to send a GetStateChunk message to a disconnected validator: %s", validatorID)
+		s.executor.Add(func() { s.router.GetStateChunkFailed(validatorID, chainID, requestID) })
+		return // Validator is not connected
+	}
+	if !s.supports(validatorID, StateSyncMsgVersion) {
+		s.log.Debug("Attempted to send a GetStateChunk message to a validator that doesn't support state sync: %s", validatorID)
+		s.executor.Add(func() { s.router.GetStateChunkFailed(validatorID, chainID, requestID) })
+		return
+	}
+
+	build := Builder{}
+	msg, err := build.GetStateChunk(chainID, requestID, summaryID, index)
+	s.log.AssertNoError(err)
+
+	s.log.Verbo("Sending a GetStateChunk message."+
+		"\nValidator: %s"+
+		"\nChain: %s"+
+		"\nRequest ID: %d"+
+		"\nSummary ID: %s"+
+		"\nChunk Index: %d",
+		validatorID,
+		chainID,
+		requestID,
+		summaryID,
+		index,
+	)
+	s.send(msg, peer)
+	s.numGetStateChunkSent.Inc()
+}
+
+// StateChunk implements the Sender interface.
+func (s *Voting) StateChunk(validatorID ids.ShortID, chainID ids.ID, requestID uint32, chunk []byte) {
+	peer, exists := s.conns.GetPeerID(validatorID)
+	if !exists {
+		s.log.Debug("Attempted to send a StateChunk message to a disconnected validator: %s", validatorID)
+		return // Validator is not connected
+	}
+
+	build := Builder{}
+	msg, err := build.StateChunk(chainID, requestID, chunk)
+	if err != nil {
+		s.log.Error("Attempted to pack too large of a StateChunk message.\nChunk length: %d", len(chunk))
+		return // Packing message failed
+	}
+
+	s.log.Verbo("Sending a StateChunk message."+
+		"\nValidator: %s"+
+		"\nChain: %s"+
+		"\nRequest ID: %d"+
+		"\nChunk length: %d",
+		validatorID,
+		chainID,
+		requestID,
+		len(chunk),
+	)
+	s.send(msg, peer)
+	s.numStateChunkSent.Inc()
+}
+
+// GetAncestors implements the Sender interface. Validators that don't support
+// GetAncestors are sent a Get for the container instead, whose response the
+// bootstrapping engine handles as a MultiPut of only that container.
+func (s *Voting) GetAncestors(validatorID ids.ShortID, chainID ids.ID, requestID uint32, containerID ids.ID) {
+	peer, exists := s.conns.GetPeerID(validatorID)
+	if !exists {
+		s.log.Debug("Attempted to send a GetAncestors message to a disconnected validator: %s", validatorID)
+		s.executor.Add(func() { s.router.GetAncestorsFailed(validatorID, chainID, requestID) })
+		return // Validator is not connected
+	}
+	if !s.supports(validatorID, AncestorsMsgVersion) {
+		s.log.Verbo("Sending a Get rather than a GetAncestors to %s", validatorID)
+		s.Get(validatorID, chainID, requestID, containerID)
+		return
+	}
+
+	build := Builder{}
+	msg, err := build.GetAncestors(chainID, requestID, containerID)
+	s.log.AssertNoError(err)
+
+	s.log.Verbo("Sending a GetAncestors message."+
+		"\nValidator: %s"+
+		"\nChain: %s"+
+		"\nRequest ID: %d"+
+		"\nContainer ID: %s",
+		validatorID,
+		chainID,
+		requestID,
+		containerID,
+	)
+	s.send(msg, peer)
+	s.numGetAncestorsSent.Inc()
+}
+
+// MultiPut implements the Sender interface.
+func (s *Voting) MultiPut(validatorID ids.ShortID, chainID ids.ID, requestID uint32, containers [][]byte) {
+	peer, exists := s.conns.GetPeerID(validatorID)
+	if !exists {
+		s.log.Debug("Attempted to send a MultiPut message to a disconnected validator: %s", validatorID)
+		return // Validator is not connected
+	}
+
+	build := Builder{}
+	msg, err := build.MultiPut(chainID, requestID, containers)
+	if err != nil {
+		s.log.Error("Attempted to pack too large of a MultiPut message.\nNumber of containers: %d", len(containers))
+		return // Packing message failed
+	}
+
+	s.log.Verbo("Sending a MultiPut message."+
+		"\nValidator: %s"+
+		"\nChain: %s"+
+		"\nRequest ID: %d"+
+		"\nNumber of containers: %d",
+		validatorID,
+		chainID,
+		requestID,
+		len(containers),
+	)
+	s.send(msg, peer)
+	s.numMultiPutSent.Inc()
+}
+
+func (s *Voting) send(msg Msg, peers ...ids.ID) { s.net.Send(msg, peers...) }
+
+// supports returns true if the message version negotiated with [validatorID]
+// is at least [msgVersion]
+func (s *Voting) supports(validatorID ids.ShortID, msgVersion uint32) bool {
+	peerVersion, ok := s.features.PeerMsgVersion(validatorID)
+	return ok && peerVersion >= msgVersion
+}
+
+// throttle wraps [handler] so that messages of type [op] are dropped when the
+// peer that sent them has exceeded its rate limit
+func (s *Voting) throttle(op Op, handler MsgHandler) MsgHandler {
+	return func(msg Msg, conn Conn) {
+		if validatorID, exists := s.conns.GetID(conn.PeerID()); exists && !s.throttler.Allow(validatorID, op) {
+			s.log.Verbo("Dropping %s message from %s due to rate limiting", op, validatorID)
+			s.numThrottled[op].Inc()
+			return
+		}
+		handler(msg, conn)
+	}
+}
+
+// containerBuilder packs a message that contains a container that was
+// compressed with [compression]
+type containerBuilder func(container []byte, compression Compression) (Msg, error)
+
+// containerMsg is a message that contains a container, along with the peers
+// it should be sent to
+type containerMsg struct {
+	msg        Msg
+	peers      []ids.ID
+	savedBytes int // bytes saved for each peer by compressing the container
+}
 
-	switch len(peers) {
-	case 0:
-	case 1:
-		s.net.SendMsg(cMsg, peers[0])
-	default:
-		s.net.MulticastMsgByMove(cMsg, peers)
+func (s *Voting) putBuilder(chainID ids.ID, requestID uint32, containerID ids.ID) containerBuilder {
+	return func(container []byte, compression Compression) (Msg, error) {
+		build := Builder{}
+		if compression == NoCompression {
+			return build.Put(chainID, requestID, containerID, container)
+		}
+		return build.CompressedPut(chainID, requestID, containerID, container, compression)
+	}
+}
+
+func (s *Voting) pushQueryBuilder(chainID ids.ID, requestID uint32, containerID ids.ID) containerBuilder {
+	return func(container []byte, compression Compression) (Msg, error) {
+		build := Builder{}
+		if compression == NoCompression {
+			return build.PushQuery(chainID, requestID, containerID, container)
+		}
+		return build.CompressedPushQuery(chainID, requestID, containerID, container, compression)
+	}
+}
+
+// packContainer packs the messages to send [container] to the peers. Peers
+// that negotiated compression are sent the container compressed, if that makes
+// it smaller. [peerIDs] are the IDs of the peers, in the same order.
+func (s *Voting) packContainer(peers []ids.ID, peerIDs []ids.ShortID, container []byte, build containerBuilder) ([]containerMsg, error) {
+	peersByCompression := make(map[Compression][]ids.ID)
+	for i, peer := range peers {
+		compression := s.features.PeerCompression(peerIDs[i])
+		peersByCompression[compression] = append(peersByCompression[compression], peer)
+	}
+
+	msgs := []containerMsg(nil)
+	uncompressedPeers := peersByCompression[NoCompression]
+	for compression, compressionPeers := range peersByCompression {
+		if compression == NoCompression {
+			continue
+		}
+
+		compressed, ok := compression.Compress(container)
+		if !ok {
+			uncompressedPeers = append(uncompressedPeers, compressionPeers...)
+			continue
+		}
+
+		msg, err := build(compressed, compression)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, containerMsg{
+			msg:        msg,
+			peers:      compressionPeers,
+			savedBytes: len(container) - len(compressed),
+		})
+	}
+
+	if len(uncompressedPeers) > 0 {
+		msg, err := build(container, NoCompression)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, containerMsg{
+			msg:   msg,
+			peers: uncompressedPeers,
+		})
+	}
+	return msgs, nil
+}
+
+// sendContainer sends the packed container messages to their peers
+func (s *Voting) sendContainer(msgs []containerMsg) {
+	for _, msg := range msgs {
+		s.send(msg.msg, msg.peers...)
+		s.compressionSavedBytesSent.Add(float64(msg.savedBytes * len(msg.peers)))
 	}
 }
 
+// container returns the decompressed container of a Put or PushQuery message
+func (s *Voting) container(msg Msg) ([]byte, error) {
+	containerBytes := msg.Get(ContainerBytes).([]byte)
+
+	compression, ok := msg.Get(ContainerCompression).(uint32)
+	if !ok {
+		return containerBytes, nil
+	}
+
+	container, err := Compression(compression).Decompress(containerBytes)
+	if err != nil {
+		return nil, err
+	}
+	s.compressionSavedBytesReceived.Add(float64(len(container) - len(containerBytes)))
+	return container, nil
+}
+
 // getAcceptedFrontier handles the recept of a getAcceptedFrontier container
 // message for a chain
-//export getAcceptedFrontier
-func getAcceptedFrontier(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.Pointer) {
-	VotingNet.numGetAcceptedFrontierReceived.Inc()
+func (s *Voting) getAcceptedFrontier(msg Msg, conn Conn) {
+	s.numGetAcceptedFrontierReceived.Inc()
 
-	validatorID, chainID, requestID, _, err := VotingNet.sanitize(_msg, _conn, GetAcceptedFrontier)
+	validatorID, chainID, requestID, err := s.sanitize(msg, conn, GetAcceptedFrontier)
 	if err != nil {
-		VotingNet.log.Error("Failed to sanitize message due to: %s", err)
+		s.log.Error("Failed to sanitize message due to: %s", err)
 		return
 	}
 
-	VotingNet.router.GetAcceptedFrontier(validatorID, chainID, requestID)
+	s.router.GetAcceptedFrontier(validatorID, chainID, requestID)
 }
 
 // acceptedFrontier handles the recept of an acceptedFrontier message
-//export acceptedFrontier
-func acceptedFrontier(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.Pointer) {
-	VotingNet.numAcceptedFrontierReceived.Inc()
+func (s *Voting) acceptedFrontier(msg Msg, conn Conn) {
+	s.numAcceptedFrontierReceived.Inc()
 
-	validatorID, chainID, requestID, msg, err := VotingNet.sanitize(_msg, _conn, AcceptedFrontier)
+	validatorID, chainID, requestID, err := s.sanitize(msg, conn, AcceptedFrontier)
 	if err != nil {
-		VotingNet.log.Error("Failed to sanitize message due to: %s", err)
+		s.log.Error("Failed to sanitize message due to: %s", err)
 		return
 	}
 
@@ -459,23 +771,22 @@ func acceptedFrontier(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _
 	for _, containerIDBytes := range msg.Get(ContainerIDs).([][]byte) {
 		containerID, err := ids.ToID(containerIDBytes)
 		if err != nil {
-			VotingNet.log.Warn("Error parsing ContainerID: %v", containerIDBytes)
+			s.log.Warn("Error parsing ContainerID: %v", containerIDBytes)
 			return
 		}
 		containerIDs.Add(containerID)
 	}
 
-	VotingNet.router.AcceptedFrontier(validatorID, chainID, requestID, containerIDs)
+	s.router.AcceptedFrontier(validatorID, chainID, requestID, containerIDs)
 }
 
 // getAccepted handles the recept of a getAccepted message
-//export getAccepted
-func getAccepted(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.Pointer) {
-	VotingNet.numGetAcceptedReceived.Inc()
+func (s *Voting) getAccepted(msg Msg, conn Conn) {
+	s.numGetAcceptedReceived.Inc()
 
-	validatorID, chainID, requestID, msg, err := VotingNet.sanitize(_msg, _conn, GetAccepted)
+	validatorID, chainID, requestID, err := s.sanitize(msg, conn, GetAccepted)
 	if err != nil {
-		VotingNet.log.Error("Failed to sanitize message due to: %s", err)
+		s.log.Error("Failed to sanitize message due to: %s", err)
 		return
 	}
 
@@ -483,23 +794,22 @@ func getAccepted(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsa
 	for _, containerIDBytes := range msg.Get(ContainerIDs).([][]byte) {
 		containerID, err := ids.ToID(containerIDBytes)
 		if err != nil {
-			VotingNet.log.Warn("Error parsing ContainerID: %v", containerIDBytes)
+			s.log.Warn("Error parsing ContainerID: %v", containerIDBytes)
 			return
 		}
 		containerIDs.Add(containerID)
 	}
 
-	VotingNet.router.GetAccepted(validatorID, chainID, requestID, containerIDs)
+	s.router.GetAccepted(validatorID, chainID, requestID, containerIDs)
 }
 
 // accepted handles the recept of an accepted message
-//export accepted
-func accepted(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.Pointer) {
-	VotingNet.numAcceptedReceived.Inc()
+func (s *Voting) accepted(msg Msg, conn Conn) {
+	s.numAcceptedReceived.Inc()
 
-	validatorID, chainID, requestID, msg, err := VotingNet.sanitize(_msg, _conn, Accepted)
+	validatorID, chainID, requestID, err := s.sanitize(msg, conn, Accepted)
 	if err != nil {
-		VotingNet.log.Error("Failed to sanitize message due to: %s", err)
+		s.log.Error("Failed to sanitize message due to: %s", err)
 		return
 	}
 
@@ -507,91 +817,96 @@ func accepted(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.
 	for _, containerIDBytes := range msg.Get(ContainerIDs).([][]byte) {
 		containerID, err := ids.ToID(containerIDBytes)
 		if err != nil {
-			VotingNet.log.Warn("Error parsing ContainerID: %v", containerIDBytes)
+			s.log.Warn("Error parsing ContainerID: %v", containerIDBytes)
 			return
 		}
 		containerIDs.Add(containerID)
 	}
 
-	VotingNet.router.Accepted(validatorID, chainID, requestID, containerIDs)
+	s.router.Accepted(validatorID, chainID, requestID, containerIDs)
 }
 
 // get handles the recept of a get container message for a chain
-//export get
-func get(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.Pointer) {
-	VotingNet.numGetReceived.Inc()
+func (s *Voting) get(msg Msg, conn Conn) {
+	s.numGetReceived.Inc()
 
-	validatorID, chainID, requestID, msg, err := VotingNet.sanitize(_msg, _conn, Get)
+	validatorID, chainID, requestID, err := s.sanitize(msg, conn, Get)
 	if err != nil {
-		VotingNet.log.Error("Failed to sanitize message due to: %s", err)
+		s.log.Error("Failed to sanitize message due to: %s", err)
 		return
 	}
 
 	containerID, _ := ids.ToID(msg.Get(ContainerID).([]byte))
 
-	VotingNet.router.Get(validatorID, chainID, requestID, containerID)
+	s.router.Get(validatorID, chainID, requestID, containerID)
 }
 
 // put handles the receipt of a container message
-//export put
-func put(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.Pointer) {
-	VotingNet.numPutReceived.Inc()
+func (s *Voting) put(msg Msg, conn Conn) {
+	s.numPutReceived.Inc()
 
-	validatorID, chainID, requestID, msg, err := VotingNet.sanitize(_msg, _conn, Put)
+	validatorID, chainID, requestID, err := s.sanitize(msg, conn, Put)
 	if err != nil {
-		VotingNet.log.Error("Failed to sanitize message due to: %s", err)
+		s.log.Error("Failed to sanitize message due to: %s", err)
 		return
 	}
 
 	containerID, _ := ids.ToID(msg.Get(ContainerID).([]byte))
 
-	containerBytes := msg.Get(ContainerBytes).([]byte)
+	containerBytes, err := s.container(msg)
+	if err != nil {
+		s.log.Debug("Failed to decompress the container of a Put message due to: %s", err)
+		s.reputation.Report(validatorID, reputation.InvalidContainer)
+		return
+	}
 
-	VotingNet.router.Put(validatorID, chainID, requestID, containerID, containerBytes)
+	s.router.Put(validatorID, chainID, requestID, containerID, containerBytes)
 }
 
 // pushQuery handles the recept of a pull query message
-//export pushQuery
-func pushQuery(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.Pointer) {
-	VotingNet.numPushQueryReceived.Inc()
+func (s *Voting) pushQuery(msg Msg, conn Conn) {
+	s.numPushQueryReceived.Inc()
 
-	validatorID, chainID, requestID, msg, err := VotingNet.sanitize(_msg, _conn, PushQuery)
+	validatorID, chainID, requestID, err := s.sanitize(msg, conn, PushQuery)
 	if err != nil {
-		VotingNet.log.Error("Failed to sanitize message due to: %s", err)
+		s.log.Error("Failed to sanitize message due to: %s", err)
 		return
 	}
 
 	containerID, _ := ids.ToID(msg.Get(ContainerID).([]byte))
 
-	containerBytes := msg.Get(ContainerBytes).([]byte)
+	containerBytes, err := s.container(msg)
+	if err != nil {
+		s.log.Debug("Failed to decompress the container of a PushQuery message due to: %s", err)
+		s.reputation.Report(validatorID, reputation.InvalidContainer)
+		return
+	}
 
-	VotingNet.router.PushQuery(validatorID, chainID, requestID, containerID, containerBytes)
+	s.router.PushQuery(validatorID, chainID, requestID, containerID, containerBytes)
 }
 
 // pullQuery handles the recept of a query message
-//export pullQuery
-func pullQuery(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.Pointer) {
-	VotingNet.numPullQueryReceived.Inc()
+func (s *Voting) pullQuery(msg Msg, conn Conn) {
+	s.numPullQueryReceived.Inc()
 
-	validatorID, chainID, requestID, msg, err := VotingNet.sanitize(_msg, _conn, PullQuery)
+	validatorID, chainID, requestID, err := s.sanitize(msg, conn, PullQuery)
 	if err != nil {
-		VotingNet.log.Error("Failed to sanitize message due to: %s", err)
+		s.log.Error("Failed to sanitize message due to: %s", err)
 		return
 	}
 
 	containerID, _ := ids.ToID(msg.Get(ContainerID).([]byte))
 
-	VotingNet.router.PullQuery(validatorID, chainID, requestID, containerID)
+	s.router.PullQuery(validatorID, chainID, requestID, containerID)
 }
 
 // chits handles the recept of a chits message
-//export chits
-func chits(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.Pointer) {
-	VotingNet.numChitsReceived.Inc()
+func (s *Voting) chits(msg Msg, conn Conn) {
+	s.numChitsReceived.Inc()
 
-	validatorID, chainID, requestID, msg, err := VotingNet.sanitize(_msg, _conn, Chits)
+	validatorID, chainID, requestID, err := s.sanitize(msg, conn, Chits)
 	if err != nil {
-		VotingNet.log.Error("Failed to sanitize message due to: %s", err)
+		s.log.Error("Failed to sanitize message due to: %s", err)
 		return
 	}
 
@@ -599,38 +914,121 @@ func chits(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.Poi
 	for _, voteBytes := range msg.Get(ContainerIDs).([][]byte) {
 		vote, err := ids.ToID(voteBytes)
 		if err != nil {
-			VotingNet.log.Warn("Error parsing chit: %v", voteBytes)
+			s.log.Warn("Error parsing chit: %v", voteBytes)
 			return
 		}
 		votes.Add(vote)
 	}
 
-	VotingNet.router.Chits(validatorID, chainID, requestID, votes)
+	s.router.Chits(validatorID, chainID, requestID, votes)
+}
+
+// getStateSummary handles the recept of a getStateSummary message
+func (s *Voting) getStateSummary(msg Msg, conn Conn) {
+	s.numGetStateSummaryReceived.Inc()
+
+	validatorID, chainID, requestID, err := s.sanitize(msg, conn, GetStateSummary)
+	if err != nil {
+		s.log.Error("Failed to sanitize message due to: %s", err)
+		return
+	}
+
+	s.router.GetStateSummary(validatorID, chainID, requestID)
+}
+
+// stateSummary handles the recept of a stateSummary message
+func (s *Voting) stateSummary(msg Msg, conn Conn) {
+	s.numStateSummaryReceived.Inc()
+
+	validatorID, chainID, requestID, err := s.sanitize(msg, conn, StateSummary)
+	if err != nil {
+		s.log.Error("Failed to sanitize message due to: %s", err)
+		return
+	}
+
+	s.router.StateSummary(validatorID, chainID, requestID, msg.Get(ContainerBytes).([]byte))
 }
 
-func (s *Voting) sanitize(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, op salticidae.Opcode) (ids.ShortID, ids.ID, uint32, Msg, error) {
-	conn := salticidae.PeerNetworkConnFromC(salticidae.CPeerNetworkConn((*C.peernetwork_conn_t)(_conn)))
-	peer := conn.GetPeerID(false)
-	defer peer.Free()
+// getStateChunk handles the recept of a getStateChunk message
+func (s *Voting) getStateChunk(msg Msg, conn Conn) {
+	s.numGetStateChunkReceived.Inc()
 
-	validatorID, exists := s.conns.GetID(peer)
+	validatorID, chainID, requestID, err := s.sanitize(msg, conn, GetStateChunk)
+	if err != nil {
+		s.log.Error("Failed to sanitize message due to: %s", err)
+		return
+	}
+
+	summaryID, err := ids.ToID(msg.Get(ContainerID).([]byte))
+	if err != nil {
+		s.log.Warn("Error parsing SummaryID: %v", msg.Get(ContainerID))
+		return
+	}
+
+	s.router.GetStateChunk(validatorID, chainID, requestID, summaryID, msg.Get(ChunkIndex).(uint32))
+}
+
+// stateChunk handles the recept of a stateChunk message
+func (s *Voting) stateChunk(msg Msg, conn Conn) {
+	s.numStateChunkReceived.Inc()
+
+	validatorID, chainID, requestID, err := s.sanitize(msg, conn, StateChunk)
+	if err != nil {
+		s.log.Error("Failed to sanitize message due to: %s", err)
+		return
+	}
+
+	s.router.StateChunk(validatorID, chainID, requestID, msg.Get(ContainerBytes).([]byte))
+}
+
+// getAncestors handles the recept of a getAncestors message
+func (s *Voting) getAncestors(msg Msg, conn Conn) {
+	s.numGetAncestorsReceived.Inc()
+
+	validatorID, chainID, requestID, err := s.sanitize(msg, conn, GetAncestors)
+	if err != nil {
+		s.log.Error("Failed to sanitize message due to: %s", err)
+		return
+	}
+
+	containerID, err := ids.ToID(msg.Get(ContainerID).([]byte))
+	if err != nil {
+		s.log.Warn("Error parsing ContainerID: %v", msg.Get(ContainerID))
+		return
+	}
+
+	s.router.GetAncestors(validatorID, chainID, requestID, containerID)
+}
+
+// multiPut handles the recept of a multiPut message
+func (s *Voting) multiPut(msg Msg, conn Conn) {
+	s.numMultiPutReceived.Inc()
+
+	validatorID, chainID, requestID, err := s.sanitize(msg, conn, MultiPut)
+	if err != nil {
+		s.log.Error("Failed to sanitize message due to: %s", err)
+		return
+	}
+
+	s.router.MultiPut(validatorID, chainID, requestID, msg.Get(MultiContainerBytes).([][]byte))
+}
+
+func (s *Voting) sanitize(msg Msg, conn Conn, op Op) (ids.ShortID, ids.ID, uint32, error) {
+	validatorID, exists := s.conns.GetID(conn.PeerID())
 	if !exists {
-		return ids.ShortID{}, ids.ID{}, 0, nil, fmt.Errorf("message received from an un-registered peer")
+		return ids.ShortID{}, ids.ID{}, 0, fmt.Errorf("message received from an un-registered peer")
 	}
 
 	s.log.Verbo("Receiving message from %s", validatorID)
 
-	msg := salticidae.MsgFromC(salticidae.CMsg(_msg))
-	codec := Codec{}
-	pMsg, err := codec.Parse(op, msg.GetPayloadByMove())
-	if err != nil {
-		return ids.ShortID{}, ids.ID{}, 0, nil, err // The message couldn't be parsed
+	if msg.Op() != op {
+		return ids.ShortID{}, ids.ID{}, 0, errBadOp
 	}
 
-	chainID, err := ids.ToID(pMsg.Get(ChainID).([]byte))
+	chainID, err := ids.ToID(msg.Get(ChainID).([]byte))
 	s.log.AssertNoError(err)
 
-	requestID := pMsg.Get(RequestID).(uint32)
+	requestID := msg.Get(RequestID).(uint32)
 
-	return validatorID, chainID, requestID, pMsg, nil
+	return validatorID, chainID, requestID, nil
 }
@@ -0,0 +1,55 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package networking
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
+	"github.com/ava-labs/gecko/utils"
+	"github.com/ava-labs/gecko/utils/logging"
+)
+
+// eventRecorder records the events reported about peers
+type eventRecorder map[[20]byte][]reputation.Event
+
+func (r eventRecorder) Report(id ids.ShortID, event reputation.Event) {
+	r[id.Key()] = append(r[id.Key()], event)
+}
+
+func TestVotingReportsUndecompressableContainers(t *testing.T) {
+	_, cert := testStakingKey(t)
+	conn := &testConn{cert: cert}
+	validatorID := ids.NewShortID([20]byte{1})
+
+	events := eventRecorder{}
+	s := Voting{
+		log:        logging.NoLog{},
+		conns:      NewConnections(),
+		reputation: events,
+	}
+	s.conns.Add(conn.PeerID(), validatorID, utils.IPDesc{})
+	s.votingMetrics.Initialize(logging.NoLog{}, prometheus.NewRegistry())
+
+	build := Builder{}
+	put, err := build.CompressedPut(ids.Empty, 0, ids.Empty, []byte{1, 2, 3}, GzipCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.put(put, conn)
+
+	pushQuery, err := build.CompressedPushQuery(ids.Empty, 0, ids.Empty, []byte{1, 2, 3}, GzipCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.pushQuery(pushQuery, conn)
+
+	reported := events[validatorID.Key()]
+	if len(reported) != 2 || reported[0] != reputation.InvalidContainer || reported[1] != reputation.InvalidContainer {
+		t.Fatalf("Should have reported both invalid containers, but reported %v", reported)
+	}
+}
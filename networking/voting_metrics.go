@@ -4,6 +4,8 @@
 package networking
 
 import (
+	"fmt"
+
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/ava-labs/gecko/utils/logging"
@@ -18,7 +20,17 @@ type votingMetrics struct {
 	numPutSent, numPutReceived,
 	numPushQuerySent, numPushQueryReceived,
 	numPullQuerySent, numPullQueryReceived,
-	numChitsSent, numChitsReceived prometheus.Counter
+	numChitsSent, numChitsReceived,
+	numGetStateSummarySent, numGetStateSummaryReceived,
+	numStateSummarySent, numStateSummaryReceived,
+	numGetStateChunkSent, numGetStateChunkReceived,
+	numStateChunkSent, numStateChunkReceived,
+	numGetAncestorsSent, numGetAncestorsReceived,
+	numMultiPutSent, numMultiPutReceived,
+	compressionSavedBytesSent, compressionSavedBytesReceived prometheus.Counter
+
+	// Number of messages of each type that were dropped due to rate limiting
+	numThrottled map[Op]prometheus.Counter
 }
 
 func (vm *votingMetrics) Initialize(log logging.Logger, registerer prometheus.Registerer) {
@@ -130,6 +142,99 @@ func (vm *votingMetrics) Initialize(log logging.Logger, registerer prometheus.Re
 			Name:      "chits_received",
 			Help:      "Number of chits messages received",
 		})
+	vm.numGetStateSummarySent = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gecko",
+			Name:      "get_state_summary_sent",
+			Help:      "Number of get state summary messages sent",
+		})
+	vm.numGetStateSummaryReceived = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gecko",
+			Name:      "get_state_summary_received",
+			Help:      "Number of get state summary messages received",
+		})
+	vm.numStateSummarySent = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gecko",
+			Name:      "state_summary_sent",
+			Help:      "Number of state summary messages sent",
+		})
+	vm.numStateSummaryReceived = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gecko",
+			Name:      "state_summary_received",
+			Help:      "Number of state summary messages received",
+		})
+	vm.numGetStateChunkSent = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gecko",
+			Name:      "get_state_chunk_sent",
+			Help:      "Number of get state chunk messages sent",
+		})
+	vm.numGetStateChunkReceived = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gecko",
+			Name:      "get_state_chunk_received",
+			Help:      "Number of get state chunk messages received",
+		})
+	vm.numStateChunkSent = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gecko",
+			Name:      "state_chunk_sent",
+			Help:      "Number of state chunk messages sent",
+		})
+	vm.numStateChunkReceived = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gecko",
+			Name:      "state_chunk_received",
+			Help:      "Number of state chunk messages received",
+		})
+	vm.numGetAncestorsSent = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gecko",
+			Name:      "get_ancestors_sent",
+			Help:      "Number of get ancestors messages sent",
+		})
+	vm.numGetAncestorsReceived = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gecko",
+			Name:      "get_ancestors_received",
+			Help:      "Number of get ancestors messages received",
+		})
+	vm.numMultiPutSent = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gecko",
+			Name:      "multi_put_sent",
+			Help:      "Number of multi put messages sent",
+		})
+	vm.numMultiPutReceived = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gecko",
+			Name:      "multi_put_received",
+			Help:      "Number of multi put messages received",
+		})
+	vm.compressionSavedBytesSent = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gecko",
+			Name:      "compression_saved_bytes_sent",
+			Help:      "Number of bytes saved by compressing the containers that were sent",
+		})
+	vm.compressionSavedBytesReceived = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gecko",
+			Name:      "compression_saved_bytes_received",
+			Help:      "Number of bytes saved by compressing the containers that were received",
+		})
+	vm.numThrottled = make(map[Op]prometheus.Counter)
+	for _, op := range votingOps {
+		vm.numThrottled[op] = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: "gecko",
+				Name:      fmt.Sprintf("%s_throttled", op),
+				Help:      fmt.Sprintf("Number of %s messages dropped due to rate limiting", op),
+			})
+	}
 
 	if err := registerer.Register(vm.numGetAcceptedFrontierSent); err != nil {
 		log.Error("Failed to register get_accepted_frontier_sent statistics due to %s", err)
@@ -185,4 +290,51 @@ func (vm *votingMetrics) Initialize(log logging.Logger, registerer prometheus.Re
 	if err := registerer.Register(vm.numChitsReceived); err != nil {
 		log.Error("Failed to register chits_received statistics due to %s", err)
 	}
+	if err := registerer.Register(vm.numGetStateSummarySent); err != nil {
+		log.Error("Failed to register get_state_summary_sent statistics due to %s", err)
+	}
+	if err := registerer.Register(vm.numGetStateSummaryReceived); err != nil {
+		log.Error("Failed to register get_state_summary_received statistics due to %s", err)
+	}
+	if err := registerer.Register(vm.numStateSummarySent); err != nil {
+		log.Error("Failed to register state_summary_sent statistics due to %s", err)
+	}
+	if err := registerer.Register(vm.numStateSummaryReceived); err != nil {
+		log.Error("Failed to register state_summary_received statistics due to %s", err)
+	}
+	if err := registerer.Register(vm.numGetStateChunkSent); err != nil {
+		log.Error("Failed to register get_state_chunk_sent statistics due to %s", err)
+	}
+	if err := registerer.Register(vm.numGetStateChunkReceived); err != nil {
+		log.Error("Failed to register get_state_chunk_received statistics due to %s", err)
+	}
+	if err := registerer.Register(vm.numStateChunkSent); err != nil {
+		log.Error("Failed to register state_chunk_sent statistics due to %s", err)
+	}
+	if err := registerer.Register(vm.numStateChunkReceived); err != nil {
+		log.Error("Failed to register state_chunk_received statistics due to %s", err)
+	}
+	if err := registerer.Register(vm.numGetAncestorsSent); err != nil {
+		log.Error("Failed to register get_ancestors_sent statistics due to %s", err)
+	}
+	if err := registerer.Register(vm.numGetAncestorsReceived); err != nil {
+		log.Error("Failed to register get_ancestors_received statistics due to %s", err)
+	}
+	if err := registerer.Register(vm.numMultiPutSent); err != nil {
+		log.Error("Failed to register multi_put_sent statistics due to %s", err)
+	}
+	if err := registerer.Register(vm.numMultiPutReceived); err != nil {
+		log.Error("Failed to register multi_put_received statistics due to %s", err)
+	}
+	if err := registerer.Register(vm.compressionSavedBytesSent); err != nil {
+		log.Error("Failed to register compression_saved_bytes_sent statistics due to %s", err)
+	}
+	if err := registerer.Register(vm.compressionSavedBytesReceived); err != nil {
+		log.Error("Failed to register compression_saved_bytes_received statistics due to %s", err)
+	}
+	for op, counter := range vm.numThrottled {
+		if err := registerer.Register(counter); err != nil {
+			log.Error("Failed to register %s_throttled statistics due to %s", op, err)
+		}
+	}
 }
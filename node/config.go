@@ -4,12 +4,18 @@
 package node
 
 import (
+	"github.com/ava-labs/gecko/chains"
 	"github.com/ava-labs/gecko/database"
 	"github.com/ava-labs/gecko/nat"
+	"github.com/ava-labs/gecko/networking"
 	"github.com/ava-labs/gecko/snow/consensus/avalanche"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
 	"github.com/ava-labs/gecko/snow/networking/router"
+	"github.com/ava-labs/gecko/snow/networking/timeout"
 	"github.com/ava-labs/gecko/utils"
 	"github.com/ava-labs/gecko/utils/logging"
+	"github.com/ava-labs/gecko/utils/timer"
+	"github.com/ava-labs/gecko/vms/proposervm"
 )
 
 // Config contains all of the configurations of an Ava node.
@@ -32,14 +38,36 @@ type Config struct {
 	// Database to use for the node
 	DB database.Database
 
+	// DBRestoreFile is a backup of a node's database to restore to DB before
+	// the node starts. DB must be empty.
+	DBRestoreFile string
+
 	// Staking configuration
 	StakingIP       utils.IPDesc
+	AltStakingIPs   []utils.IPDesc // Other IPs, such as an IPv6 address, the node can be reached at
+	DynamicIP       bool           // StakingIP was discovered through Nat, and is updated if it changes
 	EnableStaking   bool
 	StakingKeyFile  string
 	StakingCertFile string
 
+	// Compression configuration
+	EnableCompression bool
+
+	// Rate limits of the messages that peers may send
+	ThrottlerConfig networking.ThrottlerConfig
+
+	// Reputation configuration
+	ReputationConfig reputation.Config
+
+	// Request timeout configuration
+	TimeoutConfig timeout.Config
+
+	// Validator connection configuration
+	ConnectionManagerConfig networking.ConnectionManagerConfig
+
 	// Bootstrapping configuration
-	BootstrapPeers []*Peer
+	BootstrapPeers   []*Peer
+	StateSyncEnabled bool
 
 	// HTTP configuration
 	HTTPPort      uint16
@@ -61,6 +89,25 @@ type Config struct {
 	// Consensus configuration
 	ConsensusParams avalanche.Parameters
 
+	// Overrides of the consensus parameters of specific chains
+	ChainConsensus chains.ChainConsensusConfigs
+
+	// Strategies used to sample the validators of each subnet
+	SamplerConfig chains.SamplerConfig
+
+	// Clock of the Platform Chain. Only set by tests.
+	PlatformClock timer.Clock
+
+	// Proposer windows of snowman chains
+	ProposerWindowsEnabled bool
+	ProposerConfig         proposervm.Config
+
+	// Number of consensus events traced per chain
+	ConsensusTraceSize int
+
+	// Directory the inputs of each chain's consensus engine are recorded to
+	ConsensusRecordDir string
+
 	// Throughput configuration
 	ThroughputPort          uint16
 	ThroughputServerEnabled bool
@@ -70,4 +117,7 @@ type Config struct {
 
 	// Router that is used to handle incoming consensus messages
 	ConsensusRouter router.Router
+
+	// Transport that is used to connect to other nodes
+	Transport networking.Transport
 }
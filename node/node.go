@@ -3,22 +3,22 @@
 
 package node
 
-// #include "salticidae/network.h"
-// void onTerm(int sig, void *);
-// void errorHandler(SalticidaeCError *, bool, int32_t, void *);
-import "C"
-
 import (
+	"crypto"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
+	"os"
+	"os/signal"
 	"path"
 	"sync"
-	"unsafe"
-
-	"github.com/ava-labs/salticidae-go"
+	"syscall"
+	"time"
 
 	"github.com/ava-labs/gecko/api"
 	"github.com/ava-labs/gecko/api/admin"
@@ -28,15 +28,21 @@ import (
 	"github.com/ava-labs/gecko/chains"
 	"github.com/ava-labs/gecko/chains/atomic"
 	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/database/backup"
+	"github.com/ava-labs/gecko/database/leveldb"
 	"github.com/ava-labs/gecko/database/prefixdb"
 	"github.com/ava-labs/gecko/genesis"
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/networking"
 	"github.com/ava-labs/gecko/networking/xputtest"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
+	"github.com/ava-labs/gecko/snow/networking/timeout"
 	"github.com/ava-labs/gecko/snow/triggers"
 	"github.com/ava-labs/gecko/snow/validators"
+	"github.com/ava-labs/gecko/utils"
 	"github.com/ava-labs/gecko/utils/hashing"
 	"github.com/ava-labs/gecko/utils/logging"
+	"github.com/ava-labs/gecko/utils/timer"
 	"github.com/ava-labs/gecko/utils/wrappers"
 	"github.com/ava-labs/gecko/vms"
 	"github.com/ava-labs/gecko/vms/avm"
@@ -51,14 +57,18 @@ import (
 )
 
 const (
-	maxMessageSize = 1 << 25 // maximum size of a message sent with salticidae
+	maxMessageSize = 1 << 25 // maximum size of a message sent over the network
+
+	ipUpdateFrequency = 5 * time.Minute // how often the router is asked whether this node's IP changed
 )
 
 var (
 	genesisHashKey = []byte("genesisID")
+
+	errInvalidStakingKey = errors.New("staking key can't be used for signing")
 )
 
-// MainNode is the reference for node callbacks
+// MainNode is the node that is run by main
 var MainNode = Node{}
 
 // Node is an instance of an Ava node.
@@ -90,12 +100,10 @@ type Node struct {
 	DecisionDispatcher  *triggers.EventDispatcher
 	ConsensusDispatcher *triggers.EventDispatcher
 
-	// Event loop manager
-	EC salticidae.EventContext
 	// Network that manages validator peers
-	PeerNet salticidae.PeerNetwork
+	PeerNet networking.PeerNetwork
 	// Network that manages clients
-	ClientNet salticidae.MsgNetwork // TODO: Remove
+	ClientNet networking.MsgNetwork // TODO: Remove
 
 	// API that handles new connections
 	ValidatorAPI *networking.Handshake
@@ -105,6 +113,23 @@ type Node struct {
 	// current validators of the network
 	vdrs validators.Manager
 
+	// Tracks the reputation of peers, and bans misbehaving ones
+	reputation reputation.Manager
+
+	// Times out requests to peers, adapting to the latency of each peer
+	timeouts timeout.Manager
+
+	// Keeps this node connected to the validators of the subnets it validates
+	connectionManager networking.ConnectionManager
+
+	// Key used to sign this node's claims, such as the IP it can be reached at,
+	// and the certificate that proves ownership of the key
+	stakingKey  crypto.Signer
+	stakingCert []byte
+
+	// Checks whether the IP this node can be reached at changed
+	ipUpdater *timer.Repeater
+
 	// APIs that handle client messages
 	// TODO: Remove
 	Issuer     *xputtest.Issuer
@@ -123,71 +148,62 @@ type Node struct {
  ******************************************************************************
  */
 
-//export onTerm
-func onTerm(C.int, unsafe.Pointer) {
-	MainNode.Log.Debug("Terminate signal received")
-	MainNode.EC.Stop()
-}
-
-//export errorHandler
-func errorHandler(_err *C.struct_SalticidaeCError, fatal C.bool, asyncID C.int32_t, _ unsafe.Pointer) {
-	err := (*salticidae.Error)(unsafe.Pointer(_err))
-	if fatal {
-		MainNode.Log.Fatal("Error during async call: %s", salticidae.StrError(err.GetCode()))
-		MainNode.EC.Stop()
-		return
-	}
-	MainNode.Log.Debug("Error during async with ID %d call: %s", asyncID, salticidae.StrError(err.GetCode()))
-}
-
 func (n *Node) initNetlib() error {
-	// Create main event context
-	n.EC = salticidae.NewEventContext()
-
-	// Set up interrupt signal and terminate signal handlers
-	evInt := salticidae.NewSigEvent(n.EC, salticidae.SigEventCallback(C.onTerm), nil)
-	evInt.Add(salticidae.SIGINT)
-	evTerm := salticidae.NewSigEvent(n.EC, salticidae.SigEventCallback(C.onTerm), nil)
-	evTerm.Add(salticidae.SIGTERM)
-
-	// Create peer network config, may have tls enabled
-	peerConfig := salticidae.NewPeerNetworkConfig()
-	peerConfig.ConnTimeout(60)
-
-	msgConfig := peerConfig.AsMsgNetworkConfig()
-	msgConfig.MaxMsgSize(maxMessageSize)
-
+	// Create the TLS config, if staking is enabled
+	var tlsConfig *tls.Config
 	if n.Config.EnableStaking {
-		msgConfig.EnableTLS(true)
-		msgConfig.TLSKeyFile(n.Config.StakingKeyFile)
-		msgConfig.TLSCertFile(n.Config.StakingCertFile)
+		cert, err := tls.LoadX509KeyPair(n.Config.StakingCertFile, n.Config.StakingKeyFile)
+		if err != nil {
+			return err
+		}
+		stakingKey, ok := cert.PrivateKey.(crypto.Signer)
+		if !ok {
+			return errInvalidStakingKey
+		}
+		n.stakingKey = stakingKey
+		n.stakingCert = cert.Certificate[0]
+		tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientAuth:   tls.RequireAnyClientCert,
+			// Peers are authenticated by the hash of their certificate, rather
+			// than by a certificate authority
+			InsecureSkipVerify: true,
+		}
 	}
 
-	// Create the peer network
-	err := salticidae.NewError()
-	n.PeerNet = salticidae.NewPeerNetwork(n.EC, peerConfig, &err)
-	if code := err.GetCode(); code != 0 {
-		return errors.New(salticidae.StrError(code))
-	}
-	// Add peer network error handling
-	net := n.PeerNet.AsMsgNetwork()
-	net.RegErrorHandler(salticidae.MsgNetworkErrorCallback(C.errorHandler), nil)
+	// Create the peer network and the APIs that will handle its messages
+	n.PeerNet = networking.NewPeerNetwork(n.Log, n.Config.Transport, n.Config.StakingIP, tlsConfig, maxMessageSize)
+	n.ValidatorAPI = &networking.Handshake{}
+	n.ConsensusAPI = &networking.Voting{}
 
 	if n.Config.ThroughputServerEnabled {
 		// Create the client network
-		msgConfig := salticidae.NewMsgNetworkConfig()
-		msgConfig.MaxMsgSize(maxMessageSize)
-		n.ClientNet = salticidae.NewMsgNetwork(n.EC, msgConfig, &err)
-		if code := err.GetCode(); code != 0 {
-			return errors.New(salticidae.StrError(code))
-		}
-		// Add client network error handling
-		n.ClientNet.RegErrorHandler(salticidae.MsgNetworkErrorCallback(C.errorHandler), nil)
+		n.ClientNet = networking.NewMsgNetwork(n.Log, n.Config.Transport, utils.IPDesc{}, nil, maxMessageSize)
 	}
 
+	// Set up interrupt signal and terminate signal handlers
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go n.Log.RecoverAndPanic(func() {
+		<-signals
+		n.Log.Debug("Terminate signal received")
+		n.PeerNet.Close()
+	})
+
 	return nil
 }
 
+// initReputation initializes the tracking of peers' reputations
+func (n *Node) initReputation() {
+	n.reputation.Initialize(n.Log, n.Config.ReputationConfig, n.Config.ConsensusParams.Metrics)
+}
+
+// initTimeouts initializes the timing out of requests to peers
+func (n *Node) initTimeouts() {
+	n.timeouts.Initialize(n.Log, n.Config.TimeoutConfig, n.Config.ConsensusParams.Metrics)
+	go n.Log.RecoverAndPanic(n.timeouts.Dispatch)
+}
+
 func (n *Node) initValidatorNet() error {
 	// Initialize validator manager and default subnet's validator set
 	defaultSubnetValidators := validators.NewSet()
@@ -197,33 +213,69 @@ func (n *Node) initValidatorNet() error {
 	n.vdrs = validators.NewManager()
 	n.vdrs.PutValidatorSet(platformvm.DefaultSubnetID, defaultSubnetValidators)
 
-	cErr := salticidae.NewError()
-	serverIP := salticidae.NewNetAddrFromIPPortString(n.Config.StakingIP.String(), true, &cErr)
-	if code := cErr.GetCode(); code != 0 {
-		return errors.New(salticidae.StrError(code))
-	}
-
-	n.ValidatorAPI = &networking.HandshakeNet
 	n.ValidatorAPI.Initialize(
 		/*log=*/ n.Log,
 		/*validators=*/ defaultSubnetValidators,
-		/*myIP=*/ serverIP,
+		/*myIP=*/ n.Config.StakingIP,
+		/*altIPs=*/ n.Config.AltStakingIPs,
 		/*myID=*/ n.ID,
 		/*network=*/ n.PeerNet,
 		/*metrics=*/ n.Config.ConsensusParams.Metrics,
 		/*enableStaking=*/ n.Config.EnableStaking,
 		/*networkID=*/ n.Config.NetworkID,
+		/*enableCompression=*/ n.Config.EnableCompression,
+		/*reputation=*/ &n.reputation,
+		/*db=*/ prefixdb.New([]byte("peers"), n.DB),
+		/*stakingKey=*/ n.stakingKey,
+		/*stakingCert=*/ n.stakingCert,
 	)
 
 	return nil
 }
 
+// initIPUpdater keeps the IP this node claims to peers up to date, if the IP
+// was discovered through the NAT router, since it may change
+func (n *Node) initIPUpdater() {
+	if !n.Config.DynamicIP || n.Config.Nat == nil {
+		return
+	}
+	n.ipUpdater = timer.NewRepeater(n.updateIP, ipUpdateFrequency)
+	go n.Log.RecoverAndPanic(n.ipUpdater.Dispatch)
+}
+
+// updateIP asks the NAT router for this node's IP, and claims it to peers if
+// it changed
+func (n *Node) updateIP() {
+	ip, err := n.Config.Nat.IP()
+	if err != nil {
+		n.Log.Debug("Failed to get my IP from the router due to %s", err)
+		return
+	}
+	n.ValidatorAPI.SetIP(utils.IPDesc{
+		IP:   ip,
+		Port: n.Config.StakingIP.Port,
+	})
+}
+
+// initConnectionManager starts maintaining connections to the validators of
+// the subnets this node validates
+// Assumes n.vdrs and n.ValidatorAPI already initialized
+func (n *Node) initConnectionManager() {
+	n.connectionManager.Initialize(
+		n.Log,
+		n.Config.ConnectionManagerConfig,
+		n.ID,
+		n.vdrs,
+		n.ValidatorAPI,
+		n.Config.ConsensusParams.Metrics,
+	)
+}
+
 func (n *Node) initConsensusNet() {
 	vdrs, ok := n.vdrs.GetValidatorSet(platformvm.DefaultSubnetID)
 	n.Log.AssertTrue(ok, "should have initialize the validator set already")
 
-	n.ConsensusAPI = &networking.VotingNet
-	n.ConsensusAPI.Initialize(n.Log, vdrs, n.PeerNet, n.ValidatorAPI.Connections(), n.chainManager.Router(), n.Config.ConsensusParams.Metrics)
+	n.ConsensusAPI.Initialize(n.Log, vdrs, n.PeerNet, n.ValidatorAPI.Connections(), n.ValidatorAPI, &n.reputation, n.Config.ThrottlerConfig, n.chainManager.Router(), n.Config.ConsensusParams.Metrics)
 
 	n.Log.AssertNoError(n.ConsensusDispatcher.Register("gossip", n.ConsensusAPI))
 }
@@ -232,7 +284,7 @@ func (n *Node) initClients() {
 	n.Issuer = &xputtest.Issuer{}
 	n.Issuer.Initialize(n.Log)
 
-	n.CClientAPI = &xputtest.CClientHandler
+	n.CClientAPI = &xputtest.CClient{}
 	n.CClientAPI.Initialize(n.ClientNet, n.Issuer)
 
 	n.chainManager.AddRegistrant(n.Issuer)
@@ -243,57 +295,42 @@ func (n *Node) initClients() {
 func (n *Node) StartConsensusServer() error {
 	n.Log.Verbo("starting the consensus server")
 
-	n.PeerNet.AsMsgNetwork().Start()
-
-	err := salticidae.NewError()
-
-	// The IP this node listens on for P2P messaging
-	serverIP := salticidae.NewNetAddrFromIPPortString(n.Config.StakingIP.String(), true, &err)
-	if code := err.GetCode(); code != 0 {
-		return fmt.Errorf("failed to create ip addr: %s", salticidae.StrError(code))
-	}
-
 	// Listen for P2P messages
-	n.PeerNet.Listen(serverIP, &err)
-	if code := err.GetCode(); code != 0 {
-		return fmt.Errorf("failed to listen on consensus server at %s: %s", n.Config.StakingIP, salticidae.StrError(code))
+	if err := n.PeerNet.Listen(n.Config.StakingIP); err != nil {
+		return fmt.Errorf("failed to listen on consensus server at %s: %w", n.Config.StakingIP, err)
 	}
 
 	// Start a server to handle throughput tests if configuration says to. Disabled by default.
 	if n.Config.ThroughputServerEnabled {
-		n.ClientNet.Start()
-
-		clientIP := salticidae.NewNetAddrFromIPPortString(fmt.Sprintf("127.0.0.1:%d", n.Config.ThroughputPort), true, &err)
-		if code := err.GetCode(); code != 0 {
-			return fmt.Errorf("failed to start xput server: %s", salticidae.StrError(code))
+		clientIP := utils.IPDesc{
+			IP:   net.IPv4(127, 0, 0, 1),
+			Port: n.Config.ThroughputPort,
 		}
-
-		n.ClientNet.Listen(clientIP, &err)
-		if code := err.GetCode(); code != 0 {
-			return fmt.Errorf("failed to listen on xput server at 127.0.0.1:%d: %s", n.Config.ThroughputPort, salticidae.StrError(code))
+		if err := n.ClientNet.Listen(clientIP); err != nil {
+			return fmt.Errorf("failed to listen on xput server at %s: %w", clientIP, err)
 		}
+		go n.Log.RecoverAndPanic(n.ClientNet.Dispatch)
 	}
 
 	// Add bootstrap nodes to the peer network
 	for _, peer := range n.Config.BootstrapPeers {
 		if !peer.IP.Equal(n.Config.StakingIP) {
-			bootstrapAddr := salticidae.NewNetAddrFromIPPortString(peer.IP.String(), true, &err)
-			if code := err.GetCode(); code != 0 {
-				return fmt.Errorf("failed to create bootstrap ip addr: %s", salticidae.StrError(code))
-			}
-
-			n.ValidatorAPI.Connect(bootstrapAddr)
+			n.ValidatorAPI.Connect(peer.IP)
 		} else {
 			n.Log.Error("can't add self as a bootstrapper")
 		}
 	}
 
+	// Add the peers this node remembers from previous runs, so that it can
+	// rejoin the network even if the bootstrap nodes are unavailable
+	n.ValidatorAPI.ConnectToKnownPeers()
+
 	return nil
 }
 
 // Dispatch starts the node's servers.
 // Returns when the node exits.
-func (n *Node) Dispatch() { n.EC.Dispatch() }
+func (n *Node) Dispatch() { n.PeerNet.Dispatch() }
 
 /*
  ******************************************************************************
@@ -304,6 +341,16 @@ func (n *Node) Dispatch() { n.EC.Dispatch() }
 func (n *Node) initDatabase() error {
 	n.DB = n.Config.DB
 
+	if n.Config.DBRestoreFile != "" {
+		if err := n.restoreDatabase(); err != nil {
+			return err
+		}
+	} else if incomplete, err := backup.Incomplete(n.DB); err != nil {
+		return err
+	} else if incomplete {
+		return errors.New("the database was only partially restored from a backup. Restore it again with --db-restore")
+	}
+
 	expectedGenesis, err := genesis.Genesis(n.Config.NetworkID)
 	if err != nil {
 		return err
@@ -334,6 +381,32 @@ func (n *Node) initDatabase() error {
 	return nil
 }
 
+// restoreDatabase restores the backup given in the config to the database
+func (n *Node) restoreDatabase() error {
+	n.Log.Info("restoring the database from %s", n.Config.DBRestoreFile)
+
+	file, err := os.Open(n.Config.DBRestoreFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// The whole backup is checked before any of it is written, so an invalid
+	// backup leaves the database as it was
+	if _, err := backup.Verify(file); err != nil {
+		return fmt.Errorf("couldn't restore %s: %w", n.Config.DBRestoreFile, err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	numKeys, err := backup.Restore(file, n.DB)
+	if err != nil {
+		return fmt.Errorf("couldn't restore %s: %w", n.Config.DBRestoreFile, err)
+	}
+	n.Log.Info("restored %d keys from %s", numKeys, n.Config.DBRestoreFile)
+	return nil
+}
+
 // Initialize this node's ID
 // If staking is disabled, a node's ID is a hash of its IP
 // Otherwise, it is a hash of the TLS certificate that this node
@@ -437,6 +510,7 @@ func (n *Node) initChains() error {
 			StakingEnabled: n.Config.EnableStaking,
 			AVA:            avaAssetID,
 			AVM:            createAVMTx.ID(),
+			Clock:          n.Config.PlatformClock,
 		},
 	)
 	if err != nil {
@@ -487,6 +561,11 @@ func (n *Node) initAPIServer() {
 
 // Assumes n.DB, n.vdrs all initialized (non-nil)
 func (n *Node) initChainManager() {
+	// Blocks this node proposes are signed with its staking key
+	proposerConfig := n.Config.ProposerConfig
+	proposerConfig.StakingKey = n.stakingKey
+	proposerConfig.StakingCert = n.stakingCert
+
 	n.chainManager = chains.New(
 		n.Config.EnableStaking,
 		n.Log,
@@ -496,8 +575,9 @@ func (n *Node) initChainManager() {
 		n.ConsensusDispatcher,
 		n.DB,
 		n.Config.ConsensusRouter,
-		&networking.VotingNet,
+		n.ConsensusAPI,
 		n.Config.ConsensusParams,
+		n.Config.ChainConsensus,
 		n.vdrs,
 		n.ID,
 		n.Config.NetworkID,
@@ -505,6 +585,15 @@ func (n *Node) initChainManager() {
 		&n.APIServer,
 		&n.keystoreServer,
 		&n.sharedMemory,
+		&n.reputation,
+		n.Config.SamplerConfig,
+		n.ValidatorAPI,
+		&n.timeouts,
+		n.Config.StateSyncEnabled,
+		n.Config.ProposerWindowsEnabled,
+		proposerConfig,
+		n.Config.ConsensusTraceSize,
+		n.Config.ConsensusRecordDir,
 	)
 
 	n.chainManager.AddRegistrant(&n.APIServer)
@@ -538,6 +627,14 @@ func (n *Node) initMetricsAPI() {
 		n.APIServer.AddRoute(handler, &sync.RWMutex{}, "metrics", "", n.HTTPLog)
 	}
 	n.Config.ConsensusParams.Metrics = registry
+
+	// The statistics leveldb keeps, such as the sizes of its levels and the
+	// time spent compacting them, are read whenever the metrics are gathered
+	if db, ok := n.DB.(*leveldb.Database); ok {
+		if err := registry.Register(leveldb.NewCollector("gecko_leveldb", db)); err != nil {
+			n.Log.Error("Failed to register leveldb statistics due to %s", err)
+		}
+	}
 }
 
 // initAdminAPI initializes the Admin API service
@@ -545,7 +642,7 @@ func (n *Node) initMetricsAPI() {
 func (n *Node) initAdminAPI() {
 	if n.Config.AdminAPIEnabled {
 		n.Log.Info("initializing Admin API")
-		service := admin.NewService(n.ID, n.Config.NetworkID, n.Log, n.chainManager, n.ValidatorAPI.Connections(), &n.APIServer)
+		service := admin.NewService(n.ID, n.Config.NetworkID, n.Log, n.chainManager, n.ValidatorAPI.Connections(), &n.reputation, &n.timeouts, &n.APIServer, n.DB)
 		n.APIServer.AddRoute(service, &sync.RWMutex{}, "admin", "", n.HTTPLog)
 	}
 }
@@ -624,9 +721,15 @@ func (n *Node) Initialize(Config *Config, logger logging.Logger, logFactory logg
 	if err = n.initNetlib(); err != nil { // Set up all networking
 		return fmt.Errorf("problem initializing networking: %w", err)
 	}
+	n.initReputation() // Set up the tracking of peers' reputations
+	n.initTimeouts()   // Set up the timing out of requests to peers
+
 	if err := n.initValidatorNet(); err != nil { // Set up the validator handshake + authentication
 		return fmt.Errorf("problem initializing validator network: %w", err)
 	}
+	n.initConnectionManager() // Set up the maintenance of connections to validators
+	n.initIPUpdater()         // Keep the IP this node claims to peers up to date
+
 	if err := n.initVMManager(); err != nil { // Set up the vm manager
 		return fmt.Errorf("problem initializing the VM manager: %w", err)
 	}
@@ -652,7 +755,18 @@ func (n *Node) Initialize(Config *Config, logger logging.Logger, logFactory logg
 // Shutdown this node
 func (n *Node) Shutdown() {
 	n.Log.Info("shutting down the node")
+	n.connectionManager.Shutdown()
+	if n.ipUpdater != nil {
+		n.ipUpdater.Stop()
+	}
 	n.ValidatorAPI.Shutdown()
 	n.ConsensusAPI.Shutdown()
 	n.chainManager.Shutdown()
+	if n.ClientNet != nil {
+		n.ClientNet.Close()
+	}
+	n.PeerNet.Close()
 }
+
+// ChainManager returns the manager of the chains this node is running
+func (n *Node) ChainManager() chains.Manager { return n.chainManager }
@@ -0,0 +1,505 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package nodetest runs clusters of nodes in a single process over a simulated
+// network, so that multi-node behavior can be tested under go test.
+package nodetest
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/database/memdb"
+	"github.com/ava-labs/gecko/genesis"
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/networking"
+	"github.com/ava-labs/gecko/networking/simnet"
+	"github.com/ava-labs/gecko/node"
+	"github.com/ava-labs/gecko/snow/consensus/avalanche"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
+	"github.com/ava-labs/gecko/snow/networking/router"
+	"github.com/ava-labs/gecko/snow/networking/timeout"
+	"github.com/ava-labs/gecko/snow/validators"
+	"github.com/ava-labs/gecko/utils"
+	"github.com/ava-labs/gecko/utils/hashing"
+	"github.com/ava-labs/gecko/utils/logging"
+)
+
+const (
+	// StakingPort is the port every node in the cluster listens on
+	StakingPort = 9651
+
+	// maxMessageSize is the maximum size of a message sent by a Byzantine node
+	maxMessageSize = 1 << 25
+
+	// pollFrequency is how often conditions are checked while waiting
+	pollFrequency = 10 * time.Millisecond
+)
+
+var (
+	errTooManyNodes = errors.New("the local network only has five stakers")
+	errTimedOut     = errors.New("timed out")
+)
+
+// NumStakers is the number of stakers in the local network's genesis. Every
+// node in a cluster is one of these stakers.
+const NumStakers = 5
+
+// DefaultParameters are the consensus parameters used by clusters that don't
+// specify any. They are chosen to finalize quickly in small clusters.
+var DefaultParameters = avalanche.Parameters{
+	Parameters: snowball.Parameters{
+		K:                 3,
+		Alpha:             2,
+		BetaVirtuous:      5,
+		BetaRogue:         10,
+		ConcurrentRepolls: 1,
+	},
+	Parents:   2,
+	BatchSize: 30,
+}
+
+// Config describes the cluster to run
+type Config struct {
+	// Size is the number of honest nodes to run
+	Size int
+	// Byzantine is the number of stakers that are run as Byzantine nodes
+	// rather than honest nodes. Size+Byzantine must not exceed NumStakers.
+	Byzantine int
+
+	// Seed of the simulated network's random decisions
+	Seed int64
+	// Link is the initial condition of every link in the simulated network
+	Link simnet.Link
+
+	// Params are the consensus parameters of every honest node. If zero,
+	// DefaultParameters are used.
+	Params avalanche.Parameters
+
+	// Time that the Platform Chain of every honest node believes it is. If
+	// zero, the Platform Chain follows the wall clock.
+	Time time.Time
+
+	// LogLevel of the honest nodes. Logs are only kept if LogDir is set.
+	LogLevel logging.Level
+	LogDir   string
+}
+
+// Cluster is a set of nodes that are connected over a simulated network
+type Cluster struct {
+	// Network that the nodes are connected over. Can be used to change link
+	// conditions and to partition the nodes.
+	Network *simnet.Network
+
+	// Nodes are the honest nodes
+	Nodes []*node.Node
+	// Byzantine nodes only perform the handshake. They don't respond to
+	// consensus messages unless handlers are registered on their networks.
+	Byzantine []*ByzantineNode
+
+	logDir       string
+	removeLogDir bool
+	factories    []logging.Factory
+
+	lock     sync.Mutex
+	accepted []map[[32]byte]ids.Set // node index -> chain ID -> accepted IDs
+}
+
+// ByzantineNode is a staker that is controlled by the test
+type ByzantineNode struct {
+	ID        ids.ShortID
+	IP        utils.IPDesc
+	Net       networking.PeerNetwork
+	Handshake *networking.Handshake
+}
+
+// New starts a cluster. The honest nodes are given the first stakers' keys,
+// followed by the Byzantine nodes.
+func New(config Config) (*Cluster, error) {
+	if config.Size+config.Byzantine > NumStakers {
+		return nil, errTooManyNodes
+	}
+	if config.Params == (avalanche.Parameters{}) {
+		config.Params = DefaultParameters
+	}
+
+	c := &Cluster{
+		Network: simnet.New(config.Seed),
+		logDir:  config.LogDir,
+	}
+	c.Network.SetDefaultLink(config.Link)
+
+	if c.logDir == "" {
+		dir, err := ioutil.TempDir("", "gecko-nodetest")
+		if err != nil {
+			return nil, err
+		}
+		c.logDir = dir
+		c.removeLogDir = true
+		config.LogLevel = logging.Off
+	}
+
+	peers := make([]*node.Peer, config.Size)
+	for i := range peers {
+		id, err := StakerID(i)
+		if err != nil {
+			return nil, err
+		}
+		peers[i] = &node.Peer{
+			IP: IP(i),
+			ID: id,
+		}
+	}
+
+	for i := 0; i < config.Size; i++ {
+		if err := c.startNode(config, i, peers); err != nil {
+			c.Shutdown()
+			return nil, err
+		}
+	}
+	for i := config.Size; i < config.Size+config.Byzantine; i++ {
+		if err := c.startByzantineNode(i, peers); err != nil {
+			c.Shutdown()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *Cluster) startNode(config Config, i int, peers []*node.Peer) error {
+	loggingConfig, err := logging.DefaultConfig()
+	if err != nil {
+		return err
+	}
+	loggingConfig.Directory = path.Join(c.logDir, fmt.Sprintf("node%d", i))
+	loggingConfig.LogLevel = config.LogLevel
+	loggingConfig.DisplayLevel = logging.Off
+	loggingConfig.DisableDisplaying = true
+	loggingConfig.Assertions = true
+
+	factory := logging.NewFactory(loggingConfig)
+	c.factories = append(c.factories, factory)
+	log, err := factory.Make()
+	if err != nil {
+		return err
+	}
+
+	bootstrapPeers := []*node.Peer(nil)
+	for j, peer := range peers {
+		if j != i {
+			bootstrapPeers = append(bootstrapPeers, peer)
+		}
+	}
+
+	ip := IP(i)
+	nodeConfig := &node.Config{
+		NetworkID:         genesis.LocalID,
+		EnableCrypto:      true,
+		DB:                memdb.New(),
+		StakingIP:         ip,
+		EnableStaking:     true,
+		EnableCompression: true,
+		ThrottlerConfig: networking.ThrottlerConfig{
+			ValidatorRate:    1000,
+			NonValidatorRate: 100,
+			Burst:            2 * time.Second,
+		},
+		ReputationConfig: reputation.Config{
+			BanThreshold: -100,
+			BanDuration:  time.Hour,
+			HalfLife:     10 * time.Minute,
+		},
+		TimeoutConfig: timeout.Config{
+			InitialTimeout:    2 * time.Second,
+			MinimumTimeout:    500 * time.Millisecond,
+			MaximumTimeout:    10 * time.Second,
+			LatencyMultiplier: 2,
+			LatencyWeight:     0.1,
+			FailureReduction:  0.5,
+		},
+		StakingKeyFile:     path.Join(keysDir(), fmt.Sprintf("staker%d.key", i+1)),
+		StakingCertFile:    path.Join(keysDir(), fmt.Sprintf("staker%d.crt", i+1)),
+		BootstrapPeers:     bootstrapPeers,
+		AdminAPIEnabled:    true,
+		KeystoreAPIEnabled: true,
+		MetricsAPIEnabled:  true,
+		LoggingConfig:      loggingConfig,
+		ConsensusParams:    config.Params,
+		ConsensusRouter:    &router.ChainRouter{},
+		Transport:          c.Network.Transport(ip.IP),
+	}
+
+	if !config.Time.IsZero() {
+		nodeConfig.PlatformClock.Set(config.Time)
+	}
+
+	n := &node.Node{}
+	if err := n.Initialize(nodeConfig, log, factory); err != nil {
+		return fmt.Errorf("problem initializing node %d: %w", i, err)
+	}
+
+	c.lock.Lock()
+	c.Nodes = append(c.Nodes, n)
+	c.accepted = append(c.accepted, make(map[[32]byte]ids.Set))
+	c.lock.Unlock()
+
+	if err := n.DecisionDispatcher.Register("nodetest", &acceptor{cluster: c, node: i}); err != nil {
+		return err
+	}
+	if err := n.StartConsensusServer(); err != nil {
+		return fmt.Errorf("problem starting node %d: %w", i, err)
+	}
+	go log.RecoverAndPanic(n.Dispatch)
+	return nil
+}
+
+func (c *Cluster) startByzantineNode(i int, peers []*node.Peer) error {
+	cert, err := tls.LoadX509KeyPair(
+		path.Join(keysDir(), fmt.Sprintf("staker%d.crt", i+1)),
+		path.Join(keysDir(), fmt.Sprintf("staker%d.key", i+1)),
+	)
+	if err != nil {
+		return err
+	}
+	id, err := StakerID(i)
+	if err != nil {
+		return err
+	}
+
+	ip := IP(i)
+	b := &ByzantineNode{
+		ID: id,
+		IP: ip,
+		Net: networking.NewPeerNetwork(
+			logging.NoLog{},
+			c.Network.Transport(ip.IP),
+			ip,
+			&tls.Config{
+				Certificates:       []tls.Certificate{cert},
+				ClientAuth:         tls.RequireAnyClientCert,
+				InsecureSkipVerify: true,
+			},
+			maxMessageSize,
+		),
+		Handshake: &networking.Handshake{},
+	}
+	rep := &reputation.Manager{}
+	rep.Initialize(logging.NoLog{}, reputation.Config{BanThreshold: -100}, prometheus.NewRegistry())
+	b.Handshake.Initialize(
+		logging.NoLog{},
+		validators.NewSet(),
+		ip,
+		nil, // altAddrs
+		id,
+		b.Net,
+		prometheus.NewRegistry(),
+		true, // enableStaking
+		genesis.LocalID,
+		true, // enableCompression
+		rep,
+		memdb.New(),
+		cert.PrivateKey.(crypto.Signer),
+		cert.Certificate[0],
+	)
+	c.Byzantine = append(c.Byzantine, b)
+
+	if err := b.Net.Listen(ip); err != nil {
+		return err
+	}
+	go b.Net.Dispatch()
+
+	for _, peer := range peers {
+		b.Handshake.Connect(peer.IP)
+	}
+	return nil
+}
+
+// Shutdown all the nodes in the cluster
+func (c *Cluster) Shutdown() {
+	for _, n := range c.Nodes {
+		n.Shutdown()
+		n.DB.Close()
+	}
+	for _, b := range c.Byzantine {
+		b.Handshake.Shutdown()
+		b.Net.Close()
+	}
+	for _, factory := range c.factories {
+		factory.Close()
+	}
+	if c.removeLogDir {
+		os.RemoveAll(c.logDir)
+	}
+}
+
+// Hosts returns the addresses of the honest nodes, followed by the Byzantine
+// nodes. Hosts can be used to partition the network.
+func (c *Cluster) Hosts() []net.IP {
+	hosts := []net.IP(nil)
+	for i := 0; i < len(c.Nodes)+len(c.Byzantine); i++ {
+		hosts = append(hosts, IP(i).IP)
+	}
+	return hosts
+}
+
+// WaitConnected waits until every honest node is connected to every other node
+func (c *Cluster) WaitConnected(timeout time.Duration) error {
+	peers := len(c.Nodes) + len(c.Byzantine) - 1
+	return wait(timeout, func() bool {
+		for _, n := range c.Nodes {
+			if n.ValidatorAPI.Connections().Len() < peers {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// WaitBootstrapped waits until every honest node has finished bootstrapping
+// the chains with the provided aliases
+func (c *Cluster) WaitBootstrapped(timeout time.Duration, aliases ...string) error {
+	return wait(timeout, func() bool {
+		for _, n := range c.Nodes {
+			chainManager := n.ChainManager()
+			for _, alias := range aliases {
+				chainID, err := chainManager.Lookup(alias)
+				if err != nil || !chainManager.IsBootstrapped(chainID) {
+					return false
+				}
+			}
+		}
+		return true
+	})
+}
+
+// Accepted returns true if the honest node at index [i] has accepted the
+// container
+func (c *Cluster) Accepted(i int, chainID, containerID ids.ID) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	accepted := c.accepted[i][chainID.Key()]
+	return accepted.Contains(containerID)
+}
+
+// WaitAccepted waits until every honest node has accepted the container
+func (c *Cluster) WaitAccepted(chainID, containerID ids.ID, timeout time.Duration) error {
+	return wait(timeout, func() bool {
+		for i := range c.Nodes {
+			if !c.Accepted(i, chainID, containerID) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Call the API method on the honest node at index [i]. [endpoint] is the
+// route after "/ext/", for example "bc/P".
+func (c *Cluster) Call(i int, endpoint, method string, args, reply interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  args,
+	})
+	if err != nil {
+		return err
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/ext/"+endpoint, bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	c.Nodes[i].APIServer.ServeHTTP(recorder, request)
+
+	response := struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		return fmt.Errorf("problem parsing response to %s (%d): %s", method, recorder.Code, recorder.Body.String())
+	}
+	if response.Error != nil {
+		return fmt.Errorf("%s failed: %s", method, response.Error.Message)
+	}
+	if reply == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Result, reply)
+}
+
+func (c *Cluster) accept(i int, chainID, containerID ids.ID) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	accepted := c.accepted[i][chainID.Key()]
+	accepted.Add(containerID)
+	c.accepted[i][chainID.Key()] = accepted
+}
+
+// acceptor records the containers accepted by a node
+type acceptor struct {
+	cluster *Cluster
+	node    int
+}
+
+func (a *acceptor) Accept(chainID, containerID ids.ID, _ []byte) error {
+	a.cluster.accept(a.node, chainID, containerID)
+	return nil
+}
+
+// IP returns the staking IP of the node at index [i]
+func IP(i int) utils.IPDesc {
+	return utils.IPDesc{
+		IP:   net.IPv4(10, 0, 0, byte(i+1)),
+		Port: StakingPort,
+	}
+}
+
+// StakerID returns the ID of the node at index [i]
+func StakerID(i int) (ids.ShortID, error) {
+	cert, err := tls.LoadX509KeyPair(
+		path.Join(keysDir(), fmt.Sprintf("staker%d.crt", i+1)),
+		path.Join(keysDir(), fmt.Sprintf("staker%d.key", i+1)),
+	)
+	if err != nil {
+		return ids.ShortID{}, err
+	}
+	return ids.ToShortID(hashing.PubkeyBytesToAddress(cert.Certificate[0]))
+}
+
+// keysDir returns the directory of the local network's staking keys
+func keysDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return path.Join(path.Dir(file), "..", "..", "keys", "local")
+}
+
+// wait polls [done] until it returns true or [timeout] passes
+func wait(timeout time.Duration, done func() bool) error {
+	deadline := time.Now().Add(timeout)
+	for !done() {
+		if time.Now().After(deadline) {
+			return errTimedOut
+		}
+		time.Sleep(pollFrequency)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package nodetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ava-labs/gecko/api/keystore"
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/networking/simnet"
+	"github.com/ava-labs/gecko/utils/formatting"
+	"github.com/ava-labs/gecko/utils/json"
+	"github.com/ava-labs/gecko/vms/avm"
+	"github.com/ava-labs/gecko/vms/platformvm"
+	"github.com/ava-labs/gecko/vms/timestampvm"
+)
+
+const (
+	testTimeout  = time.Minute
+	testSplit    = 2 * time.Second // how long the network stays partitioned
+	testUsername = "nodetest"
+	testPassword = "Cluster!Test#Password1"
+
+	// testPrivateKey controls the funded address of the local network
+	testPrivateKey = "ewoqjP7PxY4yr3iLTpLisriqt94hdyDFNgchSxGGztUrTXtNN"
+)
+
+// genesisStakingTime is a time during the staking period of the local
+// network's genesis validators
+var genesisStakingTime = time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+func newCluster(t *testing.T, config Config) *Cluster {
+	if testing.Short() {
+		t.Skip("skipping cluster test in short mode")
+	}
+
+	c, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WaitConnected(testTimeout); err != nil {
+		c.Shutdown()
+		t.Fatalf("Nodes didn't connect to each other: %s", err)
+	}
+	if err := c.WaitBootstrapped(testTimeout, "P", "X", "timestamp"); err != nil {
+		c.Shutdown()
+		t.Fatalf("Nodes didn't finish bootstrapping: %s", err)
+	}
+	return c
+}
+
+// proposeTimestamp proposes a block on the timestamp chain of node [i] and
+// waits for every honest node to accept it
+func proposeTimestamp(t *testing.T, c *Cluster, i int, data byte) {
+	dataStr := formatting.CB58{Bytes: make([]byte, 32)}
+	dataStr.Bytes[0] = data
+
+	reply := timestampvm.ProposeBlockReply{}
+	if err := c.Call(i, "bc/timestamp", "timestamp.proposeBlock", &timestampvm.ProposeBlockArgs{Data: dataStr.String()}, &reply); err != nil {
+		t.Fatal(err)
+	}
+
+	err := wait(testTimeout, func() bool {
+		for j := range c.Nodes {
+			if !acceptedTimestamp(c, j, dataStr) {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Block wasn't accepted by every node: %s", err)
+	}
+}
+
+// acceptedTimestamp returns true if the last block node [i] accepted on the
+// timestamp chain has [data]
+func acceptedTimestamp(c *Cluster, i int, data formatting.CB58) bool {
+	reply := timestampvm.GetBlockReply{}
+	err := c.Call(i, "bc/timestamp", "timestamp.getBlock", &timestampvm.GetBlockArgs{}, &reply)
+	return err == nil && reply.Data == data.String()
+}
+
+func TestClusterSnowmanFinalizes(t *testing.T) {
+	c := newCluster(t, Config{Size: NumStakers})
+	defer c.Shutdown()
+
+	proposeTimestamp(t, c, 0, 1)
+	proposeTimestamp(t, c, 3, 2)
+}
+
+func TestClusterPartitionHeals(t *testing.T) {
+	c := newCluster(t, Config{Size: NumStakers})
+	defer c.Shutdown()
+
+	hosts := c.Hosts()
+	c.Network.Partition(hosts[:1], hosts[1:])
+
+	// The proposer is cut off from the nodes that would vote for its block,
+	// so the block can't be accepted until the partition is healed
+	dataStr := formatting.CB58{Bytes: make([]byte, 32)}
+	dataStr.Bytes[0] = 1
+	if err := c.Call(0, "bc/timestamp", "timestamp.proposeBlock", &timestampvm.ProposeBlockArgs{Data: dataStr.String()}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	err := wait(testSplit, func() bool {
+		for i := range c.Nodes {
+			if acceptedTimestamp(c, i, dataStr) {
+				return true
+			}
+		}
+		return false
+	})
+	if err == nil {
+		t.Fatalf("Block was accepted while the network was partitioned")
+	}
+
+	c.Network.Heal()
+	if err := c.WaitConnected(testTimeout); err != nil {
+		t.Fatalf("Nodes didn't reconnect after the partition healed: %s", err)
+	}
+	proposeTimestamp(t, c, 0, 2)
+}
+
+func TestClusterSilentByzantineNode(t *testing.T) {
+	c := newCluster(t, Config{
+		Size:      NumStakers - 1,
+		Byzantine: 1,
+	})
+	defer c.Shutdown()
+
+	proposeTimestamp(t, c, 0, 1)
+}
+
+func TestClusterLossyLinks(t *testing.T) {
+	c := newCluster(t, Config{Size: NumStakers})
+	defer c.Shutdown()
+
+	c.Network.SetDefaultLink(simnet.Link{Latency: 5 * time.Millisecond, Jitter: 5 * time.Millisecond, Loss: 0.05, RetransmitDelay: 200 * time.Millisecond})
+	proposeTimestamp(t, c, 0, 1)
+}
+
+func TestClusterAvalancheFinalizes(t *testing.T) {
+	c := newCluster(t, Config{Size: NumStakers})
+	defer c.Shutdown()
+
+	if err := c.Call(0, "keystore", "keystore.createUser", &keystore.CreateUserArgs{Username: testUsername, Password: testPassword}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	privateKey := formatting.CB58{}
+	if err := privateKey.FromString(testPrivateKey); err != nil {
+		t.Fatal(err)
+	}
+	importReply := avm.ImportKeyReply{}
+	if err := c.Call(0, "bc/X", "avm.importKey", &avm.ImportKeyArgs{Username: testUsername, Password: testPassword, PrivateKey: privateKey}, &importReply); err != nil {
+		t.Fatal(err)
+	}
+
+	sendReply := avm.SendReply{}
+	if err := c.Call(0, "bc/X", "avm.send", &avm.SendArgs{
+		Username: testUsername,
+		Password: testPassword,
+		Amount:   1000,
+		AssetID:  "AVA",
+		To:       importReply.Address,
+	}, &sendReply); err != nil {
+		t.Fatal(err)
+	}
+
+	chainID, err := c.Nodes[0].ChainManager().Lookup("X")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WaitAccepted(chainID, sendReply.TxID, testTimeout); err != nil {
+		t.Fatalf("Transaction wasn't accepted by every node: %s", err)
+	}
+}
+
+func TestClusterValidatorsPropagate(t *testing.T) {
+	// The staking period of the local network's genesis validators is fixed,
+	// so the P-chain is run as if the genesis validators were still staking
+	c := newCluster(t, Config{
+		Size: NumStakers,
+		Time: genesisStakingTime,
+	})
+	defer c.Shutdown()
+
+	if err := c.Call(0, "keystore", "keystore.createUser", &keystore.CreateUserArgs{Username: testUsername, Password: testPassword}, nil); err != nil {
+		t.Fatal(err)
+	}
+	accountReply := platformvm.CreateAccountReply{}
+	if err := c.Call(0, "bc/P", "platform.createAccount", &platformvm.CreateAccountArgs{
+		Username:   testUsername,
+		Password:   testPassword,
+		PrivateKey: testPrivateKey,
+	}, &accountReply); err != nil {
+		t.Fatal(err)
+	}
+	account := platformvm.GetAccountReply{}
+	if err := c.Call(0, "bc/P", "platform.getAccount", &platformvm.GetAccountArgs{Address: accountReply.Address}, &account); err != nil {
+		t.Fatal(err)
+	}
+
+	vdr := ids.NewShortID([20]byte{1, 2, 3})
+	start := genesisStakingTime.Add(time.Hour)
+	end := start.Add(platformvm.MinimumStakingDuration)
+	stake := json.Uint64(platformvm.MinimumStakeAmount)
+
+	unsignedReply := platformvm.CreateTxResponse{}
+	if err := c.Call(0, "bc/P", "platform.addDefaultSubnetValidator", &platformvm.AddDefaultSubnetValidatorArgs{
+		APIDefaultSubnetValidator: platformvm.APIDefaultSubnetValidator{
+			APIValidator: platformvm.APIValidator{
+				StartTime:   json.Uint64(start.Unix()),
+				EndTime:     json.Uint64(end.Unix()),
+				StakeAmount: &stake,
+				ID:          vdr,
+			},
+			Destination: accountReply.Address,
+		},
+		PayerNonce: account.Nonce + 1,
+	}, &unsignedReply); err != nil {
+		t.Fatal(err)
+	}
+	signReply := platformvm.SignResponse{}
+	if err := c.Call(0, "bc/P", "platform.sign", &platformvm.SignArgs{
+		Tx:       unsignedReply.UnsignedTx,
+		Signer:   accountReply.Address,
+		Username: testUsername,
+		Password: testPassword,
+	}, &signReply); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Call(0, "bc/P", "platform.issueTx", &platformvm.IssueTxArgs{Tx: signReply.Tx}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	err := wait(testTimeout, func() bool {
+		for i := range c.Nodes {
+			reply := platformvm.GetPendingValidatorsReply{}
+			if err := c.Call(i, "bc/P", "platform.getPendingValidators", &platformvm.GetPendingValidatorsArgs{}, &reply); err != nil {
+				return false
+			}
+			pending := false
+			for _, validator := range reply.Validators {
+				pending = pending || validator.ID.Equals(vdr)
+			}
+			if !pending {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Validator wasn't added by every node: %s", err)
+	}
+}
@@ -0,0 +1,28 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"github.com/ava-labs/gecko/utils/logging"
+)
+
+// Config contains all of the configurations of a replay
+type Config struct {
+	// Recording is the file the inputs of a chain's engine were recorded to
+	Recording string
+
+	// DBDir is a copy of the database of the node the recording was made on,
+	// taken before the recording started. If empty, the chain is replayed
+	// from its genesis.
+	DBDir string
+
+	// DBType is the storage engine of the database in DBDir
+	DBType string
+
+	// Configuration of the VMs, which must match the node's
+	AvaTxFee  uint64
+	PluginDir string
+
+	LoggingConfig logging.Config
+}
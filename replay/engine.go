@@ -0,0 +1,282 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/api/keystore"
+	"github.com/ava-labs/gecko/chains"
+	"github.com/ava-labs/gecko/chains/atomic"
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/database/memdb"
+	"github.com/ava-labs/gecko/database/persistent"
+	"github.com/ava-labs/gecko/database/prefixdb"
+	"github.com/ava-labs/gecko/genesis"
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/engine/avalanche"
+	"github.com/ava-labs/gecko/snow/engine/avalanche/state"
+	"github.com/ava-labs/gecko/snow/engine/common"
+	"github.com/ava-labs/gecko/snow/engine/common/queue"
+	"github.com/ava-labs/gecko/snow/networking/handler"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
+	"github.com/ava-labs/gecko/snow/triggers"
+	"github.com/ava-labs/gecko/snow/validators"
+	"github.com/ava-labs/gecko/utils/logging"
+	"github.com/ava-labs/gecko/utils/math"
+	"github.com/ava-labs/gecko/vms"
+	"github.com/ava-labs/gecko/vms/avm"
+	"github.com/ava-labs/gecko/vms/nftfx"
+	"github.com/ava-labs/gecko/vms/propertyfx"
+	"github.com/ava-labs/gecko/vms/rpcchainvm"
+	"github.com/ava-labs/gecko/vms/secp256k1fx"
+	"github.com/ava-labs/gecko/vms/spchainvm"
+	"github.com/ava-labs/gecko/vms/spdagvm"
+	"github.com/ava-labs/gecko/vms/timestampvm"
+
+	avacon "github.com/ava-labs/gecko/snow/consensus/avalanche"
+	avaeng "github.com/ava-labs/gecko/snow/engine/avalanche"
+
+	smeng "github.com/ava-labs/gecko/snow/engine/snowman"
+)
+
+const (
+	defaultChannelSize = 1000
+)
+
+var (
+	errProposerWindows = errors.New("recordings of chains with proposer windows can't be replayed, as their blocks depend on the time they're verified at")
+)
+
+// factories returns the factories of the VMs and Fxs that can be replayed. The
+// Platform VM can't be replayed, as it manages the node's chains.
+func factories(networkID uint32) (map[[32]byte]vms.VMFactory, error) {
+	avaAssetID, err := genesis.AVAAssetID(networkID)
+	if err != nil {
+		return nil, err
+	}
+	return map[[32]byte]vms.VMFactory{
+		avm.ID.Key(): &avm.Factory{
+			AVA:      avaAssetID,
+			Platform: ids.Empty,
+		},
+		genesis.EVMID.Key():  &rpcchainvm.Factory{Path: path.Join(config.PluginDir, "evm")},
+		spdagvm.ID.Key():     &spdagvm.Factory{TxFee: config.AvaTxFee},
+		spchainvm.ID.Key():   &spchainvm.Factory{},
+		timestampvm.ID.Key(): &timestampvm.Factory{},
+		secp256k1fx.ID.Key(): &secp256k1fx.Factory{},
+		nftfx.ID.Key():       &nftfx.Factory{},
+		propertyfx.ID.Key():  &propertyfx.Factory{},
+	}, nil
+}
+
+// create a new instance of the VM or Fx with ID [id]
+func create(factories map[[32]byte]vms.VMFactory, id ids.ID) (interface{}, error) {
+	factory, exists := factories[id.Key()]
+	if !exists {
+		return nil, fmt.Errorf("VM %s can't be replayed", id)
+	}
+	return factory.New()
+}
+
+// newEngine returns a fresh engine of the chain the recording was made on. Its
+// VM is created from the chain's genesis, or from the database given with
+// --db-dir. Messages sent by the engine are dropped, as the responses to them
+// are part of the recording. Decisions of consensus are passed to [decisions].
+func newEngine(log logging.Logger, recording *handler.Recording, decisions interface{}) (common.Engine, error) {
+	header := recording.Header
+	if header.ProposerWindows {
+		return nil, errProposerWindows
+	}
+
+	// Recordings made before the consensus implementation was recorded used
+	// the topological implementation
+	consensus := header.Consensus
+	if consensus == "" {
+		consensus = chains.TopologicalConsensus
+	}
+
+	db := database.Database(memdb.New())
+	if config.DBDir != "" {
+		persistentDB, err := persistent.Open(config.DBType, config.DBDir)
+		if err != nil {
+			return nil, err
+		}
+		db = persistentDB
+	}
+
+	decisionEvents := &triggers.EventDispatcher{}
+	decisionEvents.Initialize(log)
+	consensusEvents := &triggers.EventDispatcher{}
+	consensusEvents.Initialize(log)
+	if err := consensusEvents.RegisterChain(header.ChainID, "replay", decisions); err != nil {
+		return nil, err
+	}
+
+	keystore := &keystore.Keystore{}
+	keystore.Initialize(log, prefixdb.New([]byte("keystore"), db))
+	sharedMemory := &atomic.SharedMemory{}
+	sharedMemory.Initialize(log, prefixdb.New([]byte("shared memory"), db))
+
+	ctx := &snow.Context{
+		NetworkID:           header.NetworkID,
+		ChainID:             header.ChainID,
+		NodeID:              header.NodeID,
+		Log:                 log,
+		DecisionDispatcher:  decisionEvents,
+		ConsensusDispatcher: consensusEvents,
+		Keystore:            keystore.NewBlockchainKeyStore(header.ChainID),
+		SharedMemory:        sharedMemory.NewBlockchainSharedMemory(header.ChainID),
+		BCLookup:            &ids.Aliaser{},
+		Reputation:          reputation.NoReporter{},
+	}
+
+	factories, err := factories(header.NetworkID)
+	if err != nil {
+		return nil, err
+	}
+	vm, err := create(factories, header.VMID)
+	if err != nil {
+		return nil, err
+	}
+	fxs := make([]*common.Fx, len(header.FxIDs))
+	for i, fxID := range header.FxIDs {
+		fx, err := create(factories, fxID)
+		if err != nil {
+			return nil, err
+		}
+		fxs[i] = &common.Fx{
+			ID: fxID,
+			Fx: fx,
+		}
+	}
+
+	// The validators are sampled as they were while recording
+	beacons := validators.NewSet()
+	bootstrapWeight := uint64(0)
+	for _, beacon := range header.Beacons {
+		beacons.Add(validators.NewValidator(beacon.ID, beacon.Weight))
+		newWeight, err := math.Add64(bootstrapWeight, beacon.Weight)
+		if err != nil {
+			return nil, err
+		}
+		bootstrapWeight = newWeight
+	}
+	vdrs := validators.NewSet()
+	vdrs.Set(beacons.List())
+	vdrs.SetSampler(recording.Sampler())
+
+	params := avacon.Parameters{
+		Parameters: snowball.Parameters{
+			Namespace:         "replay",
+			Metrics:           prometheus.NewRegistry(),
+			K:                 header.K,
+			Alpha:             header.Alpha,
+			BetaVirtuous:      header.BetaVirtuous,
+			BetaRogue:         header.BetaRogue,
+			ConcurrentRepolls: header.ConcurrentRepolls,
+		},
+		Parents:   header.Parents,
+		BatchSize: header.BatchSize,
+	}
+
+	// Drops the messages sent by the engine
+	sender := &common.SenderTest{}
+	sender.Default(false)
+
+	// The recorded notifications of the VM are replayed instead of the VM's
+	msgChan := make(chan common.Message, defaultChannelSize)
+	go func() {
+		for range msgChan {
+		}
+	}()
+
+	db = prefixdb.New(header.ChainID.Bytes(), db)
+	vmDB := prefixdb.New([]byte("vm"), db)
+
+	ctx.Lock.Lock()
+	defer ctx.Lock.Unlock()
+
+	switch vm := vm.(type) {
+	case avalanche.DAGVM:
+		factory, err := chains.AvalancheFactory(consensus)
+		if err != nil {
+			return nil, err
+		}
+		vtxBlocker, err := queue.New(prefixdb.New([]byte("vertex_bootstrapping"), db))
+		if err != nil {
+			return nil, err
+		}
+		txBlocker, err := queue.New(prefixdb.New([]byte("tx_bootstrapping"), db))
+		if err != nil {
+			return nil, err
+		}
+		if err := vm.Initialize(ctx, vmDB, header.GenesisData, msgChan, fxs); err != nil {
+			return nil, err
+		}
+		vtxState := &state.Serializer{}
+		vtxState.Initialize(ctx, vm, prefixdb.New([]byte("vertex"), db))
+
+		engine := &avaeng.Transitive{}
+		engine.Initialize(avaeng.Config{
+			BootstrapConfig: avaeng.BootstrapConfig{
+				Config: common.Config{
+					Context:    ctx,
+					Validators: vdrs,
+					Beacons:    beacons,
+					Alpha:      bootstrapWeight/2 + 1, // must be > 50%
+					Sender:     sender,
+				},
+				VtxBlocked:   vtxBlocker,
+				TxBlocked:    txBlocker,
+				State:        vtxState,
+				VM:           vm,
+				Bootstrapped: func() {},
+			},
+			Params:    params,
+			Consensus: factory.New(),
+		})
+		return engine, nil
+	case smeng.ChainVM:
+		factory, err := chains.SnowmanFactory(consensus)
+		if err != nil {
+			return nil, err
+		}
+		blocked, err := queue.New(prefixdb.New([]byte("bootstrapping"), db))
+		if err != nil {
+			return nil, err
+		}
+		if err := vm.Initialize(ctx, vmDB, header.GenesisData, msgChan, fxs); err != nil {
+			return nil, err
+		}
+
+		engine := &smeng.Transitive{}
+		engine.Initialize(smeng.Config{
+			BootstrapConfig: smeng.BootstrapConfig{
+				Config: common.Config{
+					Context:    ctx,
+					Validators: vdrs,
+					Beacons:    beacons,
+					Alpha:      bootstrapWeight/2 + 1, // must be > 50%
+					Sender:     sender,
+				},
+				Blocked:      blocked,
+				VM:           vm,
+				StateSync:    header.StateSync,
+				Bootstrapped: func() {},
+			},
+			Params:    params.Parameters,
+			Consensus: factory.New(),
+		})
+		return engine, nil
+	default:
+		return nil, fmt.Errorf("VM %s should have type avalanche.DAGVM or snowman.ChainVM", header.VMID)
+	}
+}
@@ -0,0 +1,66 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/networking/handler"
+	"github.com/ava-labs/gecko/utils/logging"
+)
+
+var (
+	errNoRecording = errors.New("the recording to replay must be given with --recording")
+)
+
+func main() {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse arguments: %s\n", err)
+		os.Exit(2)
+	}
+
+	log, err := logging.New(config.LoggingConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to start the logger: %s\n", err)
+		os.Exit(1)
+	}
+	defer log.Stop()
+
+	file, err := os.Open(config.Recording)
+	if err != nil {
+		log.Fatal("Failed to open the recording: %s", err)
+		return
+	}
+	recording, err := handler.ReadRecording(file)
+	file.Close()
+	if err != nil {
+		log.Fatal("Failed to read the recording: %s", err)
+		return
+	}
+	engine, err := newEngine(log, recording, decisions{})
+	if err != nil {
+		log.Fatal("Failed to create the chain: %s", err)
+		return
+	}
+
+	replayed := recording.Replay(engine)
+	log.Info("Replayed %d messages to chain %s", replayed, recording.Header.ChainID)
+}
+
+// decisions prints the containers decided by consensus, so they can be compared
+// to the decisions of the node the recording was made on
+type decisions struct{}
+
+func (decisions) Accept(_, containerID ids.ID, _ []byte) error {
+	fmt.Printf("accepted %s\n", containerID)
+	return nil
+}
+
+func (decisions) Reject(_, containerID ids.ID, _ []byte) error {
+	fmt.Printf("rejected %s\n", containerID)
+	return nil
+}
@@ -0,0 +1,73 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ava-labs/gecko/database/persistent"
+	"github.com/ava-labs/gecko/utils/logging"
+	"github.com/ava-labs/gecko/utils/wrappers"
+)
+
+var (
+	config Config
+	err    error
+)
+
+// Parse the CLI arguments
+func init() {
+	errs := &wrappers.Errs{}
+	defer func() { err = errs.Err }()
+
+	loggingConfig, err := logging.DefaultConfig()
+	errs.Add(err)
+
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+
+	// Recording:
+	fs.StringVar(&config.Recording, "recording", "", "File the inputs of a chain's consensus engine were recorded to, with --snow-record-dir")
+	fs.StringVar(&config.DBDir, "db-dir", "", "Copy of the node's database, taken before the recording started. If empty, the chain is replayed from its genesis")
+	fs.StringVar(&config.DBType, "db-type", persistent.LevelDB, fmt.Sprintf("Storage engine of the database in --db-dir. Should be one of {%s}", strings.Join(persistent.Types, ", ")))
+
+	// VMs:
+	fs.Uint64Var(&config.AvaTxFee, "ava-tx-fee", 0, "Ava transaction fee, in $nAva")
+	fs.StringVar(&config.PluginDir, "plugin-dir", "./build/plugins", "Plugin directory for Ava VMs")
+
+	// Logging:
+	logsDir := fs.String("log-dir", "", "Logging directory for the replay")
+	logLevel := fs.String("log-level", "info", "The log level. Should be one of {verbo, debug, info, warn, error, fatal, off}")
+
+	ferr := fs.Parse(os.Args[1:])
+
+	if ferr == flag.ErrHelp {
+		// display usage/help text and exit successfully
+		os.Exit(0)
+	}
+
+	if ferr != nil {
+		// other type of error occurred when parsing args
+		os.Exit(2)
+	}
+
+	if config.Recording == "" {
+		errs.Add(errNoRecording)
+	}
+	errs.Add(persistent.Validate(config.DBType))
+
+	if *logsDir != "" {
+		loggingConfig.Directory = *logsDir
+	} else {
+		loggingConfig.Directory = path.Join(loggingConfig.Directory, "replay")
+	}
+	level, err := logging.ToLevel(*logLevel)
+	errs.Add(err)
+	loggingConfig.LogLevel = level
+	loggingConfig.DisplayLevel = level
+	config.LoggingConfig = loggingConfig
+}
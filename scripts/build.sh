@@ -21,6 +21,18 @@ cd "$CORETH_PATH"
 git -c advice.detachedHead=false checkout v0.1.0
 cd -
 
+# pebble is checked out at a release before fetching gecko's dependencies, so
+# that its own dependencies are fetched for the pinned version
+PEBBLE_PKG=github.com/cockroachdb/pebble
+PEBBLE_PATH="$GOPATH/src/$PEBBLE_PKG"
+if [[ ! -d "$PEBBLE_PATH/.git" ]]; then
+    git clone "https://$PEBBLE_PKG.git" "$PEBBLE_PATH"
+fi
+cd "$PEBBLE_PATH"
+git fetch --tags
+git -c advice.detachedHead=false checkout v1.1.5
+cd -
+
 GECKO_PKG=github.com/ava-labs/gecko
 GECKO_PATH="$GOPATH/src/$GECKO_PKG"
 if [[ -d "$GECKO_PATH/.git" ]]; then
@@ -33,4 +45,7 @@ fi
 
 go build -o "$PREFIX/ava" "$GECKO_PATH/main/"*.go
 go build -o "$PREFIX/xputtest" "$GECKO_PATH/xputtest/"*.go
+go build -o "$PREFIX/simulator" "$GECKO_PATH/simulator/"*.go
+go build -o "$PREFIX/replay" "$GECKO_PATH/replay/"*.go
+go build -o "$PREFIX/dbtool" "$GECKO_PATH/dbtool/"*.go
 go build -o "$PLUGIN_PREFIX/evm" "$CORETH_PATH/plugin/"*.go
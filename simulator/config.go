@@ -0,0 +1,34 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"github.com/ava-labs/gecko/snow/consensus/simulation"
+)
+
+// Output formats of the results
+const (
+	csvFormat  = "csv"
+	jsonFormat = "json"
+)
+
+// Config contains all of the configurations of a simulation sweep
+type Config struct {
+	// Network is the simulated network. Its snowball parameters are replaced
+	// by each combination of the values below.
+	Network simulation.Config
+
+	// Values of the snowball parameters to sweep over
+	Ks, Alphas, BetaVirtuous, BetaRogues []int
+
+	// Runs is the number of times each combination of parameters is simulated
+	Runs int
+
+	// Seed of the first run. Each later run uses the next seed.
+	Seed int64
+
+	// Format is either csv or json, and Output is the file results are
+	// written to. If Output is empty, results are written to stdout.
+	Format, Output string
+}
@@ -0,0 +1,84 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+
+	"github.com/ava-labs/gecko/snow/consensus/simulation"
+)
+
+func main() {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse arguments: %s\n", err)
+		os.Exit(2)
+	}
+
+	results := []simulation.Result(nil)
+	seed := config.Seed
+	for _, network := range sweep() {
+		if err := network.Valid(); err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping K = %d, Alpha = %d, BetaVirtuous = %d, BetaRogue = %d: %s\n",
+				network.Params.K, network.Params.Alpha, network.Params.BetaVirtuous, network.Params.BetaRogue, err)
+			continue
+		}
+
+		for run := 0; run < config.Runs; run++ {
+			rand.Seed(seed)
+			seed++
+
+			result, err := simulation.Run(network)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to simulate the network: %s\n", err)
+				os.Exit(1)
+			}
+			results = append(results, result)
+		}
+	}
+
+	w := io.Writer(os.Stdout)
+	if config.Output != "" {
+		file, err := os.Create(config.Output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create %s: %s\n", config.Output, err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if config.Format == jsonFormat {
+		err = simulation.WriteJSON(w, results)
+	} else {
+		err = simulation.WriteCSV(w, results)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write the results: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// sweep returns the simulated network with each combination of the snowball
+// parameters
+func sweep() []simulation.Config {
+	networks := []simulation.Config(nil)
+	for _, k := range config.Ks {
+		for _, alpha := range config.Alphas {
+			for _, betaVirtuous := range config.BetaVirtuous {
+				for _, betaRogue := range config.BetaRogues {
+					network := config.Network
+					network.Params.K = k
+					network.Params.Alpha = alpha
+					network.Params.BetaVirtuous = betaVirtuous
+					network.Params.BetaRogue = betaRogue
+					networks = append(networks, network)
+				}
+			}
+		}
+	}
+	return networks
+}
@@ -0,0 +1,98 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/gecko/snow/consensus/simulation"
+	"github.com/ava-labs/gecko/utils/wrappers"
+)
+
+var (
+	config Config
+	err    error
+)
+
+// Parse the CLI arguments
+func init() {
+	errs := &wrappers.Errs{}
+	defer func() { err = errs.Err }()
+
+	fs := flag.NewFlagSet("simulator", flag.ContinueOnError)
+
+	// Consensus:
+	fs.StringVar(&config.Network.Consensus, "consensus", simulation.Flat, fmt.Sprintf("Consensus implementation to simulate. Should be one of {%s}", strings.Join(simulation.Consensus, ", ")))
+
+	// Snowball parameters, each a comma separated list of values to sweep over:
+	ks := fs.String("snow-sample-size", "20", "Comma separated list of the number of nodes to query for each network poll")
+	alphas := fs.String("snow-quorum-size", "14", "Comma separated list of the alpha values to use for required number positive results")
+	betaVirtuous := fs.String("snow-virtuous-commit-threshold", "20", "Comma separated list of the beta values to use for virtuous decisions")
+	betaRogues := fs.String("snow-rogue-commit-threshold", "30", "Comma separated list of the beta values to use for rogue decisions")
+
+	// Network:
+	fs.IntVar(&config.Network.Nodes, "nodes", 100, "Number of nodes in the simulated network, including adversaries")
+	fs.Float64Var(&config.Network.ByzantineFraction, "byzantine-fraction", 0, "Fraction of the nodes that vote against the querier's preference")
+	fs.DurationVar(&config.Network.MinDelay, "min-delay", 10*time.Millisecond, "Minimum one way delay of a message")
+	fs.DurationVar(&config.Network.MaxDelay, "max-delay", 100*time.Millisecond, "Maximum one way delay of a message")
+	fs.DurationVar(&config.Network.MaxTime, "max-time", time.Hour, "Amount of simulated time after which a run is stopped")
+
+	// Decisions:
+	fs.IntVar(&config.Network.Choices, "choices", 2, "Number of colors for snowball, blocks for snowman or inputs for snowstorm")
+	fs.IntVar(&config.Network.InputsPerTx, "inputs-per-tx", 2, "Maximum number of inputs each snowstorm transaction consumes")
+	fs.IntVar(&config.Network.ConflictsPerInput, "conflicts-per-input", 2, "Maximum number of snowstorm transactions that consume each input")
+
+	// Runs:
+	fs.IntVar(&config.Runs, "runs", 1, "Number of times to simulate each combination of parameters")
+	fs.Int64Var(&config.Seed, "seed", time.Now().UnixNano(), "Seed of the first run")
+
+	// Output:
+	fs.StringVar(&config.Format, "format", csvFormat, fmt.Sprintf("Format of the results. Should be one of {%s, %s}", csvFormat, jsonFormat))
+	fs.StringVar(&config.Output, "output", "", "File to write the results to. Defaults to stdout")
+
+	ferr := fs.Parse(os.Args[1:])
+
+	if ferr == flag.ErrHelp {
+		// display usage/help text and exit successfully
+		os.Exit(0)
+	}
+
+	if ferr != nil {
+		// other type of error occurred when parsing args
+		os.Exit(2)
+	}
+
+	config.Network.Params.ConcurrentRepolls = 1
+
+	config.Ks, err = parseInts(*ks)
+	errs.Add(err)
+	config.Alphas, err = parseInts(*alphas)
+	errs.Add(err)
+	config.BetaVirtuous, err = parseInts(*betaVirtuous)
+	errs.Add(err)
+	config.BetaRogues, err = parseInts(*betaRogues)
+	errs.Add(err)
+
+	if config.Format != csvFormat && config.Format != jsonFormat {
+		errs.Add(fmt.Errorf("unknown output format %s", config.Format))
+	}
+}
+
+// parseInts parses a comma separated list of integers
+func parseInts(str string) ([]int, error) {
+	ints := []int(nil)
+	for _, s := range strings.Split(str, ",") {
+		i, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		ints = append(ints, i)
+	}
+	return ints, nil
+}
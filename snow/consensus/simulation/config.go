@@ -0,0 +1,106 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+)
+
+// Names of the consensus implementations that can be simulated
+const (
+	Flat        = "flat"        // snowball.Flat
+	Tree        = "tree"        // snowball.Tree
+	Topological = "topological" // snowman.Topological
+	Directed    = "directed"    // snowstorm.Directed
+	Input       = "input"       // snowstorm.Input
+)
+
+// Consensus lists the names of the consensus implementations that can be
+// simulated
+var Consensus = []string{Flat, Tree, Topological, Directed, Input}
+
+var (
+	errUnknownConsensus = errors.New("unknown consensus implementation")
+	errNoChoices        = errors.New("at least one choice must be decided on")
+	errBadDelays        = errors.New("message delays must satisfy 0 <= min delay <= max delay")
+	errNoMaxTime        = errors.New("the maximum simulated time must be positive")
+)
+
+// Config describes a simulated network and the consensus its nodes run
+type Config struct {
+	// Consensus is the name of the consensus implementation every correct
+	// node runs
+	Consensus string
+
+	// Params are the snowball parameters every correct node runs with
+	Params snowball.Parameters
+
+	// Nodes is the total number of nodes in the network, including
+	// adversaries
+	Nodes int
+
+	// ByzantineFraction is the fraction of the nodes that are adversaries.
+	// Adversaries don't run consensus; when queried, they vote against the
+	// querier's current preference.
+	ByzantineFraction float64
+
+	// Choices is the number of conflicting values that are decided on. For
+	// snowball this is the number of colors, for snowman the number of blocks
+	// and for snowstorm the number of inputs the transactions consume.
+	Choices int
+
+	// InputsPerTx and ConflictsPerInput describe the conflict graph when
+	// simulating snowstorm. Each transaction consumes up to InputsPerTx
+	// inputs, and each input is consumed by up to ConflictsPerInput
+	// transactions.
+	InputsPerTx, ConflictsPerInput int
+
+	// MinDelay and MaxDelay bound the one way delay of every message. Delays
+	// are sampled uniformly from this range.
+	MinDelay, MaxDelay time.Duration
+
+	// MaxTime is the amount of simulated time after which the simulation is
+	// stopped, even if some correct nodes haven't finalized
+	MaxTime time.Duration
+}
+
+// Byzantine returns the number of adversaries in the network
+func (c *Config) Byzantine() int { return int(float64(c.Nodes) * c.ByzantineFraction) }
+
+// Valid returns nil if the config describes a network that can be simulated
+func (c *Config) Valid() error {
+	switch {
+	case !c.knownConsensus():
+		return fmt.Errorf("%w: %s", errUnknownConsensus, c.Consensus)
+	case c.Nodes <= c.Params.K:
+		return fmt.Errorf("Nodes = %d, K = %d: Fails the condition that: K < Nodes", c.Nodes, c.Params.K)
+	case c.ByzantineFraction < 0 || c.ByzantineFraction >= 1:
+		return fmt.Errorf("ByzantineFraction = %f: Fails the condition that: 0 <= ByzantineFraction < 1", c.ByzantineFraction)
+	case c.Byzantine() == c.Nodes:
+		return fmt.Errorf("Nodes = %d, Byzantine = %d: Fails the condition that: Byzantine < Nodes", c.Nodes, c.Byzantine())
+	case c.Choices <= 0:
+		return errNoChoices
+	case c.MinDelay < 0 || c.MaxDelay < c.MinDelay:
+		return errBadDelays
+	case c.MaxTime <= 0:
+		return errNoMaxTime
+	case (c.Consensus == Directed || c.Consensus == Input) && (c.InputsPerTx <= 0 || c.ConflictsPerInput <= 0):
+		return fmt.Errorf("InputsPerTx = %d, ConflictsPerInput = %d: Fails the condition that: 0 < InputsPerTx, ConflictsPerInput", c.InputsPerTx, c.ConflictsPerInput)
+	default:
+		return c.Params.Valid()
+	}
+}
+
+func (c *Config) knownConsensus() bool {
+	for _, name := range Consensus {
+		if c.Consensus == name {
+			return true
+		}
+	}
+	return false
+}
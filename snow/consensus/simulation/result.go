@@ -0,0 +1,144 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package simulation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Result of simulating a network. Times are in simulated milliseconds.
+type Result struct {
+	Consensus    string `json:"consensus"`
+	K            int    `json:"k"`
+	Alpha        int    `json:"alpha"`
+	BetaVirtuous int    `json:"betaVirtuous"`
+	BetaRogue    int    `json:"betaRogue"`
+	Nodes        int    `json:"nodes"`
+	Byzantine    int    `json:"byzantine"`
+	Choices      int    `json:"choices"`
+
+	// Finalized is the number of correct nodes that finalized
+	Finalized int `json:"finalized"`
+
+	// Polls is the number of polls correct nodes issued
+	Polls uint64 `json:"polls"`
+
+	// Messages is the number of queries and responses that were sent
+	Messages uint64 `json:"messages"`
+
+	// Latencies, across the correct nodes that finalized, from the start of
+	// the simulation until the node finalized
+	MinLatency    float64 `json:"minLatency"`
+	MeanLatency   float64 `json:"meanLatency"`
+	MedianLatency float64 `json:"medianLatency"`
+	MaxLatency    float64 `json:"maxLatency"`
+
+	// SafetyViolations is the number of decisions that correct nodes made
+	// differently
+	SafetyViolations int `json:"safetyViolations"`
+
+	// Duration is the simulated time at which the simulation stopped
+	Duration float64 `json:"duration"`
+}
+
+// setLatencies from the times the correct nodes finalized at. Nodes that
+// didn't finalize are marked with a negative time.
+func (r *Result) setLatencies(finalized []time.Duration) {
+	latencies := []float64(nil)
+	sum := 0.0
+	for _, t := range finalized {
+		if t >= 0 {
+			latency := toMillis(t)
+			latencies = append(latencies, latency)
+			sum += latency
+		}
+	}
+
+	r.Finalized = len(latencies)
+	if len(latencies) == 0 {
+		return
+	}
+
+	sort.Float64s(latencies)
+	r.MinLatency = latencies[0]
+	r.MeanLatency = sum / float64(len(latencies))
+	r.MedianLatency = latencies[len(latencies)/2]
+	r.MaxLatency = latencies[len(latencies)-1]
+}
+
+func toMillis(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+
+// csvHeader names the columns written by WriteCSV
+var csvHeader = []string{
+	"consensus",
+	"k",
+	"alpha",
+	"beta_virtuous",
+	"beta_rogue",
+	"nodes",
+	"byzantine",
+	"choices",
+	"finalized",
+	"polls",
+	"messages",
+	"min_latency_ms",
+	"mean_latency_ms",
+	"median_latency_ms",
+	"max_latency_ms",
+	"safety_violations",
+	"duration_ms",
+}
+
+func (r *Result) csvRecord() []string {
+	formatFloat := func(f float64) string { return strconv.FormatFloat(f, 'f', 3, 64) }
+	return []string{
+		r.Consensus,
+		strconv.Itoa(r.K),
+		strconv.Itoa(r.Alpha),
+		strconv.Itoa(r.BetaVirtuous),
+		strconv.Itoa(r.BetaRogue),
+		strconv.Itoa(r.Nodes),
+		strconv.Itoa(r.Byzantine),
+		strconv.Itoa(r.Choices),
+		strconv.Itoa(r.Finalized),
+		strconv.FormatUint(r.Polls, 10),
+		strconv.FormatUint(r.Messages, 10),
+		formatFloat(r.MinLatency),
+		formatFloat(r.MeanLatency),
+		formatFloat(r.MedianLatency),
+		formatFloat(r.MaxLatency),
+		strconv.Itoa(r.SafetyViolations),
+		formatFloat(r.Duration),
+	}
+}
+
+// WriteCSV writes the results as CSV, one row per result, after a header row
+func WriteCSV(w io.Writer, results []Result) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, result := range results {
+		if err := writer.Write(result.csvRecord()); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the results as a JSON array
+func WriteJSON(w io.Writer, results []Result) error {
+	if results == nil {
+		results = []Result{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(results)
+}
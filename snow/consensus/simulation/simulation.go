@@ -0,0 +1,246 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package simulation
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/utils/random"
+)
+
+// participant is a correct node of the simulated network
+type participant interface {
+	// votes returns what this node responds to a query with
+	votes() []ids.ID
+
+	// recordPoll passes the result of a poll to this node's consensus instance
+	recordPoll(ids.Bag)
+
+	// finalized returns true once this node has decided
+	finalized() bool
+}
+
+// protocol describes how a family of consensus implementations is simulated
+type protocol interface {
+	// newParticipant returns a new correct node
+	newParticipant(params snowball.Parameters) participant
+
+	// byzantineVotes returns what an adversary responds to a query from
+	// [querier] with
+	byzantineVotes(querier participant) []ids.ID
+
+	// safetyViolations returns the number of decisions that [participants]
+	// don't agree on
+	safetyViolations(participants []participant) int
+}
+
+// newProtocol returns the protocol that simulates [config.Consensus]
+func newProtocol(config *Config) protocol {
+	switch config.Consensus {
+	case Flat:
+		return newSnowballProtocol(snowball.FlatFactory{}, config.Choices)
+	case Tree:
+		return newSnowballProtocol(snowball.TreeFactory{}, config.Choices)
+	case Topological:
+		return newSnowmanProtocol(config.Choices)
+	case Directed:
+		return newSnowstormProtocol(true, config.Choices, config.InputsPerTx, config.ConflictsPerInput)
+	default:
+		return newSnowstormProtocol(false, config.Choices, config.InputsPerTx, config.ConflictsPerInput)
+	}
+}
+
+// event is a message that is delivered at a point in simulated time
+type event struct {
+	time time.Duration
+	seq  uint64 // breaks ties so that runs with the same seed are identical
+
+	from, to int
+	query    bool     // if false, this is a response
+	votes    []ids.ID // the votes of a response
+}
+
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].time != q[j].time {
+		return q[i].time < q[j].time
+	}
+	return q[i].seq < q[j].seq
+}
+func (q eventQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*event)) }
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	n := len(old) - 1
+	e := old[n]
+	*q = old[:n]
+	return e
+}
+
+// poll is the outstanding query of a correct node
+type poll struct {
+	votes     ids.Bag
+	responses int
+}
+
+// network is a simulated network of correct nodes and adversaries. Nodes
+// [0, len(participants)) are correct, the remaining nodes are adversaries.
+type network struct {
+	config   *Config
+	protocol protocol
+
+	participants []participant
+	polls        []poll
+	finalized    []time.Duration // time each correct node finalized at, or -1
+
+	now    time.Duration
+	seq    uint64
+	events eventQueue
+	result Result
+}
+
+// Run simulates the network described by [config] until every correct node
+// has finalized or the maximum simulated time has passed. Randomness is drawn
+// from the math/rand source, so runs can be reproduced by seeding it.
+func Run(config Config) (Result, error) {
+	if err := config.Valid(); err != nil {
+		return Result{}, err
+	}
+
+	n := &network{
+		config:   &config,
+		protocol: newProtocol(&config),
+	}
+	n.initialize()
+	n.run()
+	return n.result, nil
+}
+
+func (n *network) initialize() {
+	correct := n.config.Nodes - n.config.Byzantine()
+	for i := 0; i < correct; i++ {
+		params := n.config.Params
+		// Every node registers its own metrics
+		params.Metrics = prometheus.NewRegistry()
+		n.participants = append(n.participants, n.protocol.newParticipant(params))
+		n.finalized = append(n.finalized, -1)
+	}
+	n.polls = make([]poll, correct)
+
+	n.result = Result{
+		Consensus:    n.config.Consensus,
+		K:            n.config.Params.K,
+		Alpha:        n.config.Params.Alpha,
+		BetaVirtuous: n.config.Params.BetaVirtuous,
+		BetaRogue:    n.config.Params.BetaRogue,
+		Nodes:        n.config.Nodes,
+		Byzantine:    n.config.Byzantine(),
+		Choices:      n.config.Choices,
+	}
+}
+
+func (n *network) run() {
+	for i, p := range n.participants {
+		if p.finalized() {
+			n.finalized[i] = 0
+		} else {
+			n.startPoll(i)
+		}
+	}
+
+	for n.events.Len() > 0 {
+		e := heap.Pop(&n.events).(*event)
+		if e.time > n.config.MaxTime {
+			break
+		}
+		n.now = e.time
+		if e.query {
+			n.handleQuery(e)
+		} else {
+			n.handleResponse(e)
+		}
+	}
+
+	n.result.Duration = toMillis(n.now)
+	n.result.SafetyViolations = n.protocol.safetyViolations(n.participants)
+	n.result.setLatencies(n.finalized)
+}
+
+// startPoll sends a query from correct node [i] to K other nodes
+func (n *network) startPoll(i int) {
+	n.polls[i] = poll{}
+	n.polls[i].votes.SetThreshold(n.config.Params.Alpha)
+	n.result.Polls++
+
+	sampler := random.Uniform{N: n.config.Nodes}
+	for k := 0; k < n.config.Params.K; {
+		peer := sampler.Sample()
+		if peer == i {
+			continue
+		}
+		n.send(&event{
+			from:  i,
+			to:    peer,
+			query: true,
+		})
+		k++
+	}
+}
+
+// handleQuery responds to a query with the queried node's current votes
+func (n *network) handleQuery(e *event) {
+	votes := []ids.ID(nil)
+	if e.to < len(n.participants) {
+		votes = n.participants[e.to].votes()
+	} else {
+		votes = n.protocol.byzantineVotes(n.participants[e.from])
+	}
+	n.send(&event{
+		from:  e.to,
+		to:    e.from,
+		votes: votes,
+	})
+}
+
+// handleResponse records a response, and once all K responses have arrived,
+// passes the poll to the querier's consensus instance
+func (n *network) handleResponse(e *event) {
+	p := &n.polls[e.to]
+	p.votes.Add(e.votes...)
+	p.responses++
+	if p.responses < n.config.Params.K {
+		return
+	}
+
+	participant := n.participants[e.to]
+	participant.recordPoll(p.votes)
+	if participant.finalized() {
+		n.finalized[e.to] = n.now
+	} else {
+		n.startPoll(e.to)
+	}
+}
+
+// send delivers [e] after a sampled delay
+func (n *network) send(e *event) {
+	e.time = n.now + n.delay()
+	n.seq++
+	e.seq = n.seq
+	heap.Push(&n.events, e)
+	n.result.Messages++
+}
+
+func (n *network) delay() time.Duration {
+	if n.config.MaxDelay == n.config.MinDelay {
+		return n.config.MinDelay
+	}
+	return time.Duration(random.Rand(int(n.config.MinDelay), int(n.config.MaxDelay)+1))
+}
@@ -0,0 +1,148 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package simulation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+)
+
+func testConfig(consensus string) Config {
+	return Config{
+		Consensus: consensus,
+		Params: snowball.Parameters{
+			K:                 10,
+			Alpha:             7,
+			BetaVirtuous:      10,
+			BetaRogue:         20,
+			ConcurrentRepolls: 1,
+		},
+		Nodes:             30,
+		Choices:           4,
+		InputsPerTx:       2,
+		ConflictsPerInput: 2,
+		MinDelay:          10 * time.Millisecond,
+		MaxDelay:          50 * time.Millisecond,
+		MaxTime:           time.Hour,
+	}
+}
+
+func TestRunFinalizes(t *testing.T) {
+	for _, consensus := range Consensus {
+		result, err := Run(testConfig(consensus))
+		if err != nil {
+			t.Fatalf("%s: %s", consensus, err)
+		}
+		if result.Finalized != 30 {
+			t.Fatalf("%s: Only %d of the nodes finalized", consensus, result.Finalized)
+		}
+		if result.SafetyViolations != 0 {
+			t.Fatalf("%s: Correct nodes made %d conflicting decisions", consensus, result.SafetyViolations)
+		}
+		if result.Messages != 2*10*result.Polls {
+			t.Fatalf("%s: Sent %d messages for %d polls", consensus, result.Messages, result.Polls)
+		}
+		if result.MinLatency < 20 || result.MinLatency > result.MedianLatency || result.MedianLatency > result.MaxLatency {
+			t.Fatalf("%s: Wrong latencies reported: %+v", consensus, result)
+		}
+	}
+}
+
+func TestRunStopsAtMaxTime(t *testing.T) {
+	config := testConfig(Flat)
+	config.MinDelay = time.Second
+	config.MaxDelay = time.Second
+	config.MaxTime = 5 * time.Second
+
+	result, err := Run(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Finalized != 0 {
+		t.Fatalf("Nodes shouldn't have finalized after only a few polls")
+	}
+	if result.Duration > 5000 {
+		t.Fatalf("Simulation ran for %fms, past the maximum time", result.Duration)
+	}
+}
+
+func TestRunByzantine(t *testing.T) {
+	config := testConfig(Flat)
+	config.ByzantineFraction = .1
+
+	result, err := Run(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Byzantine != 3 {
+		t.Fatalf("Expected 3 adversaries, got %d", result.Byzantine)
+	}
+	if result.Finalized != 27 {
+		t.Fatalf("Only %d of the correct nodes finalized", result.Finalized)
+	}
+}
+
+func TestConfigValid(t *testing.T) {
+	config := testConfig("unknown")
+	if err := config.Valid(); err == nil {
+		t.Fatalf("Unknown consensus should have been rejected")
+	}
+
+	config = testConfig(Flat)
+	config.Nodes = config.Params.K
+	if err := config.Valid(); err == nil {
+		t.Fatalf("Network smaller than the sample size should have been rejected")
+	}
+
+	config = testConfig(Flat)
+	config.MinDelay = config.MaxDelay + 1
+	if err := config.Valid(); err == nil {
+		t.Fatalf("Inverted delays should have been rejected")
+	}
+
+	config = testConfig(Flat)
+	config.Params.Alpha = config.Params.K / 2
+	if err := config.Valid(); err == nil {
+		t.Fatalf("Invalid snowball parameters should have been rejected")
+	}
+}
+
+func TestWriteResults(t *testing.T) {
+	results := []Result{
+		{Consensus: Flat, K: 10, MeanLatency: 1.5},
+		{Consensus: Tree, K: 20, SafetyViolations: 1},
+	}
+
+	buf := bytes.Buffer{}
+	if err := WriteCSV(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %d rows", len(records))
+	}
+	if records[1][0] != Flat || records[2][1] != "20" || records[1][12] != "1.500" {
+		t.Fatalf("Wrong rows written: %v", records)
+	}
+
+	buf.Reset()
+	if err := WriteJSON(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	parsed := []Result{}
+	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 2 || parsed[0] != results[0] || parsed[1] != results[1] {
+		t.Fatalf("Wrong results written: %v", parsed)
+	}
+}
@@ -0,0 +1,70 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package simulation
+
+import (
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/utils/random"
+)
+
+// snowballProtocol simulates nodes deciding between colors. Every node starts
+// out preferring a random color.
+type snowballProtocol struct {
+	factory snowball.Factory
+	colors  []ids.ID
+}
+
+func newSnowballProtocol(factory snowball.Factory, numColors int) *snowballProtocol {
+	p := &snowballProtocol{factory: factory}
+	for i := 0; i < numColors; i++ {
+		p.colors = append(p.colors, ids.Empty.Prefix(uint64(i)))
+	}
+	return p
+}
+
+func (p *snowballProtocol) newParticipant(params snowball.Parameters) participant {
+	sb := p.factory.New()
+
+	s := random.Uniform{N: len(p.colors)}
+	sb.Initialize(params, p.colors[s.Sample()])
+	for s.CanSample() {
+		sb.Add(p.colors[s.Sample()])
+	}
+	return &snowballParticipant{sb: sb}
+}
+
+// byzantineVotes votes for a random color the querier doesn't prefer
+func (p *snowballProtocol) byzantineVotes(querier participant) []ids.ID {
+	pref := querier.(*snowballParticipant).sb.Preference()
+
+	s := random.Uniform{N: len(p.colors)}
+	for s.CanSample() {
+		if color := p.colors[s.Sample()]; !color.Equals(pref) {
+			return []ids.ID{color}
+		}
+	}
+	return []ids.ID{pref}
+}
+
+// safetyViolations returns the number of colors, beyond the first, that
+// correct nodes finalized
+func (p *snowballProtocol) safetyViolations(participants []participant) int {
+	decided := ids.Set{}
+	for _, participant := range participants {
+		if sb := participant.(*snowballParticipant).sb; sb.Finalized() {
+			decided.Add(sb.Preference())
+		}
+	}
+	if decided.Len() == 0 {
+		return 0
+	}
+	return decided.Len() - 1
+}
+
+type snowballParticipant struct{ sb snowball.Consensus }
+
+func (p *snowballParticipant) votes() []ids.ID          { return []ids.ID{p.sb.Preference()} }
+func (p *snowballParticipant) recordPoll(votes ids.Bag) { p.sb.RecordPoll(votes) }
+func (p *snowballParticipant) finalized() bool          { return p.sb.Finalized() }
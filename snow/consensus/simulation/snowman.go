@@ -0,0 +1,134 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package simulation
+
+import (
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowman"
+	"github.com/ava-labs/gecko/utils/random"
+)
+
+// genesisID is the ID of the last accepted block when a simulation starts
+var genesisID = ids.Empty.Prefix(0)
+
+// block is a snowman block in the simulated network. Every node has its own
+// copy of each block, so that it can be decided independently.
+type block struct {
+	id     ids.ID
+	parent *block
+	status choices.Status
+}
+
+func (b *block) ID() ids.ID             { return b.id }
+func (b *block) Accept()                { b.status = choices.Accepted }
+func (b *block) Reject()                { b.status = choices.Rejected }
+func (b *block) Status() choices.Status { return b.status }
+func (b *block) Parent() snowman.Block  { return b.parent }
+func (b *block) Verify() error          { return nil }
+func (b *block) Bytes() []byte          { return b.id.Bytes() }
+
+// snowmanProtocol simulates nodes deciding on a tree of blocks. Each block's
+// parent is a random block that was created before it, or the genesis block.
+type snowmanProtocol struct {
+	blockIDs []ids.ID
+	parents  []int // index of the parent of each block, or -1 for genesis
+}
+
+func newSnowmanProtocol(numBlocks int) *snowmanProtocol {
+	p := &snowmanProtocol{}
+	for i := 0; i < numBlocks; i++ {
+		p.blockIDs = append(p.blockIDs, ids.Empty.Prefix(uint64(i+1)))
+		p.parents = append(p.parents, random.Rand(-1, i))
+	}
+	return p
+}
+
+func (p *snowmanProtocol) newParticipant(params snowball.Parameters) participant {
+	sm := snowman.TopologicalFactory{}.New()
+	sm.Initialize(snow.DefaultContextTest(), params, genesisID)
+
+	genesis := &block{
+		id:     genesisID,
+		status: choices.Accepted,
+	}
+	blocks := make([]*block, len(p.blockIDs))
+	for i, id := range p.blockIDs {
+		parent := genesis
+		if p.parents[i] >= 0 {
+			parent = blocks[p.parents[i]]
+		}
+		blocks[i] = &block{
+			id:     id,
+			parent: parent,
+			status: choices.Processing,
+		}
+		sm.Add(blocks[i])
+	}
+	return &snowmanParticipant{
+		sm:     sm,
+		blocks: blocks,
+	}
+}
+
+// byzantineVotes votes for a random block that isn't in the querier's
+// preferred chain
+func (p *snowmanProtocol) byzantineVotes(querier participant) []ids.ID {
+	sp := querier.(*snowmanParticipant)
+	preferred := ids.Set{}
+	for blk := sp.block(sp.sm.Preference()); blk != nil; blk = blk.parent {
+		preferred.Add(blk.id)
+	}
+
+	s := random.Uniform{N: len(p.blockIDs)}
+	for s.CanSample() {
+		if id := p.blockIDs[s.Sample()]; !preferred.Contains(id) {
+			return []ids.ID{id}
+		}
+	}
+	return nil
+}
+
+// safetyViolations returns the number of blocks that were accepted by one
+// correct node and rejected by another
+func (p *snowmanProtocol) safetyViolations(participants []participant) int {
+	violations := 0
+	for i := range p.blockIDs {
+		accepted, rejected := false, false
+		for _, participant := range participants {
+			switch participant.(*snowmanParticipant).blocks[i].status {
+			case choices.Accepted:
+				accepted = true
+			case choices.Rejected:
+				rejected = true
+			}
+		}
+		if accepted && rejected {
+			violations++
+		}
+	}
+	return violations
+}
+
+type snowmanParticipant struct {
+	sm     snowman.Consensus
+	blocks []*block
+}
+
+// block returns this node's copy of the block, or nil if it is the genesis
+// block
+func (p *snowmanParticipant) block(id ids.ID) *block {
+	for _, blk := range p.blocks {
+		if blk.id.Equals(id) {
+			return blk
+		}
+	}
+	return nil
+}
+
+func (p *snowmanParticipant) votes() []ids.ID          { return []ids.ID{p.sm.Preference()} }
+func (p *snowmanParticipant) recordPoll(votes ids.Bag) { p.sm.RecordPoll(votes) }
+func (p *snowmanParticipant) finalized() bool          { return p.sm.Finalized() }
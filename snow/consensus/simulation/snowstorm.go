@@ -0,0 +1,152 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package simulation
+
+import (
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+	"github.com/ava-labs/gecko/utils/random"
+)
+
+// snowstormProtocol simulates nodes deciding on a set of transactions that
+// conflict by consuming the same inputs
+type snowstormProtocol struct {
+	factory snowstorm.Factory
+	txs     []*snowstorm.TestTx
+}
+
+func newSnowstormProtocol(directed bool, numInputs, inputsPerTx, conflictsPerInput int) *snowstormProtocol {
+	p := &snowstormProtocol{}
+	if directed {
+		p.factory = snowstorm.DirectedFactory{}
+	} else {
+		p.factory = snowstorm.InputFactory{}
+	}
+
+	idCount := uint64(0)
+
+	// inputs that can still be consumed, and their index in the list
+	inputIndices := map[[32]byte]int{}
+	inputs := []ids.ID{}
+	for i := 0; i < numInputs; i++ {
+		idCount++
+		input := ids.Empty.Prefix(idCount)
+		inputIndices[input.Key()] = i
+		inputs = append(inputs, input)
+	}
+
+	consumers := map[[32]byte]int{}
+	for len(inputs) > 0 {
+		selected := []ids.ID{}
+		sampler := random.Uniform{N: len(inputs)}
+		for i := 0; i < inputsPerTx && sampler.CanSample(); i++ {
+			selected = append(selected, inputs[sampler.Sample()])
+		}
+
+		for _, input := range selected {
+			key := input.Key()
+			consumers[key]++
+			if consumers[key] < conflictsPerInput {
+				continue
+			}
+
+			// This input may not be consumed by any more transactions
+			i := inputIndices[key]
+			last := len(inputs) - 1
+			lastInput := inputs[last]
+			inputIndices[lastInput.Key()] = i
+			inputs[i] = lastInput
+			delete(inputIndices, key)
+			inputs = inputs[:last]
+		}
+
+		idCount++
+		tx := &snowstorm.TestTx{Identifier: ids.Empty.Prefix(idCount)}
+		tx.Ins.Add(selected...)
+		p.txs = append(p.txs, tx)
+	}
+	return p
+}
+
+func (p *snowstormProtocol) newParticipant(params snowball.Parameters) participant {
+	cg := p.factory.New()
+	cg.Initialize(snow.DefaultContextTest(), params)
+
+	txs := make([]*snowstorm.TestTx, len(p.txs))
+	s := random.Uniform{N: len(p.txs)}
+	for s.CanSample() {
+		i := s.Sample()
+		txs[i] = &snowstorm.TestTx{
+			Identifier: p.txs[i].Identifier,
+			Ins:        p.txs[i].Ins,
+			Stat:       choices.Processing,
+		}
+		cg.Add(txs[i])
+	}
+	return &snowstormParticipant{
+		cg:  cg,
+		txs: txs,
+	}
+}
+
+// byzantineVotes votes, in place of each transaction the querier prefers, for
+// a random transaction that conflicts with it
+func (p *snowstormProtocol) byzantineVotes(querier participant) []ids.ID {
+	sp := querier.(*snowstormParticipant)
+	preferences := sp.cg.Preferences()
+
+	votes := []ids.ID(nil)
+	for _, tx := range sp.txs {
+		if !preferences.Contains(tx.ID()) {
+			continue
+		}
+		if conflicts := sp.cg.Conflicts(tx).List(); len(conflicts) > 0 {
+			votes = append(votes, conflicts[random.Rand(0, len(conflicts))])
+		}
+	}
+	return votes
+}
+
+// safetyViolations returns the number of transactions that were accepted by
+// one correct node and rejected by another
+func (p *snowstormProtocol) safetyViolations(participants []participant) int {
+	violations := 0
+	for i := range p.txs {
+		accepted, rejected := false, false
+		for _, participant := range participants {
+			switch participant.(*snowstormParticipant).txs[i].Status() {
+			case choices.Accepted:
+				accepted = true
+			case choices.Rejected:
+				rejected = true
+			}
+		}
+		if accepted && rejected {
+			violations++
+		}
+	}
+	return violations
+}
+
+type snowstormParticipant struct {
+	cg  snowstorm.Consensus
+	txs []*snowstorm.TestTx
+}
+
+// votes for the preferred transactions, along with the accepted ones
+func (p *snowstormParticipant) votes() []ids.ID {
+	votes := p.cg.Preferences().List()
+	for _, tx := range p.txs {
+		if tx.Status() == choices.Accepted {
+			votes = append(votes, tx.ID())
+		}
+	}
+	return votes
+}
+
+func (p *snowstormParticipant) recordPoll(votes ids.Bag) { p.cg.RecordPoll(votes) }
+func (p *snowstormParticipant) finalized() bool          { return p.cg.Finalized() }
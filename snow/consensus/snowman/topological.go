@@ -4,6 +4,9 @@
 package snowman
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/snow"
 	"github.com/ava-labs/gecko/snow/consensus/snowball"
@@ -171,6 +174,39 @@ func (ts *Topological) RecordPoll(votes ids.Bag) {
 // Finalized implements the Snowman interface
 func (ts *Topological) Finalized() bool { return len(ts.blocks) == 1 }
 
+// String describes the snowball instances that decide between the children of
+// each block, starting from the last accepted block
+func (ts *Topological) String() string {
+	sb := strings.Builder{}
+	sb.WriteString("SM(")
+
+	blkIDs := []ids.ID{ts.head}
+	for len(blkIDs) > 0 {
+		blkID := blkIDs[0]
+		blkIDs = blkIDs[1:]
+
+		n, ok := ts.blocks[blkID.Key()]
+		if !ok || n.sb == nil {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n    Children of %s:\n        ", blkID))
+		sb.WriteString(strings.Replace(n.sb.String(), "\n", "\n        ", -1))
+
+		childIDs := []ids.ID{}
+		for _, child := range n.children {
+			childIDs = append(childIDs, child.ID())
+		}
+		ids.SortIDs(childIDs)
+		blkIDs = append(blkIDs, childIDs...)
+	}
+
+	if len(ts.blocks) > 1 {
+		sb.WriteString("\n")
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
 // takes in a list of votes and sets up the topological ordering. Returns the
 // reachable section of the graph annotated with the number of inbound edges and
 // the non-transitively applied votes. Also returns the list of leaf blocks.
@@ -10,6 +10,7 @@ import (
 
 	"github.com/ava-labs/gecko/database"
 	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
 	"github.com/ava-labs/gecko/snow/triggers"
 	"github.com/ava-labs/gecko/utils/logging"
 )
@@ -52,6 +53,7 @@ type Context struct {
 	Keystore            Keystore
 	SharedMemory        SharedMemory
 	BCLookup            AliasLookup
+	Reputation          reputation.Reporter
 }
 
 // DefaultContextTest ...
@@ -67,5 +69,6 @@ func DefaultContextTest() *Context {
 		DecisionDispatcher:  &decisionED,
 		ConsensusDispatcher: &consensusED,
 		BCLookup:            &ids.Aliaser{},
+		Reputation:          reputation.NoReporter{},
 	}
 }
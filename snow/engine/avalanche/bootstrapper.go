@@ -9,10 +9,17 @@ import (
 	"github.com/ava-labs/gecko/snow/consensus/avalanche"
 	"github.com/ava-labs/gecko/snow/engine/common"
 	"github.com/ava-labs/gecko/snow/engine/common/queue"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
 	"github.com/ava-labs/gecko/utils/formatting"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+const (
+	// commitInterval is the number of vertices, or transactions, executed
+	// between commits of the bootstrapping queues
+	commitInterval = 1024
+)
+
 // BootstrapConfig ...
 type BootstrapConfig struct {
 	common.Config
@@ -23,6 +30,8 @@ type BootstrapConfig struct {
 
 	State State
 	VM    DAGVM
+
+	Bootstrapped func()
 }
 
 type bootstrapper struct {
@@ -30,7 +39,10 @@ type bootstrapper struct {
 	metrics
 	common.Bootstrapper
 
-	pending    ids.Set
+	// Vertices being fetched, and the outstanding requests for them
+	pending  ids.Set
+	requests common.Requests
+
 	finished   bool
 	onFinished func()
 }
@@ -51,6 +63,18 @@ func (b *bootstrapper) Initialize(config BootstrapConfig) {
 		vm:          b.VM,
 	})
 
+	queuedVtxs, err := b.VtxBlocked.Len()
+	if err != nil {
+		b.BootstrapConfig.Context.Log.Error("Failed to read the vertex bootstrapping queue due to %s", err)
+	}
+	queuedTxs, err := b.TxBlocked.Len()
+	if err != nil {
+		b.BootstrapConfig.Context.Log.Error("Failed to read the transaction bootstrapping queue due to %s", err)
+	}
+	b.numBlockedVtx.Set(float64(queuedVtxs))
+	b.numBlockedTx.Set(float64(queuedTxs))
+	b.Progress.Initialize(uint64(queuedVtxs) + uint64(queuedTxs))
+
 	config.Bootstrapable = b
 	b.Bootstrapper.Initialize(config.Config)
 }
@@ -75,9 +99,22 @@ func (b *bootstrapper) FilterAccepted(containerIDs ids.Set) ids.Set {
 
 // ForceAccepted ...
 func (b *bootstrapper) ForceAccepted(acceptedContainerIDs ids.Set) {
-	for _, vtxID := range acceptedContainerIDs.List() {
+	b.Progress.SetPhase(common.PhaseFetching)
+
+	// Resume fetching the vertices that were being fetched before a restart
+	pendingIDs, err := b.VtxBlocked.Pending()
+	if err != nil {
+		b.BootstrapConfig.Context.Log.Warn("Failed to read the vertices being fetched before a restart due to %s", err)
+	} else if numResumed := pendingIDs.Len(); numResumed > 0 {
+		b.BootstrapConfig.Context.Log.Info("Bootstrapping resumed fetching %d vertices", numResumed)
+		b.Progress.Resume(numResumed)
+	}
+	pendingIDs.Union(acceptedContainerIDs)
+
+	for _, vtxID := range pendingIDs.List() {
 		b.fetch(vtxID)
 	}
+	b.persist()
 
 	if numPending := b.pending.Len(); numPending == 0 {
 		// TODO: This typically indicates bootstrapping has failed, so this
@@ -86,31 +123,78 @@ func (b *bootstrapper) ForceAccepted(acceptedContainerIDs ids.Set) {
 	}
 }
 
-// Put ...
-func (b *bootstrapper) Put(vdr ids.ShortID, requestID uint32, vtxID ids.ID, vtxBytes []byte) {
-	b.BootstrapConfig.Context.Log.Verbo("Put called for vertexID %s", vtxID)
+// MultiPut handles the response to a GetAncestors request. The vertices are
+// the requested vertex followed by its ancestors in breadth first order.
+func (b *bootstrapper) MultiPut(vdr ids.ShortID, requestID uint32, vtxs [][]byte) {
+	wantedVtxID, ok := b.requests.Remove(vdr, requestID)
+	if !ok {
+		b.BootstrapConfig.Context.Log.Debug("Received a MultiPut message from %s unexpectedly", vdr)
+		return
+	}
+	b.BootstrapConfig.Context.Log.Verbo("MultiPut called for vertexID %s with %d vertices", wantedVtxID, len(vtxs))
 
-	if !b.pending.Contains(vtxID) {
+	if len(vtxs) > common.MaxContainersPerMultiPut {
+		b.BootstrapConfig.Context.Log.Debug("MultiPut from %s contained %d vertices, which is more than the maximum of %d",
+			vdr, len(vtxs), common.MaxContainersPerMultiPut)
+		b.BootstrapConfig.Context.Reputation.Report(vdr, reputation.InvalidContainer)
+		b.GetAncestorsFailed(vdr, requestID)
 		return
 	}
 
-	vtx, err := b.State.ParseVertex(vtxBytes)
-	if err != nil {
-		b.BootstrapConfig.Context.Log.Warn("ParseVertex failed due to %s for block:\n%s",
-			err,
-			formatting.DumpBytes{Bytes: vtxBytes})
-		b.GetFailed(vdr, requestID, vtxID)
+	// Each vertex must be a parent of a vertex before it, starting with the
+	// requested vertex. Vertices after the first one that isn't are ignored.
+	first := avalanche.Vertex(nil)
+	eligibleIDs := ids.Set{}
+	eligibleIDs.Add(wantedVtxID)
+	for _, vtxBytes := range vtxs {
+		vtx, err := b.State.ParseVertex(vtxBytes)
+		if err != nil {
+			b.BootstrapConfig.Context.Log.Debug("ParseVertex failed due to %s for vertex:\n%s",
+				err,
+				formatting.DumpBytes{Bytes: vtxBytes})
+			break
+		}
+		if !eligibleIDs.Contains(vtx.ID()) {
+			break
+		}
+		if first == nil {
+			first = vtx
+		}
+		for _, parent := range vtx.Parents() {
+			eligibleIDs.Add(parent.ID())
+		}
+		b.Progress.Received(vdr)
+	}
+
+	if first == nil {
+		b.BootstrapConfig.Context.Log.Debug("MultiPut from %s didn't contain the requested vertex %s", vdr, wantedVtxID)
+		b.BootstrapConfig.Context.Reputation.Report(vdr, reputation.InvalidContainer)
+		b.sendRequest(wantedVtxID)
 		return
 	}
 
-	b.addVertex(vtx)
+	// Parsed vertices are known to the state, so storing the requested vertex
+	// also stores the ancestors that were sent with it
+	b.storeVertex(first)
+	b.persist()
+
+	if numPending := b.pending.Len(); numPending == 0 {
+		b.finish()
+	}
 }
 
-// GetFailed ...
-func (b *bootstrapper) GetFailed(_ ids.ShortID, _ uint32, vtxID ids.ID) { b.sendRequest(vtxID) }
+// GetAncestorsFailed requests the vertex from another validator
+func (b *bootstrapper) GetAncestorsFailed(vdr ids.ShortID, requestID uint32) {
+	vtxID, ok := b.requests.Remove(vdr, requestID)
+	if !ok {
+		b.BootstrapConfig.Context.Log.Debug("GetAncestorsFailed called with unknown requestID %d from %s", requestID, vdr)
+		return
+	}
+	b.sendRequest(vtxID)
+}
 
 func (b *bootstrapper) fetch(vtxID ids.ID) {
-	if b.pending.Contains(vtxID) {
+	if b.pending.Contains(vtxID) || b.queued(vtxID) {
 		return
 	}
 
@@ -122,27 +206,24 @@ func (b *bootstrapper) fetch(vtxID ids.ID) {
 	b.storeVertex(vtx)
 }
 
+// sendRequest requests [vtxID] and its ancestors from the sampled validator
+// with the fewest outstanding requests, so that requests are spread across
+// the validators
 func (b *bootstrapper) sendRequest(vtxID ids.ID) {
-	validators := b.BootstrapConfig.Validators.Sample(1)
-	if len(validators) == 0 {
+	validatorID, ok := b.requests.LeastLoaded(b.BootstrapConfig.Validators.Sample(common.NumFetchCandidates))
+	if !ok {
 		b.BootstrapConfig.Context.Log.Error("Dropping request for %s as there are no validators", vtxID)
 		return
 	}
-	validatorID := validators[0].ID()
 	b.RequestID++
 
 	b.pending.Add(vtxID)
-	b.BootstrapConfig.Sender.Get(validatorID, b.RequestID, vtxID)
-
-	b.numPendingRequests.Set(float64(b.pending.Len()))
-}
-
-func (b *bootstrapper) addVertex(vtx avalanche.Vertex) {
-	b.storeVertex(vtx)
+	b.requests.Add(validatorID, b.RequestID, vtxID)
+	b.BootstrapConfig.Sender.GetAncestors(validatorID, b.RequestID, vtxID)
 
-	if numPending := b.pending.Len(); numPending == 0 {
-		b.finish()
-	}
+	numPending := b.pending.Len()
+	b.numPendingRequests.Set(float64(numPending))
+	b.Progress.Requested(validatorID, numPending)
 }
 
 func (b *bootstrapper) storeVertex(vtx avalanche.Vertex) {
@@ -154,6 +235,12 @@ func (b *bootstrapper) storeVertex(vtx avalanche.Vertex) {
 		vts = vts[:newLen]
 
 		vtxID := vtx.ID()
+		if b.queued(vtxID) {
+			// The ancestry of a queued vertex was fetched when it was queued,
+			// possibly before a restart
+			continue
+		}
+
 		switch status := vtx.Status(); status {
 		case choices.Unknown:
 			b.sendRequest(vtxID)
@@ -166,6 +253,7 @@ func (b *bootstrapper) storeVertex(vtx avalanche.Vertex) {
 				vtx:         vtx,
 			}); err == nil {
 				b.numBlockedVtx.Inc()
+				b.Progress.Fetched(b.pending.Len())
 			}
 			for _, tx := range vtx.Txs() {
 				if err := b.TxBlocked.Push(&txJob{
@@ -174,6 +262,7 @@ func (b *bootstrapper) storeVertex(vtx avalanche.Vertex) {
 					tx:          tx,
 				}); err == nil {
 					b.numBlockedTx.Inc()
+					b.Progress.Fetched(b.pending.Len())
 				}
 			}
 
@@ -191,25 +280,62 @@ func (b *bootstrapper) storeVertex(vtx avalanche.Vertex) {
 	b.numPendingRequests.Set(float64(numPending))
 }
 
+// queued returns true if [vtxID] was fetched and is waiting to be executed
+func (b *bootstrapper) queued(vtxID ids.ID) bool {
+	queued, err := b.VtxBlocked.Has(vtxID)
+	return err == nil && queued
+}
+
+// persist the fetched vertices and transactions, and the vertices being
+// fetched, so that bootstrapping can resume from them after a restart
+func (b *bootstrapper) persist() {
+	if err := b.VtxBlocked.SetPending(b.pending); err != nil {
+		b.BootstrapConfig.Context.Log.Warn("Failed to record the vertices being fetched due to %s", err)
+	}
+	b.commit(b.TxBlocked)
+	b.commit(b.VtxBlocked)
+}
+
 func (b *bootstrapper) finish() {
 	if b.finished {
 		return
 	}
 
+	b.Progress.SetPhase(common.PhaseExecuting)
 	b.executeAll(b.TxBlocked, b.numBlockedTx)
 	b.executeAll(b.VtxBlocked, b.numBlockedVtx)
+	b.Progress.SetPhase(common.PhaseFinished)
 
 	// Start consensus
 	b.onFinished()
 	b.finished = true
+
+	if b.Bootstrapped != nil {
+		b.Bootstrapped()
+	}
 }
 
 func (b *bootstrapper) executeAll(jobs *queue.Jobs, numBlocked prometheus.Gauge) {
+	numExecuted := 0
 	for job, err := jobs.Pop(); err == nil; job, err = jobs.Pop() {
 		numBlocked.Dec()
 		b.BootstrapConfig.Context.Log.Debug("Executing: %s", job.ID())
 		if err := jobs.Execute(job); err != nil {
 			b.BootstrapConfig.Context.Log.Warn("Error executing: %s", err)
 		}
+		b.Progress.Executed()
+
+		// Executing a job is idempotent, so committing periodically only
+		// bounds the work repeated after a restart
+		if numExecuted++; numExecuted%commitInterval == 0 {
+			b.commit(jobs)
+		}
+	}
+	b.commit(jobs)
+}
+
+func (b *bootstrapper) commit(jobs *queue.Jobs) {
+	if err := jobs.Commit(); err != nil {
+		b.BootstrapConfig.Context.Log.Warn("Failed to commit a bootstrapping queue due to %s", err)
 	}
 }
@@ -59,7 +59,7 @@ func newConfig(t *testing.T) (BootstrapConfig, ids.ShortID, *common.SenderTest,
 	peers.Add(peer)
 
 	handler.Initialize(engine, make(chan common.Message), 1)
-	timeouts.Initialize(0)
+	timeouts.Initialize(ctx.Log, timeout.Config{}, prometheus.NewRegistry())
 	router.Initialize(ctx.Log, timeouts)
 
 	vtxBlocker, _ := queue.New(prefixdb.New([]byte("vtx"), db))
@@ -133,7 +133,7 @@ func TestBootstrapperSingleFrontier(t *testing.T) {
 	}
 
 	vtxIDToReqID := map[[32]byte]uint32{}
-	sender.GetF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
+	sender.GetAncestorsF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
 		if !vdr.Equals(peerID) {
 			t.Fatalf("Should have requested vertex from %s, requested from %s", peerID, vdr)
 		}
@@ -153,7 +153,7 @@ func TestBootstrapperSingleFrontier(t *testing.T) {
 	bs.ForceAccepted(acceptedIDs)
 
 	state.getVertex = nil
-	sender.GetF = nil
+	sender.GetAncestorsF = nil
 
 	if numReqs := len(vtxIDToReqID); numReqs != 3 {
 		t.Fatalf("Should have requested %d vertices, %d were requested", 3, numReqs)
@@ -202,11 +202,11 @@ func TestBootstrapperSingleFrontier(t *testing.T) {
 
 		switch {
 		case vtxID.Equals(vtxID0):
-			bs.Put(peerID, reqID, vtxID, vtxBytes0)
+			bs.MultiPut(peerID, reqID, [][]byte{vtxBytes0})
 		case vtxID.Equals(vtxID1):
-			bs.Put(peerID, reqID, vtxID, vtxBytes1)
+			bs.MultiPut(peerID, reqID, [][]byte{vtxBytes1})
 		case vtxID.Equals(vtxID2):
-			bs.Put(peerID, reqID, vtxID, vtxBytes2)
+			bs.MultiPut(peerID, reqID, [][]byte{vtxBytes2})
 		default:
 			t.Fatalf("Requested unknown vertex")
 		}
@@ -272,7 +272,7 @@ func TestBootstrapperUnknownByzantineResponse(t *testing.T) {
 	}
 
 	requestID := new(uint32)
-	sender.GetF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
+	sender.GetAncestorsF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
 		if !vdr.Equals(peerID) {
 			t.Fatalf("Should have requested vertex from %s, requested from %s", peerID, vdr)
 		}
@@ -288,7 +288,6 @@ func TestBootstrapperUnknownByzantineResponse(t *testing.T) {
 	bs.ForceAccepted(acceptedIDs)
 
 	state.getVertex = nil
-	sender.GetF = nil
 
 	state.parseVertex = func(vtxBytes []byte) (avalanche.Vertex, error) {
 		switch {
@@ -316,8 +315,16 @@ func TestBootstrapperUnknownByzantineResponse(t *testing.T) {
 	finished := new(bool)
 	bs.onFinished = func() { *finished = true }
 
-	bs.Put(peerID, *requestID, vtxID1, vtxBytes1)
-	bs.Put(peerID, *requestID, vtxID0, vtxBytes0)
+	// A response that doesn't start with the requested vertex should cause
+	// the vertex to be requested again
+	oldReqID := *requestID
+	bs.MultiPut(peerID, *requestID, [][]byte{vtxBytes1})
+	if *requestID == oldReqID {
+		t.Fatalf("Should have requested the vertex again")
+	}
+
+	sender.GetAncestorsF = nil
+	bs.MultiPut(peerID, *requestID, [][]byte{vtxBytes0})
 
 	state.parseVertex = nil
 	state.edge = nil
@@ -334,6 +341,111 @@ func TestBootstrapperUnknownByzantineResponse(t *testing.T) {
 	}
 }
 
+func TestBootstrapperMultiPut(t *testing.T) {
+	config, peerID, sender, state, _ := newConfig(t)
+
+	vtxID0 := ids.Empty.Prefix(0)
+	vtxID1 := ids.Empty.Prefix(1)
+	vtxID2 := ids.Empty.Prefix(2)
+	vtxID3 := ids.Empty.Prefix(3)
+
+	vtxBytes0 := []byte{0}
+	vtxBytes1 := []byte{1}
+	vtxBytes2 := []byte{2}
+	vtxBytes3 := []byte{3}
+
+	vtx0 := &Vtx{
+		id:     vtxID0,
+		height: 0,
+		status: choices.Processing,
+		bytes:  vtxBytes0,
+	}
+	vtx1 := &Vtx{
+		parents: []avalanche.Vertex{vtx0},
+		id:      vtxID1,
+		height:  1,
+		status:  choices.Processing,
+		bytes:   vtxBytes1,
+	}
+	vtx2 := &Vtx{
+		parents: []avalanche.Vertex{vtx1, vtx0},
+		id:      vtxID2,
+		height:  2,
+		status:  choices.Processing,
+		bytes:   vtxBytes2,
+	}
+	// vtx3 isn't an ancestor of vtx2
+	vtx3 := &Vtx{
+		id:     vtxID3,
+		height: 0,
+		status: choices.Processing,
+		bytes:  vtxBytes3,
+	}
+	vtxs := []*Vtx{vtx0, vtx1, vtx2, vtx3}
+
+	bs := bootstrapper{}
+	bs.metrics.Initialize(config.Context.Log, fmt.Sprintf("gecko_%s", config.Context.ChainID), prometheus.NewRegistry())
+	bs.Initialize(config)
+
+	acceptedIDs := ids.Set{}
+	acceptedIDs.Add(vtxID2)
+
+	state.getVertex = func(vtxID ids.ID) (avalanche.Vertex, error) { return nil, errUnknownVertex }
+
+	requestID := new(uint32)
+	sender.GetAncestorsF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
+		if !vtxID.Equals(vtxID2) {
+			t.Fatalf("Should have only requested the accepted frontier")
+		}
+		*requestID = reqID
+	}
+
+	bs.ForceAccepted(acceptedIDs)
+
+	sender.GetAncestorsF = nil
+
+	state.parseVertex = func(vtxBytes []byte) (avalanche.Vertex, error) {
+		for _, vtx := range vtxs {
+			if bytes.Equal(vtxBytes, vtx.Bytes()) {
+				return vtx, nil
+			}
+		}
+		t.Fatal(errParsedUnknownVertex)
+		return nil, errParsedUnknownVertex
+	}
+	state.edge = func() []ids.ID { return []ids.ID{vtxID2} }
+	state.getVertex = func(vtxID ids.ID) (avalanche.Vertex, error) {
+		for _, vtx := range vtxs {
+			if vtxID.Equals(vtx.ID()) {
+				return vtx, nil
+			}
+		}
+		t.Fatal(errUnknownVertex)
+		panic(errUnknownVertex)
+	}
+
+	finished := new(bool)
+	bs.onFinished = func() { *finished = true }
+
+	// The ancestors are in one response, so no more requests should be sent
+	bs.MultiPut(peerID, *requestID, [][]byte{vtxBytes2, vtxBytes1, vtxBytes0, vtxBytes3})
+
+	if !*finished {
+		t.Fatalf("Bootstrapping should have finished")
+	}
+	for _, vtx := range []*Vtx{vtx0, vtx1, vtx2} {
+		if vtx.Status() != choices.Accepted {
+			t.Fatalf("Vertex %s should be accepted", vtx.ID())
+		}
+	}
+	if vtx3.Status() != choices.Processing {
+		t.Fatalf("Vertex that isn't an ancestor shouldn't have been accepted")
+	}
+	if status := bs.Progress.Status(); len(status.Peers) != 1 || status.Peers[0].Received != 3 {
+		t.Fatalf("Should have received 3 vertices from the peer")
+	}
+}
+
 func TestBootstrapperVertexDependencies(t *testing.T) {
 	config, peerID, sender, state, _ := newConfig(t)
 
@@ -377,7 +489,7 @@ func TestBootstrapperVertexDependencies(t *testing.T) {
 	}
 
 	reqIDPtr := new(uint32)
-	sender.GetF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
+	sender.GetAncestorsF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
 		if !vdr.Equals(peerID) {
 			t.Fatalf("Should have requested vertex from %s, requested from %s", peerID, vdr)
 		}
@@ -393,7 +505,7 @@ func TestBootstrapperVertexDependencies(t *testing.T) {
 	bs.ForceAccepted(acceptedIDs)
 
 	state.getVertex = nil
-	sender.GetF = nil
+	sender.GetAncestorsF = nil
 
 	state.parseVertex = func(vtxBytes []byte) (avalanche.Vertex, error) {
 		switch {
@@ -403,7 +515,7 @@ func TestBootstrapperVertexDependencies(t *testing.T) {
 		t.Fatal(errParsedUnknownVertex)
 		return nil, errParsedUnknownVertex
 	}
-	sender.GetF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
+	sender.GetAncestorsF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
 		if !vdr.Equals(peerID) {
 			t.Fatalf("Should have requested vertex from %s, requested from %s", peerID, vdr)
 		}
@@ -416,10 +528,10 @@ func TestBootstrapperVertexDependencies(t *testing.T) {
 		*reqIDPtr = reqID
 	}
 
-	bs.Put(peerID, *reqIDPtr, vtxID1, vtxBytes1)
+	bs.MultiPut(peerID, *reqIDPtr, [][]byte{vtxBytes1})
 
 	state.parseVertex = nil
-	sender.GetF = nil
+	sender.GetAncestorsF = nil
 
 	if vtx0.Status() != choices.Unknown {
 		t.Fatalf("Vertex should be unknown")
@@ -463,7 +575,7 @@ func TestBootstrapperVertexDependencies(t *testing.T) {
 	finished := new(bool)
 	bs.onFinished = func() { *finished = true }
 
-	bs.Put(peerID, *reqIDPtr, vtxID0, vtxBytes0)
+	bs.MultiPut(peerID, *reqIDPtr, [][]byte{vtxBytes0})
 
 	state.parseVertex = nil
 	bs.onFinished = nil
@@ -551,7 +663,7 @@ func TestBootstrapperTxDependencies(t *testing.T) {
 	}
 
 	reqIDPtr := new(uint32)
-	sender.GetF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
+	sender.GetAncestorsF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
 		if !vdr.Equals(peerID) {
 			t.Fatalf("Should have requested vertex from %s, requested from %s", peerID, vdr)
 		}
@@ -567,7 +679,7 @@ func TestBootstrapperTxDependencies(t *testing.T) {
 	bs.ForceAccepted(acceptedIDs)
 
 	state.getVertex = nil
-	sender.GetF = nil
+	sender.GetAncestorsF = nil
 
 	state.parseVertex = func(vtxBytes []byte) (avalanche.Vertex, error) {
 		switch {
@@ -577,7 +689,7 @@ func TestBootstrapperTxDependencies(t *testing.T) {
 		t.Fatal(errParsedUnknownVertex)
 		return nil, errParsedUnknownVertex
 	}
-	sender.GetF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
+	sender.GetAncestorsF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
 		if !vdr.Equals(peerID) {
 			t.Fatalf("Should have requested vertex from %s, requested from %s", peerID, vdr)
 		}
@@ -590,10 +702,10 @@ func TestBootstrapperTxDependencies(t *testing.T) {
 		*reqIDPtr = reqID
 	}
 
-	bs.Put(peerID, *reqIDPtr, vtxID1, vtxBytes1)
+	bs.MultiPut(peerID, *reqIDPtr, [][]byte{vtxBytes1})
 
 	state.parseVertex = nil
-	sender.GetF = nil
+	sender.GetAncestorsF = nil
 
 	if tx0.Status() != choices.Processing {
 		t.Fatalf("Tx should be processing")
@@ -655,7 +767,7 @@ func TestBootstrapperTxDependencies(t *testing.T) {
 	finished := new(bool)
 	bs.onFinished = func() { *finished = true }
 
-	bs.Put(peerID, *reqIDPtr, vtxID0, vtxBytes0)
+	bs.MultiPut(peerID, *reqIDPtr, [][]byte{vtxBytes0})
 
 	state.parseVertex = nil
 	bs.onFinished = nil
@@ -747,7 +859,7 @@ func TestBootstrapperMissingTxDependency(t *testing.T) {
 	}
 
 	reqIDPtr := new(uint32)
-	sender.GetF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
+	sender.GetAncestorsF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
 		if !vdr.Equals(peerID) {
 			t.Fatalf("Should have requested vertex from %s, requested from %s", peerID, vdr)
 		}
@@ -763,7 +875,7 @@ func TestBootstrapperMissingTxDependency(t *testing.T) {
 	bs.ForceAccepted(acceptedIDs)
 
 	state.getVertex = nil
-	sender.GetF = nil
+	sender.GetAncestorsF = nil
 
 	state.parseVertex = func(vtxBytes []byte) (avalanche.Vertex, error) {
 		switch {
@@ -773,7 +885,7 @@ func TestBootstrapperMissingTxDependency(t *testing.T) {
 		t.Fatal(errParsedUnknownVertex)
 		return nil, errParsedUnknownVertex
 	}
-	sender.GetF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
+	sender.GetAncestorsF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
 		if !vdr.Equals(peerID) {
 			t.Fatalf("Should have requested vertex from %s, requested from %s", peerID, vdr)
 		}
@@ -786,10 +898,10 @@ func TestBootstrapperMissingTxDependency(t *testing.T) {
 		*reqIDPtr = reqID
 	}
 
-	bs.Put(peerID, *reqIDPtr, vtxID1, vtxBytes1)
+	bs.MultiPut(peerID, *reqIDPtr, [][]byte{vtxBytes1})
 
 	state.parseVertex = nil
-	sender.GetF = nil
+	sender.GetAncestorsF = nil
 
 	if tx0.Status() != choices.Unknown {
 		t.Fatalf("Tx should be unknown")
@@ -847,7 +959,7 @@ func TestBootstrapperMissingTxDependency(t *testing.T) {
 	finished := new(bool)
 	bs.onFinished = func() { *finished = true }
 
-	bs.Put(peerID, *reqIDPtr, vtxID0, vtxBytes0)
+	bs.MultiPut(peerID, *reqIDPtr, [][]byte{vtxBytes0})
 
 	state.parseVertex = nil
 	bs.onFinished = nil
@@ -995,7 +1107,7 @@ func TestBootstrapperPartialFetch(t *testing.T) {
 		}
 	}
 
-	sender.CantGet = false
+	sender.CantGetAncestors = false
 
 	bs.ForceAccepted(acceptedIDs)
 
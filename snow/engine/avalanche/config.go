@@ -5,6 +5,7 @@ package avalanche
 
 import (
 	"github.com/ava-labs/gecko/snow/consensus/avalanche"
+	"github.com/ava-labs/gecko/snow/engine/common"
 )
 
 // Config wraps all the parameters needed for an avalanche engine
@@ -13,4 +14,7 @@ type Config struct {
 
 	Params    avalanche.Parameters
 	Consensus avalanche.Consensus
+
+	// Trace records the polls of this engine. If nil, nothing is recorded.
+	Trace *common.Trace
 }
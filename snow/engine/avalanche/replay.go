@@ -0,0 +1,78 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avalanche
+
+import (
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/consensus/avalanche"
+	"github.com/ava-labs/gecko/snow/engine/common"
+)
+
+// Replay reproduces the consensus state of a traced engine by issuing the
+// vertices, and recording the polls, of [events] into [consensus]. The
+// vertices are fetched from [state]. [events] must include the start of
+// consensus, or a snapshot of it. When replaying from a snapshot, the
+// confidence consensus had in the processing vertices before the snapshot
+// isn't reproduced.
+func Replay(ctx *snow.Context, params avalanche.Parameters, consensus avalanche.Consensus, state State, events []common.TraceEvent) error {
+	events, err := common.TraceSinceStart(events)
+	if err != nil {
+		return err
+	}
+
+	for i, event := range events {
+		switch event.Kind {
+		case common.TraceStart:
+			frontier, err := replayVertices(state, event.Containers)
+			if err != nil {
+				return err
+			}
+			consensus.Initialize(ctx, params, frontier)
+		case common.TraceSnapshot:
+			// Later snapshots describe states that were already replayed
+			if i != 0 {
+				continue
+			}
+			frontier, err := replayVertices(state, event.Containers)
+			if err != nil {
+				return err
+			}
+			consensus.Initialize(ctx, params, frontier)
+			processing, err := replayVertices(state, event.Processing)
+			if err != nil {
+				return err
+			}
+			for _, vtx := range processing {
+				consensus.Add(vtx)
+			}
+		case common.TraceIssue:
+			vtx, err := state.GetVertex(event.Containers[0])
+			if err != nil {
+				return err
+			}
+			consensus.Add(vtx)
+		case common.TraceRecordPoll:
+			votes := ids.UniqueBag{}
+			for _, vote := range event.Votes {
+				votes.UnionSet(vote.ID, vote.Voters)
+			}
+			consensus.RecordPoll(votes)
+		}
+	}
+	return nil
+}
+
+// replayVertices fetches the vertices with IDs [vtxIDs] from [state]
+func replayVertices(state State, vtxIDs []ids.ID) ([]avalanche.Vertex, error) {
+	vtxs := []avalanche.Vertex(nil)
+	for _, vtxID := range vtxIDs {
+		vtx, err := state.GetVertex(vtxID)
+		if err != nil {
+			return nil, err
+		}
+		vtxs = append(vtxs, vtx)
+	}
+	return vtxs, nil
+}
@@ -6,12 +6,15 @@ package avalanche
 import (
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
 	"github.com/ava-labs/gecko/snow/consensus/avalanche"
 	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
 	"github.com/ava-labs/gecko/snow/engine/common"
 	"github.com/ava-labs/gecko/snow/events"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
 	"github.com/ava-labs/gecko/utils/formatting"
 	"github.com/ava-labs/gecko/utils/random"
+	"github.com/ava-labs/gecko/utils/wrappers"
 )
 
 // Transitive implements the Engine interface by attempting to fetch all
@@ -59,6 +62,7 @@ func (t *Transitive) finishBootstrapping() {
 		}
 	}
 	t.Consensus.Initialize(t.Config.Context, t.Params, frontier)
+	t.Config.Trace.Start(t.Config.State.Edge())
 	t.bootstrapped = true
 }
 
@@ -84,7 +88,8 @@ func (t *Transitive) Put(vdr ids.ShortID, requestID uint32, vtxID ids.ID, vtxByt
 	t.Config.Context.Log.Verbo("Put called for vertexID %s", vtxID)
 
 	if !t.bootstrapped {
-		t.bootstrapper.Put(vdr, requestID, vtxID, vtxBytes)
+		// Peers that can't send ancestors are asked for the vertex alone
+		t.bootstrapper.MultiPut(vdr, requestID, [][]byte{vtxBytes})
 		return
 	}
 
@@ -93,6 +98,7 @@ func (t *Transitive) Put(vdr ids.ShortID, requestID uint32, vtxID ids.ID, vtxByt
 		t.Config.Context.Log.Warn("ParseVertex failed due to %s for block:\n%s",
 			err,
 			formatting.DumpBytes{Bytes: vtxBytes})
+		t.Config.Context.Reputation.Report(vdr, reputation.InvalidContainer)
 		t.GetFailed(vdr, requestID, vtxID)
 		return
 	}
@@ -102,7 +108,8 @@ func (t *Transitive) Put(vdr ids.ShortID, requestID uint32, vtxID ids.ID, vtxByt
 // GetFailed implements the Engine interface
 func (t *Transitive) GetFailed(vdr ids.ShortID, requestID uint32, vtxID ids.ID) {
 	if !t.bootstrapped {
-		t.bootstrapper.GetFailed(vdr, requestID, vtxID)
+		// Peers that can't send ancestors are asked for the vertex alone
+		t.bootstrapper.GetAncestorsFailed(vdr, requestID)
 		return
 	}
 
@@ -123,6 +130,61 @@ func (t *Transitive) GetFailed(vdr ids.ShortID, requestID uint32, vtxID ids.ID)
 	t.numBlockedVtx.Set(float64(t.pending.Len()))
 }
 
+// GetAncestors implements the Engine interface
+func (t *Transitive) GetAncestors(vdr ids.ShortID, requestID uint32, vtxID ids.ID) {
+	vtx, err := t.Config.State.GetVertex(vtxID)
+	if err != nil {
+		t.Config.Context.Log.Debug("Dropping GetAncestors for unknown vertex %s", vtxID)
+		return
+	}
+
+	// Send the vertex and its ancestors in breadth first order, so that every
+	// vertex after the first is a parent of a vertex before it
+	queue := []avalanche.Vertex{vtx}
+	queued := ids.Set{}
+	queued.Add(vtxID)
+
+	ancestorsBytes := [][]byte(nil)
+	ancestorsBytesLen := 0
+	for len(queue) > 0 && len(ancestorsBytes) < common.MaxContainersPerMultiPut {
+		vtx := queue[0]
+		queue = queue[1:]
+
+		vtxBytes := vtx.Bytes()
+		if ancestorsBytesLen += len(vtxBytes) + wrappers.IntLen; ancestorsBytesLen > common.MaxContainersLen {
+			break
+		}
+		ancestorsBytes = append(ancestorsBytes, vtxBytes)
+
+		for _, parent := range vtx.Parents() {
+			if parentID := parent.ID(); parent.Status() != choices.Unknown && !queued.Contains(parentID) {
+				queued.Add(parentID)
+				queue = append(queue, parent)
+			}
+		}
+	}
+
+	t.Config.Sender.MultiPut(vdr, requestID, ancestorsBytes)
+}
+
+// MultiPut implements the Engine interface
+func (t *Transitive) MultiPut(vdr ids.ShortID, requestID uint32, vtxs [][]byte) {
+	if t.bootstrapped {
+		t.Config.Context.Log.Debug("Dropping MultiPut from %s as bootstrapping finished", vdr)
+		return
+	}
+	t.bootstrapper.MultiPut(vdr, requestID, vtxs)
+}
+
+// GetAncestorsFailed implements the Engine interface
+func (t *Transitive) GetAncestorsFailed(vdr ids.ShortID, requestID uint32) {
+	if t.bootstrapped {
+		t.Config.Context.Log.Debug("Dropping GetAncestorsFailed from %s as bootstrapping finished", vdr)
+		return
+	}
+	t.bootstrapper.GetAncestorsFailed(vdr, requestID)
+}
+
 // PullQuery implements the Engine interface
 func (t *Transitive) PullQuery(vdr ids.ShortID, requestID uint32, vtxID ids.ID) {
 	if !t.bootstrapped {
@@ -162,6 +224,12 @@ func (t *Transitive) Chits(vdr ids.ShortID, requestID uint32, votes ids.Set) {
 		return
 	}
 
+	if votes.Len() == 0 {
+		t.Config.Trace.QueryFailed(requestID, vdr)
+	} else {
+		t.Config.Trace.Chits(requestID, vdr, votes.List())
+	}
+
 	v := &voter{
 		t:         t,
 		vdr:       vdr,
@@ -183,6 +251,34 @@ func (t *Transitive) QueryFailed(vdr ids.ShortID, requestID uint32) {
 	t.Chits(vdr, requestID, ids.Set{})
 }
 
+// GetStateSummary implements the Engine interface. Avalanche chains can't be
+// state synced, so the request is dropped.
+func (t *Transitive) GetStateSummary(vdr ids.ShortID, requestID uint32) {
+	t.Config.Context.Log.Debug("Dropping GetStateSummary from %s as state sync isn't supported", vdr)
+}
+
+// StateSummary implements the Engine interface
+func (t *Transitive) StateSummary(vdr ids.ShortID, requestID uint32, summary []byte) {
+	t.Config.Context.Log.Debug("Received a StateSummary message from %s unexpectedly", vdr)
+}
+
+// GetStateSummaryFailed implements the Engine interface
+func (t *Transitive) GetStateSummaryFailed(vdr ids.ShortID, requestID uint32) {}
+
+// GetStateChunk implements the Engine interface. Avalanche chains can't be
+// state synced, so the request is dropped.
+func (t *Transitive) GetStateChunk(vdr ids.ShortID, requestID uint32, summaryID ids.ID, index uint32) {
+	t.Config.Context.Log.Debug("Dropping GetStateChunk from %s as state sync isn't supported", vdr)
+}
+
+// StateChunk implements the Engine interface
+func (t *Transitive) StateChunk(vdr ids.ShortID, requestID uint32, chunk []byte) {
+	t.Config.Context.Log.Debug("Received a StateChunk message from %s unexpectedly", vdr)
+}
+
+// GetStateChunkFailed implements the Engine interface
+func (t *Transitive) GetStateChunkFailed(vdr ids.ShortID, requestID uint32) {}
+
 // Notify implements the Engine interface
 func (t *Transitive) Notify(msg common.Message) {
 	if !t.bootstrapped {
@@ -2284,7 +2284,7 @@ func TestEngineBootstrappingIntoConsensus(t *testing.T) {
 		panic("Unknown vertex requested")
 	}
 
-	sender.GetF = func(inVdr ids.ShortID, reqID uint32, vtxID ids.ID) {
+	sender.GetAncestorsF = func(inVdr ids.ShortID, reqID uint32, vtxID ids.ID) {
 		if !vdrID.Equals(inVdr) {
 			t.Fatalf("Asking wrong validator for vertex")
 		}
@@ -2297,7 +2297,7 @@ func TestEngineBootstrappingIntoConsensus(t *testing.T) {
 	te.Accepted(vdrID, *requestID, acceptedFrontier)
 
 	st.getVertex = nil
-	sender.GetF = nil
+	sender.GetAncestorsF = nil
 
 	vm.ParseTxF = func(b []byte) (snowstorm.Tx, error) {
 		switch {
@@ -2327,7 +2327,7 @@ func TestEngineBootstrappingIntoConsensus(t *testing.T) {
 		panic("Unknown bytes provided")
 	}
 
-	te.Put(vdrID, *requestID, vtxID0, vtxBytes0)
+	te.MultiPut(vdrID, *requestID, [][]byte{vtxBytes0})
 
 	vm.ParseTxF = nil
 	st.parseVertex = nil
@@ -2564,3 +2564,87 @@ func TestEnginePartiallyValidVertex(t *testing.T) {
 
 	te.insert(vtx)
 }
+
+func TestEngineGetAncestors(t *testing.T) {
+	config := DefaultConfig()
+
+	vdr := validators.GenerateRandomValidator(1)
+
+	vals := validators.NewSet()
+	config.Validators = vals
+
+	vals.Add(vdr)
+
+	sender := &common.SenderTest{}
+	sender.T = t
+	config.Sender = sender
+
+	sender.Default(true)
+	sender.CantGetAcceptedFrontier = false
+
+	st := &stateTest{t: t}
+	config.State = st
+
+	st.Default(true)
+
+	st.cantEdge = false
+
+	te := &Transitive{}
+	te.Initialize(config)
+	te.finishBootstrapping()
+
+	vtx0 := &Vtx{
+		parents: []avalanche.Vertex{
+			&Vtx{
+				id:     GenerateID(),
+				status: choices.Unknown,
+			},
+		},
+		id:     GenerateID(),
+		status: choices.Accepted,
+		bytes:  []byte{0},
+	}
+	vtx1 := &Vtx{
+		parents: []avalanche.Vertex{vtx0},
+		id:      GenerateID(),
+		status:  choices.Accepted,
+		bytes:   []byte{1},
+	}
+	vtx2 := &Vtx{
+		parents: []avalanche.Vertex{vtx1, vtx0},
+		id:      GenerateID(),
+		status:  choices.Processing,
+		bytes:   []byte{2},
+	}
+
+	st.getVertex = func(vtxID ids.ID) (avalanche.Vertex, error) {
+		if !vtxID.Equals(vtx2.ID()) {
+			t.Fatalf("Wrong vertex requested")
+		}
+		return vtx2, nil
+	}
+
+	sent := new(bool)
+	sender.MultiPutF = func(inVdr ids.ShortID, requestID uint32, vtxs [][]byte) {
+		*sent = true
+		if !inVdr.Equals(vdr.ID()) {
+			t.Fatalf("Sent to the wrong validator")
+		}
+		// Each vertex is sent once, and the unknown vertex isn't sent
+		expected := [][]byte{vtx2.Bytes(), vtx1.Bytes(), vtx0.Bytes()}
+		if len(vtxs) != len(expected) {
+			t.Fatalf("Should have sent %d vertices, sent %d", len(expected), len(vtxs))
+		}
+		for i, vtxBytes := range vtxs {
+			if !bytes.Equal(vtxBytes, expected[i]) {
+				t.Fatalf("Sent the wrong vertex at index %d", i)
+			}
+		}
+	}
+
+	te.GetAncestors(vdr.ID(), 0, vtx2.ID())
+
+	if !*sent {
+		t.Fatalf("Should have responded with the vertex's ancestors")
+	}
+}
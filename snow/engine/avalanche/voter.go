@@ -7,6 +7,7 @@ import (
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/snow/consensus/avalanche"
 	"github.com/ava-labs/gecko/snow/consensus/snowstorm"
+	"github.com/ava-labs/gecko/snow/engine/common"
 )
 
 type voter struct {
@@ -39,6 +40,10 @@ func (v *voter) Update() {
 
 	v.t.Config.Context.Log.Debug("Finishing poll with:\n%s", &results)
 	v.t.Consensus.RecordPoll(results)
+	v.t.Config.Trace.RecordPoll(v.requestID, common.UniqueBagVotes(results), v.t.Consensus.Preferences().List())
+	if v.t.Config.Trace.SnapshotDue() {
+		v.t.Config.Trace.Snapshot(v.t.Config.State.Edge(), v.t.Consensus)
+	}
 
 	txs := []snowstorm.Tx(nil)
 	for _, orphanID := range v.t.Consensus.Orphans().List() {
@@ -0,0 +1,185 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package common
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/utils/timer"
+)
+
+// Phases of bootstrapping
+const (
+	PhaseFrontier  = "frontier"  // agreeing on the accepted frontier
+	PhaseStateSync = "stateSync" // downloading the state of the VM
+	PhaseFetching  = "fetching"  // fetching the containers to accept
+	PhaseExecuting = "executing" // executing the fetched containers
+	PhaseFinished  = "finished"  // bootstrapped
+)
+
+// BootstrapStatus describes the progress of bootstrapping a chain
+type BootstrapStatus struct {
+	Phase string
+
+	// Resumed is the number of containers fetching resumed from after a
+	// restart
+	Resumed int
+
+	// Fetched is the number of containers fetched since the node started.
+	// Executed is the number of containers executed since the node started.
+	// Queued is the number of fetched containers waiting to be executed,
+	// including the containers fetched before a restart.
+	Fetched, Executed, Queued uint64
+
+	// Pending is the number of outstanding requests for containers
+	Pending int
+
+	// Elapsed is the time since bootstrapping started. ETA is the estimated
+	// time until the queued containers are executed, or zero if it can't be
+	// estimated yet.
+	Elapsed, ETA time.Duration
+
+	Peers []BootstrapPeer
+}
+
+// BootstrapPeer describes the containers requested from a peer while
+// bootstrapping
+type BootstrapPeer struct {
+	ID                  ids.ShortID
+	Requested, Received uint64
+}
+
+// BootstrapProgress tracks the progress of bootstrapping a chain. It is safe
+// to read the status while the chain is bootstrapping.
+type BootstrapProgress struct {
+	lock  sync.Mutex
+	clock timer.Clock
+
+	phase                     string
+	resumed                   int
+	fetched, executed, queued uint64
+	pending                   int
+	started, executing        time.Time
+	peers                     map[[20]byte]*BootstrapPeer
+}
+
+// Initialize the progress of a chain that starts bootstrapping with [queued]
+// containers left from a previous run
+func (p *BootstrapProgress) Initialize(queued uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.phase = PhaseFrontier
+	p.queued = queued
+	p.started = p.clock.Time()
+}
+
+// SetPhase marks the start of [phase]
+func (p *BootstrapProgress) SetPhase(phase string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.phase = phase
+	if phase == PhaseExecuting {
+		p.executing = p.clock.Time()
+	}
+}
+
+// Resume records that fetching resumed from [numResumed] containers
+func (p *BootstrapProgress) Resume(numResumed int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.resumed = numResumed
+}
+
+// Requested records a request for a container sent to [validatorID], while
+// [pending] requests are outstanding
+func (p *BootstrapProgress) Requested(validatorID ids.ShortID, pending int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.peer(validatorID).Requested++
+	p.pending = pending
+}
+
+// Received records a container received from [validatorID]
+func (p *BootstrapProgress) Received(validatorID ids.ShortID) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.peer(validatorID).Received++
+}
+
+// Fetched records that a container was queued for execution, while [pending]
+// requests are outstanding
+func (p *BootstrapProgress) Fetched(pending int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.fetched++
+	p.queued++
+	p.pending = pending
+}
+
+// Executed records that a queued container was executed
+func (p *BootstrapProgress) Executed() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.executed++
+	if p.queued > 0 {
+		p.queued--
+	}
+}
+
+// Status returns the current progress
+func (p *BootstrapProgress) Status() BootstrapStatus {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := p.clock.Time()
+	status := BootstrapStatus{
+		Phase:    p.phase,
+		Resumed:  p.resumed,
+		Fetched:  p.fetched,
+		Executed: p.executed,
+		Queued:   p.queued,
+		Pending:  p.pending,
+		Peers:    make([]BootstrapPeer, 0, len(p.peers)),
+	}
+	if !p.started.IsZero() {
+		status.Elapsed = now.Sub(p.started)
+	}
+	// Only the execution rate is known in advance of finishing, as the
+	// number of containers left to fetch isn't known
+	if p.phase == PhaseExecuting && p.executed > 0 {
+		perContainer := now.Sub(p.executing) / time.Duration(p.executed)
+		status.ETA = perContainer * time.Duration(p.queued)
+	}
+	for _, peer := range p.peers {
+		status.Peers = append(status.Peers, *peer)
+	}
+	sort.Slice(status.Peers, func(i, j int) bool {
+		return status.Peers[i].Received > status.Peers[j].Received
+	})
+	return status
+}
+
+// peer returns the statistics of [validatorID]. Assumes the lock is held.
+func (p *BootstrapProgress) peer(validatorID ids.ShortID) *BootstrapPeer {
+	if p.peers == nil {
+		p.peers = make(map[[20]byte]*BootstrapPeer)
+	}
+	key := validatorID.Key()
+	peer, exists := p.peers[key]
+	if !exists {
+		peer = &BootstrapPeer{ID: validatorID}
+		p.peers[key] = peer
+	}
+	return peer
+}
@@ -21,6 +21,9 @@ type Bootstrapper struct {
 	acceptedVotes   map[[32]byte]uint64
 
 	RequestID uint32
+
+	// Progress of bootstrapping, which may be read by other goroutines
+	Progress BootstrapProgress
 }
 
 // Initialize implements the Engine interface.
@@ -29,6 +29,7 @@ type ExternalHandler interface {
 	AcceptedHandler
 	FetchHandler
 	QueryHandler
+	StateSyncHandler
 }
 
 // FrontierHandler defines how a consensus engine reacts to frontier messages
@@ -80,6 +81,20 @@ type FetchHandler interface {
 
 	// Notify this engine that a get request it issued has failed.
 	GetFailed(validatorID ids.ShortID, requestID uint32, containerID ids.ID)
+
+	// GetAncestors notifies this consensus engine that the specified validator
+	// requested that this engine send the specified container and as many of
+	// its ancestors as fit in a MultiPut message
+	GetAncestors(validatorID ids.ShortID, requestID uint32, containerID ids.ID)
+
+	// MultiPut notifies this consensus engine of containers sent by the
+	// specified validator in response to a GetAncestors request. The first
+	// container is the requested container, and each following container is
+	// an ancestor of a container before it.
+	MultiPut(validatorID ids.ShortID, requestID uint32, containers [][]byte)
+
+	// Notify this engine that a GetAncestors request it issued has failed.
+	GetAncestorsFailed(validatorID ids.ShortID, requestID uint32)
 }
 
 // QueryHandler defines how a consensus engine reacts to query messages from
@@ -106,6 +121,35 @@ type QueryHandler interface {
 	QueryFailed(validatorID ids.ShortID, requestID uint32)
 }
 
+// StateSyncHandler defines how a consensus engine reacts to state sync
+// messages from other validators
+type StateSyncHandler interface {
+	// GetStateSummary notifies this consensus engine that a summary of its
+	// state is requested by the specified validator
+	GetStateSummary(validatorID ids.ShortID, requestID uint32)
+
+	// StateSummary notifies this consensus engine of the summary of the
+	// specified validator's state
+	StateSummary(validatorID ids.ShortID, requestID uint32, summary []byte)
+
+	// GetStateSummaryFailed notifies this consensus engine that the requested
+	// state summary from the specified validator should be considered lost
+	GetStateSummaryFailed(validatorID ids.ShortID, requestID uint32)
+
+	// GetStateChunk notifies this consensus engine that the specified
+	// validator requested the chunk at [index] of the state committed to by
+	// the summary with ID [summaryID]
+	GetStateChunk(validatorID ids.ShortID, requestID uint32, summaryID ids.ID, index uint32)
+
+	// StateChunk notifies this consensus engine of a chunk of state sent by
+	// the specified validator
+	StateChunk(validatorID ids.ShortID, requestID uint32, chunk []byte)
+
+	// GetStateChunkFailed notifies this consensus engine that the requested
+	// state chunk from the specified validator should be considered lost
+	GetStateChunkFailed(validatorID ids.ShortID, requestID uint32)
+}
+
 // InternalHandler defines how this consensus engine reacts to messages from
 // other components of this validator
 type InternalHandler interface {
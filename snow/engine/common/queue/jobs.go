@@ -46,10 +46,25 @@ func (j *Jobs) SetParser(parser Parser) { j.parser = parser }
 
 // Push ...
 func (j *Jobs) Push(job Job) error {
+	if has, err := j.state.HasJob(j.db, job.ID()); err != nil {
+		return err
+	} else if has {
+		return errDuplicate
+	}
+
 	if deps := job.MissingDependencies(); deps.Len() != 0 {
-		return j.block(job, deps)
+		if err := j.block(job, deps); err != nil {
+			return err
+		}
+	} else if err := j.push(job); err != nil {
+		return err
+	}
+
+	numJobs, err := j.state.NumJobs(j.db)
+	if err != nil {
+		return err
 	}
-	return j.push(job)
+	return j.state.SetNumJobs(j.db, numJobs+1)
 }
 
 // Pop ...
@@ -64,10 +79,20 @@ func (j *Jobs) Pop() (Job, error) {
 	if err := j.state.SetStackSize(j.db, size-1); err != nil {
 		return nil, err
 	}
+	if numJobs, err := j.state.NumJobs(j.db); err != nil {
+		return nil, err
+	} else if numJobs > 0 {
+		if err := j.state.SetNumJobs(j.db, numJobs-1); err != nil {
+			return nil, err
+		}
+	}
 	job, err := j.state.StackIndex(j.db, size-1)
 	if err != nil {
 		return nil, err
 	}
+	if err := j.state.DeleteJob(j.db, job.ID()); err != nil {
+		return nil, err
+	}
 	return job, j.state.DeleteStackIndex(j.db, size-1)
 }
 
@@ -77,6 +102,21 @@ func (j *Jobs) HasNext() (bool, error) {
 	return size > 0, err
 }
 
+// Has returns true if the job with ID [jobID] was pushed and hasn't been
+// popped
+func (j *Jobs) Has(jobID ids.ID) (bool, error) { return j.state.HasJob(j.db, jobID) }
+
+// Len returns the number of jobs that were pushed and haven't been popped,
+// including the jobs that are blocked
+func (j *Jobs) Len() (uint32, error) { return j.state.NumJobs(j.db) }
+
+// SetPending records the IDs of the containers being fetched, so that fetching
+// can resume from them after a restart
+func (j *Jobs) SetPending(pendingIDs ids.Set) error { return j.state.SetPending(j.db, pendingIDs) }
+
+// Pending returns the IDs last recorded by SetPending
+func (j *Jobs) Pending() (ids.Set, error) { return j.state.Pending(j.db) }
+
 // Execute ...
 func (j *Jobs) Execute(job Job) error {
 	job.Execute()
@@ -116,7 +116,7 @@ func TestExecute(t *testing.T) {
 		BytesF:               func() []byte { return []byte{0} },
 	}
 
-	id1 := ids.Empty.Prefix(0)
+	id1 := ids.Empty.Prefix(1)
 	executed1 := new(bool)
 	job1 := &TestJob{
 		T: t,
@@ -250,3 +250,63 @@ func TestDuplicatedPush(t *testing.T) {
 		t.Fatalf("Shouldn't have a container ready to pop")
 	}
 }
+
+func TestPendingPersists(t *testing.T) {
+	parser := &TestParser{T: t}
+	db := memdb.New()
+
+	jobs, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jobs.SetParser(parser)
+
+	id0 := ids.Empty.Prefix(0)
+	id1 := ids.Empty.Prefix(1)
+	job := &TestJob{
+		T: t,
+
+		IDF:                  func() ids.ID { return id1 },
+		MissingDependenciesF: func() ids.Set { return ids.Set{id0.Key(): true} },
+		ExecuteF:             func() {},
+		BytesF:               func() []byte { return []byte{1} },
+	}
+
+	if err := jobs.Push(job); err != nil {
+		t.Fatal(err)
+	}
+
+	pending := ids.Set{}
+	pending.Add(id0)
+	if err := jobs.SetPending(pending); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := jobs.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err = New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if numJobs, err := jobs.Len(); err != nil {
+		t.Fatal(err)
+	} else if numJobs != 1 {
+		t.Fatalf("Should have had 1 job, had %d", numJobs)
+	}
+
+	if has, err := jobs.Has(id1); err != nil {
+		t.Fatal(err)
+	} else if !has {
+		t.Fatalf("Should have had the blocked job")
+	}
+
+	if pendingIDs, err := jobs.Pending(); err != nil {
+		t.Fatal(err)
+	} else if !pendingIDs.Equals(pending) {
+		t.Fatalf("Returned the wrong pending IDs")
+	}
+}
@@ -16,10 +16,14 @@ const (
 	stackID
 	jobID
 	blockingID
+	pendingID
+	numJobsID
 )
 
 var (
 	stackSize = []byte{stackSizeID}
+	pending   = []byte{pendingID}
+	numJobs   = []byte{numJobsID}
 )
 
 type prefixedState struct{ state }
@@ -32,6 +36,30 @@ func (ps *prefixedState) StackSize(db database.Database) (uint32, error) {
 	return ps.state.Int(db, stackSize)
 }
 
+func (ps *prefixedState) SetNumJobs(db database.Database, size uint32) error {
+	return ps.state.SetInt(db, numJobs, size)
+}
+
+func (ps *prefixedState) NumJobs(db database.Database) (uint32, error) {
+	size, err := ps.state.Int(db, numJobs)
+	if err == database.ErrNotFound {
+		return 0, nil
+	}
+	return size, err
+}
+
+func (ps *prefixedState) SetPending(db database.Database, pendingIDs ids.Set) error {
+	return ps.state.SetIDs(db, pending, pendingIDs)
+}
+
+func (ps *prefixedState) Pending(db database.Database) (ids.Set, error) {
+	pendingIDs, err := ps.state.IDs(db, pending)
+	if err == database.ErrNotFound {
+		return ids.Set{}, nil
+	}
+	return pendingIDs, err
+}
+
 func (ps *prefixedState) SetStackIndex(db database.Database, index uint32, job Job) error {
 	p := wrappers.Packer{Bytes: make([]byte, 1+wrappers.IntLen)}
 
@@ -0,0 +1,91 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package common
+
+import (
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/validators"
+)
+
+const (
+	// MaxContainersPerMultiPut is the maximum number of containers sent in
+	// response to a GetAncestors message
+	MaxContainersPerMultiPut = 2000
+
+	// MaxContainersLen is the maximum number of bytes of containers, including
+	// their length prefixes, sent in response to a GetAncestors message. This
+	// is well below the maximum message size, so the response always fits.
+	MaxContainersLen = 2 * 1024 * 1024
+
+	// NumFetchCandidates is the number of validators sampled when choosing
+	// which validator to request containers from
+	NumFetchCandidates = 16
+)
+
+// request is an outstanding request for a container
+type request struct {
+	validatorID ids.ShortID
+	containerID ids.ID
+}
+
+// Requests tracks the outstanding requests for containers, and the number of
+// requests outstanding with each validator, so that requests can be spread
+// across the validators
+type Requests struct {
+	requests map[uint32]request
+	load     map[[20]byte]int
+}
+
+// Add records that the container with ID [containerID] was requested from
+// [validatorID] with request ID [requestID]
+func (r *Requests) Add(validatorID ids.ShortID, requestID uint32, containerID ids.ID) {
+	if r.requests == nil {
+		r.requests = make(map[uint32]request)
+		r.load = make(map[[20]byte]int)
+	}
+	r.requests[requestID] = request{
+		validatorID: validatorID,
+		containerID: containerID,
+	}
+	r.load[validatorID.Key()]++
+}
+
+// Remove the request with ID [requestID] sent to [validatorID], and return the
+// ID of the container that was requested. Returns false if no such request is
+// outstanding.
+func (r *Requests) Remove(validatorID ids.ShortID, requestID uint32) (ids.ID, bool) {
+	req, ok := r.requests[requestID]
+	if !ok || !req.validatorID.Equals(validatorID) {
+		return ids.ID{}, false
+	}
+	delete(r.requests, requestID)
+
+	key := validatorID.Key()
+	if r.load[key]--; r.load[key] == 0 {
+		delete(r.load, key)
+	}
+	return req.containerID, true
+}
+
+// Len returns the number of outstanding requests
+func (r *Requests) Len() int { return len(r.requests) }
+
+// Outstanding returns the number of requests outstanding with [validatorID]
+func (r *Requests) Outstanding(validatorID ids.ShortID) int { return r.load[validatorID.Key()] }
+
+// LeastLoaded returns the validator in [candidates] with the fewest outstanding
+// requests, preferring validators earlier in [candidates] when tied. Returns
+// false if there are no candidates.
+func (r *Requests) LeastLoaded(candidates []validators.Validator) (ids.ShortID, bool) {
+	best := ids.ShortID{}
+	bestLoad := -1
+	for _, vdr := range candidates {
+		vdrID := vdr.ID()
+		if load := r.Outstanding(vdrID); bestLoad == -1 || load < bestLoad {
+			best = vdrID
+			bestLoad = load
+		}
+	}
+	return best, bestLoad != -1
+}
@@ -14,6 +14,7 @@ type Sender interface {
 	AcceptedSender
 	FetchSender
 	QuerySender
+	StateSyncSender
 }
 
 // FrontierSender defines how a consensus engine sends frontier messages to
@@ -52,6 +53,14 @@ type FetchSender interface {
 	// Tell the specified validator that the container whose ID is <containerID>
 	// has body <container>
 	Put(validatorID ids.ShortID, requestID uint32, containerID ids.ID, container []byte)
+
+	// GetAncestors requests that the specified validator send the specified
+	// container and as many of its ancestors as fit in a MultiPut message
+	GetAncestors(validatorID ids.ShortID, requestID uint32, containerID ids.ID)
+
+	// MultiPut responds to a GetAncestors message with a container and its
+	// ancestors
+	MultiPut(validatorID ids.ShortID, requestID uint32, containers [][]byte)
 }
 
 // QuerySender defines how a consensus engine sends query messages to other
@@ -70,3 +79,22 @@ type QuerySender interface {
 	// Chits sends chits to the specified validator
 	Chits(validatorID ids.ShortID, requestID uint32, votes ids.Set)
 }
+
+// StateSyncSender defines how a consensus engine sends state sync messages to
+// other validators
+type StateSyncSender interface {
+	// GetStateSummary requests that every validator in [validatorIDs] sends a
+	// StateSummary message.
+	GetStateSummary(validatorIDs ids.ShortSet, requestID uint32)
+
+	// StateSummary responds to a GetStateSummary message with a summary of
+	// this engine's state.
+	StateSummary(validatorID ids.ShortID, requestID uint32, summary []byte)
+
+	// GetStateChunk requests that the specified validator send the chunk at
+	// [index] of the state committed to by the summary with ID [summaryID].
+	GetStateChunk(validatorID ids.ShortID, requestID uint32, summaryID ids.ID, index uint32)
+
+	// StateChunk responds to a GetStateChunk message with a chunk of state.
+	StateChunk(validatorID ids.ShortID, requestID uint32, chunk []byte)
+}
@@ -32,19 +32,34 @@ type EngineTest struct {
 	CantGet,
 	CantGetFailed,
 	CantPut,
+	CantGetAncestors,
+	CantMultiPut,
+	CantGetAncestorsFailed,
 
 	CantPushQuery,
 	CantPullQuery,
 	CantQueryFailed,
-	CantChits bool
-
-	StartupF, ShutdownF                                                                func()
-	ContextF                                                                           func() *snow.Context
-	NotifyF                                                                            func(Message)
-	GetF, GetFailedF, PullQueryF                                                       func(validatorID ids.ShortID, requestID uint32, containerID ids.ID)
-	PutF, PushQueryF                                                                   func(validatorID ids.ShortID, requestID uint32, containerID ids.ID, container []byte)
-	GetAcceptedFrontierF, GetAcceptedFrontierFailedF, GetAcceptedFailedF, QueryFailedF func(validatorID ids.ShortID, requestID uint32)
-	AcceptedFrontierF, GetAcceptedF, AcceptedF, ChitsF                                 func(validatorID ids.ShortID, requestID uint32, containerIDs ids.Set)
+	CantChits,
+
+	CantGetStateSummary,
+	CantGetStateSummaryFailed,
+	CantStateSummary,
+
+	CantGetStateChunk,
+	CantGetStateChunkFailed,
+	CantStateChunk bool
+
+	StartupF, ShutdownF                                                                 func()
+	ContextF                                                                            func() *snow.Context
+	NotifyF                                                                             func(Message)
+	GetF, GetFailedF, PullQueryF, GetAncestorsF                                         func(validatorID ids.ShortID, requestID uint32, containerID ids.ID)
+	PutF, PushQueryF                                                                    func(validatorID ids.ShortID, requestID uint32, containerID ids.ID, container []byte)
+	GetAcceptedFrontierF, GetAcceptedFrontierFailedF, GetAcceptedFailedF, QueryFailedF  func(validatorID ids.ShortID, requestID uint32)
+	AcceptedFrontierF, GetAcceptedF, AcceptedF, ChitsF                                  func(validatorID ids.ShortID, requestID uint32, containerIDs ids.Set)
+	GetStateSummaryF, GetStateSummaryFailedF, GetStateChunkFailedF, GetAncestorsFailedF func(validatorID ids.ShortID, requestID uint32)
+	StateSummaryF, StateChunkF                                                          func(validatorID ids.ShortID, requestID uint32, bytes []byte)
+	GetStateChunkF                                                                      func(validatorID ids.ShortID, requestID uint32, summaryID ids.ID, index uint32)
+	MultiPutF                                                                           func(validatorID ids.ShortID, requestID uint32, containers [][]byte)
 }
 
 // Default ...
@@ -67,11 +82,22 @@ func (e *EngineTest) Default(cant bool) {
 	e.CantGet = cant
 	e.CantGetFailed = cant
 	e.CantPut = cant
+	e.CantGetAncestors = cant
+	e.CantMultiPut = cant
+	e.CantGetAncestorsFailed = cant
 
 	e.CantPushQuery = cant
 	e.CantPullQuery = cant
 	e.CantQueryFailed = cant
 	e.CantChits = cant
+
+	e.CantGetStateSummary = cant
+	e.CantGetStateSummaryFailed = cant
+	e.CantStateSummary = cant
+
+	e.CantGetStateChunk = cant
+	e.CantGetStateChunkFailed = cant
+	e.CantStateChunk = cant
 }
 
 // Startup ...
@@ -193,6 +219,33 @@ func (e *EngineTest) Put(validatorID ids.ShortID, requestID uint32, containerID
 	}
 }
 
+// GetAncestors ...
+func (e *EngineTest) GetAncestors(validatorID ids.ShortID, requestID uint32, containerID ids.ID) {
+	if e.GetAncestorsF != nil {
+		e.GetAncestorsF(validatorID, requestID, containerID)
+	} else if e.CantGetAncestors && e.T != nil {
+		e.T.Fatalf("Unexpectedly called GetAncestors")
+	}
+}
+
+// MultiPut ...
+func (e *EngineTest) MultiPut(validatorID ids.ShortID, requestID uint32, containers [][]byte) {
+	if e.MultiPutF != nil {
+		e.MultiPutF(validatorID, requestID, containers)
+	} else if e.CantMultiPut && e.T != nil {
+		e.T.Fatalf("Unexpectedly called MultiPut")
+	}
+}
+
+// GetAncestorsFailed ...
+func (e *EngineTest) GetAncestorsFailed(validatorID ids.ShortID, requestID uint32) {
+	if e.GetAncestorsFailedF != nil {
+		e.GetAncestorsFailedF(validatorID, requestID)
+	} else if e.CantGetAncestorsFailed && e.T != nil {
+		e.T.Fatalf("Unexpectedly called GetAncestorsFailed")
+	}
+}
+
 // PushQuery ...
 func (e *EngineTest) PushQuery(validatorID ids.ShortID, requestID uint32, containerID ids.ID, container []byte) {
 	if e.PushQueryF != nil {
@@ -228,3 +281,57 @@ func (e *EngineTest) Chits(validatorID ids.ShortID, requestID uint32, containerI
 		e.T.Fatalf("Unexpectedly called Chits")
 	}
 }
+
+// GetStateSummary ...
+func (e *EngineTest) GetStateSummary(validatorID ids.ShortID, requestID uint32) {
+	if e.GetStateSummaryF != nil {
+		e.GetStateSummaryF(validatorID, requestID)
+	} else if e.CantGetStateSummary && e.T != nil {
+		e.T.Fatalf("Unexpectedly called GetStateSummary")
+	}
+}
+
+// GetStateSummaryFailed ...
+func (e *EngineTest) GetStateSummaryFailed(validatorID ids.ShortID, requestID uint32) {
+	if e.GetStateSummaryFailedF != nil {
+		e.GetStateSummaryFailedF(validatorID, requestID)
+	} else if e.CantGetStateSummaryFailed && e.T != nil {
+		e.T.Fatalf("Unexpectedly called GetStateSummaryFailed")
+	}
+}
+
+// StateSummary ...
+func (e *EngineTest) StateSummary(validatorID ids.ShortID, requestID uint32, summary []byte) {
+	if e.StateSummaryF != nil {
+		e.StateSummaryF(validatorID, requestID, summary)
+	} else if e.CantStateSummary && e.T != nil {
+		e.T.Fatalf("Unexpectedly called StateSummary")
+	}
+}
+
+// GetStateChunk ...
+func (e *EngineTest) GetStateChunk(validatorID ids.ShortID, requestID uint32, summaryID ids.ID, index uint32) {
+	if e.GetStateChunkF != nil {
+		e.GetStateChunkF(validatorID, requestID, summaryID, index)
+	} else if e.CantGetStateChunk && e.T != nil {
+		e.T.Fatalf("Unexpectedly called GetStateChunk")
+	}
+}
+
+// GetStateChunkFailed ...
+func (e *EngineTest) GetStateChunkFailed(validatorID ids.ShortID, requestID uint32) {
+	if e.GetStateChunkFailedF != nil {
+		e.GetStateChunkFailedF(validatorID, requestID)
+	} else if e.CantGetStateChunkFailed && e.T != nil {
+		e.T.Fatalf("Unexpectedly called GetStateChunkFailed")
+	}
+}
+
+// StateChunk ...
+func (e *EngineTest) StateChunk(validatorID ids.ShortID, requestID uint32, chunk []byte) {
+	if e.StateChunkF != nil {
+		e.StateChunkF(validatorID, requestID, chunk)
+	} else if e.CantStateChunk && e.T != nil {
+		e.T.Fatalf("Unexpectedly called StateChunk")
+	}
+}
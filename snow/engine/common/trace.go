@@ -0,0 +1,326 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/utils/timer"
+)
+
+// Kinds of trace events
+const (
+	TraceStart       = "start"       // consensus started from the containers
+	TraceSnapshot    = "snapshot"    // consensus could be restarted from the containers
+	TracePoll        = "poll"        // a container was queried from the validators
+	TraceChits       = "chits"       // a validator responded to a query
+	TraceQueryFailed = "queryFailed" // a validator failed to respond to a query
+	TraceRecordPoll  = "recordPoll"  // a finished poll was recorded in consensus
+	TraceIssue       = "issue"       // a container was added to consensus
+	TraceAccept      = "accept"      // a container was accepted
+	TraceReject      = "reject"      // a container was rejected
+)
+
+var (
+	errNoTraceStart = errors.New("trace doesn't include the start of consensus, or a snapshot of it")
+)
+
+// TraceVote is the number of votes a container received. In Avalanche,
+// Voters is the set of the indices of the polled validators that voted for the
+// container.
+type TraceVote struct {
+	ID     ids.ID     `json:"id"`
+	Count  int        `json:"count"`
+	Voters ids.BitSet `json:"voters,omitempty"`
+}
+
+// TraceEvent is an event in the history of a chain's consensus. Only the fields
+// that apply to the kind of event are set.
+type TraceEvent struct {
+	Seq  uint64    `json:"seq"`
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+
+	RequestID  uint32        `json:"requestID,omitempty"`
+	Containers []ids.ID      `json:"containers,omitempty"`
+	Validator  *ids.ShortID  `json:"validator,omitempty"`
+	Validators []ids.ShortID `json:"validators,omitempty"`
+	Votes      []TraceVote   `json:"votes,omitempty"`
+
+	// Preferences are the preferences of consensus after a poll was
+	// recorded
+	Preferences []ids.ID `json:"preferences,omitempty"`
+
+	// Processing are the containers that were being decided when a snapshot
+	// was taken, in the order they were issued, and State describes the
+	// confidence of consensus in its decisions at that time
+	Processing []ids.ID `json:"processing,omitempty"`
+	State      string   `json:"state,omitempty"`
+}
+
+// Trace records the most recent consensus events of a chain in a ring buffer.
+// A nil *Trace records nothing. It is safe to read the events while the chain
+// is running.
+//
+// Since the start of consensus is eventually overwritten, the engine snapshots
+// consensus whenever half of the buffer was written since the last start or
+// snapshot, so that the buffer can always be replayed.
+type Trace struct {
+	lock  sync.Mutex
+	clock timer.Clock
+
+	events []TraceEvent
+	next   int    // index the next event is written to
+	seq    uint64 // sequence number of the next event
+
+	// Containers being decided --> sequence number of their issuance
+	processing map[[32]byte]uint64
+	// Number of events since the last start or snapshot
+	sinceSnapshot int
+}
+
+// Initialize the trace to hold the last [size] events
+func (t *Trace) Initialize(size int) {
+	t.events = make([]TraceEvent, 0, size)
+	t.processing = make(map[[32]byte]uint64)
+}
+
+// Start records that consensus started from [containerIDs]
+func (t *Trace) Start(containerIDs []ids.ID) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.processing = make(map[[32]byte]uint64)
+	t.addLocked(TraceEvent{
+		Kind:       TraceStart,
+		Containers: containerIDs,
+	})
+	t.sinceSnapshot = 0
+}
+
+// SnapshotDue returns true if consensus should be snapshotted, so that the
+// trace stays replayable
+func (t *Trace) SnapshotDue() bool {
+	if t == nil {
+		return false
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return cap(t.events) > 0 && t.sinceSnapshot >= cap(t.events)/2
+}
+
+// Snapshot records that consensus could be restarted from [containerIDs], the
+// last accepted containers, with the containers that are being decided. If
+// [consensus] describes itself, its description is recorded too.
+func (t *Trace) Snapshot(containerIDs []ids.ID, consensus interface{}) {
+	if t == nil {
+		return
+	}
+	event := TraceEvent{
+		Kind:       TraceSnapshot,
+		Containers: containerIDs,
+	}
+	if stringer, ok := consensus.(fmt.Stringer); ok {
+		event.State = stringer.String()
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	processing := make([]ids.ID, 0, len(t.processing))
+	for key := range t.processing {
+		processing = append(processing, ids.NewID(key))
+	}
+	sort.Slice(processing, func(i, j int) bool {
+		return t.processing[processing[i].Key()] < t.processing[processing[j].Key()]
+	})
+	event.Processing = processing
+
+	t.addLocked(event)
+	t.sinceSnapshot = 0
+}
+
+// Poll records that [containerID] was queried from [vdrs] with [requestID]
+func (t *Trace) Poll(requestID uint32, containerID ids.ID, vdrs ids.ShortSet) {
+	t.add(TraceEvent{
+		Kind:       TracePoll,
+		RequestID:  requestID,
+		Containers: []ids.ID{containerID},
+		Validators: vdrs.List(),
+	})
+}
+
+// Chits records that [vdr] voted for [votes] in response to [requestID]
+func (t *Trace) Chits(requestID uint32, vdr ids.ShortID, votes []ids.ID) {
+	t.add(TraceEvent{
+		Kind:       TraceChits,
+		RequestID:  requestID,
+		Containers: votes,
+		Validator:  &vdr,
+	})
+}
+
+// QueryFailed records that [vdr] failed to respond to [requestID]
+func (t *Trace) QueryFailed(requestID uint32, vdr ids.ShortID) {
+	t.add(TraceEvent{
+		Kind:      TraceQueryFailed,
+		RequestID: requestID,
+		Validator: &vdr,
+	})
+}
+
+// RecordPoll records that the poll [requestID] finished with [votes], after
+// which consensus preferred [preferences]. The state of consensus is only
+// described by snapshots, since describing it is expensive.
+func (t *Trace) RecordPoll(requestID uint32, votes []TraceVote, preferences []ids.ID) {
+	t.add(TraceEvent{
+		Kind:        TraceRecordPoll,
+		RequestID:   requestID,
+		Votes:       votes,
+		Preferences: preferences,
+	})
+}
+
+// Issue implements the triggers.Issuer interface
+func (t *Trace) Issue(chainID, containerID ids.ID, container []byte) error {
+	t.setProcessing(containerID, true)
+	t.add(TraceEvent{
+		Kind:       TraceIssue,
+		Containers: []ids.ID{containerID},
+	})
+	return nil
+}
+
+// Accept implements the triggers.Acceptor interface
+func (t *Trace) Accept(chainID, containerID ids.ID, container []byte) error {
+	t.setProcessing(containerID, false)
+	t.add(TraceEvent{
+		Kind:       TraceAccept,
+		Containers: []ids.ID{containerID},
+	})
+	return nil
+}
+
+// Reject implements the triggers.Rejector interface
+func (t *Trace) Reject(chainID, containerID ids.ID, container []byte) error {
+	t.setProcessing(containerID, false)
+	t.add(TraceEvent{
+		Kind:       TraceReject,
+		Containers: []ids.ID{containerID},
+	})
+	return nil
+}
+
+// setProcessing marks [containerID] as being decided if [processing], and as
+// decided otherwise
+func (t *Trace) setProcessing(containerID ids.ID, processing bool) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	switch {
+	case cap(t.events) == 0:
+	case processing:
+		t.processing[containerID.Key()] = t.seq
+	default:
+		delete(t.processing, containerID.Key())
+	}
+}
+
+// Events returns the recorded events, oldest first
+func (t *Trace) Events() []TraceEvent {
+	if t == nil {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	events := make([]TraceEvent, 0, len(t.events))
+	events = append(events, t.events[t.next:]...)
+	return append(events, t.events[:t.next]...)
+}
+
+func (t *Trace) add(event TraceEvent) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.addLocked(event)
+}
+
+// addLocked assumes the lock is held
+func (t *Trace) addLocked(event TraceEvent) {
+	if cap(t.events) == 0 {
+		return
+	}
+
+	event.Seq = t.seq
+	event.Time = t.clock.Time()
+	t.seq++
+	t.sinceSnapshot++
+
+	if len(t.events) < cap(t.events) {
+		t.events = append(t.events, event)
+		return
+	}
+	// The buffer is full, so the oldest event is overwritten
+	t.events[t.next] = event
+	t.next = (t.next + 1) % len(t.events)
+}
+
+// BagVotes returns the votes in [bag]
+func BagVotes(bag ids.Bag) []TraceVote {
+	votes := []TraceVote(nil)
+	for _, id := range bag.List() {
+		votes = append(votes, TraceVote{
+			ID:    id,
+			Count: bag.Count(id),
+		})
+	}
+	return votes
+}
+
+// UniqueBagVotes returns the votes in [bag]
+func UniqueBagVotes(bag ids.UniqueBag) []TraceVote {
+	votes := []TraceVote(nil)
+	for _, id := range bag.List() {
+		voters := bag.GetSet(id)
+		votes = append(votes, TraceVote{
+			ID:     id,
+			Count:  voters.Len(),
+			Voters: voters,
+		})
+	}
+	return votes
+}
+
+// TraceSinceStart returns the events of [events] from the start of consensus,
+// or the oldest snapshot of it, onwards. Errors if neither is in [events],
+// which happens if the trace wrapped around before consensus was snapshotted.
+func TraceSinceStart(events []TraceEvent) ([]TraceEvent, error) {
+	for i, event := range events {
+		if event.Kind == TraceStart || event.Kind == TraceSnapshot {
+			return events[i:], nil
+		}
+	}
+	return nil, errNoTraceStart
+}
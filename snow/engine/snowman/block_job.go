@@ -10,9 +10,11 @@ import (
 	"github.com/ava-labs/gecko/snow/choices"
 	"github.com/ava-labs/gecko/snow/consensus/snowman"
 	"github.com/ava-labs/gecko/snow/engine/common/queue"
+	"github.com/ava-labs/gecko/utils/logging"
 )
 
 type parser struct {
+	log                     logging.Logger
 	numAccepted, numDropped prometheus.Counter
 	vm                      ChainVM
 }
@@ -23,6 +25,7 @@ func (p *parser) Parse(blkBytes []byte) (queue.Job, error) {
 		return nil, err
 	}
 	return &blockJob{
+		log:         p.log,
 		numAccepted: p.numAccepted,
 		numDropped:  p.numDropped,
 		blk:         blk,
@@ -30,6 +33,7 @@ func (p *parser) Parse(blkBytes []byte) (queue.Job, error) {
 }
 
 type blockJob struct {
+	log                     logging.Logger
 	numAccepted, numDropped prometheus.Counter
 	blk                     snowman.Block
 }
@@ -51,7 +55,13 @@ func (b *blockJob) Execute() {
 	case choices.Unknown, choices.Rejected:
 		b.numDropped.Inc()
 	case choices.Processing:
-		b.blk.Verify()
+		// A block that fails verification must not be accepted, since the VM
+		// may not be able to apply it
+		if err := b.blk.Verify(); err != nil {
+			b.log.Error("Dropping block %s during bootstrapping due to %s", b.blk.ID(), err)
+			b.numDropped.Inc()
+			return
+		}
 		b.blk.Accept()
 		b.numAccepted.Inc()
 	}
@@ -9,10 +9,17 @@ import (
 	"github.com/ava-labs/gecko/snow/consensus/snowman"
 	"github.com/ava-labs/gecko/snow/engine/common"
 	"github.com/ava-labs/gecko/snow/engine/common/queue"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
 	"github.com/ava-labs/gecko/utils/formatting"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+const (
+	// commitInterval is the number of blocks executed between commits of the
+	// bootstrapping queue
+	commitInterval = 1024
+)
+
 // BootstrapConfig ...
 type BootstrapConfig struct {
 	common.Config
@@ -22,6 +29,11 @@ type BootstrapConfig struct {
 
 	VM ChainVM
 
+	// StateSync enables downloading the state of the VM from the beacons,
+	// rather than executing every block since genesis, if the VM is a
+	// StateSyncableVM
+	StateSync bool
+
 	Bootstrapped func()
 }
 
@@ -29,8 +41,12 @@ type bootstrapper struct {
 	BootstrapConfig
 	metrics
 	common.Bootstrapper
+	stateSyncer
+
+	// Blocks being fetched, and the outstanding requests for them
+	pending  ids.Set
+	requests common.Requests
 
-	pending    ids.Set
 	finished   bool
 	onFinished func()
 }
@@ -40,15 +56,32 @@ func (b *bootstrapper) Initialize(config BootstrapConfig) {
 	b.BootstrapConfig = config
 
 	b.Blocked.SetParser(&parser{
+		log:         b.BootstrapConfig.Context.Log,
 		numAccepted: b.numBootstrapped,
 		numDropped:  b.numDropped,
 		vm:          b.VM,
 	})
 
+	queued, err := b.Blocked.Len()
+	if err != nil {
+		b.BootstrapConfig.Context.Log.Error("Failed to read the bootstrapping queue due to %s", err)
+	}
+	b.numBlocked.Set(float64(queued))
+	b.Progress.Initialize(uint64(queued))
+
 	config.Bootstrapable = b
 	b.Bootstrapper.Initialize(config.Config)
 }
 
+// Startup implements the Engine interface.
+func (b *bootstrapper) Startup() {
+	if vm, ok := b.VM.(StateSyncableVM); ok && b.StateSync {
+		b.startStateSync(vm)
+		return
+	}
+	b.Bootstrapper.Startup()
+}
+
 // CurrentAcceptedFrontier ...
 func (b *bootstrapper) CurrentAcceptedFrontier() ids.Set {
 	acceptedFrontier := ids.Set{}
@@ -69,9 +102,22 @@ func (b *bootstrapper) FilterAccepted(containerIDs ids.Set) ids.Set {
 
 // ForceAccepted ...
 func (b *bootstrapper) ForceAccepted(acceptedContainerIDs ids.Set) {
-	for _, blkID := range acceptedContainerIDs.List() {
+	b.Progress.SetPhase(common.PhaseFetching)
+
+	// Resume fetching the blocks that were being fetched before a restart
+	pendingIDs, err := b.Blocked.Pending()
+	if err != nil {
+		b.BootstrapConfig.Context.Log.Warn("Failed to read the blocks being fetched before a restart due to %s", err)
+	} else if numResumed := pendingIDs.Len(); numResumed > 0 {
+		b.BootstrapConfig.Context.Log.Info("Bootstrapping resumed fetching %d blocks", numResumed)
+		b.Progress.Resume(numResumed)
+	}
+	pendingIDs.Union(acceptedContainerIDs)
+
+	for _, blkID := range pendingIDs.List() {
 		b.fetch(blkID)
 	}
+	b.persist()
 
 	if numPending := b.pending.Len(); numPending == 0 {
 		// TODO: This typically indicates bootstrapping has failed, so this
@@ -80,31 +126,75 @@ func (b *bootstrapper) ForceAccepted(acceptedContainerIDs ids.Set) {
 	}
 }
 
-// Put ...
-func (b *bootstrapper) Put(vdr ids.ShortID, requestID uint32, blkID ids.ID, blkBytes []byte) {
-	b.BootstrapConfig.Context.Log.Verbo("Put called for blkID %s", blkID)
+// MultiPut handles the response to a GetAncestors request. The blocks are the
+// requested block followed by as many of its ancestors as the validator sent.
+func (b *bootstrapper) MultiPut(vdr ids.ShortID, requestID uint32, blks [][]byte) {
+	wantedBlkID, ok := b.requests.Remove(vdr, requestID)
+	if !ok {
+		b.BootstrapConfig.Context.Log.Debug("Received a MultiPut message from %s unexpectedly", vdr)
+		return
+	}
+	b.BootstrapConfig.Context.Log.Verbo("MultiPut called for blkID %s with %d blocks", wantedBlkID, len(blks))
 
-	if !b.pending.Contains(blkID) {
+	if len(blks) > common.MaxContainersPerMultiPut {
+		b.BootstrapConfig.Context.Log.Debug("MultiPut from %s contained %d blocks, which is more than the maximum of %d",
+			vdr, len(blks), common.MaxContainersPerMultiPut)
+		b.BootstrapConfig.Context.Reputation.Report(vdr, reputation.InvalidContainer)
+		b.GetAncestorsFailed(vdr, requestID)
 		return
 	}
 
-	blk, err := b.VM.ParseBlock(blkBytes)
-	if err != nil {
-		b.BootstrapConfig.Context.Log.Warn("ParseBlock failed due to %s for block:\n%s",
-			err,
-			formatting.DumpBytes{Bytes: blkBytes})
-		b.GetFailed(vdr, requestID, blkID)
+	// Each block must be the parent of the block before it, starting with the
+	// requested block. Blocks after the first one that isn't are ignored.
+	ancestors := []snowman.Block(nil)
+	expectedID := wantedBlkID
+	for _, blkBytes := range blks {
+		blk, err := b.VM.ParseBlock(blkBytes)
+		if err != nil {
+			b.BootstrapConfig.Context.Log.Debug("ParseBlock failed due to %s for block:\n%s",
+				err,
+				formatting.DumpBytes{Bytes: blkBytes})
+			break
+		}
+		if !blk.ID().Equals(expectedID) {
+			break
+		}
+		ancestors = append(ancestors, blk)
+		expectedID = blk.Parent().ID()
+	}
+
+	if len(ancestors) == 0 {
+		b.BootstrapConfig.Context.Log.Debug("MultiPut from %s didn't contain the requested block %s", vdr, wantedBlkID)
+		b.BootstrapConfig.Context.Reputation.Report(vdr, reputation.InvalidContainer)
+		b.sendRequest(wantedBlkID)
 		return
 	}
 
-	b.addBlock(blk)
+	// Store the oldest blocks first, so that the ancestry of each block is
+	// already queued when the block is stored
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		b.Progress.Received(vdr)
+		b.storeBlock(ancestors[i])
+	}
+	b.persist()
+
+	if numPending := b.pending.Len(); numPending == 0 {
+		b.finish()
+	}
 }
 
-// GetFailed ...
-func (b *bootstrapper) GetFailed(_ ids.ShortID, _ uint32, blkID ids.ID) { b.sendRequest(blkID) }
+// GetAncestorsFailed requests the block from another validator
+func (b *bootstrapper) GetAncestorsFailed(vdr ids.ShortID, requestID uint32) {
+	blkID, ok := b.requests.Remove(vdr, requestID)
+	if !ok {
+		b.BootstrapConfig.Context.Log.Debug("GetAncestorsFailed called with unknown requestID %d from %s", requestID, vdr)
+		return
+	}
+	b.sendRequest(blkID)
+}
 
 func (b *bootstrapper) fetch(blkID ids.ID) {
-	if b.pending.Contains(blkID) {
+	if b.pending.Contains(blkID) || b.queued(blkID) {
 		return
 	}
 
@@ -116,33 +206,32 @@ func (b *bootstrapper) fetch(blkID ids.ID) {
 	b.storeBlock(blk)
 }
 
+// sendRequest requests [blkID] and its ancestors from the sampled validator
+// with the fewest outstanding requests, so that requests are spread across
+// the validators
 func (b *bootstrapper) sendRequest(blkID ids.ID) {
-	validators := b.BootstrapConfig.Validators.Sample(1)
-	if len(validators) == 0 {
+	validatorID, ok := b.requests.LeastLoaded(b.BootstrapConfig.Validators.Sample(common.NumFetchCandidates))
+	if !ok {
 		b.BootstrapConfig.Context.Log.Error("Dropping request for %s as there are no validators", blkID)
 		return
 	}
-	validatorID := validators[0].ID()
 	b.RequestID++
 
 	b.pending.Add(blkID)
-	b.BootstrapConfig.Sender.Get(validatorID, b.RequestID, blkID)
+	b.requests.Add(validatorID, b.RequestID, blkID)
+	b.BootstrapConfig.Sender.GetAncestors(validatorID, b.RequestID, blkID)
 
-	b.numPendingRequests.Set(float64(b.pending.Len()))
-}
-
-func (b *bootstrapper) addBlock(blk snowman.Block) {
-	b.storeBlock(blk)
-
-	if numPending := b.pending.Len(); numPending == 0 {
-		b.finish()
-	}
+	numPending := b.pending.Len()
+	b.numPendingRequests.Set(float64(numPending))
+	b.Progress.Requested(validatorID, numPending)
 }
 
 func (b *bootstrapper) storeBlock(blk snowman.Block) {
 	status := blk.Status()
 	blkID := blk.ID()
-	for status == choices.Processing {
+	// Blocks that are already queued had their ancestry fetched when they were
+	// queued, possibly before a restart
+	for status == choices.Processing && !b.queued(blkID) {
 		b.pending.Remove(blkID)
 
 		if err := b.Blocked.Push(&blockJob{
@@ -151,6 +240,7 @@ func (b *bootstrapper) storeBlock(blk snowman.Block) {
 			blk:         blk,
 		}); err == nil {
 			b.numBlocked.Inc()
+			b.Progress.Fetched(b.pending.Len())
 		}
 
 		blk = blk.Parent()
@@ -158,9 +248,11 @@ func (b *bootstrapper) storeBlock(blk snowman.Block) {
 		blkID = blk.ID()
 	}
 
-	switch status := blk.Status(); status {
+	switch status {
 	case choices.Unknown:
-		b.sendRequest(blkID)
+		if !b.queued(blkID) {
+			b.sendRequest(blkID)
+		}
 	case choices.Accepted:
 		b.BootstrapConfig.Context.Log.Verbo("Bootstrapping confirmed %s", blkID)
 	case choices.Rejected:
@@ -171,12 +263,31 @@ func (b *bootstrapper) storeBlock(blk snowman.Block) {
 	b.numPendingRequests.Set(float64(numPending))
 }
 
+// queued returns true if [blkID] was fetched and is waiting to be executed
+func (b *bootstrapper) queued(blkID ids.ID) bool {
+	queued, err := b.Blocked.Has(blkID)
+	return err == nil && queued
+}
+
+// persist the fetched blocks, and the blocks being fetched, so that
+// bootstrapping can resume from them after a restart
+func (b *bootstrapper) persist() {
+	if err := b.Blocked.SetPending(b.pending); err != nil {
+		b.BootstrapConfig.Context.Log.Warn("Failed to record the blocks being fetched due to %s", err)
+	}
+	if err := b.Blocked.Commit(); err != nil {
+		b.BootstrapConfig.Context.Log.Warn("Failed to commit the bootstrapping queue due to %s", err)
+	}
+}
+
 func (b *bootstrapper) finish() {
 	if b.finished {
 		return
 	}
 
+	b.Progress.SetPhase(common.PhaseExecuting)
 	b.executeAll(b.Blocked, b.numBlocked)
+	b.Progress.SetPhase(common.PhaseFinished)
 
 	// Start consensus
 	b.onFinished()
@@ -188,10 +299,25 @@ func (b *bootstrapper) finish() {
 }
 
 func (b *bootstrapper) executeAll(jobs *queue.Jobs, numBlocked prometheus.Gauge) {
+	numExecuted := 0
 	for job, err := jobs.Pop(); err == nil; job, err = jobs.Pop() {
 		numBlocked.Dec()
 		if err := jobs.Execute(job); err != nil {
 			b.BootstrapConfig.Context.Log.Warn("Error executing: %s", err)
 		}
+		b.Progress.Executed()
+
+		// Executing a job is idempotent, so committing periodically only
+		// bounds the work repeated after a restart
+		if numExecuted++; numExecuted%commitInterval == 0 {
+			b.commit(jobs)
+		}
+	}
+	b.commit(jobs)
+}
+
+func (b *bootstrapper) commit(jobs *queue.Jobs) {
+	if err := jobs.Commit(); err != nil {
+		b.BootstrapConfig.Context.Log.Warn("Failed to commit the bootstrapping queue due to %s", err)
 	}
 }
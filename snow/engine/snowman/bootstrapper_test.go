@@ -53,7 +53,7 @@ func newConfig(t *testing.T) (BootstrapConfig, ids.ShortID, *common.SenderTest,
 	peers.Add(peer)
 
 	handler.Initialize(engine, make(chan common.Message), 1)
-	timeouts.Initialize(0)
+	timeouts.Initialize(ctx.Log, timeout.Config{}, prometheus.NewRegistry())
 	router.Initialize(ctx.Log, timeouts)
 
 	blocker, _ := queue.New(db)
@@ -113,7 +113,7 @@ func TestBootstrapperSingleFrontier(t *testing.T) {
 	}
 
 	reqID := new(uint32)
-	sender.GetF = func(vdr ids.ShortID, innerReqID uint32, blkID ids.ID) {
+	sender.GetAncestorsF = func(vdr ids.ShortID, innerReqID uint32, blkID ids.ID) {
 		if !vdr.Equals(peerID) {
 			t.Fatalf("Should have requested block from %s, requested from %s", peerID, vdr)
 		}
@@ -129,7 +129,93 @@ func TestBootstrapperSingleFrontier(t *testing.T) {
 	bs.ForceAccepted(acceptedIDs)
 
 	vm.GetBlockF = nil
-	sender.GetF = nil
+	sender.GetAncestorsF = nil
+
+	vm.ParseBlockF = func(blkBytes []byte) (snowman.Block, error) {
+		switch {
+		case bytes.Equal(blkBytes, blkBytes1):
+			return blk1, nil
+		}
+		t.Fatal(errUnknownBlock)
+		return nil, errUnknownBlock
+	}
+
+	finished := new(bool)
+	bs.onFinished = func() { *finished = true }
+
+	bs.MultiPut(peerID, *reqID, [][]byte{blkBytes1})
+
+	vm.ParseBlockF = nil
+	bs.onFinished = nil
+
+	if !*finished {
+		t.Fatalf("Bootstrapping should have finished")
+	}
+	if blk1.Status() != choices.Accepted {
+		t.Fatalf("Block should be accepted")
+	}
+}
+
+// Peers that don't support GetAncestors respond with a Put
+func TestBootstrapperPut(t *testing.T) {
+	config, peerID, sender, vm := newConfig(t)
+
+	blkID0 := ids.Empty.Prefix(0)
+	blkID1 := ids.Empty.Prefix(1)
+
+	blkBytes0 := []byte{0}
+	blkBytes1 := []byte{1}
+
+	blk0 := &Blk{
+		id:     blkID0,
+		height: 0,
+		status: choices.Accepted,
+		bytes:  blkBytes0,
+	}
+	blk1 := &Blk{
+		parent: blk0,
+		id:     blkID1,
+		height: 1,
+		status: choices.Processing,
+		bytes:  blkBytes1,
+	}
+
+	engineConfig := DefaultConfig()
+	engineConfig.BootstrapConfig = config
+	bs := &Transitive{}
+	bs.Initialize(engineConfig)
+
+	acceptedIDs := ids.Set{}
+	acceptedIDs.Add(blkID1)
+
+	vm.GetBlockF = func(blkID ids.ID) (snowman.Block, error) {
+		switch {
+		case blkID.Equals(blkID1):
+			return nil, errUnknownBlock
+		default:
+			t.Fatal(errUnknownBlock)
+			panic(errUnknownBlock)
+		}
+	}
+
+	reqID := new(uint32)
+	sender.GetAncestorsF = func(vdr ids.ShortID, innerReqID uint32, blkID ids.ID) {
+		if !vdr.Equals(peerID) {
+			t.Fatalf("Should have requested block from %s, requested from %s", peerID, vdr)
+		}
+		switch {
+		case blkID.Equals(blkID1):
+		default:
+			t.Fatalf("Requested unknown vertex")
+		}
+
+		*reqID = innerReqID
+	}
+
+	bs.ForceAccepted(acceptedIDs)
+
+	vm.GetBlockF = nil
+	sender.GetAncestorsF = nil
 
 	vm.ParseBlockF = func(blkBytes []byte) (snowman.Block, error) {
 		switch {
@@ -156,6 +242,91 @@ func TestBootstrapperSingleFrontier(t *testing.T) {
 	}
 }
 
+func TestBootstrapperInvalidBlock(t *testing.T) {
+	config, peerID, sender, vm := newConfig(t)
+
+	blkID0 := ids.Empty.Prefix(0)
+	blkID1 := ids.Empty.Prefix(1)
+
+	blkBytes0 := []byte{0}
+	blkBytes1 := []byte{1}
+
+	blk0 := &Blk{
+		id:     blkID0,
+		height: 0,
+		status: choices.Accepted,
+		bytes:  blkBytes0,
+	}
+	blk1 := &Blk{
+		parent:   blk0,
+		id:       blkID1,
+		height:   1,
+		status:   choices.Processing,
+		validity: errors.New("invalid block"),
+		bytes:    blkBytes1,
+	}
+
+	bs := bootstrapper{}
+	bs.metrics.Initialize(config.Context.Log, fmt.Sprintf("gecko_%s", config.Context.ChainID), prometheus.NewRegistry())
+	bs.Initialize(config)
+
+	acceptedIDs := ids.Set{}
+	acceptedIDs.Add(blkID1)
+
+	vm.GetBlockF = func(blkID ids.ID) (snowman.Block, error) {
+		switch {
+		case blkID.Equals(blkID1):
+			return nil, errUnknownBlock
+		default:
+			t.Fatal(errUnknownBlock)
+			panic(errUnknownBlock)
+		}
+	}
+
+	reqID := new(uint32)
+	sender.GetAncestorsF = func(vdr ids.ShortID, innerReqID uint32, blkID ids.ID) {
+		if !vdr.Equals(peerID) {
+			t.Fatalf("Should have requested block from %s, requested from %s", peerID, vdr)
+		}
+		switch {
+		case blkID.Equals(blkID1):
+		default:
+			t.Fatalf("Requested unknown vertex")
+		}
+
+		*reqID = innerReqID
+	}
+
+	bs.ForceAccepted(acceptedIDs)
+
+	vm.GetBlockF = nil
+	sender.GetAncestorsF = nil
+
+	vm.ParseBlockF = func(blkBytes []byte) (snowman.Block, error) {
+		switch {
+		case bytes.Equal(blkBytes, blkBytes1):
+			return blk1, nil
+		}
+		t.Fatal(errUnknownBlock)
+		return nil, errUnknownBlock
+	}
+
+	finished := new(bool)
+	bs.onFinished = func() { *finished = true }
+
+	bs.MultiPut(peerID, *reqID, [][]byte{blkBytes1})
+
+	vm.ParseBlockF = nil
+	bs.onFinished = nil
+
+	if !*finished {
+		t.Fatalf("Bootstrapping should have finished")
+	}
+	if blk1.Status() != choices.Processing {
+		t.Fatalf("Block failed verification, so it shouldn't be accepted")
+	}
+}
+
 func TestBootstrapperUnknownByzantineResponse(t *testing.T) {
 	config, peerID, sender, vm := newConfig(t)
 
@@ -206,7 +377,7 @@ func TestBootstrapperUnknownByzantineResponse(t *testing.T) {
 	}
 
 	requestID := new(uint32)
-	sender.GetF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
+	sender.GetAncestorsF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
 		if !vdr.Equals(peerID) {
 			t.Fatalf("Should have requested block from %s, requested from %s", peerID, vdr)
 		}
@@ -222,12 +393,13 @@ func TestBootstrapperUnknownByzantineResponse(t *testing.T) {
 	bs.ForceAccepted(acceptedIDs)
 
 	vm.GetBlockF = nil
-	sender.GetF = nil
 
 	vm.ParseBlockF = func(blkBytes []byte) (snowman.Block, error) {
 		switch {
 		case bytes.Equal(blkBytes, blkBytes1):
 			return blk1, nil
+		case bytes.Equal(blkBytes, blkBytes2):
+			return blk2, nil
 		}
 		t.Fatal(errUnknownBlock)
 		return nil, errUnknownBlock
@@ -236,8 +408,22 @@ func TestBootstrapperUnknownByzantineResponse(t *testing.T) {
 	finished := new(bool)
 	bs.onFinished = func() { *finished = true }
 
-	bs.Put(peerID, *requestID, blkID2, blkBytes2)
-	bs.Put(peerID, *requestID, blkID1, blkBytes1)
+	// A response that doesn't start with the requested block should cause the
+	// block to be requested again
+	oldReqID := *requestID
+	bs.MultiPut(peerID, *requestID, [][]byte{blkBytes2})
+	if *requestID == oldReqID {
+		t.Fatalf("Should have requested the block again")
+	}
+
+	// The response to the old request should be dropped
+	bs.MultiPut(peerID, oldReqID, [][]byte{blkBytes1})
+	if *finished {
+		t.Fatalf("Should have dropped the response to the old request")
+	}
+
+	sender.GetAncestorsF = nil
+	bs.MultiPut(peerID, *requestID, [][]byte{blkBytes1})
 
 	vm.ParseBlockF = nil
 
@@ -302,7 +488,7 @@ func TestBootstrapperDependency(t *testing.T) {
 	}
 
 	requestID := new(uint32)
-	sender.GetF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
+	sender.GetAncestorsF = func(vdr ids.ShortID, reqID uint32, vtxID ids.ID) {
 		if !vdr.Equals(peerID) {
 			t.Fatalf("Should have requested block from %s, requested from %s", peerID, vdr)
 		}
@@ -318,7 +504,7 @@ func TestBootstrapperDependency(t *testing.T) {
 	bs.ForceAccepted(acceptedIDs)
 
 	vm.GetBlockF = nil
-	sender.GetF = nil
+	sender.GetAncestorsF = nil
 
 	vm.ParseBlockF = func(blkBytes []byte) (snowman.Block, error) {
 		switch {
@@ -336,7 +522,7 @@ func TestBootstrapperDependency(t *testing.T) {
 	finished := new(bool)
 	bs.onFinished = func() { *finished = true }
 
-	bs.Put(peerID, *requestID, blkID1, blkBytes1)
+	bs.MultiPut(peerID, *requestID, [][]byte{blkBytes1})
 
 	if !*finished {
 		t.Fatalf("Bootstrapping should have finished")
@@ -349,6 +535,132 @@ func TestBootstrapperDependency(t *testing.T) {
 	}
 }
 
+func TestBootstrapperMultiPut(t *testing.T) {
+	config, peerID, sender, vm := newConfig(t)
+
+	blkID0 := ids.Empty.Prefix(0)
+	blkID1 := ids.Empty.Prefix(1)
+	blkID2 := ids.Empty.Prefix(2)
+	blkID3 := ids.Empty.Prefix(3)
+
+	blkBytes0 := []byte{0}
+	blkBytes1 := []byte{1}
+	blkBytes2 := []byte{2}
+	blkBytes3 := []byte{3}
+
+	blk0 := &Blk{
+		id:     blkID0,
+		height: 0,
+		status: choices.Accepted,
+		bytes:  blkBytes0,
+	}
+	blk1 := &Blk{
+		parent: blk0,
+		id:     blkID1,
+		height: 1,
+		status: choices.Processing,
+		bytes:  blkBytes1,
+	}
+	blk2 := &Blk{
+		parent: blk1,
+		id:     blkID2,
+		height: 2,
+		status: choices.Processing,
+		bytes:  blkBytes2,
+	}
+	blk3 := &Blk{
+		parent: blk2,
+		id:     blkID3,
+		height: 3,
+		status: choices.Processing,
+		bytes:  blkBytes3,
+	}
+
+	bs := bootstrapper{}
+	bs.metrics.Initialize(config.Context.Log, fmt.Sprintf("gecko_%s", config.Context.ChainID), prometheus.NewRegistry())
+	bs.Initialize(config)
+
+	acceptedIDs := ids.Set{}
+	acceptedIDs.Add(blkID3)
+
+	vm.GetBlockF = func(blkID ids.ID) (snowman.Block, error) { return nil, errUnknownBlock }
+	vm.ParseBlockF = func(blkBytes []byte) (snowman.Block, error) {
+		switch {
+		case bytes.Equal(blkBytes, blkBytes1):
+			return blk1, nil
+		case bytes.Equal(blkBytes, blkBytes2):
+			return blk2, nil
+		case bytes.Equal(blkBytes, blkBytes3):
+			return blk3, nil
+		}
+		t.Fatal(errUnknownBlock)
+		return nil, errUnknownBlock
+	}
+
+	requestID := new(uint32)
+	sender.GetAncestorsF = func(vdr ids.ShortID, reqID uint32, blkID ids.ID) {
+		if !blkID.Equals(blkID3) {
+			t.Fatalf("Should have only requested the accepted frontier")
+		}
+		*requestID = reqID
+	}
+
+	bs.ForceAccepted(acceptedIDs)
+
+	sender.GetAncestorsF = nil
+
+	finished := new(bool)
+	bs.onFinished = func() { *finished = true }
+
+	// The ancestors are in one response, so no more requests should be sent
+	bs.MultiPut(peerID, *requestID, [][]byte{blkBytes3, blkBytes2, blkBytes1})
+
+	if !*finished {
+		t.Fatalf("Bootstrapping should have finished")
+	}
+	for _, blk := range []*Blk{blk1, blk2, blk3} {
+		if blk.Status() != choices.Accepted {
+			t.Fatalf("Block %s should be accepted", blk.ID())
+		}
+	}
+	if status := bs.Progress.Status(); len(status.Peers) != 1 || status.Peers[0].Received != 3 {
+		t.Fatalf("Should have received 3 blocks from the peer")
+	}
+}
+
+func TestBootstrapperSpreadsRequests(t *testing.T) {
+	config, _, sender, vm := newConfig(t)
+
+	for i := 0; i < 3; i++ {
+		config.Validators.Add(validators.GenerateRandomValidator(1))
+	}
+
+	bs := bootstrapper{}
+	bs.metrics.Initialize(config.Context.Log, fmt.Sprintf("gecko_%s", config.Context.ChainID), prometheus.NewRegistry())
+	bs.Initialize(config)
+
+	acceptedIDs := ids.Set{}
+	for i := uint64(0); i < 8; i++ {
+		acceptedIDs.Add(ids.Empty.Prefix(i))
+	}
+
+	vm.GetBlockF = func(blkID ids.ID) (snowman.Block, error) { return nil, errUnknownBlock }
+
+	requested := map[[20]byte]int{}
+	sender.GetAncestorsF = func(vdr ids.ShortID, _ uint32, _ ids.ID) { requested[vdr.Key()]++ }
+
+	bs.ForceAccepted(acceptedIDs)
+
+	if len(requested) != 4 {
+		t.Fatalf("Should have requested blocks from all 4 validators, requested from %d", len(requested))
+	}
+	for _, numRequested := range requested {
+		if numRequested != 2 {
+			t.Fatalf("Should have requested 2 blocks from each validator, requested %d", numRequested)
+		}
+	}
+}
+
 func TestBootstrapperAcceptedFrontier(t *testing.T) {
 	config, _, _, vm := newConfig(t)
 
@@ -463,7 +775,7 @@ func TestBootstrapperPartialFetch(t *testing.T) {
 		}
 	}
 
-	sender.CantGet = false
+	sender.CantGetAncestors = false
 	bs.onFinished = func() {}
 
 	bs.ForceAccepted(acceptedIDs)
@@ -476,3 +788,116 @@ func TestBootstrapperPartialFetch(t *testing.T) {
 		t.Fatalf("wrong number pending")
 	}
 }
+
+func TestBootstrapperResume(t *testing.T) {
+	config, peerID, sender, vm := newConfig(t)
+
+	db := memdb.New()
+	config.Blocked, _ = queue.New(db)
+
+	blkID0 := ids.Empty.Prefix(0)
+	blkID1 := ids.Empty.Prefix(1)
+	blkID2 := ids.Empty.Prefix(2)
+
+	blkBytes0 := []byte{0}
+	blkBytes1 := []byte{1}
+	blkBytes2 := []byte{2}
+
+	blk0 := &Blk{
+		id:     blkID0,
+		height: 0,
+		status: choices.Accepted,
+		bytes:  blkBytes0,
+	}
+	blk1 := &Blk{
+		parent: blk0,
+		id:     blkID1,
+		height: 1,
+		status: choices.Unknown,
+		bytes:  blkBytes1,
+	}
+	blk2 := &Blk{
+		parent: blk1,
+		id:     blkID2,
+		height: 2,
+		status: choices.Processing,
+		bytes:  blkBytes2,
+	}
+
+	vm.GetBlockF = func(blkID ids.ID) (snowman.Block, error) { return nil, errUnknownBlock }
+	vm.ParseBlockF = func(blkBytes []byte) (snowman.Block, error) {
+		switch {
+		case bytes.Equal(blkBytes, blkBytes1):
+			return blk1, nil
+		case bytes.Equal(blkBytes, blkBytes2):
+			return blk2, nil
+		}
+		t.Fatal(errUnknownBlock)
+		return nil, errUnknownBlock
+	}
+
+	requested := map[[32]byte]uint32{}
+	sender.GetAncestorsF = func(vdr ids.ShortID, reqID uint32, blkID ids.ID) {
+		if !vdr.Equals(peerID) {
+			t.Fatalf("Should have requested block from %s, requested from %s", peerID, vdr)
+		}
+		requested[blkID.Key()] = reqID
+	}
+
+	bs := bootstrapper{}
+	bs.metrics.Initialize(config.Context.Log, fmt.Sprintf("gecko_%s", config.Context.ChainID), prometheus.NewRegistry())
+	bs.Initialize(config)
+
+	acceptedIDs := ids.Set{}
+	acceptedIDs.Add(blkID2)
+
+	bs.ForceAccepted(acceptedIDs)
+	bs.MultiPut(peerID, requested[blkID2.Key()], [][]byte{blkBytes2})
+
+	if _, ok := requested[blkID1.Key()]; !ok {
+		t.Fatalf("Should have requested the parent of the fetched block")
+	}
+
+	// Restart bootstrapping with the queue that was persisted
+	config.Blocked, _ = queue.New(db)
+	requested = map[[32]byte]uint32{}
+
+	bs = bootstrapper{}
+	bs.metrics.Initialize(config.Context.Log, fmt.Sprintf("gecko_%s", config.Context.ChainID), prometheus.NewRegistry())
+	bs.Initialize(config)
+
+	if status := bs.Progress.Status(); status.Queued != 1 {
+		t.Fatalf("Should have resumed with 1 queued block, resumed with %d", status.Queued)
+	}
+
+	bs.ForceAccepted(acceptedIDs)
+
+	if len(requested) != 1 {
+		t.Fatalf("Should have only requested the block being fetched before the restart")
+	}
+	if _, ok := requested[blkID1.Key()]; !ok {
+		t.Fatalf("Should have resumed fetching the parent of the fetched block")
+	}
+	if status := bs.Progress.Status(); status.Resumed != 1 || status.Phase != common.PhaseFetching {
+		t.Fatalf("Wrong bootstrapping status %+v", status)
+	}
+
+	finished := new(bool)
+	bs.onFinished = func() { *finished = true }
+
+	blk1.status = choices.Processing
+	bs.MultiPut(peerID, requested[blkID1.Key()], [][]byte{blkBytes1})
+
+	if !*finished {
+		t.Fatalf("Bootstrapping should have finished")
+	}
+	if blk1.Status() != choices.Accepted {
+		t.Fatalf("Block should be accepted")
+	}
+	if blk2.Status() != choices.Accepted {
+		t.Fatalf("Block should be accepted")
+	}
+	if status := bs.Progress.Status(); status.Executed != 2 || status.Queued != 0 || status.Phase != common.PhaseFinished {
+		t.Fatalf("Wrong bootstrapping status %+v", status)
+	}
+}
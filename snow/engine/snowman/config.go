@@ -6,6 +6,7 @@ package snowman
 import (
 	"github.com/ava-labs/gecko/snow/consensus/snowball"
 	"github.com/ava-labs/gecko/snow/consensus/snowman"
+	"github.com/ava-labs/gecko/snow/engine/common"
 )
 
 // Config wraps all the parameters needed for a snowman engine
@@ -14,4 +15,7 @@ type Config struct {
 
 	Params    snowball.Parameters
 	Consensus snowman.Consensus
+
+	// Trace records the polls of this engine. If nil, nothing is recorded.
+	Trace *common.Trace
 }
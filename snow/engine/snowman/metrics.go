@@ -12,6 +12,7 @@ import (
 type metrics struct {
 	numPendingRequests, numBlocked prometheus.Gauge
 	numBootstrapped, numDropped    prometheus.Counter
+	numStateChunks                 prometheus.Counter
 
 	numPolls, numBlkRequests, numBlockedBlk prometheus.Gauge
 }
@@ -42,6 +43,12 @@ func (m *metrics) Initialize(log logging.Logger, namespace string, registerer pr
 			Name:      "sm_bs_dropped",
 			Help:      "Number of dropped bootstrap blocks",
 		})
+	m.numStateChunks = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sm_ss_chunks",
+			Help:      "Number of state chunks downloaded by state sync",
+		})
 	m.numPolls = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Namespace: namespace,
@@ -73,6 +80,9 @@ func (m *metrics) Initialize(log logging.Logger, namespace string, registerer pr
 	if err := registerer.Register(m.numDropped); err != nil {
 		log.Error("Failed to register sm_bs_dropped statistics due to %s", err)
 	}
+	if err := registerer.Register(m.numStateChunks); err != nil {
+		log.Error("Failed to register sm_ss_chunks statistics due to %s", err)
+	}
 	if err := registerer.Register(m.numPolls); err != nil {
 		log.Error("Failed to register sm_polls statistics due to %s", err)
 	}
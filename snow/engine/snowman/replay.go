@@ -0,0 +1,57 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package snowman
+
+import (
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowman"
+	"github.com/ava-labs/gecko/snow/engine/common"
+)
+
+// Replay reproduces the consensus state of a traced engine by issuing the
+// blocks, and recording the polls, of [events] into [consensus]. The blocks
+// are fetched from [vm]. [events] must include the start of consensus, or a
+// snapshot of it. When replaying from a snapshot, the confidence consensus had
+// in the processing blocks before the snapshot isn't reproduced.
+func Replay(ctx *snow.Context, params snowball.Parameters, consensus snowman.Consensus, vm ChainVM, events []common.TraceEvent) error {
+	events, err := common.TraceSinceStart(events)
+	if err != nil {
+		return err
+	}
+
+	for i, event := range events {
+		switch event.Kind {
+		case common.TraceStart:
+			consensus.Initialize(ctx, params, event.Containers[0])
+		case common.TraceSnapshot:
+			// Later snapshots describe states that were already replayed
+			if i != 0 {
+				continue
+			}
+			consensus.Initialize(ctx, params, event.Containers[0])
+			for _, blkID := range event.Processing {
+				blk, err := vm.GetBlock(blkID)
+				if err != nil {
+					return err
+				}
+				consensus.Add(blk)
+			}
+		case common.TraceIssue:
+			blk, err := vm.GetBlock(event.Containers[0])
+			if err != nil {
+				return err
+			}
+			consensus.Add(blk)
+		case common.TraceRecordPoll:
+			votes := ids.Bag{}
+			for _, vote := range event.Votes {
+				votes.AddCount(vote.ID, vote.Count)
+			}
+			consensus.RecordPoll(votes)
+		}
+	}
+	return nil
+}
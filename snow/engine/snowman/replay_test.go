@@ -0,0 +1,248 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package snowman
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowball"
+	"github.com/ava-labs/gecko/snow/consensus/snowman"
+	"github.com/ava-labs/gecko/snow/engine/common"
+	"github.com/ava-labs/gecko/snow/validators"
+)
+
+// replayBlocks returns a genesis block and two conflicting children of it
+func replayBlocks() []*Blk {
+	gBlk := &Blk{
+		id:     ids.Empty.Prefix(0),
+		status: choices.Accepted,
+	}
+	return []*Blk{
+		gBlk,
+		&Blk{
+			parent: gBlk,
+			id:     ids.Empty.Prefix(1),
+			status: choices.Processing,
+			bytes:  []byte{1},
+		},
+		&Blk{
+			parent: gBlk,
+			id:     ids.Empty.Prefix(2),
+			status: choices.Processing,
+			bytes:  []byte{2},
+		},
+	}
+}
+
+func replayVM(t *testing.T, blks []*Blk) *VMTest {
+	vm := &VMTest{}
+	vm.T = t
+	vm.Default(true)
+	vm.CantSetPreference = false
+	vm.LastAcceptedF = func() ids.ID {
+		for i := len(blks) - 1; i > 0; i-- {
+			if blks[i].Status() == choices.Accepted {
+				return blks[i].ID()
+			}
+		}
+		return blks[0].ID()
+	}
+	vm.GetBlockF = func(id ids.ID) (snowman.Block, error) {
+		for _, blk := range blks {
+			if blk.ID().Equals(id) {
+				return blk, nil
+			}
+		}
+		return nil, errUnknownBlock
+	}
+	vm.ParseBlockF = func(b []byte) (snowman.Block, error) {
+		for _, blk := range blks {
+			if bytes.Equal(blk.Bytes(), b) {
+				return blk, nil
+			}
+		}
+		return nil, errUnknownBytes
+	}
+	return vm
+}
+
+// runReplayedEngine runs an engine, traced into a buffer of [traceSize]
+// events, until the first of the replay blocks is accepted
+func runReplayedEngine(t *testing.T, traceSize int) (*Transitive, Config, []*Blk) {
+	config := DefaultConfig()
+	config.Params = snowball.Parameters{
+		Metrics:           prometheus.NewRegistry(),
+		K:                 1,
+		Alpha:             1,
+		BetaVirtuous:      2,
+		BetaRogue:         3,
+		ConcurrentRepolls: 1,
+	}
+
+	vdr := validators.GenerateRandomValidator(1)
+	vals := validators.NewSet()
+	vals.Add(vdr)
+	config.Validators = vals
+
+	config.Trace = &common.Trace{}
+	config.Trace.Initialize(traceSize)
+	if err := config.Context.ConsensusDispatcher.RegisterChain(config.Context.ChainID, "trace", config.Trace); err != nil {
+		t.Fatal(err)
+	}
+
+	queries := []uint32(nil)
+	sender := &common.SenderTest{}
+	sender.T = t
+	sender.Default(true)
+	sender.PushQueryF = func(_ ids.ShortSet, requestID uint32, _ ids.ID, _ []byte) {
+		queries = append(queries, requestID)
+	}
+	sender.PullQueryF = func(_ ids.ShortSet, requestID uint32, _ ids.ID) {
+		queries = append(queries, requestID)
+	}
+	config.Sender = sender
+
+	blks := replayBlocks()
+	vm := replayVM(t, blks)
+	config.VM = vm
+
+	te := &Transitive{}
+	te.Initialize(config)
+	te.finishBootstrapping()
+
+	vm.BuildBlockF = func() (snowman.Block, error) { return blks[1], nil }
+	te.Notify(common.PendingTxs)
+	te.Put(vdr.ID(), 0, blks[2].ID(), blks[2].Bytes())
+
+	// Vote for the first block until it's accepted
+	for len(queries) > 0 && blks[1].Status() != choices.Accepted {
+		requestID := queries[0]
+		queries = queries[1:]
+
+		votes := ids.Set{}
+		votes.Add(blks[1].ID())
+		te.Chits(vdr.ID(), requestID, votes)
+	}
+	if status := blks[1].Status(); status != choices.Accepted {
+		t.Fatalf("Block should have been accepted but is %s", status)
+	}
+	if status := blks[2].Status(); status != choices.Rejected {
+		t.Fatalf("Block should have been rejected but is %s", status)
+	}
+	return te, config, blks
+}
+
+func TestEngineReplayTrace(t *testing.T) {
+	te, config, blks := runReplayedEngine(t, 100)
+
+	// The dump is replayed as it would be after being fetched from the API
+	dump, err := json.Marshal(config.Trace.Events())
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []common.TraceEvent(nil)
+	if err := json.Unmarshal(dump, &events); err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := map[string]int{}
+	for _, event := range events {
+		kinds[event.Kind]++
+	}
+	switch {
+	case kinds[common.TraceStart] != 1:
+		t.Fatalf("The start of consensus should have been traced once")
+	case kinds[common.TraceIssue] != 2:
+		t.Fatalf("Both blocks should have been traced as issued")
+	case kinds[common.TracePoll] != kinds[common.TraceChits]:
+		t.Fatalf("Every poll should have been answered")
+	case kinds[common.TraceRecordPoll] == 0:
+		t.Fatalf("The polls should have been traced as recorded")
+	case kinds[common.TraceAccept] != 1 || kinds[common.TraceReject] != 1:
+		t.Fatalf("The decisions should have been traced")
+	}
+
+	replayedBlks := replayBlocks()
+	replayed := &snowman.Topological{}
+	params := config.Params
+	params.Metrics = prometheus.NewRegistry()
+	if err := Replay(snow.DefaultContextTest(), params, replayed, replayVM(t, replayedBlks), events); err != nil {
+		t.Fatal(err)
+	}
+
+	if !replayed.Preference().Equals(te.Consensus.Preference()) {
+		t.Fatalf("Replayed preference should be %s but is %s", te.Consensus.Preference(), replayed.Preference())
+	}
+	for i, blk := range blks {
+		if status := replayedBlks[i].Status(); status != blk.Status() {
+			t.Fatalf("Replayed block %d should be %s but is %s", i, blk.Status(), status)
+		}
+	}
+}
+
+func TestEngineReplayTruncatedTrace(t *testing.T) {
+	trace := &common.Trace{}
+	trace.Initialize(2)
+	trace.Start([]ids.ID{ids.Empty})
+	trace.Poll(1, ids.Empty, ids.ShortSet{})
+	trace.QueryFailed(1, ids.ShortEmpty)
+
+	events := trace.Events()
+	if len(events) != 2 {
+		t.Fatalf("Trace should hold 2 events but holds %d", len(events))
+	}
+	if events[0].Kind != common.TracePoll || events[1].Kind != common.TraceQueryFailed {
+		t.Fatalf("Trace should hold the most recent events, oldest first")
+	}
+
+	params := DefaultConfig().Params
+	if err := Replay(snow.DefaultContextTest(), params, &snowman.Topological{}, &VMTest{}, events); err == nil {
+		t.Fatalf("Replaying a trace without the start of consensus should have errored")
+	}
+}
+
+func TestEngineReplaySnapshot(t *testing.T) {
+	// The start is overwritten, but consensus is replayed from a snapshot taken
+	// while both blocks were processing
+	te, config, _ := runReplayedEngine(t, 12)
+
+	events := config.Trace.Events()
+	snapshots := 0
+	for _, event := range events {
+		switch event.Kind {
+		case common.TraceStart:
+			t.Fatalf("The start of consensus should have been overwritten")
+		case common.TraceSnapshot:
+			snapshots++
+			if event.State == "" {
+				t.Fatalf("Snapshot should describe the state of consensus")
+			}
+		case common.TraceRecordPoll:
+			if event.State != "" {
+				t.Fatalf("Polls shouldn't describe the state of consensus")
+			}
+		}
+	}
+	if snapshots == 0 {
+		t.Fatalf("Consensus should have been snapshotted")
+	}
+
+	replayedBlks := replayBlocks()
+	replayed := &snowman.Topological{}
+	params := config.Params
+	params.Metrics = prometheus.NewRegistry()
+	if err := Replay(snow.DefaultContextTest(), params, replayed, replayVM(t, replayedBlks), events); err != nil {
+		t.Fatal(err)
+	}
+	if !replayed.Preference().Equals(te.Consensus.Preference()) {
+		t.Fatalf("Replayed preference should be %s but is %s", te.Consensus.Preference(), replayed.Preference())
+	}
+}
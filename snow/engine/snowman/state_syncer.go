@@ -0,0 +1,286 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package snowman
+
+import (
+	stdmath "math"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/engine/common"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
+	"github.com/ava-labs/gecko/utils/formatting"
+	"github.com/ava-labs/gecko/utils/math"
+)
+
+const (
+	// maxPendingChunks is the maximum number of state chunks requested at once
+	maxPendingChunks = 16
+
+	// maxChunkAttempts is the number of times a state chunk is requested
+	// before state sync is abandoned
+	maxChunkAttempts = 5
+)
+
+// chunkRequest is an outstanding request for a state chunk
+type chunkRequest struct {
+	validatorID ids.ShortID
+	index       uint32
+}
+
+// stateSyncer tracks the progress of downloading the state of a
+// StateSyncableVM from the beacons
+type stateSyncer struct {
+	vm StateSyncableVM
+
+	// Summaries the beacons have yet to send, and the ID of the request for
+	// them
+	pendingSummaries ids.ShortSet
+	summaryRequestID uint32
+
+	// Summaries received, the stake that attested to each of them, and the
+	// validators that attested to each of them. Keys are summary IDs.
+	summaries      map[[32]byte]Summary
+	summaryWeights map[[32]byte]uint64
+	summaryVdrs    map[[32]byte][]ids.ShortID
+
+	// Summary being synced to, and the validators chunks are requested from
+	summary  Summary
+	syncVdrs []ids.ShortID
+	nextVdr  int
+
+	chunks        [][]byte
+	numChunks     uint32                  // number of verified chunks received
+	missingChunks []uint32                // indices of chunks to request
+	pendingChunks map[uint32]chunkRequest // keys are request IDs
+	chunkFailures map[uint32]int          // keys are chunk indices
+	synced        bool                    // true once state sync has ended
+}
+
+// startStateSync asks the beacons for a summary of their state
+func (b *bootstrapper) startStateSync(vm StateSyncableVM) {
+	b.Progress.SetPhase(common.PhaseStateSync)
+	b.vm = vm
+	b.summaries = make(map[[32]byte]Summary)
+	b.summaryWeights = make(map[[32]byte]uint64)
+	b.summaryVdrs = make(map[[32]byte][]ids.ShortID)
+
+	for _, vdr := range b.BootstrapConfig.Beacons.List() {
+		b.pendingSummaries.Add(vdr.ID())
+	}
+	// This node's state is what is being synced, so it can't attest to it
+	b.pendingSummaries.Remove(b.BootstrapConfig.Context.NodeID)
+
+	if b.pendingSummaries.Len() == 0 {
+		b.BootstrapConfig.Context.Log.Info("State sync skipped due to no provided bootstraps")
+		b.endStateSync()
+		return
+	}
+
+	vdrs := ids.ShortSet{}
+	vdrs.Union(b.pendingSummaries)
+
+	b.RequestID++
+	b.summaryRequestID = b.RequestID
+	b.BootstrapConfig.Sender.GetStateSummary(vdrs, b.RequestID)
+}
+
+// StateSummary implements the Engine interface.
+func (b *bootstrapper) StateSummary(vdr ids.ShortID, requestID uint32, summaryBytes []byte) {
+	if requestID != b.summaryRequestID || !b.pendingSummaries.Contains(vdr) {
+		b.BootstrapConfig.Context.Log.Debug("Received a StateSummary message from %s unexpectedly", vdr)
+		return
+	}
+	b.pendingSummaries.Remove(vdr)
+
+	summary, err := b.vm.ParseSummary(summaryBytes)
+	if err != nil {
+		b.BootstrapConfig.Context.Log.Warn("ParseSummary failed due to %s for summary:\n%s",
+			err,
+			formatting.DumpBytes{Bytes: summaryBytes})
+		b.BootstrapConfig.Context.Reputation.Report(vdr, reputation.InvalidContainer)
+	} else {
+		weight := uint64(0)
+		if validator, ok := b.BootstrapConfig.Validators.Get(vdr); ok {
+			weight = validator.Weight()
+		}
+
+		key := summary.ID().Key()
+		newWeight, err := math.Add64(weight, b.summaryWeights[key])
+		if err != nil {
+			newWeight = stdmath.MaxUint64
+		}
+		b.summaries[key] = summary
+		b.summaryWeights[key] = newWeight
+		b.summaryVdrs[key] = append(b.summaryVdrs[key], vdr)
+	}
+
+	if b.pendingSummaries.Len() == 0 {
+		b.chooseSummary()
+	}
+}
+
+// GetStateSummaryFailed implements the Engine interface.
+func (b *bootstrapper) GetStateSummaryFailed(vdr ids.ShortID, requestID uint32) {
+	if requestID != b.summaryRequestID || !b.pendingSummaries.Contains(vdr) {
+		return
+	}
+	b.pendingSummaries.Remove(vdr)
+
+	if b.pendingSummaries.Len() == 0 {
+		b.chooseSummary()
+	}
+}
+
+// chooseSummary starts downloading the state of the most recent summary that
+// enough stake attested to. If there isn't one, or it's no more recent than
+// this node's state, the chain is bootstrapped without state sync.
+func (b *bootstrapper) chooseSummary() {
+	bestKey := [32]byte{}
+	best := Summary(nil)
+	for key, weight := range b.summaryWeights {
+		if weight < b.BootstrapConfig.Alpha {
+			continue
+		}
+		if summary := b.summaries[key]; best == nil || summary.Height() > best.Height() {
+			bestKey = key
+			best = summary
+		}
+	}
+
+	if best == nil {
+		b.BootstrapConfig.Context.Log.Warn("State sync skipped as no state summary was attested to by enough stake")
+		b.endStateSync()
+		return
+	}
+	if current, err := b.vm.StateSummary(); err == nil && current.Height() >= best.Height() {
+		b.BootstrapConfig.Context.Log.Info("State sync skipped as this node's state is already at height %d", current.Height())
+		b.endStateSync()
+		return
+	}
+
+	b.BootstrapConfig.Context.Log.Info("State syncing to summary %s of block %s at height %d, which has %d chunks",
+		best.ID(), best.BlockID(), best.Height(), best.NumChunks())
+
+	b.summary = best
+	b.syncVdrs = b.summaryVdrs[bestKey]
+	b.chunks = make([][]byte, best.NumChunks())
+	b.pendingChunks = make(map[uint32]chunkRequest)
+	b.chunkFailures = make(map[uint32]int)
+	for i := uint32(0); i < best.NumChunks(); i++ {
+		b.missingChunks = append(b.missingChunks, i)
+	}
+
+	// The attestations are no longer needed
+	b.summaries = nil
+	b.summaryWeights = nil
+	b.summaryVdrs = nil
+
+	b.requestChunks()
+}
+
+// requestChunks requests missing chunks, round robin from the validators that
+// attested to the summary, until [maxPendingChunks] requests are outstanding
+func (b *bootstrapper) requestChunks() {
+	if b.numChunks == uint32(len(b.chunks)) {
+		b.finishStateSync()
+		return
+	}
+
+	for len(b.pendingChunks) < maxPendingChunks && len(b.missingChunks) > 0 {
+		index := b.missingChunks[0]
+		b.missingChunks = b.missingChunks[1:]
+
+		vdr := b.syncVdrs[b.nextVdr%len(b.syncVdrs)]
+		b.nextVdr++
+
+		b.RequestID++
+		b.pendingChunks[b.RequestID] = chunkRequest{
+			validatorID: vdr,
+			index:       index,
+		}
+		b.BootstrapConfig.Sender.GetStateChunk(vdr, b.RequestID, b.summary.ID(), index)
+	}
+}
+
+// StateChunk implements the Engine interface.
+func (b *bootstrapper) StateChunk(vdr ids.ShortID, requestID uint32, chunk []byte) {
+	request, ok := b.pendingChunks[requestID]
+	if !ok || !request.validatorID.Equals(vdr) {
+		b.BootstrapConfig.Context.Log.Debug("Received a StateChunk message from %s unexpectedly", vdr)
+		return
+	}
+	delete(b.pendingChunks, requestID)
+
+	if err := b.summary.VerifyChunk(request.index, chunk); err != nil {
+		b.BootstrapConfig.Context.Log.Warn("Chunk %d of state summary %s from %s failed verification due to %s",
+			request.index, b.summary.ID(), vdr, err)
+		b.BootstrapConfig.Context.Reputation.Report(vdr, reputation.InvalidContainer)
+		b.retryChunk(request.index)
+		return
+	}
+
+	b.chunks[request.index] = chunk
+	b.numChunks++
+	b.numStateChunks.Inc()
+	b.requestChunks()
+}
+
+// GetStateChunkFailed implements the Engine interface.
+func (b *bootstrapper) GetStateChunkFailed(vdr ids.ShortID, requestID uint32) {
+	request, ok := b.pendingChunks[requestID]
+	if !ok || !request.validatorID.Equals(vdr) {
+		return
+	}
+	delete(b.pendingChunks, requestID)
+
+	b.retryChunk(request.index)
+}
+
+// retryChunk requests the chunk at [index] again, unless it has already been
+// requested [maxChunkAttempts] times. Then the chunk may not be available from
+// any of the validators, so state sync is abandoned and the chain is
+// bootstrapped without it.
+func (b *bootstrapper) retryChunk(index uint32) {
+	b.chunkFailures[index]++
+	if b.chunkFailures[index] < maxChunkAttempts {
+		b.missingChunks = append(b.missingChunks, index)
+		b.requestChunks()
+		return
+	}
+
+	b.BootstrapConfig.Context.Log.Warn("State sync to summary %s abandoned as chunk %d failed %d times. Bootstrapping without state sync",
+		b.summary.ID(), index, b.chunkFailures[index])
+
+	b.synced = true
+	b.chunks = nil
+	b.missingChunks = nil
+	b.pendingChunks = nil
+	b.endStateSync()
+}
+
+// finishStateSync hands the downloaded state to the VM, and then bootstraps
+// the blocks accepted since the summary
+func (b *bootstrapper) finishStateSync() {
+	if b.synced {
+		return
+	}
+	b.synced = true
+
+	if err := b.vm.SyncState(b.summary, b.chunks); err != nil {
+		b.BootstrapConfig.Context.Log.Error("State sync to summary %s failed due to %s. Bootstrapping without state sync",
+			b.summary.ID(), err)
+	} else {
+		b.BootstrapConfig.Context.Log.Info("State sync finished at block %s", b.summary.BlockID())
+	}
+
+	b.chunks = nil
+	b.endStateSync()
+}
+
+// endStateSync bootstraps the blocks accepted since the state, if any, that was
+// synced to
+func (b *bootstrapper) endStateSync() {
+	b.Progress.SetPhase(common.PhaseFrontier)
+	b.Bootstrapper.Startup()
+}
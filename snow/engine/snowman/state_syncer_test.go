@@ -0,0 +1,260 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package snowman
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+)
+
+var (
+	errUnknownSummary = errors.New("unknown summary")
+	errInvalidChunk   = errors.New("invalid chunk")
+)
+
+type testSummary struct {
+	id, blkID ids.ID
+	height    uint64
+	chunks    [][]byte
+	bytes     []byte
+}
+
+func (s *testSummary) ID() ids.ID        { return s.id }
+func (s *testSummary) BlockID() ids.ID   { return s.blkID }
+func (s *testSummary) Height() uint64    { return s.height }
+func (s *testSummary) NumChunks() uint32 { return uint32(len(s.chunks)) }
+func (s *testSummary) Bytes() []byte     { return s.bytes }
+func (s *testSummary) VerifyChunk(index uint32, chunk []byte) error {
+	if int(index) >= len(s.chunks) || !bytes.Equal(s.chunks[index], chunk) {
+		return errInvalidChunk
+	}
+	return nil
+}
+
+type stateSyncableVMTest struct {
+	*VMTest
+
+	StateSummaryF  func() (Summary, error)
+	ParseSummaryF  func([]byte) (Summary, error)
+	GetStateChunkF func(ids.ID, uint32) ([]byte, error)
+	SyncStateF     func(Summary, [][]byte) error
+}
+
+func (vm *stateSyncableVMTest) StateSummary() (Summary, error) { return vm.StateSummaryF() }
+func (vm *stateSyncableVMTest) ParseSummary(b []byte) (Summary, error) {
+	return vm.ParseSummaryF(b)
+}
+func (vm *stateSyncableVMTest) GetStateChunk(summaryID ids.ID, index uint32) ([]byte, error) {
+	return vm.GetStateChunkF(summaryID, index)
+}
+func (vm *stateSyncableVMTest) SyncState(summary Summary, chunks [][]byte) error {
+	return vm.SyncStateF(summary, chunks)
+}
+
+func TestBootstrapperStateSync(t *testing.T) {
+	config, peerID, sender, vm := newConfig(t)
+
+	summary := &testSummary{
+		id:     ids.Empty.Prefix(0),
+		blkID:  ids.Empty.Prefix(1),
+		height: 10,
+		chunks: [][]byte{{0}, {1}, {2}},
+		bytes:  []byte{3},
+	}
+	ssVM := &stateSyncableVMTest{
+		VMTest: vm,
+		StateSummaryF: func() (Summary, error) {
+			return nil, errUnknownSummary
+		},
+		ParseSummaryF: func(b []byte) (Summary, error) {
+			if !bytes.Equal(b, summary.bytes) {
+				t.Fatalf("Parsed the wrong summary")
+			}
+			return summary, nil
+		},
+	}
+	config.VM = ssVM
+	config.StateSync = true
+
+	bs := bootstrapper{}
+	bs.metrics.Initialize(config.Context.Log, fmt.Sprintf("gecko_%s", config.Context.ChainID), prometheus.NewRegistry())
+	bs.Initialize(config)
+
+	summaryReqID := new(uint32)
+	sender.GetStateSummaryF = func(vdrs ids.ShortSet, reqID uint32) {
+		if vdrs.Len() != 1 || !vdrs.Contains(peerID) {
+			t.Fatalf("Should have requested a summary from %s", peerID)
+		}
+		*summaryReqID = reqID
+	}
+
+	bs.Startup()
+
+	chunkReqs := map[uint32]uint32{} // request ID -> chunk index
+	sender.GetStateChunkF = func(vdr ids.ShortID, reqID uint32, summaryID ids.ID, index uint32) {
+		if !vdr.Equals(peerID) {
+			t.Fatalf("Should have requested a chunk from %s, requested from %s", peerID, vdr)
+		}
+		if !summaryID.Equals(summary.id) {
+			t.Fatalf("Requested a chunk of the wrong summary")
+		}
+		chunkReqs[reqID] = index
+	}
+
+	bs.StateSummary(peerID, *summaryReqID, summary.bytes)
+
+	if len(chunkReqs) != len(summary.chunks) {
+		t.Fatalf("Should have requested %d chunks, requested %d", len(summary.chunks), len(chunkReqs))
+	}
+
+	synced := new(bool)
+	ssVM.SyncStateF = func(s Summary, chunks [][]byte) error {
+		if !s.ID().Equals(summary.id) {
+			t.Fatalf("Synced to the wrong summary")
+		}
+		for i, chunk := range chunks {
+			if !bytes.Equal(chunk, summary.chunks[i]) {
+				t.Fatalf("Synced chunk %d incorrectly", i)
+			}
+		}
+		*synced = true
+		return nil
+	}
+	startedBootstrap := new(bool)
+	sender.GetAcceptedFrontierF = func(ids.ShortSet, uint32) { *startedBootstrap = true }
+
+	// An invalid chunk should be requested again
+	for reqID, index := range chunkReqs {
+		if index != 0 {
+			continue
+		}
+		delete(chunkReqs, reqID)
+		bs.StateChunk(peerID, reqID, []byte{9})
+		break
+	}
+	if len(chunkReqs) != len(summary.chunks) {
+		t.Fatalf("Should have requested the invalid chunk again")
+	}
+
+	for reqID, index := range chunkReqs {
+		if *synced {
+			t.Fatalf("Synced before every chunk was received")
+		}
+		bs.StateChunk(peerID, reqID, summary.chunks[index])
+	}
+
+	if !*synced {
+		t.Fatalf("Should have synced the state")
+	}
+	if !*startedBootstrap {
+		t.Fatalf("Should have bootstrapped after syncing the state")
+	}
+}
+
+func TestBootstrapperStateSyncNoSummary(t *testing.T) {
+	config, peerID, sender, vm := newConfig(t)
+
+	config.VM = &stateSyncableVMTest{VMTest: vm}
+	config.StateSync = true
+
+	bs := bootstrapper{}
+	bs.metrics.Initialize(config.Context.Log, fmt.Sprintf("gecko_%s", config.Context.ChainID), prometheus.NewRegistry())
+	bs.Initialize(config)
+
+	summaryReqID := new(uint32)
+	sender.GetStateSummaryF = func(_ ids.ShortSet, reqID uint32) { *summaryReqID = reqID }
+
+	bs.Startup()
+
+	startedBootstrap := new(bool)
+	sender.GetAcceptedFrontierF = func(ids.ShortSet, uint32) { *startedBootstrap = true }
+
+	bs.GetStateSummaryFailed(peerID, *summaryReqID)
+
+	if !*startedBootstrap {
+		t.Fatalf("Should have bootstrapped without state sync")
+	}
+}
+
+func TestBootstrapperStateSyncAbandoned(t *testing.T) {
+	config, peerID, sender, vm := newConfig(t)
+
+	summary := &testSummary{
+		id:     ids.Empty.Prefix(0),
+		blkID:  ids.Empty.Prefix(1),
+		height: 10,
+		chunks: [][]byte{{0}, {1}},
+		bytes:  []byte{3},
+	}
+	ssVM := &stateSyncableVMTest{
+		VMTest: vm,
+		StateSummaryF: func() (Summary, error) {
+			return nil, errUnknownSummary
+		},
+		ParseSummaryF: func([]byte) (Summary, error) {
+			return summary, nil
+		},
+		SyncStateF: func(Summary, [][]byte) error {
+			t.Fatalf("Shouldn't have synced an incomplete state")
+			return nil
+		},
+	}
+	config.VM = ssVM
+	config.StateSync = true
+
+	bs := bootstrapper{}
+	bs.metrics.Initialize(config.Context.Log, fmt.Sprintf("gecko_%s", config.Context.ChainID), prometheus.NewRegistry())
+	bs.Initialize(config)
+
+	summaryReqID := new(uint32)
+	sender.GetStateSummaryF = func(_ ids.ShortSet, reqID uint32) { *summaryReqID = reqID }
+
+	bs.Startup()
+
+	chunkReqs := map[uint32]uint32{} // request ID -> chunk index
+	sender.GetStateChunkF = func(_ ids.ShortID, reqID uint32, _ ids.ID, index uint32) {
+		chunkReqs[reqID] = index
+	}
+
+	// A summary sent in response to another request is ignored
+	bs.StateSummary(peerID, *summaryReqID+1, summary.bytes)
+	if len(chunkReqs) != 0 {
+		t.Fatalf("Shouldn't have accepted a summary with the wrong request ID")
+	}
+
+	bs.StateSummary(peerID, *summaryReqID, summary.bytes)
+
+	startedBootstrap := new(bool)
+	sender.GetAcceptedFrontierF = func(ids.ShortSet, uint32) { *startedBootstrap = true }
+
+	// Chunk 0 is never delivered
+	for attempt := 1; attempt <= maxChunkAttempts; attempt++ {
+		if *startedBootstrap {
+			t.Fatalf("Abandoned state sync after %d attempts", attempt-1)
+		}
+		for reqID, index := range chunkReqs {
+			if index != 0 {
+				continue
+			}
+			delete(chunkReqs, reqID)
+			bs.GetStateChunkFailed(peerID, reqID)
+			break
+		}
+	}
+
+	if !*startedBootstrap {
+		t.Fatalf("Should have bootstrapped without state sync after chunk 0 failed %d times", maxChunkAttempts)
+	}
+
+	// Chunks that arrive after state sync was abandoned are ignored
+	for reqID, index := range chunkReqs {
+		bs.StateChunk(peerID, reqID, summary.chunks[index])
+	}
+}
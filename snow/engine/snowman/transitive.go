@@ -10,7 +10,9 @@ import (
 	"github.com/ava-labs/gecko/snow/consensus/snowman"
 	"github.com/ava-labs/gecko/snow/engine/common"
 	"github.com/ava-labs/gecko/snow/events"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
 	"github.com/ava-labs/gecko/utils/formatting"
+	"github.com/ava-labs/gecko/utils/wrappers"
 )
 
 // Transitive implements the Engine interface by attempting to fetch all
@@ -45,9 +47,13 @@ func (t *Transitive) Initialize(config Config) {
 }
 
 func (t *Transitive) finishBootstrapping() {
+	if vm, ok := t.Config.VM.(BootstrappableVM); ok {
+		vm.Bootstrapped()
+	}
 	tail := t.Config.VM.LastAccepted()
 	t.Config.VM.SetPreference(tail)
 	t.Consensus.Initialize(t.Config.Context, t.Params, tail)
+	t.Config.Trace.Start([]ids.ID{tail})
 	t.bootstrapped = true
 }
 
@@ -72,7 +78,8 @@ func (t *Transitive) Put(vdr ids.ShortID, requestID uint32, blkID ids.ID, blkByt
 	t.Config.Context.Log.Verbo("Put called for blockID %s", blkID)
 
 	if !t.bootstrapped {
-		t.bootstrapper.Put(vdr, requestID, blkID, blkBytes)
+		// Peers that can't send ancestors are asked for the block alone
+		t.bootstrapper.MultiPut(vdr, requestID, [][]byte{blkBytes})
 		return
 	}
 
@@ -81,6 +88,7 @@ func (t *Transitive) Put(vdr ids.ShortID, requestID uint32, blkID ids.ID, blkByt
 		t.Config.Context.Log.Warn("ParseBlock failed due to %s for block:\n%s",
 			err,
 			formatting.DumpBytes{Bytes: blkBytes})
+		t.Config.Context.Reputation.Report(vdr, reputation.InvalidContainer)
 		t.GetFailed(vdr, requestID, blkID)
 		return
 	}
@@ -91,7 +99,8 @@ func (t *Transitive) Put(vdr ids.ShortID, requestID uint32, blkID ids.ID, blkByt
 // GetFailed implements the Engine interface
 func (t *Transitive) GetFailed(vdr ids.ShortID, requestID uint32, blkID ids.ID) {
 	if !t.bootstrapped {
-		t.bootstrapper.GetFailed(vdr, requestID, blkID)
+		// Peers that can't send ancestors are asked for the block alone
+		t.bootstrapper.GetAncestorsFailed(vdr, requestID)
 		return
 	}
 
@@ -103,6 +112,89 @@ func (t *Transitive) GetFailed(vdr ids.ShortID, requestID uint32, blkID ids.ID)
 	t.numBlockedBlk.Set(float64(t.pending.Len()))
 }
 
+// GetAncestors implements the Engine interface
+func (t *Transitive) GetAncestors(vdr ids.ShortID, requestID uint32, blkID ids.ID) {
+	blk, err := t.Config.VM.GetBlock(blkID)
+	if err != nil {
+		t.Config.Context.Log.Debug("Dropping GetAncestors for unknown block %s", blkID)
+		return
+	}
+
+	ancestorsBytes := [][]byte{blk.Bytes()}
+	ancestorsBytesLen := len(blk.Bytes()) + wrappers.IntLen
+	for len(ancestorsBytes) < common.MaxContainersPerMultiPut {
+		blk = blk.Parent()
+		if blk == nil || blk.Status() == choices.Unknown {
+			break
+		}
+		blkBytes := blk.Bytes()
+		if ancestorsBytesLen += len(blkBytes) + wrappers.IntLen; ancestorsBytesLen > common.MaxContainersLen {
+			break
+		}
+		ancestorsBytes = append(ancestorsBytes, blkBytes)
+	}
+
+	t.Config.Sender.MultiPut(vdr, requestID, ancestorsBytes)
+}
+
+// MultiPut implements the Engine interface
+func (t *Transitive) MultiPut(vdr ids.ShortID, requestID uint32, blks [][]byte) {
+	if t.bootstrapped {
+		t.Config.Context.Log.Debug("Dropping MultiPut from %s as bootstrapping finished", vdr)
+		return
+	}
+	t.bootstrapper.MultiPut(vdr, requestID, blks)
+}
+
+// GetAncestorsFailed implements the Engine interface
+func (t *Transitive) GetAncestorsFailed(vdr ids.ShortID, requestID uint32) {
+	if t.bootstrapped {
+		t.Config.Context.Log.Debug("Dropping GetAncestorsFailed from %s as bootstrapping finished", vdr)
+		return
+	}
+	t.bootstrapper.GetAncestorsFailed(vdr, requestID)
+}
+
+// GetStateSummary implements the Engine interface
+func (t *Transitive) GetStateSummary(vdr ids.ShortID, requestID uint32) {
+	vm, ok := t.Config.VM.(StateSyncableVM)
+	if !ok {
+		t.Config.Context.Log.Debug("Dropping GetStateSummary from %s as the VM doesn't support state sync", vdr)
+		return
+	}
+	if !t.bootstrapped {
+		t.Config.Context.Log.Debug("Dropping GetStateSummary from %s due to bootstrapping", vdr)
+		return
+	}
+
+	summary, err := vm.StateSummary()
+	if err != nil {
+		t.Config.Context.Log.Warn("Failed to summarize the state due to %s", err)
+		return
+	}
+	t.Config.Sender.StateSummary(vdr, requestID, summary.Bytes())
+}
+
+// GetStateChunk implements the Engine interface
+func (t *Transitive) GetStateChunk(vdr ids.ShortID, requestID uint32, summaryID ids.ID, index uint32) {
+	vm, ok := t.Config.VM.(StateSyncableVM)
+	if !ok {
+		t.Config.Context.Log.Debug("Dropping GetStateChunk from %s as the VM doesn't support state sync", vdr)
+		return
+	}
+	if !t.bootstrapped {
+		t.Config.Context.Log.Debug("Dropping GetStateChunk from %s due to bootstrapping", vdr)
+		return
+	}
+
+	chunk, err := vm.GetStateChunk(summaryID, index)
+	if err != nil {
+		t.Config.Context.Log.Debug("Failed to get chunk %d of state summary %s due to %s", index, summaryID, err)
+		return
+	}
+	t.Config.Sender.StateChunk(vdr, requestID, chunk)
+}
+
 // PullQuery implements the Engine interface
 func (t *Transitive) PullQuery(vdr ids.ShortID, requestID uint32, blkID ids.ID) {
 	if !t.bootstrapped {
@@ -142,9 +234,12 @@ func (t *Transitive) Chits(vdr ids.ShortID, requestID uint32, votes ids.Set) {
 		return
 	}
 
+	t.Config.Trace.Chits(requestID, vdr, votes.List())
+
 	// Since this is snowman, there should only be one ID in the vote set
 	if votes.Len() != 1 {
 		t.Config.Context.Log.Warn("Chits was called with the wrong number of votes %d. ValidatorID: %s, RequestID: %d", votes.Len(), vdr, requestID)
+		t.Config.Context.Reputation.Report(vdr, reputation.InvalidChits)
 		t.QueryFailed(vdr, requestID)
 		return
 	}
@@ -173,6 +268,8 @@ func (t *Transitive) QueryFailed(vdr ids.ShortID, requestID uint32) {
 		return
 	}
 
+	t.Config.Trace.QueryFailed(requestID, vdr)
+
 	t.blocked.Register(&voter{
 		t:         t,
 		vdr:       vdr,
@@ -300,6 +397,7 @@ func (t *Transitive) pullSample(blkID ids.ID) {
 	t.RequestID++
 	if numVdrs := len(vdrs); numVdrs == p.K && t.polls.Add(t.RequestID, vdrSet.Len()) {
 		t.Config.Sender.PullQuery(vdrSet, t.RequestID, blkID)
+		t.Config.Trace.Poll(t.RequestID, blkID, vdrSet)
 	} else if numVdrs < p.K {
 		t.Config.Context.Log.Error("Query for %s was dropped due to an insufficient number of validators", blkID)
 	}
@@ -318,6 +416,7 @@ func (t *Transitive) pushSample(blk snowman.Block) bool {
 	queryIssued := false
 	if numVdrs := len(vdrs); numVdrs == p.K && t.polls.Add(t.RequestID, vdrSet.Len()) {
 		t.Config.Sender.PushQuery(vdrSet, t.RequestID, blk.ID(), blk.Bytes())
+		t.Config.Trace.Poll(t.RequestID, blk.ID(), vdrSet)
 		queryIssued = true
 	} else if numVdrs < p.K {
 		t.Config.Context.Log.Error("Query for %s was dropped due to an insufficient number of validators", blk.ID())
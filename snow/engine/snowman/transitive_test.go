@@ -1169,3 +1169,54 @@ func TestEngineUndeclaredDependencyDeadlock(t *testing.T) {
 		t.Fatalf("Should have bubbled invalid votes to the valid parent")
 	}
 }
+
+func TestEngineGetAncestors(t *testing.T) {
+	vdr, _, sender, vm, te, gBlk := setup(t)
+
+	blk1 := &Blk{
+		parent: gBlk,
+		id:     GenerateID(),
+		status: choices.Accepted,
+		bytes:  []byte{1},
+	}
+	blk2 := &Blk{
+		parent: blk1,
+		id:     GenerateID(),
+		status: choices.Processing,
+		bytes:  []byte{2},
+	}
+
+	vm.GetBlockF = func(blkID ids.ID) (snowman.Block, error) {
+		if !blkID.Equals(blk2.ID()) {
+			t.Fatalf("Wrong block requested")
+		}
+		return blk2, nil
+	}
+
+	sent := new(bool)
+	sender.MultiPutF = func(inVdr ids.ShortID, requestID uint32, blks [][]byte) {
+		*sent = true
+		if !inVdr.Equals(vdr.ID()) {
+			t.Fatalf("Sent to the wrong validator")
+		}
+		if requestID != 5 {
+			t.Fatalf("Wrong request ID")
+		}
+		// The genesis block has no parent, so the ancestry ends there
+		expected := [][]byte{blk2.Bytes(), blk1.Bytes(), gBlk.Bytes()}
+		if len(blks) != len(expected) {
+			t.Fatalf("Should have sent %d blocks, sent %d", len(expected), len(blks))
+		}
+		for i, blkBytes := range blks {
+			if !bytes.Equal(blkBytes, expected[i]) {
+				t.Fatalf("Sent the wrong block at index %d", i)
+			}
+		}
+	}
+
+	te.GetAncestors(vdr.ID(), 5, blk2.ID())
+
+	if !*sent {
+		t.Fatalf("Should have responded with the block's ancestors")
+	}
+}
@@ -55,3 +55,68 @@ type ChainVM interface {
 	// returned.
 	LastAccepted() ids.ID
 }
+
+// Summary commits to the state of a chain at an accepted block. The state is
+// split into chunks, each of which can be verified against the summary.
+type Summary interface {
+	// ID of this summary, which uniquely identifies its bytes
+	ID() ids.ID
+
+	// BlockID is the ID of the accepted block whose state this summary
+	// commits to
+	BlockID() ids.ID
+
+	// Height of the block whose state this summary commits to
+	Height() uint64
+
+	// NumChunks is the number of chunks the state is split into
+	NumChunks() uint32
+
+	// VerifyChunk returns nil iff [chunk] is the chunk at [index] of the state
+	// this summary commits to
+	VerifyChunk(index uint32, chunk []byte) error
+
+	// Bytes is the byte representation of this summary
+	Bytes() []byte
+}
+
+// BootstrappableVM is a ChainVM that is told when its chain finishes
+// bootstrapping. Until then, the blocks it verifies were already accepted by
+// the network.
+type BootstrappableVM interface {
+	ChainVM
+
+	// Bootstrapped is called once the chain is bootstrapped, before consensus
+	// starts
+	Bootstrapped()
+}
+
+// StateSyncableVM is a ChainVM whose state can be downloaded from other
+// validators, rather than rebuilt by executing every block since genesis.
+//
+// Bootstrapping a StateSyncableVM starts by asking the beacons for a summary
+// of their state. Once a summary is attested to by enough stake, its chunks
+// are downloaded and handed to the VM, after which the blocks accepted since
+// the summary are bootstrapped as usual.
+type StateSyncableVM interface {
+	ChainVM
+
+	// StateSummary returns a summary of the state at a recently accepted
+	// block. The state committed to by the summary must remain available
+	// through GetStateChunk for long enough for other validators to download
+	// it.
+	StateSummary() (Summary, error)
+
+	// ParseSummary attempts to create a summary from a stream of bytes
+	ParseSummary([]byte) (Summary, error)
+
+	// GetStateChunk returns the chunk at [index] of the state committed to by
+	// the summary with ID [summaryID]. Each chunk must fit in a single
+	// network message.
+	GetStateChunk(summaryID ids.ID, index uint32) ([]byte, error)
+
+	// SyncState replaces the state of the VM with the state committed to by
+	// [summary], made up of the already verified [chunks]. Afterwards, the
+	// block the summary commits to must be the last accepted block.
+	SyncState(summary Summary, chunks [][]byte) error
+}
@@ -5,6 +5,7 @@ package snowman
 
 import (
 	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/engine/common"
 )
 
 type voter struct {
@@ -47,6 +48,10 @@ func (v *voter) Update() {
 
 	v.t.Config.Context.Log.Verbo("Finishing poll [%d] with:\n%s", v.requestID, &results)
 	v.t.Consensus.RecordPoll(results)
+	v.t.Config.Trace.RecordPoll(v.requestID, common.BagVotes(results), []ids.ID{v.t.Consensus.Preference()})
+	if v.t.Config.Trace.SnapshotDue() {
+		v.t.Config.Trace.Snapshot([]ids.ID{v.t.Config.VM.LastAccepted()}, v.t.Consensus)
+	}
 
 	v.t.Config.VM.SetPreference(v.t.Consensus.Preference())
 
@@ -18,6 +18,8 @@ type Handler struct {
 	wg      sync.WaitGroup
 	engine  common.Engine
 	msgChan <-chan common.Message
+
+	recorder *Recorder // Records the dispatched messages, if not nil
 }
 
 // Initialize this consensus handler
@@ -29,6 +31,10 @@ func (h *Handler) Initialize(engine common.Engine, msgChan <-chan common.Message
 	h.wg.Add(1)
 }
 
+// Record every message dispatched to the engine with [recorder]. Must be
+// called before Dispatch. The recorder is closed when the handler shuts down.
+func (h *Handler) Record(recorder *Recorder) { h.recorder = recorder }
+
 // Context of this Handler
 func (h *Handler) Context() *snow.Context { return h.engine.Context() }
 
@@ -61,6 +67,7 @@ func (h *Handler) dispatchMsg(msg message) bool {
 	defer ctx.Lock.Unlock()
 
 	ctx.Log.Verbo("Forwarding message to consensus: %s", msg)
+	h.recorder.message(msg)
 
 	switch msg.messageType {
 	case getAcceptedFrontierMsg:
@@ -89,10 +96,31 @@ func (h *Handler) dispatchMsg(msg message) bool {
 		h.engine.QueryFailed(msg.validatorID, msg.requestID)
 	case chitsMsg:
 		h.engine.Chits(msg.validatorID, msg.requestID, msg.containerIDs)
+	case getStateSummaryMsg:
+		h.engine.GetStateSummary(msg.validatorID, msg.requestID)
+	case stateSummaryMsg:
+		h.engine.StateSummary(msg.validatorID, msg.requestID, msg.container)
+	case getStateSummaryFailedMsg:
+		h.engine.GetStateSummaryFailed(msg.validatorID, msg.requestID)
+	case getStateChunkMsg:
+		h.engine.GetStateChunk(msg.validatorID, msg.requestID, msg.containerID, msg.index)
+	case stateChunkMsg:
+		h.engine.StateChunk(msg.validatorID, msg.requestID, msg.container)
+	case getStateChunkFailedMsg:
+		h.engine.GetStateChunkFailed(msg.validatorID, msg.requestID)
+	case getAncestorsMsg:
+		h.engine.GetAncestors(msg.validatorID, msg.requestID, msg.containerID)
+	case multiPutMsg:
+		h.engine.MultiPut(msg.validatorID, msg.requestID, msg.containers)
+	case getAncestorsFailedMsg:
+		h.engine.GetAncestorsFailed(msg.validatorID, msg.requestID)
 	case notifyMsg:
 		h.engine.Notify(msg.notification)
 	case shutdownMsg:
 		h.engine.Shutdown()
+		if err := h.recorder.Close(); err != nil {
+			ctx.Log.Warn("Failed to record messages due to %s", err)
+		}
 		return false
 	}
 	return true
@@ -232,6 +260,102 @@ func (h *Handler) QueryFailed(validatorID ids.ShortID, requestID uint32) {
 	}
 }
 
+// GetStateSummary passes a GetStateSummary message received from the network
+// to the consensus engine.
+func (h *Handler) GetStateSummary(validatorID ids.ShortID, requestID uint32) {
+	h.msgs <- message{
+		messageType: getStateSummaryMsg,
+		validatorID: validatorID,
+		requestID:   requestID,
+	}
+}
+
+// StateSummary passes a StateSummary message received from the network to the
+// consensus engine.
+func (h *Handler) StateSummary(validatorID ids.ShortID, requestID uint32, summary []byte) {
+	h.msgs <- message{
+		messageType: stateSummaryMsg,
+		validatorID: validatorID,
+		requestID:   requestID,
+		container:   summary,
+	}
+}
+
+// GetStateSummaryFailed passes a GetStateSummaryFailed message to the
+// consensus engine.
+func (h *Handler) GetStateSummaryFailed(validatorID ids.ShortID, requestID uint32) {
+	h.msgs <- message{
+		messageType: getStateSummaryFailedMsg,
+		validatorID: validatorID,
+		requestID:   requestID,
+	}
+}
+
+// GetStateChunk passes a GetStateChunk message received from the network to
+// the consensus engine.
+func (h *Handler) GetStateChunk(validatorID ids.ShortID, requestID uint32, summaryID ids.ID, index uint32) {
+	h.msgs <- message{
+		messageType: getStateChunkMsg,
+		validatorID: validatorID,
+		requestID:   requestID,
+		containerID: summaryID,
+		index:       index,
+	}
+}
+
+// StateChunk passes a StateChunk message received from the network to the
+// consensus engine.
+func (h *Handler) StateChunk(validatorID ids.ShortID, requestID uint32, chunk []byte) {
+	h.msgs <- message{
+		messageType: stateChunkMsg,
+		validatorID: validatorID,
+		requestID:   requestID,
+		container:   chunk,
+	}
+}
+
+// GetStateChunkFailed passes a GetStateChunkFailed message to the consensus
+// engine.
+func (h *Handler) GetStateChunkFailed(validatorID ids.ShortID, requestID uint32) {
+	h.msgs <- message{
+		messageType: getStateChunkFailedMsg,
+		validatorID: validatorID,
+		requestID:   requestID,
+	}
+}
+
+// GetAncestors passes a GetAncestors message received from the network to the
+// consensus engine.
+func (h *Handler) GetAncestors(validatorID ids.ShortID, requestID uint32, containerID ids.ID) {
+	h.msgs <- message{
+		messageType: getAncestorsMsg,
+		validatorID: validatorID,
+		requestID:   requestID,
+		containerID: containerID,
+	}
+}
+
+// MultiPut passes a MultiPut message received from the network to the
+// consensus engine.
+func (h *Handler) MultiPut(validatorID ids.ShortID, requestID uint32, containers [][]byte) {
+	h.msgs <- message{
+		messageType: multiPutMsg,
+		validatorID: validatorID,
+		requestID:   requestID,
+		containers:  containers,
+	}
+}
+
+// GetAncestorsFailed passes a GetAncestorsFailed message to the consensus
+// engine.
+func (h *Handler) GetAncestorsFailed(validatorID ids.ShortID, requestID uint32) {
+	h.msgs <- message{
+		messageType: getAncestorsFailedMsg,
+		validatorID: validatorID,
+		requestID:   requestID,
+	}
+}
+
 // Shutdown shuts down the dispatcher
 func (h *Handler) Shutdown() { h.msgs <- message{messageType: shutdownMsg}; h.wg.Wait() }
 
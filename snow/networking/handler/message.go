@@ -28,6 +28,15 @@ const (
 	pullQueryMsg
 	chitsMsg
 	queryFailedMsg
+	getStateSummaryMsg
+	stateSummaryMsg
+	getStateSummaryFailedMsg
+	getStateChunkMsg
+	stateChunkMsg
+	getStateChunkFailedMsg
+	getAncestorsMsg
+	multiPutMsg
+	getAncestorsFailedMsg
 	notifyMsg
 	shutdownMsg
 )
@@ -38,7 +47,9 @@ type message struct {
 	requestID    uint32
 	containerID  ids.ID
 	container    []byte
+	containers   [][]byte
 	containerIDs ids.Set
+	index        uint32
 	notification common.Message
 }
 
@@ -85,6 +96,24 @@ func (t msgType) String() string {
 		return "Chits Message"
 	case queryFailedMsg:
 		return "Query Failed Message"
+	case getStateSummaryMsg:
+		return "Get State Summary Message"
+	case stateSummaryMsg:
+		return "State Summary Message"
+	case getStateSummaryFailedMsg:
+		return "Get State Summary Failed Message"
+	case getStateChunkMsg:
+		return "Get State Chunk Message"
+	case stateChunkMsg:
+		return "State Chunk Message"
+	case getStateChunkFailedMsg:
+		return "Get State Chunk Failed Message"
+	case getAncestorsMsg:
+		return "Get Ancestors Message"
+	case multiPutMsg:
+		return "Multi Put Message"
+	case getAncestorsFailedMsg:
+		return "Get Ancestors Failed Message"
 	case notifyMsg:
 		return "Notify Message"
 	case shutdownMsg:
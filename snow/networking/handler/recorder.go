@@ -0,0 +1,397 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package handler
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/engine/common"
+	"github.com/ava-labs/gecko/snow/validators"
+	"github.com/ava-labs/gecko/utils/timer"
+	"github.com/ava-labs/gecko/utils/wrappers"
+)
+
+// Kinds of records
+const (
+	messageRecord byte = iota // a message was dispatched to the engine
+	startupRecord             // the engine was started
+	sampleRecord              // the engine sampled validators
+)
+
+const (
+	maxRecordSize = math.MaxInt32
+	hashLen       = 32
+	shortHashLen  = 20
+)
+
+var (
+	errUnknownRecord = errors.New("unknown record kind")
+)
+
+// RecordedValidator is a validator as it was when a recording started
+type RecordedValidator struct {
+	ID     ids.ShortID `json:"id"`
+	Weight uint64      `json:"weight"`
+}
+
+// Header describes the chain a recording was made on, so that a fresh engine
+// and VM can be created to replay it
+type Header struct {
+	NetworkID   uint32              `json:"networkID"`
+	NodeID      ids.ShortID         `json:"nodeID"`
+	ChainID     ids.ID              `json:"chainID"`
+	SubnetID    ids.ID              `json:"subnetID"`
+	VMID        ids.ID              `json:"vmID"`
+	FxIDs       []ids.ID            `json:"fxIDs"`
+	GenesisData []byte              `json:"genesisData"`
+	Beacons     []RecordedValidator `json:"beacons"`
+
+	// Consensus parameters of the chain. Parents and BatchSize only apply to
+	// Avalanche chains.
+	K                 int `json:"k"`
+	Alpha             int `json:"alpha"`
+	BetaVirtuous      int `json:"betaVirtuous"`
+	BetaRogue         int `json:"betaRogue"`
+	ConcurrentRepolls int `json:"concurrentRepolls"`
+	Parents           int `json:"parents"`
+	BatchSize         int `json:"batchSize"`
+
+	// Consensus names the implementation of the chain's consensus. If empty,
+	// the chain used the topological implementation.
+	Consensus string `json:"consensus"`
+
+	// StateSync is true if the chain could be bootstrapped from a state
+	// summary, and ProposerWindows is true if the chain's VM was wrapped to
+	// give its validators turns to propose blocks. Both only apply to Snowman
+	// chains.
+	StateSync       bool `json:"stateSync"`
+	ProposerWindows bool `json:"proposerWindows"`
+}
+
+// Recorder writes every input of a chain's engine: the messages dispatched to
+// it, its startup and the validators it samples. Each record is written as
+// soon as it happens, so a recording is complete up to a crash of the node.
+// A nil *Recorder records nothing.
+type Recorder struct {
+	lock  sync.Mutex
+	clock timer.Clock
+
+	w   io.WriteCloser
+	err error // first error that occurred while writing
+}
+
+// Initialize the recorder to write to [w], starting with [header]
+func (r *Recorder) Initialize(w io.WriteCloser, header Header) error {
+	r.w = w
+
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	p := wrappers.Packer{MaxSize: maxRecordSize}
+	p.PackBytes(headerBytes)
+	if p.Errored() {
+		return p.Err
+	}
+	_, err = w.Write(p.Bytes)
+	return err
+}
+
+// Startup records that the engine was started
+func (r *Recorder) Startup() { r.write(startupRecord, func(*wrappers.Packer) {}) }
+
+// Validators returns [vdrs] with every sample of the validators recorded. The
+// returned set should be used as the engine's validators, as the validators
+// it samples decide which responses are counted.
+func (r *Recorder) Validators(vdrs validators.Set) validators.Set {
+	if r == nil {
+		return vdrs
+	}
+	return &recordedSet{
+		set:      vdrs,
+		recorder: r,
+	}
+}
+
+// Close the recorder. Returns the first error that occurred while recording,
+// if any.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.w.Close(); r.err == nil {
+		r.err = err
+	}
+	return r.err
+}
+
+func (r *Recorder) message(msg message) {
+	r.write(messageRecord, func(p *wrappers.Packer) {
+		p.PackInt(uint32(msg.messageType))
+		packShortID(p, msg.validatorID)
+		p.PackInt(msg.requestID)
+		packID(p, msg.containerID)
+		p.PackBytes(msg.container)
+		p.Pack2DByteSlice(msg.containers)
+		p.PackInt(uint32(msg.containerIDs.Len()))
+		for _, containerID := range msg.containerIDs.List() {
+			p.PackFixedBytes(containerID.Bytes())
+		}
+		p.PackInt(msg.index)
+		p.PackInt(uint32(msg.notification))
+	})
+}
+
+func (r *Recorder) sample(vdrs []validators.Validator) {
+	r.write(sampleRecord, func(p *wrappers.Packer) {
+		p.PackInt(uint32(len(vdrs)))
+		for _, vdr := range vdrs {
+			p.PackFixedBytes(vdr.ID().Bytes())
+			p.PackLong(vdr.Weight())
+		}
+	})
+}
+
+// write a record of kind [kind], whose contents are packed by [pack]. Records
+// are prefixed by their length. Once writing fails, nothing else is written.
+func (r *Recorder) write(kind byte, pack func(*wrappers.Packer)) {
+	if r == nil {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.err != nil {
+		return
+	}
+
+	// The length of the record is written once it's known
+	p := wrappers.Packer{
+		MaxSize: maxRecordSize,
+		Bytes:   make([]byte, wrappers.IntLen),
+		Offset:  wrappers.IntLen,
+	}
+	p.PackByte(kind)
+	p.PackLong(uint64(r.clock.Time().UnixNano()))
+	pack(&p)
+	if p.Errored() {
+		r.err = p.Err
+		return
+	}
+	binary.BigEndian.PutUint32(p.Bytes, uint32(len(p.Bytes)-wrappers.IntLen))
+
+	// The record is written at once, so a crash can only truncate the last
+	// record
+	_, r.err = r.w.Write(p.Bytes)
+}
+
+// set is embedded under another name, as the name Set is taken by its Set
+// method
+type set = validators.Set
+
+// recordedSet records the validators sampled from a set
+type recordedSet struct {
+	set
+	recorder *Recorder
+}
+
+func (s *recordedSet) Sample(size int) []validators.Validator {
+	vdrs := s.set.Sample(size)
+	s.recorder.sample(vdrs)
+	return vdrs
+}
+
+// Zero IDs are the IDs of messages that don't apply to a validator or a
+// container. They're packed as a flag followed by the ID, if any.
+
+func packID(p *wrappers.Packer, id ids.ID) {
+	p.PackBool(!id.IsZero())
+	if !id.IsZero() {
+		p.PackFixedBytes(id.Bytes())
+	}
+}
+
+func unpackID(p *wrappers.Packer) ids.ID {
+	if !p.UnpackBool() {
+		return ids.ID{}
+	}
+	id, err := ids.ToID(p.UnpackFixedBytes(hashLen))
+	p.Add(err)
+	return id
+}
+
+func packShortID(p *wrappers.Packer, id ids.ShortID) {
+	p.PackBool(!id.IsZero())
+	if !id.IsZero() {
+		p.PackFixedBytes(id.Bytes())
+	}
+}
+
+func unpackShortID(p *wrappers.Packer) ids.ShortID {
+	if !p.UnpackBool() {
+		return ids.ShortID{}
+	}
+	id, err := ids.ToShortID(p.UnpackFixedBytes(shortHashLen))
+	p.Add(err)
+	return id
+}
+
+// Recording is a recording of the inputs of a chain's engine, read back to be
+// replayed
+type Recording struct {
+	Header Header
+
+	records []record
+	samples [][]validators.Validator
+}
+
+// record is a message dispatched to the engine, or its startup
+type record struct {
+	startup bool
+	msg     message
+}
+
+// ReadRecording reads the recording written by a Recorder to [r]. If the
+// node crashed while writing the last record, that record is dropped.
+func ReadRecording(r io.Reader) (*Recording, error) {
+	headerBytes, err := readRecord(r)
+	if err != nil {
+		return nil, err
+	}
+	recording := &Recording{}
+	if err := json.Unmarshal(headerBytes, &recording.Header); err != nil {
+		return nil, err
+	}
+
+	for {
+		recordBytes, err := readRecord(r)
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			return recording, nil
+		default:
+			return nil, err
+		}
+
+		p := wrappers.Packer{Bytes: recordBytes}
+		kind := p.UnpackByte()
+		p.UnpackLong() // The time of the record isn't needed to replay it
+		switch kind {
+		case messageRecord:
+			recording.records = append(recording.records, record{msg: unpackMessage(&p)})
+		case startupRecord:
+			recording.records = append(recording.records, record{startup: true})
+		case sampleRecord:
+			recording.samples = append(recording.samples, unpackSample(&p))
+		default:
+			p.Add(errUnknownRecord)
+		}
+		if p.Errored() {
+			return nil, p.Err
+		}
+	}
+}
+
+// readRecord reads a length prefixed record from [r]
+func readRecord(r io.Reader) ([]byte, error) {
+	lenBytes := [wrappers.IntLen]byte{}
+	if _, err := io.ReadFull(r, lenBytes[:]); err != nil {
+		return nil, err
+	}
+	recordBytes := make([]byte, binary.BigEndian.Uint32(lenBytes[:]))
+	if _, err := io.ReadFull(r, recordBytes); err != nil {
+		return nil, err
+	}
+	return recordBytes, nil
+}
+
+func unpackMessage(p *wrappers.Packer) message {
+	msg := message{
+		messageType: msgType(p.UnpackInt()),
+		validatorID: unpackShortID(p),
+		requestID:   p.UnpackInt(),
+		containerID: unpackID(p),
+		container:   p.UnpackBytes(),
+		containers:  p.Unpack2DByteSlice(),
+	}
+	numContainerIDs := p.UnpackInt()
+	for i := uint32(0); i < numContainerIDs && !p.Errored(); i++ {
+		containerID, err := ids.ToID(p.UnpackFixedBytes(hashLen))
+		p.Add(err)
+		msg.containerIDs.Add(containerID)
+	}
+	msg.index = p.UnpackInt()
+	msg.notification = common.Message(p.UnpackInt())
+	return msg
+}
+
+func unpackSample(p *wrappers.Packer) []validators.Validator {
+	vdrs := []validators.Validator(nil)
+	numVdrs := p.UnpackInt()
+	for i := uint32(0); i < numVdrs && !p.Errored(); i++ {
+		vdrID, err := ids.ToShortID(p.UnpackFixedBytes(shortHashLen))
+		p.Add(err)
+		vdrs = append(vdrs, validators.NewValidator(vdrID, p.UnpackLong()))
+	}
+	return vdrs
+}
+
+// Sampler returns a sampler that returns the recorded samples of the
+// validators, in the order they were recorded. The engine replaying the
+// recording must sample its validators with it, as the validators it samples
+// decide which responses are counted.
+func (r *Recording) Sampler() validators.Sampler {
+	return &replaySampler{samples: r.samples}
+}
+
+// Replay feeds the recording into [engine]. The engine should be fresh, and
+// created for the chain described by the recording's header. Stops once the
+// engine is shut down or the recording ends. Returns the number of messages
+// that were dispatched.
+func (r *Recording) Replay(engine common.Engine) int {
+	h := &Handler{engine: engine}
+	ctx := engine.Context()
+	dispatched := 0
+	for _, record := range r.records {
+		if record.startup {
+			ctx.Lock.Lock()
+			engine.Startup()
+			ctx.Lock.Unlock()
+			continue
+		}
+		dispatched++
+		if !h.dispatchMsg(record.msg) {
+			break
+		}
+	}
+	return dispatched
+}
+
+// replaySampler returns recorded samples of validators
+type replaySampler struct{ samples [][]validators.Validator }
+
+func (s *replaySampler) Initialize([]validators.Validator) {}
+
+// Sample returns the next recorded sample. Once the recorded samples run out,
+// which only happens if the replay diverged from the recording, no validators
+// are returned.
+func (s *replaySampler) Sample(int) []validators.Validator {
+	if len(s.samples) == 0 {
+		return nil
+	}
+	vdrs := s.samples[0]
+	s.samples = s.samples[1:]
+	return vdrs
+}
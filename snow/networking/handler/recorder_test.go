@@ -0,0 +1,147 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/engine/common"
+	"github.com/ava-labs/gecko/snow/validators"
+)
+
+type testFile struct{ bytes.Buffer }
+
+func (*testFile) Close() error { return nil }
+
+// callsEngine returns an engine that appends a description of each of its
+// calls to [calls]
+func callsEngine(t *testing.T, calls *[]string) *common.EngineTest {
+	ctx := snow.DefaultContextTest()
+
+	engine := &common.EngineTest{}
+	engine.T = t
+	engine.Default(true)
+	engine.ContextF = func() *snow.Context { return ctx }
+	engine.StartupF = func() { *calls = append(*calls, "startup") }
+	engine.PutF = func(vdr ids.ShortID, requestID uint32, containerID ids.ID, container []byte) {
+		*calls = append(*calls, fmt.Sprintf("put %s %d %s %v", vdr, requestID, containerID, container))
+	}
+	engine.ChitsF = func(vdr ids.ShortID, requestID uint32, votes ids.Set) {
+		// Sets aren't ordered, so the votes are sorted to be compared
+		voteList := votes.List()
+		ids.SortIDs(voteList)
+		*calls = append(*calls, fmt.Sprintf("chits %s %d %s", vdr, requestID, voteList))
+	}
+	engine.MultiPutF = func(vdr ids.ShortID, requestID uint32, containers [][]byte) {
+		*calls = append(*calls, fmt.Sprintf("multiPut %s %d %v", vdr, requestID, containers))
+	}
+	engine.NotifyF = func(msg common.Message) { *calls = append(*calls, fmt.Sprintf("notify %s", msg)) }
+	engine.ShutdownF = func() { *calls = append(*calls, "shutdown") }
+	return engine
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	vdr := validators.GenerateRandomValidator(1)
+	vdrs := validators.NewSet()
+	vdrs.Add(vdr)
+
+	file := &testFile{}
+	recorder := &Recorder{}
+	header := Header{
+		ChainID: ids.Empty.Prefix(0),
+		VMID:    ids.Empty.Prefix(1),
+		Beacons: []RecordedValidator{{
+			ID:     vdr.ID(),
+			Weight: vdr.Weight(),
+		}},
+		K: 1,
+	}
+	if err := recorder.Initialize(file, header); err != nil {
+		t.Fatal(err)
+	}
+
+	recorded := []string(nil)
+	engine := callsEngine(t, &recorded)
+	msgChan := make(chan common.Message, 1)
+
+	handler := &Handler{}
+	handler.Initialize(engine, msgChan, 10)
+	handler.Record(recorder)
+
+	recorder.Startup()
+	engine.Startup()
+	if sampled := recorder.Validators(vdrs).Sample(1); len(sampled) != 1 {
+		t.Fatalf("Should have sampled the validator")
+	}
+
+	votes := ids.Set{}
+	votes.Add(ids.Empty.Prefix(2), ids.Empty.Prefix(3))
+	handler.Put(vdr.ID(), 1, ids.Empty.Prefix(2), []byte{1, 2})
+	handler.Chits(vdr.ID(), 2, votes)
+	handler.MultiPut(vdr.ID(), 3, [][]byte{{1}, {2, 3}})
+	handler.msgs <- message{messageType: notifyMsg, notification: common.PendingTxs}
+
+	go handler.Dispatch()
+	handler.Shutdown()
+
+	recording, err := ReadRecording(bytes.NewReader(file.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recording.Header.ChainID.Equals(header.ChainID) || !recording.Header.VMID.Equals(header.VMID) {
+		t.Fatalf("The header should have described the chain")
+	}
+	if len(recording.Header.Beacons) != 1 || !recording.Header.Beacons[0].ID.Equals(vdr.ID()) {
+		t.Fatalf("The header should have described the beacons")
+	}
+
+	replayed := []string(nil)
+	if dispatched := recording.Replay(callsEngine(t, &replayed)); dispatched != 5 {
+		t.Fatalf("Should have dispatched 5 messages but dispatched %d", dispatched)
+	}
+	if len(replayed) != len(recorded) {
+		t.Fatalf("Should have replayed %v but replayed %v", recorded, replayed)
+	}
+	for i, call := range recorded {
+		if replayed[i] != call {
+			t.Fatalf("Call %d should have been %q but was %q", i, call, replayed[i])
+		}
+	}
+
+	replayedVdrs := validators.NewSet()
+	replayedVdrs.SetSampler(recording.Sampler())
+	if sampled := replayedVdrs.Sample(1); len(sampled) != 1 || !sampled[0].ID().Equals(vdr.ID()) {
+		t.Fatalf("The recorded sample should have been replayed")
+	}
+	if sampled := replayedVdrs.Sample(1); len(sampled) != 0 {
+		t.Fatalf("Only the recorded samples should have been replayed")
+	}
+}
+
+func TestReadTruncatedRecording(t *testing.T) {
+	file := &testFile{}
+	recorder := &Recorder{}
+	if err := recorder.Initialize(file, Header{}); err != nil {
+		t.Fatal(err)
+	}
+	recorder.message(message{messageType: getMsg, validatorID: ids.ShortEmpty, containerID: ids.Empty})
+	recorder.message(message{messageType: putMsg, container: []byte{1, 2, 3}})
+
+	// The node crashed while writing the last record
+	recordingBytes := file.Bytes()
+	recording, err := ReadRecording(bytes.NewReader(recordingBytes[:len(recordingBytes)-1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recording.records) != 1 {
+		t.Fatalf("Should have read 1 record but read %d", len(recording.records))
+	}
+	if msg := recording.records[0].msg; msg.messageType != getMsg || !msg.containerID.Equals(ids.Empty) {
+		t.Fatalf("Should have read the first message")
+	}
+}
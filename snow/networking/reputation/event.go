@@ -0,0 +1,75 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package reputation
+
+import (
+	"github.com/ava-labs/gecko/ids"
+)
+
+// Event is a behavior of a peer that affects its reputation
+type Event uint32
+
+// Events that can be reported about a peer
+const (
+	// InvalidContainer is reported when a peer sends a container that can't
+	// be parsed
+	InvalidContainer Event = iota
+	// InvalidChits is reported when a peer answers a query with chits that
+	// can't be a valid answer
+	InvalidChits
+	// RequestTimedOut is reported when a peer doesn't answer a request in time
+	RequestTimedOut
+	// HandshakeFailed is reported when a peer fails the handshake, for example
+	// because it is running a different network or its clock is too skewed
+	HandshakeFailed
+	// InvalidPeerList is reported when a peer gossips an IP that wasn't
+	// correctly signed by the node that claims it
+	InvalidPeerList
+)
+
+// Penalty returns the amount that the event lowers the peer's score by
+func (e Event) Penalty() float64 {
+	switch e {
+	case InvalidContainer:
+		return 20
+	case InvalidChits:
+		return 10
+	case RequestTimedOut:
+		return 1
+	case HandshakeFailed:
+		return 50
+	case InvalidPeerList:
+		return 20
+	default:
+		return 0
+	}
+}
+
+func (e Event) String() string {
+	switch e {
+	case InvalidContainer:
+		return "Invalid Container"
+	case InvalidChits:
+		return "Invalid Chits"
+	case RequestTimedOut:
+		return "Request Timed Out"
+	case HandshakeFailed:
+		return "Handshake Failed"
+	case InvalidPeerList:
+		return "Invalid Peer List"
+	default:
+		return "Unknown Event"
+	}
+}
+
+// Reporter is fed with the events that affect the reputation of peers
+type Reporter interface {
+	Report(ids.ShortID, Event)
+}
+
+// NoReporter ignores all the reported events
+type NoReporter struct{}
+
+// Report implements the Reporter interface
+func (NoReporter) Report(ids.ShortID, Event) {}
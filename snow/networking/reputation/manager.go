@@ -0,0 +1,293 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package reputation
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/utils/logging"
+	"github.com/ava-labs/gecko/utils/timer"
+)
+
+const (
+	// recoveredScore is the penalty below which a peer is considered to have
+	// recovered
+	recoveredScore = 0.01
+
+	// maxTimeoutShare is the share of the ban threshold that timeouts can
+	// lower a peer's score by. An honest peer may be slow or briefly
+	// overloaded, so timeouts alone never get a peer banned.
+	maxTimeoutShare = 0.5
+)
+
+// Config defines when peers are banned
+type Config struct {
+	// BanThreshold is the score at, or below, which a peer is banned
+	BanThreshold float64
+
+	// BanDuration is the amount of time a peer is banned for
+	BanDuration time.Duration
+
+	// HalfLife is the amount of time it takes for half of a peer's penalties
+	// to be forgiven
+	HalfLife time.Duration
+}
+
+var (
+	errInvalidThreshold = errors.New("ban threshold must be negative and finite")
+	errInvalidDuration  = errors.New("ban duration must not be negative")
+	errInvalidHalfLife  = errors.New("score half life must not be negative")
+)
+
+// Valid returns nil if the config describes a valid ban policy. A half life of
+// 0 means that penalties are never forgiven.
+func (c Config) Valid() error {
+	switch {
+	case !(c.BanThreshold < 0) || math.IsInf(c.BanThreshold, -1):
+		return errInvalidThreshold
+	case c.BanDuration < 0:
+		return errInvalidDuration
+	case c.HalfLife < 0:
+		return errInvalidHalfLife
+	default:
+		return nil
+	}
+}
+
+// PeerScore is the current score of a peer
+type PeerScore struct {
+	ID    ids.ShortID
+	Score float64
+}
+
+// PeerBan is a peer that is currently banned
+type PeerBan struct {
+	ID    ids.ShortID
+	Until time.Time
+}
+
+// score of a peer at the time it was last updated. The penalties of timeouts
+// are kept apart from the others, so they can be capped.
+type score struct {
+	value    float64
+	timeouts float64
+	updated  time.Time
+}
+
+// Manager tracks the reputation of peers. Peers start with a score of 0. Each
+// reported event lowers the score of the peer by the penalty of the event, and
+// the score recovers towards 0 over time, although timeouts can only lower it
+// by half of the ban threshold. Peers whose score falls to the ban threshold
+// are banned.
+type Manager struct {
+	log    logging.Logger
+	config Config
+	clock  timer.Clock
+
+	lock        sync.Mutex
+	scores      map[[20]byte]*score
+	bans        map[[20]byte]time.Time
+	banHandlers []func(ids.ShortID)
+
+	numBanned prometheus.Gauge
+	numBans   prometheus.Counter
+}
+
+// Initialize this manager
+func (m *Manager) Initialize(log logging.Logger, config Config, registerer prometheus.Registerer) {
+	m.log = log
+	m.config = config
+	m.scores = make(map[[20]byte]*score)
+	m.bans = make(map[[20]byte]time.Time)
+
+	m.numBanned = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "gecko",
+			Name:      "banned_peers",
+			Help:      "Number of peers that are currently banned",
+		})
+	m.numBans = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gecko",
+			Name:      "peer_bans",
+			Help:      "Number of times a peer was banned",
+		})
+
+	if err := registerer.Register(m.numBanned); err != nil {
+		log.Error("Failed to register banned_peers statistics due to %s", err)
+	}
+	if err := registerer.Register(m.numBans); err != nil {
+		log.Error("Failed to register peer_bans statistics due to %s", err)
+	}
+}
+
+// RegisterBanHandler registers a handler that is called whenever a peer is
+// banned, so that it can be disconnected
+func (m *Manager) RegisterBanHandler(handler func(ids.ShortID)) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.banHandlers = append(m.banHandlers, handler)
+}
+
+// Report implements the Reporter interface
+func (m *Manager) Report(id ids.ShortID, event Event) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := m.clock.Time()
+	key := id.Key()
+
+	s, exists := m.scores[key]
+	if !exists {
+		s = &score{updated: now}
+		m.scores[key] = s
+	}
+	recovery := m.recovery(s, now)
+	s.value *= recovery
+	s.timeouts *= recovery
+	s.updated = now
+	if event == RequestTimedOut {
+		s.timeouts = math.Max(s.timeouts-event.Penalty(), maxTimeoutShare*m.config.BanThreshold)
+	} else {
+		s.value -= event.Penalty()
+	}
+	value := s.value + s.timeouts
+
+	m.log.Debug("Peer %s reported for %s. Score: %f", id, event, value)
+
+	if value <= m.config.BanThreshold && !m.banned(key, now) {
+		m.log.Info("Banning peer %s for %s due to a score of %f", id, m.config.BanDuration, value)
+		m.ban(id, now.Add(m.config.BanDuration))
+	}
+}
+
+// Score returns the current score of the peer
+func (m *Manager) Score(id ids.ShortID) float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	s, exists := m.scores[id.Key()]
+	if !exists {
+		return 0
+	}
+	return m.decay(s, m.clock.Time())
+}
+
+// Scores returns the current scores of the peers that have been reported.
+// Peers that have recovered from their penalties are forgotten.
+func (m *Manager) Scores() []PeerScore {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := m.clock.Time()
+	scores := []PeerScore(nil)
+	for key, s := range m.scores {
+		value := m.decay(s, now)
+		if value > -recoveredScore {
+			delete(m.scores, key)
+			continue
+		}
+		scores = append(scores, PeerScore{
+			ID:    ids.NewShortID(key),
+			Score: value,
+		})
+	}
+	return scores
+}
+
+// Ban the peer for [duration]. If the peer is already banned, the ban is
+// replaced.
+func (m *Manager) Ban(id ids.ShortID, duration time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.ban(id, m.clock.Time().Add(duration))
+}
+
+// Unban the peer and forgive its penalties
+func (m *Manager) Unban(id ids.ShortID) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	key := id.Key()
+	delete(m.bans, key)
+	delete(m.scores, key)
+	m.numBanned.Set(float64(len(m.bans)))
+}
+
+// Banned returns true if the peer is currently banned
+func (m *Manager) Banned(id ids.ShortID) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.banned(id.Key(), m.clock.Time())
+}
+
+// Bans returns the peers that are currently banned
+func (m *Manager) Bans() []PeerBan {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := m.clock.Time()
+	bans := []PeerBan(nil)
+	for key, until := range m.bans {
+		if m.banned(key, now) {
+			bans = append(bans, PeerBan{
+				ID:    ids.NewShortID(key),
+				Until: until,
+			})
+		}
+	}
+	return bans
+}
+
+// decay returns the score after it recovered until [now]. Assumes the lock is
+// held.
+func (m *Manager) decay(s *score, now time.Time) float64 {
+	return (s.value + s.timeouts) * m.recovery(s, now)
+}
+
+// recovery returns the factor the penalties of the score are multiplied by as
+// they recover until [now]. Assumes the lock is held.
+func (m *Manager) recovery(s *score, now time.Time) float64 {
+	if m.config.HalfLife <= 0 {
+		return 1
+	}
+	halfLives := now.Sub(s.updated).Seconds() / m.config.HalfLife.Seconds()
+	return math.Pow(0.5, halfLives)
+}
+
+// banned returns true if the peer is banned at [now]. Expired bans are
+// removed. Assumes the lock is held.
+func (m *Manager) banned(key [20]byte, now time.Time) bool {
+	until, exists := m.bans[key]
+	if !exists {
+		return false
+	}
+	if !now.Before(until) {
+		delete(m.bans, key)
+		m.numBanned.Set(float64(len(m.bans)))
+		return false
+	}
+	return true
+}
+
+// ban the peer until [until] and notify the ban handlers. Assumes the lock is
+// held.
+func (m *Manager) ban(id ids.ShortID, until time.Time) {
+	m.bans[id.Key()] = until
+	m.numBanned.Set(float64(len(m.bans)))
+	m.numBans.Inc()
+
+	for _, handler := range m.banHandlers {
+		go handler(id)
+	}
+}
@@ -0,0 +1,153 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package reputation
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/utils/logging"
+)
+
+func newTestManager() *Manager {
+	m := &Manager{}
+	m.Initialize(logging.NoLog{}, Config{
+		BanThreshold: -50,
+		BanDuration:  time.Hour,
+		HalfLife:     time.Minute,
+	}, prometheus.NewRegistry())
+	m.clock.Set(time.Unix(1000, 0))
+	return m
+}
+
+func TestManagerScoreDecays(t *testing.T) {
+	m := newTestManager()
+	peer := ids.NewShortID([20]byte{1})
+
+	m.Report(peer, InvalidContainer)
+	if score := m.Score(peer); score != -InvalidContainer.Penalty() {
+		t.Fatalf("Score was %f, expected %f", score, -InvalidContainer.Penalty())
+	}
+
+	m.clock.Set(m.clock.Time().Add(time.Minute))
+	if score := m.Score(peer); math.Abs(score+InvalidContainer.Penalty()/2) > 1e-9 {
+		t.Fatalf("Score was %f, expected %f", score, -InvalidContainer.Penalty()/2)
+	}
+}
+
+func TestManagerBans(t *testing.T) {
+	m := newTestManager()
+	peer := ids.NewShortID([20]byte{1})
+
+	banned := make(chan ids.ShortID, 1)
+	m.RegisterBanHandler(func(id ids.ShortID) { banned <- id })
+
+	m.Report(peer, InvalidContainer)
+	m.Report(peer, InvalidContainer)
+	if m.Banned(peer) {
+		t.Fatalf("Shouldn't have banned the peer above the threshold")
+	}
+
+	m.Report(peer, InvalidChits)
+	if !m.Banned(peer) {
+		t.Fatalf("Should have banned the peer at the threshold")
+	}
+	if id := <-banned; !id.Equals(peer) {
+		t.Fatalf("Ban handler was called with %s, expected %s", id, peer)
+	}
+	if bans := m.Bans(); len(bans) != 1 || !bans[0].ID.Equals(peer) {
+		t.Fatalf("Should have listed the ban")
+	}
+
+	m.clock.Set(m.clock.Time().Add(time.Hour))
+	if m.Banned(peer) {
+		t.Fatalf("Ban should have expired")
+	}
+}
+
+func TestManagerManualBan(t *testing.T) {
+	m := newTestManager()
+	peer := ids.NewShortID([20]byte{1})
+
+	m.Ban(peer, time.Minute)
+	if !m.Banned(peer) {
+		t.Fatalf("Should have banned the peer")
+	}
+
+	m.Report(peer, RequestTimedOut)
+	m.Unban(peer)
+	if m.Banned(peer) {
+		t.Fatalf("Should have unbanned the peer")
+	}
+	if score := m.Score(peer); score != 0 {
+		t.Fatalf("Unbanning should have forgiven the peer's penalties, but the score was %f", score)
+	}
+}
+
+func TestManagerForgetsRecoveredPeers(t *testing.T) {
+	m := newTestManager()
+	peer := ids.NewShortID([20]byte{1})
+
+	m.Report(peer, RequestTimedOut)
+	if scores := m.Scores(); len(scores) != 1 {
+		t.Fatalf("Should have listed the reported peer")
+	}
+
+	m.clock.Set(m.clock.Time().Add(time.Hour))
+	if scores := m.Scores(); len(scores) != 0 {
+		t.Fatalf("Should have forgotten the recovered peer")
+	}
+}
+
+func TestManagerTimeoutsDontBan(t *testing.T) {
+	m := newTestManager()
+	peer := ids.NewShortID([20]byte{1})
+
+	for i := 0; i < 1000; i++ {
+		m.Report(peer, RequestTimedOut)
+	}
+	if m.Banned(peer) {
+		t.Fatalf("Shouldn't have banned the peer for timeouts alone")
+	}
+	if score := m.Score(peer); score != -25 {
+		t.Fatalf("Timeouts should have lowered the score to -25, but it was %f", score)
+	}
+
+	// Timeouts still count towards a ban along with other misbehavior
+	m.Report(peer, InvalidContainer)
+	m.Report(peer, InvalidContainer)
+	if !m.Banned(peer) {
+		t.Fatalf("Should have banned the peer at the threshold")
+	}
+}
+
+func TestConfigValid(t *testing.T) {
+	valid := Config{
+		BanThreshold: -50,
+		BanDuration:  time.Hour,
+		HalfLife:     time.Minute,
+	}
+	if err := valid.Valid(); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []func(*Config){
+		func(c *Config) { c.BanThreshold = 0 },
+		func(c *Config) { c.BanThreshold = math.NaN() },
+		func(c *Config) { c.BanThreshold = math.Inf(-1) },
+		func(c *Config) { c.BanDuration = -time.Second },
+		func(c *Config) { c.HalfLife = -time.Second },
+	}
+	for i, invalidate := range invalid {
+		config := valid
+		invalidate(&config)
+		if err := config.Valid(); err == nil {
+			t.Fatalf("Config %d should have been invalid", i)
+		}
+	}
+}
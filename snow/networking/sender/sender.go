@@ -6,6 +6,7 @@ package sender
 import (
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/networking/reputation"
 	"github.com/ava-labs/gecko/snow/networking/router"
 	"github.com/ava-labs/gecko/snow/networking/timeout"
 )
@@ -39,6 +40,7 @@ func (s *Sender) GetAcceptedFrontier(validatorIDs ids.ShortSet, requestID uint32
 	for _, validatorID := range validatorList {
 		vID := validatorID
 		s.timeouts.Register(validatorID, s.ctx.ChainID, requestID, func() {
+			s.ctx.Reputation.Report(vID, reputation.RequestTimedOut)
 			s.router.GetAcceptedFrontierFailed(vID, s.ctx.ChainID, requestID)
 		})
 	}
@@ -64,6 +66,7 @@ func (s *Sender) GetAccepted(validatorIDs ids.ShortSet, requestID uint32, contai
 	for _, validatorID := range validatorList {
 		vID := validatorID
 		s.timeouts.Register(validatorID, s.ctx.ChainID, requestID, func() {
+			s.ctx.Reputation.Report(vID, reputation.RequestTimedOut)
 			s.router.GetAcceptedFailed(vID, s.ctx.ChainID, requestID)
 		})
 	}
@@ -88,6 +91,7 @@ func (s *Sender) Get(validatorID ids.ShortID, requestID uint32, containerID ids.
 	// Add a timeout -- if we don't get a response before the timeout expires,
 	// send this consensus engine a GetFailed message
 	s.timeouts.Register(validatorID, s.ctx.ChainID, requestID, func() {
+		s.ctx.Reputation.Report(validatorID, reputation.RequestTimedOut)
 		s.router.GetFailed(validatorID, s.ctx.ChainID, requestID, containerID)
 	})
 	s.sender.Get(validatorID, s.ctx.ChainID, requestID, containerID)
@@ -102,6 +106,28 @@ func (s *Sender) Put(validatorID ids.ShortID, requestID uint32, containerID ids.
 	s.sender.Put(validatorID, s.ctx.ChainID, requestID, containerID, container)
 }
 
+// GetAncestors sends a GetAncestors message to the consensus engine running on
+// the specified chain on the specified validator.
+// The GetAncestors message signifies that this consensus engine would like the
+// recipient to send it the specified container and as many of its ancestors as
+// fit in one MultiPut message.
+func (s *Sender) GetAncestors(validatorID ids.ShortID, requestID uint32, containerID ids.ID) {
+	s.ctx.Log.Verbo("Sending GetAncestors to validator %s. RequestID: %d. ContainerID: %s", validatorID, requestID, containerID)
+	s.timeouts.Register(validatorID, s.ctx.ChainID, requestID, func() {
+		s.ctx.Reputation.Report(validatorID, reputation.RequestTimedOut)
+		s.router.GetAncestorsFailed(validatorID, s.ctx.ChainID, requestID)
+	})
+	s.sender.GetAncestors(validatorID, s.ctx.ChainID, requestID, containerID)
+}
+
+// MultiPut sends a MultiPut message to the consensus engine running on the
+// specified chain on the specified validator, in response to a GetAncestors
+// message.
+func (s *Sender) MultiPut(validatorID ids.ShortID, requestID uint32, containers [][]byte) {
+	s.ctx.Log.Verbo("Sending MultiPut to validator %s. RequestID: %d. NumContainers: %d", validatorID, requestID, len(containers))
+	s.sender.MultiPut(validatorID, s.ctx.ChainID, requestID, containers)
+}
+
 // PushQuery sends a PushQuery message to the consensus engines running on the specified chains
 // on the specified validators.
 // The PushQuery message signifies that this consensus engine would like each validator to send
@@ -121,6 +147,7 @@ func (s *Sender) PushQuery(validatorIDs ids.ShortSet, requestID uint32, containe
 	for _, validatorID := range validatorList {
 		vID := validatorID
 		s.timeouts.Register(validatorID, s.ctx.ChainID, requestID, func() {
+			s.ctx.Reputation.Report(vID, reputation.RequestTimedOut)
 			s.router.QueryFailed(vID, s.ctx.ChainID, requestID)
 		})
 	}
@@ -146,6 +173,7 @@ func (s *Sender) PullQuery(validatorIDs ids.ShortSet, requestID uint32, containe
 	for _, validatorID := range validatorList {
 		vID := validatorID
 		s.timeouts.Register(validatorID, s.ctx.ChainID, requestID, func() {
+			s.ctx.Reputation.Report(vID, reputation.RequestTimedOut)
 			s.router.QueryFailed(vID, s.ctx.ChainID, requestID)
 		})
 	}
@@ -163,3 +191,48 @@ func (s *Sender) Chits(validatorID ids.ShortID, requestID uint32, votes ids.Set)
 	}
 	s.sender.Chits(validatorID, s.ctx.ChainID, requestID, votes)
 }
+
+// GetStateSummary requests that every validator in [validatorIDs] sends a
+// summary of its state
+func (s *Sender) GetStateSummary(validatorIDs ids.ShortSet, requestID uint32) {
+	if validatorIDs.Contains(s.ctx.NodeID) {
+		validatorIDs.Remove(s.ctx.NodeID)
+		go s.router.GetStateSummary(s.ctx.NodeID, s.ctx.ChainID, requestID)
+	}
+	validatorList := validatorIDs.List()
+	for _, validatorID := range validatorList {
+		vID := validatorID
+		s.timeouts.Register(validatorID, s.ctx.ChainID, requestID, func() {
+			s.ctx.Reputation.Report(vID, reputation.RequestTimedOut)
+			s.router.GetStateSummaryFailed(vID, s.ctx.ChainID, requestID)
+		})
+	}
+	s.sender.GetStateSummary(validatorIDs, s.ctx.ChainID, requestID)
+}
+
+// StateSummary responds to a GetStateSummary message with a summary of this
+// validator's state
+func (s *Sender) StateSummary(validatorID ids.ShortID, requestID uint32, summary []byte) {
+	if validatorID.Equals(s.ctx.NodeID) {
+		go s.router.StateSummary(validatorID, s.ctx.ChainID, requestID, summary)
+		return
+	}
+	s.sender.StateSummary(validatorID, s.ctx.ChainID, requestID, summary)
+}
+
+// GetStateChunk requests that the specified validator send the chunk at
+// [index] of the state committed to by the summary with ID [summaryID]
+func (s *Sender) GetStateChunk(validatorID ids.ShortID, requestID uint32, summaryID ids.ID, index uint32) {
+	s.ctx.Log.Verbo("Sending GetStateChunk to validator %s. RequestID: %d. SummaryID: %s. Index: %d", validatorID, requestID, summaryID, index)
+	s.timeouts.Register(validatorID, s.ctx.ChainID, requestID, func() {
+		s.ctx.Reputation.Report(validatorID, reputation.RequestTimedOut)
+		s.router.GetStateChunkFailed(validatorID, s.ctx.ChainID, requestID)
+	})
+	s.sender.GetStateChunk(validatorID, s.ctx.ChainID, requestID, summaryID, index)
+}
+
+// StateChunk responds to a GetStateChunk message with a chunk of state
+func (s *Sender) StateChunk(validatorID ids.ShortID, requestID uint32, chunk []byte) {
+	s.ctx.Log.Verbo("Sending StateChunk to validator %s. RequestID: %d", validatorID, requestID)
+	s.sender.StateChunk(validatorID, s.ctx.ChainID, requestID, chunk)
+}
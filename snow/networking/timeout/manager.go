@@ -4,25 +4,160 @@
 package timeout
 
 import (
+	"errors"
+	"math"
+	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/utils/hashing"
+	"github.com/ava-labs/gecko/utils/logging"
 	"github.com/ava-labs/gecko/utils/timer"
 	"github.com/ava-labs/gecko/utils/wrappers"
 )
 
-// Manager registers and fires timeouts for the snow API.
-type Manager struct{ tm timer.TimeoutManager }
+const (
+	// MaxTrackedPeers is the maximum number of peers whose latency is tracked.
+	// Once full, the peer whose latency was sampled least recently is
+	// forgotten to make room for a new one, so that peers that left the
+	// network don't accumulate.
+	MaxTrackedPeers = 1000
+)
+
+// Config defines how the timeout of requests to each peer adapts to the
+// latency of the peer's responses
+type Config struct {
+	// InitialTimeout is the timeout of requests to peers that haven't
+	// responded to any requests yet
+	InitialTimeout time.Duration
+
+	// MinimumTimeout and MaximumTimeout bound the timeout of every request
+	MinimumTimeout, MaximumTimeout time.Duration
+
+	// LatencyMultiplier is the multiple of a peer's average latency that is
+	// allowed before its requests time out
+	LatencyMultiplier float64
+
+	// LatencyWeight, in (0, 1], is the weight of each new latency sample in a
+	// peer's exponentially weighted moving average latency
+	LatencyWeight float64
+
+	// FailureReduction, in (0, 1], is the factor a peer's timeout is
+	// multiplied by for each consecutive request to the peer that failed
+	FailureReduction float64
+}
+
+var (
+	errInvalidBounds     = errors.New("minimum timeout must be positive and at most the maximum timeout")
+	errInvalidInitial    = errors.New("initial timeout must be between the minimum and maximum timeouts")
+	errInvalidMultiplier = errors.New("latency multiplier must be at least 1")
+	errInvalidWeight     = errors.New("latency weight must be in (0, 1]")
+	errInvalidReduction  = errors.New("failure reduction must be in (0, 1]")
+)
+
+// Valid returns nil if the config describes a valid timeout policy
+func (c Config) Valid() error {
+	switch {
+	case c.MinimumTimeout <= 0 || c.MinimumTimeout > c.MaximumTimeout:
+		return errInvalidBounds
+	case c.InitialTimeout < c.MinimumTimeout || c.InitialTimeout > c.MaximumTimeout:
+		return errInvalidInitial
+	case c.LatencyMultiplier < 1:
+		return errInvalidMultiplier
+	case c.LatencyWeight <= 0 || c.LatencyWeight > 1:
+		return errInvalidWeight
+	case c.FailureReduction <= 0 || c.FailureReduction > 1:
+		return errInvalidReduction
+	default:
+		return nil
+	}
+}
+
+// PeerTimeout is the current estimate of a peer's latency, and the timeout of
+// requests sent to the peer
+type PeerTimeout struct {
+	ID       ids.ShortID
+	Latency  time.Duration // zero if the peer hasn't responded to a request
+	Timeout  time.Duration
+	Failures int // consecutive requests that failed
+}
+
+// peer is the latency history of a peer
+type peer struct {
+	latency  time.Duration // moving average of the latency of responses
+	observed bool          // true if latency has been sampled
+	failures int           // consecutive failures
+	updated  time.Time     // time the latency or failures last changed
+}
+
+// request is an outstanding request, or a failed request that may still be
+// answered
+type request struct {
+	validatorID ids.ShortID
+	sent        time.Time
+	failed      time.Time // zero unless the request failed
+}
+
+// Manager registers and fires timeouts for the snow API. The timeout of a
+// request depends on the validator it was sent to: it is a multiple of the
+// moving average of the validator's response latency, reduced for each
+// consecutive request to the validator that failed, and kept within the
+// configured bounds. Responses that arrive after their request failed are still
+// sampled, so that the timeout of a slow validator grows to fit its latency
+// rather than shrinking with every failure.
+type Manager struct {
+	tm     timer.TimeoutManager
+	config Config
+	clock  timer.Clock
+
+	lock     sync.Mutex
+	peers    map[[20]byte]*peer   // keys are validator IDs
+	requests map[[32]byte]request // keys are request IDs
+	late     map[[32]byte]request // failed requests that may still be answered
+
+	latencies, timeouts *prometheus.GaugeVec
+}
 
 // Initialize this timeout manager.
 //
 // External requests are requests that depend on other nodes to perform an
 // action. Internal requests are requests that only exist inside this node.
 //
-// [duration] is the amount of time to allow for external requests
+// [config] determines the amount of time to allow for external requests
 // before the request times out.
-func (m *Manager) Initialize(duration time.Duration) { m.tm.Initialize(duration) }
+func (m *Manager) Initialize(log logging.Logger, config Config, registerer prometheus.Registerer) {
+	m.config = config
+	m.peers = make(map[[20]byte]*peer)
+	m.requests = make(map[[32]byte]request)
+	m.late = make(map[[32]byte]request)
+	m.tm.Initialize(config.InitialTimeout)
+
+	m.latencies = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "gecko",
+			Name:      "peer_latency",
+			Help:      "Moving average of the response latency of each validator in milliseconds",
+		},
+		[]string{"validator"},
+	)
+	m.timeouts = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "gecko",
+			Name:      "peer_timeout",
+			Help:      "Timeout of requests sent to each validator in milliseconds",
+		},
+		[]string{"validator"},
+	)
+
+	if err := registerer.Register(m.latencies); err != nil {
+		log.Error("Failed to register peer_latency statistics due to %s", err)
+	}
+	if err := registerer.Register(m.timeouts); err != nil {
+		log.Error("Failed to register peer_timeout statistics due to %s", err)
+	}
+}
 
 // Dispatch ...
 func (m *Manager) Dispatch() { m.tm.Dispatch() }
@@ -30,12 +165,175 @@ func (m *Manager) Dispatch() { m.tm.Dispatch() }
 // Register request to time out unless Manager.Cancel is called
 // before the timeout duration passes, with the same request parameters.
 func (m *Manager) Register(validatorID ids.ShortID, chainID ids.ID, requestID uint32, timeout func()) {
-	m.tm.Put(createRequestID(validatorID, chainID, requestID), timeout)
+	id := createRequestID(validatorID, chainID, requestID)
+
+	m.lock.Lock()
+	delete(m.late, id.Key())
+	m.requests[id.Key()] = request{
+		validatorID: validatorID,
+		sent:        m.clock.Time(),
+	}
+	duration := m.timeout(m.peers[validatorID.Key()])
+	m.lock.Unlock()
+
+	m.tm.PutTimeout(id, duration, func() {
+		m.Fail(validatorID, chainID, requestID)
+		timeout()
+	})
 }
 
-// Cancel request timeout with the specified parameters.
+// Cancel request timeout with the specified parameters. The request is
+// considered to have been answered, so its latency is recorded, even if the
+// request already failed.
 func (m *Manager) Cancel(validatorID ids.ShortID, chainID ids.ID, requestID uint32) {
-	m.tm.Remove(createRequestID(validatorID, chainID, requestID))
+	id := createRequestID(validatorID, chainID, requestID)
+	m.tm.Remove(id)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	req, exists := m.requests[id.Key()]
+	if exists {
+		delete(m.requests, id.Key())
+	} else if req, exists = m.late[id.Key()]; exists {
+		delete(m.late, id.Key())
+	} else {
+		return
+	}
+
+	p := m.peer(validatorID)
+	latency := m.clock.Time().Sub(req.sent)
+	if p.observed {
+		weight := m.config.LatencyWeight
+		p.latency = time.Duration(weight*float64(latency) + (1-weight)*float64(p.latency))
+	} else {
+		p.latency = latency
+		p.observed = true
+	}
+	p.failures = 0
+	m.update(validatorID, p)
+}
+
+// Fail cancels the request timeout with the specified parameters. The request
+// is considered to have failed, which shortens the timeout of later requests
+// to the validator. If the validator answers the request within the maximum
+// timeout after it failed, the latency of the answer is still recorded.
+func (m *Manager) Fail(validatorID ids.ShortID, chainID ids.ID, requestID uint32) {
+	id := createRequestID(validatorID, chainID, requestID)
+	m.tm.Remove(id)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	req, exists := m.requests[id.Key()]
+	if !exists {
+		return
+	}
+	delete(m.requests, id.Key())
+
+	now := m.clock.Time()
+	for key, lateReq := range m.late {
+		if now.Sub(lateReq.failed) > m.config.MaximumTimeout {
+			delete(m.late, key)
+		}
+	}
+	req.failed = now
+	m.late[id.Key()] = req
+
+	p := m.peer(validatorID)
+	p.failures++
+	m.update(validatorID, p)
+}
+
+// Timeout returns the timeout of the next request sent to [validatorID]
+func (m *Manager) Timeout(validatorID ids.ShortID) time.Duration {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.timeout(m.peers[validatorID.Key()])
+}
+
+// Estimates returns the latency and timeout of every validator that requests
+// have been sent to
+func (m *Manager) Estimates() []PeerTimeout {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	estimates := make([]PeerTimeout, 0, len(m.peers))
+	for key, p := range m.peers {
+		estimates = append(estimates, PeerTimeout{
+			ID:       ids.NewShortID(key),
+			Latency:  p.latency,
+			Timeout:  m.timeout(p),
+			Failures: p.failures,
+		})
+	}
+	return estimates
+}
+
+// peer returns the latency history of [validatorID], creating it if needed.
+// Assumes the lock is held.
+func (m *Manager) peer(validatorID ids.ShortID) *peer {
+	p, exists := m.peers[validatorID.Key()]
+	if !exists {
+		if len(m.peers) >= MaxTrackedPeers {
+			m.evict()
+		}
+		p = &peer{}
+		m.peers[validatorID.Key()] = p
+	}
+	return p
+}
+
+// evict forgets the peer whose latency history was updated least recently.
+// Assumes the lock is held.
+func (m *Manager) evict() {
+	oldestKey := [20]byte{}
+	var oldest *peer
+	for key, p := range m.peers {
+		if oldest == nil || p.updated.Before(oldest.updated) {
+			oldestKey = key
+			oldest = p
+		}
+	}
+	if oldest == nil {
+		return
+	}
+
+	delete(m.peers, oldestKey)
+	validator := ids.NewShortID(oldestKey).String()
+	m.latencies.DeleteLabelValues(validator)
+	m.timeouts.DeleteLabelValues(validator)
+}
+
+// timeout of requests to [p], which may be nil if no requests were sent to
+// the peer
+func (m *Manager) timeout(p *peer) time.Duration {
+	timeout := float64(m.config.InitialTimeout)
+	if p != nil {
+		if p.observed {
+			timeout = m.config.LatencyMultiplier * float64(p.latency)
+		}
+		timeout *= math.Pow(m.config.FailureReduction, float64(p.failures))
+	}
+
+	switch {
+	case timeout < float64(m.config.MinimumTimeout):
+		return m.config.MinimumTimeout
+	case timeout > float64(m.config.MaximumTimeout):
+		return m.config.MaximumTimeout
+	default:
+		return time.Duration(timeout)
+	}
+}
+
+// update the exported estimates of [validatorID]. Assumes the lock is held.
+func (m *Manager) update(validatorID ids.ShortID, p *peer) {
+	p.updated = m.clock.Time()
+
+	validator := validatorID.String()
+	m.latencies.WithLabelValues(validator).Set(float64(p.latency) / float64(time.Millisecond))
+	m.timeouts.WithLabelValues(validator).Set(float64(m.timeout(p)) / float64(time.Millisecond))
 }
 
 func createRequestID(validatorID ids.ShortID, chainID ids.ID, requestID uint32) ids.ID {
@@ -8,12 +8,37 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/utils/logging"
 )
 
+func fixedConfig(duration time.Duration) Config {
+	return Config{
+		InitialTimeout:    duration,
+		MinimumTimeout:    duration,
+		MaximumTimeout:    duration,
+		LatencyMultiplier: 1,
+		LatencyWeight:     1,
+		FailureReduction:  1,
+	}
+}
+
+func adaptiveConfig() Config {
+	return Config{
+		InitialTimeout:    2 * time.Second,
+		MinimumTimeout:    100 * time.Millisecond,
+		MaximumTimeout:    10 * time.Second,
+		LatencyMultiplier: 2,
+		LatencyWeight:     0.5,
+		FailureReduction:  0.5,
+	}
+}
+
 func TestManagerFire(t *testing.T) {
 	manager := Manager{}
-	manager.Initialize(time.Millisecond)
+	manager.Initialize(logging.NoLog{}, fixedConfig(time.Millisecond), prometheus.NewRegistry())
 	go manager.Dispatch()
 
 	wg := sync.WaitGroup{}
@@ -26,7 +51,7 @@ func TestManagerFire(t *testing.T) {
 
 func TestManagerCancel(t *testing.T) {
 	manager := Manager{}
-	manager.Initialize(50 * time.Millisecond)
+	manager.Initialize(logging.NoLog{}, fixedConfig(50*time.Millisecond), prometheus.NewRegistry())
 	go manager.Dispatch()
 
 	wg := sync.WaitGroup{}
@@ -46,3 +71,198 @@ func TestManagerCancel(t *testing.T) {
 		t.Fatalf("Should have cancelled the function")
 	}
 }
+
+func TestManagerLatency(t *testing.T) {
+	manager := Manager{}
+	manager.Initialize(logging.NoLog{}, adaptiveConfig(), prometheus.NewRegistry())
+
+	vdr := ids.NewShortID([20]byte{1})
+	chainID := ids.NewID([32]byte{})
+	now := time.Now()
+
+	if timeout := manager.Timeout(vdr); timeout != 2*time.Second {
+		t.Fatalf("Timeout of a new peer should be %s, was %s", 2*time.Second, timeout)
+	}
+
+	manager.clock.Set(now)
+	manager.Register(vdr, chainID, 0, func() {})
+	manager.clock.Set(now.Add(time.Second))
+	manager.Cancel(vdr, chainID, 0)
+
+	if timeout := manager.Timeout(vdr); timeout != 2*time.Second {
+		t.Fatalf("Timeout should be twice the latency, was %s", timeout)
+	}
+
+	manager.Register(vdr, chainID, 1, func() {})
+	manager.clock.Set(now.Add(1500 * time.Millisecond))
+	manager.Cancel(vdr, chainID, 1)
+
+	// The average latency is now (1s + 500ms) / 2
+	if timeout := manager.Timeout(vdr); timeout != 1500*time.Millisecond {
+		t.Fatalf("Timeout should follow the average latency, was %s", timeout)
+	}
+
+	// Cancelling an unknown request shouldn't change the latency
+	manager.Cancel(vdr, chainID, 1)
+	if timeout := manager.Timeout(vdr); timeout != 1500*time.Millisecond {
+		t.Fatalf("Timeout shouldn't have changed, was %s", timeout)
+	}
+
+	manager.Register(vdr, chainID, 2, func() {})
+	manager.clock.Set(now.Add(time.Minute))
+	manager.Cancel(vdr, chainID, 2)
+
+	if timeout := manager.Timeout(vdr); timeout != 10*time.Second {
+		t.Fatalf("Timeout should be capped at the maximum, was %s", timeout)
+	}
+
+	estimates := manager.Estimates()
+	if len(estimates) != 1 {
+		t.Fatalf("Should have estimated the latency of 1 peer, estimated %d", len(estimates))
+	}
+	if !estimates[0].ID.Equals(vdr) {
+		t.Fatalf("Estimated the latency of the wrong peer")
+	}
+	if estimates[0].Timeout != 10*time.Second {
+		t.Fatalf("Estimated the wrong timeout")
+	}
+}
+
+func TestManagerFailures(t *testing.T) {
+	manager := Manager{}
+	manager.Initialize(logging.NoLog{}, adaptiveConfig(), prometheus.NewRegistry())
+
+	vdr := ids.NewShortID([20]byte{1})
+	otherVdr := ids.NewShortID([20]byte{2})
+	chainID := ids.NewID([32]byte{})
+
+	for i, expected := range []time.Duration{time.Second, 500 * time.Millisecond, 250 * time.Millisecond, 125 * time.Millisecond, 100 * time.Millisecond} {
+		manager.Register(vdr, chainID, uint32(i), func() {})
+		manager.Fail(vdr, chainID, uint32(i))
+
+		if timeout := manager.Timeout(vdr); timeout != expected {
+			t.Fatalf("After %d failures the timeout should be %s, was %s", i+1, expected, timeout)
+		}
+	}
+
+	if timeout := manager.Timeout(otherVdr); timeout != 2*time.Second {
+		t.Fatalf("Failures of one peer shouldn't change the timeout of another")
+	}
+
+	// A response resets the failures
+	now := time.Now()
+	manager.clock.Set(now)
+	manager.Register(vdr, chainID, 10, func() {})
+	manager.clock.Set(now.Add(time.Second))
+	manager.Cancel(vdr, chainID, 10)
+
+	if timeout := manager.Timeout(vdr); timeout != 2*time.Second {
+		t.Fatalf("Timeout should be twice the latency, was %s", timeout)
+	}
+}
+
+func TestManagerTimeoutCountsAsFailure(t *testing.T) {
+	config := adaptiveConfig()
+	config.InitialTimeout = time.Millisecond
+	config.MinimumTimeout = time.Microsecond
+
+	manager := Manager{}
+	manager.Initialize(logging.NoLog{}, config, prometheus.NewRegistry())
+	go manager.Dispatch()
+
+	vdr := ids.NewShortID([20]byte{1})
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	manager.Register(vdr, ids.NewID([32]byte{}), 0, wg.Done)
+	wg.Wait()
+
+	if timeout := manager.Timeout(vdr); timeout != 500*time.Microsecond {
+		t.Fatalf("Timeout should have been reduced after the request timed out, was %s", timeout)
+	}
+}
+
+func TestManagerLateResponse(t *testing.T) {
+	manager := Manager{}
+	manager.Initialize(logging.NoLog{}, adaptiveConfig(), prometheus.NewRegistry())
+
+	vdr := ids.NewShortID([20]byte{1})
+	chainID := ids.NewID([32]byte{})
+	now := time.Now()
+
+	manager.clock.Set(now)
+	manager.Register(vdr, chainID, 0, func() {})
+	manager.clock.Set(now.Add(2 * time.Second))
+	manager.Fail(vdr, chainID, 0)
+
+	if timeout := manager.Timeout(vdr); timeout != time.Second {
+		t.Fatalf("Timeout should have been reduced after the request failed, was %s", timeout)
+	}
+
+	// The late response shows the validator is slow rather than unresponsive
+	manager.clock.Set(now.Add(3 * time.Second))
+	manager.Cancel(vdr, chainID, 0)
+
+	if timeout := manager.Timeout(vdr); timeout != 6*time.Second {
+		t.Fatalf("Timeout should have grown to twice the late latency, was %s", timeout)
+	}
+
+	// Failed requests are forgotten after the maximum timeout
+	manager.Register(vdr, chainID, 1, func() {})
+	manager.Fail(vdr, chainID, 1)
+	manager.clock.Set(now.Add(time.Minute))
+	manager.Register(vdr, chainID, 2, func() {})
+	manager.Fail(vdr, chainID, 2)
+	manager.Cancel(vdr, chainID, 1)
+
+	if timeout := manager.Timeout(vdr); timeout != 1500*time.Millisecond {
+		t.Fatalf("A response to a forgotten request shouldn't have been sampled, timeout was %s", timeout)
+	}
+}
+
+func TestConfigValid(t *testing.T) {
+	if err := adaptiveConfig().Valid(); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []func(*Config){
+		func(c *Config) { c.MinimumTimeout = 0 },
+		func(c *Config) { c.MaximumTimeout = c.MinimumTimeout / 2 },
+		func(c *Config) { c.InitialTimeout = c.MaximumTimeout + 1 },
+		func(c *Config) { c.LatencyMultiplier = 0.5 },
+		func(c *Config) { c.LatencyWeight = 0 },
+		func(c *Config) { c.FailureReduction = 1.5 },
+	}
+	for i, invalidate := range invalid {
+		config := adaptiveConfig()
+		invalidate(&config)
+		if err := config.Valid(); err == nil {
+			t.Fatalf("Config %d should have been invalid", i)
+		}
+	}
+}
+
+func TestManagerForgetsOldestPeer(t *testing.T) {
+	manager := Manager{}
+	manager.Initialize(logging.NoLog{}, adaptiveConfig(), prometheus.NewRegistry())
+
+	chainID := ids.NewID([32]byte{})
+	start := time.Unix(1000, 0)
+	for i := 0; i <= MaxTrackedPeers; i++ {
+		vdr := ids.NewShortID([20]byte{byte(i), byte(i >> 8)})
+		manager.clock.Set(start.Add(time.Duration(i) * time.Second))
+		manager.Register(vdr, chainID, 0, func() {})
+		manager.Cancel(vdr, chainID, 0)
+	}
+
+	estimates := manager.Estimates()
+	if len(estimates) != MaxTrackedPeers {
+		t.Fatalf("Should have tracked %d peers, but tracked %d", MaxTrackedPeers, len(estimates))
+	}
+	first := ids.NewShortID([20]byte{})
+	for _, estimate := range estimates {
+		if estimate.ID.Equals(first) {
+			t.Fatalf("Should have forgotten the peer that was sampled least recently")
+		}
+	}
+}
@@ -21,6 +21,9 @@ type Manager interface {
 	// 1) the validator set of the subnet with the specified ID
 	// 2) false if there is no subnet with the specified ID
 	GetValidatorSet(ids.ID) (Set, bool)
+
+	// Subnets returns the IDs of the subnets that have a validator set
+	Subnets() []ids.ID
 }
 
 // NewManager returns a new, empty manager
@@ -60,3 +63,15 @@ func (m *manager) GetValidatorSet(subnetID ids.ID) (Set, bool) {
 	set, exists := m.validatorSets[subnetID.Key()]
 	return set, exists
 }
+
+// Subnets implements the Manager interface.
+func (m *manager) Subnets() []ids.ID {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	subnets := make([]ids.ID, 0, len(m.validatorSets))
+	for key := range m.validatorSets {
+		subnets = append(subnets, ids.NewID(key))
+	}
+	return subnets
+}
@@ -0,0 +1,184 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package validators
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/utils/random"
+)
+
+// Sampling strategies that can be selected by name
+const (
+	// WeightedStrategy samples validators with probability proportional to
+	// their weight
+	WeightedStrategy = "weighted"
+
+	// UniformStrategy samples every validator with the same probability,
+	// regardless of its weight
+	UniformStrategy = "uniform"
+
+	// ConnectedPrefix, prepended to the name of a strategy, restricts that
+	// strategy to the validators this node is connected to. For example,
+	// "connected-weighted".
+	ConnectedPrefix = "connected-"
+)
+
+// Sampler selects validators out of a set, without replacement
+type Sampler interface {
+	// Initialize the sampler to sample from [vdrs]. Called whenever the
+	// validators, or their weights, change.
+	Initialize(vdrs []Validator)
+
+	// Sample returns up to [size] validators, in the order they were sampled.
+	// No validator is returned more than once.
+	Sample(size int) []Validator
+}
+
+// Connectivity reports which validators this node is connected to
+type Connectivity interface {
+	IsConnected(ids.ShortID) bool
+}
+
+// ValidateStrategy returns nil if [strategy] names a sampling strategy
+func ValidateStrategy(strategy string) error {
+	switch strings.TrimPrefix(strategy, ConnectedPrefix) {
+	case WeightedStrategy, UniformStrategy:
+		return nil
+	default:
+		return fmt.Errorf("unknown sampling strategy %s", strategy)
+	}
+}
+
+// NewSampler returns the sampler that implements [strategy]. Strategies
+// prefixed with ConnectedPrefix consult [connectivity] when sampling.
+func NewSampler(strategy string, connectivity Connectivity) (Sampler, error) {
+	if err := ValidateStrategy(strategy); err != nil {
+		return nil, err
+	}
+
+	inner := strings.TrimPrefix(strategy, ConnectedPrefix)
+	sampler := Sampler(nil)
+	switch inner {
+	case WeightedStrategy:
+		sampler = NewWeightedSampler()
+	default:
+		sampler = NewUniformSampler()
+	}
+
+	if inner == strategy {
+		return sampler, nil
+	}
+	if connectivity == nil {
+		return nil, fmt.Errorf("sampling strategy %s requires connectivity to be known", strategy)
+	}
+	return NewConnectedSampler(sampler, connectivity), nil
+}
+
+// NewWeightedSampler returns a sampler that samples validators with
+// probability proportional to their weight. Initialize runs in O(n) time and
+// Sample runs in O(size * log(n)) time.
+func NewWeightedSampler() Sampler { return &weightedSampler{} }
+
+// weightedSampler stores the weights in a tree of cumulative weights. Sampled
+// validators have their weight removed from the tree, and restored once the
+// sample is complete, so the tree never needs to be rebuilt between samples.
+type weightedSampler struct {
+	vdrs    []Validator
+	sampler random.Weighted
+}
+
+func (s *weightedSampler) Initialize(vdrs []Validator) {
+	s.vdrs = vdrs
+	s.sampler.Weights = make([]uint64, len(vdrs))
+	for i, vdr := range vdrs {
+		s.sampler.Weights[i] = vdr.Weight()
+	}
+	s.sampler.Replace()
+}
+
+func (s *weightedSampler) Sample(size int) []Validator {
+	indices := []int(nil)
+	for ; size > 0 && s.sampler.CanSample(); size-- {
+		indices = append(indices, s.sampler.Sample())
+	}
+
+	list := make([]Validator, len(indices))
+	for i, index := range indices {
+		list[i] = s.vdrs[index]
+		s.sampler.ReplaceIndex(index)
+	}
+	return list
+}
+
+// NewUniformSampler returns a sampler that samples every validator with the
+// same probability. Initialize runs in O(1) time and Sample runs in O(size)
+// time.
+func NewUniformSampler() Sampler { return &uniformSampler{} }
+
+type uniformSampler struct{ vdrs []Validator }
+
+func (s *uniformSampler) Initialize(vdrs []Validator) { s.vdrs = vdrs }
+
+func (s *uniformSampler) Sample(size int) []Validator {
+	list := []Validator(nil)
+	sampler := random.Uniform{N: len(s.vdrs)}
+	for ; size > 0 && sampler.CanSample(); size-- {
+		list = append(list, s.vdrs[sampler.Sample()])
+	}
+	return list
+}
+
+// NewConnectedSampler returns a sampler that only returns the validators of
+// [sampler] that [connectivity] reports as connected
+func NewConnectedSampler(sampler Sampler, connectivity Connectivity) Sampler {
+	return &connectedSampler{
+		sampler:      sampler,
+		connectivity: connectivity,
+	}
+}
+
+// connectedSampler draws from the wrapped sampler and drops the validators
+// that aren't connected. If too few of the drawn validators are connected, a
+// sample twice as large is drawn. Because the wrapped sampler returns
+// validators in the order they were sampled, the connected validators of a
+// sample are distributed as if only the connected validators were sampled.
+type connectedSampler struct {
+	sampler      Sampler
+	connectivity Connectivity
+	numVdrs      int
+}
+
+func (s *connectedSampler) Initialize(vdrs []Validator) {
+	s.numVdrs = len(vdrs)
+	s.sampler.Initialize(vdrs)
+}
+
+func (s *connectedSampler) Sample(size int) []Validator {
+	if size <= 0 {
+		return nil
+	}
+
+	for draw := size; ; draw *= 2 {
+		if draw > s.numVdrs {
+			draw = s.numVdrs
+		}
+
+		list := []Validator(nil)
+		for _, vdr := range s.sampler.Sample(draw) {
+			if len(list) == size {
+				break
+			}
+			if s.connectivity.IsConnected(vdr.ID()) {
+				list = append(list, vdr)
+			}
+		}
+
+		if len(list) == size || draw == s.numVdrs {
+			return list
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package validators
+
+import (
+	"testing"
+
+	"github.com/ava-labs/gecko/ids"
+)
+
+type testConnectivity struct{ connected ids.ShortSet }
+
+func (c *testConnectivity) IsConnected(id ids.ShortID) bool { return c.connected.Contains(id) }
+
+func testValidators(weights ...uint64) []Validator {
+	vdrs := []Validator(nil)
+	for i, weight := range weights {
+		vdrs = append(vdrs, NewValidator(ids.NewShortID([20]byte{byte(i + 1)}), weight))
+	}
+	return vdrs
+}
+
+// checkSample fails the test if [sampled] isn't [size] distinct validators
+func checkSample(t *testing.T, sampled []Validator, size int) {
+	if len(sampled) != size {
+		t.Fatalf("Sampled %d validators, expected %d", len(sampled), size)
+	}
+	seen := ids.ShortSet{}
+	for _, vdr := range sampled {
+		if seen.Contains(vdr.ID()) {
+			t.Fatalf("Sampled validator %s twice", vdr.ID())
+		}
+		seen.Add(vdr.ID())
+	}
+}
+
+func TestWeightedSampler(t *testing.T) {
+	vdrs := testValidators(1, 0, 1000000, 1)
+	s := NewWeightedSampler()
+	s.Initialize(vdrs)
+
+	for i := 0; i < 10; i++ {
+		sampled := s.Sample(1)
+		checkSample(t, sampled, 1)
+		if !sampled[0].ID().Equals(vdrs[2].ID()) {
+			t.Fatalf("Should have sampled the heaviest validator")
+		}
+	}
+
+	// Validators without weight can't be sampled
+	checkSample(t, s.Sample(4), 3)
+	checkSample(t, s.Sample(3), 3)
+}
+
+func TestUniformSampler(t *testing.T) {
+	vdrs := testValidators(1, 1000000, 1, 1)
+	s := NewUniformSampler()
+	s.Initialize(vdrs)
+
+	counts := make([]int, len(vdrs))
+	for i := 0; i < 1000; i++ {
+		sampled := s.Sample(1)
+		checkSample(t, sampled, 1)
+		for j, vdr := range vdrs {
+			if vdr.ID().Equals(sampled[0].ID()) {
+				counts[j]++
+			}
+		}
+	}
+	for i, count := range counts {
+		if count < 150 || count > 350 {
+			t.Fatalf("Validator %d was sampled %d times out of 1000", i, count)
+		}
+	}
+
+	checkSample(t, s.Sample(5), 4)
+}
+
+func TestConnectedSampler(t *testing.T) {
+	vdrs := testValidators(1, 1, 1, 1, 1, 1, 1, 1, 1, 1)
+	connectivity := &testConnectivity{}
+	connectivity.connected.Add(vdrs[3].ID(), vdrs[7].ID())
+
+	s := NewConnectedSampler(NewWeightedSampler(), connectivity)
+	s.Initialize(vdrs)
+
+	for i := 0; i < 10; i++ {
+		sampled := s.Sample(2)
+		checkSample(t, sampled, 2)
+		for _, vdr := range sampled {
+			if !connectivity.connected.Contains(vdr.ID()) {
+				t.Fatalf("Sampled a validator that isn't connected")
+			}
+		}
+	}
+
+	checkSample(t, s.Sample(5), 2)
+	checkSample(t, s.Sample(0), 0)
+}
+
+func TestNewSampler(t *testing.T) {
+	connectivity := &testConnectivity{}
+	for _, strategy := range []string{WeightedStrategy, UniformStrategy, ConnectedPrefix + WeightedStrategy, ConnectedPrefix + UniformStrategy} {
+		if err := ValidateStrategy(strategy); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewSampler(strategy, connectivity); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := NewSampler(ConnectedPrefix+WeightedStrategy, nil); err == nil {
+		t.Fatalf("Connected sampler requires connectivity")
+	}
+	if err := ValidateStrategy("alias"); err == nil {
+		t.Fatalf("Unknown strategy should have been rejected")
+	}
+	if _, err := NewSampler(ConnectedPrefix+ConnectedPrefix+UniformStrategy, connectivity); err == nil {
+		t.Fatalf("Doubly connected strategy should have been rejected")
+	}
+}
+
+func TestSetSetSampler(t *testing.T) {
+	vdrs := testValidators(1, 1, 1)
+	connectivity := &testConnectivity{}
+	connectivity.connected.Add(vdrs[0].ID())
+
+	s := NewSet()
+	s.Set(vdrs)
+	s.SetSampler(NewConnectedSampler(NewUniformSampler(), connectivity))
+
+	sampled := s.Sample(3)
+	checkSample(t, sampled, 1)
+	if !sampled[0].ID().Equals(vdrs[0].ID()) {
+		t.Fatalf("Should have only sampled the connected validator")
+	}
+
+	// Changes to the set should be reflected by the sampler
+	s.Remove(vdrs[0].ID())
+	checkSample(t, s.Sample(3), 0)
+
+	newVdr := NewValidator(ids.NewShortID([20]byte{0xFF}), 1)
+	connectivity.connected.Add(newVdr.ID())
+	s.Add(newVdr)
+	sampled = s.Sample(3)
+	checkSample(t, sampled, 1)
+	if !sampled[0].ID().Equals(newVdr.ID()) {
+		t.Fatalf("Should have sampled the added validator")
+	}
+}
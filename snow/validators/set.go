@@ -10,7 +10,6 @@ import (
 
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/utils/formatting"
-	"github.com/ava-labs/gecko/utils/random"
 )
 
 // Set of validators that can be sampled
@@ -45,21 +44,34 @@ type Set interface {
 	// [size]. Otherwise, the length of the returned validators will equal
 	// [size].
 	Sample(size int) []Validator
+
+	// SetSampler replaces the strategy used to sample validators. By default,
+	// validators are sampled by weight.
+	SetSampler(Sampler)
 }
 
 // NewSet returns a new, empty set of validators.
-func NewSet() Set { return &set{vdrMap: make(map[[20]byte]int)} }
+func NewSet() Set {
+	return &set{
+		vdrMap:  make(map[[20]byte]int),
+		sampler: NewWeightedSampler(),
+	}
+}
 
 // set of validators. Validator function results are cached. Therefore, to
 // update a validators weight, one should ensure to call add with the updated
-// validator. Sample will run in O(NumValidators) time. All other functions run
-// in O(1) time.
+// validator. The first Sample after the set changes initializes the sampler,
+// which takes O(NumValidators) time with the default sampler. All other
+// functions run in O(1) time.
 // set implements Set
 type set struct {
 	lock     sync.Mutex
 	vdrMap   map[[20]byte]int
 	vdrSlice []Validator
-	sampler  random.Weighted
+
+	sampler Sampler
+	// dirty is true if the set has changed since the sampler was initialized
+	dirty bool
 }
 
 // Set implements the Set interface.
@@ -73,7 +85,7 @@ func (s *set) Set(vdrs []Validator) {
 func (s *set) set(vdrs []Validator) {
 	s.vdrMap = make(map[[20]byte]int, len(vdrs))
 	s.vdrSlice = s.vdrSlice[:0]
-	s.sampler.Weights = s.sampler.Weights[:0]
+	s.dirty = true
 
 	for _, vdr := range vdrs {
 		s.add(vdr)
@@ -102,7 +114,7 @@ func (s *set) add(vdr Validator) {
 	i := len(s.vdrSlice)
 	s.vdrMap[vdrID.Key()] = i
 	s.vdrSlice = append(s.vdrSlice, vdr)
-	s.sampler.Weights = append(s.sampler.Weights, w)
+	s.dirty = true
 }
 
 // Get implements the Set interface.
@@ -145,12 +157,11 @@ func (s *set) remove(vdrID ids.ShortID) {
 	// Move e -> i
 	s.vdrMap[eKey] = i
 	s.vdrSlice[i] = eVdr
-	s.sampler.Weights[i] = s.sampler.Weights[e]
 
 	// Remove i
 	delete(s.vdrMap, iKey)
 	s.vdrSlice = s.vdrSlice[:e]
-	s.sampler.Weights = s.sampler.Weights[:e]
+	s.dirty = true
 }
 
 // Contains implements the Set interface.
@@ -199,14 +210,21 @@ func (s *set) Sample(size int) []Validator {
 }
 
 func (s *set) sample(size int) []Validator {
-	list := make([]Validator, size)[:0]
-
-	s.sampler.Replace() // Must replace, otherwise changes won't be reflected
-	for ; size > 0 && s.sampler.CanSample(); size-- {
-		i := s.sampler.Sample()
-		list = append(list, s.vdrSlice[i])
+	if s.dirty {
+		// The sampler keeps the list, so it must not be modified in place
+		s.sampler.Initialize(s.list())
+		s.dirty = false
 	}
-	return list
+	return s.sampler.Sample(size)
+}
+
+// SetSampler implements the Set interface.
+func (s *set) SetSampler(sampler Sampler) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.sampler = sampler
+	s.dirty = true
 }
 
 func (s *set) String() string {
@@ -222,7 +240,7 @@ func (s *set) string() string {
 	sb.WriteString(fmt.Sprintf("Validator Set: (Size = %d)", len(s.vdrSlice)))
 	format := fmt.Sprintf("\n    Validator[%s]: %%33s, %%d", formatting.IntFormat(len(s.vdrSlice)-1))
 	for i, vdr := range s.vdrSlice {
-		sb.WriteString(fmt.Sprintf(format, i, vdr.ID(), s.sampler.Weights[i]))
+		sb.WriteString(fmt.Sprintf(format, i, vdr.ID(), vdr.Weight()))
 	}
 
 	return sb.String()
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 )
 
 // This was taken from: https://stackoverflow.com/a/50825191/3478466
@@ -71,9 +72,13 @@ func (ipDesc IPDesc) IsPrivate() bool {
 			return true
 		}
 	}
-	return true
+	return false
 }
 
+// IsIPv4 returns true if the ip address in this descriptor is an IPv4 address,
+// including IPv4 addresses that are represented as IPv4-mapped IPv6 addresses
+func (ipDesc IPDesc) IsIPv4() bool { return ipDesc.IP.To4() != nil }
+
 // IsZero returns if the IP or port is zeroed out
 func (ipDesc IPDesc) IsZero() bool {
 	ip := ipDesc.IP
@@ -83,6 +88,23 @@ func (ipDesc IPDesc) IsZero() bool {
 		ip.Equal(net.IPv6zero)
 }
 
+// ToIPDescs parses a comma separated list of ip port pairs. IPv6 addresses must
+// be enclosed in square brackets, for example "1.2.3.4:9651,[2001:db8::1]:9651".
+func ToIPDescs(str string) ([]IPDesc, error) {
+	ipDescs := []IPDesc(nil)
+	for _, ipStr := range strings.Split(str, ",") {
+		if ipStr == "" {
+			continue
+		}
+		ipDesc, err := ToIPDesc(ipStr)
+		if err != nil {
+			return nil, err
+		}
+		ipDescs = append(ipDescs, ipDesc)
+	}
+	return ipDescs, nil
+}
+
 // ToIPDesc ...
 func ToIPDesc(str string) (IPDesc, error) {
 	host, portStr, err := net.SplitHostPort(str)
@@ -149,3 +149,67 @@ func TestToIPDesc(t *testing.T) {
 		})
 	}
 }
+
+func TestToIPDescs(t *testing.T) {
+	result, err := ToIPDescs("127.0.0.1:42,[2001:db8::1]:9651,")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expected := []IPDesc{
+		{net.ParseIP("127.0.0.1"), 42},
+		{net.ParseIP("2001:db8::1"), 9651},
+	}
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %d ips, got %d", len(expected), len(result))
+	}
+	for i, ipDesc := range expected {
+		if !ipDesc.Equal(result[i]) {
+			t.Errorf("Expected %v, got %v", ipDesc, result[i])
+		}
+	}
+
+	if _, err := ToIPDescs("127.0.0.1:42,2001:db8::1:9651"); err == nil {
+		t.Errorf("Unexpected success")
+	}
+}
+
+func TestIPDescIsIPv4(t *testing.T) {
+	tests := []struct {
+		ipDesc IPDesc
+		result bool
+	}{
+		{IPDesc{net.ParseIP("127.0.0.1"), 0}, true},
+		{IPDesc{net.ParseIP("::ffff:127.0.0.1"), 0}, true},
+		{IPDesc{net.ParseIP("::1"), 0}, false},
+		{IPDesc{net.ParseIP("2001:db8::1"), 0}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ipDesc.String(), func(t *testing.T) {
+			if result := tt.ipDesc.IsIPv4(); result != tt.result {
+				t.Errorf("Expected %v, got %v", tt.result, result)
+			}
+		})
+	}
+}
+
+func TestIPDescIsPrivate(t *testing.T) {
+	tests := []struct {
+		ipDesc IPDesc
+		result bool
+	}{
+		{IPDesc{net.ParseIP("127.0.0.1"), 0}, true},
+		{IPDesc{net.ParseIP("10.1.2.3"), 0}, true},
+		{IPDesc{net.ParseIP("::1"), 0}, true},
+		{IPDesc{net.ParseIP("fe80::1"), 0}, true},
+		{IPDesc{net.ParseIP("fd00::1"), 0}, true},
+		{IPDesc{net.ParseIP("1.2.3.4"), 0}, false},
+		{IPDesc{net.ParseIP("2001:db8::1"), 0}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ipDesc.String(), func(t *testing.T) {
+			if result := tt.ipDesc.IsPrivate(); result != tt.result {
+				t.Errorf("Expected %v, got %v", tt.result, result)
+			}
+		})
+	}
+}
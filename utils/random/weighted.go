@@ -102,6 +102,13 @@ func (s *Weighted) Replace() {
 	}
 }
 
+// ReplaceIndex restores the weight of the item at index [i], so that it may be
+// sampled again. Unlike Replace, this takes only O(log(len(weights))) time.
+func (s *Weighted) ReplaceIndex(i int) {
+	s.init()
+	s.changeWeight(i, int64(s.Weights[i]))
+}
+
 func (s *Weighted) changeWeight(i int, newWeight int64) {
 	change := s.weights[i] - newWeight
 
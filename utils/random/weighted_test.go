@@ -92,3 +92,27 @@ func TestWeightedReset(t *testing.T) {
 		t.Fatalf("Shouldn't be able to sample")
 	}
 }
+
+func TestWeightedReplaceIndex(t *testing.T) {
+	s := &Weighted{Weights: []uint64{0, 1, 0, 2, 0}}
+
+	first := s.Sample()
+	second := s.Sample()
+	if s.CanSample() {
+		t.Fatalf("Shouldn't be able to sample")
+	}
+
+	s.ReplaceIndex(first)
+	if !s.CanSample() {
+		t.Fatalf("Should be able to sample")
+	}
+	if i := s.Sample(); i != first {
+		t.Fatalf("Sampled %d, expected %d", i, first)
+	}
+
+	s.ReplaceIndex(first)
+	s.ReplaceIndex(second)
+	if i, j := s.Sample(), s.Sample(); i+j != 4 {
+		t.Fatalf("Sampled %d and %d, expected 1 and 3", i, j)
+	}
+}
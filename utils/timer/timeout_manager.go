@@ -4,7 +4,7 @@
 package timer
 
 import (
-	"container/list"
+	"container/heap"
 	"sync"
 	"time"
 
@@ -14,25 +14,51 @@ import (
 type timeoutHandler func()
 
 type timeout struct {
-	id      ids.ID
-	handler timeoutHandler
-	timer   time.Time
+	id       ids.ID
+	handler  timeoutHandler
+	deadline time.Time
+	index    int // index of this timeout in the queue
+}
+
+// timeoutQueue orders timeouts by their deadline
+type timeoutQueue []*timeout
+
+func (q timeoutQueue) Len() int           { return len(q) }
+func (q timeoutQueue) Less(i, j int) bool { return q[i].deadline.Before(q[j].deadline) }
+func (q timeoutQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *timeoutQueue) Push(x interface{}) {
+	t := x.(*timeout)
+	t.index = len(*q)
+	*q = append(*q, t)
+}
+
+func (q *timeoutQueue) Pop() interface{} {
+	old := *q
+	n := len(old) - 1
+	t := old[n]
+	old[n] = nil
+	*q = old[:n]
+	return t
 }
 
 // TimeoutManager is a manager for timeouts.
 type TimeoutManager struct {
-	lock        sync.Mutex
-	duration    time.Duration // Amount of time before a timeout
-	timeoutMap  map[[32]byte]*list.Element
-	timeoutList *list.List
-	timer       *Timer // Timer that will fire to clear the timeouts
+	lock         sync.Mutex
+	duration     time.Duration // Default amount of time before a timeout
+	timeoutMap   map[[32]byte]*timeout
+	timeoutQueue timeoutQueue
+	timer        *Timer // Timer that will fire to clear the timeouts
 }
 
 // Initialize is a constructor b/c Golang, in its wisdom, doesn't ... have them?
 func (tm *TimeoutManager) Initialize(duration time.Duration) {
 	tm.duration = duration
-	tm.timeoutMap = make(map[[32]byte]*list.Element)
-	tm.timeoutList = list.New()
+	tm.timeoutMap = make(map[[32]byte]*timeout)
 	tm.timer = NewTimer(tm.Timeout)
 }
 
@@ -44,10 +70,16 @@ func (tm *TimeoutManager) Stop() { tm.timer.Stop() }
 
 // Put puts hash into the hash map
 func (tm *TimeoutManager) Put(id ids.ID, handler func()) {
+	tm.PutTimeout(id, tm.duration, handler)
+}
+
+// PutTimeout puts hash into the hash map, to time out after [duration] rather
+// than the default duration
+func (tm *TimeoutManager) PutTimeout(id ids.ID, duration time.Duration, handler func()) {
 	tm.lock.Lock()
 	defer tm.lock.Unlock()
 
-	tm.put(id, handler)
+	tm.put(id, duration, handler)
 }
 
 // Remove the item that no longer needs to be there.
@@ -67,10 +99,9 @@ func (tm *TimeoutManager) Timeout() {
 }
 
 func (tm *TimeoutManager) timeout() {
-	timeBound := time.Now().Add(-tm.duration)
-	// removeExpiredHead returns false once there is nothing left to remove
+	// removeExpiredHead returns nil once there is nothing left to remove
 	for {
-		timeout := tm.removeExpiredHead(timeBound)
+		timeout := tm.removeExpiredHead(time.Now())
 		if timeout == nil {
 			break
 		}
@@ -83,41 +114,41 @@ func (tm *TimeoutManager) timeout() {
 	tm.registerTimeout()
 }
 
-func (tm *TimeoutManager) put(id ids.ID, handler timeoutHandler) {
+func (tm *TimeoutManager) put(id ids.ID, duration time.Duration, handler timeoutHandler) {
 	tm.remove(id)
 
-	tm.timeoutMap[id.Key()] = tm.timeoutList.PushBack(timeout{
-		id:      id,
-		handler: handler,
-		timer:   time.Now(),
-	})
+	t := &timeout{
+		id:       id,
+		handler:  handler,
+		deadline: time.Now().Add(duration),
+	}
+	tm.timeoutMap[id.Key()] = t
+	heap.Push(&tm.timeoutQueue, t)
 
-	if tm.timeoutList.Len() == 1 {
+	if t.index == 0 {
+		// This timeout expires before any other
 		tm.registerTimeout()
 	}
 }
 
 func (tm *TimeoutManager) remove(id ids.ID) {
 	key := id.Key()
-	e, exists := tm.timeoutMap[key]
+	t, exists := tm.timeoutMap[key]
 	if !exists {
 		return
 	}
 	delete(tm.timeoutMap, key)
-	tm.timeoutList.Remove(e)
+	heap.Remove(&tm.timeoutQueue, t.index)
 }
 
-// Returns true if the head was removed, false otherwise
+// Returns the handler of the head if the head was removed, nil otherwise
 func (tm *TimeoutManager) removeExpiredHead(t time.Time) func() {
-	if tm.timeoutList.Len() == 0 {
+	if tm.timeoutQueue.Len() == 0 {
 		return nil
 	}
 
-	e := tm.timeoutList.Front()
-	head := e.Value.(timeout)
-
-	headTime := head.timer
-	if headTime.Before(t) {
+	head := tm.timeoutQueue[0]
+	if head.deadline.Before(t) {
 		tm.remove(head.id)
 		return head.handler
 	}
@@ -125,18 +156,12 @@ func (tm *TimeoutManager) removeExpiredHead(t time.Time) func() {
 }
 
 func (tm *TimeoutManager) registerTimeout() {
-	if tm.timeoutList.Len() == 0 {
+	if tm.timeoutQueue.Len() == 0 {
 		// There are no pending timeouts
 		tm.timer.Cancel()
 		return
 	}
 
-	e := tm.timeoutList.Front()
-	head := e.Value.(timeout)
-
-	timeBound := time.Now().Add(-tm.duration)
-	headTime := head.timer
-	duration := headTime.Sub(timeBound)
-
-	tm.timer.SetTimeoutIn(duration)
+	head := tm.timeoutQueue[0]
+	tm.timer.SetTimeoutIn(time.Until(head.deadline))
 }
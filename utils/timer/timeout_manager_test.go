@@ -23,3 +23,31 @@ func TestTimeoutManager(t *testing.T) {
 	tm.Put(ids.NewID([32]byte{}), wg.Done)
 	tm.Put(ids.NewID([32]byte{1}), wg.Done)
 }
+
+func TestTimeoutManagerPutTimeout(t *testing.T) {
+	fired := make(chan int, 2)
+
+	tm := TimeoutManager{}
+	tm.Initialize(time.Hour)
+	go tm.Dispatch()
+	defer tm.Stop()
+
+	tm.Put(ids.NewID([32]byte{}), func() { fired <- 0 })
+	tm.PutTimeout(ids.NewID([32]byte{1}), 50*time.Millisecond, func() { fired <- 1 })
+	tm.PutTimeout(ids.NewID([32]byte{2}), time.Millisecond, func() { fired <- 2 })
+
+	if i := <-fired; i != 2 {
+		t.Fatalf("Timeout %d fired first, expected 2", i)
+	}
+	if i := <-fired; i != 1 {
+		t.Fatalf("Timeout %d fired second, expected 1", i)
+	}
+
+	// Removing the head should leave the other timeouts in place
+	tm.PutTimeout(ids.NewID([32]byte{3}), 10*time.Millisecond, func() { fired <- 3 })
+	tm.PutTimeout(ids.NewID([32]byte{4}), 30*time.Millisecond, func() { fired <- 4 })
+	tm.Remove(ids.NewID([32]byte{3}))
+	if i := <-fired; i != 4 {
+		t.Fatalf("Timeout %d fired, expected 4", i)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"math"
+	"net"
 
 	"github.com/ava-labs/gecko/utils"
 	"github.com/ava-labs/gecko/utils/hashing"
@@ -256,6 +257,25 @@ func (p *Packer) UnpackFixedByteSlices(size int) [][]byte {
 	return bytes
 }
 
+// Pack2DByteSlice append a 2D byte slice to the byte array. Each byte slice is
+// prefixed with its length.
+func (p *Packer) Pack2DByteSlice(byteSlices [][]byte) {
+	p.PackInt(uint32(len(byteSlices)))
+	for _, bytes := range byteSlices {
+		p.PackBytes(bytes)
+	}
+}
+
+// Unpack2DByteSlice returns a 2D byte slice from the byte array.
+func (p *Packer) Unpack2DByteSlice() [][]byte {
+	sliceSize := p.UnpackInt()
+	bytes := [][]byte(nil)
+	for i := uint32(0); i < sliceSize && !p.Errored(); i++ {
+		bytes = append(bytes, p.UnpackBytes())
+	}
+	return bytes
+}
+
 // PackStr append a string to the byte array
 func (p *Packer) PackStr(str string) {
 	strSize := len(str)
@@ -272,16 +292,25 @@ func (p *Packer) UnpackStr() string {
 	return string(p.UnpackFixedBytes(int(strSize)))
 }
 
-// PackIP unpacks an ip port pair from the byte array
+// PackIP packs an ip port pair to the byte array. IPv4 addresses are packed
+// as IPv4-mapped IPv6 addresses, so every IP is packed into 16 bytes.
 func (p *Packer) PackIP(ip utils.IPDesc) {
-	p.PackFixedBytes(ip.IP.To16())
+	ipBytes := ip.IP.To16()
+	if ipBytes == nil {
+		ipBytes = net.IPv6zero
+	}
+	p.PackFixedBytes(ipBytes)
 	p.PackShort(ip.Port)
 }
 
-// UnpackIP unpacks an ip port pair from the byte array
+// UnpackIP unpacks an ip port pair from the byte array. IPv4-mapped IPv6
+// addresses are unpacked as IPv4 addresses.
 func (p *Packer) UnpackIP() utils.IPDesc {
-	ip := p.UnpackFixedBytes(16)
+	ip := net.IP(p.UnpackFixedBytes(16))
 	port := p.UnpackShort()
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
 	return utils.IPDesc{
 		IP:   ip,
 		Port: port,
@@ -432,6 +461,20 @@ func TryUnpackBytes(packer *Packer) interface{} {
 	return packer.UnpackBytes()
 }
 
+// TryPack2DBytes attempts to pack the value as a list of byte slices
+func TryPack2DBytes(packer *Packer, valIntf interface{}) {
+	if val, ok := valIntf.([][]byte); ok {
+		packer.Pack2DByteSlice(val)
+	} else {
+		packer.Add(errBadType)
+	}
+}
+
+// TryUnpack2DBytes attempts to unpack the value as a list of byte slices
+func TryUnpack2DBytes(packer *Packer) interface{} {
+	return packer.Unpack2DByteSlice()
+}
+
 // TryPackStr attempts to pack the value as a string
 func TryPackStr(packer *Packer, valIntf interface{}) {
 	if val, ok := valIntf.(string); ok {
@@ -5,8 +5,11 @@ package wrappers
 
 import (
 	"bytes"
+	"net"
 	"reflect"
 	"testing"
+
+	"github.com/ava-labs/gecko/utils"
 )
 
 const (
@@ -402,6 +405,39 @@ func TestPackerString(t *testing.T) {
 	}
 }
 
+func TestPacker2DByteSlice(t *testing.T) {
+	p := Packer{MaxSize: 1024}
+
+	expected := [][]byte{[]byte("Ava"), {}, []byte("Labs")}
+	p.Pack2DByteSlice(expected)
+	if p.Errored() {
+		t.Fatal(p.Err)
+	}
+
+	expectedBytes := []byte("\x00\x00\x00\x03\x00\x00\x00\x03Ava\x00\x00\x00\x00\x00\x00\x00\x04Labs")
+	if !bytes.Equal(p.Bytes, expectedBytes) {
+		t.Fatalf("Packer.Pack2DByteSlice wrote:\n%v\nExpected:\n%v", p.Bytes, expectedBytes)
+	}
+
+	p = Packer{Bytes: expectedBytes}
+	actual := p.Unpack2DByteSlice()
+	if p.Errored() {
+		t.Fatalf("Packer.Unpack2DByteSlice unexpectedly raised %s", p.Err)
+	} else if len(actual) != len(expected) {
+		t.Fatalf("Packer.Unpack2DByteSlice returned %d slices, but expected %d", len(actual), len(expected))
+	}
+	for i, slice := range actual {
+		if !bytes.Equal(slice, expected[i]) {
+			t.Fatalf("Packer.Unpack2DByteSlice returned %v at %d, but expected %v", slice, i, expected[i])
+		}
+	}
+
+	p = Packer{Bytes: expectedBytes[:len(expectedBytes)-1]}
+	if p.Unpack2DByteSlice(); !p.Errored() {
+		t.Fatalf("Packer.Unpack2DByteSlice should have set error, due to attempted out of bounds read")
+	}
+}
+
 func TestPacker(t *testing.T) {
 	packer := Packer{
 		MaxSize: 3,
@@ -506,3 +542,38 @@ func TestPackerUnpackBool(t *testing.T) {
 		t.Fatalf("Packer.UnpackBool returned %t, expected sentinal value %t", actual, BoolSentinal)
 	}
 }
+
+func TestPackerPackIP(t *testing.T) {
+	for _, ipStr := range []string{"1.2.3.4", "::ffff:1.2.3.4", "2001:db8::1", "::1"} {
+		ip := utils.IPDesc{
+			IP:   net.ParseIP(ipStr),
+			Port: 9651,
+		}
+
+		p := Packer{MaxSize: 18}
+		p.PackIP(ip)
+		if p.Errored() {
+			t.Fatalf("Packer.PackIP unexpectedly raised %s", p.Err)
+		} else if len(p.Bytes) != 18 {
+			t.Fatalf("Packer.PackIP wrote %d bytes, expected %d", len(p.Bytes), 18)
+		}
+
+		p = Packer{Bytes: p.Bytes}
+		actual := p.UnpackIP()
+		if p.Errored() {
+			t.Fatalf("Packer.UnpackIP unexpectedly raised %s", p.Err)
+		} else if !ip.Equal(actual) {
+			t.Fatalf("Packer.UnpackIP returned %s, expected %s", actual, ip)
+		} else if ip.IsIPv4() != actual.IsIPv4() {
+			t.Fatalf("Packer.UnpackIP changed the address family of %s", ip)
+		}
+	}
+
+	p := Packer{MaxSize: 18}
+	p.PackIP(utils.IPDesc{})
+	if p.Errored() {
+		t.Fatalf("Packer.PackIP unexpectedly raised %s", p.Err)
+	} else if len(p.Bytes) != 18 {
+		t.Fatalf("Packer.PackIP wrote %d bytes for an empty IP, expected %d", len(p.Bytes), 18)
+	}
+}
@@ -0,0 +1,76 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/engine/common"
+	"github.com/ava-labs/gecko/utils/hashing"
+)
+
+// InspectorKeys describes the keys an Inspector can decode. IDs and addresses
+// are in their string representation, without a chain prefix.
+var InspectorKeys = []string{
+	"dbInitialized",
+	"tx/<txID>",
+	"status/<txID>",
+	"utxo/<utxoID>",
+	"funds/<address>",
+}
+
+// Inspector decodes the state of an AVM chain from its database, without
+// running the chain. It's used to debug a node's database offline.
+type Inspector struct{ vm *VM }
+
+// NewInspector returns an inspector of the chain whose database is [db]. [fxs]
+// must be the fxs the chain was created with, in the same order, as they
+// decide how the chain's state is serialized. Nothing is written to [db].
+func NewInspector(ctx *snow.Context, db database.Database, fxs []*common.Fx) (*Inspector, error) {
+	vm := &VM{ctx: ctx}
+	if err := vm.initCodec(fxs); err != nil {
+		return nil, err
+	}
+	vm.state = newPrefixedState(db, vm.codec)
+	return &Inspector{vm: vm}, nil
+}
+
+// Get returns the decoded value of [key], which is one of InspectorKeys
+func (i *Inspector) Get(key string) (interface{}, error) {
+	state := i.vm.state
+	name, arg := key, ""
+	if index := strings.Index(key, "/"); index >= 0 {
+		name, arg = key[:index], key[index+1:]
+	}
+
+	switch name {
+	case "dbInitialized":
+		return state.DBInitialized()
+	case "tx", "status", "utxo":
+		id, err := ids.FromString(arg)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "tx":
+			return state.Tx(id)
+		case "status":
+			return state.Status(id)
+		default:
+			return state.UTXO(id)
+		}
+	case "funds":
+		address, err := ids.ShortFromString(arg)
+		if err != nil {
+			return nil, err
+		}
+		return state.Funds(ids.NewID(hashing.ComputeHash256Array(address.Bytes())))
+	default:
+		return nil, fmt.Errorf("unknown key %s", key)
+	}
+}
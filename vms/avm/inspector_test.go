@@ -0,0 +1,48 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package avm
+
+import (
+	"testing"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/engine/common"
+	"github.com/ava-labs/gecko/vms/secp256k1fx"
+)
+
+func TestInspector(t *testing.T) {
+	genesisBytes, _, vm := GenesisVM(t)
+	ctx.Lock.Unlock()
+	defer vm.Shutdown()
+
+	inspector, err := NewInspector(ctx, vm.baseDB, []*common.Fx{&common.Fx{
+		ID: ids.Empty,
+		Fx: &secp256k1fx.Fx{},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	genesisTx := GetFirstTxFromGenesisTest(genesisBytes, t)
+	txIntf, err := inspector.Get("tx/" + genesisTx.ID().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx, ok := txIntf.(*Tx); !ok || !tx.ID().Equals(genesisTx.ID()) {
+		t.Fatalf("Should have decoded the genesis tx")
+	}
+
+	status, err := inspector.Get("status/" + genesisTx.ID().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != choices.Accepted {
+		t.Fatalf("The genesis tx should have been accepted but was %s", status)
+	}
+
+	if _, err := inspector.Get("vertex/" + genesisTx.ID().String()); err == nil {
+		t.Fatalf("Should have errored on an unknown key")
+	}
+}
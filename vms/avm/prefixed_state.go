@@ -5,10 +5,12 @@ package avm
 
 import (
 	"github.com/ava-labs/gecko/cache"
+	"github.com/ava-labs/gecko/database"
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/snow/choices"
 	"github.com/ava-labs/gecko/utils/hashing"
 	"github.com/ava-labs/gecko/vms/components/ava"
+	"github.com/ava-labs/gecko/vms/components/codec"
 )
 
 const (
@@ -32,6 +34,25 @@ type prefixedState struct {
 	uniqueTx                  cache.Deduplicator
 }
 
+// newPrefixedState returns the state of the VM stored in [db], whose values
+// are serialized with [c]
+func newPrefixedState(db database.Database, c codec.Codec) *prefixedState {
+	return &prefixedState{
+		state: &state{State: ava.State{
+			Cache: &cache.LRU{Size: stateCacheSize},
+			DB:    db,
+			Codec: c,
+		}},
+
+		tx:       &cache.LRU{Size: idCacheSize},
+		utxo:     &cache.LRU{Size: idCacheSize},
+		txStatus: &cache.LRU{Size: idCacheSize},
+		funds:    &cache.LRU{Size: idCacheSize},
+
+		uniqueTx: &cache.EvictableLRU{Size: txCacheSize},
+	}
+}
+
 // UniqueTx de-duplicates the transaction.
 func (s *prefixedState) UniqueTx(tx *UniqueTx) *UniqueTx {
 	return s.uniqueTx.Deduplicate(tx).(*UniqueTx)
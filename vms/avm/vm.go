@@ -12,7 +12,6 @@ import (
 
 	"github.com/gorilla/rpc/v2"
 
-	"github.com/ava-labs/gecko/cache"
 	"github.com/ava-labs/gecko/database"
 	"github.com/ava-labs/gecko/database/versiondb"
 	"github.com/ava-labs/gecko/ids"
@@ -112,18 +111,55 @@ func (vm *VM) Initialize(
 	vm.toEngine = toEngine
 	vm.baseDB = db
 	vm.db = versiondb.New(db)
-	vm.typeToFxIndex = map[reflect.Type]int{}
 	vm.Aliaser.Initialize()
 
 	vm.pubsub = cjson.NewPubSubServer(ctx)
-	c := codec.NewDefault()
 
 	errs := wrappers.Errs{}
 	errs.Add(
 		vm.pubsub.Register("accepted"),
 		vm.pubsub.Register("rejected"),
 		vm.pubsub.Register("verified"),
+	)
+	if errs.Errored() {
+		return errs.Err
+	}
+
+	if err := vm.initCodec(fxs); err != nil {
+		return err
+	}
+	vm.state = newPrefixedState(vm.db, vm.codec)
+
+	if err := vm.initAliases(genesisBytes); err != nil {
+		return err
+	}
+
+	if dbStatus, err := vm.state.DBInitialized(); err != nil || dbStatus == choices.Unknown {
+		if err := vm.initState(genesisBytes); err != nil {
+			return err
+		}
+	}
+
+	vm.timer = timer.NewTimer(func() {
+		ctx.Lock.Lock()
+		defer ctx.Lock.Unlock()
+
+		vm.FlushTxs()
+	})
+	go ctx.Log.RecoverAndPanic(vm.timer.Dispatch)
+	vm.batchTimeout = batchTimeout
+
+	return vm.db.Commit()
+}
 
+// initCodec registers the transaction types and the types of [fxs] with the
+// codec of the VM. [vm.ctx] must be set.
+func (vm *VM) initCodec(fxs []*common.Fx) error {
+	vm.typeToFxIndex = map[reflect.Type]int{}
+	c := codec.NewDefault()
+
+	errs := wrappers.Errs{}
+	errs.Add(
 		c.RegisterType(&BaseTx{}),
 		c.RegisterType(&CreateAssetTx{}),
 		c.RegisterType(&OperationTx{}),
@@ -158,42 +194,7 @@ func (vm *VM) Initialize(
 	}
 
 	vm.codec = c
-
-	vm.state = &prefixedState{
-		state: &state{State: ava.State{
-			Cache: &cache.LRU{Size: stateCacheSize},
-			DB:    vm.db,
-			Codec: vm.codec,
-		}},
-
-		tx:       &cache.LRU{Size: idCacheSize},
-		utxo:     &cache.LRU{Size: idCacheSize},
-		txStatus: &cache.LRU{Size: idCacheSize},
-		funds:    &cache.LRU{Size: idCacheSize},
-
-		uniqueTx: &cache.EvictableLRU{Size: txCacheSize},
-	}
-
-	if err := vm.initAliases(genesisBytes); err != nil {
-		return err
-	}
-
-	if dbStatus, err := vm.state.DBInitialized(); err != nil || dbStatus == choices.Unknown {
-		if err := vm.initState(genesisBytes); err != nil {
-			return err
-		}
-	}
-
-	vm.timer = timer.NewTimer(func() {
-		ctx.Lock.Lock()
-		defer ctx.Lock.Unlock()
-
-		vm.FlushTxs()
-	})
-	go ctx.Log.RecoverAndPanic(vm.timer.Dispatch)
-	vm.batchTimeout = batchTimeout
-
-	return vm.db.Commit()
+	return nil
 }
 
 // Shutdown implements the avalanche.DAGVM interface
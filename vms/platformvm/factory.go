@@ -7,6 +7,7 @@ import (
 	"github.com/ava-labs/gecko/chains"
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/snow/validators"
+	"github.com/ava-labs/gecko/utils/timer"
 )
 
 // ID of the platform VM
@@ -21,6 +22,10 @@ type Factory struct {
 	StakingEnabled bool
 	AVA            ids.ID
 	AVM            ids.ID
+
+	// Clock of the Platform Chain. Tests may set it, so that the chain's
+	// stakers are added and removed as if it were a different time.
+	Clock timer.Clock
 }
 
 // New returns a new instance of the Platform Chain
@@ -31,5 +36,6 @@ func (f *Factory) New() (interface{}, error) {
 		stakingEnabled: f.StakingEnabled,
 		ava:            f.AVA,
 		avm:            f.AVM,
+		clock:          f.Clock,
 	}, nil
 }
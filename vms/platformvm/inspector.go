@@ -0,0 +1,90 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package platformvm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/vms/components/core"
+)
+
+// InspectorKeys describes the keys an Inspector can decode. IDs and addresses
+// are in their string representation. If the subnet of a validator set is
+// omitted, the default subnet is used.
+var InspectorKeys = []string{
+	"timestamp",
+	"lastAccepted",
+	"chains",
+	"subnets",
+	"currentValidators[/<subnetID>]",
+	"pendingValidators[/<subnetID>]",
+	"account/<address>",
+	"block/<blockID>",
+}
+
+// Inspector decodes the state of the platform chain from its database, without
+// running the chain. It's used to debug a node's database offline.
+type Inspector struct{ vm *VM }
+
+// NewInspector returns an inspector of the platform chain whose database is
+// [db]. Nothing is written to [db].
+func NewInspector(ctx *snow.Context, db database.Database) (*Inspector, error) {
+	vm := &VM{SnowmanVM: &core.SnowmanVM{}}
+	if err := vm.SnowmanVM.Initialize(ctx, db, vm.unmarshalBlockFunc, nil); err != nil {
+		return nil, err
+	}
+	vm.codec = Codec
+	vm.registerDBTypes()
+	return &Inspector{vm: vm}, nil
+}
+
+// Get returns the decoded value of [key], which is one of InspectorKeys
+func (i *Inspector) Get(key string) (interface{}, error) {
+	vm := i.vm
+	name, arg := key, ""
+	if index := strings.Index(key, "/"); index >= 0 {
+		name, arg = key[:index], key[index+1:]
+	}
+
+	switch name {
+	case "timestamp":
+		return vm.getTimestamp(vm.DB)
+	case "lastAccepted":
+		return vm.State.GetLastAccepted(vm.DB)
+	case "chains":
+		return vm.getChains(vm.DB)
+	case "subnets":
+		return vm.getSubnets(vm.DB)
+	case "currentValidators", "pendingValidators":
+		subnetID := DefaultSubnetID
+		if arg != "" {
+			var err error
+			if subnetID, err = ids.FromString(arg); err != nil {
+				return nil, err
+			}
+		}
+		if name == "currentValidators" {
+			return vm.getCurrentValidators(vm.DB, subnetID)
+		}
+		return vm.getPendingValidators(vm.DB, subnetID)
+	case "account":
+		address, err := ids.ShortFromString(arg)
+		if err != nil {
+			return nil, err
+		}
+		return vm.getAccount(vm.DB, address)
+	case "block":
+		blockID, err := ids.FromString(arg)
+		if err != nil {
+			return nil, err
+		}
+		return vm.State.GetBlock(vm.DB, blockID)
+	default:
+		return nil, fmt.Errorf("unknown key %s", key)
+	}
+}
@@ -0,0 +1,49 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package platformvm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInspector(t *testing.T) {
+	vm := defaultVM()
+
+	inspector, err := NewInspector(defaultContext(), vm.DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timestamp, err := inspector.Get("timestamp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !timestamp.(time.Time).Equal(defaultGenesisTime) {
+		t.Fatalf("Should have decoded the genesis time but decoded %s", timestamp)
+	}
+
+	subnets, err := inspector.Get("subnets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subnets := subnets.([]*CreateSubnetTx); len(subnets) != 1 || !subnets[0].ID().Equals(testSubnet1.ID()) {
+		t.Fatalf("Should have decoded the test subnet")
+	}
+
+	validators, err := inspector.Get("currentValidators")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if validators := validators.(*EventHeap); validators.Len() != len(keys) {
+		t.Fatalf("Should have decoded %d validators but decoded %d", len(keys), validators.Len())
+	}
+
+	if _, err := inspector.Get("currentValidators/" + testSubnet1.ID().String()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inspector.Get("utxo"); err == nil {
+		t.Fatalf("Should have errored on an unknown key")
+	}
+}
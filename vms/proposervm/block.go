@@ -0,0 +1,329 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package proposervm
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"time"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowman"
+	"github.com/ava-labs/gecko/utils/hashing"
+	"github.com/ava-labs/gecko/utils/wrappers"
+	"github.com/ava-labs/gecko/vms/components/missing"
+)
+
+var (
+	errUnknownParent            = errors.New("block's parent is unknown")
+	errWrongHeight              = errors.New("block's height isn't one more than its parent's height")
+	errWrongInnerParent         = errors.New("block's inner block isn't a child of its parent's inner block")
+	errInnerDecided             = errors.New("block's inner block was already decided")
+	errTimestampTooEarly        = errors.New("block's timestamp is earlier than its parent's timestamp")
+	errTimestampTooLate         = errors.New("block's timestamp is too far ahead of local time")
+	errProposerWindowNotStarted = errors.New("block's proposer may not propose a block yet")
+	errUnwrappedBlock           = errors.New("block without a proposer can't follow a block with one")
+	errUnwrappedAfterActivation = errors.New("block without a proposer can't be issued after the proposer windows activated")
+	errWrongProposerCert        = errors.New("block's certificate doesn't belong to its proposer")
+	errUnsupportedKey           = errors.New("block's certificate has a key type that isn't supported for signing")
+	errExtraBytes               = errors.New("block has trailing bytes")
+)
+
+// Block wraps a block of the inner VM with the proposer that built it, and
+// when it was built. When staking is enabled, the proposer signs the block,
+// and includes the staking certificate its ID is derived from.
+//
+// Blocks that were accepted before the proposer layer was enabled aren't
+// wrapped. They are represented by Blocks with no proposer, at height 0, whose
+// IDs and bytes are the IDs and bytes of the inner blocks.
+type Block struct {
+	vm *VM
+
+	id, parentID ids.ID
+	height       uint64
+	timestamp    int64 // Unix time, in seconds
+	proposer     ids.ShortID
+	cert         []byte // staking certificate of the proposer
+	inner        snowman.Block
+	signature    []byte // proposer's signature of unsignedBytes
+	bytes        []byte
+	status       choices.Status
+
+	// unsignedBytes is the prefix of bytes that the proposer signed
+	unsignedBytes []byte
+
+	// wrapped is false if the block was built before the proposer layer was
+	// enabled
+	wrapped bool
+}
+
+// newBlock wraps [inner] in a block proposed by this node, which is signed if
+// this node has a staking key
+func (vm *VM) newBlock(parentID ids.ID, height uint64, timestamp int64, inner snowman.Block) (*Block, error) {
+	cert := vm.config.StakingCert
+	innerBytes := inner.Bytes()
+	p := wrappers.Packer{Bytes: make([]byte, hashing.HashLen+2*wrappers.LongLen+hashing.AddrLen+2*wrappers.IntLen+len(cert)+len(innerBytes))}
+	p.PackFixedBytes(parentID.Bytes())
+	p.PackLong(height)
+	p.PackLong(uint64(timestamp))
+	p.PackFixedBytes(vm.ctx.NodeID.Bytes())
+	p.PackBytes(cert)
+	p.PackBytes(innerBytes)
+	if p.Errored() {
+		return nil, p.Err
+	}
+	unsignedBytes := p.Bytes
+
+	signature := []byte(nil)
+	if vm.config.StakingKey != nil {
+		sig, err := vm.config.StakingKey.Sign(rand.Reader, hashing.ComputeHash256(unsignedBytes), crypto.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		signature = sig
+	}
+
+	p = wrappers.Packer{Bytes: make([]byte, len(unsignedBytes)+wrappers.IntLen+len(signature))}
+	p.PackFixedBytes(unsignedBytes)
+	p.PackBytes(signature)
+	if p.Errored() {
+		return nil, p.Err
+	}
+
+	return &Block{
+		vm:            vm,
+		id:            ids.NewID(hashing.ComputeHash256Array(p.Bytes)),
+		parentID:      parentID,
+		height:        height,
+		timestamp:     timestamp,
+		proposer:      vm.ctx.NodeID,
+		cert:          cert,
+		inner:         inner,
+		signature:     signature,
+		bytes:         p.Bytes,
+		status:        choices.Processing,
+		wrapped:       true,
+		unsignedBytes: unsignedBytes,
+	}, nil
+}
+
+// parseBlock parses the bytes of a wrapped block
+func (vm *VM) parseBlock(b []byte) (*Block, error) {
+	p := wrappers.Packer{Bytes: b}
+	parentBytes := p.UnpackFixedBytes(hashing.HashLen)
+	height := p.UnpackLong()
+	timestamp := p.UnpackLong()
+	proposerBytes := p.UnpackFixedBytes(hashing.AddrLen)
+	cert := p.UnpackBytes()
+	innerBytes := p.UnpackBytes()
+	unsignedLen := p.Offset
+	signature := p.UnpackBytes()
+	if p.Offset != len(b) {
+		p.Add(errExtraBytes)
+	}
+	if p.Errored() {
+		return nil, p.Err
+	}
+
+	parentID, err := ids.ToID(parentBytes)
+	if err != nil {
+		return nil, err
+	}
+	proposer, err := ids.ToShortID(proposerBytes)
+	if err != nil {
+		return nil, err
+	}
+	inner, err := vm.ChainVM.ParseBlock(innerBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Block{
+		vm:            vm,
+		id:            ids.NewID(hashing.ComputeHash256Array(b)),
+		parentID:      parentID,
+		height:        height,
+		timestamp:     int64(timestamp),
+		proposer:      proposer,
+		cert:          cert,
+		inner:         inner,
+		signature:     signature,
+		bytes:         b,
+		status:        choices.Processing,
+		wrapped:       true,
+		unsignedBytes: b[:unsignedLen],
+	}, nil
+}
+
+// unwrappedBlock represents [inner], which was built without a proposer
+func (vm *VM) unwrappedBlock(inner snowman.Block) *Block {
+	parentID := ids.ID{}
+	if parent := inner.Parent(); parent != nil {
+		// The genesis block may not have a parent
+		parentID = parent.ID()
+	}
+	return &Block{
+		vm:       vm,
+		id:       inner.ID(),
+		parentID: parentID,
+		inner:    inner,
+		bytes:    inner.Bytes(),
+		status:   inner.Status(),
+	}
+}
+
+// ID implements the snowman.Block interface
+func (b *Block) ID() ids.ID { return b.id }
+
+// Status implements the snowman.Block interface
+func (b *Block) Status() choices.Status { return b.status }
+
+// Bytes implements the snowman.Block interface
+func (b *Block) Bytes() []byte { return b.bytes }
+
+// Parent implements the snowman.Block interface
+func (b *Block) Parent() snowman.Block {
+	if parent, err := b.vm.getBlock(b.parentID); err == nil {
+		return parent
+	}
+	return &missing.Block{BlkID: b.parentID}
+}
+
+// Verify implements the snowman.Block interface. The block must follow its
+// parent, must be signed by its proposer if staking is enabled, and must not be
+// built before its proposer's window started.
+//
+// The windows are computed from the current validator set, so they only reduce
+// how many blocks validators build at the same height. Safety doesn't depend
+// on them. Blocks verified while bootstrapping were already accepted by the
+// network, possibly while other validators were proposing or before the
+// windows activated, so they aren't checked against the windows, the
+// activation time, or local time.
+func (b *Block) Verify() error {
+	if !b.wrapped {
+		// No blocks without a proposer may be issued after the activation.
+		// Once a block has a proposer every block after it must have one too.
+		if b.vm.bootstrapped && !b.vm.clock.Time().Before(b.vm.config.ActivationTime) {
+			return errUnwrappedAfterActivation
+		}
+		if wrapped, err := b.vm.inners.Has(b.parentID.Bytes()); err != nil {
+			return err
+		} else if wrapped {
+			return errUnwrappedBlock
+		}
+		if _, err := b.vm.getBlock(b.parentID); err != nil {
+			return errUnknownParent
+		}
+		return b.verifyInner()
+	}
+
+	parent, err := b.vm.getBlock(b.parentID)
+	if err != nil {
+		return errUnknownParent
+	}
+
+	switch {
+	case b.height != parent.height+1:
+		return errWrongHeight
+	case !b.inner.Parent().ID().Equals(parent.inner.ID()):
+		return errWrongInnerParent
+	case b.inner.Status() != choices.Processing:
+		return errInnerDecided
+	case b.timestamp < parent.timestamp:
+		return errTimestampTooEarly
+	}
+
+	if b.vm.bootstrapped {
+		timestamp := time.Unix(b.timestamp, 0)
+		if timestamp.After(b.vm.clock.Time().Add(b.vm.config.MaxClockSkew)) {
+			return errTimestampTooLate
+		}
+		windowStart := time.Unix(parent.timestamp, 0).Add(b.vm.windower.delay(b.height, b.proposer))
+		if timestamp.Before(windowStart) {
+			return errProposerWindowNotStarted
+		}
+	}
+	if err := b.verifyProposer(); err != nil {
+		return err
+	}
+
+	if err := b.verifyInner(); err != nil {
+		return err
+	}
+	return b.vm.putBlock(b)
+}
+
+// verifyProposer checks that the block was signed by its proposer. Blocks are
+// only signed when staking is enabled.
+func (b *Block) verifyProposer() error {
+	if b.vm.config.StakingKey == nil {
+		return nil
+	}
+
+	cert, err := x509.ParseCertificate(b.cert)
+	if err != nil {
+		return err
+	}
+	certID, err := ids.ToShortID(hashing.PubkeyBytesToAddress(cert.Raw))
+	if err != nil {
+		return err
+	}
+	if !certID.Equals(b.proposer) {
+		return errWrongProposerCert
+	}
+
+	var algorithm x509.SignatureAlgorithm
+	switch cert.PublicKeyAlgorithm {
+	case x509.RSA:
+		algorithm = x509.SHA256WithRSA
+	case x509.ECDSA:
+		algorithm = x509.ECDSAWithSHA256
+	default:
+		return errUnsupportedKey
+	}
+	return cert.CheckSignature(algorithm, b.unsignedBytes, b.signature)
+}
+
+// verifyInner verifies the inner block, unless another block that wraps it
+// was already verified
+func (b *Block) verifyInner() error {
+	innerKey := b.inner.ID().Key()
+	if b.vm.verified[innerKey] == 0 {
+		if err := b.inner.Verify(); err != nil {
+			return err
+		}
+	}
+	b.vm.verified[innerKey]++
+	return nil
+}
+
+// Accept implements the snowman.Block interface
+func (b *Block) Accept() {
+	b.status = choices.Accepted
+	b.vm.unverify(b)
+	if b.wrapped {
+		if err := b.vm.putBlock(b); err != nil {
+			b.vm.ctx.Log.Error("Failed to persist block %s due to %s", b.id, err)
+		}
+	}
+	b.vm.setLastAccepted(b.id)
+	b.inner.Accept()
+}
+
+// Reject implements the snowman.Block interface
+func (b *Block) Reject() {
+	b.status = choices.Rejected
+	if b.wrapped {
+		if err := b.vm.putBlock(b); err != nil {
+			b.vm.ctx.Log.Error("Failed to persist block %s due to %s", b.id, err)
+		}
+	}
+	// The inner block may still be accepted as part of another block
+	if b.vm.unverify(b) == 0 && b.inner.Status() == choices.Processing {
+		b.inner.Reject()
+	}
+}
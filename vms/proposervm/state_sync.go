@@ -0,0 +1,203 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package proposervm
+
+import (
+	"errors"
+
+	"github.com/ava-labs/gecko/cache"
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/utils/hashing"
+	"github.com/ava-labs/gecko/utils/wrappers"
+
+	smeng "github.com/ava-labs/gecko/snow/engine/snowman"
+)
+
+const (
+	// summaryCacheSize is the number of summaries this node served whose
+	// chunks can be requested
+	summaryCacheSize = 16
+)
+
+var (
+	errWrongSummaryBlock = errors.New("summary's block doesn't wrap the block the inner summary commits to")
+	errUnknownSummary    = errors.New("summary isn't known")
+)
+
+// stateSyncableVM is a VM whose inner VM supports state sync. The summaries of
+// the inner VM are extended with the wrapped block that contains the block
+// they commit to, so that the block is accepted once the state is synced.
+type stateSyncableVM struct {
+	*VM
+	innerVM smeng.StateSyncableVM
+
+	// IDs of the summaries this node served --> IDs of their inner summaries
+	summaryIDs cache.LRU
+}
+
+func newStateSyncableVM(vm *VM, innerVM smeng.StateSyncableVM) *stateSyncableVM {
+	return &stateSyncableVM{
+		VM:         vm,
+		innerVM:    innerVM,
+		summaryIDs: cache.LRU{Size: summaryCacheSize},
+	}
+}
+
+// StateSummary implements the smeng.StateSyncableVM interface
+func (vm *stateSyncableVM) StateSummary() (smeng.Summary, error) {
+	inner, err := vm.innerVM.StateSummary()
+	if err != nil {
+		return nil, err
+	}
+
+	// The inner block was either accepted as part of a wrapped block, or
+	// accepted before the proposer layer was enabled
+	var blk *Block
+	if blkIDBytes, err := vm.inners.Get(inner.BlockID().Bytes()); err == nil {
+		blkID, err := ids.ToID(blkIDBytes)
+		if err != nil {
+			return nil, err
+		}
+		if blk, err = vm.getBlock(blkID); err != nil {
+			return nil, err
+		}
+	}
+
+	summary, err := newStateSummary(blk, inner)
+	if err != nil {
+		return nil, err
+	}
+	vm.summaryIDs.Put(summary.ID(), inner.ID())
+	return summary, nil
+}
+
+// ParseSummary implements the smeng.StateSyncableVM interface
+func (vm *stateSyncableVM) ParseSummary(b []byte) (smeng.Summary, error) {
+	p := wrappers.Packer{Bytes: b}
+	blkBytes := p.UnpackBytes()
+	innerBytes := p.UnpackBytes()
+	if p.Offset != len(b) {
+		p.Add(errExtraBytes)
+	}
+	if p.Errored() {
+		return nil, p.Err
+	}
+
+	inner, err := vm.innerVM.ParseSummary(innerBytes)
+	if err != nil {
+		return nil, err
+	}
+	var blk *Block
+	if len(blkBytes) > 0 {
+		if blk, err = vm.parseBlock(blkBytes); err != nil {
+			return nil, err
+		}
+		if !blk.inner.ID().Equals(inner.BlockID()) {
+			return nil, errWrongSummaryBlock
+		}
+		if err := blk.verifyProposer(); err != nil {
+			return nil, err
+		}
+	}
+	return &stateSummary{
+		id:    ids.NewID(hashing.ComputeHash256Array(b)),
+		block: blk,
+		inner: inner,
+		bytes: b,
+	}, nil
+}
+
+// GetStateChunk implements the smeng.StateSyncableVM interface
+func (vm *stateSyncableVM) GetStateChunk(summaryID ids.ID, index uint32) ([]byte, error) {
+	innerID, ok := vm.summaryIDs.Get(summaryID)
+	if !ok {
+		// The summary may not have been requested since this node restarted
+		if _, err := vm.StateSummary(); err != nil {
+			return nil, err
+		}
+		if innerID, ok = vm.summaryIDs.Get(summaryID); !ok {
+			return nil, errUnknownSummary
+		}
+	}
+	return vm.innerVM.GetStateChunk(innerID.(ids.ID), index)
+}
+
+// SyncState implements the smeng.StateSyncableVM interface
+func (vm *stateSyncableVM) SyncState(s smeng.Summary, chunks [][]byte) error {
+	summary, ok := s.(*stateSummary)
+	if !ok {
+		return errUnknownSummary
+	}
+	if err := vm.innerVM.SyncState(summary.inner, chunks); err != nil {
+		return err
+	}
+
+	if blk := summary.block; blk != nil {
+		blk.status = choices.Accepted
+		if err := vm.putBlock(blk); err != nil {
+			return err
+		}
+	}
+	vm.setLastAccepted(summary.BlockID())
+	vm.preferred = vm.lastAccepted
+	return nil
+}
+
+// stateSummary is a summary of the inner VM's state, and the wrapped block that
+// contains the block the inner summary commits to. If that block was accepted
+// before the proposer layer was enabled, there is no wrapped block.
+type stateSummary struct {
+	id    ids.ID
+	block *Block
+	inner smeng.Summary
+	bytes []byte
+}
+
+func newStateSummary(blk *Block, inner smeng.Summary) (*stateSummary, error) {
+	blkBytes := []byte(nil)
+	if blk != nil {
+		blkBytes = blk.bytes
+	}
+	innerBytes := inner.Bytes()
+	p := wrappers.Packer{Bytes: make([]byte, 2*wrappers.IntLen+len(blkBytes)+len(innerBytes))}
+	p.PackBytes(blkBytes)
+	p.PackBytes(innerBytes)
+	if p.Errored() {
+		return nil, p.Err
+	}
+	return &stateSummary{
+		id:    ids.NewID(hashing.ComputeHash256Array(p.Bytes)),
+		block: blk,
+		inner: inner,
+		bytes: p.Bytes,
+	}, nil
+}
+
+// ID implements the smeng.Summary interface
+func (s *stateSummary) ID() ids.ID { return s.id }
+
+// BlockID implements the smeng.Summary interface
+func (s *stateSummary) BlockID() ids.ID {
+	if s.block != nil {
+		return s.block.id
+	}
+	return s.inner.BlockID()
+}
+
+// Height implements the smeng.Summary interface. Heights are those of the
+// inner blocks, since blocks accepted before the proposer layer was enabled
+// have no height of their own.
+func (s *stateSummary) Height() uint64 { return s.inner.Height() }
+
+// NumChunks implements the smeng.Summary interface
+func (s *stateSummary) NumChunks() uint32 { return s.inner.NumChunks() }
+
+// VerifyChunk implements the smeng.Summary interface
+func (s *stateSummary) VerifyChunk(index uint32, chunk []byte) error {
+	return s.inner.VerifyChunk(index, chunk)
+}
+
+// Bytes implements the smeng.Summary interface
+func (s *stateSummary) Bytes() []byte { return s.bytes }
@@ -0,0 +1,161 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package proposervm
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/gecko/database/memdb"
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/validators"
+	"github.com/ava-labs/gecko/utils/hashing"
+
+	smeng "github.com/ava-labs/gecko/snow/engine/snowman"
+)
+
+var errInvalidChunk = errors.New("invalid chunk")
+
+type testSummary struct {
+	blkID  ids.ID
+	height uint64
+	chunks [][]byte
+}
+
+func (s *testSummary) ID() ids.ID        { return ids.NewID(hashing.ComputeHash256Array(s.Bytes())) }
+func (s *testSummary) BlockID() ids.ID   { return s.blkID }
+func (s *testSummary) Height() uint64    { return s.height }
+func (s *testSummary) NumChunks() uint32 { return uint32(len(s.chunks)) }
+func (s *testSummary) Bytes() []byte     { return s.blkID.Bytes() }
+func (s *testSummary) VerifyChunk(index uint32, chunk []byte) error {
+	if int(index) >= len(s.chunks) || !bytes.Equal(s.chunks[index], chunk) {
+		return errInvalidChunk
+	}
+	return nil
+}
+
+// testStateSyncVM serves, and syncs to, a single summary
+type testStateSyncVM struct {
+	*smeng.VMTest
+	summary *testSummary
+	synced  smeng.Summary
+}
+
+func (vm *testStateSyncVM) StateSummary() (smeng.Summary, error) { return vm.summary, nil }
+func (vm *testStateSyncVM) ParseSummary(b []byte) (smeng.Summary, error) {
+	if !bytes.Equal(b, vm.summary.Bytes()) {
+		return nil, errUnknownSummary
+	}
+	return vm.summary, nil
+}
+func (vm *testStateSyncVM) GetStateChunk(summaryID ids.ID, index uint32) ([]byte, error) {
+	if !summaryID.Equals(vm.summary.ID()) {
+		return nil, errUnknownSummary
+	}
+	return vm.summary.chunks[index], nil
+}
+func (vm *testStateSyncVM) SyncState(summary smeng.Summary, chunks [][]byte) error {
+	vm.synced = summary
+	return nil
+}
+
+// setupStateSync returns a proposer VM that supports state sync, wrapping a VM
+// that knows about [blks] and whose summary commits to the second block
+func setupStateSync(t *testing.T, blks ...*testBlock) (*stateSyncableVM, *testStateSyncVM) {
+	vm, innerVM := setup(t, memdb.New(), blks...)
+	innerSyncVM := &testStateSyncVM{
+		VMTest: innerVM,
+		summary: &testSummary{
+			blkID:  blks[1].ID(),
+			height: 1,
+			chunks: [][]byte{{1}, {2}},
+		},
+	}
+	return newStateSyncableVM(vm, innerSyncVM), innerSyncVM
+}
+
+func TestNewStateSyncableVM(t *testing.T) {
+	innerVM := &smeng.VMTest{}
+	if _, ok := New(innerVM, memdb.New(), validators.NewSet(), DefaultConfig()).(smeng.StateSyncableVM); ok {
+		t.Fatalf("VM shouldn't support state sync if the inner VM doesn't")
+	}
+	innerSyncVM := &testStateSyncVM{VMTest: innerVM}
+	if _, ok := New(innerSyncVM, memdb.New(), validators.NewSet(), DefaultConfig()).(smeng.StateSyncableVM); !ok {
+		t.Fatalf("VM should support state sync if the inner VM does")
+	}
+}
+
+func TestVMStateSync(t *testing.T) {
+	genesis, innerBlk1, innerBlk2 := testBlocks()
+	server, _ := setupStateSync(t, genesis, innerBlk1, innerBlk2)
+	defer server.Shutdown()
+
+	start := time.Unix(1000000, 0)
+	server.clock.Set(start)
+
+	blk1 := newTestBlock(t, server.VM, genesis.ID(), 1, start.Unix(), innerBlk1)
+	if err := blk1.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	blk1.Accept()
+
+	summary, err := server.StateSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !summary.BlockID().Equals(blk1.ID()) {
+		t.Fatalf("Summary should commit to the block that wraps the inner summary's block")
+	}
+	if chunk, err := server.GetStateChunk(summary.ID(), 1); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(chunk, []byte{2}) {
+		t.Fatalf("Served the wrong chunk")
+	}
+
+	// The client hasn't accepted any of the blocks
+	innerBlk1.status = choices.Processing
+	client, innerClient := setupStateSync(t, genesis, innerBlk1, innerBlk2)
+	defer client.Shutdown()
+
+	parsed, err := client.ParseSummary(summary.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.ID().Equals(summary.ID()) || !parsed.BlockID().Equals(blk1.ID()) {
+		t.Fatalf("Parsed the wrong summary")
+	}
+	if err := client.SyncState(parsed, [][]byte{{1}, {2}}); err != nil {
+		t.Fatal(err)
+	}
+	if innerClient.synced != innerClient.summary {
+		t.Fatalf("Inner VM should have synced to the inner summary")
+	}
+	if !client.LastAccepted().Equals(blk1.ID()) {
+		t.Fatalf("The summary's block should be the last accepted block")
+	}
+	if blk, err := client.GetBlock(blk1.ID()); err != nil {
+		t.Fatal(err)
+	} else if blk.Status() != choices.Accepted {
+		t.Fatalf("The summary's block should have been accepted")
+	}
+}
+
+func TestVMStateSyncWrongBlock(t *testing.T) {
+	genesis, innerBlk1, innerBlk2 := testBlocks()
+	vm, innerVM := setupStateSync(t, genesis, innerBlk1, innerBlk2)
+	defer vm.Shutdown()
+
+	// The block doesn't contain the block the inner summary commits to
+	blk1 := newTestBlock(t, vm.VM, genesis.ID(), 1, 0, innerBlk2)
+	summary, err := newStateSummary(blk1, innerVM.summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vm.ParseSummary(summary.Bytes()); err != errWrongSummaryBlock {
+		t.Fatalf("Should have failed to parse the summary, but got %v", err)
+	}
+}
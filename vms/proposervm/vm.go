@@ -0,0 +1,380 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package proposervm wraps a snowman ChainVM so that validators take turns
+// proposing blocks.
+//
+// Without it, every validator builds a block as soon as its VM has pending
+// transactions, and consensus has to choose between the competing siblings.
+// With it, the validators are put in a stake-weighted order at each height,
+// and each validator waits for its window before building a block. Blocks
+// record their proposer and timestamp, which are checked in Verify.
+//
+// The inner VM doesn't need to change. Its blocks are wrapped when they are
+// built, and unwrapped when they are handed back to it.
+//
+// When staking is enabled, proposers sign the blocks they build with their
+// staking keys, so a node can't propose blocks in another node's window.
+package proposervm
+
+import (
+	"crypto"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/database/prefixdb"
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowman"
+	"github.com/ava-labs/gecko/snow/engine/common"
+	"github.com/ava-labs/gecko/snow/validators"
+	"github.com/ava-labs/gecko/utils/timer"
+	"github.com/ava-labs/gecko/utils/wrappers"
+
+	smeng "github.com/ava-labs/gecko/snow/engine/snowman"
+)
+
+// Config defines the proposer windows
+type Config struct {
+	// WindowDuration is how long each proposer may propose a block before the
+	// next proposer may too
+	WindowDuration time.Duration
+
+	// NumWindows is the number of proposers at each height. Once all of their
+	// windows have started, any node may propose a block.
+	NumWindows int
+
+	// MaxClockSkew is how far ahead of local time a block's timestamp may be
+	MaxClockSkew time.Duration
+
+	// ActivationTime is when the proposer windows start being enforced. Until
+	// then, blocks are built without a proposer. After it, blocks without a
+	// proposer fail verification. By default, it's far enough in the future
+	// that the windows are never enforced.
+	ActivationTime time.Time
+
+	// StakingKey signs the blocks this node proposes, and StakingCert proves
+	// that this node owns the key. If StakingKey is nil, as when staking is
+	// disabled, blocks aren't signed and their proposers aren't checked.
+	StakingKey  crypto.Signer
+	StakingCert []byte
+}
+
+// DefaultConfig returns the default proposer windows
+func DefaultConfig() Config {
+	return Config{
+		WindowDuration: 5 * time.Second,
+		NumWindows:     6,
+		MaxClockSkew:   10 * time.Second,
+		ActivationTime: DefaultActivationTime,
+	}
+}
+
+// DefaultActivationTime is used until an activation time is scheduled
+var DefaultActivationTime = time.Date(9999, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Valid returns nil if the config describes valid proposer windows
+func (c Config) Valid() error {
+	switch {
+	case c.WindowDuration <= 0:
+		return errInvalidWindowDuration
+	case c.NumWindows <= 0:
+		return errInvalidNumWindows
+	case c.MaxClockSkew < 0:
+		return errInvalidClockSkew
+	default:
+		return nil
+	}
+}
+
+var (
+	errInvalidWindowDuration = errors.New("proposer window duration must be positive")
+	errInvalidNumWindows     = errors.New("number of proposer windows must be positive")
+	errInvalidClockSkew      = errors.New("max clock skew can't be negative")
+	errWrongInnerBlock       = errors.New("inner VM didn't build on the preferred block")
+
+	lastAcceptedKey = []byte("lastAccepted")
+)
+
+// VM wraps a ChainVM with proposer windows
+type VM struct {
+	smeng.ChainVM
+
+	config   Config
+	windower windower
+	clock    timer.Clock
+
+	ctx      *snow.Context
+	toEngine chan<- common.Message
+
+	// blocks maps the IDs of wrapped blocks to their status and bytes.
+	// inners maps the IDs of the inner blocks of accepted wrapped blocks to
+	// the IDs of the wrapped blocks.
+	// state holds the ID of the last accepted block.
+	blocks, inners, state database.Database
+
+	preferred, lastAccepted ids.ID
+
+	// bootstrapped is false while the chain is bootstrapping blocks that the
+	// network already accepted
+	bootstrapped bool
+
+	// Number of verified, undecided blocks that contain each inner block,
+	// whether they wrap it or not
+	verified map[[32]byte]int
+
+	timerLock   sync.Mutex
+	notifyTimer *time.Timer
+}
+
+// New returns [vm] wrapped with the proposer windows described by [config].
+// [db] stores the wrapped blocks, and must not be used by [vm]. [vdrs] are
+// the validators of the chain, who take turns proposing blocks. If [vm] is a
+// smeng.StateSyncableVM, so is the returned VM.
+func New(vm smeng.ChainVM, db database.Database, vdrs validators.Set, config Config) smeng.ChainVM {
+	wrapped := newVM(vm, db, vdrs, config)
+	if innerVM, ok := vm.(smeng.StateSyncableVM); ok {
+		return newStateSyncableVM(wrapped, innerVM)
+	}
+	return wrapped
+}
+
+func newVM(vm smeng.ChainVM, db database.Database, vdrs validators.Set, config Config) *VM {
+	return &VM{
+		ChainVM: vm,
+		config:  config,
+		windower: windower{
+			validators: vdrs,
+			config:     config,
+		},
+		blocks:   prefixdb.New([]byte("block"), db),
+		inners:   prefixdb.New([]byte("inner"), db),
+		state:    prefixdb.New([]byte("state"), db),
+		verified: make(map[[32]byte]int),
+	}
+}
+
+// Initialize implements the common.VM interface
+func (vm *VM) Initialize(
+	ctx *snow.Context,
+	db database.Database,
+	genesisBytes []byte,
+	toEngine chan<- common.Message,
+	fxs []*common.Fx,
+) error {
+	vm.ctx = ctx
+	vm.toEngine = toEngine
+	vm.windower.chainID = ctx.ChainID
+
+	if err := vm.ChainVM.Initialize(ctx, db, genesisBytes, toEngine, fxs); err != nil {
+		return err
+	}
+
+	lastAcceptedBytes, err := vm.state.Get(lastAcceptedKey)
+	switch err {
+	case nil:
+		if vm.lastAccepted, err = ids.ToID(lastAcceptedBytes); err != nil {
+			return err
+		}
+	case database.ErrNotFound:
+		// No blocks were accepted since the proposer layer was enabled
+		vm.lastAccepted = vm.ChainVM.LastAccepted()
+	default:
+		return err
+	}
+	vm.preferred = vm.lastAccepted
+	return nil
+}
+
+// Shutdown implements the common.VM interface
+func (vm *VM) Shutdown() {
+	vm.timerLock.Lock()
+	if vm.notifyTimer != nil {
+		vm.notifyTimer.Stop()
+	}
+	vm.timerLock.Unlock()
+
+	vm.ChainVM.Shutdown()
+}
+
+// BuildBlock implements the ChainVM interface. If this node's window at the
+// next height hasn't started, the engine is notified again once it starts.
+func (vm *VM) BuildBlock() (snowman.Block, error) {
+	parent, err := vm.getBlock(vm.preferred)
+	if err != nil {
+		return nil, err
+	}
+
+	now := vm.clock.Time()
+	if now.Before(vm.config.ActivationTime) && !parent.wrapped {
+		// Until the proposer windows activate, blocks are built as if the
+		// proposer layer weren't enabled
+		inner, err := vm.ChainVM.BuildBlock()
+		if err != nil {
+			return nil, err
+		}
+		return vm.unwrappedBlock(inner), nil
+	}
+
+	height := parent.height + 1
+	windowStart := time.Unix(parent.timestamp, 0).Add(vm.windower.delay(height, vm.ctx.NodeID))
+	if now.Before(windowStart) {
+		vm.ctx.Log.Debug("Delaying building a block at height %d until %s", height, windowStart)
+		vm.notifyAt(windowStart)
+		return nil, errProposerWindowNotStarted
+	}
+
+	inner, err := vm.ChainVM.BuildBlock()
+	if err != nil {
+		return nil, err
+	}
+	if !inner.Parent().ID().Equals(parent.inner.ID()) {
+		return nil, errWrongInnerBlock
+	}
+
+	timestamp := now.Unix()
+	if timestamp < parent.timestamp {
+		timestamp = parent.timestamp
+	}
+	return vm.newBlock(parent.id, height, timestamp, inner)
+}
+
+// ParseBlock implements the ChainVM interface
+func (vm *VM) ParseBlock(b []byte) (snowman.Block, error) {
+	if blk, err := vm.parseBlock(b); err == nil {
+		if status, err := vm.getStatus(blk.id); err == nil {
+			blk.status = status
+		}
+		return blk, nil
+	}
+
+	// The block may have been built before the proposer layer was enabled
+	inner, err := vm.ChainVM.ParseBlock(b)
+	if err != nil {
+		return nil, err
+	}
+	return vm.unwrappedBlock(inner), nil
+}
+
+// GetBlock implements the ChainVM interface
+func (vm *VM) GetBlock(id ids.ID) (snowman.Block, error) { return vm.getBlock(id) }
+
+// SetPreference implements the ChainVM interface
+func (vm *VM) SetPreference(id ids.ID) {
+	blk, err := vm.getBlock(id)
+	if err != nil {
+		vm.ctx.Log.Error("Failed to set preference to unknown block %s", id)
+		return
+	}
+	vm.preferred = id
+	vm.ChainVM.SetPreference(blk.inner.ID())
+}
+
+// LastAccepted implements the ChainVM interface
+func (vm *VM) LastAccepted() ids.ID { return vm.lastAccepted }
+
+// Bootstrapped implements the smeng.BootstrappableVM interface
+func (vm *VM) Bootstrapped() {
+	vm.bootstrapped = true
+	if innerVM, ok := vm.ChainVM.(smeng.BootstrappableVM); ok {
+		innerVM.Bootstrapped()
+	}
+}
+
+func (vm *VM) getBlock(id ids.ID) (*Block, error) {
+	if value, err := vm.blocks.Get(id.Bytes()); err == nil {
+		p := wrappers.Packer{Bytes: value}
+		status := choices.Status(p.UnpackInt())
+		blkBytes := p.UnpackBytes()
+		if p.Errored() {
+			return nil, p.Err
+		}
+
+		blk, err := vm.parseBlock(blkBytes)
+		if err != nil {
+			return nil, err
+		}
+		blk.status = status
+		return blk, nil
+	}
+
+	// An inner block that was accepted as part of a wrapped block must not be
+	// treated as an unwrapped block
+	if wrapped, err := vm.inners.Has(id.Bytes()); err != nil {
+		return nil, err
+	} else if wrapped {
+		return nil, database.ErrNotFound
+	}
+
+	inner, err := vm.ChainVM.GetBlock(id)
+	if err != nil {
+		return nil, err
+	}
+	return vm.unwrappedBlock(inner), nil
+}
+
+func (vm *VM) getStatus(id ids.ID) (choices.Status, error) {
+	value, err := vm.blocks.Get(id.Bytes())
+	if err != nil {
+		return choices.Unknown, err
+	}
+	p := wrappers.Packer{Bytes: value}
+	status := choices.Status(p.UnpackInt())
+	return status, p.Err
+}
+
+func (vm *VM) putBlock(blk *Block) error {
+	p := wrappers.Packer{Bytes: make([]byte, 2*wrappers.IntLen+len(blk.bytes))}
+	p.PackInt(uint32(blk.status))
+	p.PackBytes(blk.bytes)
+	if p.Errored() {
+		return p.Err
+	}
+	if blk.status == choices.Accepted {
+		if err := vm.inners.Put(blk.inner.ID().Bytes(), blk.id.Bytes()); err != nil {
+			return err
+		}
+	}
+	return vm.blocks.Put(blk.id.Bytes(), p.Bytes)
+}
+
+func (vm *VM) setLastAccepted(id ids.ID) {
+	vm.lastAccepted = id
+	if err := vm.state.Put(lastAcceptedKey, id.Bytes()); err != nil {
+		vm.ctx.Log.Error("Failed to persist the last accepted block %s due to %s", id, err)
+	}
+}
+
+// unverify records that [blk] was decided, and returns the number of verified,
+// undecided blocks left that contain the same inner block
+func (vm *VM) unverify(blk *Block) int {
+	innerKey := blk.inner.ID().Key()
+	numVerified := vm.verified[innerKey]
+	if numVerified <= 1 {
+		delete(vm.verified, innerKey)
+		return 0
+	}
+	vm.verified[innerKey] = numVerified - 1
+	return numVerified - 1
+}
+
+// notifyAt notifies the engine that a block may be built at [t]
+func (vm *VM) notifyAt(t time.Time) {
+	vm.timerLock.Lock()
+	defer vm.timerLock.Unlock()
+
+	if vm.notifyTimer != nil {
+		vm.notifyTimer.Stop()
+	}
+	vm.notifyTimer = time.AfterFunc(t.Sub(vm.clock.Time()), func() {
+		// If a notification is already queued, the engine will build a block
+		// anyway
+		select {
+		case vm.toEngine <- common.PendingTxs:
+		default:
+		}
+	})
+}
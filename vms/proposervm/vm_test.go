@@ -0,0 +1,461 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package proposervm
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/gecko/database"
+	"github.com/ava-labs/gecko/database/memdb"
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow"
+	"github.com/ava-labs/gecko/snow/choices"
+	"github.com/ava-labs/gecko/snow/consensus/snowman"
+	"github.com/ava-labs/gecko/snow/engine/common"
+	"github.com/ava-labs/gecko/snow/validators"
+	"github.com/ava-labs/gecko/utils/hashing"
+
+	smeng "github.com/ava-labs/gecko/snow/engine/snowman"
+)
+
+var errUnknownBlock = errors.New("unknown block")
+
+type testBlock struct {
+	parent      snowman.Block
+	id          ids.ID
+	status      choices.Status
+	bytes       []byte
+	numVerified int
+}
+
+func (b *testBlock) Parent() snowman.Block  { return b.parent }
+func (b *testBlock) ID() ids.ID             { return b.id }
+func (b *testBlock) Status() choices.Status { return b.status }
+func (b *testBlock) Accept()                { b.status = choices.Accepted }
+func (b *testBlock) Reject()                { b.status = choices.Rejected }
+func (b *testBlock) Verify() error          { b.numVerified++; return nil }
+func (b *testBlock) Bytes() []byte          { return b.bytes }
+
+// setup returns a proposer VM, whose only validator isn't this node, wrapping
+// a VM that knows about [blks]. The first block is the inner VM's last
+// accepted block.
+func setup(t *testing.T, db database.Database, blks ...*testBlock) (*VM, *smeng.VMTest) {
+	innerVM := &smeng.VMTest{}
+	innerVM.T = t
+	innerVM.Default(true)
+	innerVM.InitializeF = func(*snow.Context, database.Database, []byte, chan<- common.Message, []*common.Fx) error { return nil }
+	innerVM.ShutdownF = func() {}
+	innerVM.LastAcceptedF = func() ids.ID { return blks[0].ID() }
+	innerVM.SetPreferenceF = func(ids.ID) {}
+	innerVM.GetBlockF = func(id ids.ID) (snowman.Block, error) {
+		for _, blk := range blks {
+			if blk.ID().Equals(id) {
+				return blk, nil
+			}
+		}
+		return nil, errUnknownBlock
+	}
+	innerVM.ParseBlockF = func(b []byte) (snowman.Block, error) {
+		for _, blk := range blks {
+			if bytes.Equal(blk.Bytes(), b) {
+				return blk, nil
+			}
+		}
+		return nil, errUnknownBlock
+	}
+
+	vdrs := validators.NewSet()
+	vdrs.Add(validators.GenerateRandomValidator(1))
+
+	// The windows are enforced unless a test schedules the activation
+	config := DefaultConfig()
+	config.ActivationTime = time.Time{}
+
+	vm := newVM(innerVM, db, vdrs, config)
+	if err := vm.Initialize(snow.DefaultContextTest(), memdb.New(), nil, make(chan common.Message, 1), nil); err != nil {
+		t.Fatal(err)
+	}
+	vm.Bootstrapped()
+	return vm, innerVM
+}
+
+// newTestBlock wraps [inner] in a block proposed by this node
+func newTestBlock(t *testing.T, vm *VM, parentID ids.ID, height uint64, timestamp int64, inner snowman.Block) *Block {
+	blk, err := vm.newBlock(parentID, height, timestamp, inner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blk
+}
+
+// setStakingKey gives this node a new staking key, and the ID derived from its
+// certificate
+func setStakingKey(t *testing.T, vm *VM) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeID, err := ids.ToShortID(hashing.PubkeyBytesToAddress(cert))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm.config.StakingKey = key
+	vm.config.StakingCert = cert
+	vm.ctx.NodeID = nodeID
+}
+
+func testBlocks() (*testBlock, *testBlock, *testBlock) {
+	genesis := &testBlock{
+		id:     ids.Empty.Prefix(0),
+		status: choices.Accepted,
+		bytes:  []byte{0},
+	}
+	blk1 := &testBlock{
+		parent: genesis,
+		id:     ids.Empty.Prefix(1),
+		status: choices.Processing,
+		bytes:  []byte{1},
+	}
+	blk2 := &testBlock{
+		parent: blk1,
+		id:     ids.Empty.Prefix(2),
+		status: choices.Processing,
+		bytes:  []byte{2},
+	}
+	return genesis, blk1, blk2
+}
+
+func TestVMBuildBlockWaitsForWindow(t *testing.T) {
+	genesis, innerBlk1, innerBlk2 := testBlocks()
+	vm, innerVM := setup(t, memdb.New(), genesis, innerBlk1, innerBlk2)
+	defer vm.Shutdown()
+
+	start := time.Unix(1000000, 0)
+	vm.clock.Set(start)
+
+	// The parent was built before the proposer layer was enabled, so every
+	// window has passed
+	innerVM.BuildBlockF = func() (snowman.Block, error) { return innerBlk1, nil }
+	blk1, err := vm.BuildBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := blk1.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	vm.SetPreference(blk1.ID())
+
+	innerVM.BuildBlockF = func() (snowman.Block, error) { return innerBlk2, nil }
+
+	// This node isn't a validator, so it must wait for the validator's window
+	vm.clock.Set(start.Add(time.Second))
+	if _, err := vm.BuildBlock(); err != errProposerWindowNotStarted {
+		t.Fatalf("Should have waited for the proposer window, but got %v", err)
+	}
+
+	vm.clock.Set(start.Add(vm.config.WindowDuration))
+	blk2, err := vm.BuildBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := blk2.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if !blk2.Parent().ID().Equals(blk1.ID()) {
+		t.Fatalf("Wrong parent")
+	}
+}
+
+func TestVMVerifyTimestamps(t *testing.T) {
+	genesis, innerBlk1, innerBlk2 := testBlocks()
+	vm, _ := setup(t, memdb.New(), genesis, innerBlk1, innerBlk2)
+	defer vm.Shutdown()
+
+	start := time.Unix(1000000, 0)
+	vm.clock.Set(start)
+
+	blk1 := newTestBlock(t, vm, genesis.ID(), 1, start.Unix(), innerBlk1)
+	if err := blk1.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	early := newTestBlock(t, vm, blk1.ID(), 2, start.Unix()+1, innerBlk2)
+	if err := early.Verify(); err != errProposerWindowNotStarted {
+		t.Fatalf("Should have failed verification due to the proposer window, but got %v", err)
+	}
+
+	beforeParent := newTestBlock(t, vm, blk1.ID(), 2, start.Unix()-1, innerBlk2)
+	if err := beforeParent.Verify(); err != errTimestampTooEarly {
+		t.Fatalf("Should have failed verification due to the timestamp, but got %v", err)
+	}
+
+	future := newTestBlock(t, vm, blk1.ID(), 2, start.Add(time.Hour).Unix(), innerBlk2)
+	if err := future.Verify(); err != errTimestampTooLate {
+		t.Fatalf("Should have failed verification due to the timestamp, but got %v", err)
+	}
+
+	wrongHeight := newTestBlock(t, vm, blk1.ID(), 3, start.Add(time.Minute).Unix(), innerBlk2)
+	if err := wrongHeight.Verify(); err != errWrongHeight {
+		t.Fatalf("Should have failed verification due to the height, but got %v", err)
+	}
+
+	if innerBlk2.numVerified != 0 {
+		t.Fatalf("Inner block shouldn't have been verified")
+	}
+}
+
+func TestVMVerifyWhileBootstrapping(t *testing.T) {
+	genesis, innerBlk1, innerBlk2 := testBlocks()
+	vm, _ := setup(t, memdb.New(), genesis, innerBlk1, innerBlk2)
+	defer vm.Shutdown()
+	vm.bootstrapped = false
+
+	start := time.Unix(1000000, 0)
+	vm.clock.Set(start)
+
+	// Blocks accepted by the network before the activation aren't wrapped
+	unwrapped, err := vm.ParseBlock(innerBlk1.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unwrapped.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if innerBlk1.numVerified != 1 {
+		t.Fatalf("Inner block should have been verified")
+	}
+
+	// The proposer's window may not have started according to the current
+	// validators
+	blk1 := newTestBlock(t, vm, genesis.ID(), 1, start.Unix(), innerBlk1)
+	if err := blk1.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	early := newTestBlock(t, vm, blk1.ID(), 2, start.Unix()+1, innerBlk2)
+	if err := early.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if innerBlk2.numVerified != 1 {
+		t.Fatalf("Inner block should have been verified")
+	}
+
+	vm.Bootstrapped()
+	late := newTestBlock(t, vm, blk1.ID(), 2, start.Unix()+2, innerBlk2)
+	if err := late.Verify(); err != errProposerWindowNotStarted {
+		t.Fatalf("Should have failed verification due to the proposer window, but got %v", err)
+	}
+	if err := unwrapped.Verify(); err != errUnwrappedAfterActivation {
+		t.Fatalf("Unwrapped block should have failed verification after the activation, but got %v", err)
+	}
+}
+
+func TestConfigValid(t *testing.T) {
+	if err := DefaultConfig().Valid(); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfig()
+	config.NumWindows = 0
+	if err := config.Valid(); err != errInvalidNumWindows {
+		t.Fatalf("Should have rejected the number of windows, but got %v", err)
+	}
+
+	config = DefaultConfig()
+	config.WindowDuration = 0
+	if err := config.Valid(); err != errInvalidWindowDuration {
+		t.Fatalf("Should have rejected the window duration, but got %v", err)
+	}
+
+	config = DefaultConfig()
+	config.MaxClockSkew = -time.Second
+	if err := config.Valid(); err != errInvalidClockSkew {
+		t.Fatalf("Should have rejected the clock skew, but got %v", err)
+	}
+}
+
+func TestVMSharedInnerBlock(t *testing.T) {
+	genesis, innerBlk1, _ := testBlocks()
+	vm, _ := setup(t, memdb.New(), genesis, innerBlk1)
+	defer vm.Shutdown()
+
+	start := time.Unix(1000000, 0)
+	vm.clock.Set(start)
+
+	blkA := newTestBlock(t, vm, genesis.ID(), 1, start.Unix(), innerBlk1)
+	blkB := newTestBlock(t, vm, genesis.ID(), 1, start.Unix()+1, innerBlk1)
+	if err := blkA.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if err := blkB.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if innerBlk1.numVerified != 1 {
+		t.Fatalf("Inner block should have been verified once, but was verified %d times", innerBlk1.numVerified)
+	}
+
+	blkA.Reject()
+	if innerBlk1.Status() != choices.Processing {
+		t.Fatalf("Inner block is still wrapped by a processing block, so it shouldn't be decided")
+	}
+	blkB.Accept()
+	if innerBlk1.Status() != choices.Accepted {
+		t.Fatalf("Inner block should have been accepted")
+	}
+}
+
+func TestVMUnwrappedAfterWrapped(t *testing.T) {
+	genesis, innerBlk1, innerBlk2 := testBlocks()
+	vm, _ := setup(t, memdb.New(), genesis, innerBlk1, innerBlk2)
+	defer vm.Shutdown()
+
+	start := time.Unix(1000000, 0)
+	vm.clock.Set(start)
+	vm.config.ActivationTime = start.Add(time.Hour)
+
+	blk1 := newTestBlock(t, vm, genesis.ID(), 1, start.Unix(), innerBlk1)
+	if err := blk1.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	blk1.Accept()
+
+	if _, err := vm.GetBlock(innerBlk1.ID()); err == nil {
+		t.Fatalf("Accepted inner block shouldn't be returned as an unwrapped block")
+	}
+
+	unwrapped, err := vm.ParseBlock(innerBlk2.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unwrapped.Verify(); err != errUnwrappedBlock {
+		t.Fatalf("Unwrapped block should have failed verification, but got %v", err)
+	}
+}
+
+func TestVMActivation(t *testing.T) {
+	genesis, innerBlk1, innerBlk2 := testBlocks()
+	vm, innerVM := setup(t, memdb.New(), genesis, innerBlk1, innerBlk2)
+	defer vm.Shutdown()
+
+	start := time.Unix(1000000, 0)
+	vm.clock.Set(start)
+	vm.config.ActivationTime = start.Add(time.Hour)
+
+	// Until the activation, blocks are built without a proposer
+	innerVM.BuildBlockF = func() (snowman.Block, error) { return innerBlk1, nil }
+	blk1, err := vm.BuildBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !blk1.ID().Equals(innerBlk1.ID()) {
+		t.Fatalf("Block built before the activation shouldn't have been wrapped")
+	}
+	if err := blk1.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	vm.clock.Set(vm.config.ActivationTime)
+	unwrapped, err := vm.ParseBlock(innerBlk2.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unwrapped.Verify(); err != errUnwrappedAfterActivation {
+		t.Fatalf("Unwrapped block should have failed verification after the activation, but got %v", err)
+	}
+}
+
+func TestVMSignedBlocks(t *testing.T) {
+	genesis, innerBlk1, _ := testBlocks()
+	vm, _ := setup(t, memdb.New(), genesis, innerBlk1)
+	defer vm.Shutdown()
+
+	start := time.Unix(1000000, 0)
+	vm.clock.Set(start)
+	setStakingKey(t, vm)
+
+	signed := newTestBlock(t, vm, genesis.ID(), 1, start.Unix(), innerBlk1)
+	parsed, err := vm.ParseBlock(signed.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parsed.Verify(); err != nil {
+		t.Fatalf("Signed block failed verification due to %s", err)
+	}
+
+	// This node can't propose blocks in the validator's name
+	myID := vm.ctx.NodeID
+	vm.ctx.NodeID = vm.windower.proposers(1)[0]
+	impersonated := newTestBlock(t, vm, genesis.ID(), 1, start.Unix(), innerBlk1)
+	vm.ctx.NodeID = myID
+	if err := impersonated.Verify(); err != errWrongProposerCert {
+		t.Fatalf("Block signed by another node should have failed verification, but got %v", err)
+	}
+
+	forgedBytes := append([]byte(nil), signed.Bytes()...)
+	forgedBytes[len(forgedBytes)-1]++
+	forged, err := vm.ParseBlock(forgedBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := forged.Verify(); err == nil {
+		t.Fatalf("Block with a forged signature should have failed verification")
+	}
+}
+
+func TestVMParseAndRestart(t *testing.T) {
+	genesis, innerBlk1, _ := testBlocks()
+	db := memdb.New()
+	vm, _ := setup(t, db, genesis, innerBlk1)
+
+	start := time.Unix(1000000, 0)
+	vm.clock.Set(start)
+
+	blk1 := newTestBlock(t, vm, genesis.ID(), 1, start.Unix(), innerBlk1)
+
+	parsed, err := vm.ParseBlock(blk1.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.ID().Equals(blk1.ID()) {
+		t.Fatalf("Parsed the wrong block")
+	}
+	if err := parsed.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	parsed.Accept()
+	vm.Shutdown()
+
+	vm, _ = setup(t, db, genesis, innerBlk1)
+	defer vm.Shutdown()
+
+	if lastAccepted := vm.LastAccepted(); !lastAccepted.Equals(blk1.ID()) {
+		t.Fatalf("Last accepted should be %s but is %s", blk1.ID(), lastAccepted)
+	}
+	blk, err := vm.GetBlock(blk1.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blk.Status() != choices.Accepted {
+		t.Fatalf("Block should be accepted but is %s", blk.Status())
+	}
+	if !blk.Parent().ID().Equals(genesis.ID()) {
+		t.Fatalf("Wrong parent")
+	}
+}
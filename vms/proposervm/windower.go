@@ -0,0 +1,83 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package proposervm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/validators"
+	"github.com/ava-labs/gecko/utils/hashing"
+	"github.com/ava-labs/gecko/utils/wrappers"
+)
+
+// windower decides when each validator may propose a block at a height.
+//
+// At each height, the validators are put in a stake-weighted random order that
+// every node derives from the same seed. The i-th proposer in the order may
+// propose a block once i windows have passed since the parent's timestamp.
+// Once [NumWindows] windows have passed, any node may propose a block.
+type windower struct {
+	validators validators.Set
+	chainID    ids.ID
+	config     Config
+}
+
+// proposers returns the first [NumWindows] proposers at [height], in the order
+// their windows start
+func (w *windower) proposers(height uint64) []ids.ShortID {
+	vdrs := w.validators.List()
+	// The validators must be in the same order on every node
+	sort.Slice(vdrs, func(i, j int) bool {
+		return bytes.Compare(vdrs[i].ID().Bytes(), vdrs[j].ID().Bytes()) == -1
+	})
+
+	weights := make([]uint64, len(vdrs))
+	totalWeight := uint64(0)
+	for i, vdr := range vdrs {
+		weights[i] = vdr.Weight()
+		totalWeight += weights[i]
+	}
+
+	p := wrappers.Packer{Bytes: make([]byte, wrappers.LongLen)}
+	p.PackLong(height)
+	seed := hashing.ByteArraysToHash256Array(w.chainID.Bytes(), p.Bytes)
+	source := rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(seed[:]))))
+
+	proposers := []ids.ShortID(nil)
+	for len(proposers) < w.config.NumWindows && totalWeight > 0 {
+		// Sample without replacement, with probability proportional to stake
+		sample := source.Uint64() % totalWeight
+		for i, weight := range weights {
+			if sample < weight {
+				proposers = append(proposers, vdrs[i].ID())
+				totalWeight -= weight
+				weights[i] = 0
+				break
+			}
+			sample -= weight
+		}
+	}
+	return proposers
+}
+
+// delay returns how long after the parent's timestamp [nodeID] may propose a
+// block at [height]
+func (w *windower) delay(height uint64, nodeID ids.ShortID) time.Duration {
+	proposers := w.proposers(height)
+	if len(proposers) == 0 {
+		// Without validators there is no one to take turns with
+		return 0
+	}
+	for i, proposer := range proposers {
+		if proposer.Equals(nodeID) {
+			return time.Duration(i) * w.config.WindowDuration
+		}
+	}
+	return time.Duration(len(proposers)) * w.config.WindowDuration
+}
@@ -0,0 +1,110 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package proposervm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ava-labs/gecko/ids"
+	"github.com/ava-labs/gecko/snow/validators"
+)
+
+func TestWindowerNoValidators(t *testing.T) {
+	w := windower{
+		validators: validators.NewSet(),
+		chainID:    ids.Empty.Prefix(0),
+		config:     DefaultConfig(),
+	}
+
+	if proposers := w.proposers(1); len(proposers) != 0 {
+		t.Fatalf("Expected no proposers but got %d", len(proposers))
+	}
+	if delay := w.delay(1, ids.ShortEmpty); delay != 0 {
+		t.Fatalf("Expected no delay but got %s", delay)
+	}
+}
+
+func TestWindowerDeterministic(t *testing.T) {
+	vdrs0 := validators.NewSet()
+	vdrs1 := validators.NewSet()
+	vdrList := []validators.Validator(nil)
+	for i := 0; i < 10; i++ {
+		vdrList = append(vdrList, validators.GenerateRandomValidator(uint64(i+1)))
+	}
+	for i := range vdrList {
+		vdrs0.Add(vdrList[i])
+		// Add the validators in a different order
+		vdrs1.Add(vdrList[len(vdrList)-1-i])
+	}
+
+	config := DefaultConfig()
+	chainID := ids.Empty.Prefix(0)
+	w0 := windower{validators: vdrs0, chainID: chainID, config: config}
+	w1 := windower{validators: vdrs1, chainID: chainID, config: config}
+
+	for height := uint64(1); height < 20; height++ {
+		proposers0 := w0.proposers(height)
+		proposers1 := w1.proposers(height)
+		if len(proposers0) != config.NumWindows {
+			t.Fatalf("Expected %d proposers but got %d", config.NumWindows, len(proposers0))
+		}
+		if len(proposers1) != len(proposers0) {
+			t.Fatalf("Expected %d proposers but got %d", len(proposers0), len(proposers1))
+		}
+
+		seen := ids.ShortSet{}
+		for i, proposer := range proposers0 {
+			if !proposer.Equals(proposers1[i]) {
+				t.Fatalf("Proposer %d at height %d differs between nodes", i, height)
+			}
+			if seen.Contains(proposer) {
+				t.Fatalf("Proposer %s was sampled twice at height %d", proposer, height)
+			}
+			seen.Add(proposer)
+
+			if delay := w0.delay(height, proposer); delay != time.Duration(i)*config.WindowDuration {
+				t.Fatalf("Wrong delay for proposer %d at height %d: %s", i, height, delay)
+			}
+		}
+	}
+
+	otherChain := windower{validators: vdrs0, chainID: ids.Empty.Prefix(1), config: config}
+	differs := false
+	for height := uint64(1); height < 20 && !differs; height++ {
+		differs = !otherChain.proposers(height)[0].Equals(w0.proposers(height)[0])
+	}
+	if !differs {
+		t.Fatalf("Proposers should depend on the chain")
+	}
+}
+
+func TestWindowerStakeWeighted(t *testing.T) {
+	heavy := validators.GenerateRandomValidator(1000000)
+	light := validators.GenerateRandomValidator(1)
+	vdrs := validators.NewSet()
+	vdrs.Add(heavy)
+	vdrs.Add(light)
+
+	config := DefaultConfig()
+	w := windower{validators: vdrs, chainID: ids.Empty.Prefix(0), config: config}
+
+	heavyFirst := 0
+	for height := uint64(1); height <= 100; height++ {
+		proposers := w.proposers(height)
+		if len(proposers) != 2 {
+			t.Fatalf("Expected 2 proposers but got %d", len(proposers))
+		}
+		if proposers[0].Equals(heavy.ID()) {
+			heavyFirst++
+		}
+	}
+	if heavyFirst < 95 {
+		t.Fatalf("The heavy validator should almost always be the first proposer, but was only first %d times", heavyFirst)
+	}
+
+	if delay := w.delay(1, ids.NewShortID([20]byte{0xff})); delay != 2*config.WindowDuration {
+		t.Fatalf("Non-validators should wait for every window, but waited %s", delay)
+	}
+}
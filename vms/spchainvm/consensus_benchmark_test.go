@@ -58,7 +58,11 @@ func ConsensusLeader(numBlocks, numTxsPerBlock int, b *testing.B) {
 		beacons := validators.NewSet()
 
 		timeoutManager := timeout.Manager{}
-		timeoutManager.Initialize(2 * time.Second)
+		timeoutManager.Initialize(logging.NoLog{}, timeout.Config{
+			InitialTimeout: 2 * time.Second,
+			MinimumTimeout: 2 * time.Second,
+			MaximumTimeout: 2 * time.Second,
+		}, prometheus.NewRegistry())
 		go timeoutManager.Dispatch()
 
 		router := &router.ChainRouter{}
@@ -185,7 +189,11 @@ func ConsensusFollower(numBlocks, numTxsPerBlock int, b *testing.B) {
 		beacons := validators.NewSet()
 
 		timeoutManager := timeout.Manager{}
-		timeoutManager.Initialize(2 * time.Second)
+		timeoutManager.Initialize(logging.NoLog{}, timeout.Config{
+			InitialTimeout: 2 * time.Second,
+			MinimumTimeout: 2 * time.Second,
+			MaximumTimeout: 2 * time.Second,
+		}, prometheus.NewRegistry())
 		go timeoutManager.Dispatch()
 
 		router := &router.ChainRouter{}
@@ -10,8 +10,6 @@ import (
 	"runtime"
 	"runtime/pprof"
 
-	"github.com/ava-labs/salticidae-go"
-
 	"github.com/ava-labs/gecko/genesis"
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/utils/crypto"
@@ -44,20 +42,12 @@ func main() {
 	// Init the network
 	log.AssertNoError(net.Initialize())
 
-	net.net.Start()
-	defer net.net.Stop()
+	defer net.net.Close()
 
 	// connect to the node
-	serr := salticidae.NewError()
-	remoteIP := salticidae.NewNetAddrFromIPPortString(config.RemoteIP.String(), true, &serr)
-	if code := serr.GetCode(); code != 0 {
-		log.Fatal("Sync error %s", salticidae.StrError(serr.GetCode()))
-		return
-	}
-
-	net.conn = net.net.ConnectSync(remoteIP, true, &serr)
-	if serr.GetCode() != 0 {
-		log.Fatal("Sync error %s", salticidae.StrError(serr.GetCode()))
+	net.conn, err = net.net.ConnectSync(config.RemoteIP)
+	if err != nil {
+		log.Fatal("Sync error %s", err)
 		return
 	}
 
@@ -93,5 +83,5 @@ func main() {
 	}
 
 	// start processing network messages
-	net.ec.Dispatch()
+	net.net.Dispatch()
 }
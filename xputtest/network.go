@@ -3,29 +3,24 @@
 
 package main
 
-// #include "salticidae/network.h"
-// void onTerm(int sig, void *);
-// void decidedTx(msg_t *, msgnetwork_conn_t *, void *);
-import "C"
-
 import (
-	"fmt"
-	"unsafe"
-
-	"github.com/ava-labs/salticidae-go"
+	"math"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/ava-labs/gecko/ids"
 	"github.com/ava-labs/gecko/networking"
+	"github.com/ava-labs/gecko/utils"
 	"github.com/ava-labs/gecko/utils/logging"
 )
 
 // network stores the persistent data needed when running the test.
 type network struct {
-	ec    salticidae.EventContext
 	build networking.Builder
 
-	net  salticidae.MsgNetwork
-	conn salticidae.MsgNetworkConn
+	net  networking.MsgNetwork
+	conn networking.Conn
 
 	log     logging.Logger
 	decided chan ids.ID
@@ -36,43 +31,25 @@ type network struct {
 var net = network{}
 
 func (n *network) Initialize() error {
-	n.ec = salticidae.NewEventContext()
-	evInt := salticidae.NewSigEvent(n.ec, salticidae.SigEventCallback(C.onTerm), nil)
-	evInt.Add(salticidae.SIGINT)
-	evTerm := salticidae.NewSigEvent(n.ec, salticidae.SigEventCallback(C.onTerm), nil)
-	evTerm.Add(salticidae.SIGTERM)
+	n.net = networking.NewMsgNetwork(n.log, networking.TCPTransport{}, utils.IPDesc{}, nil, math.MaxInt32)
 
-	serr := salticidae.NewError()
-	netconfig := salticidae.NewMsgNetworkConfig()
-	n.net = salticidae.NewMsgNetwork(n.ec, netconfig, &serr)
-	if serr.GetCode() != 0 {
-		return fmt.Errorf("sync error %s", salticidae.StrError(serr.GetCode()))
-	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		n.log.Info("Terminate signal received")
+		n.net.Close()
+	}()
 
-	n.net.RegHandler(networking.DecidedTx, salticidae.MsgNetworkMsgCallback(C.decidedTx), nil)
+	n.net.RegisterHandler(networking.DecidedTx, n.decidedTx)
 	return nil
 }
 
-//export onTerm
-func onTerm(C.int, unsafe.Pointer) {
-	net.log.Info("Terminate signal received")
-	net.ec.Stop()
-}
-
 // decidedTx handles the recept of a decidedTx message
-//export decidedTx
-func decidedTx(_msg *C.struct_msg_t, _conn *C.struct_msgnetwork_conn_t, _ unsafe.Pointer) {
-	msg := salticidae.MsgFromC(salticidae.CMsg(_msg))
-
-	pMsg, err := net.build.Parse(networking.DecidedTx, msg.GetPayloadByMove())
-	if err != nil {
-		net.log.Warn("Failed to parse DecidedTx message")
-		return
-	}
-
-	txID, err := ids.ToID(pMsg.Get(networking.TxID).([]byte))
-	net.log.AssertNoError(err) // Length is checked in message parsing
+func (n *network) decidedTx(msg networking.Msg, _ networking.Conn) {
+	txID, err := ids.ToID(msg.Get(networking.TxID).([]byte))
+	n.log.AssertNoError(err) // Length is checked in message parsing
 
-	net.log.Debug("Decided %s", txID)
-	net.decided <- txID
+	n.log.Debug("Decided %s", txID)
+	n.decided <- txID
 }
@@ -6,10 +6,7 @@ package main
 import (
 	"time"
 
-	"github.com/ava-labs/salticidae-go"
-
 	"github.com/ava-labs/gecko/ids"
-	"github.com/ava-labs/gecko/networking"
 	"github.com/ava-labs/gecko/utils/crypto"
 	"github.com/ava-labs/gecko/utils/timer"
 	"github.com/ava-labs/gecko/vms/platformvm"
@@ -67,22 +64,14 @@ func (n *network) IssueSPChain(chainID ids.ID, wallet *chainwallet.Wallet) {
 
 			it, err := n.build.IssueTx(chainID, tx.Bytes())
 			n.log.AssertNoError(err)
-			ds := it.DataStream()
-			ba := salticidae.NewByteArrayMovedFromDataStream(ds, false)
-			newMsg := salticidae.NewMsgMovedFromByteArray(networking.IssueTx, ba, false)
-
-			n.conn.GetNet().SendMsg(newMsg, n.conn)
-
-			ds.Free()
-			ba.Free()
-			newMsg.Free()
+			n.conn.Send(it)
 
 			numPending++
 			n.log.Debug("Sent tx, pending = %d, accepted = %d", numPending, numAccepted)
 		}
 		if numAccepted+numPending >= config.NumTxs {
 			n.log.Info("done with test")
-			net.ec.Stop()
+			net.net.Close()
 			return
 		}
 	}
@@ -6,10 +6,7 @@ package main
 import (
 	"time"
 
-	"github.com/ava-labs/salticidae-go"
-
 	"github.com/ava-labs/gecko/ids"
-	"github.com/ava-labs/gecko/networking"
 	"github.com/ava-labs/gecko/utils/crypto"
 	"github.com/ava-labs/gecko/utils/timer"
 	"github.com/ava-labs/gecko/vms/platformvm"
@@ -79,15 +76,7 @@ func (n *network) IssueSPDAG(chainID ids.ID, wallet *dagwallet.Wallet) {
 
 			it, err := n.build.IssueTx(chainID, tx.Bytes())
 			n.log.AssertNoError(err)
-			ds := it.DataStream()
-			ba := salticidae.NewByteArrayMovedFromDataStream(ds, false)
-			newMsg := salticidae.NewMsgMovedFromByteArray(networking.IssueTx, ba, false)
-
-			n.conn.GetNet().SendMsg(newMsg, n.conn)
-
-			ds.Free()
-			ba.Free()
-			newMsg.Free()
+			n.conn.Send(it)
 
 			pending[tx.ID().Key()] = tx
 			n.log.Debug("Sent tx, pending = %d, accepted = %d", len(pending), numAccepted)